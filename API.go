@@ -1,5 +1,7 @@
 package goex
 
+import "context"
+
 // api interface
 
 type API interface {
@@ -24,3 +26,25 @@ type API interface {
 
 	GetAssets(currencyPair CurrencyPair) (*Assets, error)
 }
+
+// APIWithContext is the context-aware counterpart of API. Every method accepts a
+// context.Context that is passed down to the underlying http request, so a call
+// can be cancelled or bounded by a per-call deadline.
+type APIWithContext interface {
+	LimitBuyWithContext(ctx context.Context, amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error)
+	LimitSellWithContext(ctx context.Context, amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error)
+	MarketBuyWithContext(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error)
+	MarketSellWithContext(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error)
+	CancelOrderWithContext(ctx context.Context, orderId string, currency CurrencyPair) (bool, error)
+	GetOneOrderWithContext(ctx context.Context, orderId string, currency CurrencyPair) (*Order, error)
+	GetUnfinishOrdersWithContext(ctx context.Context, currency CurrencyPair) ([]Order, error)
+	GetOrderHistorysWithContext(ctx context.Context, currency CurrencyPair, opt ...OptionalParameter) ([]Order, error)
+	GetAccountWithContext(ctx context.Context) (*Account, error)
+
+	GetTickerWithContext(ctx context.Context, currency CurrencyPair) (*Ticker, error)
+	GetDepthWithContext(ctx context.Context, size int, currency CurrencyPair) (*Depth, error)
+	GetKlineRecordsWithContext(ctx context.Context, currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error)
+	GetTradesWithContext(ctx context.Context, currencyPair CurrencyPair, since int64) ([]Trade, error)
+
+	GetExchangeName() string
+}
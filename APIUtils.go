@@ -1,6 +1,7 @@
 package goex
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -16,6 +17,8 @@ import (
   @method 调用的函数，比如: api.GetTicker ,注意：不是api.GetTicker(...)
   @params 参数,顺序一定要按照实际调用函数入参顺序一样
   @return 返回
+
+  Deprecated: RE retries any error after a fixed delay, use RetryPolicy.Do or NewRetryAPI.
 */
 func RE(retry int, delay time.Duration, method interface{}, params ...interface{}) interface{} {
 
@@ -75,21 +78,20 @@ func CancelAllUnfinishedOrders(api API, currencyPair CurrencyPair) int {
 	}
 
 	c := 0
+	policy := &RetryPolicy{MaxAttempts: 2, BaseDelay: 200 * time.Millisecond}
 
 	for {
-		ret := RE(2, 200*time.Millisecond, api.GetUnfinishOrders, currencyPair)
-
-		if err, isok := ret.(error); isok {
+		var orders []Order
+		err := policy.Do(context.Background(), "GetUnfinishOrders", true, func() (err error) {
+			orders, err = api.GetUnfinishOrders(currencyPair)
+			return err
+		})
+		if err != nil {
 			logger.Log.Error("[api error]", err)
 			break
 		}
 
-		if ret == nil {
-			break
-		}
-
-		orders, isok := ret.([]Order)
-		if !isok || len(orders) == 0 {
+		if len(orders) == 0 {
 			break
 		}
 
@@ -118,20 +120,20 @@ func CancelAllUnfinishedFutureOrders(api FutureRestAPI, contractType string, cur
 	}
 
 	c := 0
+	policy := &RetryPolicy{MaxAttempts: 10, BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second}
 
 	for {
-		ret := RE(10, 200*time.Millisecond, api.GetUnfinishFutureOrders, currencyPair, contractType)
-		if err, isOk := ret.(error); isOk {
+		var orders []FutureOrder
+		err := policy.Do(context.Background(), "GetUnfinishFutureOrders", true, func() (err error) {
+			orders, err = api.GetUnfinishFutureOrders(currencyPair, contractType)
+			return err
+		})
+		if err != nil {
 			logger.Log.Error("[api error]", err)
 			break
 		}
 
-		if ret == nil {
-			break
-		}
-
-		orders, isOk := ret.([]FutureOrder)
-		if !isOk || len(orders) == 0 {
+		if len(orders) == 0 {
 			break
 		}
 
@@ -1,20 +1,51 @@
 package goex
 
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ApiError is the error kind shared by all exchanges, ErrCode identifies the kind.
+// errors.Is(err, EX_ERR_NOT_FIND_ORDER) matches any ApiError of that kind, whatever
+// the message, status and native code, use errors.As to read them.
 type ApiError struct {
 	ErrCode,
 	ErrMsg,
 	OriginErrMsg string
+	HttpStatus int    //0 if the error did not come with a http response
+	NativeCode string //the error code of the exchange
 }
 
 func (e ApiError) Error() string {
 	return e.ErrMsg
 }
 
+func (e ApiError) Is(target error) bool {
+	switch t := target.(type) {
+	case ApiError:
+		return t.ErrCode == e.ErrCode
+	case *ApiError:
+		return t != nil && t.ErrCode == e.ErrCode
+	}
+	return false
+}
+
 func (e ApiError) OriginErr(err string) ApiError {
 	e.ErrMsg = err
 	return e
 }
 
+func (e ApiError) WithHttpStatus(status int) ApiError {
+	e.HttpStatus = status
+	return e
+}
+
+func (e ApiError) WithNativeCode(code string) ApiError {
+	e.NativeCode = code
+	return e
+}
+
 var (
 	API_ERR                      = ApiError{ErrCode: "EX_ERR_0000", ErrMsg: "unknown error"}
 	HTTP_ERR_CODE                = ApiError{ErrCode: "HTTP_ERR_0001", ErrMsg: "http request error"}
@@ -28,4 +59,105 @@ var (
 	EX_ERR_INVALID_CURRENCY_PAIR = ApiError{ErrCode: "EX_ERR_0007", ErrMsg: "invalid currency pair"}
 	EX_ERR_NOT_FIND_ORDER        = ApiError{ErrCode: "EX_ERR_0008", ErrMsg: "not find order"}
 	EX_ERR_SYMBOL_ERR            = ApiError{ErrCode: "EX_ERR_0009", ErrMsg: "symbol error"}
+	EX_ERR_AUTH                  = ApiError{ErrCode: "EX_ERR_0010", ErrMsg: "authentication failure"}
+	EX_ERR_MAINTENANCE           = ApiError{ErrCode: "EX_ERR_0011", ErrMsg: "exchange under maintenance"}
+	EX_ERR_DUPLICATE_CLIENT_OID  = ApiError{ErrCode: "EX_ERR_0012", ErrMsg: "duplicate client order id"}
+	EX_ERR_INVALID_PARAMETER     = ApiError{ErrCode: "EX_ERR_0013", ErrMsg: "invalid parameter"}
+	EX_ERR_TIMESTAMP             = ApiError{ErrCode: "EX_ERR_0014", ErrMsg: "timestamp out of recv window"}
+	EX_ERR_SERVER                = ApiError{ErrCode: "EX_ERR_0015", ErrMsg: "exchange server error"}
+	EX_ERR_ORDER_STATE           = ApiError{ErrCode: "EX_ERR_0016", ErrMsg: "order state does not allow the operation"}
+	EX_ERR_MIN_AMOUNT            = ApiError{ErrCode: "EX_ERR_0017", ErrMsg: "amount under the minimum"}
+	EX_ERR_MIN_NOTIONAL          = ApiError{ErrCode: "EX_ERR_0018", ErrMsg: "order value under the minimum"}
 )
+
+// HttpStatusError is the error of a non 200 response, the kind follows the status.
+// The message keeps the "HttpStatusCode:%d ,Desc:%s" format, the body is in OriginErrMsg.
+func HttpStatusError(status int, body string) ApiError {
+	e := httpStatusKind(status)
+	if e == API_ERR {
+		e = HTTP_ERR_CODE
+	}
+	e.ErrMsg = fmt.Sprintf("HttpStatusCode:%d ,Desc:%s", status, body)
+	e.OriginErrMsg = body
+	e.HttpStatus = status
+	return e
+}
+
+func httpStatusKind(status int) ApiError {
+	switch {
+	case status == http.StatusTooManyRequests, status == http.StatusTeapot: //binance bans with 418
+		return EX_ERR_API_LIMIT
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return EX_ERR_AUTH
+	case status == http.StatusServiceUnavailable:
+		return EX_ERR_MAINTENANCE
+	case status >= 500:
+		return EX_ERR_SERVER
+	}
+	return API_ERR
+}
+
+// ErrorCodes maps the native error codes of an exchange to the shared kinds.
+type ErrorCodes map[string]ApiError
+
+// Adapt returns the kind of nativeCode with msg, status and code filled in. An unknown
+// code falls back to the kind of the http status, then to API_ERR.
+func (codes ErrorCodes) Adapt(httpStatus int, nativeCode, msg string) ApiError {
+	e, ok := codes[nativeCode]
+	if !ok {
+		e = httpStatusKind(httpStatus)
+	}
+	if msg != "" {
+		e.ErrMsg = msg
+	}
+	e.OriginErrMsg = msg
+	e.HttpStatus = httpStatus
+	e.NativeCode = nativeCode
+	return e
+}
+
+// CommonErrorMessages tells the kind by the wording most exchanges use, it is for the
+// exchanges which document no error codes.
+var CommonErrorMessages = ErrorMessages{
+	"insufficient":         EX_ERR_INSUFFICIENT_BALANCE,
+	"balance not enough":   EX_ERR_INSUFFICIENT_BALANCE,
+	"not enough balance":   EX_ERR_INSUFFICIENT_BALANCE,
+	"order not exist":      EX_ERR_NOT_FIND_ORDER,
+	"order not found":      EX_ERR_NOT_FIND_ORDER,
+	"order does not exist": EX_ERR_NOT_FIND_ORDER,
+	"signature":            EX_ERR_SIGN,
+	"sign error":           EX_ERR_SIGN,
+	"api key":              EX_ERR_AUTH,
+	"apikey":               EX_ERR_AUTH,
+	"too many requests":    EX_ERR_API_LIMIT,
+	"too frequent":         EX_ERR_API_LIMIT,
+	"rate limit":           EX_ERR_API_LIMIT,
+	"invalid symbol":       EX_ERR_INVALID_CURRENCY_PAIR,
+	"timestamp":            EX_ERR_TIMESTAMP,
+	"maintenance":          EX_ERR_MAINTENANCE,
+}
+
+// ErrorMessages maps parts of the error messages of an exchange without error codes to
+// the shared kinds, matched case-insensitively.
+type ErrorMessages map[string]ApiError
+
+// Adapt returns the kind of the longest part contained in msg, falling back like
+// ErrorCodes.Adapt.
+func (msgs ErrorMessages) Adapt(httpStatus int, msg string) ApiError {
+	var (
+		e     = httpStatusKind(httpStatus)
+		match string
+		lower = strings.ToLower(msg)
+	)
+	for part, kind := range msgs {
+		if len(part) > len(match) && strings.Contains(lower, strings.ToLower(part)) {
+			e, match = kind, part
+		}
+	}
+	if msg != "" {
+		e.ErrMsg = msg
+	}
+	e.OriginErrMsg = msg
+	e.HttpStatus = httpStatus
+	return e
+}
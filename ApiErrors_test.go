@@ -0,0 +1,70 @@
+package goex
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApiError_Is(t *testing.T) {
+	err := fmt.Errorf("cancel order: %w", EX_ERR_NOT_FIND_ORDER.OriginErr("order 1 not found").WithNativeCode("-2011"))
+	assert.True(t, errors.Is(err, EX_ERR_NOT_FIND_ORDER))
+	assert.True(t, errors.Is(err, &EX_ERR_NOT_FIND_ORDER))
+	assert.False(t, errors.Is(err, EX_ERR_CANCEL_ORDER_FAIL))
+
+	var apiErr ApiError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "-2011", apiErr.NativeCode)
+	assert.Equal(t, "order 1 not found", apiErr.Error())
+}
+
+func TestErrorCodes_Adapt(t *testing.T) {
+	codes := ErrorCodes{"1047": EX_ERR_INSUFFICIENT_BALANCE}
+
+	err := codes.Adapt(http.StatusOK, "1047", "Insufficient margin available.")
+	assert.True(t, errors.Is(err, EX_ERR_INSUFFICIENT_BALANCE))
+	assert.Equal(t, "Insufficient margin available.", err.Error())
+	assert.Equal(t, http.StatusOK, err.HttpStatus)
+	assert.Equal(t, "1047", err.NativeCode)
+
+	assert.True(t, errors.Is(codes.Adapt(http.StatusTooManyRequests, "9999", "slow down"), EX_ERR_API_LIMIT))
+	assert.True(t, errors.Is(codes.Adapt(http.StatusBadRequest, "9999", "what"), API_ERR))
+}
+
+func TestErrorMessages_Adapt(t *testing.T) {
+	msgs := ErrorMessages{
+		"not enough":         EX_ERR_INSUFFICIENT_BALANCE,
+		"not enough to fill": EX_ERR_PLACE_ORDER_FAIL,
+	}
+
+	err := msgs.Adapt(http.StatusOK, "Not enough BTC.")
+	assert.True(t, errors.Is(err, EX_ERR_INSUFFICIENT_BALANCE))
+	assert.Equal(t, "Not enough BTC.", err.Error())
+	assert.True(t, errors.Is(msgs.Adapt(http.StatusOK, "Not enough to fill the order"), EX_ERR_PLACE_ORDER_FAIL), "the longest part wins")
+	assert.True(t, errors.Is(msgs.Adapt(http.StatusTooManyRequests, "slow down"), EX_ERR_API_LIMIT))
+}
+
+func TestHttpStatusError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"msg":"system maintenance"}`))
+	}))
+	defer ts.Close()
+
+	_, err := HttpGet(http.DefaultClient, ts.URL)
+	assert.True(t, errors.Is(err, EX_ERR_MAINTENANCE))
+	assert.Equal(t, `HttpStatusCode:503 ,Desc:{"msg":"system maintenance"}`, err.Error())
+
+	var apiErr ApiError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.HttpStatus)
+	assert.Equal(t, `{"msg":"system maintenance"}`, apiErr.OriginErrMsg)
+
+	assert.True(t, errors.Is(HttpStatusError(http.StatusNotFound, ""), HTTP_ERR_CODE))
+	assert.True(t, errors.Is(HttpStatusError(http.StatusUnauthorized, ""), EX_ERR_AUTH))
+}
@@ -158,6 +158,7 @@ const (
 	COINBENE        = "coinbene.com"
 	ATOP            = "a.top"
 	BITGET_SWAP     = "bitget_swap"
+	SIM             = "sim"
 )
 
 const (
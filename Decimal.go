@@ -0,0 +1,329 @@
+package goex
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, value * 10^-scale. The zero value is 0.
+// Use it for prices and amounts instead of float64 arithmetic, e.g. 0.1+0.2 is 0.3.
+// Values are immutable, the methods return new ones.
+type Decimal struct {
+	value *big.Int //nil is 0
+	scale int32    //digits after the point, >= 0
+}
+
+var (
+	bigOne = big.NewInt(1)
+	bigTen = big.NewInt(10)
+)
+
+func NewDecimal(value int64, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{value: new(big.Int).Mul(big.NewInt(value), pow10(-scale))}
+	}
+	return Decimal{value: big.NewInt(value), scale: scale}
+}
+
+// ParseDecimal parses "-12.340", ".5", "+3" and exponents like "1.5e-8". The scale
+// is kept, "0.10" prints as "0.10".
+func ParseDecimal(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+	var exp int64
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(str[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		exp, str = e, str[:i]
+	}
+
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+	}
+	digits := intPart + fracPart
+	if strings.TrimLeft(digits, "+-") == "" || strings.ContainsAny(strings.TrimLeft(digits, "+-"), "+-") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	value, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	scale := int64(len(fracPart)) - exp
+	if scale < 0 {
+		return Decimal{value: value.Mul(value, pow10(int32(-scale)))}, nil
+	}
+	if scale > math.MaxInt32 {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal{value: value, scale: int32(scale)}, nil
+}
+
+// MustParseDecimal is ParseDecimal panicking on error, for constants.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// DecimalFromFloat returns the shortest decimal that converts back to f, so a value
+// parsed from a json number or string with up to 15 significant digits is recovered
+// exactly: 0.00001234 stays 0.00001234. NaN and infinities are 0.
+func DecimalFromFloat(f float64) Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}
+	}
+	d, _ := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	return d
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) bigValue() *big.Int {
+	if d.value == nil {
+		return new(big.Int)
+	}
+	return d.value
+}
+
+// rescale returns the value of d at a scale >= d.scale.
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale == d.scale {
+		return d.bigValue()
+	}
+	return new(big.Int).Mul(d.bigValue(), pow10(scale-d.scale))
+}
+
+func maxScale(d, d2 Decimal) int32 {
+	if d.scale > d2.scale {
+		return d.scale
+	}
+	return d2.scale
+}
+
+func (d Decimal) Add(d2 Decimal) Decimal {
+	scale := maxScale(d, d2)
+	return Decimal{value: new(big.Int).Add(d.rescale(scale), d2.rescale(scale)), scale: scale}
+}
+
+func (d Decimal) Sub(d2 Decimal) Decimal {
+	scale := maxScale(d, d2)
+	return Decimal{value: new(big.Int).Sub(d.rescale(scale), d2.rescale(scale)), scale: scale}
+}
+
+func (d Decimal) Mul(d2 Decimal) Decimal {
+	return Decimal{value: new(big.Int).Mul(d.bigValue(), d2.bigValue()), scale: d.scale + d2.scale}
+}
+
+// Div returns d/d2 rounded half away from zero to scale digits. It panics if d2 is 0.
+func (d Decimal) Div(d2 Decimal, scale int32) Decimal {
+	return d.quo(d2, scale, true)
+}
+
+func (d Decimal) quo(d2 Decimal, scale int32, round bool) Decimal {
+	if d2.IsZero() {
+		panic("goex: decimal division by zero")
+	}
+	if scale < 0 {
+		scale = 0
+	}
+	//d/d2 * 10^scale = d.value * 10^(scale+d2.scale) / (d2.value * 10^d.scale)
+	num := new(big.Int).Mul(d.bigValue(), pow10(scale+d2.scale))
+	den := new(big.Int).Mul(d2.bigValue(), pow10(d.scale))
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if round {
+		q = roundQuo(q, r, den)
+	}
+	return Decimal{value: q, scale: scale}
+}
+
+// roundQuo rounds the truncated quotient q half away from zero.
+func roundQuo(q, r, den *big.Int) *big.Int {
+	if r.Sign() == 0 {
+		return q
+	}
+	r2 := new(big.Int).Abs(r)
+	r2.Lsh(r2, 1)
+	if r2.Cmp(new(big.Int).Abs(den)) < 0 {
+		return q
+	}
+	if r.Sign()*den.Sign() < 0 {
+		return q.Sub(q, bigOne)
+	}
+	return q.Add(q, bigOne)
+}
+
+// Round rounds half away from zero to places digits after the point. A decimal with
+// fewer digits is returned as is, 1.5 rounded to 2 places stays 1.5.
+func (d Decimal) Round(places int32) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	if places >= d.scale {
+		return d
+	}
+	den := pow10(d.scale - places)
+	q, r := new(big.Int).QuoRem(d.bigValue(), den, new(big.Int))
+	return Decimal{value: roundQuo(q, r, den), scale: places}
+}
+
+// Truncate drops the digits after places, rounding toward zero.
+func (d Decimal) Truncate(places int32) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	if places >= d.scale {
+		return d
+	}
+	return Decimal{value: new(big.Int).Quo(d.bigValue(), pow10(d.scale-places)), scale: places}
+}
+
+// RoundStep rounds to the nearest multiple of step, e.g. the tick size of a price.
+// A zero step returns d.
+func (d Decimal) RoundStep(step Decimal) Decimal {
+	if step.IsZero() {
+		return d
+	}
+	return d.quo(step, 0, true).Mul(step)
+}
+
+// TruncateStep rounds toward zero to a multiple of step, e.g. the lot size of an amount.
+// A zero step returns d.
+func (d Decimal) TruncateStep(step Decimal) Decimal {
+	if step.IsZero() {
+		return d
+	}
+	return d.quo(step, 0, false).Mul(step)
+}
+
+// Normalize drops the trailing zeros after the point, 1.500 is 1.5.
+func (d Decimal) Normalize() Decimal {
+	if d.scale == 0 || d.value == nil {
+		return Decimal{value: d.value}
+	}
+	value, scale := new(big.Int).Set(d.value), d.scale
+	r := new(big.Int)
+	for scale > 0 {
+		q, _ := new(big.Int).QuoRem(value, bigTen, r)
+		if r.Sign() != 0 {
+			break
+		}
+		value, scale = q, scale-1
+	}
+	return Decimal{value: value, scale: scale}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(d.bigValue()), scale: d.scale}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{value: new(big.Int).Abs(d.bigValue()), scale: d.scale}
+}
+
+// Cmp returns -1, 0 or 1 as d is less than, equal to or greater than d2.
+func (d Decimal) Cmp(d2 Decimal) int {
+	scale := maxScale(d, d2)
+	return d.rescale(scale).Cmp(d2.rescale(scale))
+}
+
+// Equal compares the values, 0.10 equals 0.1.
+func (d Decimal) Equal(d2 Decimal) bool {
+	return d.Cmp(d2) == 0
+}
+
+func (d Decimal) Sign() int {
+	return d.bigValue().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Scale is the number of digits after the point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String formats d without exponent, with Scale digits after the point.
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.bigValue()).String()
+	if d.scale > 0 {
+		if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		digits = digits[:len(digits)-int(d.scale)] + "." + digits[len(digits)-int(d.scale):]
+	}
+	if d.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// StringFixed formats d rounded to places digits, padding with zeros.
+func (d Decimal) StringFixed(places int32) string {
+	r := d.Round(places)
+	if places > r.scale {
+		r = Decimal{value: r.rescale(places), scale: places}
+	}
+	return r.String()
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts json numbers and strings, null and "" are 0.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	str := string(bytes.Trim(data, `"`))
+	if str == "" || str == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	dec, err := ParseDecimal(str)
+	if err != nil {
+		return err
+	}
+	*d = dec
+	return nil
+}
+
+// decimal accessors of the models, exact as long as the exchange sent at most 15
+// significant digits, see DecimalFromFloat
+
+func (ord *Order) PriceDecimal() Decimal      { return DecimalFromFloat(ord.Price) }
+func (ord *Order) AmountDecimal() Decimal     { return DecimalFromFloat(ord.Amount) }
+func (ord *Order) AvgPriceDecimal() Decimal   { return DecimalFromFloat(ord.AvgPrice) }
+func (ord *Order) DealAmountDecimal() Decimal { return DecimalFromFloat(ord.DealAmount) }
+func (ord *Order) FeeDecimal() Decimal        { return DecimalFromFloat(ord.Fee) }
+
+func (ord *FutureOrder) PriceDecimal() Decimal      { return DecimalFromFloat(ord.Price) }
+func (ord *FutureOrder) AmountDecimal() Decimal     { return DecimalFromFloat(ord.Amount) }
+func (ord *FutureOrder) AvgPriceDecimal() Decimal   { return DecimalFromFloat(ord.AvgPrice) }
+func (ord *FutureOrder) DealAmountDecimal() Decimal { return DecimalFromFloat(ord.DealAmount) }
+func (ord *FutureOrder) FeeDecimal() Decimal        { return DecimalFromFloat(ord.Fee) }
+
+func (t *Trade) PriceDecimal() Decimal  { return DecimalFromFloat(t.Price) }
+func (t *Trade) AmountDecimal() Decimal { return DecimalFromFloat(t.Amount) }
+
+func (t *Ticker) LastDecimal() Decimal { return DecimalFromFloat(t.Last) }
+func (t *Ticker) BuyDecimal() Decimal  { return DecimalFromFloat(t.Buy) }
+func (t *Ticker) SellDecimal() Decimal { return DecimalFromFloat(t.Sell) }
+
+func (r DepthRecord) PriceDecimal() Decimal  { return DecimalFromFloat(r.Price) }
+func (r DepthRecord) AmountDecimal() Decimal { return DecimalFromFloat(r.Amount) }
@@ -0,0 +1,104 @@
+package goex
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	for s, want := range map[string]string{
+		"0":           "0",
+		"-12.340":     "-12.340",
+		".5":          "0.5",
+		"-.5":         "-0.5",
+		"+3":          "3",
+		"0.00001234":  "0.00001234",
+		"1.5e-8":      "0.000000015",
+		"1.2E+3":      "1200",
+		" 42.0 ":      "42.0",
+		"29316.42000": "29316.42000",
+	} {
+		d, err := ParseDecimal(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, d.String(), s)
+	}
+
+	for _, s := range []string{"", "-", ".", "1.2.3", "abc", "1e", "--1", "1-2"} {
+		_, err := ParseDecimal(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	a, b := MustParseDecimal("0.1"), MustParseDecimal("0.2")
+	assert.Equal(t, "0.3", a.Add(b).String())
+	assert.True(t, a.Add(b).Equal(MustParseDecimal("0.30")))
+	assert.Equal(t, "-0.1", a.Sub(b).String())
+	assert.Equal(t, "0.02", a.Mul(b).String())
+	assert.Equal(t, "0.3333", a.Div(MustParseDecimal("0.3"), 4).String())
+	assert.Equal(t, "-0.6667", MustParseDecimal("-2").Div(MustParseDecimal("3"), 4).String())
+	assert.Equal(t, "3000012.345678000000", MustParseDecimal("30000.12345678").Mul(MustParseDecimal("100.0000")).String())
+	assert.Panics(t, func() { a.Div(Decimal{}, 2) })
+
+	var zero Decimal
+	assert.Equal(t, "0", zero.String())
+	assert.Equal(t, "0.1", zero.Add(a).String())
+	assert.Equal(t, -1, zero.Cmp(a))
+	assert.Equal(t, 1, a.Cmp(a.Neg()))
+	assert.Equal(t, "0.1", a.Neg().Abs().String())
+}
+
+func TestDecimal_Round(t *testing.T) {
+	d := MustParseDecimal("1.005")
+	assert.Equal(t, "1.01", d.Round(2).String())
+	assert.Equal(t, "1.00", d.Truncate(2).String())
+	assert.Equal(t, "1.005", d.Round(5).String())
+	assert.Equal(t, "-1.01", d.Neg().Round(2).String())
+	assert.Equal(t, "-1.00", d.Neg().Truncate(2).String())
+	assert.Equal(t, "1.00500", d.StringFixed(5))
+	assert.Equal(t, "1", d.StringFixed(0))
+	assert.Equal(t, "1.5", MustParseDecimal("1.500").Normalize().String())
+	assert.Equal(t, "100", MustParseDecimal("100.00").Normalize().String())
+
+	tick, lot := MustParseDecimal("0.05"), MustParseDecimal("0.001")
+	assert.Equal(t, "10.05", MustParseDecimal("10.03").RoundStep(tick).String())
+	assert.Equal(t, "10.00", MustParseDecimal("10.02").RoundStep(tick).String())
+	assert.Equal(t, "0.123", MustParseDecimal("0.12399").TruncateStep(lot).String())
+	assert.Equal(t, "0.12399", MustParseDecimal("0.12399").TruncateStep(Decimal{}).String())
+}
+
+func TestDecimalFromFloat(t *testing.T) {
+	assert.Equal(t, "0.00001234", DecimalFromFloat(ToFloat64("0.00001234")).String())
+	assert.Equal(t, "29316.42", DecimalFromFloat(29316.42).String())
+	assert.Equal(t, "0.3", DecimalFromFloat(0.1).Add(DecimalFromFloat(0.2)).String())
+	assert.Equal(t, "0", DecimalFromFloat(0).String())
+
+	dep := DepthRecord{Price: ToFloat64("0.00000321"), Amount: ToFloat64("123456.78")}
+	assert.Equal(t, "0.00000321", dep.PriceDecimal().String())
+	assert.Equal(t, "123456.78", dep.AmountDecimal().String())
+	assert.Equal(t, "0.00001", FloatToString(0.00001, 8))
+	assert.Equal(t, 1.01, FloatToFixed(1.005, 2))
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	var v struct {
+		Price  Decimal `json:"price"`
+		Amount Decimal `json:"amount"`
+		Fee    Decimal `json:"fee"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"price":"0.00001234","amount":0.10,"fee":null}`), &v))
+	assert.Equal(t, "0.00001234", v.Price.String())
+	assert.Equal(t, "0.10", v.Amount.String())
+	assert.True(t, v.Fee.IsZero())
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{"price":"0.00001234","amount":"0.10","fee":"0"}`, string(data))
+
+	assert.Equal(t, "1.5", ToDecimal("1.5").String())
+	assert.Equal(t, "2", ToDecimal(2).String())
+	assert.Equal(t, "0.1", ToDecimal(json.Number("0.1")).String())
+}
@@ -1,5 +1,7 @@
 package goex
 
+import "context"
+
 type FutureRestAPI interface {
 	/**
 	 *获取交易所名字
@@ -123,3 +125,27 @@ type FutureRestAPI interface {
 	 */
 	GetTrades(contractType string, currencyPair CurrencyPair, since int64) ([]Trade, error)
 }
+
+// FutureRestAPIWithContext is the context-aware counterpart of FutureRestAPI.
+type FutureRestAPIWithContext interface {
+	GetExchangeName() string
+
+	GetFutureEstimatedPriceWithContext(ctx context.Context, currencyPair CurrencyPair) (float64, error)
+	GetFutureTickerWithContext(ctx context.Context, currencyPair CurrencyPair, contractType string) (*Ticker, error)
+	GetFutureDepthWithContext(ctx context.Context, currencyPair CurrencyPair, contractType string, size int) (*Depth, error)
+	GetFutureIndexWithContext(ctx context.Context, currencyPair CurrencyPair) (float64, error)
+	GetFutureUserinfoWithContext(ctx context.Context, currencyPair ...CurrencyPair) (*FutureAccount, error)
+
+	LimitFuturesOrderWithContext(ctx context.Context, currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error)
+	MarketFuturesOrderWithContext(ctx context.Context, currencyPair CurrencyPair, contractType, amount string, openType int) (*FutureOrder, error)
+	FutureCancelOrderWithContext(ctx context.Context, currencyPair CurrencyPair, contractType, orderId string) (bool, error)
+
+	GetFuturePositionWithContext(ctx context.Context, currencyPair CurrencyPair, contractType string) ([]FuturePosition, error)
+	GetFutureOrdersWithContext(ctx context.Context, orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error)
+	GetFutureOrderWithContext(ctx context.Context, orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error)
+	GetUnfinishFutureOrdersWithContext(ctx context.Context, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error)
+	GetFutureOrderHistoryWithContext(ctx context.Context, pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error)
+
+	GetKlineRecordsWithContext(ctx context.Context, contractType string, currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]FutureKline, error)
+	GetTradesWithContext(ctx context.Context, contractType string, currencyPair CurrencyPair, since int64) ([]Trade, error)
+}
@@ -2,8 +2,8 @@ package goex
 
 //http request 工具函数
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/soulsplit/goex/internal/logger"
 	"github.com/valyala/fasthttp"
@@ -28,12 +28,37 @@ var (
 	socksDialer fasthttp.DialFunc
 )
 
+// WrappedTransport is a http.RoundTripper sending the requests through another one,
+// like RateLimiter and cassette.Recorder.
+type WrappedTransport interface {
+	http.RoundTripper
+	Unwrap() http.RoundTripper
+}
+
 func NewHttpRequestWithFasthttp(client *http.Client, reqMethod, reqUrl, postData string, headers map[string]string) ([]byte, error) {
+	return NewHttpRequestWithFasthttpWithContext(context.Background(), client, reqMethod, reqUrl, postData, headers)
+}
+
+// fasthttp has no context support, so the context is only checked before the
+// request is sent and its deadline (if any) is used as the request deadline.
+func NewHttpRequestWithFasthttpWithContext(ctx context.Context, client *http.Client, reqMethod, reqUrl, postData string, headers map[string]string) ([]byte, error) {
 	logger.Log.Debug("use fasthttp client")
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	transport := client.Transport
+	if wrapper, ok := transport.(WrappedTransport); ok {
+		//fasthttp would skip the rate limits or the recording
+		return nil, fmt.Errorf("fasthttp can not send requests through the %T transport of the http client, unset HTTP_LIB", wrapper)
+	}
 
-	if transport != nil {
-		if proxy, err := transport.(*http.Transport).Proxy(nil); err == nil && proxy != nil {
+	if t, ok := transport.(*http.Transport); ok && t.Proxy != nil {
+		proxyReq, err := http.NewRequest(reqMethod, reqUrl, nil)
+		if err != nil {
+			return nil, err
+		}
+		if proxy, err := t.Proxy(proxyReq); err == nil && proxy != nil {
 			proxyUrl := proxy.String()
 			logger.Log.Debug("proxy url: ", proxyUrl)
 			if proxy.Scheme != "socks5" {
@@ -59,25 +84,37 @@ func NewHttpRequestWithFasthttp(client *http.Client, reqMethod, reqUrl, postData
 	req.SetRequestURI(reqUrl)
 	req.SetBodyString(postData)
 
-	err := fastHttpClient.Do(req, resp)
+	var err error
+	if deadline, ok := ctx.Deadline(); ok {
+		err = fastHttpClient.DoDeadline(req, resp, deadline)
+	} else {
+		err = fastHttpClient.Do(req, resp)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode() != 200 {
-		return nil, errors.New(fmt.Sprintf("HttpStatusCode:%d ,Desc:%s", resp.StatusCode(), string(resp.Body())))
+		return nil, HttpStatusError(resp.StatusCode(), string(resp.Body()))
 	}
 	return resp.Body(), nil
 }
 
 func NewHttpRequest(client *http.Client, reqType string, reqUrl string, postData string, requstHeaders map[string]string) ([]byte, error) {
+	return NewHttpRequestWithContext(context.Background(), client, reqType, reqUrl, postData, requstHeaders)
+}
+
+func NewHttpRequestWithContext(ctx context.Context, client *http.Client, reqType string, reqUrl string, postData string, requstHeaders map[string]string) ([]byte, error) {
 	logger.Log.Debugf("[%s] request url: %s", reqType, reqUrl)
 	lib := os.Getenv("HTTP_LIB")
 	if lib == "fasthttp" {
-		return NewHttpRequestWithFasthttp(client, reqType, reqUrl, postData, requstHeaders)
+		return NewHttpRequestWithFasthttpWithContext(ctx, client, reqType, reqUrl, postData, requstHeaders)
 	}
 
-	req, _ := http.NewRequest(reqType, reqUrl, strings.NewReader(postData))
+	req, err := http.NewRequestWithContext(ctx, reqType, reqUrl, strings.NewReader(postData))
+	if err != nil {
+		return nil, err
+	}
 	if req.Header.Get("User-Agent") == "" {
 		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 5.1) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/31.0.1650.63 Safari/537.36")
 	}
@@ -100,14 +137,18 @@ func NewHttpRequest(client *http.Client, reqType string, reqUrl string, postData
 	}
 
 	if resp.StatusCode != 200 {
-		return nil, errors.New(fmt.Sprintf("HttpStatusCode:%d ,Desc:%s", resp.StatusCode, string(bodyData)))
+		return nil, HttpStatusError(resp.StatusCode, string(bodyData))
 	}
 
 	return bodyData, nil
 }
 
 func HttpGet(client *http.Client, reqUrl string) (map[string]interface{}, error) {
-	respData, err := NewHttpRequest(client, "GET", reqUrl, "", nil)
+	return HttpGetWithContext(context.Background(), client, reqUrl)
+}
+
+func HttpGetWithContext(ctx context.Context, client *http.Client, reqUrl string) (map[string]interface{}, error) {
+	respData, err := NewHttpRequestWithContext(ctx, client, "GET", reqUrl, "", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -122,11 +163,15 @@ func HttpGet(client *http.Client, reqUrl string) (map[string]interface{}, error)
 }
 
 func HttpGet2(client *http.Client, reqUrl string, headers map[string]string) (map[string]interface{}, error) {
+	return HttpGet2WithContext(context.Background(), client, reqUrl, headers)
+}
+
+func HttpGet2WithContext(ctx context.Context, client *http.Client, reqUrl string, headers map[string]string) (map[string]interface{}, error) {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	respData, err := NewHttpRequest(client, "GET", reqUrl, "", headers)
+	respData, err := NewHttpRequestWithContext(ctx, client, "GET", reqUrl, "", headers)
 	if err != nil {
 		return nil, err
 	}
@@ -141,11 +186,15 @@ func HttpGet2(client *http.Client, reqUrl string, headers map[string]string) (ma
 }
 
 func HttpGet3(client *http.Client, reqUrl string, headers map[string]string) ([]interface{}, error) {
+	return HttpGet3WithContext(context.Background(), client, reqUrl, headers)
+}
+
+func HttpGet3WithContext(ctx context.Context, client *http.Client, reqUrl string, headers map[string]string) ([]interface{}, error) {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	respData, err := NewHttpRequest(client, "GET", reqUrl, "", headers)
+	respData, err := NewHttpRequestWithContext(ctx, client, "GET", reqUrl, "", headers)
 	if err != nil {
 		return nil, err
 	}
@@ -160,11 +209,15 @@ func HttpGet3(client *http.Client, reqUrl string, headers map[string]string) ([]
 }
 
 func HttpGet4(client *http.Client, reqUrl string, headers map[string]string, result interface{}) error {
+	return HttpGet4WithContext(context.Background(), client, reqUrl, headers, result)
+}
+
+func HttpGet4WithContext(ctx context.Context, client *http.Client, reqUrl string, headers map[string]string, result interface{}) error {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	respData, err := NewHttpRequest(client, "GET", reqUrl, "", headers)
+	respData, err := NewHttpRequestWithContext(ctx, client, "GET", reqUrl, "", headers)
 	if err != nil {
 		return err
 	}
@@ -177,12 +230,17 @@ func HttpGet4(client *http.Client, reqUrl string, headers map[string]string, res
 
 	return nil
 }
+
 func HttpGet5(client *http.Client, reqUrl string, headers map[string]string) ([]byte, error) {
+	return HttpGet5WithContext(context.Background(), client, reqUrl, headers)
+}
+
+func HttpGet5WithContext(ctx context.Context, client *http.Client, reqUrl string, headers map[string]string) ([]byte, error) {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	respData, err := NewHttpRequest(client, "GET", reqUrl, "", headers)
+	respData, err := NewHttpRequestWithContext(ctx, client, "GET", reqUrl, "", headers)
 	if err != nil {
 		return nil, err
 	}
@@ -191,44 +249,68 @@ func HttpGet5(client *http.Client, reqUrl string, headers map[string]string) ([]
 }
 
 func HttpPostForm(client *http.Client, reqUrl string, postData url.Values) ([]byte, error) {
+	return HttpPostFormWithContext(context.Background(), client, reqUrl, postData)
+}
+
+func HttpPostFormWithContext(ctx context.Context, client *http.Client, reqUrl string, postData url.Values) ([]byte, error) {
 	headers := map[string]string{
 		"Content-Type": "application/x-www-form-urlencoded"}
-	return NewHttpRequest(client, "POST", reqUrl, postData.Encode(), headers)
+	return NewHttpRequestWithContext(ctx, client, "POST", reqUrl, postData.Encode(), headers)
 }
 
 func HttpPostForm2(client *http.Client, reqUrl string, postData url.Values, headers map[string]string) ([]byte, error) {
+	return HttpPostForm2WithContext(context.Background(), client, reqUrl, postData, headers)
+}
+
+func HttpPostForm2WithContext(ctx context.Context, client *http.Client, reqUrl string, postData url.Values, headers map[string]string) ([]byte, error) {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	return NewHttpRequest(client, "POST", reqUrl, postData.Encode(), headers)
+	return NewHttpRequestWithContext(ctx, client, "POST", reqUrl, postData.Encode(), headers)
 }
 
 func HttpPostForm3(client *http.Client, reqUrl string, postData string, headers map[string]string) ([]byte, error) {
-	return NewHttpRequest(client, "POST", reqUrl, postData, headers)
+	return HttpPostForm3WithContext(context.Background(), client, reqUrl, postData, headers)
+}
+
+func HttpPostForm3WithContext(ctx context.Context, client *http.Client, reqUrl string, postData string, headers map[string]string) ([]byte, error) {
+	return NewHttpRequestWithContext(ctx, client, "POST", reqUrl, postData, headers)
 }
 
 func HttpPostForm4(client *http.Client, reqUrl string, postData map[string]string, headers map[string]string) ([]byte, error) {
+	return HttpPostForm4WithContext(context.Background(), client, reqUrl, postData, headers)
+}
+
+func HttpPostForm4WithContext(ctx context.Context, client *http.Client, reqUrl string, postData map[string]string, headers map[string]string) ([]byte, error) {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/json"
 	data, _ := json.Marshal(postData)
-	return NewHttpRequest(client, "POST", reqUrl, string(data), headers)
+	return NewHttpRequestWithContext(ctx, client, "POST", reqUrl, string(data), headers)
 }
 
 func HttpDeleteForm(client *http.Client, reqUrl string, postData url.Values, headers map[string]string) ([]byte, error) {
+	return HttpDeleteFormWithContext(context.Background(), client, reqUrl, postData, headers)
+}
+
+func HttpDeleteFormWithContext(ctx context.Context, client *http.Client, reqUrl string, postData url.Values, headers map[string]string) ([]byte, error) {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	return NewHttpRequest(client, "DELETE", reqUrl, postData.Encode(), headers)
+	return NewHttpRequestWithContext(ctx, client, "DELETE", reqUrl, postData.Encode(), headers)
 }
 
 func HttpPut(client *http.Client, reqUrl string, postData url.Values, headers map[string]string) ([]byte, error) {
+	return HttpPutWithContext(context.Background(), client, reqUrl, postData, headers)
+}
+
+func HttpPutWithContext(ctx context.Context, client *http.Client, reqUrl string, postData url.Values, headers map[string]string) ([]byte, error) {
 	if headers == nil {
 		headers = map[string]string{}
 	}
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	return NewHttpRequest(client, "PUT", reqUrl, postData.Encode(), headers)
+	return NewHttpRequestWithContext(ctx, client, "PUT", reqUrl, postData.Encode(), headers)
 }
@@ -0,0 +1,51 @@
+package goex
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHttpRequestWithContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer ts.Close()
+
+	ret, err := HttpGetWithContext(context.Background(), http.DefaultClient, ts.URL+"/fast")
+	assert.Nil(t, err)
+	assert.Equal(t, true, ret["ok"])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = HttpGetWithContext(ctx, http.DefaultClient, ts.URL+"/slow")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestNewHttpRequestWithFasthttp(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}}
+	body, err := NewHttpRequestWithFasthttp(client, http.MethodGet, ts.URL, "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"ok":true}`, string(body))
+
+	client = &http.Client{Transport: NewRateLimiter(&RateLimitConfig{})}
+	_, err = NewHttpRequestWithFasthttp(client, http.MethodGet, ts.URL, "", nil)
+	assert.EqualError(t, err, "fasthttp can not send requests through the *goex.RateLimiter transport of the http client, unset HTTP_LIB")
+}
@@ -0,0 +1,182 @@
+package goex
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/soulsplit/goex/internal/logger"
+)
+
+type InstrumentStatus int
+
+const (
+	INSTRUMENT_TRADING   InstrumentStatus = iota + 1
+	INSTRUMENT_PENDING                    //listed, trading not started
+	INSTRUMENT_SUSPENDED                  //halted, settling or delivering
+	INSTRUMENT_DELISTED                   //closed or delivered
+)
+
+var instrumentStatusNames = map[InstrumentStatus]string{
+	INSTRUMENT_TRADING:   "TRADING",
+	INSTRUMENT_PENDING:   "PENDING",
+	INSTRUMENT_SUSPENDED: "SUSPENDED",
+	INSTRUMENT_DELISTED:  "DELISTED",
+}
+
+func (s InstrumentStatus) String() string {
+	if name, ok := instrumentStatusNames[s]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// InstrumentInfo is the trading rules of a spot pair or a contract.
+type InstrumentInfo struct {
+	Id           string       //symbol or contract id of the exchange: BTCUSDT, BTC-USD-210326, BTC-USD-SWAP
+	Pair         CurrencyPair //BTC_USD for the contracts quoted in usd
+	Kind         int          //SPOT, FUTURE, SWAP or SWAP_USDT
+	ContractType string       //this_week, quarter, swap ... empty for spot
+	TickSize     Decimal      //price step
+	LotSize      Decimal      //amount step, in contracts for futures and swaps
+	MinAmount    Decimal
+	MinNotional  Decimal //minimum price*amount, 0 if none
+	ContractVal  Decimal //face value of a contract, 0 for spot
+	// ContractValCurrency is the currency of ContractVal, USD for the inverse contracts.
+	ContractValCurrency Currency
+	Expiry              time.Time //zero for spot and perpetual swaps
+	Status              InstrumentStatus
+}
+
+func (ins *InstrumentInfo) IsTrading() bool {
+	return ins.Status == INSTRUMENT_TRADING
+}
+
+// InstrumentsAPI lists the instruments of an exchange, wrap it with NewInstrumentsCache
+// to query them often. GetInstruments is left to the adapters, the okex and bitget
+// swaps list their native contracts with it.
+type InstrumentsAPI interface {
+	GetExchangeName() string
+	GetInstrumentsInfo() ([]InstrumentInfo, error)
+}
+
+// InstrumentsCache keeps the instruments of an InstrumentsAPI, loading them again
+// when they are older than the refresh interval. If the refresh fails the stale
+// instruments are kept. The loaded instruments are registered in Symbols.
+type InstrumentsCache struct {
+	api     InstrumentsAPI
+	refresh time.Duration
+
+	sync.Mutex
+	instruments []InstrumentInfo
+	byId        map[string]int
+	updated     time.Time
+	now         func() time.Time
+}
+
+func NewInstrumentsCache(api InstrumentsAPI, refresh time.Duration) *InstrumentsCache {
+	return &InstrumentsCache{api: api, refresh: refresh, now: time.Now}
+}
+
+func (c *InstrumentsCache) GetExchangeName() string {
+	return c.api.GetExchangeName()
+}
+
+// GetInstrumentsInfo returns a copy of the cached instruments.
+func (c *InstrumentsCache) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	c.Lock()
+	defer c.Unlock()
+	if err := c.load(false); err != nil {
+		return nil, err
+	}
+	return append([]InstrumentInfo(nil), c.instruments...), nil
+}
+
+// GetInstrument finds an instrument by pair and contract type, empty for spot. The
+// pairs are matched like the adapters take them: the pair may use an alias, a usdt
+// margined contract is found by its USD pair too, and SWAP_CONTRACT is the usdt
+// margined swap if there is no inverse one. It returns EX_ERR_SYMBOL_ERR if there is none.
+func (c *InstrumentsCache) GetInstrument(pair CurrencyPair, contractType string) (*InstrumentInfo, error) {
+	c.Lock()
+	defer c.Unlock()
+	if err := c.load(false); err != nil {
+		return nil, err
+	}
+
+	find := func(pair CurrencyPair, contractType string) *InstrumentInfo {
+		for i := range c.instruments {
+			if ins := c.instruments[i]; CanonicalPair(ins.Pair).Eq(pair) && ins.ContractType == contractType {
+				return &ins
+			}
+		}
+		return nil
+	}
+
+	pair = CanonicalPair(pair)
+	if ins := find(pair, contractType); ins != nil {
+		return ins, nil
+	}
+	if contractType != "" {
+		for _, p := range []CurrencyPair{pair.AdaptUsdToUsdt(), pair.AdaptUsdtToUsd()} {
+			if ins := find(p, contractType); ins != nil {
+				return ins, nil
+			}
+		}
+	}
+	if contractType == SWAP_CONTRACT {
+		if ins := find(pair.AdaptUsdToUsdt(), SWAP_USDT_CONTRACT); ins != nil {
+			return ins, nil
+		}
+	}
+	return nil, EX_ERR_SYMBOL_ERR.OriginErr(fmt.Sprintf("%s has no instrument %s %s", c.api.GetExchangeName(), pair, contractType))
+}
+
+// GetInstrumentById finds an instrument by the id of the exchange.
+func (c *InstrumentsCache) GetInstrumentById(id string) (*InstrumentInfo, error) {
+	c.Lock()
+	defer c.Unlock()
+	if err := c.load(false); err != nil {
+		return nil, err
+	}
+	if i, ok := c.byId[id]; ok {
+		ins := c.instruments[i]
+		return &ins, nil
+	}
+	return nil, EX_ERR_SYMBOL_ERR.OriginErr(fmt.Sprintf("%s has no instrument %s", c.api.GetExchangeName(), id))
+}
+
+// Refresh loads the instruments now.
+func (c *InstrumentsCache) Refresh() error {
+	c.Lock()
+	defer c.Unlock()
+	return c.load(true)
+}
+
+func (c *InstrumentsCache) load(force bool) error {
+	now := c.now()
+	if !force && c.instruments != nil && (c.refresh <= 0 || now.Sub(c.updated) < c.refresh) {
+		return nil
+	}
+
+	instruments, err := c.api.GetInstrumentsInfo()
+	if err != nil {
+		if c.instruments != nil && !force {
+			logger.Warnf("[%s] refresh instruments error: %v, keep the ones of %s", c.api.GetExchangeName(), err, c.updated.Format(time.RFC3339))
+			c.updated = now
+			return nil
+		}
+		return err
+	}
+
+	c.instruments = instruments
+	if c.instruments == nil {
+		c.instruments = []InstrumentInfo{}
+	}
+	c.byId = make(map[string]int, len(instruments))
+	for i, ins := range instruments {
+		c.byId[ins.Id] = i
+	}
+	c.updated = now
+	Symbols.RegisterInstruments(c.api.GetExchangeName(), instruments)
+	return nil
+}
@@ -0,0 +1,93 @@
+package goex
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeInstrumentsAPI struct {
+	calls       int
+	err         error
+	instruments []InstrumentInfo
+}
+
+func (f *fakeInstrumentsAPI) GetExchangeName() string { return "fake" }
+
+func (f *fakeInstrumentsAPI) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	f.calls++
+	return f.instruments, f.err
+}
+
+func TestInstrumentsCache(t *testing.T) {
+	api := &fakeInstrumentsAPI{instruments: []InstrumentInfo{
+		{Id: "BTCUSDT", Pair: BTC_USDT, Kind: SPOT, TickSize: MustParseDecimal("0.01"), Status: INSTRUMENT_TRADING},
+		{Id: "BTCUSD_PERP", Pair: BTC_USD, Kind: SWAP, ContractType: SWAP_CONTRACT, Status: INSTRUMENT_TRADING},
+	}}
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewInstrumentsCache(api, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	ins, err := cache.GetInstrument(BTC_USDT, "")
+	require.NoError(t, err)
+	assert.Equal(t, "BTCUSDT", ins.Id)
+	assert.Equal(t, "0.01", ins.TickSize.String())
+	assert.True(t, ins.IsTrading())
+
+	ins, err = cache.GetInstrumentById("BTCUSD_PERP")
+	require.NoError(t, err)
+	assert.Equal(t, SWAP_CONTRACT, ins.ContractType)
+
+	_, err = cache.GetInstrument(ETH_USDT, "")
+	assert.True(t, errors.Is(err, EX_ERR_SYMBOL_ERR))
+	assert.Equal(t, 1, api.calls)
+
+	now = now.Add(time.Hour)
+	api.err = errors.New("timeout")
+	all, err := cache.GetInstrumentsInfo()
+	require.NoError(t, err, "the stale instruments are kept")
+	assert.Len(t, all, 2)
+	assert.Equal(t, 2, api.calls)
+	assert.Error(t, cache.Refresh())
+
+	api.err = nil
+	api.instruments = api.instruments[:1]
+	require.NoError(t, cache.Refresh())
+	_, err = cache.GetInstrumentById("BTCUSD_PERP")
+	assert.True(t, errors.Is(err, EX_ERR_SYMBOL_ERR))
+}
+
+func TestInstrumentsCache_GetInstrument(t *testing.T) {
+	cache := NewInstrumentsCache(&fakeInstrumentsAPI{instruments: []InstrumentInfo{
+		{Id: "XBTUSD", Pair: NewCurrencyPair2("XBT_USD"), Kind: SWAP, ContractType: SWAP_CONTRACT},
+		{Id: "ETHUSDT", Pair: ETH_USDT, Kind: SWAP_USDT, ContractType: SWAP_USDT_CONTRACT},
+		{Id: "ETHUSDT_210625", Pair: ETH_USDT, Kind: FUTURE, ContractType: QUARTER_CONTRACT},
+	}}, 0)
+
+	for _, c := range []struct {
+		pair         CurrencyPair
+		contractType string
+		id           string
+	}{
+		{BTC_USD, SWAP_CONTRACT, "XBTUSD"},
+		{ETH_USD, SWAP_USDT_CONTRACT, "ETHUSDT"},
+		{ETH_USDT, SWAP_CONTRACT, "ETHUSDT"},
+		{ETH_USD, SWAP_CONTRACT, "ETHUSDT"},
+		{ETH_USD, QUARTER_CONTRACT, "ETHUSDT_210625"},
+	} {
+		ins, err := cache.GetInstrument(c.pair, c.contractType)
+		require.NoError(t, err, c.id)
+		assert.Equal(t, c.id, ins.Id)
+	}
+	_, err := cache.GetInstrument(ETH_USD, "")
+	assert.True(t, errors.Is(err, EX_ERR_SYMBOL_ERR), "spot pairs are exact")
+}
+
+func TestInstrumentsCache_Error(t *testing.T) {
+	cache := NewInstrumentsCache(&fakeInstrumentsAPI{err: EX_ERR_API_LIMIT}, time.Minute)
+	_, err := cache.GetInstrumentsInfo()
+	assert.True(t, errors.Is(err, EX_ERR_API_LIMIT))
+}
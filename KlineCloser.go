@@ -0,0 +1,31 @@
+package goex
+
+import "sync"
+
+// KlineCloser closes the candles of the streams which only push the forming candle,
+// a candle is closed once the first update of the next one arrives.
+type KlineCloser struct {
+	lock  sync.Mutex
+	lasts map[string]*FutureKline
+}
+
+// Next records the update k of the stream. It returns the last update of the
+// previous candle when k starts a new one, and stale for the late updates of a
+// candle older than the last seen.
+func (c *KlineCloser) Next(stream string, k *FutureKline) (closed *FutureKline, stale bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.lasts == nil {
+		c.lasts = make(map[string]*FutureKline, 4)
+	}
+	last, ok := c.lasts[stream]
+	if ok && k.Timestamp < last.Timestamp {
+		return nil, true
+	}
+	c.lasts[stream] = k
+	if ok && k.Timestamp > last.Timestamp {
+		return last, false
+	}
+	return nil, false
+}
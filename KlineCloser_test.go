@@ -0,0 +1,32 @@
+package goex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKlineCloser_Next(t *testing.T) {
+	var c KlineCloser
+	k := func(ts int64, close float64) *FutureKline {
+		return &FutureKline{Kline: &Kline{Timestamp: ts, Close: close}}
+	}
+
+	closed, stale := c.Next("btc", k(60, 1))
+	assert.Nil(t, closed)
+	assert.False(t, stale)
+	closed, _ = c.Next("btc", k(60, 2))
+	assert.Nil(t, closed, "the same candle is still forming")
+	closed, _ = c.Next("eth", k(120, 10))
+	assert.Nil(t, closed, "the streams are apart")
+
+	closed, stale = c.Next("btc", k(120, 3))
+	assert.False(t, stale)
+	assert.Equal(t, k(60, 2), closed, "the last update of the previous candle")
+
+	closed, stale = c.Next("btc", k(60, 4))
+	assert.Nil(t, closed)
+	assert.True(t, stale)
+	closed, _ = c.Next("btc", k(180, 5))
+	assert.Equal(t, k(120, 3), closed, "the stale update is dropped")
+}
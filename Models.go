@@ -138,6 +138,7 @@ type Depth struct {
 	ContractId   string `json:"contract_id,omitempty"`   // for futures
 	Pair         CurrencyPair
 	UTime        time.Time
+	UpdateId     int64        // sequence number of the book if the exchange has one, see OrderBook
 	AskList      DepthRecords // Descending order
 	BidList      DepthRecords // Descending order
 }
@@ -165,7 +166,8 @@ type Kline struct {
 
 type FutureKline struct {
 	*Kline
-	Vol2 float64 //个数
+	Vol2         float64 //个数
+	ContractType string  //set by the ws streams
 }
 
 type FutureSubAccount struct {
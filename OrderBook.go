@@ -0,0 +1,356 @@
+package goex
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrOrderBookNotSynced = errors.New("order book not synced")
+	ErrOrderBookGap       = errors.New("order book sequence gap")
+)
+
+// DepthUpdate is one message of an incremental depth stream. Amount 0 removes a price level.
+//
+// Sequence numbers are exchange specific, set what the exchange sends:
+//
+//	binance: FirstUpdateId=U, LastUpdateId=u (futures also PrevUpdateId=pu)
+//	huobi mbp: PrevUpdateId=prevSeqNum, LastUpdateId=seqNum
+//	okex depth_l2_tbt: no sequence numbers, Snapshot=true for the partial message
+type DepthUpdate struct {
+	Pair          CurrencyPair
+	FirstUpdateId int64
+	LastUpdateId  int64
+	PrevUpdateId  int64
+	Snapshot      bool //a full book, replaces the local one
+	UTime         time.Time
+	BidList       DepthRecords
+	AskList       DepthRecords
+}
+
+type OrderBookConfig struct {
+	Pair CurrencyPair
+	// Snapshot loads a full book, usually the REST GetDepth with Depth.UpdateId set.
+	// It is called from Update whenever the book is out of sync.
+	Snapshot func() (*Depth, error)
+	// Resync is called when the book went out of sync and there is no Snapshot func.
+	// It should make the exchange push a new snapshot (resubscribe, send a req ...).
+	Resync func()
+	// TopOfBookCallback is called when the best bid or the best ask changes. The
+	// DepthRecord is empty if that side of the book is empty.
+	TopOfBookCallback func(pair CurrencyPair, bid, ask DepthRecord)
+	ErrorHandleFunc   func(err error)
+	MaxBuffered       int //diffs kept while waiting for a snapshot, default 1000
+}
+
+// OrderBook keeps a local full order book from a snapshot and a diff stream. Feed
+// every message of the stream to Update, read the book with Depth.
+type OrderBook struct {
+	sync.Mutex
+	conf OrderBookConfig
+
+	bids      DepthRecords //descending
+	asks      DepthRecords //ascending
+	updateId  int64
+	utime     time.Time
+	synced    bool
+	fresh     bool //no diff applied since the snapshot
+	resyncing bool
+	epoch     int //bumped when the book is dropped or loaded, a snapshot fetched before is stale
+	buffer    []*DepthUpdate
+
+	topBid, topAsk DepthRecord
+}
+
+func NewOrderBook(conf *OrderBookConfig) *OrderBook {
+	ob := &OrderBook{conf: *conf}
+	if ob.conf.MaxBuffered <= 0 {
+		ob.conf.MaxBuffered = 1000
+	}
+	return ob
+}
+
+func (ob *OrderBook) Synced() bool {
+	ob.Lock()
+	defer ob.Unlock()
+	return ob.synced
+}
+
+func (ob *OrderBook) UpdateId() int64 {
+	ob.Lock()
+	defer ob.Unlock()
+	return ob.updateId
+}
+
+// Depth returns a copy of the top size levels, all levels if size <= 0.
+// AskList is in descending order like everywhere else in goex.
+func (ob *OrderBook) Depth(size int) (*Depth, error) {
+	ob.Lock()
+	defer ob.Unlock()
+
+	if !ob.synced {
+		return nil, ErrOrderBookNotSynced
+	}
+
+	dep := &Depth{Pair: ob.conf.Pair, UTime: ob.utime, UpdateId: ob.updateId}
+	bids, asks := ob.bids, ob.asks
+	if size > 0 && len(bids) > size {
+		bids = bids[:size]
+	}
+	if size > 0 && len(asks) > size {
+		asks = asks[:size]
+	}
+	dep.BidList = append(DepthRecords(nil), bids...)
+	dep.AskList = make(DepthRecords, len(asks))
+	for i, a := range asks {
+		dep.AskList[len(asks)-1-i] = a
+	}
+	return dep, nil
+}
+
+// Top returns the best bid and ask.
+func (ob *OrderBook) Top() (bid, ask DepthRecord, err error) {
+	ob.Lock()
+	defer ob.Unlock()
+
+	if !ob.synced {
+		return bid, ask, ErrOrderBookNotSynced
+	}
+	return ob.topBid, ob.topAsk, nil
+}
+
+// Reset drops the local book, the next Update loads a new snapshot.
+func (ob *OrderBook) Reset() {
+	ob.Lock()
+	ob.unsync()
+	ob.Unlock()
+}
+
+// Update applies one message of the diff stream. A sequence gap drops the book,
+// reports ErrOrderBookGap to ErrorHandleFunc and resyncs. The Snapshot func is called
+// without holding the lock, the diffs passed meanwhile are buffered.
+func (ob *OrderBook) Update(u *DepthUpdate) error {
+	ob.Lock()
+	fetch, err := ob.update(u)
+	epoch := ob.epoch
+	bid, ask, changed := ob.topOfBook()
+	ob.Unlock()
+
+	if fetch {
+		if err2 := ob.loadSnapshot(epoch); err2 != nil {
+			if err != nil {
+				err = fmt.Errorf("%w, resync: %s", err, err2.Error())
+			} else {
+				err = err2
+			}
+		}
+		ob.Lock()
+		bid, ask, changed = ob.topOfBook()
+		ob.Unlock()
+	}
+
+	if err != nil && ob.conf.ErrorHandleFunc != nil {
+		ob.conf.ErrorHandleFunc(err)
+	}
+	if changed && ob.conf.TopOfBookCallback != nil {
+		ob.conf.TopOfBookCallback(ob.conf.Pair, bid, ask)
+	}
+	return err
+}
+
+// update is true if the caller has to load a snapshot with loadSnapshot.
+func (ob *OrderBook) update(u *DepthUpdate) (bool, error) {
+	if u.Snapshot {
+		ob.load(u.BidList, u.AskList, u.LastUpdateId, u.UTime)
+		return false, ob.applyBuffer()
+	}
+
+	if !ob.synced {
+		ob.bufferUpdate(u)
+		return ob.resync(), nil
+	}
+
+	if err := ob.apply(u); err != nil {
+		ob.unsync()
+		ob.bufferUpdate(u)
+		return ob.resync(), err
+	}
+	return false, nil
+}
+
+func (ob *OrderBook) unsync() {
+	ob.synced = false
+	ob.resyncing = false
+	ob.bids, ob.asks = nil, nil
+	ob.updateId = 0
+	ob.epoch++
+}
+
+func (ob *OrderBook) bufferUpdate(u *DepthUpdate) {
+	if len(ob.buffer) >= ob.conf.MaxBuffered {
+		ob.buffer = ob.buffer[1:]
+	}
+	ob.buffer = append(ob.buffer, u)
+}
+
+// resync is true if the snapshot is to be loaded right away with the Snapshot func,
+// else it asks the exchange for one and waits for it in Update.
+func (ob *OrderBook) resync() bool {
+	if ob.resyncing {
+		return false
+	}
+	ob.resyncing = true
+	if ob.conf.Snapshot != nil {
+		return true
+	}
+	if ob.conf.Resync != nil {
+		ob.conf.Resync()
+	}
+	return false
+}
+
+// loadSnapshot calls the Snapshot func, the result is dropped if the book was reset or
+// loaded from the stream meanwhile.
+func (ob *OrderBook) loadSnapshot(epoch int) error {
+	dep, err := ob.conf.Snapshot()
+
+	ob.Lock()
+	defer ob.Unlock()
+	if ob.epoch != epoch {
+		return nil
+	}
+	if err != nil {
+		ob.resyncing = false
+		return err
+	}
+	ob.load(dep.BidList, dep.AskList, dep.UpdateId, dep.UTime)
+	return ob.applyBuffer()
+}
+
+func (ob *OrderBook) load(bids, asks DepthRecords, updateId int64, utime time.Time) {
+	ob.bids = ob.bids[:0]
+	ob.asks = ob.asks[:0]
+	for _, r := range bids {
+		ob.set(BUY, r)
+	}
+	for _, r := range asks {
+		ob.set(SELL, r)
+	}
+	ob.updateId = updateId
+	ob.utime = utime
+	ob.synced = true
+	ob.fresh = true
+	ob.resyncing = false
+	ob.epoch++
+}
+
+// applyBuffer applies the diffs received while waiting for the snapshot. Diffs without
+// sequence numbers are dropped if they are older than the snapshot. On a gap the book
+// stays out of sync and only the diffs after the gap are kept.
+func (ob *OrderBook) applyBuffer() error {
+	buffer := ob.buffer
+	ob.buffer = nil
+	snapshotTime := ob.utime
+	for i, u := range buffer {
+		if u.LastUpdateId == 0 && !u.UTime.IsZero() && u.UTime.Before(snapshotTime) {
+			continue
+		}
+		if err := ob.apply(u); err != nil {
+			ob.unsync()
+			ob.buffer = buffer[i:]
+			return err
+		}
+	}
+	return nil
+}
+
+// apply checks the sequence of u against the book and applies it. Diffs older than
+// the book are dropped.
+func (ob *OrderBook) apply(u *DepthUpdate) error {
+	if u.LastUpdateId != 0 && ob.updateId != 0 {
+		if u.LastUpdateId <= ob.updateId {
+			return nil
+		}
+
+		var ok bool
+		switch {
+		case ob.fresh && u.FirstUpdateId != 0:
+			ok = u.FirstUpdateId <= ob.updateId+1
+		case ob.fresh && u.PrevUpdateId != 0:
+			ok = u.PrevUpdateId <= ob.updateId
+		case u.PrevUpdateId != 0:
+			ok = u.PrevUpdateId == ob.updateId
+		case u.FirstUpdateId != 0:
+			ok = u.FirstUpdateId == ob.updateId+1
+		default:
+			ok = true
+		}
+		if !ok {
+			return fmt.Errorf("%w: %s book at %d, update %d-%d (prev %d)", ErrOrderBookGap,
+				ob.conf.Pair, ob.updateId, u.FirstUpdateId, u.LastUpdateId, u.PrevUpdateId)
+		}
+	}
+
+	for _, r := range u.BidList {
+		ob.set(BUY, r)
+	}
+	for _, r := range u.AskList {
+		ob.set(SELL, r)
+	}
+	if u.LastUpdateId != 0 {
+		ob.updateId = u.LastUpdateId
+	}
+	if !u.UTime.IsZero() {
+		ob.utime = u.UTime
+	}
+	ob.fresh = false
+	return nil
+}
+
+// set replaces the amount of a price level, removing it when the amount is 0.
+func (ob *OrderBook) set(side TradeSide, r DepthRecord) {
+	levels := &ob.asks
+	if side == BUY {
+		levels = &ob.bids
+	}
+
+	i := sort.Search(len(*levels), func(i int) bool {
+		if side == BUY {
+			return (*levels)[i].Price <= r.Price
+		}
+		return (*levels)[i].Price >= r.Price
+	})
+	found := i < len(*levels) && (*levels)[i].Price == r.Price
+
+	switch {
+	case r.Amount <= 0 && found:
+		*levels = append((*levels)[:i], (*levels)[i+1:]...)
+	case r.Amount <= 0:
+	case found:
+		(*levels)[i].Amount = r.Amount
+	default:
+		*levels = append(*levels, DepthRecord{})
+		copy((*levels)[i+1:], (*levels)[i:])
+		(*levels)[i] = r
+	}
+}
+
+// topOfBook returns the best levels and whether they changed since the last call.
+// Nothing is reported while the book is out of sync.
+func (ob *OrderBook) topOfBook() (bid, ask DepthRecord, changed bool) {
+	if !ob.synced {
+		return
+	}
+	if len(ob.bids) > 0 {
+		bid = ob.bids[0]
+	}
+	if len(ob.asks) > 0 {
+		ask = ob.asks[0]
+	}
+	changed = bid != ob.topBid || ask != ob.topAsk
+	ob.topBid, ob.topAsk = bid, ask
+	return
+}
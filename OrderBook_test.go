@@ -0,0 +1,132 @@
+package goex
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func records(pa ...float64) DepthRecords {
+	var r DepthRecords
+	for i := 0; i+1 < len(pa); i += 2 {
+		r = append(r, DepthRecord{Price: pa[i], Amount: pa[i+1]})
+	}
+	return r
+}
+
+func TestOrderBook_BinanceSequence(t *testing.T) {
+	snapshots := []*Depth{
+		{UpdateId: 100, BidList: records(99, 1, 98, 2), AskList: records(102, 1, 101, 3)},
+		{UpdateId: 120, BidList: records(97, 5), AskList: records(103, 5)},
+	}
+	var (
+		snapshotCalls int
+		errs          []error
+		tops          [][2]DepthRecord
+	)
+	ob := NewOrderBook(&OrderBookConfig{
+		Pair: BTC_USDT,
+		Snapshot: func() (*Depth, error) {
+			dep := snapshots[snapshotCalls]
+			snapshotCalls++
+			return dep, nil
+		},
+		TopOfBookCallback: func(pair CurrencyPair, bid, ask DepthRecord) {
+			tops = append(tops, [2]DepthRecord{bid, ask})
+		},
+		ErrorHandleFunc: func(err error) { errs = append(errs, err) },
+	})
+
+	_, err := ob.Depth(5)
+	assert.Equal(t, ErrOrderBookNotSynced, err)
+
+	//stale, dropped after the snapshot is loaded
+	require.NoError(t, ob.Update(&DepthUpdate{FirstUpdateId: 90, LastUpdateId: 100, BidList: records(99, 7)}))
+	assert.Equal(t, 1, snapshotCalls)
+	assert.Equal(t, int64(100), ob.UpdateId())
+
+	//first diff overlaps the snapshot
+	require.NoError(t, ob.Update(&DepthUpdate{FirstUpdateId: 95, LastUpdateId: 105, BidList: records(99, 0, 100, 4)}))
+	require.NoError(t, ob.Update(&DepthUpdate{FirstUpdateId: 106, LastUpdateId: 110, AskList: records(101, 0)}))
+
+	dep, err := ob.Depth(0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(110), dep.UpdateId)
+	assert.Equal(t, records(100, 4, 98, 2), dep.BidList)
+	assert.Equal(t, records(102, 1), dep.AskList)
+
+	dep, _ = ob.Depth(1)
+	assert.Equal(t, records(100, 4), dep.BidList)
+
+	//111 is missing
+	err = ob.Update(&DepthUpdate{FirstUpdateId: 112, LastUpdateId: 121, BidList: records(97, 6)})
+	assert.True(t, errors.Is(err, ErrOrderBookGap))
+	require.Len(t, errs, 1)
+	assert.Equal(t, 2, snapshotCalls)
+	assert.True(t, ob.Synced())
+
+	dep, _ = ob.Depth(0)
+	assert.Equal(t, int64(121), dep.UpdateId)
+	assert.Equal(t, records(97, 6), dep.BidList)
+	assert.Equal(t, records(103, 5), dep.AskList)
+
+	require.Len(t, tops, 4)
+	assert.Equal(t, [2]DepthRecord{{Price: 99, Amount: 1}, {Price: 101, Amount: 3}}, tops[0])
+	assert.Equal(t, [2]DepthRecord{{Price: 100, Amount: 4}, {Price: 101, Amount: 3}}, tops[1])
+	assert.Equal(t, [2]DepthRecord{{Price: 100, Amount: 4}, {Price: 102, Amount: 1}}, tops[2])
+	assert.Equal(t, [2]DepthRecord{{Price: 97, Amount: 6}, {Price: 103, Amount: 5}}, tops[3])
+}
+
+func TestOrderBook_StreamSnapshot(t *testing.T) {
+	var resyncs int
+	ob := NewOrderBook(&OrderBookConfig{Pair: BTC_USDT, Resync: func() { resyncs++ }})
+
+	//huobi mbp: diffs arrive before the requested snapshot
+	require.NoError(t, ob.Update(&DepthUpdate{PrevUpdateId: 10, LastUpdateId: 11, BidList: records(99, 1)}))
+	require.NoError(t, ob.Update(&DepthUpdate{PrevUpdateId: 11, LastUpdateId: 12, BidList: records(98, 1)}))
+	assert.Equal(t, 1, resyncs)
+	assert.False(t, ob.Synced())
+
+	require.NoError(t, ob.Update(&DepthUpdate{Snapshot: true, LastUpdateId: 11, BidList: records(99, 1, 97, 1), AskList: records(100, 1)}))
+	dep, err := ob.Depth(0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), dep.UpdateId)
+	assert.Equal(t, records(99, 1, 98, 1, 97, 1), dep.BidList)
+
+	err = ob.Update(&DepthUpdate{PrevUpdateId: 13, LastUpdateId: 14})
+	assert.True(t, errors.Is(err, ErrOrderBookGap))
+	assert.False(t, ob.Synced())
+	assert.Equal(t, 2, resyncs)
+
+	//okex depth_l2_tbt: no sequence numbers at all
+	ob = NewOrderBook(&OrderBookConfig{Pair: BTC_USDT})
+	require.NoError(t, ob.Update(&DepthUpdate{Snapshot: true, BidList: records(99, 1), AskList: records(101, 1, 100, 2)}))
+	require.NoError(t, ob.Update(&DepthUpdate{AskList: records(100, 0, 102, 1)}))
+	dep, _ = ob.Depth(0)
+	assert.Equal(t, records(102, 1, 101, 1), dep.AskList)
+}
+
+func TestOrderBook_SnapshotUnlocked(t *testing.T) {
+	var (
+		ob            *OrderBook
+		snapshotCalls int
+		t0            = time.Unix(1609459200, 0)
+	)
+	ob = NewOrderBook(&OrderBookConfig{Pair: BTC_USDT, Snapshot: func() (*Depth, error) {
+		snapshotCalls++
+		assert.False(t, ob.Synced(), "the book is not locked")
+		//pushed while the snapshot is loading
+		require.NoError(t, ob.Update(&DepthUpdate{UTime: t0.Add(2 * time.Second), AskList: records(101, 5)}))
+		return &Depth{UTime: t0.Add(time.Second), BidList: records(99, 1), AskList: records(101, 1, 100, 2)}, nil
+	}})
+
+	//no sequence numbers, older than the snapshot
+	require.NoError(t, ob.Update(&DepthUpdate{UTime: t0, AskList: records(100, 0)}))
+	assert.Equal(t, 1, snapshotCalls)
+	dep, err := ob.Depth(0)
+	require.NoError(t, err)
+	assert.Equal(t, records(101, 5, 100, 2), dep.AskList)
+}
@@ -0,0 +1,172 @@
+package goex
+
+import "fmt"
+
+// OrderRules rounds the orders to the increments of their instrument and rejects
+// the ones the exchange would refuse, before any request is sent.
+type OrderRules struct {
+	instruments *InstrumentsCache
+}
+
+func NewOrderRules(instruments *InstrumentsCache) *OrderRules {
+	return &OrderRules{instruments: instruments}
+}
+
+// Normalize rounds the price of a limit order to the tick size, down for a buy and
+// up for a sell so the order is never more aggressive than asked, and the amount
+// down to the lot size. It fails with EX_ERR_MIN_AMOUNT or EX_ERR_MIN_NOTIONAL if
+// the rounded order is under the minimums, contractType is empty for spot.
+func (r *OrderRules) Normalize(pair CurrencyPair, contractType string, side TradeSide, price, amount string) (string, string, error) {
+	ins, err := r.instrument(pair, contractType)
+	if err != nil {
+		return "", "", err
+	}
+	p, err := ParseDecimal(price)
+	if err != nil || p.Sign() <= 0 {
+		return "", "", EX_ERR_INVALID_PARAMETER.OriginErr(fmt.Sprintf("%s invalid price %q", ins.Id, price))
+	}
+	a, err := ParseDecimal(amount)
+	if err != nil {
+		return "", "", EX_ERR_INVALID_PARAMETER.OriginErr(fmt.Sprintf("%s invalid amount %q", ins.Id, amount))
+	}
+
+	p = roundPrice(p, ins.TickSize, side)
+	a = a.TruncateStep(ins.LotSize)
+	if err = checkAmount(ins, a); err != nil {
+		return "", "", err
+	}
+	if notional := p.Mul(a); ins.MinNotional.Sign() > 0 && notional.Cmp(ins.MinNotional) < 0 {
+		return "", "", EX_ERR_MIN_NOTIONAL.OriginErr(fmt.Sprintf("%s order value %s under the minimum %s", ins.Id, notional.Normalize(), ins.MinNotional))
+	}
+	return p.Normalize().String(), a.Normalize().String(), nil
+}
+
+// NormalizeAmount rounds the amount of a market order down to the lot size, the
+// notional is not checked as the price is unknown.
+func (r *OrderRules) NormalizeAmount(pair CurrencyPair, contractType string, amount string) (string, error) {
+	ins, err := r.instrument(pair, contractType)
+	if err != nil {
+		return "", err
+	}
+	a, err := ParseDecimal(amount)
+	if err != nil {
+		return "", EX_ERR_INVALID_PARAMETER.OriginErr(fmt.Sprintf("%s invalid amount %q", ins.Id, amount))
+	}
+	a = a.TruncateStep(ins.LotSize)
+	if err = checkAmount(ins, a); err != nil {
+		return "", err
+	}
+	return a.Normalize().String(), nil
+}
+
+func (r *OrderRules) instrument(pair CurrencyPair, contractType string) (*InstrumentInfo, error) {
+	ins, err := r.instruments.GetInstrument(pair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	if ins.Status != 0 && !ins.IsTrading() {
+		return nil, EX_ERR_SYMBOL_ERR.OriginErr(fmt.Sprintf("%s is %s", ins.Id, ins.Status))
+	}
+	return ins, nil
+}
+
+func roundPrice(price, tick Decimal, side TradeSide) Decimal {
+	if tick.Sign() <= 0 {
+		return price
+	}
+	rounded := price.TruncateStep(tick)
+	if (side == SELL || side == SELL_MARKET) && rounded.Cmp(price) < 0 {
+		rounded = rounded.Add(tick)
+	}
+	return rounded
+}
+
+func checkAmount(ins *InstrumentInfo, amount Decimal) error {
+	if amount.Sign() <= 0 || amount.Cmp(ins.MinAmount) < 0 {
+		return EX_ERR_MIN_AMOUNT.OriginErr(fmt.Sprintf("%s amount %s under the minimum %s", ins.Id, amount.Normalize(), ins.MinAmount))
+	}
+	return nil
+}
+
+// ValidatedAPI normalizes the orders of an API with OrderRules. Market buys are sent
+// as is, their amount is in the quote currency on some exchanges.
+type ValidatedAPI struct {
+	API
+	rules *OrderRules
+}
+
+func NewValidatedAPI(api API, rules *OrderRules) *ValidatedAPI {
+	return &ValidatedAPI{API: api, rules: rules}
+}
+
+func (v *ValidatedAPI) LimitBuy(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	price, amount, err := v.rules.Normalize(currency, "", BUY, price, amount)
+	if err != nil {
+		return nil, err
+	}
+	return v.API.LimitBuy(amount, price, currency, opt...)
+}
+
+func (v *ValidatedAPI) LimitSell(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	price, amount, err := v.rules.Normalize(currency, "", SELL, price, amount)
+	if err != nil {
+		return nil, err
+	}
+	return v.API.LimitSell(amount, price, currency, opt...)
+}
+
+func (v *ValidatedAPI) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
+	amount, err := v.rules.NormalizeAmount(currency, "", amount)
+	if err != nil {
+		return nil, err
+	}
+	return v.API.MarketSell(amount, price, currency)
+}
+
+// ValidatedFutureRestAPI normalizes the orders of a FutureRestAPI with OrderRules,
+// the amounts are in contracts.
+type ValidatedFutureRestAPI struct {
+	FutureRestAPI
+	rules *OrderRules
+}
+
+func NewValidatedFutureRestAPI(api FutureRestAPI, rules *OrderRules) *ValidatedFutureRestAPI {
+	return &ValidatedFutureRestAPI{FutureRestAPI: api, rules: rules}
+}
+
+// openTypeSide is the side of the trade: opening a long and closing a short buy.
+func openTypeSide(openType int) TradeSide {
+	if openType == OPEN_BUY || openType == CLOSE_SELL {
+		return BUY
+	}
+	return SELL
+}
+
+func (v *ValidatedFutureRestAPI) PlaceFutureOrder(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64) (string, error) {
+	var err error
+	if matchPrice == 1 {
+		amount, err = v.rules.NormalizeAmount(currencyPair, contractType, amount)
+	} else {
+		price, amount, err = v.rules.Normalize(currencyPair, contractType, openTypeSide(openType), price, amount)
+	}
+	if err != nil {
+		return "", err
+	}
+	return v.FutureRestAPI.PlaceFutureOrder(currencyPair, contractType, price, amount, openType, matchPrice, leverRate)
+}
+
+func (v *ValidatedFutureRestAPI) LimitFuturesOrder(currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	price, amount, err := v.rules.Normalize(currencyPair, contractType, openTypeSide(openType), price, amount)
+	if err != nil {
+		return nil, err
+	}
+	return v.FutureRestAPI.LimitFuturesOrder(currencyPair, contractType, price, amount, openType, opt...)
+}
+
+func (v *ValidatedFutureRestAPI) MarketFuturesOrder(currencyPair CurrencyPair, contractType, amount string, openType int) (*FutureOrder, error) {
+	amount, err := v.rules.NormalizeAmount(currencyPair, contractType, amount)
+	if err != nil {
+		return nil, err
+	}
+	return v.FutureRestAPI.MarketFuturesOrder(currencyPair, contractType, amount, openType)
+}
@@ -0,0 +1,112 @@
+package goex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOrderRules() *OrderRules {
+	api := &fakeInstrumentsAPI{instruments: []InstrumentInfo{
+		{Id: "BTCUSDT", Pair: BTC_USDT, Kind: SPOT, TickSize: MustParseDecimal("0.01"), LotSize: MustParseDecimal("0.001"),
+			MinAmount: MustParseDecimal("0.001"), MinNotional: MustParseDecimal("10"), Status: INSTRUMENT_TRADING},
+		{Id: "ETHUSDT", Pair: ETH_USDT, Kind: SPOT, TickSize: MustParseDecimal("0.01"), LotSize: MustParseDecimal("0.0001"),
+			MinAmount: MustParseDecimal("0.0001"), Status: INSTRUMENT_SUSPENDED},
+		{Id: "BTC-USD-SWAP", Pair: BTC_USD, Kind: SWAP, ContractType: SWAP_CONTRACT, TickSize: MustParseDecimal("0.5"),
+			LotSize: MustParseDecimal("1"), MinAmount: MustParseDecimal("1"), Status: INSTRUMENT_TRADING},
+	}}
+	return NewOrderRules(NewInstrumentsCache(api, 0))
+}
+
+func TestOrderRules_Normalize(t *testing.T) {
+	rules := newTestOrderRules()
+
+	price, amount, err := rules.Normalize(BTC_USDT, "", BUY, "29316.427", "0.12399")
+	require.NoError(t, err)
+	assert.Equal(t, "29316.42", price)
+	assert.Equal(t, "0.123", amount)
+
+	price, _, err = rules.Normalize(BTC_USDT, "", SELL, "29316.421", "0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "29316.43", price)
+
+	price, _, err = rules.Normalize(BTC_USDT, "", SELL, "29316.42", "0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "29316.42", price)
+
+	_, _, err = rules.Normalize(BTC_USDT, "", BUY, "29316", "0.0009")
+	assert.True(t, errors.Is(err, EX_ERR_MIN_AMOUNT))
+
+	_, _, err = rules.Normalize(BTC_USDT, "", BUY, "9000", "0.001")
+	assert.True(t, errors.Is(err, EX_ERR_MIN_NOTIONAL))
+
+	_, _, err = rules.Normalize(BTC_USDT, "", BUY, "abc", "1")
+	assert.True(t, errors.Is(err, EX_ERR_INVALID_PARAMETER))
+
+	_, _, err = rules.Normalize(ETH_USDT, "", BUY, "1800", "1")
+	assert.True(t, errors.Is(err, EX_ERR_SYMBOL_ERR), "suspended")
+
+	_, _, err = rules.Normalize(LTC_USDT, "", BUY, "100", "1")
+	assert.True(t, errors.Is(err, EX_ERR_SYMBOL_ERR))
+
+	amount, err = rules.NormalizeAmount(BTC_USD, SWAP_CONTRACT, "12.7")
+	require.NoError(t, err)
+	assert.Equal(t, "12", amount)
+}
+
+type fakeOrderAPI struct {
+	API
+	calls         int
+	price, amount string
+}
+
+func (f *fakeOrderAPI) LimitBuy(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	f.calls++
+	f.price, f.amount = price, amount
+	return &Order{Currency: currency, Side: BUY}, nil
+}
+
+func TestValidatedAPI(t *testing.T) {
+	api := &fakeOrderAPI{}
+	validated := NewValidatedAPI(api, newTestOrderRules())
+
+	_, err := validated.LimitBuy("0.0001", "30000", BTC_USDT)
+	assert.True(t, errors.Is(err, EX_ERR_MIN_AMOUNT))
+	assert.Equal(t, 0, api.calls, "rejected before the request")
+
+	_, err = validated.LimitBuy("0.01", "30000.005", BTC_USDT)
+	require.NoError(t, err)
+	assert.Equal(t, 1, api.calls)
+	assert.Equal(t, "30000", api.price)
+	assert.Equal(t, "0.01", api.amount)
+}
+
+type fakeFutureOrderAPI struct {
+	FutureRestAPI
+	price, amount string
+}
+
+func (f *fakeFutureOrderAPI) PlaceFutureOrder(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64) (string, error) {
+	f.price, f.amount = price, amount
+	return "1", nil
+}
+
+func TestValidatedFutureRestAPI(t *testing.T) {
+	api := &fakeFutureOrderAPI{}
+	validated := NewValidatedFutureRestAPI(api, newTestOrderRules())
+
+	_, err := validated.PlaceFutureOrder(BTC_USD, SWAP_CONTRACT, "30000.3", "2", CLOSE_BUY, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "30000.5", api.price, "closing a long sells")
+	assert.Equal(t, "2", api.amount)
+
+	_, err = validated.PlaceFutureOrder(BTC_USD, SWAP_CONTRACT, "0", "3.9", OPEN_BUY, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "0", api.price)
+	assert.Equal(t, "3", api.amount)
+
+	_, err = validated.PlaceFutureOrder(BTC_USD, SWAP_CONTRACT, "30000", "0.5", OPEN_BUY, 0, 10)
+	assert.True(t, errors.Is(err, EX_ERR_MIN_AMOUNT))
+}
@@ -0,0 +1,266 @@
+package goex
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RateLimitPolicy int
+
+const (
+	RateLimitQueue    RateLimitPolicy = iota //wait until the request fits in the limits
+	RateLimitFailFast                        //return EX_ERR_API_LIMIT right away
+)
+
+// RateLimitRule is a fixed window limit, windows are aligned to the clock like the
+// binance ones (a 1 minute window starts at second 0).
+type RateLimitRule struct {
+	Name     string //REQUEST_WEIGHT, ORDERS ...
+	Interval time.Duration
+	Limit    int
+	// Method and Path restrict the limit to some endpoints. Path is matched segment by
+	// segment, a "*" segment matches any one segment (an order id ...) and a trailing
+	// "/" makes it a prefix: "/v1/" matches all the /v1 endpoints, "/api/spot/v3/orders"
+	// only that one.
+	Method string
+	Path   string
+	// Weight of a matching request, nil counts 1. A weight of 0 is not counted.
+	Weight func(req *http.Request) int
+	// UsedHeader is the response header telling the weight the exchange counted in
+	// the current window, e.g. X-Mbx-Used-Weight-1m. The local count follows it.
+	UsedHeader string
+}
+
+func (l *RateLimitRule) weight(req *http.Request) int {
+	if l.Method != "" && l.Method != req.Method {
+		return 0
+	}
+	if l.Path != "" && !matchPath(l.Path, req.URL.Path) {
+		return 0
+	}
+	if l.Weight == nil {
+		return 1
+	}
+	return l.Weight(req)
+}
+
+func matchPath(pattern, path string) bool {
+	want := strings.Split(strings.Trim(pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if strings.HasSuffix(pattern, "/") {
+		if want[0] == "" {
+			return true
+		}
+		if len(got) < len(want) {
+			return false
+		}
+	} else if len(got) != len(want) {
+		return false
+	}
+	for i, seg := range want {
+		if seg != "*" && seg != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type RateLimitConfig struct {
+	Limits []RateLimitRule
+	Policy RateLimitPolicy
+	// MaxWait makes the queue policy fail fast when the wait would be longer, 0 waits as long as needed.
+	MaxWait   time.Duration
+	Transport http.RoundTripper //default http.DefaultTransport
+}
+
+type rateWindow struct {
+	RateLimitRule
+	start time.Time
+	used  int
+}
+
+// roll starts a new window if the current one is over.
+func (w *rateWindow) roll(now time.Time) {
+	if start := now.Truncate(w.Interval); !start.Equal(w.start) {
+		w.start = start
+		w.used = 0
+	}
+}
+
+// RateLimiter is a http.RoundTripper keeping the requests of one exchange in its
+// limits. Install it on the http client of the adapter, see APIBuilder.RateLimit.
+// Responses with status 429 or 418 block all requests until their Retry-After.
+type RateLimiter struct {
+	sync.Mutex
+	transport    http.RoundTripper
+	policy       RateLimitPolicy
+	maxWait      time.Duration
+	windows      []*rateWindow
+	blockedUntil time.Time
+	clock        func() time.Time
+}
+
+func NewRateLimiter(conf *RateLimitConfig) *RateLimiter {
+	rl := &RateLimiter{
+		transport: conf.Transport,
+		policy:    conf.Policy,
+		maxWait:   conf.MaxWait,
+		clock:     time.Now,
+	}
+	if rl.transport == nil {
+		rl.transport = http.DefaultTransport
+	}
+	rl.SetLimits(conf.Limits)
+	return rl
+}
+
+// SetLimits replaces the limits, the weight used in the current windows is kept for
+// limits with the same name and interval.
+func (rl *RateLimiter) SetLimits(limits []RateLimitRule) {
+	rl.Lock()
+	defer rl.Unlock()
+
+	windows := make([]*rateWindow, 0, len(limits))
+	for _, l := range limits {
+		if l.Interval <= 0 || l.Limit <= 0 {
+			continue
+		}
+		w := &rateWindow{RateLimitRule: l}
+		for _, old := range rl.windows {
+			if old.Name == l.Name && old.Interval == l.Interval && old.Path == l.Path && old.Method == l.Method {
+				w.start, w.used = old.start, old.used
+			}
+		}
+		windows = append(windows, w)
+	}
+	rl.windows = windows
+}
+
+// Used returns the weight counted in the current window of the named limit.
+func (rl *RateLimiter) Used(name string, interval time.Duration) int {
+	rl.Lock()
+	defer rl.Unlock()
+	now := rl.clock()
+	for _, w := range rl.windows {
+		if w.Name == name && w.Interval == interval {
+			w.roll(now)
+			return w.used
+		}
+	}
+	return 0
+}
+
+// Unwrap is the transport sending the requests in the limits.
+func (rl *RateLimiter) Unwrap() http.RoundTripper {
+	return rl.transport
+}
+
+func (rl *RateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	for {
+		wait, reason := rl.reserve(req)
+		if wait <= 0 {
+			break
+		}
+		if rl.policy == RateLimitFailFast || (rl.maxWait > 0 && wait > rl.maxWait) {
+			return nil, EX_ERR_API_LIMIT.OriginErr(fmt.Sprintf("rate limit %s, retry after %s", reason, wait))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	resp, err := rl.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	rl.update(resp)
+	return resp, nil
+}
+
+// reserve counts the request in all its windows if it fits, else returns how long to wait.
+func (rl *RateLimiter) reserve(req *http.Request) (time.Duration, string) {
+	rl.Lock()
+	defer rl.Unlock()
+
+	now := rl.clock()
+	if now.Before(rl.blockedUntil) {
+		return rl.blockedUntil.Sub(now), "blocked by the exchange"
+	}
+
+	var (
+		wait   time.Duration
+		reason string
+	)
+	weights := make([]int, len(rl.windows))
+	for i, w := range rl.windows {
+		w.roll(now)
+		weights[i] = w.weight(req)
+		//a request heavier than the limit goes alone in a new window
+		if weights[i] > 0 && w.used+weights[i] > w.Limit && w.used > 0 {
+			if d := w.start.Add(w.Interval).Sub(now); d > wait {
+				wait, reason = d, fmt.Sprintf("%s %d/%s", w.Name, w.Limit, w.Interval)
+			}
+		}
+	}
+	if wait > 0 {
+		return wait, reason
+	}
+
+	for i, w := range rl.windows {
+		w.used += weights[i]
+	}
+	return 0, ""
+}
+
+func (rl *RateLimiter) update(resp *http.Response) {
+	rl.Lock()
+	defer rl.Unlock()
+
+	now := rl.clock()
+	for _, w := range rl.windows {
+		if w.UsedHeader == "" {
+			continue
+		}
+		if used, err := strconv.Atoi(resp.Header.Get(w.UsedHeader)); err == nil {
+			w.roll(now)
+			w.used = used
+		}
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusTeapot {
+		return
+	}
+	until := now.Add(rl.retryAfter(resp.Header.Get("Retry-After"), now))
+	if until.After(rl.blockedUntil) {
+		rl.blockedUntil = until
+	}
+}
+
+// retryAfter parses the Retry-After header, seconds or a http date. Without the
+// header the requests wait for the shortest window to end.
+func (rl *RateLimiter) retryAfter(header string, now time.Time) time.Duration {
+	if secs, err := strconv.Atoi(header); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return t.Sub(now)
+	}
+
+	wait := time.Second
+	for i, w := range rl.windows {
+		w.roll(now)
+		if d := w.start.Add(w.Interval).Sub(now); i == 0 || d < wait {
+			wait = d
+		}
+	}
+	return wait
+}
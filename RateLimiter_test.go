@@ -0,0 +1,215 @@
+package goex
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.Lock()
+	c.now = c.now.Add(d)
+	c.Unlock()
+}
+
+func newTestRateLimiter(conf *RateLimitConfig) (*RateLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	rl := NewRateLimiter(conf)
+	rl.clock = clock.Now
+	return rl, clock
+}
+
+func TestRateLimiter_FailFast(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	rl, clock := newTestRateLimiter(&RateLimitConfig{
+		Limits: []RateLimitRule{{Name: "REQUEST_WEIGHT", Interval: time.Minute, Limit: 3}},
+		Policy: RateLimitFailFast,
+	})
+	client := &http.Client{Transport: rl}
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(ts.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	_, err := client.Get(ts.URL)
+	assert.True(t, errors.Is(err, EX_ERR_API_LIMIT))
+	assert.Equal(t, 3, rl.Used("REQUEST_WEIGHT", time.Minute))
+
+	clock.Add(time.Minute)
+	resp, err := client.Get(ts.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 1, rl.Used("REQUEST_WEIGHT", time.Minute))
+}
+
+func TestRateLimiter_Queue(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	rl := NewRateLimiter(&RateLimitConfig{
+		Limits: []RateLimitRule{{Name: "ORDERS", Interval: 100 * time.Millisecond, Limit: 2}},
+	})
+	client := &http.Client{Transport: rl}
+
+	begin := time.Now()
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(ts.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.True(t, time.Since(begin) >= 100*time.Millisecond, "5 requests at 2/100ms span at least 2 windows")
+
+	rl = NewRateLimiter(&RateLimitConfig{
+		Limits:  []RateLimitRule{{Name: "ORDERS", Interval: time.Hour, Limit: 1}},
+		MaxWait: 10 * time.Millisecond,
+	})
+	client = &http.Client{Transport: rl}
+	resp, err := client.Get(ts.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	_, err = client.Get(ts.URL)
+	assert.True(t, errors.Is(err, EX_ERR_API_LIMIT), "the wait is longer than MaxWait")
+}
+
+func TestRateLimiter_Weight(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	rl, _ := newTestRateLimiter(&RateLimitConfig{
+		Limits: []RateLimitRule{
+			{Name: "REQUEST_WEIGHT", Interval: time.Minute, Limit: 10, Weight: func(req *http.Request) int { return ToInt(req.URL.Query().Get("w")) }},
+			{Name: "ORDERS", Interval: time.Minute, Limit: 10, Method: http.MethodPost, Path: "/order"},
+		},
+		Policy: RateLimitFailFast,
+	})
+	client := &http.Client{Transport: rl}
+
+	resp, err := client.Get(ts.URL + "/depth?w=5")
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = client.Post(ts.URL+"/order?w=1", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 6, rl.Used("REQUEST_WEIGHT", time.Minute))
+	assert.Equal(t, 1, rl.Used("ORDERS", time.Minute))
+
+	//heavier than the limit, allowed alone in a new window only
+	_, err = client.Get(ts.URL + "/depth?w=50")
+	assert.True(t, errors.Is(err, EX_ERR_API_LIMIT))
+}
+
+func TestRateLimiter_UsedHeader(t *testing.T) {
+	used := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		used += 10
+		w.Header().Set("X-Mbx-Used-Weight-1m", strconv.Itoa(used))
+	}))
+	defer ts.Close()
+
+	rl, _ := newTestRateLimiter(&RateLimitConfig{
+		Limits: []RateLimitRule{{Name: "REQUEST_WEIGHT", Interval: time.Minute, Limit: 20, UsedHeader: "X-Mbx-Used-Weight-1m"}},
+		Policy: RateLimitFailFast,
+	})
+	client := &http.Client{Transport: rl}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(ts.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, 20, rl.Used("REQUEST_WEIGHT", time.Minute))
+	_, err := client.Get(ts.URL)
+	assert.True(t, errors.Is(err, EX_ERR_API_LIMIT))
+}
+
+func TestRateLimiter_RetryAfter(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	rl, clock := newTestRateLimiter(&RateLimitConfig{
+		Limits: []RateLimitRule{{Name: "REQUEST_WEIGHT", Interval: time.Minute, Limit: 1000}},
+		Policy: RateLimitFailFast,
+	})
+	client := &http.Client{Transport: rl}
+
+	resp, err := client.Get(ts.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	clock.Add(29 * time.Second)
+	_, err = client.Get(ts.URL)
+	assert.True(t, errors.Is(err, EX_ERR_API_LIMIT))
+
+	clock.Add(time.Second)
+	resp, err = client.Get(ts.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestRateLimiter_SetLimits(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	rl, _ := newTestRateLimiter(&RateLimitConfig{
+		Limits: []RateLimitRule{{Name: "REQUEST_WEIGHT", Interval: time.Minute, Limit: 1200}},
+	})
+	client := &http.Client{Transport: rl}
+	resp, err := client.Get(ts.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	rl.SetLimits([]RateLimitRule{
+		{Name: "REQUEST_WEIGHT", Interval: time.Minute, Limit: 2400},
+		{Name: "ORDERS", Interval: 10 * time.Second, Limit: 50},
+	})
+	assert.Equal(t, 1, rl.Used("REQUEST_WEIGHT", time.Minute))
+	assert.Equal(t, 0, rl.Used("ORDERS", 10*time.Second))
+}
+
+func TestRateLimitRule_Path(t *testing.T) {
+	for _, c := range []struct {
+		pattern, path string
+		match         bool
+	}{
+		{"/api/spot/v3/orders", "/api/spot/v3/orders", true},
+		{"/api/spot/v3/orders", "/api/spot/v3/orders_pending", false},
+		{"/api/spot/v3/orders", "/api/spot/v3/orders/123", false},
+		{"/api/spot/v3/orders/*", "/api/spot/v3/orders/123", true},
+		{"/api/spot/v3/instruments", "/api/spot/v3/instruments/BTC-USDT/ticker", false},
+		{"/api/spot/v3/instruments/*/ticker", "/api/spot/v3/instruments/BTC-USDT/ticker", true},
+		{"/api/spot/v3/instruments/*/ticker", "/api/spot/v3/instruments/BTC-USDT/book", false},
+		{"/api/futures/v3/order", "/api/futures/v3/orders", false},
+		{"/v1/", "/v1/order/orders/place", true},
+		{"/v1/", "/v10/order", false},
+		{"/", "/market/depth", true},
+	} {
+		rule := RateLimitRule{Path: c.pattern}
+		req, _ := http.NewRequest(http.MethodGet, "https://www.okex.com"+c.path+"?limit=100", nil)
+		assert.Equal(t, c.match, rule.weight(req) == 1, c.pattern+" "+c.path)
+	}
+}
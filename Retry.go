@@ -0,0 +1,476 @@
+package goex
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/soulsplit/goex/internal/logger"
+)
+
+// RetryAttempt is passed to RetryPolicy.OnAttempt after every call.
+type RetryAttempt struct {
+	Method  string
+	Attempt int   //1 for the first call
+	Err     error //nil if the call succeeded
+	Retry   bool  //whether the call will be retried
+	Delay   time.Duration
+}
+
+// RetryPolicy retries the calls failing with a retryable error, waiting an exponential
+// backoff (BaseDelay, 2*BaseDelay, 4*BaseDelay ... up to MaxDelay) between attempts.
+type RetryPolicy struct {
+	MaxAttempts int //including the first call, default 3
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Jitter takes up to this fraction off each delay at random, 0 to 1, so clients
+	// failing together do not retry together.
+	Jitter float64
+	// Retryable classifies the errors, default Retryable.
+	Retryable func(err error, idempotent bool) bool
+	// OnAttempt is called after every call, e.g. LogRetryAttempt.
+	OnAttempt func(attempt RetryAttempt)
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// DefaultRetryPolicy makes 3 attempts with a 200ms to 5s jittered backoff and logs the failures.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      0.5,
+		OnAttempt:   LogRetryAttempt,
+	}
+}
+
+func LogRetryAttempt(a RetryAttempt) {
+	switch {
+	case a.Err == nil:
+		if a.Attempt > 1 {
+			logger.Infof("[retry] %s succeeded at attempt %d", a.Method, a.Attempt)
+		}
+	case a.Retry:
+		logger.Warnf("[retry] %s attempt %d failed: %v, retry in %s", a.Method, a.Attempt, a.Err, a.Delay)
+	default:
+		logger.Errorf("[retry] %s attempt %d failed: %v", a.Method, a.Attempt, a.Err)
+	}
+}
+
+// Retryable is the default classification of RetryPolicy. Rate limits, maintenance and
+// connection failures are retried as the exchange did not process the request. Server
+// errors, timeouts and broken connections leave the request in an unknown state, they
+// are retried only for idempotent calls. Any other ApiError kind (insufficient balance,
+// auth, invalid parameter ...) fails again on retry.
+func Retryable(err error, idempotent bool) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, EX_ERR_API_LIMIT) || errors.Is(err, EX_ERR_MAINTENANCE) {
+		return true
+	}
+	if errors.Is(err, EX_ERR_SERVER) {
+		return idempotent
+	}
+	var apiErr ApiError
+	if errors.As(err, &apiErr) {
+		return false
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return idempotent
+	}
+	return false
+}
+
+// Do calls call until it succeeds, fails with an error not retryable or the attempts
+// run out, and returns the last error.
+func (p *RetryPolicy) Do(ctx context.Context, method string, idempotent bool, call func() error) error {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	return p.do(ctx, method, idempotent, maxAttempts, call)
+}
+
+// Place calls an order placement. Without a client order id the exchange cannot tell a
+// retry from a new order, goex generates a new one on each call, so the order is placed
+// once. With one the exchange rejects a second order under the same id, the placement is
+// retried like an idempotent call.
+func (p *RetryPolicy) Place(ctx context.Context, method, clientOid string, call func() error) error {
+	if clientOid == "" {
+		return p.do(ctx, method, false, 1, call)
+	}
+	return p.Do(ctx, method, true, call)
+}
+
+func (p *RetryPolicy) do(ctx context.Context, method string, idempotent bool, maxAttempts int, call func() error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = Retryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := call()
+		a := RetryAttempt{Method: method, Attempt: attempt, Err: err}
+		a.Retry = err != nil && attempt < maxAttempts && ctx.Err() == nil && retryable(err, idempotent)
+		if a.Retry {
+			a.Delay = p.backoff(attempt)
+		}
+		if p.OnAttempt != nil {
+			p.OnAttempt(a)
+		}
+		if !a.Retry {
+			return err
+		}
+		if sleepErr := p.wait(ctx, a.Delay); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// backoff is the delay after the given failed attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(float64(d) * p.Jitter * rand.Float64())
+	}
+	return d
+}
+
+func (p *RetryPolicy) wait(ctx context.Context, d time.Duration) error {
+	if p.sleep != nil {
+		return p.sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RetryAPI retries the calls of an API with a RetryPolicy.
+type RetryAPI struct {
+	API
+	policy *RetryPolicy
+}
+
+func NewRetryAPI(api API, policy *RetryPolicy) *RetryAPI {
+	if policy == nil {
+		policy = DefaultRetryPolicy()
+	}
+	return &RetryAPI{API: api, policy: policy}
+}
+
+func (r *RetryAPI) do(method string, idempotent bool, call func() error) error {
+	return r.policy.Do(context.Background(), r.API.GetExchangeName()+"."+method, idempotent, call)
+}
+
+// place makes the order placements once, the API does not take a client order id.
+func (r *RetryAPI) place(method string, call func() error) error {
+	return r.policy.Place(context.Background(), r.API.GetExchangeName()+"."+method, "", call)
+}
+
+func (r *RetryAPI) LimitBuy(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (ord *Order, err error) {
+	err = r.place("LimitBuy", func() error {
+		ord, err = r.API.LimitBuy(amount, price, currency, opt...)
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) LimitSell(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (ord *Order, err error) {
+	err = r.place("LimitSell", func() error {
+		ord, err = r.API.LimitSell(amount, price, currency, opt...)
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) MarketBuy(amount, price string, currency CurrencyPair) (ord *Order, err error) {
+	err = r.place("MarketBuy", func() error {
+		ord, err = r.API.MarketBuy(amount, price, currency)
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) MarketSell(amount, price string, currency CurrencyPair) (ord *Order, err error) {
+	err = r.place("MarketSell", func() error {
+		ord, err = r.API.MarketSell(amount, price, currency)
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) CancelOrder(orderId string, currency CurrencyPair) (ok bool, err error) {
+	err = r.do("CancelOrder", true, func() error {
+		ok, err = r.API.CancelOrder(orderId, currency)
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) GetOneOrder(orderId string, currency CurrencyPair) (ord *Order, err error) {
+	err = r.do("GetOneOrder", true, func() error {
+		ord, err = r.API.GetOneOrder(orderId, currency)
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) GetUnfinishOrders(currency CurrencyPair) (orders []Order, err error) {
+	err = r.do("GetUnfinishOrders", true, func() error {
+		orders, err = r.API.GetUnfinishOrders(currency)
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) GetOrderHistorys(currency CurrencyPair, opt ...OptionalParameter) (orders []Order, err error) {
+	err = r.do("GetOrderHistorys", true, func() error {
+		orders, err = r.API.GetOrderHistorys(currency, opt...)
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) GetTradeHistory(currency CurrencyPair, opt ...OptionalParameter) (trades []Trade, err error) {
+	err = r.do("GetTradeHistory", true, func() error {
+		trades, err = r.API.GetTradeHistory(currency, opt...)
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) GetAccount() (acc *Account, err error) {
+	err = r.do("GetAccount", true, func() error {
+		acc, err = r.API.GetAccount()
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) GetTicker(currency CurrencyPair) (ticker *Ticker, err error) {
+	err = r.do("GetTicker", true, func() error {
+		ticker, err = r.API.GetTicker(currency)
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) GetDepth(size int, currency CurrencyPair) (dep *Depth, err error) {
+	err = r.do("GetDepth", true, func() error {
+		dep, err = r.API.GetDepth(size, currency)
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) (klines []Kline, err error) {
+	err = r.do("GetKlineRecords", true, func() error {
+		klines, err = r.API.GetKlineRecords(currency, period, size, optional...)
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) GetTrades(currencyPair CurrencyPair, since int64) (trades []Trade, err error) {
+	err = r.do("GetTrades", true, func() error {
+		trades, err = r.API.GetTrades(currencyPair, since)
+		return err
+	})
+	return
+}
+
+func (r *RetryAPI) GetAssets(currencyPair CurrencyPair) (assets *Assets, err error) {
+	err = r.do("GetAssets", true, func() error {
+		assets, err = r.API.GetAssets(currencyPair)
+		return err
+	})
+	return
+}
+
+// RetryFutureRestAPI retries the calls of a FutureRestAPI with a RetryPolicy.
+type RetryFutureRestAPI struct {
+	FutureRestAPI
+	policy *RetryPolicy
+}
+
+func NewRetryFutureRestAPI(api FutureRestAPI, policy *RetryPolicy) *RetryFutureRestAPI {
+	if policy == nil {
+		policy = DefaultRetryPolicy()
+	}
+	return &RetryFutureRestAPI{FutureRestAPI: api, policy: policy}
+}
+
+func (r *RetryFutureRestAPI) do(method string, idempotent bool, call func() error) error {
+	return r.policy.Do(context.Background(), r.FutureRestAPI.GetExchangeName()+"."+method, idempotent, call)
+}
+
+// place makes the order placements once, the FutureRestAPI does not take a client order id.
+func (r *RetryFutureRestAPI) place(method string, call func() error) error {
+	return r.policy.Place(context.Background(), r.FutureRestAPI.GetExchangeName()+"."+method, "", call)
+}
+
+func (r *RetryFutureRestAPI) GetFutureEstimatedPrice(currencyPair CurrencyPair) (price float64, err error) {
+	err = r.do("GetFutureEstimatedPrice", true, func() error {
+		price, err = r.FutureRestAPI.GetFutureEstimatedPrice(currencyPair)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) GetFutureTicker(currencyPair CurrencyPair, contractType string) (ticker *Ticker, err error) {
+	err = r.do("GetFutureTicker", true, func() error {
+		ticker, err = r.FutureRestAPI.GetFutureTicker(currencyPair, contractType)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) GetFutureDepth(currencyPair CurrencyPair, contractType string, size int) (dep *Depth, err error) {
+	err = r.do("GetFutureDepth", true, func() error {
+		dep, err = r.FutureRestAPI.GetFutureDepth(currencyPair, contractType, size)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) GetFutureIndex(currencyPair CurrencyPair) (index float64, err error) {
+	err = r.do("GetFutureIndex", true, func() error {
+		index, err = r.FutureRestAPI.GetFutureIndex(currencyPair)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) GetFutureUserinfo(currencyPair ...CurrencyPair) (acc *FutureAccount, err error) {
+	err = r.do("GetFutureUserinfo", true, func() error {
+		acc, err = r.FutureRestAPI.GetFutureUserinfo(currencyPair...)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) PlaceFutureOrder(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64) (orderId string, err error) {
+	err = r.place("PlaceFutureOrder", func() error {
+		orderId, err = r.FutureRestAPI.PlaceFutureOrder(currencyPair, contractType, price, amount, openType, matchPrice, leverRate)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) LimitFuturesOrder(currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (ord *FutureOrder, err error) {
+	err = r.place("LimitFuturesOrder", func() error {
+		ord, err = r.FutureRestAPI.LimitFuturesOrder(currencyPair, contractType, price, amount, openType, opt...)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) MarketFuturesOrder(currencyPair CurrencyPair, contractType, amount string, openType int) (ord *FutureOrder, err error) {
+	err = r.place("MarketFuturesOrder", func() error {
+		ord, err = r.FutureRestAPI.MarketFuturesOrder(currencyPair, contractType, amount, openType)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) FutureCancelOrder(currencyPair CurrencyPair, contractType, orderId string) (ok bool, err error) {
+	err = r.do("FutureCancelOrder", true, func() error {
+		ok, err = r.FutureRestAPI.FutureCancelOrder(currencyPair, contractType, orderId)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) GetFuturePosition(currencyPair CurrencyPair, contractType string) (positions []FuturePosition, err error) {
+	err = r.do("GetFuturePosition", true, func() error {
+		positions, err = r.FutureRestAPI.GetFuturePosition(currencyPair, contractType)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, contractType string) (orders []FutureOrder, err error) {
+	err = r.do("GetFutureOrders", true, func() error {
+		orders, err = r.FutureRestAPI.GetFutureOrders(orderIds, currencyPair, contractType)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) GetFutureOrder(orderId string, currencyPair CurrencyPair, contractType string) (ord *FutureOrder, err error) {
+	err = r.do("GetFutureOrder", true, func() error {
+		ord, err = r.FutureRestAPI.GetFutureOrder(orderId, currencyPair, contractType)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType string) (orders []FutureOrder, err error) {
+	err = r.do("GetUnfinishFutureOrders", true, func() error {
+		orders, err = r.FutureRestAPI.GetUnfinishFutureOrders(currencyPair, contractType)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) GetFutureOrderHistory(pair CurrencyPair, contractType string, optional ...OptionalParameter) (orders []FutureOrder, err error) {
+	err = r.do("GetFutureOrderHistory", true, func() error {
+		orders, err = r.FutureRestAPI.GetFutureOrderHistory(pair, contractType, optional...)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) GetFee() (fee float64, err error) {
+	err = r.do("GetFee", true, func() error {
+		fee, err = r.FutureRestAPI.GetFee()
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) GetContractValue(currencyPair CurrencyPair) (value float64, err error) {
+	err = r.do("GetContractValue", true, func() error {
+		value, err = r.FutureRestAPI.GetContractValue(currencyPair)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) GetKlineRecords(contractType string, currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) (klines []FutureKline, err error) {
+	err = r.do("GetKlineRecords", true, func() error {
+		klines, err = r.FutureRestAPI.GetKlineRecords(contractType, currency, period, size, optional...)
+		return err
+	})
+	return
+}
+
+func (r *RetryFutureRestAPI) GetTrades(contractType string, currencyPair CurrencyPair, since int64) (trades []Trade, err error) {
+	err = r.do("GetTrades", true, func() error {
+		trades, err = r.FutureRestAPI.GetTrades(contractType, currencyPair, since)
+		return err
+	})
+	return
+}
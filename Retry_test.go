@@ -0,0 +1,186 @@
+package goex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryable(t *testing.T) {
+	dialErr := &url.Error{Op: "Get", URL: "https://api.binance.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	readErr := &url.Error{Op: "Get", URL: "https://api.binance.com", Err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")}}
+
+	for _, c := range []struct {
+		err                error
+		idempotent, placed bool
+	}{
+		{EX_ERR_API_LIMIT.OriginErr("too many requests"), true, true},
+		{&url.Error{Op: "Get", Err: EX_ERR_API_LIMIT}, true, true},
+		{EX_ERR_MAINTENANCE, true, true},
+		{HttpStatusError(502, "bad gateway"), true, false},
+		{dialErr, true, true},
+		{readErr, true, false},
+		{fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), true, false},
+		{EX_ERR_INSUFFICIENT_BALANCE, false, false},
+		{EX_ERR_NOT_FIND_ORDER, false, false},
+		{HttpStatusError(400, "bad request"), false, false},
+		{errors.New("json: cannot unmarshal"), false, false},
+	} {
+		assert.Equal(t, c.idempotent, Retryable(c.err, true), "idempotent %v", c.err)
+		assert.Equal(t, c.placed, Retryable(c.err, false), "order placement %v", c.err)
+	}
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	var (
+		attempts []RetryAttempt
+		delays   []time.Duration
+	)
+	p := &RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    250 * time.Millisecond,
+		OnAttempt:   func(a RetryAttempt) { attempts = append(attempts, a) },
+		sleep: func(ctx context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return nil
+		},
+	}
+
+	calls := 0
+	err := p.Do(context.Background(), "GetTicker", true, func() error {
+		calls++
+		return EX_ERR_SERVER
+	})
+	assert.True(t, errors.Is(err, EX_ERR_SERVER))
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 250 * time.Millisecond}, delays)
+	require.Len(t, attempts, 4)
+	assert.True(t, attempts[2].Retry)
+	assert.False(t, attempts[3].Retry)
+	assert.Equal(t, 4, attempts[3].Attempt)
+
+	calls = 0
+	err = p.Do(context.Background(), "LimitBuy", false, func() error {
+		calls++
+		return EX_ERR_INSUFFICIENT_BALANCE
+	})
+	assert.True(t, errors.Is(err, EX_ERR_INSUFFICIENT_BALANCE))
+	assert.Equal(t, 1, calls, "insufficient balance is not retried")
+
+	calls = 0
+	err = p.Do(context.Background(), "GetDepth", true, func() error {
+		if calls++; calls < 3 {
+			return EX_ERR_API_LIMIT
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryPolicy_Jitter(t *testing.T) {
+	p := &RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		d := p.backoff(3)
+		assert.True(t, d > 2*time.Second && d <= 4*time.Second, "%s", d)
+	}
+}
+
+func TestRetryPolicy_Context(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour}
+
+	calls := 0
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	err := p.Do(ctx, "GetTicker", true, func() error {
+		calls++
+		return EX_ERR_SERVER
+	})
+	assert.True(t, errors.Is(err, EX_ERR_SERVER))
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicy_Place(t *testing.T) {
+	p := &RetryPolicy{MaxAttempts: 3, sleep: func(ctx context.Context, d time.Duration) error { return nil }}
+
+	calls := 0
+	err := p.Place(context.Background(), "LimitBuy", "", func() error {
+		calls++
+		return EX_ERR_API_LIMIT
+	})
+	assert.True(t, errors.Is(err, EX_ERR_API_LIMIT))
+	assert.Equal(t, 1, calls, "no client order id, a retry may place a second order")
+
+	calls = 0
+	err = p.Place(context.Background(), "LimitBuy", "goex123", func() error {
+		calls++
+		if calls < 3 {
+			return HttpStatusError(504, "gateway timeout")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = p.Place(context.Background(), "LimitBuy", "goex123", func() error {
+		calls++
+		return EX_ERR_INSUFFICIENT_BALANCE
+	})
+	assert.True(t, errors.Is(err, EX_ERR_INSUFFICIENT_BALANCE))
+	assert.Equal(t, 1, calls)
+}
+
+type flakyAPI struct {
+	API
+	tickerErrs []error
+	buyCalls   int
+}
+
+func (f *flakyAPI) GetExchangeName() string { return "flaky" }
+
+func (f *flakyAPI) GetTicker(currency CurrencyPair) (*Ticker, error) {
+	if len(f.tickerErrs) > 0 {
+		err := f.tickerErrs[0]
+		f.tickerErrs = f.tickerErrs[1:]
+		return nil, err
+	}
+	return &Ticker{Pair: currency, Last: 1}, nil
+}
+
+func (f *flakyAPI) LimitBuy(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	f.buyCalls++
+	return nil, HttpStatusError(504, "gateway timeout")
+}
+
+func TestRetryAPI(t *testing.T) {
+	var methods []string
+	policy := &RetryPolicy{
+		MaxAttempts: 3,
+		OnAttempt:   func(a RetryAttempt) { methods = append(methods, a.Method) },
+		sleep:       func(ctx context.Context, d time.Duration) error { return nil },
+	}
+	api := &flakyAPI{tickerErrs: []error{EX_ERR_SERVER, EX_ERR_API_LIMIT}}
+	var _ API = NewRetryAPI(api, policy)
+
+	ticker, err := NewRetryAPI(api, policy).GetTicker(BTC_USDT)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, ticker.Last)
+	assert.Equal(t, []string{"flaky.GetTicker", "flaky.GetTicker", "flaky.GetTicker"}, methods)
+
+	_, err = NewRetryAPI(api, policy).LimitBuy("1", "1", BTC_USDT)
+	assert.True(t, errors.Is(err, EX_ERR_SERVER))
+	assert.Equal(t, 1, api.buyCalls, "the order may have been placed")
+}
@@ -0,0 +1,190 @@
+package goex
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// currencyAliases are the other names of a currency on some exchanges.
+var currencyAliases = map[string]Currency{
+	"XBT": BTC,
+	"BCC": BCH,
+}
+
+var currencyAliasesLock sync.RWMutex
+
+// RegisterCurrencyAlias makes alias another name of c in CanonicalCurrency.
+func RegisterCurrencyAlias(alias string, c Currency) {
+	currencyAliasesLock.Lock()
+	defer currencyAliasesLock.Unlock()
+	currencyAliases[strings.ToUpper(alias)] = c
+}
+
+// CanonicalCurrency returns the currency goex uses for c, BTC for XBT and BCH for BCC.
+func CanonicalCurrency(c Currency) Currency {
+	currencyAliasesLock.RLock()
+	defer currencyAliasesLock.RUnlock()
+	if canonical, ok := currencyAliases[strings.ToUpper(c.Symbol)]; ok {
+		return canonical
+	}
+	return c
+}
+
+func CanonicalPair(pair CurrencyPair) CurrencyPair {
+	pair.CurrencyA = CanonicalCurrency(pair.CurrencyA)
+	pair.CurrencyB = CanonicalCurrency(pair.CurrencyB)
+	return pair
+}
+
+type symbolEntry struct {
+	symbol       string
+	pair         CurrencyPair
+	contractType string
+}
+
+type exchangeSymbols struct {
+	bySymbol map[string]symbolEntry //upper case native symbol
+	byPair   map[string]string      //pair and contract type to native symbol
+}
+
+// SymbolRegistry maps the native symbols of the exchanges to CurrencyPair and back.
+// The pairs are canonical, so XBTUSD of bitmex and XXBTZUSD of kraken are BTC_USD.
+type SymbolRegistry struct {
+	sync.RWMutex
+	exchanges map[string]*exchangeSymbols
+}
+
+// Symbols is the registry used by the adapters. The instruments loaded by an
+// InstrumentsCache are registered in it, and the listings of binance, huobi spot,
+// bitmex, kraken and bitfinex register themselves when they are fetched.
+var Symbols = NewSymbolRegistry()
+
+func NewSymbolRegistry() *SymbolRegistry {
+	return &SymbolRegistry{exchanges: make(map[string]*exchangeSymbols)}
+}
+
+func symbolPairKey(pair CurrencyPair, contractType string) string {
+	return CanonicalPair(pair).String() + "|" + contractType
+}
+
+func (r *SymbolRegistry) exchange(exName string) *exchangeSymbols {
+	ex, ok := r.exchanges[exName]
+	if !ok {
+		ex = &exchangeSymbols{bySymbol: make(map[string]symbolEntry), byPair: make(map[string]string)}
+		r.exchanges[exName] = ex
+	}
+	return ex
+}
+
+// Register maps the native symbol of exName to pair and contractType, empty for spot.
+func (r *SymbolRegistry) Register(exName, symbol string, pair CurrencyPair, contractType string) {
+	r.Lock()
+	defer r.Unlock()
+	r.register(r.exchange(exName), symbol, pair, contractType)
+}
+
+func (r *SymbolRegistry) register(ex *exchangeSymbols, symbol string, pair CurrencyPair, contractType string) {
+	pair = CanonicalPair(pair)
+	ex.bySymbol[strings.ToUpper(symbol)] = symbolEntry{symbol: symbol, pair: pair, contractType: contractType}
+	ex.byPair[symbolPairKey(pair, contractType)] = symbol
+}
+
+// RegisterInstruments maps the ids of the instruments of exName.
+func (r *SymbolRegistry) RegisterInstruments(exName string, instruments []InstrumentInfo) {
+	r.Lock()
+	defer r.Unlock()
+	ex := r.exchange(exName)
+	for _, ins := range instruments {
+		r.register(ex, ins.Id, ins.Pair, ins.ContractType)
+	}
+}
+
+// Load registers the instruments listed by api.
+func (r *SymbolRegistry) Load(api InstrumentsAPI) error {
+	instruments, err := api.GetInstrumentsInfo()
+	if err != nil {
+		return err
+	}
+	r.RegisterInstruments(api.GetExchangeName(), instruments)
+	return nil
+}
+
+// Reset drops the symbols of exNames, of all the exchanges if none is given. The
+// adapters guess the symbols again until they are loaded, tests loading instruments
+// reset the registry when they are done.
+func (r *SymbolRegistry) Reset(exNames ...string) {
+	r.Lock()
+	defer r.Unlock()
+	if len(exNames) == 0 {
+		r.exchanges = make(map[string]*exchangeSymbols)
+		return
+	}
+	for _, exName := range exNames {
+		delete(r.exchanges, exName)
+	}
+}
+
+// Loaded tells if symbols of exName are registered.
+func (r *SymbolRegistry) Loaded(exName string) bool {
+	r.RLock()
+	defer r.RUnlock()
+	ex, ok := r.exchanges[exName]
+	return ok && len(ex.bySymbol) > 0
+}
+
+// Pair returns the pair and the contract type of a native symbol, the case is
+// ignored. It returns EX_ERR_SYMBOL_ERR if the symbol is unknown.
+func (r *SymbolRegistry) Pair(exName, symbol string) (CurrencyPair, string, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if ex, ok := r.exchanges[exName]; ok {
+		if e, ok := ex.bySymbol[strings.ToUpper(symbol)]; ok {
+			return e.pair, e.contractType, nil
+		}
+	}
+	return UNKNOWN_PAIR, "", EX_ERR_SYMBOL_ERR.OriginErr(fmt.Sprintf("%s unknown symbol %s", exName, symbol))
+}
+
+// Symbol returns the native symbol of pair and contractType, the pair may use an
+// alias. A contract quoted in USDT is found by its USD pair and the other way
+// round, as the futures APIs take BTC_USD for both.
+func (r *SymbolRegistry) Symbol(exName string, pair CurrencyPair, contractType string) (string, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if ex, ok := r.exchanges[exName]; ok {
+		if symbol, ok := ex.byPair[symbolPairKey(pair, contractType)]; ok {
+			return symbol, nil
+		}
+		if contractType != "" {
+			for _, p := range []CurrencyPair{pair.AdaptUsdToUsdt(), pair.AdaptUsdtToUsd()} {
+				if symbol, ok := ex.byPair[symbolPairKey(p, contractType)]; ok {
+					return symbol, nil
+				}
+			}
+		}
+	}
+	return "", EX_ERR_SYMBOL_ERR.OriginErr(fmt.Sprintf("%s has no symbol for %s %s", exName, pair, contractType))
+}
+
+// Resolve is Pair for the parsers of the adapters. Until the symbols of exName are
+// loaded it falls back to guess, which returns UNKNOWN_PAIR if it can't tell.
+func (r *SymbolRegistry) Resolve(exName, symbol string, guess func(symbol string) CurrencyPair) (CurrencyPair, error) {
+	if r.Loaded(exName) || guess == nil {
+		pair, _, err := r.Pair(exName, symbol)
+		return pair, err
+	}
+	pair := guess(symbol)
+	if pair.Eq(UNKNOWN_PAIR) || pair.CurrencyA.Symbol == "" || pair.CurrencyB.Symbol == "" {
+		return UNKNOWN_PAIR, EX_ERR_SYMBOL_ERR.OriginErr(fmt.Sprintf("%s unknown symbol %s", exName, symbol))
+	}
+	return CanonicalPair(pair), nil
+}
+
+// ResolveSymbol is Symbol falling back to guess until the symbols of exName are loaded.
+func (r *SymbolRegistry) ResolveSymbol(exName string, pair CurrencyPair, contractType string, guess func(pair CurrencyPair, contractType string) string) (string, error) {
+	if r.Loaded(exName) || guess == nil {
+		return r.Symbol(exName, pair, contractType)
+	}
+	return guess(pair, contractType), nil
+}
@@ -0,0 +1,106 @@
+package goex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalPair(t *testing.T) {
+	assert.Equal(t, BTC_USD.String(), CanonicalPair(NewCurrencyPair(XBT, USD)).String())
+	assert.Equal(t, BCH_USDT.String(), CanonicalPair(NewCurrencyPair2("bcc_usdt")).String())
+	assert.Equal(t, ETH_BTC.String(), CanonicalPair(ETH_BTC).String())
+}
+
+func TestSymbolRegistry(t *testing.T) {
+	r := NewSymbolRegistry()
+	assert.False(t, r.Loaded(KRAKEN))
+
+	r.Register(KRAKEN, "XXBTZUSD", NewCurrencyPair(XBT, USD), "")
+	r.RegisterInstruments(BINANCE_SWAP, []InstrumentInfo{
+		{Id: "BTCUSDT", Pair: BTC_USDT, ContractType: SWAP_USDT_CONTRACT},
+		{Id: "1000SHIBUSDT", Pair: NewCurrencyPair2("1000SHIB_USDT"), ContractType: SWAP_USDT_CONTRACT},
+	})
+	assert.True(t, r.Loaded(KRAKEN))
+
+	pair, contractType, err := r.Pair(KRAKEN, "xxbtzusd")
+	require.NoError(t, err)
+	assert.Equal(t, "BTC_USD", pair.String())
+	assert.Equal(t, "", contractType)
+
+	pair, contractType, err = r.Pair(BINANCE_SWAP, "1000SHIBUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, "1000SHIB_USDT", pair.String())
+	assert.Equal(t, SWAP_USDT_CONTRACT, contractType)
+
+	_, _, err = r.Pair(BINANCE_SWAP, "BTCUSD_PERP")
+	assert.True(t, errors.Is(err, EX_ERR_SYMBOL_ERR))
+
+	symbol, err := r.Symbol(KRAKEN, NewCurrencyPair(XBT, USD), "")
+	require.NoError(t, err)
+	assert.Equal(t, "XXBTZUSD", symbol)
+
+	symbol, err = r.Symbol(BINANCE_SWAP, BTC_USD, SWAP_USDT_CONTRACT)
+	require.NoError(t, err, "usd finds the usdt contract")
+	assert.Equal(t, "BTCUSDT", symbol)
+
+	_, err = r.Symbol(BINANCE_SWAP, BTC_USDT, SWAP_CONTRACT)
+	assert.True(t, errors.Is(err, EX_ERR_SYMBOL_ERR))
+}
+
+func TestSymbolRegistry_Resolve(t *testing.T) {
+	r := NewSymbolRegistry()
+	guess := func(symbol string) CurrencyPair {
+		if symbol == "XBTUSD" {
+			return NewCurrencyPair(XBT, USD)
+		}
+		return UNKNOWN_PAIR
+	}
+
+	pair, err := r.Resolve(BITMEX, "XBTUSD", guess)
+	require.NoError(t, err, "guessed until loaded")
+	assert.Equal(t, "BTC_USD", pair.String())
+
+	_, err = r.Resolve(BITMEX, "ETHUSD", guess)
+	assert.True(t, errors.Is(err, EX_ERR_SYMBOL_ERR))
+
+	r.Register(BITMEX, "ETHUSD", ETH_USD, SWAP_CONTRACT)
+	pair, err = r.Resolve(BITMEX, "ETHUSD", guess)
+	require.NoError(t, err)
+	assert.Equal(t, "ETH_USD", pair.String())
+	_, err = r.Resolve(BITMEX, "XBTUSD", guess)
+	assert.True(t, errors.Is(err, EX_ERR_SYMBOL_ERR), "not guessed once loaded")
+
+	symbol, err := r.ResolveSymbol(KRAKEN, BTC_USD, "", func(pair CurrencyPair, _ string) string { return "XBTUSD" })
+	require.NoError(t, err)
+	assert.Equal(t, "XBTUSD", symbol)
+}
+
+func TestInstrumentsCache_RegistersSymbols(t *testing.T) {
+	t.Cleanup(func() { Symbols.Reset("fake") })
+	api := &fakeInstrumentsAPI{instruments: []InstrumentInfo{{Id: "XBT-EUR", Pair: NewCurrencyPair(XBT, EUR)}}}
+	_, err := NewInstrumentsCache(api, 0).GetInstrumentsInfo()
+	require.NoError(t, err)
+
+	pair, _, err := Symbols.Pair("fake", "XBT-EUR")
+	require.NoError(t, err)
+	assert.Equal(t, "BTC_EUR", pair.String())
+}
+
+func TestSymbolRegistry_Reset(t *testing.T) {
+	r := NewSymbolRegistry()
+	r.Register(BINANCE, "BTCUSDT", BTC_USDT, "")
+	r.Register(KRAKEN, "XXBTZUSD", BTC_USD, "")
+	r.Reset(BINANCE)
+	assert.False(t, r.Loaded(BINANCE))
+	assert.True(t, r.Loaded(KRAKEN))
+
+	pair, err := r.Resolve(BINANCE, "ETHUSDT", func(string) CurrencyPair { return ETH_USDT })
+	require.NoError(t, err, "guessed again")
+	assert.Equal(t, ETH_USDT, pair)
+
+	r.Reset()
+	assert.False(t, r.Loaded(KRAKEN))
+}
@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"io/ioutil"
-	"math"
 	"net/url"
 	"strconv"
 	"strings"
@@ -93,13 +92,39 @@ func ToInt64(v interface{}) int64 {
 	}
 }
 
+// FloatToString rounds v half away from zero to precision digits, without exponent
+// and trailing zeros: FloatToString(0.00001, 8) is "0.00001", not "1e-05".
 func FloatToString(v float64, precision int) string {
-	return fmt.Sprint(FloatToFixed(v, precision))
+	return DecimalFromFloat(v).Round(int32(precision)).Normalize().String()
 }
 
+// FloatToFixed rounds in decimal, 1.005 is 1.01 at 2 digits.
 func FloatToFixed(v float64, precision int) float64 {
-	p := math.Pow(10, float64(precision))
-	return math.Round(v*p) / p
+	return DecimalFromFloat(v).Round(int32(precision)).Float64()
+}
+
+// ToDecimal is ToFloat64 for Decimal, strings are parsed exactly.
+func ToDecimal(v interface{}) Decimal {
+	switch vv := v.(type) {
+	case nil:
+		return Decimal{}
+	case Decimal:
+		return vv
+	case string:
+		d, _ := ParseDecimal(vv)
+		return d
+	case json.Number:
+		d, _ := ParseDecimal(vv.String())
+		return d
+	case float64:
+		return DecimalFromFloat(vv)
+	case int:
+		return NewDecimal(int64(vv), 0)
+	case int64:
+		return NewDecimal(vv, 0)
+	default:
+		panic("to decimal error.")
+	}
 }
 
 func ValuesToJson(v url.Values) ([]byte, error) {
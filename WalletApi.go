@@ -1,5 +1,7 @@
 package goex
 
+import "context"
+
 type WalletApi interface {
 	//获取钱包资产
 	GetAccount() (*Account, error)
@@ -12,3 +14,12 @@ type WalletApi interface {
 	//获取充值记录
 	GetDepositHistory(currency *Currency) ([]DepositWithdrawHistory, error)
 }
+
+// WalletApiWithContext is the context-aware counterpart of WalletApi.
+type WalletApiWithContext interface {
+	GetAccountWithContext(ctx context.Context) (*Account, error)
+	WithdrawalWithContext(ctx context.Context, param WithdrawParameter) (withdrawId string, err error)
+	TransferWithContext(ctx context.Context, param TransferParameter) error
+	GetWithDrawHistoryWithContext(ctx context.Context, currency *Currency) ([]DepositWithdrawHistory, error)
+	GetDepositHistoryWithContext(ctx context.Context, currency *Currency) ([]DepositWithdrawHistory, error)
+}
@@ -4,32 +4,52 @@ type FuturesWsApi interface {
 	DepthCallback(func(depth *Depth))
 	TickerCallback(func(ticker *FutureTicker))
 	TradeCallback(func(trade *Trade, contract string))
-	//OrderCallback(func(order *FutureOrder))
-	//PositionCallback(func(position *FuturePosition))
-	//AccountCallback(func(account *FutureAccount))
+	//closed is false for the updates of the forming candle
+	KlineCallback(func(kline *FutureKline, period KlinePeriod, closed bool))
+	OrderCallback(func(order *FutureOrder))
+	PositionCallback(func(position *FuturePosition))
+	AccountCallback(func(account *FutureAccount))
 
 	SubscribeDepth(pair CurrencyPair, contractType string) error
 	SubscribeTicker(pair CurrencyPair, contractType string) error
 	SubscribeTrade(pair CurrencyPair, contractType string) error
+	SubscribeKline(pair CurrencyPair, contractType string, period KlinePeriod) error
 
-	//Login() error
-	//SubscribeOrder(pair CurrencyPair, contractType string) error
-	//SubscribePosition(pair CurrencyPair, contractType string) error
-	//SubscribeAccount(pair CurrencyPair) error
+	//an Unsubscribe of a stream which is not subscribed returns ErrNotSubscribed
+	UnsubscribeDepth(pair CurrencyPair, contractType string) error
+	UnsubscribeTicker(pair CurrencyPair, contractType string) error
+	UnsubscribeTrade(pair CurrencyPair, contractType string) error
+	UnsubscribeKline(pair CurrencyPair, contractType string, period KlinePeriod) error
+
+	//the private streams need the api key, their Subscribe methods login first if needed
+	Login() error
+	SubscribeOrder(pair CurrencyPair, contractType string) error
+	SubscribePosition(pair CurrencyPair, contractType string) error
+	SubscribeAccount(pair CurrencyPair) error
 }
 
 type SpotWsApi interface {
 	DepthCallback(func(depth *Depth))
 	TickerCallback(func(ticker *Ticker))
 	TradeCallback(func(trade *Trade))
-	//OrderCallback(func(order *Order))
-	//AccountCallback(func(account *Account))
+	//closed is false for the updates of the forming candle
+	KlineCallback(func(kline *Kline, period KlinePeriod, closed bool))
+	OrderCallback(func(order *Order))
+	AccountCallback(func(account *Account))
 
 	SubscribeDepth(pair CurrencyPair) error
 	SubscribeTicker(pair CurrencyPair) error
 	SubscribeTrade(pair CurrencyPair) error
+	SubscribeKline(pair CurrencyPair, period KlinePeriod) error
+
+	//an Unsubscribe of a stream which is not subscribed returns ErrNotSubscribed
+	UnsubscribeDepth(pair CurrencyPair) error
+	UnsubscribeTicker(pair CurrencyPair) error
+	UnsubscribeTrade(pair CurrencyPair) error
+	UnsubscribeKline(pair CurrencyPair, period KlinePeriod) error
 
-	//Login() error
-	//SubscribeOrder(pair CurrencyPair) error
-	//SubscribeAccount(pair CurrencyPair) error
+	//the private streams need the api key, their Subscribe methods login first if needed
+	Login() error
+	SubscribeOrder(pair CurrencyPair) error
+	SubscribeAccount(pair CurrencyPair) error
 }
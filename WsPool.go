@@ -0,0 +1,360 @@
+package goex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/soulsplit/goex/internal/logger"
+)
+
+// WsPool spreads the subscriptions over as many connections as needed to keep each one
+// under the stream limit of the exchange. The connections are dialed by the builder on
+// demand and share its ProtoHandleFunc. The subscriptions of a connection which is
+// closed for good, e.g. the reconnect gave up after wsPoolReconnects attempts, are
+// moved to the others.
+type WsPool struct {
+	builder *WsBuilder
+	maxSubs int
+
+	lock   sync.Mutex
+	conns  []*poolConn
+	subs   map[string]*poolSub //by key
+	acks   map[string]string   //subscription keys by ack id
+	closed bool
+}
+
+// poolConn is a connection, its slots are reserved before it is dialed.
+type poolConn struct {
+	conn   *WsConn
+	count  int
+	dialed chan struct{} //closed when conn is set or err is
+	err    error
+}
+
+type poolSub struct {
+	pc  *poolConn
+	sub WsSubscription
+}
+
+// wsPoolReconnects are the reconnect attempts of a pool connection, the builder's
+// Backoff gives the waits. A dead connection does not hold up its streams any longer.
+const wsPoolReconnects = 3
+
+// NewWsPool keeps at most maxSubs subscriptions on a connection.
+func NewWsPool(builder *WsBuilder, maxSubs int) *WsPool {
+	if maxSubs <= 0 {
+		maxSubs = 1
+	}
+	return &WsPool{
+		builder: builder,
+		maxSubs: maxSubs,
+		subs:    make(map[string]*poolSub, 16),
+		acks:    make(map[string]string, 16),
+	}
+}
+
+// build connects pc, Build panics if it can not connect.
+func (p *WsPool) build(pc *poolConn) (conn *WsConn, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	conf := *p.builder.wsConfig
+	backoff := conf.Backoff
+	if backoff == nil {
+		backoff = LinearBackoff(conf.reconnectInterval, 0)
+	}
+	conf.Backoff = func(retry int) (time.Duration, bool) {
+		if retry >= wsPoolReconnects {
+			return 0, false
+		}
+		return backoff(retry)
+	}
+	stateHandle := conf.StateHandleFunc
+	conf.StateHandleFunc = func(change WsStateChange) {
+		if stateHandle != nil {
+			stateHandle(change)
+		}
+		if change.To == WsClosed {
+			go func() {
+				<-pc.dialed
+				p.rebalance(pc)
+			}()
+		}
+	}
+	return (&WsBuilder{&conf}).Build(), nil
+}
+
+// dial connects a reserved connection without holding p.lock, so the acks of the
+// other connections are not held up, and then attaches it to the pool.
+func (p *WsPool) dial(pc *poolConn) {
+	conn, err := p.build(pc)
+
+	p.lock.Lock()
+	if err == nil && p.closed {
+		err = ErrWsClosed
+	}
+	if err == nil {
+		pc.conn = conn
+		Log.Infof("[ws pool][%s] %d connections", p.builder.wsConfig.WsUrl, len(p.conns))
+	} else {
+		p.drop(pc)
+	}
+	pc.err = err
+	close(pc.dialed)
+	p.lock.Unlock()
+
+	if err == ErrWsClosed {
+		conn.CloseWs()
+	}
+}
+
+// acquire is a connection with room for one more subscription, or else a new one
+// to dial, p.lock is held.
+func (p *WsPool) acquire() (pc *poolConn, dial bool) {
+	for _, pc := range p.conns {
+		if pc.count < p.maxSubs && (pc.conn == nil || pc.conn.State() != WsClosed) {
+			return pc, false
+		}
+	}
+	pc = &poolConn{dialed: make(chan struct{})}
+	p.conns = append(p.conns, pc)
+	return pc, true
+}
+
+// drop takes pc out of the connections, p.lock is held.
+func (p *WsPool) drop(pc *poolConn) {
+	for i, c := range p.conns {
+		if c == pc {
+			p.conns = append(p.conns[:i], p.conns[i+1:]...)
+			return
+		}
+	}
+}
+
+func (p *WsPool) Subscribe(subEvent interface{}) error {
+	return p.AddSubscription(WsSubscription{Event: subEvent})
+}
+
+func (p *WsPool) Unsubscribe(subEvent, unsubEvent interface{}) error {
+	data, err := json.Marshal(subEvent)
+	if err != nil {
+		return err
+	}
+	return p.RemoveSubscription(string(data), unsubEvent)
+}
+
+// AddSubscription is WsConn.AddSubscription on a connection with room for it.
+func (p *WsPool) AddSubscription(sub WsSubscription) error {
+	if sub.Key == "" {
+		data, err := json.Marshal(sub.Event)
+		if err != nil {
+			return err
+		}
+		sub.Key = string(data)
+	}
+
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return ErrWsClosed
+	}
+	if _, ok := p.subs[sub.Key]; ok {
+		p.lock.Unlock()
+		return nil
+	}
+	pc, dial := p.acquire()
+	pc.count++
+	s := &poolSub{pc: pc, sub: sub}
+	p.subs[sub.Key] = s
+	if sub.AckId != "" {
+		p.acks[sub.AckId] = sub.Key
+	}
+	p.lock.Unlock()
+
+	if dial {
+		p.dial(pc)
+	}
+	<-pc.dialed
+	if pc.err != nil {
+		p.lock.Lock()
+		p.forget(sub.Key, pc)
+		p.lock.Unlock()
+		return pc.err
+	}
+
+	p.lock.Lock()
+	removed := p.subs[sub.Key] != s //while dialing
+	p.lock.Unlock()
+	if removed {
+		return nil
+	}
+
+	err := pc.conn.AddSubscription(sub)
+	if err != nil {
+		p.lock.Lock()
+		p.forget(sub.Key, pc)
+		closed := p.closed
+		p.lock.Unlock()
+		if err == ErrWsClosed && !closed { //the connection died, moved by rebalance or else again
+			return p.AddSubscription(sub)
+		}
+	}
+	return err
+}
+
+// forget drops the subscription if it is still on pc, p.lock is held.
+func (p *WsPool) forget(key string, pc *poolConn) {
+	s, ok := p.subs[key]
+	if !ok || s.pc != pc {
+		return
+	}
+	delete(p.subs, key)
+	if s.sub.AckId != "" {
+		delete(p.acks, s.sub.AckId)
+	}
+	pc.count--
+}
+
+func (p *WsPool) RemoveSubscription(key string, unsubEvent interface{}) error {
+	p.lock.Lock()
+	s, ok := p.subs[key]
+	if !ok {
+		p.lock.Unlock()
+		return ErrNotSubscribed
+	}
+	p.forget(key, s.pc)
+	conn := s.pc.conn
+	p.lock.Unlock()
+	if conn == nil { //not sent yet, AddSubscription drops it when dialed
+		return nil
+	}
+	return conn.RemoveSubscription(key, unsubEvent)
+}
+
+// AckSubscription passes the ack to the connection of the subscription, an unknown
+// ack id is ignored.
+func (p *WsPool) AckSubscription(ackId string, err error) {
+	p.lock.Lock()
+	s, ok := p.subs[p.acks[ackId]]
+	var conn *WsConn
+	if ok {
+		conn = s.pc.conn
+		if err != nil {
+			p.forget(s.sub.Key, s.pc)
+		}
+	}
+	p.lock.Unlock()
+	if conn != nil {
+		conn.AckSubscription(ackId, err)
+	}
+}
+
+// rebalance moves the subscriptions of a closed connection to the others.
+func (p *WsPool) rebalance(pc *poolConn) {
+	p.lock.Lock()
+	if p.closed || pc.conn == nil {
+		p.lock.Unlock()
+		return
+	}
+	p.drop(pc)
+	//in the order of the connection, then the ones still waiting for their ack
+	order := make(map[string]int)
+	for i, key := range pc.conn.Subscriptions() {
+		order[key] = i + 1
+	}
+	var subs []WsSubscription
+	for key, s := range p.subs {
+		if s.pc == pc {
+			subs = append(subs, s.sub)
+			p.forget(key, pc)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		oi, oj := order[subs[i].Key], order[subs[j].Key]
+		if oi == 0 || oj == 0 {
+			return oi > oj || (oi == oj && subs[i].Key < subs[j].Key)
+		}
+		return oi < oj
+	})
+	p.lock.Unlock()
+
+	Log.Warnf("[ws pool][%s] a connection is closed, moving its %d subscriptions", p.builder.wsConfig.WsUrl, len(subs))
+	for _, sub := range subs {
+		if err := p.AddSubscription(sub); err != nil {
+			Log.Errorf("[ws pool][%s] resubscribe %s error: %s", p.builder.wsConfig.WsUrl, sub.Key, err)
+			if p.builder.wsConfig.ErrorHandleFunc != nil {
+				p.builder.wsConfig.ErrorHandleFunc(err)
+			}
+		}
+	}
+}
+
+// Conns are the open connections.
+func (p *WsPool) Conns() []*WsConn {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	conns := make([]*WsConn, 0, len(p.conns))
+	for _, pc := range p.conns {
+		if pc.conn != nil {
+			conns = append(conns, pc.conn)
+		}
+	}
+	return conns
+}
+
+// Conn is the connection of a subscription, nil while it is dialed or if the key is
+// not subscribed.
+func (p *WsPool) Conn(key string) *WsConn {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if s, ok := p.subs[key]; ok {
+		return s.pc.conn
+	}
+	return nil
+}
+
+// Subscriptions are the keys of all the connections.
+func (p *WsPool) Subscriptions() []string {
+	var keys []string
+	for _, c := range p.Conns() {
+		keys = append(keys, c.Subscriptions()...)
+	}
+	return keys
+}
+
+func (p *WsPool) PendingAcks() []string {
+	var ids []string
+	for _, c := range p.Conns() {
+		ids = append(ids, c.PendingAcks()...)
+	}
+	return ids
+}
+
+// Close closes all the connections, see WsConn.Close.
+func (p *WsPool) Close(ctx context.Context) error {
+	p.lock.Lock()
+	p.closed = true
+	var conns []*WsConn
+	for _, pc := range p.conns {
+		if pc.conn != nil { //else dialing, closed when dialed
+			conns = append(conns, pc.conn)
+		}
+	}
+	p.conns = nil
+	p.lock.Unlock()
+
+	var err error
+	for _, conn := range conns {
+		if e := conn.Close(ctx); e != nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,189 @@
+package goex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWsPool(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer c.Close()
+		for {
+			_, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req struct{ Op, Ch string }
+			json.Unmarshal(msg, &req)
+			switch {
+			case req.Op == "kill":
+				return
+			case req.Op != "sub":
+			case req.Ch == "bad":
+				c.WriteMessage(websocket.TextMessage, []byte(`{"ack":"bad","error":"unknown channel"}`))
+			default:
+				c.WriteMessage(websocket.TextMessage, []byte(`{"ack":"`+req.Ch+`"}`))
+			}
+		}
+	}))
+	defer srv.Close()
+
+	var pool *WsPool
+	pool = NewWsPool(NewWsBuilder().WsUrl(wsUrl(srv)).SubscribeAckTimeout(time.Second).
+		ErrorHandleFunc(func(error) {}).ProtoHandleFunc(func(data []byte) error {
+		var ack struct{ Ack, Error string }
+		json.Unmarshal(data, &ack)
+		var err error
+		if ack.Error != "" {
+			err = errors.New(ack.Error)
+		}
+		pool.AckSubscription(ack.Ack, err)
+		return nil
+	}), 2)
+
+	sub := func(ch string) error {
+		return pool.AddSubscription(WsSubscription{Key: ch, AckId: ch, Event: map[string]string{"op": "sub", "ch": ch}})
+	}
+	for _, ch := range []string{"a", "b", "c", "a"} {
+		require.NoError(t, sub(ch))
+	}
+	assert.EqualError(t, sub("bad"), "unknown channel")
+	pool.AckSubscription("unknown", nil)
+	assert.Equal(t, []string{"a", "b", "c"}, pool.Subscriptions())
+	conns := pool.Conns()
+	require.Len(t, conns, 2)
+	assert.Equal(t, []string{"a", "b"}, conns[0].Subscriptions())
+
+	//the subscriptions of a dead connection move to the others
+	require.NoError(t, conns[0].SendJsonMessage(map[string]string{"op": "kill"}))
+	require.Eventually(t, func() bool {
+		return len(pool.Subscriptions()) == 3 && len(pool.PendingAcks()) == 0 && pool.Conns()[0] == conns[1]
+	}, time.Second, 5*time.Millisecond, "the dead connection is dropped")
+	assert.Equal(t, WsClosed, conns[0].State())
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, pool.Subscriptions())
+	require.Len(t, pool.Conns(), 2)
+	assert.Equal(t, []string{"c", "a"}, conns[1].Subscriptions()[:2])
+
+	assert.NoError(t, pool.RemoveSubscription("c", map[string]string{"op": "unsub", "ch": "c"}))
+	assert.Equal(t, ErrNotSubscribed, pool.RemoveSubscription("c", nil))
+	require.NoError(t, sub("d"), "fills the free slot")
+	assert.Len(t, pool.Conns(), 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, pool.Close(ctx))
+	assert.Empty(t, pool.Conns())
+	assert.Equal(t, ErrWsClosed, sub("e"))
+}
+
+func TestWsPool_DialOutsideLock(t *testing.T) {
+	var (
+		upgrader = websocket.Upgrader{}
+		dialing  = make(chan struct{})
+		release  = make(chan struct{})
+		dials    int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&dials, 1) == 2 {
+			close(dialing)
+			<-release
+		}
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer c.Close()
+		for {
+			_, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req struct{ Ch string }
+			json.Unmarshal(msg, &req)
+			c.WriteMessage(websocket.TextMessage, []byte(`{"ack":"`+req.Ch+`"}`))
+		}
+	}))
+	defer srv.Close()
+
+	var pool *WsPool
+	pool = NewWsPool(NewWsBuilder().WsUrl(wsUrl(srv)).SubscribeAckTimeout(time.Second).
+		ErrorHandleFunc(func(error) {}).ProtoHandleFunc(func(data []byte) error {
+		var ack struct{ Ack string }
+		json.Unmarshal(data, &ack)
+		pool.AckSubscription(ack.Ack, nil)
+		return nil
+	}), 1)
+	sub := func(ch string) error {
+		return pool.AddSubscription(WsSubscription{Key: ch, AckId: ch, Event: map[string]string{"ch": ch}})
+	}
+	require.NoError(t, sub("a"))
+
+	added := make(chan error, 1)
+	go func() { added <- sub("b") }()
+	<-dialing
+	done := make(chan struct{})
+	go func() {
+		pool.AckSubscription("a", nil)
+		assert.Len(t, pool.Conns(), 1, "the connection being dialed is not listed")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the pool is locked while dialing")
+	}
+
+	close(release)
+	require.NoError(t, <-added)
+	assert.Equal(t, []string{"a", "b"}, pool.Subscriptions())
+	assert.NoError(t, pool.Close(context.Background()))
+}
+
+func TestWsPool_ReconnectGivesUp(t *testing.T) {
+	var (
+		upgrader = websocket.Upgrader{}
+		refuse   int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&refuse, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer c.Close()
+		for {
+			_, msg, err := c.ReadMessage()
+			if err != nil || string(msg) == `{"op":"kill"}` {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	pool := NewWsPool(NewWsBuilder().WsUrl(wsUrl(srv)).AutoReconnect().Backoff(LinearBackoff(time.Millisecond, 0)).
+		ErrorHandleFunc(func(error) {}).ProtoHandleFunc(func([]byte) error { return nil }), 10)
+	defer pool.Close(context.Background())
+	require.NoError(t, pool.Subscribe(map[string]string{"ch": "a"}))
+	conn := pool.Conns()[0]
+
+	atomic.StoreInt32(&refuse, wsPoolReconnects)
+	require.NoError(t, conn.SendJsonMessage(map[string]string{"op": "kill"}))
+	require.Eventually(t, func() bool {
+		conns := pool.Conns()
+		return len(conns) == 1 && conns[0] != conn
+	}, time.Second, 5*time.Millisecond, "moved once the reconnect gave up")
+	assert.Equal(t, WsClosed, conn.State())
+	assert.Equal(t, []string{`{"ch":"a"}`}, pool.Subscriptions())
+}
@@ -0,0 +1,259 @@
+package goex
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	. "github.com/soulsplit/goex/internal/logger"
+)
+
+const (
+	WsFrameIn  = "in"
+	WsFrameOut = "out"
+)
+
+// WsFrame is one websocket frame as it went over the wire. Inbound frames are
+// kept before DecompressFunc, so a replay goes through the same code path.
+type WsFrame struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"dir"`
+	Type      int       `json:"type"` //websocket.TextMessage, BinaryMessage ...
+	Text      string    `json:"text,omitempty"`
+	Binary    []byte    `json:"binary,omitempty"`
+}
+
+func (f *WsFrame) Data() []byte {
+	if f.Type == websocket.TextMessage {
+		return []byte(f.Text)
+	}
+	return f.Binary
+}
+
+const wsRedacted = "REDACTED"
+
+// WsRedactKeys are the credential fields of the outbound json frames, they are
+// recorded as REDACTED at any depth and matched case-insensitively. The args of a
+// login op, e.g. the okex and bitmex ones, are all credentials and redacted too.
+var WsRedactKeys = []string{
+	"apiKey", "api_key", "accessKey", "passphrase", "sign", "signature",
+	"authSig", "authPayload", "authNonce", "Authorization", "token",
+}
+
+var wsLoginOps = map[string]bool{"login": true, "auth": true, "authkey": true, "authkeyexpires": true}
+
+// redactWsFrame scrubs the credentials of an outbound json frame, the frame is kept
+// verbatim if there are none.
+func redactWsFrame(data []byte) []byte {
+	var obj map[string]interface{}
+	if json.Unmarshal(data, &obj) != nil {
+		return data
+	}
+	keys := make(map[string]bool, len(WsRedactKeys))
+	for _, k := range WsRedactKeys {
+		keys[strings.ToLower(k)] = true
+	}
+
+	redacted := false
+	if op, _ := obj["op"].(string); wsLoginOps[strings.ToLower(op)] && obj["args"] != nil {
+		obj["args"], redacted = wsRedacted, true
+	}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, e := range v {
+				if keys[strings.ToLower(k)] {
+					v[k], redacted = wsRedacted, true
+					continue
+				}
+				walk(e)
+			}
+		case []interface{}:
+			for _, e := range v {
+				walk(e)
+			}
+		}
+	}
+	walk(obj)
+	if !redacted {
+		return data
+	}
+	out, _ := json.Marshal(obj)
+	return out
+}
+
+// WsRecorder writes frames as json lines, see WsBuilder.Recorder. The credentials of
+// the outbound frames are redacted, see WsRedactKeys.
+type WsRecorder struct {
+	sync.Mutex
+	w     io.Writer
+	file  *os.File
+	clock func() time.Time
+}
+
+func NewWsRecorder(path string) (*WsRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &WsRecorder{w: f, file: f, clock: time.Now}, nil
+}
+
+func NewWsRecorderWithWriter(w io.Writer) *WsRecorder {
+	return &WsRecorder{w: w, clock: time.Now}
+}
+
+func (r *WsRecorder) Record(direction string, msgType int, data []byte) {
+	frame := WsFrame{Time: r.clock(), Direction: direction, Type: msgType}
+	if direction == WsFrameOut && msgType == websocket.TextMessage {
+		data = redactWsFrame(data)
+	}
+	if msgType == websocket.TextMessage {
+		frame.Text = string(data)
+	} else {
+		frame.Binary = data
+	}
+
+	line, err := json.Marshal(frame)
+	if err != nil {
+		Log.Errorf("[ws] record frame error, %s", err.Error())
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	if _, err = r.w.Write(append(line, '\n')); err != nil {
+		Log.Errorf("[ws] record frame error, %s", err.Error())
+	}
+}
+
+func (r *WsRecorder) Close() error {
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
+
+func ReadWsFrames(rd io.Reader) ([]WsFrame, error) {
+	var frames []WsFrame
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var f WsFrame
+		if err := json.Unmarshal(scanner.Bytes(), &f); err != nil {
+			return nil, err
+		}
+		frames = append(frames, f)
+	}
+	return frames, scanner.Err()
+}
+
+// WsReplayer plays the inbound frames of a recorded session.
+type WsReplayer struct {
+	Frames []WsFrame
+	Speed  float64 //1 original speed, 10 ten times faster, 0 no delay at all
+}
+
+func NewWsReplayer(path string) (*WsReplayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	frames, err := ReadWsFrames(f)
+	if err != nil {
+		return nil, err
+	}
+	return &WsReplayer{Frames: frames, Speed: 1}, nil
+}
+
+func (rp *WsReplayer) inbound() []WsFrame {
+	var frames []WsFrame
+	for _, f := range rp.Frames {
+		if f.Direction == WsFrameIn && (f.Type == websocket.TextMessage || f.Type == websocket.BinaryMessage) {
+			frames = append(frames, f)
+		}
+	}
+	return frames
+}
+
+// play calls send for every inbound frame, waiting between frames as recorded (scaled by Speed).
+func (rp *WsReplayer) play(ctx context.Context, send func(f *WsFrame) error) error {
+	frames := rp.inbound()
+	for i := range frames {
+		if i > 0 && rp.Speed > 0 {
+			wait := time.Duration(float64(frames[i].Time.Sub(frames[i-1].Time)) / rp.Speed)
+			if wait > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := send(&frames[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Play feeds the inbound frames to conf.ProtoHandleFunc the way WsConn does, using
+// conf.DecompressFunc for binary frames. Handler errors go to conf.ErrorHandleFunc.
+func (rp *WsReplayer) Play(ctx context.Context, conf *WsConfig) error {
+	return rp.play(ctx, func(f *WsFrame) error {
+		if err := handleWsFrame(conf, f.Type, f.Data()); err != nil && conf.ErrorHandleFunc != nil {
+			conf.ErrorHandleFunc(err)
+		}
+		return nil
+	})
+}
+
+// Server starts a local websocket server that sends the inbound frames to every
+// client that connects. Outbound frames (subscriptions, heartbeats) are read and dropped.
+func (rp *WsReplayer) Server() *httptest.Server {
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			Log.Errorf("[ws] replay server upgrade error, %s", err.Error())
+			return
+		}
+		defer c.Close()
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		go func() {
+			defer cancel()
+			for {
+				if _, _, err := c.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		err = rp.play(ctx, func(f *WsFrame) error {
+			return c.WriteMessage(f.Type, f.Data())
+		})
+		if err != nil {
+			return
+		}
+		<-ctx.Done()
+	}))
+}
@@ -0,0 +1,132 @@
+package goex
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func wsUrl(srv *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+type wsMessages struct {
+	sync.Mutex
+	msgs []string
+}
+
+func (m *wsMessages) handle(data []byte) error {
+	m.Lock()
+	defer m.Unlock()
+	m.msgs = append(m.msgs, string(data))
+	return nil
+}
+
+func (m *wsMessages) get() []string {
+	m.Lock()
+	defer m.Unlock()
+	return append([]string(nil), m.msgs...)
+}
+
+func TestWsRecorder(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer c.Close()
+		_, sub, _ := c.ReadMessage()
+		c.WriteMessage(websocket.TextMessage, []byte(`{"ack":`+string(sub)+`}`))
+		c.WriteMessage(websocket.BinaryMessage, []byte("depth"))
+		c.ReadMessage()
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	rec := NewWsRecorderWithWriter(&buf)
+	msgs := new(wsMessages)
+	ws := NewWsBuilder().WsUrl(wsUrl(srv)).Recorder(rec).ProtoHandleFunc(msgs.handle).Build()
+	require.NoError(t, ws.Subscribe(map[string]string{"sub": "depth"}))
+
+	require.Eventually(t, func() bool { return len(msgs.get()) == 2 }, time.Second, 10*time.Millisecond)
+	ws.CloseWs()
+
+	frames, err := ReadWsFrames(&buf)
+	require.NoError(t, err)
+	require.Len(t, frames, 3)
+	assert.Equal(t, WsFrameOut, frames[0].Direction)
+	assert.Equal(t, `{"sub":"depth"}`, frames[0].Text)
+	assert.Equal(t, WsFrameIn, frames[1].Direction)
+	assert.Equal(t, `{"ack":{"sub":"depth"}}`, frames[1].Text)
+	assert.Equal(t, websocket.BinaryMessage, frames[2].Type)
+	assert.Equal(t, []byte("depth"), frames[2].Data())
+}
+
+func TestRedactWsFrame(t *testing.T) {
+	for _, c := range []struct{ frame, want string }{
+		{`{"args":["spot/ticker:BTC-USDT"],"op":"subscribe"}`, `{"args":["spot/ticker:BTC-USDT"],"op":"subscribe"}`},
+		{`{"op":"login","args":["key","pass","1600000000.000","c2lnbg=="]}`, `{"args":"REDACTED","op":"login"}`},
+		{`{"action":"req","ch":"auth","params":{"authType":"api","accessKey":"key","signature":"sig","timestamp":"2021-01-01T00:00:00"}}`,
+			`{"action":"req","ch":"auth","params":{"accessKey":"REDACTED","authType":"api","signature":"REDACTED","timestamp":"2021-01-01T00:00:00"}}`},
+		{`ping`, `ping`},
+	} {
+		assert.Equal(t, c.want, string(redactWsFrame([]byte(c.frame))), c.frame)
+	}
+
+	var buf bytes.Buffer
+	NewWsRecorderWithWriter(&buf).Record(WsFrameOut, websocket.TextMessage, []byte(`{"event":"auth","apiKey":"key","authSig":"sig"}`))
+	assert.NotContains(t, buf.String(), `\"key\"`)
+	assert.NotContains(t, buf.String(), "sig\"")
+}
+
+func testFrames() []WsFrame {
+	t0 := time.Unix(1600000000, 0)
+	return []WsFrame{
+		{Time: t0, Direction: WsFrameOut, Type: websocket.TextMessage, Text: "sub"},
+		{Time: t0.Add(100 * time.Millisecond), Direction: WsFrameIn, Type: websocket.TextMessage, Text: "a"},
+		{Time: t0.Add(300 * time.Millisecond), Direction: WsFrameIn, Type: websocket.BinaryMessage, Binary: []byte("B")},
+		{Time: t0.Add(400 * time.Millisecond), Direction: WsFrameIn, Type: websocket.TextMessage, Text: "c"},
+	}
+}
+
+func TestWsReplayer_Play(t *testing.T) {
+	rp := &WsReplayer{Frames: testFrames(), Speed: 10}
+	msgs := new(wsMessages)
+	conf := &WsConfig{
+		ProtoHandleFunc: msgs.handle,
+		DecompressFunc: func(data []byte) ([]byte, error) {
+			return bytes.ToLower(data), nil
+		},
+	}
+
+	start := time.Now()
+	require.NoError(t, rp.Play(context.Background(), conf))
+	elapsed := time.Since(start)
+	assert.Equal(t, []string{"a", "b", "c"}, msgs.get())
+	assert.True(t, elapsed >= 30*time.Millisecond && elapsed < 300*time.Millisecond, elapsed.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, rp.Play(ctx, conf))
+}
+
+func TestWsReplayer_Server(t *testing.T) {
+	rp := &WsReplayer{Frames: testFrames(), Speed: 0}
+	srv := rp.Server()
+	defer srv.Close()
+
+	msgs := new(wsMessages)
+	ws := NewWsBuilder().WsUrl(wsUrl(srv)).ProtoHandleFunc(msgs.handle).Build()
+	defer ws.CloseWs()
+
+	require.Eventually(t, func() bool { return len(msgs.get()) == 3 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"a", "B", "c"}, msgs.get())
+}
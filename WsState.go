@@ -0,0 +1,71 @@
+package goex
+
+import (
+	"fmt"
+	"time"
+)
+
+// WsState is the state of a WsConn.
+type WsState int32
+
+const (
+	WsConnecting WsState = iota
+	WsConnected
+	WsReconnecting //the connection is lost, the pushes until the next WsConnected are missed
+	WsClosed
+)
+
+func (s WsState) String() string {
+	switch s {
+	case WsConnecting:
+		return "connecting"
+	case WsConnected:
+		return "connected"
+	case WsReconnecting:
+		return "reconnecting"
+	case WsClosed:
+		return "closed"
+	}
+	return fmt.Sprintf("WsState(%d)", int32(s))
+}
+
+// WsStateChange is passed to the StateHandleFunc of a WsConn.
+type WsStateChange struct {
+	From WsState
+	To   WsState
+	Err  error         //why the connection was lost or closed, nil for Close
+	Down time.Duration //for a WsConnected after a reconnect, how long nothing was received
+}
+
+// WsBackoff is the wait after the failed reconnect attempt retry, counted from 1.
+// The reconnect gives up if ok is false.
+type WsBackoff func(retry int) (wait time.Duration, ok bool)
+
+// LinearBackoff waits interval*retry, it gives up after maxRetries attempts or never
+// if maxRetries is 0.
+func LinearBackoff(interval time.Duration, maxRetries int) WsBackoff {
+	return func(retry int) (time.Duration, bool) {
+		if maxRetries > 0 && retry >= maxRetries {
+			return 0, false
+		}
+		return interval * time.Duration(retry), true
+	}
+}
+
+// ExponentialBackoff doubles the wait from min up to max, it gives up after maxRetries
+// attempts or never if maxRetries is 0.
+func ExponentialBackoff(min, max time.Duration, maxRetries int) WsBackoff {
+	return func(retry int) (time.Duration, bool) {
+		if maxRetries > 0 && retry >= maxRetries {
+			return 0, false
+		}
+		wait := min
+		for i := 1; i < retry && wait < max; i++ {
+			wait *= 2
+		}
+		if wait > max {
+			wait = max
+		}
+		return wait, true
+	}
+}
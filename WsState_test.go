@@ -0,0 +1,162 @@
+package goex
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	linear := LinearBackoff(time.Second, 3)
+	wait, ok := linear(2)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, wait)
+	_, ok = linear(3)
+	assert.False(t, ok)
+
+	exp := ExponentialBackoff(100*time.Millisecond, time.Second, 0)
+	var waits []time.Duration
+	for retry := 1; retry <= 6; retry++ {
+		wait, ok := exp(retry)
+		require.True(t, ok, "retries forever")
+		waits = append(waits, wait)
+	}
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond,
+		800 * time.Millisecond, time.Second, time.Second}, waits)
+}
+
+type wsStates struct {
+	sync.Mutex
+	changes []WsStateChange
+}
+
+func (s *wsStates) handle(change WsStateChange) {
+	s.Lock()
+	defer s.Unlock()
+	s.changes = append(s.changes, change)
+}
+
+func (s *wsStates) get() []WsStateChange {
+	s.Lock()
+	defer s.Unlock()
+	return append([]WsStateChange(nil), s.changes...)
+}
+
+func (s *wsStates) to() []WsState {
+	var states []WsState
+	for _, c := range s.get() {
+		states = append(states, c.To)
+	}
+	return states
+}
+
+func TestWsConn_Reconnect(t *testing.T) {
+	var conns int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&conns, 1)
+		if n == 2 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer c.Close()
+		if n == 1 {
+			return //dropped
+		}
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	states := new(wsStates)
+	ws := NewWsBuilder().WsUrl(wsUrl(srv)).AutoReconnect().
+		Backoff(ExponentialBackoff(time.Millisecond, 10*time.Millisecond, 0)).
+		StateHandleFunc(states.handle).ProtoHandleFunc(func([]byte) error { return nil }).Build()
+	require.Eventually(t, func() bool { return ws.State() == WsConnected && atomic.LoadInt32(&conns) == 3 },
+		time.Second, 5*time.Millisecond)
+
+	changes := states.get()
+	require.Len(t, changes, 3)
+	assert.Equal(t, []WsState{WsConnected, WsReconnecting, WsConnected}, states.to())
+	assert.Error(t, changes[1].Err)
+	assert.True(t, changes[2].Down > 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, ws.Close(ctx), "the server answers the close frame")
+	assert.NoError(t, ws.Close(ctx))
+	ws.CloseWs()
+	assert.Equal(t, WsClosed, ws.State())
+	assert.Equal(t, WsStateChange{From: WsConnected, To: WsClosed}, states.get()[3])
+
+	ws.SendMessage([]byte("dropped"))
+	assert.Equal(t, ErrWsClosed, ws.SendJsonMessage("dropped"))
+	assert.Equal(t, ErrWsClosed, ws.Subscribe("dropped"))
+}
+
+func TestWsConn_ReconnectFail(t *testing.T) {
+	var conns int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&conns, 1) > 1 {
+			http.Error(w, "gone", http.StatusNotFound)
+			return
+		}
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		c.Close()
+	}))
+	defer srv.Close()
+
+	var (
+		states  = new(wsStates)
+		lastErr atomic.Value
+	)
+	ws := NewWsBuilder().WsUrl(wsUrl(srv)).AutoReconnect().
+		Backoff(LinearBackoff(time.Millisecond, 3)).
+		ErrorHandleFunc(func(err error) { lastErr.Store(err) }).
+		StateHandleFunc(states.handle).ProtoHandleFunc(func([]byte) error { return nil }).Build()
+	require.Eventually(t, func() bool { return ws.State() == WsClosed }, time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, int32(4), atomic.LoadInt32(&conns), "3 attempts")
+	assert.Equal(t, []WsState{WsConnected, WsReconnecting, WsClosed}, states.to())
+	assert.Error(t, states.get()[2].Err)
+	require.Eventually(t, func() bool { return lastErr.Load() != nil }, time.Second, 5*time.Millisecond)
+	ws.CloseWs()
+}
+
+func TestWsConn_Context(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer c.Close()
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ws := NewWsBuilder().WsUrl(wsUrl(srv)).Context(ctx).ProtoHandleFunc(func([]byte) error { return nil }).Build()
+	assert.Equal(t, WsConnected, ws.State())
+	cancel()
+	require.Eventually(t, func() bool { return ws.State() == WsClosed }, time.Second, 5*time.Millisecond)
+	assert.True(t, errors.Is(ws.SendJsonMessage("x"), ErrWsClosed))
+}
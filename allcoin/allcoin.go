@@ -2,7 +2,7 @@ package allcoin
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"net/url"
@@ -27,6 +27,12 @@ const (
 	UNFINISHED_ORDERS_INFO = "Api_Order/trustList"
 )
 
+// adaptError maps a {"code":1,"msg":"..."} response, allcoin documents no codes.
+func adaptError(respmap map[string]interface{}) ApiError {
+	msg, _ := respmap["msg"].(string)
+	return CommonErrorMessages.Adapt(http.StatusOK, msg).WithNativeCode(fmt.Sprint(respmap["code"]))
+}
+
 type Allcoin struct {
 	accessKey,
 	secretKey string
@@ -139,7 +145,7 @@ func (ac *Allcoin) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error)
 	msg := respmap["msg"].(string)
 	log.Println("code=", code, "msg:", msg)
 	if code != 0 {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, adaptError(respmap)
 	}
 	data := respmap["data"].(map[string]interface{})
 	log.Println("1", data)
@@ -197,7 +203,7 @@ func (ac *Allcoin) placeOrder(amount, price string, pair CurrencyPair, orderType
 	}
 	code := respmap["code"].(float64)
 	if code != 0 {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, adaptError(respmap)
 	}
 	data := respmap["data"].(map[string]interface{})
 
@@ -243,7 +249,7 @@ func (ac *Allcoin) GetAccount() (*Account, error) {
 	//msg := respmap["msg"].(string)
 	//log.Println("code=", code, "msg:", msg)
 	if code != 0 {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, adaptError(respmap)
 	}
 	data := respmap["data"].(map[string]interface{})
 
@@ -313,7 +319,7 @@ func (ac *Allcoin) CancelOrder(orderId string, currencyPair CurrencyPair) (bool,
 	}
 	code := respmap["code"].(int)
 	if code != 0 {
-		return false, errors.New(respmap["msg"].(string))
+		return false, adaptError(respmap)
 	}
 
 	//orderIdCanceled := ToInt(respmap["orderId"])
@@ -349,7 +355,7 @@ func (ac *Allcoin) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Orde
 	}
 	code := respmap["code"].(float64)
 	if code != 0 {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, adaptError(respmap)
 	}
 
 	data := respmap["data"].(map[string]interface{})
@@ -416,7 +422,7 @@ func (ac *Allcoin) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error)
 	//msg := respmap["msg"].(string)
 	//log.Println("code=", code, "msg:", msg)
 	if code != 0 {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, adaptError(respmap)
 	}
 	data, isok := respmap["data"].([]map[string]interface{})
 
@@ -0,0 +1,539 @@
+// Package arbitrage scans the books of one or several exchanges for triangular
+// and cross-exchange opportunities that pay after the taker fees.
+package arbitrage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/internal/logger"
+)
+
+const epsilon = 1e-12
+
+const (
+	Triangular    = "triangular"
+	CrossExchange = "cross"
+)
+
+// Venue is an exchange to scan, its books are pushed by Ws or polled with API.
+type Venue struct {
+	Name     string //default API.GetExchangeName()
+	API      API
+	Ws       SpotWsApi //optional, depth or else ticker pushes
+	TakerFee float64
+	// SharedWs keeps the callbacks of a Ws that feeds others too: Start only subscribes
+	// and the caller forwards the pushes with Update and UpdateTicker. Else Start sets
+	// the depth and ticker callbacks of Ws, which must be dedicated to the scanner.
+	SharedWs bool
+}
+
+// Triangle is a cycle through three pairs of a venue from Start and back, both
+// ways round are scanned, e.g. USDT over BTC_USDT, ETH_BTC and ETH_USDT.
+type Triangle struct {
+	Venue string
+	Start Currency
+	Pairs [3]CurrencyPair
+}
+
+type Config struct {
+	Venues    []Venue
+	Triangles []Triangle
+	Cross     []CurrencyPair //pairs compared across all the venues
+	// MaxSize caps an opportunity, in its start currency. The books of a ticker
+	// have no amounts, their opportunities are sized by MaxSize, 1 when it is 0.
+	MaxSize             float64
+	MinReturn           float64       //profit over size an opportunity must beat, default 0
+	DepthSize           int           //default 20
+	PollInterval        time.Duration //of the venues without a ws, default 1s
+	MaxAge              time.Duration //older books are not used, default 10s
+	OpportunityCallback func(op Opportunity)
+	ErrorHandleFunc     func(venue string, err error)
+}
+
+// Leg is an order of an opportunity, an IOC limit order at the worst level it takes.
+type Leg struct {
+	Venue  string
+	Pair   CurrencyPair
+	Side   TradeSide
+	Price  float64
+	Amount float64 //in the base currency of Pair
+}
+
+// Send places the leg on api as an IOC limit order. The amount is truncated and
+// the price rounded to the tick sizes of Pair, 8 decimals when they are not set.
+func (l Leg) Send(api API) (*Order, error) {
+	amountDigits, priceDigits := int32(l.Pair.AmountTickSize), l.Pair.PriceTickSize
+	if amountDigits <= 0 {
+		amountDigits = 8
+	}
+	if priceDigits <= 0 {
+		priceDigits = 8
+	}
+	amount := DecimalFromFloat(l.Amount).Truncate(amountDigits).Normalize().String()
+	price := FloatToString(l.Price, priceDigits)
+	if l.Side == BUY {
+		return api.LimitBuy(amount, price, l.Pair, Ioc)
+	}
+	return api.LimitSell(amount, price, l.Pair, Ioc)
+}
+
+type Opportunity struct {
+	Kind   string //Triangular or CrossExchange
+	Start  Currency
+	Size   float64 //of Start put in
+	Profit float64 //of Start after the fees
+	Return float64 //Profit / Size
+	Legs   []Leg   //in the order of the conversions
+	Time   time.Time
+}
+
+type bookKey struct {
+	venue string
+	pair  string
+}
+
+type book struct {
+	bids, asks DepthRecords //best first, Amount 0 is unknown
+	updated    time.Time
+}
+
+// step converts the currency held into the other one of pair on a venue.
+type step struct {
+	venue *Venue
+	pair  CurrencyPair
+	side  TradeSide
+}
+
+type path struct {
+	kind  string
+	start Currency
+	steps []step
+}
+
+// Scanner keeps the books the paths need and evaluates the paths going through a
+// book each time it is updated.
+type Scanner struct {
+	sync.Mutex
+	conf   Config
+	venues map[string]*Venue
+	paths  []*path
+	byBook map[bookKey][]*path
+	books  map[bookKey]*book
+	now    func() time.Time
+}
+
+func NewScanner(conf *Config) (*Scanner, error) {
+	s := &Scanner{
+		conf:   *conf,
+		venues: make(map[string]*Venue),
+		byBook: make(map[bookKey][]*path),
+		books:  make(map[bookKey]*book),
+		now:    time.Now,
+	}
+	if s.conf.DepthSize <= 0 {
+		s.conf.DepthSize = 20
+	}
+	if s.conf.PollInterval <= 0 {
+		s.conf.PollInterval = time.Second
+	}
+	if s.conf.MaxAge <= 0 {
+		s.conf.MaxAge = 10 * time.Second
+	}
+
+	var names []string
+	for i := range s.conf.Venues {
+		v := s.conf.Venues[i]
+		if v.Name == "" && v.API != nil {
+			v.Name = v.API.GetExchangeName()
+		}
+		if v.Name == "" || s.venues[v.Name] != nil {
+			return nil, fmt.Errorf("venue name %q is empty or used twice", v.Name)
+		}
+		s.venues[v.Name] = &v
+		names = append(names, v.Name)
+	}
+
+	for _, t := range s.conf.Triangles {
+		v, ok := s.venues[t.Venue]
+		if !ok {
+			return nil, fmt.Errorf("unknown venue %s", t.Venue)
+		}
+		paths, err := trianglePaths(v, t)
+		if err != nil {
+			return nil, err
+		}
+		s.paths = append(s.paths, paths...)
+	}
+
+	for _, pair := range s.conf.Cross {
+		for _, buy := range names {
+			for _, sell := range names {
+				if buy == sell {
+					continue
+				}
+				s.paths = append(s.paths, &path{kind: CrossExchange, start: pair.CurrencyB, steps: []step{
+					{venue: s.venues[buy], pair: pair, side: BUY},
+					{venue: s.venues[sell], pair: pair, side: SELL}}})
+			}
+		}
+	}
+
+	if len(s.paths) == 0 {
+		return nil, errors.New("nothing to scan")
+	}
+	for _, p := range s.paths {
+		for _, st := range p.steps {
+			key := bookKey{st.venue.Name, st.pair.String()}
+			s.byBook[key] = append(s.byBook[key], p)
+		}
+	}
+	return s, nil
+}
+
+// convert tells how pair turns from into the other currency of the pair: selling
+// it when it is the base, buying the base with it when it is the quote.
+func convert(pair CurrencyPair, from Currency) (to Currency, side TradeSide, ok bool) {
+	oriented := pair
+	if !oriented.CurrencyA.Eq(from) {
+		oriented = pair.Reverse()
+	}
+	if !oriented.CurrencyA.Eq(from) {
+		return to, side, false
+	}
+	if oriented.Eq(pair) {
+		return oriented.CurrencyB, SELL, true
+	}
+	return oriented.CurrencyB, BUY, true
+}
+
+// trianglePaths returns the cycle of a triangle both ways round.
+func trianglePaths(v *Venue, t Triangle) ([]*path, error) {
+	var paths []*path
+	for first := range t.Pairs {
+		cur, side, ok := convert(t.Pairs[first], t.Start)
+		if !ok {
+			continue
+		}
+		p := &path{kind: Triangular, start: t.Start, steps: []step{{venue: v, pair: t.Pairs[first], side: side}}}
+		used := map[int]bool{first: true}
+		for len(p.steps) < 3 {
+			found := false
+			for i, pair := range t.Pairs {
+				if used[i] {
+					continue
+				}
+				var to Currency
+				if to, side, ok = convert(pair, cur); ok {
+					p.steps = append(p.steps, step{venue: v, pair: pair, side: side})
+					used[i], cur, found = true, to, true
+					break
+				}
+			}
+			if !found {
+				break
+			}
+		}
+		if len(p.steps) == 3 && cur.Eq(t.Start) {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) != 2 {
+		return nil, fmt.Errorf("%s: %v is not a cycle from %s", t.Venue, t.Pairs, t.Start)
+	}
+	return paths, nil
+}
+
+// Start subscribes the depth, or else the ticker, of the books on the venues with a
+// ws and polls the others until ctx is done.
+func (s *Scanner) Start(ctx context.Context) error {
+	for _, v := range s.venues {
+		if v.Ws == nil || v.SharedWs {
+			continue
+		}
+		name := v.Name
+		v.Ws.DepthCallback(func(depth *Depth) {
+			s.Update(name, depth)
+		})
+		v.Ws.TickerCallback(func(ticker *Ticker) {
+			s.UpdateTicker(name, ticker)
+		})
+	}
+
+	polled := make(map[bookKey]bool)
+	for key := range s.byBook {
+		v := s.venues[key.venue]
+		if v.Ws != nil {
+			pair := NewCurrencyPair2(key.pair)
+			err := v.Ws.SubscribeDepth(pair)
+			if err != nil {
+				err = v.Ws.SubscribeTicker(pair)
+			}
+			if err == nil {
+				continue
+			}
+			if v.API == nil {
+				return fmt.Errorf("%s %s: %w", key.venue, key.pair, err)
+			}
+			s.handleError(v.Name, err)
+		}
+		polled[key] = true
+	}
+
+	if len(polled) > 0 {
+		go func() {
+			tick := time.NewTicker(s.conf.PollInterval)
+			defer tick.Stop()
+			for {
+				s.poll(ctx, polled)
+				select {
+				case <-ctx.Done():
+					return
+				case <-tick.C:
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+func (s *Scanner) poll(ctx context.Context, keys map[bookKey]bool) {
+	var wg sync.WaitGroup
+	for key := range keys {
+		wg.Add(1)
+		go func(key bookKey) {
+			defer wg.Done()
+			v := s.venues[key.venue]
+			pair := NewCurrencyPair2(key.pair)
+			var (
+				dep *Depth
+				err error
+			)
+			if ctxApi, ok := v.API.(APIWithContext); ok {
+				dep, err = ctxApi.GetDepthWithContext(ctx, s.conf.DepthSize, pair)
+			} else {
+				dep, err = v.API.GetDepth(s.conf.DepthSize, pair)
+			}
+			if err != nil {
+				s.handleError(v.Name, err)
+				return
+			}
+			dep.Pair = pair
+			s.Update(v.Name, dep)
+		}(key)
+	}
+	wg.Wait()
+}
+
+// Poll reads the books of all the paths with GetDepth once and returns what Scan finds.
+func (s *Scanner) Poll(ctx context.Context) []Opportunity {
+	keys := make(map[bookKey]bool)
+	for key := range s.byBook {
+		if s.venues[key.venue].API != nil {
+			keys[key] = true
+		}
+	}
+	s.poll(ctx, keys)
+	return s.Scan()
+}
+
+func (s *Scanner) handleError(venue string, err error) {
+	if s.conf.ErrorHandleFunc != nil {
+		s.conf.ErrorHandleFunc(venue, err)
+		return
+	}
+	logger.Warnf("[arbitrage] %s: %s", venue, err.Error())
+}
+
+// bookOf is the book of a push, a push without pair is for the only book of the
+// venue as some adapters do not set it.
+func (s *Scanner) bookOf(venue string, pair CurrencyPair) (bookKey, error) {
+	if pair != (CurrencyPair{}) {
+		return bookKey{venue, pair.String()}, nil
+	}
+	var found []bookKey
+	for key := range s.byBook {
+		if key.venue == venue {
+			found = append(found, key)
+		}
+	}
+	if len(found) != 1 {
+		return bookKey{}, fmt.Errorf("a push without pair for one of the %d books of the venue", len(found))
+	}
+	return found[0], nil
+}
+
+// Update sets the book of a pair on a venue, from a feed of one's own too, and
+// calls OpportunityCallback for the opportunities of the paths through it.
+func (s *Scanner) Update(venue string, dep *Depth) {
+	key, err := s.bookOf(venue, dep.Pair)
+	if err != nil {
+		s.handleError(venue, err)
+		return
+	}
+	s.Lock()
+	paths, ok := s.byBook[key]
+	if !ok {
+		s.Unlock()
+		return
+	}
+	b := &book{
+		bids:    append(DepthRecords(nil), dep.BidList...),
+		asks:    append(DepthRecords(nil), dep.AskList...),
+		updated: s.now(),
+	}
+	sort.Sort(sort.Reverse(b.bids))
+	sort.Sort(b.asks)
+	s.books[key] = b
+
+	var found []Opportunity
+	for _, p := range paths {
+		if op, ok := s.evaluate(p); ok {
+			found = append(found, op)
+		}
+	}
+	s.Unlock()
+
+	if s.conf.OpportunityCallback != nil {
+		for _, op := range found {
+			s.conf.OpportunityCallback(op)
+		}
+	}
+}
+
+// UpdateTicker sets the book of a pair on a venue to the best bid and ask of a ticker.
+func (s *Scanner) UpdateTicker(venue string, ticker *Ticker) {
+	s.Update(venue, &Depth{Pair: ticker.Pair,
+		BidList: DepthRecords{{Price: ticker.Buy}}, AskList: DepthRecords{{Price: ticker.Sell}}})
+}
+
+// Scan evaluates every path on the books it has, the best return first.
+func (s *Scanner) Scan() []Opportunity {
+	s.Lock()
+	defer s.Unlock()
+
+	var found []Opportunity
+	for _, p := range s.paths {
+		if op, ok := s.evaluate(p); ok {
+			found = append(found, op)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Return > found[j].Return })
+	return found
+}
+
+// evaluate walks the levels of the steps together while one more unit of start
+// comes back as more than one unit, each chunk as large as the smallest level left.
+func (s *Scanner) evaluate(p *path) (Opportunity, bool) {
+	now := s.now()
+	n := len(p.steps)
+	levels := make([]DepthRecords, n)
+	for i, st := range p.steps {
+		b, ok := s.books[bookKey{st.venue.Name, st.pair.String()}]
+		if !ok || now.Sub(b.updated) > s.conf.MaxAge {
+			return Opportunity{}, false
+		}
+		levels[i] = b.asks
+		if st.side == SELL {
+			levels[i] = b.bids
+		}
+	}
+
+	idx := make([]int, n)
+	used := make([]float64, n) //base taken from the current level
+	legs := make([]Leg, n)
+	for i, st := range p.steps {
+		legs[i] = Leg{Venue: st.venue.Name, Pair: st.pair, Side: st.side}
+	}
+
+	var in, out float64
+	for {
+		//rate is one unit of start converted up to the step, capacity in start units
+		rate, capacity := 1.0, math.Inf(1)
+		for i, st := range p.steps {
+			if idx[i] >= len(levels[i]) {
+				return s.opportunity(p, legs, in, out, now)
+			}
+			lvl := levels[i][idx[i]]
+			if lvl.Price <= 0 {
+				return s.opportunity(p, legs, in, out, now)
+			}
+			left := math.Inf(1)
+			if lvl.Amount > 0 {
+				left = lvl.Amount - used[i]
+			}
+			fee := 1 - st.venue.TakerFee
+			if st.side == BUY {
+				capacity = math.Min(capacity, left*lvl.Price/rate)
+				rate *= fee / lvl.Price
+			} else {
+				capacity = math.Min(capacity, left/rate)
+				rate *= lvl.Price * fee
+			}
+		}
+		if rate <= 1 {
+			break
+		}
+
+		chunk := capacity
+		if s.conf.MaxSize > 0 {
+			chunk = math.Min(chunk, s.conf.MaxSize-in)
+		}
+		unknown := math.IsInf(chunk, 1)
+		if unknown {
+			chunk = 1
+		}
+		if chunk <= epsilon {
+			break
+		}
+
+		x := chunk
+		for i, st := range p.steps {
+			lvl := levels[i][idx[i]]
+			fee := 1 - st.venue.TakerFee
+			var base float64
+			if st.side == BUY {
+				base = x / lvl.Price
+				x = base * fee
+			} else {
+				base = x
+				x = base * lvl.Price * fee
+			}
+			legs[i].Price = lvl.Price
+			legs[i].Amount += base
+			used[i] += base
+			if lvl.Amount > 0 && used[i] >= lvl.Amount-epsilon {
+				idx[i]++
+				used[i] = 0
+			}
+		}
+		in += chunk
+		out += x
+		if unknown {
+			break
+		}
+	}
+	return s.opportunity(p, legs, in, out, now)
+}
+
+func (s *Scanner) opportunity(p *path, legs []Leg, in, out float64, now time.Time) (Opportunity, bool) {
+	if in <= epsilon {
+		return Opportunity{}, false
+	}
+	op := Opportunity{
+		Kind:   p.kind,
+		Start:  p.start,
+		Size:   in,
+		Profit: out - in,
+		Return: (out - in) / in,
+		Legs:   legs,
+		Time:   now,
+	}
+	return op, op.Return > s.conf.MinReturn
+}
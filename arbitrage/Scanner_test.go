@@ -0,0 +1,209 @@
+package arbitrage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/sim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner_Triangular(t *testing.T) {
+	var found []Opportunity
+	s, err := NewScanner(&Config{
+		Venues:              []Venue{{Name: "a"}},
+		Triangles:           []Triangle{{Venue: "a", Start: USDT, Pairs: [3]CurrencyPair{BTC_USDT, ETH_BTC, ETH_USDT}}},
+		OpportunityCallback: func(op Opportunity) { found = append(found, op) },
+	})
+	require.NoError(t, err)
+	require.Len(t, s.paths, 2)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.Update("a", &Depth{Pair: BTC_USDT,
+		AskList: DepthRecords{{Price: 100, Amount: 1}},
+		BidList: DepthRecords{{Price: 99, Amount: 1}}})
+	s.Update("a", &Depth{Pair: ETH_BTC,
+		AskList: DepthRecords{{Price: 0.05, Amount: 10}},
+		BidList: DepthRecords{{Price: 0.04, Amount: 10}}})
+	assert.Empty(t, found, "the cycle is not complete")
+
+	//usdt -> 0.01 btc -> 0.2 eth -> 1.1 usdt until the first eth bid runs out
+	s.Update("a", &Depth{Pair: ETH_USDT,
+		AskList: DepthRecords{{Price: 6, Amount: 10}},
+		BidList: DepthRecords{{Price: 5, Amount: 10}, {Price: 5.5, Amount: 5}}})
+	require.Len(t, found, 1)
+	op := found[0]
+	assert.Equal(t, Triangular, op.Kind)
+	assert.Equal(t, USDT, op.Start)
+	assert.InDelta(t, 25, op.Size, 1e-9)
+	assert.InDelta(t, 2.5, op.Profit, 1e-9)
+	assert.InDelta(t, 0.1, op.Return, 1e-9)
+	require.Len(t, op.Legs, 3)
+	assert.Equal(t, BUY, op.Legs[0].Side)
+	assert.InDelta(t, 0.25, op.Legs[0].Amount, 1e-9)
+	assert.Equal(t, BUY, op.Legs[1].Side)
+	assert.Equal(t, ETH_BTC, op.Legs[1].Pair)
+	assert.InDelta(t, 5, op.Legs[1].Amount, 1e-9)
+	assert.Equal(t, Leg{Venue: "a", Pair: ETH_USDT, Side: SELL, Price: 5.5, Amount: op.Legs[2].Amount}, op.Legs[2])
+	assert.InDelta(t, 5, op.Legs[2].Amount, 1e-9)
+
+	assert.Len(t, s.Scan(), 1)
+	now = now.Add(11 * time.Second)
+	assert.Empty(t, s.Scan(), "stale books")
+}
+
+func TestScanner_Cross(t *testing.T) {
+	a, b := sim.New(nil), sim.New(nil)
+	for _, ex := range []*sim.Exchange{a, b} {
+		ex.Deposit(BTC, 10)
+		ex.Deposit(USDT, 1e4)
+	}
+	_, err := a.LimitSell("2", "100", BTC_USDT)
+	require.NoError(t, err)
+	_, err = b.LimitBuy("1", "102", BTC_USDT)
+	require.NoError(t, err)
+
+	s, err := NewScanner(&Config{
+		Venues: []Venue{{Name: "a", API: a, TakerFee: 0.001}, {Name: "b", API: b, TakerFee: 0.001}},
+		Cross:  []CurrencyPair{BTC_USDT},
+	})
+	require.NoError(t, err)
+
+	found := s.Poll(context.Background())
+	require.Len(t, found, 1)
+	op := found[0]
+	assert.Equal(t, CrossExchange, op.Kind)
+	assert.Equal(t, USDT, op.Start)
+	assert.InDelta(t, 100.1001001, op.Size, 1e-6, "buys what sells as the 1 btc bid after the fee")
+	assert.InDelta(t, 101.898-op.Size, op.Profit, 1e-6)
+	assert.Equal(t, "a", op.Legs[0].Venue)
+	assert.Equal(t, BUY, op.Legs[0].Side)
+	assert.Equal(t, "b", op.Legs[1].Venue)
+	assert.InDelta(t, 1, op.Legs[1].Amount, 1e-9)
+
+	ord, err := op.Legs[1].Send(b)
+	require.NoError(t, err)
+	assert.Equal(t, ORDER_FINISH, ord.Status)
+	assert.Equal(t, 102.0, ord.AvgPrice)
+
+	s.conf.MaxSize = 50
+	found = s.Scan()
+	require.Len(t, found, 1)
+	assert.InDelta(t, 50, found[0].Size, 1e-9)
+	assert.InDelta(t, 0.5, found[0].Legs[0].Amount, 1e-9)
+}
+
+func TestScanner_TickerSize(t *testing.T) {
+	ws := &fakeSpotWs{depthErr: assert.AnError}
+	s, err := NewScanner(&Config{
+		Venues:    []Venue{{Name: "a", Ws: ws}, {Name: "b", Ws: &fakeSpotWs{}}},
+		Cross:     []CurrencyPair{ETH_USDT},
+		MinReturn: 0.01,
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Start(context.Background()))
+	assert.Equal(t, []string{"ticker"}, ws.subscribed)
+
+	ws.tickerCallback(&Ticker{Pair: ETH_USDT, Buy: 99, Sell: 100})
+	s.venues["b"].Ws.(*fakeSpotWs).depthCallback(&Depth{Pair: ETH_USDT,
+		BidList: DepthRecords{{Price: 100.5, Amount: 1}}})
+	assert.Empty(t, s.Scan(), "0.5% is under MinReturn")
+
+	s.venues["b"].Ws.(*fakeSpotWs).depthCallback(&Depth{Pair: ETH_USDT,
+		BidList: DepthRecords{{Price: 102, Amount: 0.5}}})
+	found := s.Scan()
+	require.Len(t, found, 1)
+	assert.InDelta(t, 50, found[0].Size, 1e-9, "sized by the depth of b")
+
+	s.conf.MaxSize = 0
+	ws.tickerCallback(&Ticker{Pair: ETH_USDT, Buy: 105, Sell: 101})
+	s.venues["b"].Ws.(*fakeSpotWs).tickerCallback(&Ticker{Pair: ETH_USDT, Buy: 103, Sell: 104})
+	found = s.Scan()
+	require.Len(t, found, 1)
+	assert.Equal(t, 1.0, found[0].Size, "no amounts and no MaxSize")
+	assert.Equal(t, "a", found[0].Legs[0].Venue)
+}
+
+func TestScanner_SharedWs(t *testing.T) {
+	ws := &fakeSpotWs{}
+	var errs []error
+	s, err := NewScanner(&Config{
+		Venues:          []Venue{{Name: "a", Ws: ws, SharedWs: true}, {Name: "b", Ws: &fakeSpotWs{}}},
+		Cross:           []CurrencyPair{ETH_USDT},
+		ErrorHandleFunc: func(venue string, err error) { errs = append(errs, err) },
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Start(context.Background()))
+	assert.Equal(t, []string{"depth"}, ws.subscribed)
+	assert.Nil(t, ws.depthCallback, "the callbacks of a shared ws are kept")
+
+	s.UpdateTicker("a", &Ticker{Buy: 99, Sell: 100})
+	s.venues["b"].Ws.(*fakeSpotWs).depthCallback(&Depth{BidList: DepthRecords{{Price: 102, Amount: 1}}})
+	found := s.Scan()
+	require.Len(t, found, 1, "the pushes without pair are for the only book of the venues")
+	assert.Equal(t, "a", found[0].Legs[0].Venue)
+	assert.Empty(t, errs)
+
+	s, err = NewScanner(&Config{
+		Venues:          []Venue{{Name: "a", Ws: ws, SharedWs: true}, {Name: "b", Ws: &fakeSpotWs{}}},
+		Cross:           []CurrencyPair{ETH_USDT, BTC_USDT},
+		ErrorHandleFunc: func(venue string, err error) { errs = append(errs, err) },
+	})
+	require.NoError(t, err)
+	s.UpdateTicker("a", &Ticker{Buy: 99, Sell: 100})
+	assert.Len(t, errs, 1, "which book is unknown")
+}
+
+func TestNewScanner_Errors(t *testing.T) {
+	_, err := NewScanner(&Config{Venues: []Venue{{Name: "a"}}})
+	assert.Error(t, err, "nothing to scan")
+
+	_, err = NewScanner(&Config{Venues: []Venue{{Name: "a"}, {Name: "a"}}, Cross: []CurrencyPair{BTC_USDT}})
+	assert.Error(t, err)
+
+	_, err = NewScanner(&Config{Venues: []Venue{{Name: "a"}},
+		Triangles: []Triangle{{Venue: "a", Start: USDT, Pairs: [3]CurrencyPair{BTC_USDT, ETH_BTC, LTC_BTC}}}})
+	assert.Error(t, err, "not a cycle")
+
+	_, err = NewScanner(&Config{Venues: []Venue{{Name: "a"}},
+		Triangles: []Triangle{{Venue: "b", Start: USDT, Pairs: [3]CurrencyPair{BTC_USDT, ETH_BTC, ETH_USDT}}}})
+	assert.Error(t, err, "unknown venue")
+}
+
+type fakeSpotWs struct {
+	depthCallback  func(*Depth)
+	tickerCallback func(*Ticker)
+	depthErr       error
+	subscribed     []string
+}
+
+func (ws *fakeSpotWs) DepthCallback(f func(*Depth))   { ws.depthCallback = f }
+func (ws *fakeSpotWs) TickerCallback(f func(*Ticker)) { ws.tickerCallback = f }
+func (ws *fakeSpotWs) TradeCallback(f func(*Trade))   {}
+func (ws *fakeSpotWs) SubscribeDepth(pair CurrencyPair) error {
+	if ws.depthErr != nil {
+		return ws.depthErr
+	}
+	ws.subscribed = append(ws.subscribed, "depth")
+	return nil
+}
+func (ws *fakeSpotWs) SubscribeTicker(pair CurrencyPair) error {
+	ws.subscribed = append(ws.subscribed, "ticker")
+	return nil
+}
+func (ws *fakeSpotWs) SubscribeTrade(pair CurrencyPair) error                       { return nil }
+func (ws *fakeSpotWs) KlineCallback(f func(*Kline, KlinePeriod, bool))              {}
+func (ws *fakeSpotWs) SubscribeKline(pair CurrencyPair, period KlinePeriod) error   { return nil }
+func (ws *fakeSpotWs) UnsubscribeDepth(pair CurrencyPair) error                     { return nil }
+func (ws *fakeSpotWs) UnsubscribeTicker(pair CurrencyPair) error                    { return nil }
+func (ws *fakeSpotWs) UnsubscribeTrade(pair CurrencyPair) error                     { return nil }
+func (ws *fakeSpotWs) UnsubscribeKline(pair CurrencyPair, period KlinePeriod) error { return nil }
+func (ws *fakeSpotWs) OrderCallback(f func(*Order))                                 {}
+func (ws *fakeSpotWs) AccountCallback(f func(*Account))                             {}
+func (ws *fakeSpotWs) Login() error                                                 { return nil }
+func (ws *fakeSpotWs) SubscribeOrder(pair CurrencyPair) error                       { return nil }
+func (ws *fakeSpotWs) SubscribeAccount(pair CurrencyPair) error                     { return nil }
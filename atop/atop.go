@@ -23,7 +23,6 @@ GetExchangeName() string
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 
 	. "github.com/soulsplit/goex"
@@ -133,6 +132,12 @@ var KlinePeriodConverter = map[KlinePeriod]string{
 	KLINE_PERIOD_1MONTH: "30day",
 }
 
+// adaptError maps a {"code":400,"info":"..."} response, atop documents no codes.
+func adaptError(respMap map[string]interface{}) ApiError {
+	msg, _ := respMap["info"].(string)
+	return CommonErrorMessages.Adapt(http.StatusOK, msg).WithNativeCode(fmt.Sprint(respMap["code"]))
+}
+
 type Exchange struct {
 	accessKey,
 	secretKey string
@@ -250,7 +255,7 @@ func (exchange *Exchange) plateOrder(amount, price string, currencyPair Currency
 
 	code := respMap["code"].(float64)
 	if code != 200 {
-		return nil, errors.New(respMap["info"].(string))
+		return nil, adaptError(respMap)
 	}
 
 	//return &Order{}, nil
@@ -350,7 +355,7 @@ func (exchange *Exchange) CancelOrder(orderId string, currencyPair CurrencyPair)
 	}
 	code := respMap["code"].(float64)
 	if code != 200 {
-		return false, errors.New(respMap["info"].(string))
+		return false, adaptError(respMap)
 	}
 
 	//orderIdCanceled := ToInt(respmap["orderId"])
@@ -386,7 +391,7 @@ func (exchange *Exchange) GetOneOrder(orderId string, currencyPair CurrencyPair)
 	code := respMap["code"].(float64)
 
 	if code != 200 {
-		return nil, errors.New(respMap["info"].(string))
+		return nil, adaptError(respMap)
 	}
 
 	data := respMap["data"].(map[string]interface{})
@@ -452,7 +457,7 @@ func (exchange *Exchange) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order,
 
 	code := respMap["code"].(float64)
 	if code != 200 {
-		return nil, errors.New(respMap["info"].(string))
+		return nil, adaptError(respMap)
 	}
 	data := respMap["data"].([]interface{})
 	orders := make([]Order, 0)
@@ -569,7 +574,7 @@ func (exchange *Exchange) GetOrderHistorys(currency CurrencyPair, optional ...Op
 
 	code := respMap["code"].(float64)
 	if code != 200 {
-		return nil, errors.New(respMap["info"].(string))
+		return nil, adaptError(respMap)
 	}
 	data := respMap["data"].(map[string]interface{})
 	records := data["record"].([]interface{})
@@ -619,7 +624,7 @@ func (exchange *Exchange) Withdraw(amount, memo string, currency Currency, fees,
 	if respMap["code"].(float64) == 200 {
 		return respMap["id"].(string), nil
 	}
-	return "", errors.New(string(resp))
+	return "", adaptError(respMap)
 }
 
 func (exchange *Exchange) CancelWithdraw(id string, currency Currency, safePwd string) (bool, error) {
@@ -0,0 +1,232 @@
+// Package backtest replays historical klines, trades and depth through the
+// simulated exchange of package sim, so a strategy written against goex.API and
+// goex.FutureRestAPI runs unchanged on history. Fees, slippage and the fill model
+// are the ones of sim.Config.
+package backtest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	. "github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/kline"
+	"github.com/soulsplit/goex/sim"
+)
+
+// Event is one piece of market data, exactly one of Kline, Trade and Depth is set.
+// ContractType is empty for spot.
+type Event struct {
+	Time         time.Time
+	Pair         CurrencyPair
+	ContractType string
+	Period       KlinePeriod //of Kline
+	Kline        *Kline
+	Trade        *Trade
+	Depth        *Depth
+}
+
+// price is the price the event tells for the pair.
+func (ev *Event) price() float64 {
+	switch {
+	case ev.Kline != nil:
+		return ev.Kline.Close
+	case ev.Trade != nil:
+		return ev.Trade.Price
+	case ev.Depth != nil && len(ev.Depth.BidList) > 0 && len(ev.Depth.AskList) > 0:
+		bid, ask := ev.Depth.BidList[0].Price, ev.Depth.AskList[0].Price
+		for _, r := range ev.Depth.BidList {
+			if r.Price > bid {
+				bid = r.Price
+			}
+		}
+		for _, r := range ev.Depth.AskList {
+			if r.Price < ask {
+				ask = r.Price
+			}
+		}
+		return (bid + ask) / 2
+	}
+	return 0
+}
+
+// KlineEvents returns the events of klines, each one happens when the kline closes.
+func KlineEvents(pair CurrencyPair, contractType string, period KlinePeriod, klines []Kline) []Event {
+	events := make([]Event, 0, len(klines))
+	for _, k := range kline.Normalize(klines) {
+		k := k
+		events = append(events, Event{Time: time.Unix(kline.Next(k.Timestamp, period), 0),
+			Pair: pair, ContractType: contractType, Period: period, Kline: &k})
+	}
+	return events
+}
+
+// TradeEvents returns the events of trades, Date is in milliseconds.
+func TradeEvents(pair CurrencyPair, contractType string, trades []Trade) []Event {
+	events := make([]Event, 0, len(trades))
+	for i := range trades {
+		t := trades[i]
+		events = append(events, Event{Time: time.Unix(0, t.Date*int64(time.Millisecond)),
+			Pair: pair, ContractType: contractType, Trade: &t})
+	}
+	return events
+}
+
+// DepthEvents returns the events of depth snapshots, at their UTime.
+func DepthEvents(pair CurrencyPair, contractType string, depths []Depth) []Event {
+	events := make([]Event, 0, len(depths))
+	for i := range depths {
+		d := depths[i]
+		events = append(events, Event{Time: d.UTime, Pair: pair, ContractType: contractType, Depth: &d})
+	}
+	return events
+}
+
+// Strategy is called after every event was applied to the exchange, with the
+// clock of the exchange at the time of the event.
+type Strategy interface {
+	OnEvent(ev *Event) error
+}
+
+type StrategyFunc func(ev *Event) error
+
+func (f StrategyFunc) OnEvent(ev *Event) error {
+	return f(ev)
+}
+
+type Config struct {
+	sim.Config                        //fees, slippage, fill model and leverage, Clock is set by the backtest
+	Balances     map[Currency]float64 //spot deposits
+	Margin       map[Currency]float64 //futures deposits
+	Quote        Currency             //currency of the equity, default USDT
+	SharpePeriod time.Duration        //sampling of the returns of the Sharpe ratio, default 24h
+}
+
+type Backtest struct {
+	conf    Config
+	now     time.Time
+	spot    *sim.Exchange
+	futures *sim.Futures
+	events  []Event
+	prices  map[Currency]float64 //in Quote
+	equity  []EquityPoint
+}
+
+func New(conf *Config) *Backtest {
+	bt := &Backtest{prices: make(map[Currency]float64)}
+	if conf != nil {
+		bt.conf = *conf
+	}
+	if bt.conf.Quote.Symbol == "" {
+		bt.conf.Quote = USDT
+	}
+	if bt.conf.SharpePeriod <= 0 {
+		bt.conf.SharpePeriod = 24 * time.Hour
+	}
+
+	simConf := bt.conf.Config
+	simConf.Clock = func() time.Time { return bt.now }
+	bt.spot = sim.New(&simConf)
+	bt.futures = bt.spot.Futures()
+	for c, amount := range bt.conf.Balances {
+		bt.spot.Deposit(c, amount)
+	}
+	for c, amount := range bt.conf.Margin {
+		bt.futures.Deposit(c, amount)
+	}
+	return bt
+}
+
+// API is the spot exchange the strategy trades on.
+func (bt *Backtest) API() API {
+	return bt.spot
+}
+
+// FutureAPI is the futures exchange the strategy trades on.
+func (bt *Backtest) FutureAPI() FutureRestAPI {
+	return bt.futures
+}
+
+// Add queues events to replay, the events of all the calls are replayed in time order.
+func (bt *Backtest) Add(events ...Event) {
+	bt.events = append(bt.events, events...)
+}
+
+// Run replays the events through the exchange and the strategy, then reports on
+// the account. Events of the same time are replayed in the order they were added.
+func (bt *Backtest) Run(ctx context.Context, strategy Strategy) (*Report, error) {
+	if len(bt.events) == 0 {
+		return nil, errors.New("no events to replay")
+	}
+	sort.SliceStable(bt.events, func(i, j int) bool {
+		return bt.events[i].Time.Before(bt.events[j].Time)
+	})
+
+	for i := range bt.events {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ev := &bt.events[i]
+		bt.now = ev.Time
+		bt.replay(ev)
+		if err := strategy.OnEvent(ev); err != nil {
+			return nil, err
+		}
+		bt.mark()
+	}
+	return bt.report(), nil
+}
+
+func (bt *Backtest) replay(ev *Event) {
+	if p := ev.price(); p > 0 && ev.Pair.CurrencyB.Eq(bt.conf.Quote) {
+		bt.prices[ev.Pair.CurrencyA] = p
+	}
+
+	switch {
+	case ev.ContractType == "" && ev.Kline != nil:
+		bt.spot.ReplayKline(ev.Pair, ev.Period, *ev.Kline)
+	case ev.ContractType == "" && ev.Trade != nil:
+		bt.spot.ReplayTrade(ev.Pair, *ev.Trade)
+	case ev.ContractType == "" && ev.Depth != nil:
+		bt.spot.ReplayDepth(ev.Pair, ev.Depth)
+	case ev.Kline != nil:
+		bt.futures.ReplayKline(ev.Pair, ev.ContractType, ev.Period, *ev.Kline)
+	case ev.Trade != nil:
+		bt.futures.ReplayTrade(ev.Pair, ev.ContractType, *ev.Trade)
+	case ev.Depth != nil:
+		bt.futures.ReplayDepth(ev.Pair, ev.ContractType, ev.Depth)
+	}
+}
+
+// value returns amount of currency in Quote, 0 when there was no price for it yet.
+func (bt *Backtest) value(currency Currency, amount float64) float64 {
+	if currency.Eq(bt.conf.Quote) {
+		return amount
+	}
+	return amount * bt.prices[currency]
+}
+
+// Equity is the value of the spot balances and of the futures accounts in Quote.
+func (bt *Backtest) Equity() float64 {
+	var equity float64
+	acc, _ := bt.spot.GetAccount()
+	for c, sub := range acc.SubAccounts {
+		equity += bt.value(c, sub.Amount+sub.ForzenAmount)
+	}
+	futAcc, _ := bt.futures.GetFutureUserinfo()
+	for c, sub := range futAcc.FutureSubAccounts {
+		equity += bt.value(c, sub.AccountRights)
+	}
+	return equity
+}
+
+// mark records the equity, once per time.
+func (bt *Backtest) mark() {
+	point := EquityPoint{Time: bt.now, Equity: bt.Equity()}
+	if n := len(bt.equity); n > 0 && bt.equity[n-1].Time.Equal(bt.now) {
+		bt.equity[n-1] = point
+		return
+	}
+	bt.equity = append(bt.equity, point)
+}
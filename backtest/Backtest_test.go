@@ -0,0 +1,128 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/sim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var t0 = time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func hourKlines(closes ...float64) []Kline {
+	klines := make([]Kline, len(closes))
+	for i, c := range closes {
+		open := c
+		if i > 0 {
+			open = closes[i-1]
+		}
+		klines[i] = Kline{Timestamp: t0.Unix() + int64(i)*3600, Open: open, Close: c,
+			High: math.Max(open, c), Low: math.Min(open, c), Vol: 10}
+	}
+	return klines
+}
+
+func TestBacktest_Run(t *testing.T) {
+	bt := New(&Config{
+		Config:   sim.Config{TakerFee: 0.001, MakerFee: 0.0005},
+		Balances: map[Currency]float64{USDT: 1000}})
+	bt.Add(KlineEvents(BTC_USDT, "", KLINE_PERIOD_1H, hourKlines(100, 100, 110, 90, 95, 120))...)
+
+	api := bt.API()
+	step := 0
+	report, err := bt.Run(context.Background(), StrategyFunc(func(ev *Event) error {
+		step++
+		switch step {
+		case 1:
+			_, err := api.MarketBuy("5", "", BTC_USDT)
+			return err
+		case 2:
+			klines, err := api.GetKlineRecords(BTC_USDT, KLINE_PERIOD_1H, 10)
+			require.NoError(t, err)
+			assert.Len(t, klines, 2)
+			ticker, _ := api.GetTicker(BTC_USDT)
+			assert.Equal(t, 100.0, ticker.Last)
+			//rests until the market trades at 115
+			_, err = api.LimitSell("2", "115", BTC_USDT)
+			return err
+		}
+		return nil
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, t0.Add(time.Hour).Unix(), report.Start.Unix(), "the first kline is known when it closes")
+	assert.Equal(t, t0.Add(6*time.Hour).Unix(), report.End.Unix())
+	require.Len(t, report.Trades, 2)
+	assert.Equal(t, 115.0, report.Trades[1].Price)
+	require.Len(t, report.Equity, 6)
+
+	btc := 5 * (1 - 0.001)
+	assert.InDelta(t, 500+btc*100, report.InitialEquity, 1e-9)
+	final := 500 + 2*115*(1-0.0005) + (btc-2)*120
+	assert.InDelta(t, final, report.FinalEquity, 1e-9)
+	assert.InDelta(t, final/report.InitialEquity-1, report.Return, 1e-12)
+
+	//the peak is at 110, the trough at 90
+	peak := 500 + btc*110
+	trough := 500 + btc*90
+	assert.InDelta(t, (peak-trough)/peak, report.MaxDrawdown, 1e-9)
+}
+
+func TestBacktest_Futures(t *testing.T) {
+	bt := New(&Config{Margin: map[Currency]float64{USDT: 1000}})
+	bt.Add(KlineEvents(BTC_USDT, QUARTER_CONTRACT, KLINE_PERIOD_1H, hourKlines(100, 90, 80))...)
+	bt.Add(TradeEvents(BTC_USDT, QUARTER_CONTRACT, []Trade{{Price: 85, Amount: 1, Date: t0.Add(150*time.Minute).UnixNano() / int64(time.Millisecond)}})...)
+
+	fut := bt.FutureAPI()
+	report, err := bt.Run(context.Background(), StrategyFunc(func(ev *Event) error {
+		if ev.Time.Equal(t0.Add(time.Hour)) {
+			_, err := fut.MarketFuturesOrder(BTC_USDT, QUARTER_CONTRACT, "1", OPEN_SELL)
+			return err
+		}
+		return nil
+	}))
+	require.NoError(t, err)
+	require.Len(t, report.Equity, 4)
+	assert.InDelta(t, 1015, report.Equity[2].Equity, 1e-9, "marked at the trade")
+	assert.InDelta(t, 1020, report.FinalEquity, 1e-9)
+	assert.Equal(t, QUARTER_CONTRACT, report.Trades[0].Misc)
+}
+
+func TestBacktest_Errors(t *testing.T) {
+	bt := New(nil)
+	_, err := bt.Run(context.Background(), StrategyFunc(func(ev *Event) error { return nil }))
+	assert.Error(t, err)
+
+	bt.Add(DepthEvents(BTC_USDT, "", []Depth{{UTime: t0,
+		AskList: DepthRecords{{Price: 101, Amount: 1}}, BidList: DepthRecords{{Price: 99, Amount: 1}}}})...)
+	_, err = bt.Run(context.Background(), StrategyFunc(func(ev *Event) error {
+		_, err := bt.API().MarketBuy("1", "", BTC_USDT)
+		return err
+	}))
+	assert.True(t, errors.Is(err, EX_ERR_INSUFFICIENT_BALANCE))
+}
+
+func TestSharpe(t *testing.T) {
+	var equity []EquityPoint
+	for i, e := range []float64{100, 101, 100, 102, 101, 103} {
+		equity = append(equity, EquityPoint{Time: t0.Add(time.Duration(i) * 12 * time.Hour), Equity: e})
+	}
+	//daily samples 100, 100, 101, 103
+	returns := []float64{0, 0.01, 103.0/101 - 1}
+	mean := (returns[0] + returns[1] + returns[2]) / 3
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	expected := mean / math.Sqrt(variance/2) * math.Sqrt(365)
+	assert.InDelta(t, expected, sharpe(equity, 24*time.Hour), 1e-9)
+
+	assert.Equal(t, 0.0, sharpe(equity[:2], 24*time.Hour))
+	assert.InDelta(t, 1.0/102, maxDrawdown(equity[3:]), 1e-12)
+}
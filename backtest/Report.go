@@ -0,0 +1,91 @@
+package backtest
+
+import (
+	"math"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+type EquityPoint struct {
+	Time   time.Time
+	Equity float64
+}
+
+type Report struct {
+	Start, End    time.Time
+	InitialEquity float64
+	FinalEquity   float64
+	Return        float64       //FinalEquity/InitialEquity - 1
+	Trades        []Trade       //spot and futures fills, futures ones have the contract type in Misc
+	Equity        []EquityPoint //after every replayed time
+	MaxDrawdown   float64       //largest fall of the equity from a peak, as a ratio of the peak
+	Sharpe        float64       //annualized, of the returns sampled every SharpePeriod, no risk free rate
+}
+
+func (bt *Backtest) report() *Report {
+	r := &Report{
+		Trades: bt.spot.AccountTrades(),
+		Equity: bt.equity,
+	}
+	first, last := bt.equity[0], bt.equity[len(bt.equity)-1]
+	r.Start, r.End = first.Time, last.Time
+	r.InitialEquity, r.FinalEquity = first.Equity, last.Equity
+	if r.InitialEquity > 0 {
+		r.Return = r.FinalEquity/r.InitialEquity - 1
+	}
+	r.MaxDrawdown = maxDrawdown(bt.equity)
+	r.Sharpe = sharpe(bt.equity, bt.conf.SharpePeriod)
+	return r
+}
+
+func maxDrawdown(equity []EquityPoint) float64 {
+	var peak, drawdown float64
+	for _, p := range equity {
+		if p.Equity > peak {
+			peak = p.Equity
+		}
+		if peak > 0 {
+			drawdown = math.Max(drawdown, (peak-p.Equity)/peak)
+		}
+	}
+	return drawdown
+}
+
+// sharpe samples the equity at the end of every period since the first point and
+// annualizes the mean over the standard deviation of the returns.
+func sharpe(equity []EquityPoint, period time.Duration) float64 {
+	samples := []float64{equity[0].Equity}
+	next := equity[0].Time.Add(period)
+	for i, p := range equity {
+		for p.Time.After(next) {
+			samples = append(samples, equity[i-1].Equity)
+			next = next.Add(period)
+		}
+	}
+	samples = append(samples, equity[len(equity)-1].Equity)
+
+	var returns []float64
+	for i := 1; i < len(samples); i++ {
+		if samples[i-1] > 0 {
+			returns = append(returns, samples[i]/samples[i-1]-1)
+		}
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	var mean, variance float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	if std == 0 {
+		return 0
+	}
+	return mean / std * math.Sqrt(float64(365*24*time.Hour)/float64(period))
+}
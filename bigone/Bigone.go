@@ -21,6 +21,21 @@ const (
 	ORDERS_URI  = "%s/viewer/orders"
 )
 
+var errorCodes = goex.ErrorCodes{
+	"10005": goex.EX_ERR_SERVER,
+	"10007": goex.EX_ERR_INVALID_PARAMETER,
+	"10013": goex.EX_ERR_SYMBOL_ERR,
+	"10014": goex.EX_ERR_INSUFFICIENT_BALANCE,
+	"40004": goex.EX_ERR_AUTH,
+	"40301": goex.EX_ERR_AUTH,
+	"40603": goex.EX_ERR_INSUFFICIENT_BALANCE,
+	"54041": goex.EX_ERR_DUPLICATE_CLIENT_OID,
+}
+
+func adaptError(code int, msg string) goex.ApiError {
+	return errorCodes.Adapt(http.StatusOK, fmt.Sprint(code), msg)
+}
+
 type Exchange struct {
 	accessKey,
 	secretKey string
@@ -147,7 +162,7 @@ func (exchange *Exchange) placeOrder(amount, price string, pair goex.CurrencyPai
 
 	if len(resp.Errors) > 0 {
 		log.Printf("placeOrder - failed : %v", resp.Errors)
-		return nil, fmt.Errorf(resp.Errors[0].Message)
+		return nil, adaptError(resp.Errors[0].Code, resp.Errors[0].Message)
 	}
 
 	side := goex.BUY
@@ -335,7 +350,7 @@ func (exchange *Exchange) CancelOrder(orderId string, currency goex.CurrencyPair
 	}
 	if len(resp.Errors) > 0 {
 		log.Printf("getOrdersList - response error : %v", resp.Errors)
-		return false, fmt.Errorf("%s", resp.Errors[0].Message)
+		return false, adaptError(resp.Errors[0].Code, resp.Errors[0].Message)
 	}
 	return true, nil
 }
@@ -135,7 +135,7 @@ func (bo *BigoneV3) placeOrder(amount, price string, pair goex.CurrencyPair, ord
 
 	if len(resp.Errors) > 0 {
 		log.Printf("placeOrder - failed : %v", resp.Errors)
-		return nil, fmt.Errorf(resp.Errors[0].Message)
+		return nil, adaptError(resp.Errors[0].Code, resp.Errors[0].Message)
 	}
 
 	side := goex.BUY
@@ -284,7 +284,7 @@ func (bo *BigoneV3) CancelOrder(orderId string, currency goex.CurrencyPair) (boo
 	}
 	if len(resp.Errors) > 0 {
 		log.Printf("getOrdersList - response error : %v", resp.Errors)
-		return false, fmt.Errorf("%s", resp.Errors[0].Message)
+		return false, adaptError(resp.Errors[0].Code, resp.Errors[0].Message)
 	}
 	return true, nil
 }
@@ -1,30 +1,35 @@
 package binance
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/soulsplit/goex"
 )
 
-func adaptStreamToCurrencyPair(stream string) goex.CurrencyPair {
-	symbol := strings.Split(stream, "@")[0]
-
-	if strings.HasSuffix(symbol, "usdt") {
-		return goex.NewCurrencyPair2(fmt.Sprintf("%s_usdt", strings.TrimSuffix(symbol, "usdt")))
-	}
-
-	if strings.HasSuffix(symbol, "usd") {
-		return goex.NewCurrencyPair2(fmt.Sprintf("%s_usd", strings.TrimSuffix(symbol, "usd")))
-	}
+// adaptStreamToCurrencyPair resolves the symbol of a spot stream such as btcusdt@ticker.
+func adaptStreamToCurrencyPair(stream string) (goex.CurrencyPair, error) {
+	return goex.Symbols.Resolve(goex.BINANCE, strings.Split(stream, "@")[0], adaptSymbolToCurrencyPair)
+}
 
-	if strings.HasSuffix(symbol, "btc") {
-		return goex.NewCurrencyPair2(fmt.Sprintf("%s_btc", strings.TrimSuffix(symbol, "btc")))
+// adaptContractSymbol resolves the symbol s of a futures message. The pair of a
+// coin margined contract such as BTCUSD_PERP is in ps, a usdt margined symbol is the pair.
+func adaptContractSymbol(m map[string]interface{}) (goex.CurrencyPair, error) {
+	symbol, _ := m["s"].(string)
+	if pair, ok := m["ps"].(string); ok {
+		return goex.Symbols.Resolve(goex.BINANCE_FUTURES, symbol, func(string) goex.CurrencyPair {
+			return adaptSymbolToCurrencyPair(pair)
+		})
 	}
-
-	return goex.UNKNOWN_PAIR
+	return goex.Symbols.Resolve(goex.BINANCE_SWAP, symbol, adaptSymbolToCurrencyPair)
 }
 
+// adaptSymbolToCurrencyPair guesses the pair by the quote suffix, it is the fallback
+// of goex.Symbols until the instruments are loaded.
 func adaptSymbolToCurrencyPair(symbol string) goex.CurrencyPair {
 	symbol = strings.ToUpper(symbol)
 
@@ -56,7 +61,7 @@ func adaptOrderStatus(status string) goex.TradeStatus {
 		tradeStatus = goex.ORDER_FINISH
 	case "PARTIALLY_FILLED":
 		tradeStatus = goex.ORDER_PART_FINISH
-	case "CANCELED":
+	case "CANCELED", "EXPIRED":
 		tradeStatus = goex.ORDER_CANCEL
 	case "PENDING_CANCEL":
 		tradeStatus = goex.ORDER_CANCEL_ING
@@ -65,3 +70,122 @@ func adaptOrderStatus(status string) goex.TradeStatus {
 	}
 	return tradeStatus
 }
+
+var errorCodes = goex.ErrorCodes{
+	"-1000": goex.EX_ERR_SERVER,
+	"-1001": goex.EX_ERR_SERVER,
+	"-1003": goex.EX_ERR_API_LIMIT,
+	"-1006": goex.EX_ERR_SERVER,
+	"-1007": goex.EX_ERR_SERVER,
+	"-1013": goex.EX_ERR_INVALID_PARAMETER,
+	"-1015": goex.EX_ERR_API_LIMIT,
+	"-1016": goex.EX_ERR_MAINTENANCE,
+	"-1021": goex.EX_ERR_TIMESTAMP,
+	"-1022": goex.EX_ERR_SIGN,
+	"-1100": goex.EX_ERR_INVALID_PARAMETER,
+	"-1101": goex.EX_ERR_INVALID_PARAMETER,
+	"-1102": goex.EX_ERR_INVALID_PARAMETER,
+	"-1103": goex.EX_ERR_INVALID_PARAMETER,
+	"-1104": goex.EX_ERR_INVALID_PARAMETER,
+	"-1105": goex.EX_ERR_INVALID_PARAMETER,
+	"-1106": goex.EX_ERR_INVALID_PARAMETER,
+	"-1111": goex.EX_ERR_INVALID_PARAMETER,
+	"-1116": goex.EX_ERR_INVALID_PARAMETER,
+	"-1117": goex.EX_ERR_INVALID_PARAMETER,
+	"-1121": goex.EX_ERR_INVALID_CURRENCY_PAIR,
+	"-2010": goex.EX_ERR_PLACE_ORDER_FAIL,
+	"-2011": goex.EX_ERR_CANCEL_ORDER_FAIL,
+	"-2013": goex.EX_ERR_NOT_FIND_ORDER,
+	"-2014": goex.EX_ERR_AUTH,
+	"-2015": goex.EX_ERR_AUTH,
+	"-2018": goex.EX_ERR_INSUFFICIENT_BALANCE,
+	"-2019": goex.EX_ERR_INSUFFICIENT_BALANCE,
+	"-4015": goex.EX_ERR_INVALID_PARAMETER,
+	"-4116": goex.EX_ERR_DUPLICATE_CLIENT_OID,
+	"-4164": goex.EX_ERR_INVALID_PARAMETER,
+}
+
+// adaptError maps the {"code":-2011,"msg":"Unknown order sent."} body of a failed
+// request to a goex.ApiError, other errors are returned as they are.
+func adaptError(err error) error {
+	var apiErr goex.ApiError
+	if !errors.As(err, &apiErr) || apiErr.HttpStatus == 0 {
+		return err
+	}
+	if code, msg, ok := parseErrorBody([]byte(apiErr.OriginErrMsg)); ok {
+		return adaptErrorCode(apiErr.HttpStatus, code, msg)
+	}
+	return err
+}
+
+// adaptErrorBody is for the error bodies binance sends with a 200 status.
+func adaptErrorBody(body []byte) error {
+	if code, msg, ok := parseErrorBody(body); ok {
+		return adaptErrorCode(http.StatusOK, code, msg)
+	}
+	return goex.API_ERR.OriginErr(string(body)).WithHttpStatus(http.StatusOK)
+}
+
+// adaptErrorResponse is for a code and msg already decoded from a 200 response.
+func adaptErrorResponse(code int, msg string) error {
+	return adaptErrorCode(http.StatusOK, code, msg)
+}
+
+func parseErrorBody(body []byte) (code int, msg string, ok bool) {
+	var resp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if json.Unmarshal(body, &resp) != nil || resp.Code == 0 || resp.Code == http.StatusOK {
+		return 0, "", false
+	}
+	return resp.Code, resp.Msg, true
+}
+
+func adaptErrorCode(httpStatus, code int, msg string) goex.ApiError {
+	e := errorCodes.Adapt(httpStatus, strconv.Itoa(code), msg)
+
+	//-2010 and -2011 tell the reason in the message only
+	lowerMsg := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lowerMsg, "insufficient"):
+		e.ErrCode = goex.EX_ERR_INSUFFICIENT_BALANCE.ErrCode
+	case strings.Contains(lowerMsg, "duplicate order"):
+		e.ErrCode = goex.EX_ERR_DUPLICATE_CLIENT_OID.ErrCode
+	case strings.Contains(lowerMsg, "unknown order sent"), strings.Contains(lowerMsg, "order does not exist"):
+		e.ErrCode = goex.EX_ERR_NOT_FIND_ORDER.ErrCode
+	case strings.Contains(lowerMsg, "too much request"):
+		e.ErrCode = goex.EX_ERR_API_LIMIT.ErrCode
+	case strings.Contains(lowerMsg, "filter failure: min_notional"), strings.Contains(lowerMsg, "filter failure: notional"):
+		e.ErrCode = goex.EX_ERR_MIN_NOTIONAL.ErrCode
+	}
+	return e
+}
+
+// adaptWsKline parses the k object of a kline stream, x tells whether the candle is closed.
+func adaptWsKline(k map[string]interface{}) (*goex.Kline, bool) {
+	closed, _ := k["x"].(bool)
+	return &goex.Kline{
+		Timestamp: goex.ToInt64(k["t"]) / 1000,
+		Open:      goex.ToFloat64(k["o"]),
+		Close:     goex.ToFloat64(k["c"]),
+		High:      goex.ToFloat64(k["h"]),
+		Low:       goex.ToFloat64(k["l"]),
+		Vol:       goex.ToFloat64(k["v"]),
+	}, closed
+}
+
+// adaptWsTrade parses a trade event, the side follows GetTrades.
+func adaptWsTrade(m map[string]interface{}) *goex.Trade {
+	side := goex.SELL
+	if isBuyerMaker, _ := m["m"].(bool); isBuyerMaker {
+		side = goex.BUY
+	}
+	return &goex.Trade{
+		Tid:    goex.ToInt64(m["t"]),
+		Type:   side,
+		Amount: goex.ToFloat64(m["q"]),
+		Price:  goex.ToFloat64(m["p"]),
+		Date:   goex.ToInt64(m["T"]),
+	}
+}
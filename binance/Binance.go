@@ -1,6 +1,7 @@
 package binance
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -217,7 +218,7 @@ func (exchange *Exchange) Ping() bool {
 func (exchange *Exchange) setTimeOffset() error {
 	respmap, err := HttpGet(exchange.httpClient, exchange.apiV3+SERVER_TIME_URL)
 	if err != nil {
-		return err
+		return adaptError(err)
 	}
 
 	stime := int64(ToInt(respmap["serverTime"]))
@@ -229,11 +230,15 @@ func (exchange *Exchange) setTimeOffset() error {
 }
 
 func (exchange *Exchange) GetTicker(currency CurrencyPair) (*Ticker, error) {
+	return exchange.GetTickerWithContext(context.Background(), currency)
+}
+
+func (exchange *Exchange) GetTickerWithContext(ctx context.Context, currency CurrencyPair) (*Ticker, error) {
 	tickerUri := exchange.apiV3 + fmt.Sprintf(TICKER_URI, currency.ToSymbol(""))
-	tickerMap, err := HttpGet(exchange.httpClient, tickerUri)
+	tickerMap, err := HttpGetWithContext(ctx, exchange.httpClient, tickerUri)
 
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	var ticker Ticker
@@ -250,6 +255,10 @@ func (exchange *Exchange) GetTicker(currency CurrencyPair) (*Ticker, error) {
 }
 
 func (exchange *Exchange) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error) {
+	return exchange.GetDepthWithContext(context.Background(), size, currencyPair)
+}
+
+func (exchange *Exchange) GetDepthWithContext(ctx context.Context, size int, currencyPair CurrencyPair) (*Depth, error) {
 	if size <= 5 {
 		size = 5
 	} else if size <= 10 {
@@ -267,13 +276,13 @@ func (exchange *Exchange) GetDepth(size int, currencyPair CurrencyPair) (*Depth,
 	}
 
 	apiUrl := fmt.Sprintf(exchange.apiV3+DEPTH_URI, currencyPair.ToSymbol(""), size)
-	resp, err := HttpGet(exchange.httpClient, apiUrl)
+	resp, err := HttpGetWithContext(ctx, exchange.httpClient, apiUrl)
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	if _, isok := resp["code"]; isok {
-		return nil, errors.New(resp["msg"].(string))
+		return nil, adaptErrorResponse(ToInt(resp["code"]), resp["msg"].(string))
 	}
 
 	bids := resp["bids"].([]interface{})
@@ -282,6 +291,7 @@ func (exchange *Exchange) GetDepth(size int, currencyPair CurrencyPair) (*Depth,
 	depth := new(Depth)
 	depth.Pair = currencyPair
 	depth.UTime = time.Now()
+	depth.UpdateId = ToInt64(resp["lastUpdateId"])
 	n := 0
 	for _, bid := range bids {
 		_bid := bid.([]interface{})
@@ -313,7 +323,7 @@ func (exchange *Exchange) GetDepth(size int, currencyPair CurrencyPair) (*Depth,
 	return depth, nil
 }
 
-func (exchange *Exchange) placeOrder(amount, price string, pair CurrencyPair, orderType, orderSide string) (*Order, error) {
+func (exchange *Exchange) placeOrder(ctx context.Context, amount, price string, pair CurrencyPair, orderType, orderSide string) (*Order, error) {
 	path := exchange.apiV3 + ORDER_URI
 	params := url.Values{}
 	params.Set("symbol", pair.ToSymbol(""))
@@ -332,10 +342,10 @@ func (exchange *Exchange) placeOrder(amount, price string, pair CurrencyPair, or
 
 	exchange.buildParamsSigned(&params)
 
-	resp, err := HttpPostForm2(exchange.httpClient, path, params,
+	resp, err := HttpPostForm2WithContext(ctx, exchange.httpClient, path, params,
 		map[string]string{"X-MBX-APIKEY": exchange.accessKey})
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	respmap := make(map[string]interface{})
@@ -346,7 +356,7 @@ func (exchange *Exchange) placeOrder(amount, price string, pair CurrencyPair, or
 
 	orderId := ToInt(respmap["orderId"])
 	if orderId <= 0 {
-		return nil, errors.New(string(resp))
+		return nil, adaptErrorBody(resp)
 	}
 
 	side := BUY
@@ -375,15 +385,19 @@ func (exchange *Exchange) placeOrder(amount, price string, pair CurrencyPair, or
 }
 
 func (exchange *Exchange) GetAccount() (*Account, error) {
+	return exchange.GetAccountWithContext(context.Background())
+}
+
+func (exchange *Exchange) GetAccountWithContext(ctx context.Context) (*Account, error) {
 	params := url.Values{}
 	exchange.buildParamsSigned(&params)
 	path := exchange.apiV3 + ACCOUNT_URI + params.Encode()
-	respmap, err := HttpGet2(exchange.httpClient, path, map[string]string{"X-MBX-APIKEY": exchange.accessKey})
+	respmap, err := HttpGet2WithContext(ctx, exchange.httpClient, path, map[string]string{"X-MBX-APIKEY": exchange.accessKey})
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 	if _, isok := respmap["code"]; isok == true {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, adaptErrorResponse(ToInt(respmap["code"]), respmap["msg"].(string))
 	}
 	acc := Account{}
 	acc.Exchange = exchange.GetExchangeName()
@@ -404,22 +418,42 @@ func (exchange *Exchange) GetAccount() (*Account, error) {
 }
 
 func (exchange *Exchange) LimitBuy(amount, price string, currencyPair CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
-	return exchange.placeOrder(amount, price, currencyPair, "LIMIT", "BUY")
+	return exchange.LimitBuyWithContext(context.Background(), amount, price, currencyPair, opt...)
+}
+
+func (exchange *Exchange) LimitBuyWithContext(ctx context.Context, amount, price string, currencyPair CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	return exchange.placeOrder(ctx, amount, price, currencyPair, "LIMIT", "BUY")
 }
 
 func (exchange *Exchange) LimitSell(amount, price string, currencyPair CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
-	return exchange.placeOrder(amount, price, currencyPair, "LIMIT", "SELL")
+	return exchange.LimitSellWithContext(context.Background(), amount, price, currencyPair, opt...)
+}
+
+func (exchange *Exchange) LimitSellWithContext(ctx context.Context, amount, price string, currencyPair CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	return exchange.placeOrder(ctx, amount, price, currencyPair, "LIMIT", "SELL")
 }
 
 func (exchange *Exchange) MarketBuy(amount, price string, currencyPair CurrencyPair) (*Order, error) {
-	return exchange.placeOrder(amount, price, currencyPair, "MARKET", "BUY")
+	return exchange.MarketBuyWithContext(context.Background(), amount, price, currencyPair)
+}
+
+func (exchange *Exchange) MarketBuyWithContext(ctx context.Context, amount, price string, currencyPair CurrencyPair) (*Order, error) {
+	return exchange.placeOrder(ctx, amount, price, currencyPair, "MARKET", "BUY")
 }
 
 func (exchange *Exchange) MarketSell(amount, price string, currencyPair CurrencyPair) (*Order, error) {
-	return exchange.placeOrder(amount, price, currencyPair, "MARKET", "SELL")
+	return exchange.MarketSellWithContext(context.Background(), amount, price, currencyPair)
+}
+
+func (exchange *Exchange) MarketSellWithContext(ctx context.Context, amount, price string, currencyPair CurrencyPair) (*Order, error) {
+	return exchange.placeOrder(ctx, amount, price, currencyPair, "MARKET", "SELL")
 }
 
 func (exchange *Exchange) CancelOrder(orderId string, currencyPair CurrencyPair) (bool, error) {
+	return exchange.CancelOrderWithContext(context.Background(), orderId, currencyPair)
+}
+
+func (exchange *Exchange) CancelOrderWithContext(ctx context.Context, orderId string, currencyPair CurrencyPair) (bool, error) {
 	path := exchange.apiV3 + ORDER_URI
 	params := url.Values{}
 	params.Set("symbol", currencyPair.ToSymbol(""))
@@ -427,10 +461,10 @@ func (exchange *Exchange) CancelOrder(orderId string, currencyPair CurrencyPair)
 
 	exchange.buildParamsSigned(&params)
 
-	resp, err := HttpDeleteForm(exchange.httpClient, path, params, map[string]string{"X-MBX-APIKEY": exchange.accessKey})
+	resp, err := HttpDeleteFormWithContext(ctx, exchange.httpClient, path, params, map[string]string{"X-MBX-APIKEY": exchange.accessKey})
 
 	if err != nil {
-		return false, exchange.adaptError(err)
+		return false, adaptError(err)
 	}
 
 	respmap := make(map[string]interface{})
@@ -441,13 +475,17 @@ func (exchange *Exchange) CancelOrder(orderId string, currencyPair CurrencyPair)
 
 	orderIdCanceled := ToInt(respmap["orderId"])
 	if orderIdCanceled <= 0 {
-		return false, errors.New(string(resp))
+		return false, adaptErrorBody(resp)
 	}
 
 	return true, nil
 }
 
 func (exchange *Exchange) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Order, error) {
+	return exchange.GetOneOrderWithContext(context.Background(), orderId, currencyPair)
+}
+
+func (exchange *Exchange) GetOneOrderWithContext(ctx context.Context, orderId string, currencyPair CurrencyPair) (*Order, error) {
 	params := url.Values{}
 	params.Set("symbol", currencyPair.ToSymbol(""))
 	if orderId != "" {
@@ -458,9 +496,9 @@ func (exchange *Exchange) GetOneOrder(orderId string, currencyPair CurrencyPair)
 	exchange.buildParamsSigned(&params)
 	path := exchange.apiV3 + ORDER_URI + "?" + params.Encode()
 
-	respmap, err := HttpGet2(exchange.httpClient, path, map[string]string{"X-MBX-APIKEY": exchange.accessKey})
+	respmap, err := HttpGet2WithContext(ctx, exchange.httpClient, path, map[string]string{"X-MBX-APIKEY": exchange.accessKey})
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	order := exchange.adaptOrder(currencyPair, respmap)
@@ -469,15 +507,19 @@ func (exchange *Exchange) GetOneOrder(orderId string, currencyPair CurrencyPair)
 }
 
 func (exchange *Exchange) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error) {
+	return exchange.GetUnfinishOrdersWithContext(context.Background(), currencyPair)
+}
+
+func (exchange *Exchange) GetUnfinishOrdersWithContext(ctx context.Context, currencyPair CurrencyPair) ([]Order, error) {
 	params := url.Values{}
 	params.Set("symbol", currencyPair.ToSymbol(""))
 
 	exchange.buildParamsSigned(&params)
 	path := exchange.apiV3 + UNFINISHED_ORDERS_INFO + params.Encode()
 
-	respmap, err := HttpGet3(exchange.httpClient, path, map[string]string{"X-MBX-APIKEY": exchange.accessKey})
+	respmap, err := HttpGet3WithContext(ctx, exchange.httpClient, path, map[string]string{"X-MBX-APIKEY": exchange.accessKey})
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	orders := make([]Order, 0)
@@ -490,6 +532,10 @@ func (exchange *Exchange) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order,
 }
 
 func (exchange *Exchange) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
+	return exchange.GetKlineRecordsWithContext(context.Background(), currency, period, size, optional...)
+}
+
+func (exchange *Exchange) GetKlineRecordsWithContext(ctx context.Context, currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
 	params := url.Values{}
 	params.Set("symbol", currency.ToSymbol(""))
 	params.Set("interval", _INERNAL_KLINE_PERIOD_CONVERTER[period])
@@ -497,9 +543,9 @@ func (exchange *Exchange) GetKlineRecords(currency CurrencyPair, period KlinePer
 	MergeOptionalParameter(&params, optional...)
 
 	klineUrl := exchange.apiV3 + KLINE_URI + "?" + params.Encode()
-	klines, err := HttpGet3(exchange.httpClient, klineUrl, nil)
+	klines, err := HttpGet3WithContext(ctx, exchange.httpClient, klineUrl, nil)
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 	var klineRecords []Kline
 
@@ -523,6 +569,10 @@ func (exchange *Exchange) GetKlineRecords(currency CurrencyPair, period KlinePer
 //非个人，整个交易所的交易记录
 //注意：since is fromId
 func (exchange *Exchange) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	return exchange.GetTradesWithContext(context.Background(), currencyPair, since)
+}
+
+func (exchange *Exchange) GetTradesWithContext(ctx context.Context, currencyPair CurrencyPair, since int64) ([]Trade, error) {
 	param := url.Values{}
 	param.Set("symbol", currencyPair.ToSymbol(""))
 	param.Set("limit", "500")
@@ -530,10 +580,10 @@ func (exchange *Exchange) GetTrades(currencyPair CurrencyPair, since int64) ([]T
 		param.Set("fromId", strconv.Itoa(int(since)))
 	}
 	apiUrl := exchange.apiV3 + "historicalTrades?" + param.Encode()
-	resp, err := HttpGet3(exchange.httpClient, apiUrl, map[string]string{
+	resp, err := HttpGet3WithContext(ctx, exchange.httpClient, apiUrl, map[string]string{
 		"X-MBX-APIKEY": exchange.accessKey})
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	var trades []Trade
@@ -557,6 +607,10 @@ func (exchange *Exchange) GetTrades(currencyPair CurrencyPair, since int64) ([]T
 }
 
 func (exchange *Exchange) GetOrderHistorys(currency CurrencyPair, optional ...OptionalParameter) ([]Order, error) {
+	return exchange.GetOrderHistorysWithContext(context.Background(), currency, optional...)
+}
+
+func (exchange *Exchange) GetOrderHistorysWithContext(ctx context.Context, currency CurrencyPair, optional ...OptionalParameter) ([]Order, error) {
 	params := url.Values{}
 	params.Set("symbol", currency.AdaptUsdToUsdt().ToSymbol(""))
 	MergeOptionalParameter(&params, optional...)
@@ -564,9 +618,9 @@ func (exchange *Exchange) GetOrderHistorys(currency CurrencyPair, optional ...Op
 
 	path := exchange.apiV3 + "allOrders?" + params.Encode()
 
-	respmap, err := HttpGet3(exchange.httpClient, path, map[string]string{"X-MBX-APIKEY": exchange.accessKey})
+	respmap, err := HttpGet3WithContext(ctx, exchange.httpClient, path, map[string]string{"X-MBX-APIKEY": exchange.accessKey})
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	orders := make([]Order, 0)
@@ -598,13 +652,15 @@ func (exchange *Exchange) toCurrencyPair(symbol string) CurrencyPair {
 func (exchange *Exchange) GetExchangeInfo() (*ExchangeInfo, error) {
 	resp, err := HttpGet5(exchange.httpClient, exchange.apiV3+"exchangeInfo", nil)
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 	info := &ExchangeInfo{}
 	err = json.Unmarshal(resp, info)
 	if err != nil {
 		return nil, err
 	}
+	seedRateLimits(exchange.httpClient, info.RateLimits, SpotRateLimits())
+	Symbols.RegisterInstruments(BINANCE, spotInstruments(info.Symbols))
 
 	return info, nil
 }
@@ -625,25 +681,6 @@ func (exchange *Exchange) GetTradeSymbol(currencyPair CurrencyPair) (*TradeSymbo
 	return nil, errors.New("symbol not found")
 }
 
-func (exchange *Exchange) adaptError(err error) error {
-	errStr := err.Error()
-
-	if strings.Contains(errStr, "Order does not exist") ||
-		strings.Contains(errStr, "Unknown order sent") {
-		return EX_ERR_NOT_FIND_ORDER.OriginErr(errStr)
-	}
-
-	if strings.Contains(errStr, "Too much request") {
-		return EX_ERR_API_LIMIT.OriginErr(errStr)
-	}
-
-	if strings.Contains(errStr, "insufficient") {
-		return EX_ERR_INSUFFICIENT_BALANCE.OriginErr(errStr)
-	}
-
-	return err
-}
-
 func (exchange *Exchange) adaptOrder(currencyPair CurrencyPair, orderMap map[string]interface{}) Order {
 	side := orderMap["side"].(string)
 
@@ -65,18 +65,24 @@ type PositionRiskResponse struct {
 type SymbolInfo struct {
 	Symbol         string
 	Pair           string
-	ContractType   string `json:"contractType"`
-	DeliveryDate   int64  `json:"deliveryDate"`
-	ContractStatus string `json:"contractStatus"`
-	ContractSize   int    `json:"contractSize"`
-	PricePrecision int    `json:"pricePrecision"`
+	ContractType   string   `json:"contractType"`
+	DeliveryDate   int64    `json:"deliveryDate"`
+	ContractStatus string   `json:"contractStatus"` //dapi
+	Status         string   `json:"status"`         //fapi
+	ContractSize   int      `json:"contractSize"`
+	PricePrecision int      `json:"pricePrecision"`
+	BaseAsset      string   `json:"baseAsset"`
+	QuoteAsset     string   `json:"quoteAsset"`
+	MarginAsset    string   `json:"marginAsset"`
+	Filters        []Filter `json:"filters"`
 }
 
 type BinanceFutures struct {
 	base         *Exchange
 	apikey       string
 	exchangeInfo *struct {
-		Symbols []SymbolInfo `json:"symbols"`
+		RateLimits []RateLimit  `json:"rateLimits"`
+		Symbols    []SymbolInfo `json:"symbols"`
 	}
 }
 
@@ -199,7 +205,7 @@ func (bs *BinanceFutures) GetFutureDepth(currencyPair CurrencyPair, contractType
 
 	ret, err := HttpGet(bs.base.httpClient, fmt.Sprintf(depthUri, symbol, limit))
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 	logger.Debug(ret)
 
@@ -244,7 +250,7 @@ func (bs *BinanceFutures) GetFutureUserinfo(currencyPair ...CurrencyPair) (*Futu
 		"X-MBX-APIKEY": bs.apikey})
 
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	logger.Debug(string(respData))
@@ -309,7 +315,7 @@ func (bs *BinanceFutures) PlaceFutureOrder(currencyPair CurrencyPair, contractTy
 		map[string]string{"X-MBX-APIKEY": bs.apikey})
 
 	if err != nil {
-		return "", err
+		return "", adaptError(err)
 	}
 
 	logger.Debug(string(resp))
@@ -328,7 +334,7 @@ func (bs *BinanceFutures) PlaceFutureOrder(currencyPair CurrencyPair, contractTy
 		return fmt.Sprint(response.OrderId), nil
 	}
 
-	return "", errors.New(response.Msg)
+	return "", adaptErrorResponse(response.Code, response.Msg)
 }
 
 func (bs *BinanceFutures) LimitFuturesOrder(currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
@@ -375,7 +381,7 @@ func (bs *BinanceFutures) FutureCancelOrder(currencyPair CurrencyPair, contractT
 	resp, err := HttpDeleteForm(bs.base.httpClient, reqUrl, url.Values{}, map[string]string{"X-MBX-APIKEY": bs.apikey})
 	if err != nil {
 		logger.Errorf("request url: %s", reqUrl)
-		return false, err
+		return false, adaptError(err)
 	}
 
 	logger.Debug(string(resp))
@@ -395,7 +401,7 @@ func (bs *BinanceFutures) GetFuturePosition(currencyPair CurrencyPair, contractT
 
 	respBody, err := HttpGet5(bs.base.httpClient, path, map[string]string{"X-MBX-APIKEY": bs.apikey})
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 	logger.Debug(string(respBody))
 
@@ -464,7 +470,7 @@ func (bs *BinanceFutures) GetFutureOrder(orderId string, currencyPair CurrencyPa
 	resp, err := HttpGet5(bs.base.httpClient, reqUrl, map[string]string{"X-MBX-APIKEY": bs.apikey})
 	if err != nil {
 		logger.Errorf("request url: %s", reqUrl)
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	logger.Debug(string(resp))
@@ -509,7 +515,7 @@ func (bs *BinanceFutures) GetUnfinishFutureOrders(currencyPair CurrencyPair, con
 			"X-MBX-APIKEY": bs.apikey,
 		})
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 	logger.Debug(string(respbody))
 
@@ -578,20 +584,27 @@ func (bs *BinanceFutures) GetFutureEstimatedPrice(currencyPair CurrencyPair) (fl
 }
 
 func (bs *BinanceFutures) GetExchangeInfo() {
+	bs.loadExchangeInfo()
+}
+
+func (bs *BinanceFutures) loadExchangeInfo() error {
 	exchangeInfoUri := bs.base.apiV1 + "exchangeInfo"
 	ret, err := HttpGet5(bs.base.httpClient, exchangeInfoUri, map[string]string{})
 	if err != nil {
 		logger.Error("[exchangeInfo] Http Error", err)
-		return
+		return adaptError(err)
 	}
 
 	err = json.Unmarshal(ret, &bs.exchangeInfo)
 	if err != nil {
 		logger.Error("json unmarshal response content error , content= ", string(ret))
-		return
+		return err
 	}
+	seedRateLimits(bs.base.httpClient, bs.exchangeInfo.RateLimits, FuturesRateLimits())
+	Symbols.RegisterInstruments(BINANCE_FUTURES, contractInstruments(bs.exchangeInfo.Symbols))
 
 	logger.Debug("[ExchangeInfo]", bs.exchangeInfo)
+	return nil
 }
 
 func (bs *BinanceFutures) adaptToSymbol(pair CurrencyPair, contractType string) (string, error) {
@@ -641,7 +654,7 @@ func (bs *BinanceFutures) adaptStatus(status string) TradeStatus {
 	switch status {
 	case "NEW":
 		return ORDER_UNFINISH
-	case "CANCELED":
+	case "CANCELED", "EXPIRED":
 		return ORDER_CANCEL
 	case "FILLED":
 		return ORDER_FINISH
@@ -66,7 +66,7 @@ func (bs *BinanceSwap) Ping() bool {
 func (bs *BinanceSwap) setTimeOffset() error {
 	respmap, err := HttpGet(bs.httpClient, bs.apiV1+SERVER_TIME_URL)
 	if err != nil {
-		return err
+		return adaptError(err)
 	}
 
 	stime := int64(ToInt(respmap["serverTime"]))
@@ -153,11 +153,11 @@ func (bs *BinanceSwap) GetFutureDepth(currency CurrencyPair, contractType string
 	apiUrl := fmt.Sprintf(bs.apiV1+DEPTH_URI, currencyPair2.ToSymbol(""), size)
 	resp, err := HttpGet(bs.httpClient, apiUrl)
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	if _, isok := resp["code"]; isok {
-		return nil, errors.New(resp["msg"].(string))
+		return nil, adaptErrorResponse(ToInt(resp["code"]), resp["msg"].(string))
 	}
 
 	bids := resp["bids"].([]interface{})
@@ -218,7 +218,7 @@ func (bs *BinanceSwap) GetTrades(contractType string, currencyPair CurrencyPair,
 	resp, err := HttpGet3(bs.httpClient, apiUrl, map[string]string{
 		"X-MBX-APIKEY": bs.accessKey})
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	var trades []Trade
@@ -245,7 +245,7 @@ func (bs *BinanceSwap) GetTrades(contractType string, currencyPair CurrencyPair,
 func (bs *BinanceSwap) GetFutureIndex(currencyPair CurrencyPair) (float64, error) {
 	respmap, err := HttpGet(bs.httpClient, bs.apiV1+"premiumIndex?symbol="+bs.adaptCurrencyPair(currencyPair).ToSymbol(""))
 	if err != nil {
-		return 0.0, err
+		return 0.0, adaptError(err)
 	}
 
 	return ToFloat64(respmap["markPrice"]), nil
@@ -262,11 +262,11 @@ func (bs *BinanceSwap) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureA
 	path := bs.apiV1 + ACCOUNT_URI + params.Encode()
 	respmap, err := HttpGet2(bs.httpClient, path, map[string]string{"X-MBX-APIKEY": bs.accessKey})
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	if _, isok := respmap["code"]; isok == true {
-		return nil, errors.New(respmap["msg"].(string))
+		return nil, adaptErrorResponse(ToInt(respmap["code"]), respmap["msg"].(string))
 	}
 
 	balances := respmap["assets"].([]interface{})
@@ -297,7 +297,7 @@ func (bs *BinanceSwap) Transfer(currency Currency, transferType int, amount floa
 	resp, err := HttpPostForm2(bs.httpClient, uri, params,
 		map[string]string{"X-MBX-APIKEY": bs.accessKey})
 	if err != nil {
-		return 0, err
+		return 0, adaptError(err)
 	}
 
 	respmap := make(map[string]interface{})
@@ -368,7 +368,7 @@ func (bs *BinanceSwap) PlaceFutureOrder2(currencyPair CurrencyPair, contractType
 	resp, err := HttpPostForm2(bs.httpClient, path, params,
 		map[string]string{"X-MBX-APIKEY": bs.accessKey})
 	if err != nil {
-		return fOrder, err
+		return fOrder, adaptError(err)
 	}
 
 	respmap := make(map[string]interface{})
@@ -379,7 +379,7 @@ func (bs *BinanceSwap) PlaceFutureOrder2(currencyPair CurrencyPair, contractType
 
 	orderId := ToInt(respmap["orderId"])
 	if orderId <= 0 {
-		return fOrder, errors.New(string(resp))
+		return fOrder, adaptErrorBody(resp)
 	}
 	fOrder.OrderID2 = strconv.Itoa(orderId)
 
@@ -414,7 +414,7 @@ func (bs *BinanceSwap) FutureCancelOrder(currencyPair CurrencyPair, contractType
 	resp, err := HttpDeleteForm(bs.httpClient, path, params, map[string]string{"X-MBX-APIKEY": bs.accessKey})
 
 	if err != nil {
-		return false, err
+		return false, adaptError(err)
 	}
 
 	respmap := make(map[string]interface{})
@@ -425,7 +425,7 @@ func (bs *BinanceSwap) FutureCancelOrder(currencyPair CurrencyPair, contractType
 
 	orderIdCanceled := ToInt(respmap["orderId"])
 	if orderIdCanceled <= 0 {
-		return false, errors.New(string(resp))
+		return false, adaptErrorBody(resp)
 	}
 
 	return true, nil
@@ -454,7 +454,7 @@ func (bs *BinanceSwap) FutureCancelAllOrders(currencyPair CurrencyPair, contract
 	resp, err := HttpDeleteForm(bs.httpClient, path, params, map[string]string{"X-MBX-APIKEY": bs.accessKey})
 
 	if err != nil {
-		return false, err
+		return false, adaptError(err)
 	}
 
 	respmap := make(map[string]interface{})
@@ -464,7 +464,7 @@ func (bs *BinanceSwap) FutureCancelAllOrders(currencyPair CurrencyPair, contract
 	}
 
 	if ToInt(respmap["code"]) != 200 {
-		return false, errors.New(respmap["msg"].(string))
+		return false, adaptErrorResponse(ToInt(respmap["code"]), respmap["msg"].(string))
 	}
 
 	return true, nil
@@ -492,7 +492,7 @@ func (bs *BinanceSwap) FutureCancelOrders(currencyPair CurrencyPair, contractTyp
 	resp, err := HttpDeleteForm(bs.httpClient, path, params, map[string]string{"X-MBX-APIKEY": bs.accessKey})
 
 	if err != nil {
-		return false, err
+		return false, adaptError(err)
 	}
 
 	respmap := make(map[string]interface{})
@@ -502,7 +502,7 @@ func (bs *BinanceSwap) FutureCancelOrders(currencyPair CurrencyPair, contractTyp
 	}
 
 	if ToInt(respmap["code"]) != 200 {
-		return false, errors.New(respmap["msg"].(string))
+		return false, adaptErrorResponse(ToInt(respmap["code"]), respmap["msg"].(string))
 	}
 
 	return true, nil
@@ -526,7 +526,7 @@ func (bs *BinanceSwap) GetFuturePosition(currencyPair CurrencyPair, contractType
 	result, err := HttpGet3(bs.httpClient, path, map[string]string{"X-MBX-APIKEY": bs.accessKey})
 
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	var positions []FuturePosition
@@ -581,7 +581,7 @@ func (bs *BinanceSwap) GetFutureOrders(orderIds []string, currencyPair CurrencyP
 	result, err := HttpGet3(bs.httpClient, path, map[string]string{"X-MBX-APIKEY": bs.accessKey})
 
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	orders := make([]FutureOrder, 0)
@@ -629,7 +629,7 @@ func (bs *BinanceSwap) GetFutureOrder(orderId string, currencyPair CurrencyPair,
 	result, err := HttpGet3(bs.httpClient, path, map[string]string{"X-MBX-APIKEY": bs.accessKey})
 
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	order := &FutureOrder{}
@@ -729,7 +729,7 @@ func (bs *BinanceSwap) GetUnfinishFutureOrders(currencyPair CurrencyPair, contra
 	result, err := HttpGet3(bs.httpClient, path, map[string]string{"X-MBX-APIKEY": bs.accessKey})
 
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 
 	orders := make([]FutureOrder, 0)
@@ -779,7 +779,7 @@ func (bs *BinanceSwap) GetKlineRecords(contractType string, currency CurrencyPai
 	klineUrl := bs.apiV1 + KLINE_URI + "?" + params.Encode()
 	klines, err := HttpGet3(bs.httpClient, klineUrl, nil)
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 	var klineRecords []FutureKline
 
@@ -802,7 +802,7 @@ func (bs *BinanceSwap) GetKlineRecords(contractType string, currency CurrencyPai
 func (bs *BinanceSwap) GetServerTime() (int64, error) {
 	respmap, err := HttpGet(bs.httpClient, bs.apiV1+SERVER_TIME_URL)
 	if err != nil {
-		return 0, err
+		return 0, adaptError(err)
 	}
 
 	stime := int64(ToInt(respmap["serverTime"]))
@@ -1,12 +1,17 @@
 package binance
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/cassette"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var ba = NewWithConfig(
@@ -94,3 +99,158 @@ func TestBinance_GetOrderHistorys(t *testing.T) {
 			Optional("startTime", "1607656034333").
 			Optional("limit", "5")))
 }
+
+func TestBinance_Replay(t *testing.T) {
+	rec, err := cassette.New(&cassette.Config{Path: "testdata/spot.json", Mode: cassette.ModeReplay})
+	require.NoError(t, err)
+	bn := NewWithConfig(&goex.APIConfig{HttpClient: &http.Client{Transport: rec}, ApiKey: "key", ApiSecretKey: "secret"})
+
+	ticker, err := bn.GetTicker(goex.BTC_USDT)
+	require.NoError(t, err)
+	assert.Equal(t, 29316.43, ticker.Last)
+	assert.Equal(t, 29316.42, ticker.Buy)
+	assert.Equal(t, 29316.43, ticker.Sell)
+	assert.Equal(t, 28700.0, ticker.Low)
+	assert.Equal(t, 29680.0, ticker.High)
+	assert.Equal(t, uint64(1609459199), ticker.Date)
+
+	dep, err := bn.GetDepth(5, goex.BTC_USDT)
+	require.NoError(t, err)
+	require.Len(t, dep.BidList, 3)
+	require.Len(t, dep.AskList, 3)
+	assert.Equal(t, 29316.42, dep.BidList[0].Price)
+	assert.Equal(t, 29318.55, dep.AskList[0].Price) //descending
+	assert.Equal(t, 1.0652, dep.AskList[2].Amount)
+
+	acc, err := bn.GetAccount()
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, acc.SubAccounts[goex.BTC].Amount)
+	assert.Equal(t, 0.1, acc.SubAccounts[goex.BTC].ForzenAmount)
+	assert.Equal(t, 1200.0, acc.SubAccounts[goex.USDT].Amount)
+	assert.Equal(t, 1.0, acc.SubAccounts[goex.BCH].Amount)
+}
+
+func TestBinance_AdaptError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v3/time":
+			w.Write([]byte(`{"serverTime":1609459200000}`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-2011,"msg":"Unknown order sent."}`))
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-2010,"msg":"Account has insufficient balance for requested action."}`))
+		case r.URL.Path == "/api/v3/account":
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"code":-1003,"msg":"Too many requests."}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+		}
+	}))
+	defer srv.Close()
+	bn := NewWithConfig(&goex.APIConfig{HttpClient: http.DefaultClient, Endpoint: srv.URL, ApiKey: "key", ApiSecretKey: "secret"})
+
+	_, err := bn.CancelOrder("1", goex.BTC_USDT)
+	assert.True(t, errors.Is(err, goex.EX_ERR_NOT_FIND_ORDER))
+	var apiErr goex.ApiError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.HttpStatus)
+	assert.Equal(t, "-2011", apiErr.NativeCode)
+	assert.Equal(t, "Unknown order sent.", apiErr.Error())
+
+	_, err = bn.LimitBuy("1", "1", goex.BTC_USDT)
+	assert.True(t, errors.Is(err, goex.EX_ERR_INSUFFICIENT_BALANCE))
+
+	_, err = bn.GetAccount()
+	assert.True(t, errors.Is(err, goex.EX_ERR_API_LIMIT))
+
+	_, err = bn.GetDepth(5, goex.BTC_USDT)
+	assert.True(t, errors.Is(err, goex.EX_ERR_INVALID_CURRENCY_PAIR))
+	assert.False(t, errors.Is(err, goex.EX_ERR_API_LIMIT))
+}
+
+func TestBinance_SeedRateLimits(t *testing.T) {
+	t.Cleanup(func() { goex.Symbols.Reset(goex.BINANCE) })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Mbx-Used-Weight-1m", "10")
+		w.Write([]byte(`{"timezone":"UTC","serverTime":1609459200000,"rateLimits":[
+			{"rateLimitType":"REQUEST_WEIGHT","interval":"MINUTE","intervalNum":1,"limit":1200},
+			{"rateLimitType":"ORDERS","interval":"SECOND","intervalNum":10,"limit":100},
+			{"rateLimitType":"RAW_REQUESTS","interval":"MINUTE","intervalNum":5,"limit":6100}],"symbols":[]}`))
+	}))
+	defer srv.Close()
+
+	rl := goex.NewRateLimiter(&goex.RateLimitConfig{Limits: SpotRateLimits(), Policy: goex.RateLimitFailFast})
+	bn := NewWithConfig(&goex.APIConfig{HttpClient: &http.Client{Transport: rl}, Endpoint: srv.URL})
+
+	_, err := bn.GetExchangeInfo()
+	require.NoError(t, err)
+	assert.Equal(t, 10, rl.Used("REQUEST_WEIGHT", time.Minute), "the count follows X-Mbx-Used-Weight-1m")
+	assert.Equal(t, 2, rl.Used("RAW_REQUESTS", 5*time.Minute), "time and exchangeInfo")
+
+	//the daily order limit is gone, the 10s one is now 100
+	for i := 0; i < 100; i++ {
+		req := httptest.NewRequest(http.MethodPost, srv.URL+"/api/v3/order", nil)
+		req.RequestURI = ""
+		resp, err := rl.RoundTrip(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, 0, rl.Used("ORDERS", 24*time.Hour))
+	assert.Equal(t, 100, rl.Used("ORDERS", 10*time.Second))
+}
+
+func TestBinance_GetInstrumentsInfo(t *testing.T) {
+	t.Cleanup(func() { goex.Symbols.Reset(goex.BINANCE, goex.BINANCE_FUTURES, goex.BINANCE_SWAP) })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/exchangeInfo":
+			w.Write([]byte(`{"symbols":[{"symbol":"SHIBUSDT","status":"TRADING","baseAsset":"SHIB","quoteAsset":"USDT","filters":[
+				{"filterType":"PRICE_FILTER","minPrice":"0.00000001","maxPrice":"1.00000000","tickSize":"0.00000001"},
+				{"filterType":"LOT_SIZE","minQty":"1.00","maxQty":"92233720368.00","stepSize":"1.00"},
+				{"filterType":"MIN_NOTIONAL","minNotional":"10.00000000"}]},
+				{"symbol":"BTCUSDT","status":"BREAK","baseAsset":"BTC","quoteAsset":"USDT","filters":[]}]}`))
+		case "/dapi/v1/exchangeInfo":
+			w.Write([]byte(`{"symbols":[{"symbol":"BTCUSD_210326","pair":"BTCUSD","contractType":"CURRENT_QUARTER","deliveryDate":1616745600000,
+				"contractStatus":"TRADING","contractSize":100,"baseAsset":"BTC","quoteAsset":"USD","marginAsset":"BTC","filters":[
+				{"filterType":"PRICE_FILTER","tickSize":"0.1"},{"filterType":"LOT_SIZE","minQty":"1","stepSize":"1"}]},
+				{"symbol":"ETHUSD_PERP","pair":"ETHUSD","contractType":"PERPETUAL","deliveryDate":4133404800000,
+				"contractStatus":"TRADING","contractSize":10,"baseAsset":"ETH","quoteAsset":"USD","marginAsset":"ETH","filters":[]}]}`))
+		default:
+			w.Write([]byte(`{"serverTime":1609459200000}`))
+		}
+	}))
+	defer srv.Close()
+
+	var _ goex.InstrumentsAPI = (*Exchange)(nil)
+	var _ goex.InstrumentsAPI = (*BinanceSwap)(nil)
+	bn := NewWithConfig(&goex.APIConfig{HttpClient: http.DefaultClient, Endpoint: srv.URL})
+	instruments, err := bn.GetInstrumentsInfo()
+	require.NoError(t, err)
+	require.Len(t, instruments, 2)
+	shib := instruments[0]
+	assert.Equal(t, "SHIB_USDT", shib.Pair.String())
+	assert.Equal(t, goex.SPOT, shib.Kind)
+	assert.Equal(t, "0.00000001", shib.TickSize.String())
+	assert.Equal(t, "1", shib.LotSize.String())
+	assert.Equal(t, "10", shib.MinNotional.String())
+	assert.Equal(t, goex.INSTRUMENT_TRADING, shib.Status)
+	assert.Equal(t, goex.INSTRUMENT_SUSPENDED, instruments[1].Status)
+
+	dapi := NewBinanceFutures(&goex.APIConfig{HttpClient: http.DefaultClient, Endpoint: srv.URL})
+	instruments, err = dapi.GetInstrumentsInfo()
+	require.NoError(t, err)
+	require.Len(t, instruments, 2)
+	quarter := instruments[0]
+	assert.Equal(t, goex.BTC_USD.String(), quarter.Pair.String())
+	assert.Equal(t, goex.FUTURE, quarter.Kind)
+	assert.Equal(t, goex.QUARTER_CONTRACT, quarter.ContractType)
+	assert.Equal(t, "0.1", quarter.TickSize.String())
+	assert.Equal(t, "100", quarter.ContractVal.String())
+	assert.Equal(t, goex.USD, quarter.ContractValCurrency)
+	assert.Equal(t, time.Date(2021, 3, 26, 8, 0, 0, 0, time.UTC), quarter.Expiry.UTC())
+	assert.Equal(t, goex.SWAP, instruments[1].Kind)
+	assert.True(t, instruments[1].Expiry.IsZero())
+}
@@ -3,11 +3,13 @@ package binance
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/soulsplit/goex"
@@ -16,22 +18,40 @@ import (
 
 type FuturesWs struct {
 	base *BinanceFutures
-	f    *goex.WsConn
-	d    *goex.WsConn
+	f    *goex.WsPool
+	d    *goex.WsPool
 
 	depthCallFn  func(depth *goex.Depth)
 	tickerCallFn func(ticker *goex.FutureTicker)
 	tradeCalFn   func(trade *goex.Trade, contract string)
+	klineCallFn  func(kline *goex.FutureKline, period goex.KlinePeriod, closed bool)
+
+	orderCallFn    func(order *goex.FutureOrder)
+	positionCallFn func(position *goex.FuturePosition)
+	accountCallFn  func(account *goex.FutureAccount)
+
+	fUser             *userDataStream
+	dUser             *userDataStream
+	subsLock          sync.Mutex
+	orderSubs         map[string]futuresSub
+	positionSubs      map[string]futuresSub
+	accountCurrencies map[string]bool
+	klineSubs         map[string]klineSub //by stream
+}
+
+type klineSub struct {
+	pair         goex.CurrencyPair
+	contractType string
+	period       goex.KlinePeriod
 }
 
 func NewFuturesWs() *FuturesWs {
 	futuresWs := new(FuturesWs)
 
-	wsBuilder := goex.NewWsBuilder().
-		ProxyUrl(os.Getenv("HTTPS_PROXY")).
-		ProtoHandleFunc(futuresWs.handle).AutoReconnect()
-	futuresWs.f = wsBuilder.WsUrl("wss://fstream.binance.com/ws").Build()
-	futuresWs.d = wsBuilder.WsUrl("wss://dstream.binance.com/ws").Build()
+	futuresWs.f = goex.NewWsPool(goex.NewWsBuilder().WsUrl("wss://fstream.binance.com/ws").
+		ProxyUrl(os.Getenv("HTTPS_PROXY")).ProtoHandleFunc(futuresWs.handle).AutoReconnect(), futuresWsMaxStreams)
+	futuresWs.d = goex.NewWsPool(goex.NewWsBuilder().WsUrl("wss://dstream.binance.com/ws").
+		ProxyUrl(os.Getenv("HTTPS_PROXY")).ProtoHandleFunc(futuresWs.handle).AutoReconnect(), futuresWsMaxStreams)
 	futuresWs.base = NewBinanceFutures(&goex.APIConfig{
 		HttpClient: &http.Client{
 			Transport: &http.Transport{
@@ -58,49 +78,116 @@ func (s *FuturesWs) TradeCallback(f func(trade *goex.Trade, contract string)) {
 	s.tradeCalFn = f
 }
 
-func (s *FuturesWs) SubscribeDepth(pair goex.CurrencyPair, contractType string) error {
-	switch contractType {
-	case goex.SWAP_USDT_CONTRACT:
-		return s.f.Subscribe(req{
-			Method: "SUBSCRIBE",
-			Params: []string{pair.AdaptUsdToUsdt().ToLower().ToSymbol("") + "@depth10@100ms"},
-			Id:     1,
-		})
-	default:
-		sym, _ := s.base.adaptToSymbol(pair.AdaptUsdtToUsd(), contractType)
-		return s.d.Subscribe(req{
-			Method: "SUBSCRIBE",
-			Params: []string{strings.ToLower(sym) + "@depth10@100ms"},
-			Id:     2,
-		})
+func (s *FuturesWs) KlineCallback(f func(kline *goex.FutureKline, period goex.KlinePeriod, closed bool)) {
+	s.klineCallFn = f
+}
+
+// stream is the name of a stream of the pair and its connection, fstream for the usdt
+// contracts and dstream for the coin ones.
+func (s *FuturesWs) stream(pair goex.CurrencyPair, contractType, name string) (*goex.WsPool, goex.CurrencyPair, string, error) {
+	if contractType == goex.SWAP_USDT_CONTRACT {
+		pair = pair.AdaptUsdToUsdt()
+		return s.f, pair, pair.ToLower().ToSymbol("") + "@" + name, nil
 	}
-	return errors.New("contract is error")
+
+	pair = pair.AdaptUsdtToUsd()
+	sym, err := s.base.adaptToSymbol(pair, contractType)
+	if err != nil {
+		return nil, pair, "", err
+	}
+	return s.d, pair, strings.ToLower(sym) + "@" + name, nil
 }
 
-func (s *FuturesWs) SubscribeTicker(pair goex.CurrencyPair, contractType string) error {
-	switch contractType {
-	case goex.SWAP_USDT_CONTRACT:
-		return s.f.Subscribe(req{
-			Method: "SUBSCRIBE",
-			Params: []string{pair.AdaptUsdToUsdt().ToLower().ToSymbol("") + "@ticker"},
-			Id:     1,
-		})
-	default:
-		sym, _ := s.base.adaptToSymbol(pair.AdaptUsdtToUsd(), contractType)
-		return s.d.Subscribe(req{
-			Method: "SUBSCRIBE",
-			Params: []string{strings.ToLower(sym) + "@ticker"},
-			Id:     2,
-		})
+func (s *FuturesWs) subscribe(pair goex.CurrencyPair, contractType, name string) error {
+	conn, _, stream, err := s.stream(pair, contractType, name)
+	if err != nil {
+		return err
+	}
+	return subscribeStream(conn, stream)
+}
+
+func (s *FuturesWs) unsubscribe(pair goex.CurrencyPair, contractType, name string) error {
+	conn, _, stream, err := s.stream(pair, contractType, name)
+	if err != nil {
+		return err
 	}
-	return errors.New("contract is error")
+	return unsubscribeStream(conn, stream)
+}
+
+func (s *FuturesWs) SubscribeDepth(pair goex.CurrencyPair, contractType string) error {
+	return s.subscribe(pair, contractType, "depth10@100ms")
+}
+
+func (s *FuturesWs) SubscribeTicker(pair goex.CurrencyPair, contractType string) error {
+	return s.subscribe(pair, contractType, "ticker")
 }
 
 func (s *FuturesWs) SubscribeTrade(pair goex.CurrencyPair, contractType string) error {
-	panic("implement me")
+	return s.subscribe(pair, contractType, "trade")
+}
+
+func (s *FuturesWs) SubscribeKline(pair goex.CurrencyPair, contractType string, period goex.KlinePeriod) error {
+	interval, ok := _INERNAL_KLINE_PERIOD_CONVERTER[period]
+	if !ok {
+		return fmt.Errorf("unsupported kline period %d in binance", period)
+	}
+	conn, pair, stream, err := s.stream(pair, contractType, "kline_"+interval)
+	if err != nil {
+		return err
+	}
+
+	s.subsLock.Lock()
+	if s.klineSubs == nil {
+		s.klineSubs = make(map[string]klineSub, 4)
+	}
+	s.klineSubs[stream] = klineSub{pair, contractType, period}
+	s.subsLock.Unlock()
+
+	err = subscribeStream(conn, stream)
+	if err != nil {
+		s.subsLock.Lock()
+		delete(s.klineSubs, stream)
+		s.subsLock.Unlock()
+	}
+	return err
+}
+
+func (s *FuturesWs) UnsubscribeDepth(pair goex.CurrencyPair, contractType string) error {
+	return s.unsubscribe(pair, contractType, "depth10@100ms")
+}
+
+func (s *FuturesWs) UnsubscribeTicker(pair goex.CurrencyPair, contractType string) error {
+	return s.unsubscribe(pair, contractType, "ticker")
+}
+
+func (s *FuturesWs) UnsubscribeTrade(pair goex.CurrencyPair, contractType string) error {
+	return s.unsubscribe(pair, contractType, "trade")
+}
+
+func (s *FuturesWs) UnsubscribeKline(pair goex.CurrencyPair, contractType string, period goex.KlinePeriod) error {
+	interval, ok := _INERNAL_KLINE_PERIOD_CONVERTER[period]
+	if !ok {
+		return fmt.Errorf("unsupported kline period %d in binance", period)
+	}
+	conn, _, stream, err := s.stream(pair, contractType, "kline_"+interval)
+	if err != nil {
+		return err
+	}
+	if err = unsubscribeStream(conn, stream); err != nil {
+		return err
+	}
+
+	s.subsLock.Lock()
+	delete(s.klineSubs, stream)
+	s.subsLock.Unlock()
+	return nil
 }
 
 func (s *FuturesWs) handle(data []byte) error {
+	if wsResult(data, s.f, s.d) {
+		return nil
+	}
+
 	var m = make(map[string]interface{}, 4)
 	err := json.Unmarshal(data, &m)
 	if err != nil {
@@ -108,15 +195,14 @@ func (s *FuturesWs) handle(data []byte) error {
 	}
 
 	if e, ok := m["e"].(string); ok && e == "depthUpdate" {
+		pair, err := adaptContractSymbol(m)
+		if err != nil {
+			logger.Error("[depth]", err)
+			return err
+		}
 		dep := s.depthHandle(m["b"].([]interface{}), m["a"].([]interface{}))
 		dep.ContractType = m["s"].(string)
-		symbol, ok := m["ps"].(string)
-
-		if ok {
-			dep.Pair = adaptSymbolToCurrencyPair(symbol)
-		} else {
-			dep.Pair = adaptSymbolToCurrencyPair(dep.ContractType) //usdt swap
-		}
+		dep.Pair = pair
 
 		dep.UTime = time.Unix(0, goex.ToInt64(m["T"])*int64(time.Millisecond))
 		s.depthCallFn(dep)
@@ -125,7 +211,30 @@ func (s *FuturesWs) handle(data []byte) error {
 	}
 
 	if e, ok := m["e"].(string); ok && e == "24hrTicker" {
-		s.tickerCallFn(s.tickerHandle(m))
+		ticker, err := s.tickerHandle(m)
+		if err != nil {
+			logger.Error("[ticker]", err)
+			return err
+		}
+		s.tickerCallFn(ticker)
+		return nil
+	}
+
+	if e, ok := m["e"].(string); ok && e == "kline" {
+		return s.klineHandle(m)
+	}
+
+	if e, ok := m["e"].(string); ok && e == "trade" {
+		pair, err := adaptContractSymbol(m)
+		if err != nil {
+			logger.Error("[trade]", err)
+			return err
+		}
+		if s.tradeCalFn != nil {
+			trade := adaptWsTrade(m)
+			trade.Pair = pair
+			s.tradeCalFn(trade, toString(m["s"]))
+		}
 		return nil
 	}
 
@@ -134,6 +243,31 @@ func (s *FuturesWs) handle(data []byte) error {
 	return nil
 }
 
+func (s *FuturesWs) klineHandle(m map[string]interface{}) error {
+	k, ok := m["k"].(map[string]interface{})
+	if !ok {
+		return errors.New("kline response without k")
+	}
+
+	s.subsLock.Lock()
+	sub, ok := s.klineSubs[strings.ToLower(toString(m["s"]))+"@kline_"+toString(k["i"])]
+	s.subsLock.Unlock()
+	if !ok || s.klineCallFn == nil {
+		return nil
+	}
+
+	kline, closed := adaptWsKline(k)
+	kline.Pair = sub.pair
+	//v is the base volume of the usdt contracts and the contract volume of the coin ones
+	vol2 := kline.Vol
+	if sub.contractType != goex.SWAP_USDT_CONTRACT {
+		vol2 = goex.ToFloat64(k["q"])
+	}
+	s.klineCallFn(&goex.FutureKline{Kline: kline, Vol2: vol2, ContractType: sub.contractType}, sub.period, closed)
+
+	return nil
+}
+
 func (s *FuturesWs) depthHandle(bids []interface{}, asks []interface{}) *goex.Depth {
 	var dep goex.Depth
 
@@ -159,16 +293,15 @@ func (s *FuturesWs) depthHandle(bids []interface{}, asks []interface{}) *goex.De
 	return &dep
 }
 
-func (s *FuturesWs) tickerHandle(m map[string]interface{}) *goex.FutureTicker {
+func (s *FuturesWs) tickerHandle(m map[string]interface{}) (*goex.FutureTicker, error) {
+	pair, err := adaptContractSymbol(m)
+	if err != nil {
+		return nil, err
+	}
+
 	var ticker goex.FutureTicker
 	ticker.Ticker = new(goex.Ticker)
-
-	symbol, ok := m["ps"].(string)
-	if ok {
-		ticker.Pair = adaptSymbolToCurrencyPair(symbol)
-	} else {
-		ticker.Pair = adaptSymbolToCurrencyPair(m["s"].(string)) //usdt swap
-	}
+	ticker.Pair = pair
 
 	ticker.ContractType = m["s"].(string)
 	ticker.Date = goex.ToUint64(m["E"])
@@ -177,5 +310,5 @@ func (s *FuturesWs) tickerHandle(m map[string]interface{}) *goex.FutureTicker {
 	ticker.Last = goex.ToFloat64(m["c"])
 	ticker.Vol = goex.ToFloat64(m["v"])
 
-	return &ticker
+	return &ticker, nil
 }
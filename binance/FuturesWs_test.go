@@ -1,12 +1,15 @@
 package binance
 
 import (
+	"errors"
 	"log"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var futuresWs *FuturesWs
@@ -44,3 +47,69 @@ func TestFuturesWs_SubscribeTicker(t *testing.T) {
 
 	time.Sleep(30 * time.Second)
 }
+
+func TestFuturesWs_adaptContractSymbol(t *testing.T) {
+	t.Cleanup(func() { goex.Symbols.Reset(goex.BINANCE_FUTURES) })
+	pair, err := adaptContractSymbol(map[string]interface{}{"s": "BTCUSDT"})
+	assert.NoError(t, err, "guessed until the symbols are loaded")
+	assert.Equal(t, "BTC_USDT", pair.String())
+
+	goex.Symbols.RegisterInstruments(goex.BINANCE_FUTURES, contractInstruments([]SymbolInfo{
+		{Symbol: "BTCUSD_PERP", Pair: "BTCUSD", ContractType: "PERPETUAL", BaseAsset: "BTC", QuoteAsset: "USD", ContractSize: 100},
+	}))
+	pair, err = adaptContractSymbol(map[string]interface{}{"s": "BTCUSD_PERP", "ps": "BTCUSD"})
+	assert.NoError(t, err)
+	assert.Equal(t, "BTC_USD", pair.String())
+
+	_, err = adaptContractSymbol(map[string]interface{}{"s": "XYZUSD_PERP", "ps": "XYZUSD"})
+	assert.True(t, errors.Is(err, goex.EX_ERR_SYMBOL_ERR))
+}
+
+func TestFuturesWs_Trade(t *testing.T) {
+	var (
+		trades    []*goex.Trade
+		contracts []string
+	)
+	ws := &FuturesWs{}
+	ws.TradeCallback(func(trade *goex.Trade, contract string) {
+		trades = append(trades, trade)
+		contracts = append(contracts, contract)
+	})
+
+	require.NoError(t, ws.handle([]byte(`{"e":"trade","E":1609459200120,"T":1609459200118,"s":"BTCUSDT","t":388041210,"p":"28998.10","q":"0.250","X":"MARKET","m":true}`)))
+	require.Len(t, trades, 1)
+	assert.Equal(t, []string{"BTCUSDT"}, contracts)
+	assert.Equal(t, goex.BTC_USDT.String(), trades[0].Pair.String())
+	assert.Equal(t, int64(388041210), trades[0].Tid)
+	assert.Equal(t, goex.BUY, trades[0].Type)
+	assert.Equal(t, 28998.1, trades[0].Price)
+	assert.Equal(t, 0.25, trades[0].Amount)
+}
+
+func TestFuturesWs_Kline(t *testing.T) {
+	var (
+		klines []*goex.FutureKline
+		closed []bool
+	)
+	ws := &FuturesWs{klineSubs: map[string]klineSub{
+		"btcusdt@kline_1m":     {goex.BTC_USDT, goex.SWAP_USDT_CONTRACT, goex.KLINE_PERIOD_1MIN},
+		"btcusd_perp@kline_1m": {goex.BTC_USD, goex.SWAP_CONTRACT, goex.KLINE_PERIOD_1MIN},
+	}}
+	ws.KlineCallback(func(kline *goex.FutureKline, period goex.KlinePeriod, c bool) {
+		klines = append(klines, kline)
+		closed = append(closed, c)
+	})
+
+	require.NoError(t, ws.handle([]byte(`{"e":"kline","E":1609459260000,"s":"BTCUSDT","k":{"t":1609459200000,"T":1609459259999,"s":"BTCUSDT","i":"1m","o":"28948.19","c":"28995.00","h":"29000.00","l":"28940.00","v":"316.28","n":2280,"x":true,"q":"9163521.78"}}`)))
+	require.NoError(t, ws.handle([]byte(`{"e":"kline","E":1609459230000,"s":"BTCUSD_PERP","ps":"BTCUSD","k":{"t":1609459200000,"T":1609459259999,"s":"BTCUSD_PERP","i":"1m","o":"28950.1","c":"28990.2","h":"29001.5","l":"28941.0","v":"31200","n":820,"x":false,"q":"107.68"}}`)))
+	require.Len(t, klines, 2)
+	assert.Equal(t, []bool{true, false}, closed)
+	assert.Equal(t, goex.SWAP_USDT_CONTRACT, klines[0].ContractType)
+	assert.Equal(t, goex.BTC_USDT, klines[0].Pair)
+	assert.Equal(t, int64(1609459200), klines[0].Timestamp)
+	assert.Equal(t, 316.28, klines[0].Vol)
+	assert.Equal(t, 316.28, klines[0].Vol2)
+	assert.Equal(t, goex.SWAP_CONTRACT, klines[1].ContractType)
+	assert.Equal(t, 31200.0, klines[1].Vol, "the contracts")
+	assert.Equal(t, 107.68, klines[1].Vol2, "the base currency")
+}
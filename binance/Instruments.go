@@ -0,0 +1,143 @@
+package binance
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+func adaptInstrumentStatus(status string) InstrumentStatus {
+	switch status {
+	case "TRADING":
+		return INSTRUMENT_TRADING
+	case "PRE_TRADING", "PENDING_TRADING":
+		return INSTRUMENT_PENDING
+	case "CLOSE", "DELIVERED", "SETTLING":
+		return INSTRUMENT_DELISTED
+	}
+	return INSTRUMENT_SUSPENDED //BREAK, HALT, AUCTION_MATCH, POST_TRADING, PRE_DELIVERING, DELIVERING
+}
+
+// applyFilters copies the price, lot size and notional filters.
+func applyFilters(ins *InstrumentInfo, filters []Filter) {
+	for _, f := range filters {
+		switch f.FilterType {
+		case "PRICE_FILTER":
+			ins.TickSize = DecimalFromFloat(f.TickSize)
+		case "LOT_SIZE":
+			ins.LotSize = DecimalFromFloat(f.StepSize)
+			ins.MinAmount = DecimalFromFloat(f.MinQty)
+		case "MIN_NOTIONAL", "NOTIONAL":
+			ins.MinNotional = DecimalFromFloat(f.MinNotional)
+		}
+	}
+}
+
+func (ts TradeSymbol) instrument() InstrumentInfo {
+	ins := InstrumentInfo{
+		Id:     ts.Symbol,
+		Pair:   NewCurrencyPair(NewCurrency(ts.BaseAsset, ""), NewCurrency(ts.QuoteAsset, "")),
+		Kind:   SPOT,
+		Status: adaptInstrumentStatus(ts.Status),
+	}
+	applyFilters(&ins, ts.Filters)
+	return ins
+}
+
+// instrument converts a dapi (coin margined) or fapi (usdt margined) symbol.
+func (info SymbolInfo) instrument() InstrumentInfo {
+	status := info.ContractStatus
+	if status == "" {
+		status = info.Status
+	}
+	ins := InstrumentInfo{
+		Id:     info.Symbol,
+		Pair:   NewCurrencyPair(NewCurrency(info.BaseAsset, ""), NewCurrency(info.QuoteAsset, "")),
+		Kind:   FUTURE,
+		Status: adaptInstrumentStatus(status),
+	}
+	switch info.ContractType {
+	case "PERPETUAL":
+		ins.ContractType = SWAP_CONTRACT
+		ins.Kind = SWAP
+	case "CURRENT_QUARTER":
+		ins.ContractType = QUARTER_CONTRACT
+	case "NEXT_QUARTER":
+		ins.ContractType = BI_QUARTER_CONTRACT
+	default:
+		ins.ContractType = info.Symbol
+	}
+	if ins.Kind == FUTURE && info.DeliveryDate > 0 {
+		ins.Expiry = time.Unix(0, info.DeliveryDate*int64(time.Millisecond))
+	}
+
+	if info.ContractSize > 0 { //inverse contracts of dapi, the size is in usd
+		ins.ContractVal = NewDecimal(int64(info.ContractSize), 0)
+		ins.ContractValCurrency = NewCurrency(info.QuoteAsset, "")
+	} else {
+		ins.ContractVal = NewDecimal(1, 0)
+		ins.ContractValCurrency = NewCurrency(info.BaseAsset, "")
+		if ins.Kind == SWAP {
+			ins.Kind = SWAP_USDT
+			ins.ContractType = SWAP_USDT_CONTRACT
+		}
+	}
+	applyFilters(&ins, info.Filters)
+	return ins
+}
+
+func spotInstruments(symbols []TradeSymbol) []InstrumentInfo {
+	instruments := make([]InstrumentInfo, 0, len(symbols))
+	for _, sym := range symbols {
+		instruments = append(instruments, sym.instrument())
+	}
+	return instruments
+}
+
+func contractInstruments(symbols []SymbolInfo) []InstrumentInfo {
+	instruments := make([]InstrumentInfo, 0, len(symbols))
+	for _, info := range symbols {
+		instruments = append(instruments, info.instrument())
+	}
+	return instruments
+}
+
+// GetInstrumentsInfo returns the spot symbols and refreshes ExchangeInfo.
+func (exchange *Exchange) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	info, err := exchange.GetExchangeInfo()
+	if err != nil {
+		return nil, err
+	}
+	exchange.ExchangeInfo = info
+	return spotInstruments(info.Symbols), nil
+}
+
+// GetInstrumentsInfo returns the coin margined contracts.
+func (bs *BinanceFutures) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	if err := bs.loadExchangeInfo(); err != nil {
+		return nil, err
+	}
+
+	return contractInstruments(bs.exchangeInfo.Symbols), nil
+}
+
+// GetInstrumentsInfo returns the usdt margined contracts.
+func (bs *BinanceSwap) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	resp, err := HttpGet5(bs.httpClient, bs.apiV1+"exchangeInfo", nil)
+	if err != nil {
+		return nil, adaptError(err)
+	}
+	var info struct {
+		RateLimits []RateLimit  `json:"rateLimits"`
+		Symbols    []SymbolInfo `json:"symbols"`
+	}
+	if err = json.Unmarshal(resp, &info); err != nil {
+		return nil, err
+	}
+	seedRateLimits(bs.httpClient, info.RateLimits, FuturesRateLimits())
+
+	instruments := contractInstruments(info.Symbols)
+	Symbols.RegisterInstruments(BINANCE_SWAP, instruments)
+	return instruments, nil
+}
@@ -0,0 +1,169 @@
+package binance
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+// SpotRateLimits are the spot limits until GetExchangeInfo loads the current ones.
+func SpotRateLimits() []RateLimitRule {
+	return []RateLimitRule{
+		{Name: "REQUEST_WEIGHT", Interval: time.Minute, Limit: 1200, Weight: spotWeight, UsedHeader: "X-Mbx-Used-Weight-1m"},
+		{Name: "ORDERS", Interval: 10 * time.Second, Limit: 50, Weight: orderWeight, UsedHeader: "X-Mbx-Order-Count-10s"},
+		{Name: "ORDERS", Interval: 24 * time.Hour, Limit: 160000, Weight: orderWeight, UsedHeader: "X-Mbx-Order-Count-1d"},
+		{Name: "RAW_REQUESTS", Interval: 5 * time.Minute, Limit: 6100},
+	}
+}
+
+// FuturesRateLimits are the dapi and fapi limits until GetExchangeInfo loads the current ones.
+func FuturesRateLimits() []RateLimitRule {
+	return []RateLimitRule{
+		{Name: "REQUEST_WEIGHT", Interval: time.Minute, Limit: 2400, Weight: futuresWeight, UsedHeader: "X-Mbx-Used-Weight-1m"},
+		{Name: "ORDERS", Interval: time.Minute, Limit: 1200, Weight: orderWeight, UsedHeader: "X-Mbx-Order-Count-1m"},
+		{Name: "ORDERS", Interval: 10 * time.Second, Limit: 300, Weight: orderWeight, UsedHeader: "X-Mbx-Order-Count-10s"},
+	}
+}
+
+// spotWeight is the request weight of the /api/v3 endpoints.
+func spotWeight(req *http.Request) int {
+	q := req.URL.Query()
+	switch endpoint(req) {
+	case "depth":
+		return depthWeight(ToInt(q.Get("limit")), 1, 5, 10, 50)
+	case "ticker/24hr":
+		if q.Get("symbol") == "" {
+			return 40
+		}
+	case "ticker/price", "ticker/bookTicker":
+		if q.Get("symbol") == "" {
+			return 2
+		}
+	case "openOrders":
+		if q.Get("symbol") == "" {
+			return 40
+		}
+		return 3
+	case "order":
+		if req.Method == http.MethodGet {
+			return 2
+		}
+	case "account", "allOrders", "myTrades", "exchangeInfo":
+		return 10
+	case "historicalTrades":
+		return 5
+	}
+	return 1
+}
+
+// futuresWeight is the request weight of the /dapi/v1 and /fapi/v1 endpoints.
+func futuresWeight(req *http.Request) int {
+	q := req.URL.Query()
+	switch endpoint(req) {
+	case "depth":
+		return depthWeight(ToInt(q.Get("limit")), 2, 5, 10, 20)
+	case "ticker/24hr":
+		if q.Get("symbol") == "" {
+			return 40
+		}
+	case "openOrders":
+		if q.Get("symbol") == "" {
+			return 40
+		}
+	case "account", "balance", "positionRisk", "allOrders":
+		return 5
+	case "klines":
+		return depthWeight(ToInt(q.Get("limit")), 1, 2, 5, 10)
+	}
+	return 1
+}
+
+// orderWeight counts the new orders.
+func orderWeight(req *http.Request) int {
+	if req.Method == http.MethodPost && (endpoint(req) == "order" || endpoint(req) == "batchOrders") {
+		return 1
+	}
+	return 0
+}
+
+// depthWeight is the weight by limit: up to 100, 500, 1000 and more.
+func depthWeight(limit int, weights ...int) int {
+	switch {
+	case limit <= 100:
+		return weights[0]
+	case limit <= 500:
+		return weights[1]
+	case limit <= 1000:
+		return weights[2]
+	}
+	return weights[3]
+}
+
+// endpoint returns the path after the api version, e.g. ticker/24hr for /api/v3/ticker/24hr.
+func endpoint(req *http.Request) string {
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[2]
+}
+
+// adaptRateLimits turns the rateLimits of exchangeInfo into rules, weights and
+// headers are taken from defaults.
+func adaptRateLimits(limits []RateLimit, defaults []RateLimitRule) []RateLimitRule {
+	var rules []RateLimitRule
+	for _, l := range limits {
+		var interval time.Duration
+		switch l.Interval {
+		case "SECOND":
+			interval = time.Second
+		case "MINUTE":
+			interval = time.Minute
+		case "HOUR":
+			interval = time.Hour
+		case "DAY":
+			interval = 24 * time.Hour
+		default:
+			continue
+		}
+		if l.IntervalNum > 0 {
+			interval *= time.Duration(l.IntervalNum)
+		}
+
+		rule := RateLimitRule{Name: l.RateLimitType, Interval: interval, Limit: int(l.Limit)}
+		for _, d := range defaults {
+			if d.Name == rule.Name {
+				rule.Weight = d.Weight
+				break
+			}
+		}
+		switch rule.Name {
+		case "REQUEST_WEIGHT":
+			rule.UsedHeader = "X-Mbx-Used-Weight-" + intervalLetter(l.IntervalNum, l.Interval)
+		case "ORDERS":
+			rule.UsedHeader = "X-Mbx-Order-Count-" + intervalLetter(l.IntervalNum, l.Interval)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func intervalLetter(num int64, interval string) string {
+	if num <= 0 {
+		num = 1
+	}
+	return fmt.Sprintf("%d%s", num, strings.ToLower(interval[:1]))
+}
+
+// seedRateLimits installs the limits of exchangeInfo if the client goes through a RateLimiter.
+func seedRateLimits(client *http.Client, limits []RateLimit, defaults []RateLimitRule) {
+	if client == nil || len(limits) == 0 {
+		return
+	}
+	if rl, ok := client.Transport.(*RateLimiter); ok {
+		rl.SetLimits(adaptRateLimits(limits, defaults))
+	}
+}
@@ -2,10 +2,12 @@ package binance
 
 import (
 	json2 "encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/soulsplit/goex"
@@ -29,14 +31,31 @@ type depthResp struct {
 	Asks         [][]interface{} `json:"asks"`
 }
 
-type SpotWs struct {
-	c *goex.WsConn
-
-	reqId int
+type depthUpdateResp struct {
+	Ev string          `json:"e"`
+	E  int64           `json:"E"`
+	U  int64           `json:"U"`
+	U2 int64           `json:"u"`
+	B  [][]interface{} `json:"b"`
+	A  [][]interface{} `json:"a"`
+}
 
-	depthCallFn  func(depth *goex.Depth)
-	tickerCallFn func(ticker *goex.Ticker)
-	tradeCallFn  func(trade *goex.Trade)
+type SpotWs struct {
+	c *goex.WsPool
+
+	depthCallFn       func(depth *goex.Depth)
+	depthUpdateCallFn func(update *goex.DepthUpdate)
+	tickerCallFn      func(ticker *goex.Ticker)
+	tradeCallFn       func(trade *goex.Trade)
+	klineCallFn       func(kline *goex.Kline, period goex.KlinePeriod, closed bool)
+	orderCallFn       func(order *goex.Order)
+	accountCallFn     func(account *goex.Account)
+
+	user              *userDataStream
+	subsLock          sync.Mutex
+	orderPairs        map[string]goex.CurrencyPair
+	accountCurrencies map[string]bool
+	klinePeriods      map[string]goex.KlinePeriod //by stream
 }
 
 func NewSpotWs() *SpotWs {
@@ -48,8 +67,7 @@ func NewSpotWs() *SpotWs {
 		ProxyUrl(os.Getenv("HTTPS_PROXY")).
 		ProtoHandleFunc(spotWs.handle).AutoReconnect()
 
-	spotWs.c = wsBuilder.Build()
-	spotWs.reqId = 1
+	spotWs.c = goex.NewWsPool(wsBuilder, spotWsMaxStreams)
 
 	return spotWs
 }
@@ -58,6 +76,12 @@ func (s *SpotWs) DepthCallback(f func(depth *goex.Depth)) {
 	s.depthCallFn = f
 }
 
+// DepthUpdateCallback receives the diff stream, feed it to a goex.OrderBook
+// using GetDepth as the snapshot.
+func (s *SpotWs) DepthUpdateCallback(f func(update *goex.DepthUpdate)) {
+	s.depthUpdateCallFn = f
+}
+
 func (s *SpotWs) TickerCallback(f func(ticker *goex.Ticker)) {
 	s.tickerCallFn = f
 }
@@ -66,34 +90,82 @@ func (s *SpotWs) TradeCallback(f func(trade *goex.Trade)) {
 	s.tradeCallFn = f
 }
 
+func (s *SpotWs) KlineCallback(f func(kline *goex.Kline, period goex.KlinePeriod, closed bool)) {
+	s.klineCallFn = f
+}
+
 func (s *SpotWs) SubscribeDepth(pair goex.CurrencyPair) error {
-	defer func() {
-		s.reqId++
-	}()
+	return subscribeStream(s.c, pair.ToLower().ToSymbol("")+"@depth10@100ms")
+}
 
-	return s.c.Subscribe(req{
-		Method: "SUBSCRIBE",
-		Params: []string{
-			fmt.Sprintf("%s@depth10@100ms", pair.ToLower().ToSymbol("")),
-		},
-		Id: s.reqId,
-	})
+func (s *SpotWs) SubscribeDepthUpdate(pair goex.CurrencyPair) error {
+	if s.depthUpdateCallFn == nil {
+		return errors.New("please set depth update callback func")
+	}
+	return subscribeStream(s.c, pair.ToLower().ToSymbol("")+"@depth@100ms")
 }
 
 func (s *SpotWs) SubscribeTicker(pair goex.CurrencyPair) error {
-	defer func() {
-		s.reqId++
-	}()
-
-	return s.c.Subscribe(req{
-		Method: "SUBSCRIBE",
-		Params: []string{pair.ToLower().ToSymbol("") + "@ticker"},
-		Id:     s.reqId,
-	})
+	return subscribeStream(s.c, pair.ToLower().ToSymbol("")+"@ticker")
 }
 
 func (s *SpotWs) SubscribeTrade(pair goex.CurrencyPair) error {
-	panic("implement me")
+	return subscribeStream(s.c, pair.ToLower().ToSymbol("")+"@trade")
+}
+
+func (s *SpotWs) SubscribeKline(pair goex.CurrencyPair, period goex.KlinePeriod) error {
+	interval, ok := _INERNAL_KLINE_PERIOD_CONVERTER[period]
+	if !ok {
+		return fmt.Errorf("unsupported kline period %d in binance", period)
+	}
+	stream := pair.ToLower().ToSymbol("") + "@kline_" + interval
+
+	s.subsLock.Lock()
+	if s.klinePeriods == nil {
+		s.klinePeriods = make(map[string]goex.KlinePeriod, 4)
+	}
+	s.klinePeriods[stream] = period
+	s.subsLock.Unlock()
+
+	err := subscribeStream(s.c, stream)
+	if err != nil {
+		s.subsLock.Lock()
+		delete(s.klinePeriods, stream)
+		s.subsLock.Unlock()
+	}
+	return err
+}
+
+func (s *SpotWs) UnsubscribeDepth(pair goex.CurrencyPair) error {
+	return unsubscribeStream(s.c, pair.ToLower().ToSymbol("")+"@depth10@100ms")
+}
+
+func (s *SpotWs) UnsubscribeDepthUpdate(pair goex.CurrencyPair) error {
+	return unsubscribeStream(s.c, pair.ToLower().ToSymbol("")+"@depth@100ms")
+}
+
+func (s *SpotWs) UnsubscribeTicker(pair goex.CurrencyPair) error {
+	return unsubscribeStream(s.c, pair.ToLower().ToSymbol("")+"@ticker")
+}
+
+func (s *SpotWs) UnsubscribeTrade(pair goex.CurrencyPair) error {
+	return unsubscribeStream(s.c, pair.ToLower().ToSymbol("")+"@trade")
+}
+
+func (s *SpotWs) UnsubscribeKline(pair goex.CurrencyPair, period goex.KlinePeriod) error {
+	interval, ok := _INERNAL_KLINE_PERIOD_CONVERTER[period]
+	if !ok {
+		return fmt.Errorf("unsupported kline period %d in binance", period)
+	}
+	stream := pair.ToLower().ToSymbol("") + "@kline_" + interval
+	if err := unsubscribeStream(s.c, stream); err != nil {
+		return err
+	}
+
+	s.subsLock.Lock()
+	delete(s.klinePeriods, stream)
+	s.subsLock.Unlock()
+	return nil
 }
 
 func (s *SpotWs) handle(data []byte) error {
@@ -104,12 +176,34 @@ func (s *SpotWs) handle(data []byte) error {
 		return err
 	}
 
+	if r.Stream == "" && wsResult(data, s.c) {
+		return nil
+	}
+
+	pair, err := adaptStreamToCurrencyPair(r.Stream)
+	if err != nil {
+		logger.Errorf("[%s] %s", r.Stream, err)
+		return err
+	}
+
 	if strings.HasSuffix(r.Stream, "@depth10@100ms") {
-		return s.depthHandle(r.Data, adaptStreamToCurrencyPair(r.Stream))
+		return s.depthHandle(r.Data, pair)
+	}
+
+	if strings.HasSuffix(r.Stream, "@depth@100ms") {
+		return s.depthUpdateHandle(r.Data, pair)
 	}
 
 	if strings.HasSuffix(r.Stream, "@ticker") {
-		return s.tickerHandle(r.Data, adaptStreamToCurrencyPair(r.Stream))
+		return s.tickerHandle(r.Data, pair)
+	}
+
+	if strings.Contains(r.Stream, "@kline_") {
+		return s.klineHandle(r.Data, pair, r.Stream)
+	}
+
+	if strings.HasSuffix(r.Stream, "@trade") {
+		return s.tradeHandle(r.Data, pair)
 	}
 
 	logger.Warn("unknown ws response:", string(data))
@@ -154,6 +248,38 @@ func (s *SpotWs) depthHandle(data json2.RawMessage, pair goex.CurrencyPair) erro
 	return nil
 }
 
+func (s *SpotWs) depthUpdateHandle(data json2.RawMessage, pair goex.CurrencyPair) error {
+	var r depthUpdateResp
+	err := json2.Unmarshal(data, &r)
+	if err != nil {
+		logger.Errorf("unmarshal depth update response error %s[] , response data = %s", err, string(data))
+		return err
+	}
+
+	update := &goex.DepthUpdate{
+		Pair:          pair,
+		FirstUpdateId: r.U,
+		LastUpdateId:  r.U2,
+		UTime:         time.Unix(0, r.E*int64(time.Millisecond)),
+	}
+	for _, bid := range r.B {
+		update.BidList = append(update.BidList, goex.DepthRecord{
+			Price:  goex.ToFloat64(bid[0]),
+			Amount: goex.ToFloat64(bid[1]),
+		})
+	}
+	for _, ask := range r.A {
+		update.AskList = append(update.AskList, goex.DepthRecord{
+			Price:  goex.ToFloat64(ask[0]),
+			Amount: goex.ToFloat64(ask[1]),
+		})
+	}
+
+	s.depthUpdateCallFn(update)
+
+	return nil
+}
+
 func (s *SpotWs) tickerHandle(data json2.RawMessage, pair goex.CurrencyPair) error {
 	var (
 		tickerData = make(map[string]interface{}, 4)
@@ -179,3 +305,45 @@ func (s *SpotWs) tickerHandle(data json2.RawMessage, pair goex.CurrencyPair) err
 
 	return nil
 }
+
+func (s *SpotWs) klineHandle(data json2.RawMessage, pair goex.CurrencyPair, stream string) error {
+	var r struct {
+		K map[string]interface{} `json:"k"`
+	}
+	err := json2.Unmarshal(data, &r)
+	if err != nil {
+		logger.Errorf("unmarshal kline response data error [%s] , data = %s", err, string(data))
+		return err
+	}
+
+	s.subsLock.Lock()
+	period, ok := s.klinePeriods[stream]
+	s.subsLock.Unlock()
+	if !ok || s.klineCallFn == nil {
+		return nil
+	}
+
+	kline, closed := adaptWsKline(r.K)
+	kline.Pair = pair
+	s.klineCallFn(kline, period, closed)
+
+	return nil
+}
+
+func (s *SpotWs) tradeHandle(data json2.RawMessage, pair goex.CurrencyPair) error {
+	var m map[string]interface{}
+	err := json2.Unmarshal(data, &m)
+	if err != nil {
+		logger.Errorf("unmarshal trade response data error [%s] , data = %s", err, string(data))
+		return err
+	}
+	if s.tradeCallFn == nil {
+		return nil
+	}
+
+	trade := adaptWsTrade(m)
+	trade.Pair = pair
+	s.tradeCallFn(trade)
+
+	return nil
+}
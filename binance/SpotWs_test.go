@@ -1,12 +1,18 @@
 package binance
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var spotWs *SpotWs
@@ -36,3 +42,120 @@ func TestSpotWs_SubscribeTicker(t *testing.T) {
 	spotWs.SubscribeTicker(goex.LTC_USDT)
 	time.Sleep(30 * time.Minute)
 }
+
+func TestSpotWs_Replay(t *testing.T) {
+	rp, err := goex.NewWsReplayer("testdata/spot_ws.jsonl")
+	require.NoError(t, err)
+	rp.Speed = 0
+
+	var (
+		depths  []*goex.Depth
+		tickers []*goex.Ticker
+	)
+	ws := &SpotWs{}
+	ws.DepthCallback(func(depth *goex.Depth) { depths = append(depths, depth) })
+	ws.TickerCallback(func(ticker *goex.Ticker) { tickers = append(tickers, ticker) })
+
+	require.NoError(t, rp.Play(context.Background(), &goex.WsConfig{ProtoHandleFunc: ws.handle}))
+
+	require.Len(t, depths, 1)
+	assert.Equal(t, goex.BTC_USDT.String(), depths[0].Pair.String())
+	assert.Equal(t, 29316.42, depths[0].BidList[0].Price)
+	assert.Equal(t, 29317.0, depths[0].AskList[0].Price)
+
+	require.Len(t, tickers, 1)
+	assert.Equal(t, goex.BTC_USDT.String(), tickers[0].Pair.String())
+	assert.Equal(t, 29316.43, tickers[0].Last)
+	assert.Equal(t, 51262.30, tickers[0].Vol)
+	assert.Equal(t, uint64(1609459200200), tickers[0].Date)
+}
+
+func TestSpotWs_DepthUpdate(t *testing.T) {
+	var update *goex.DepthUpdate
+	ws := &SpotWs{}
+	ws.DepthUpdateCallback(func(u *goex.DepthUpdate) { update = u })
+
+	err := ws.handle([]byte(`{"stream":"btcusdt@depth@100ms","data":{"e":"depthUpdate","E":1609459200100,"s":"BTCUSDT","U":157,"u":160,"b":[["29316.42","0.5"]],"a":[["29316.43","0"]]}}`))
+	require.NoError(t, err)
+	require.NotNil(t, update)
+	assert.Equal(t, goex.BTC_USDT.String(), update.Pair.String())
+	assert.Equal(t, int64(157), update.FirstUpdateId)
+	assert.Equal(t, int64(160), update.LastUpdateId)
+	assert.Equal(t, goex.DepthRecords{{Price: 29316.42, Amount: 0.5}}, update.BidList)
+	assert.Equal(t, goex.DepthRecords{{Price: 29316.43, Amount: 0}}, update.AskList)
+
+	assert.Error(t, (&SpotWs{}).SubscribeDepthUpdate(goex.BTC_USDT), "no callback")
+}
+
+func TestSpotWs_Trade(t *testing.T) {
+	var trades []*goex.Trade
+	ws := &SpotWs{}
+	ws.TradeCallback(func(trade *goex.Trade) { trades = append(trades, trade) })
+
+	require.NoError(t, ws.handle([]byte(`{"stream":"btcusdt@trade","data":{"e":"trade","E":1609459200120,"s":"BTCUSDT","t":530342082,"p":"29316.43","q":"0.012","b":4011238190,"a":4011238188,"T":1609459200118,"m":false,"M":true}}`)))
+	require.Len(t, trades, 1)
+	assert.Equal(t, goex.BTC_USDT.String(), trades[0].Pair.String())
+	assert.Equal(t, int64(530342082), trades[0].Tid)
+	assert.Equal(t, goex.SELL, trades[0].Type)
+	assert.Equal(t, 29316.43, trades[0].Price)
+	assert.Equal(t, 0.012, trades[0].Amount)
+	assert.Equal(t, int64(1609459200118), trades[0].Date)
+}
+
+func TestSpotWs_Kline(t *testing.T) {
+	var (
+		klines []*goex.Kline
+		closed []bool
+	)
+	ws := &SpotWs{klinePeriods: map[string]goex.KlinePeriod{"btcusdt@kline_1h": goex.KLINE_PERIOD_60MIN}}
+	ws.KlineCallback(func(kline *goex.Kline, period goex.KlinePeriod, c bool) {
+		assert.Equal(t, goex.KlinePeriod(goex.KLINE_PERIOD_60MIN), period, "the subscribed period")
+		klines = append(klines, kline)
+		closed = append(closed, c)
+	})
+
+	require.NoError(t, ws.handle([]byte(`{"stream":"btcusdt@kline_1h","data":{"e":"kline","E":1609462799000,"s":"BTCUSDT","k":{"t":1609459200000,"T":1609462799999,"s":"BTCUSDT","i":"1h","o":"28923.63","c":"29278.40","h":"29470.00","l":"28690.17","v":"2311.81","n":58389,"x":false,"q":"67463131.89"}}}`)))
+	require.NoError(t, ws.handle([]byte(`{"stream":"btcusdt@kline_1h","data":{"e":"kline","E":1609462800000,"s":"BTCUSDT","k":{"t":1609459200000,"T":1609462799999,"s":"BTCUSDT","i":"1h","o":"28923.63","c":"29331.69","h":"29470.00","l":"28690.17","v":"2311.81","n":58390,"x":true}}}`)))
+	require.NoError(t, ws.handle([]byte(`{"stream":"ethusdt@kline_1h","data":{"e":"kline","s":"ETHUSDT","k":{"t":1609459200000,"i":"1h","x":false}}}`)))
+	require.Len(t, klines, 2, "only the subscribed streams")
+	assert.Equal(t, []bool{false, true}, closed)
+	assert.Equal(t, goex.BTC_USDT.String(), klines[0].Pair.String())
+	assert.Equal(t, int64(1609459200), klines[0].Timestamp)
+	assert.Equal(t, 28923.63, klines[0].Open)
+	assert.Equal(t, 29278.40, klines[0].Close)
+	assert.Equal(t, 29470.0, klines[0].High)
+	assert.Equal(t, 28690.17, klines[0].Low)
+	assert.Equal(t, 2311.81, klines[0].Vol)
+	assert.Equal(t, 29331.69, klines[1].Close)
+
+	assert.Error(t, ws.SubscribeKline(goex.BTC_USDT, goex.KLINE_PERIOD_1YEAR))
+}
+
+func TestSpotWs_Subscriptions(t *testing.T) {
+	rp := &goex.WsReplayer{}
+	srv := rp.Server()
+	defer srv.Close()
+
+	ws := &SpotWs{}
+	ws.c = goex.NewWsPool(goex.NewWsBuilder().WsUrl("ws"+strings.TrimPrefix(srv.URL, "http")).ProtoHandleFunc(ws.handle), 1)
+	defer ws.c.Close(context.Background())
+
+	subscribe := func(sub func() error, result string) error {
+		done := make(chan error, 1)
+		go func() { done <- sub() }()
+		require.Eventually(t, func() bool { return len(ws.c.PendingAcks()) == 1 }, time.Second, 10*time.Millisecond)
+		require.NoError(t, ws.handle([]byte(fmt.Sprintf(result, ws.c.PendingAcks()[0]))))
+		return <-done
+	}
+
+	require.NoError(t, subscribe(func() error { return ws.SubscribeTicker(goex.BTC_USDT) }, `{"result":null,"id":%s}`))
+	assert.NoError(t, ws.SubscribeTicker(goex.BTC_USDT), "subscribed already")
+	err := subscribe(func() error { return ws.SubscribeDepth(goex.BTC_USDT) }, `{"code":2,"msg":"Invalid request: unknown variant","id":%s}`)
+	assert.True(t, errors.Is(err, goex.EX_ERR_INVALID_PARAMETER), err)
+	assert.Equal(t, []string{"btcusdt@ticker"}, ws.c.Subscriptions())
+	assert.Len(t, ws.c.Conns(), 2, "a stream a connection")
+
+	require.NoError(t, ws.UnsubscribeTicker(goex.BTC_USDT))
+	assert.Equal(t, goex.ErrNotSubscribed, ws.UnsubscribeTicker(goex.BTC_USDT))
+	assert.Empty(t, ws.c.Subscriptions())
+}
@@ -0,0 +1,533 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/internal/logger"
+)
+
+const userDataKeepalive = 30 * time.Minute
+
+// userDataStream is a listenKey stream of the private events, the key is kept alive
+// every 30 minutes and a new one is opened when it expires, until close.
+type userDataStream struct {
+	apiKey     string
+	httpClient *http.Client
+	restUrl    string //the listenKey endpoint
+	wsUrl      string //the listenKey is appended
+	handle     func(m map[string]interface{}) error
+
+	lock      sync.Mutex
+	listenKey string
+	conn      *goex.WsConn
+	stop      chan struct{}
+}
+
+func newUserDataStream(config *goex.APIConfig, restUrl, wsUrl string, handle func(m map[string]interface{}) error) *userDataStream {
+	return &userDataStream{
+		apiKey:     config.ApiKey,
+		httpClient: config.HttpClient,
+		restUrl:    restUrl,
+		wsUrl:      wsUrl,
+		handle:     handle,
+	}
+}
+
+// start opens the stream once, the later calls do nothing until close.
+func (u *userDataStream) start() error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.conn != nil {
+		return nil
+	}
+	if u.apiKey == "" {
+		return goex.EX_ERR_NOT_FIND_APIKEY
+	}
+	if err := u.connect(); err != nil {
+		return err
+	}
+	u.stop = make(chan struct{})
+	go u.keepaliveLoop(u.stop)
+	return nil
+}
+
+func (u *userDataStream) connect() error {
+	resp, err := goex.HttpPostForm2(u.httpClient, u.restUrl, url.Values{}, map[string]string{"X-MBX-APIKEY": u.apiKey})
+	if err != nil {
+		return adaptError(err)
+	}
+
+	var r struct {
+		ListenKey string `json:"listenKey"`
+	}
+	if err = json.Unmarshal(resp, &r); err != nil || r.ListenKey == "" {
+		return adaptErrorBody(resp)
+	}
+
+	u.listenKey = r.ListenKey
+	u.conn = goex.NewWsBuilder().
+		WsUrl(u.wsUrl + r.ListenKey).
+		ProxyUrl(os.Getenv("HTTPS_PROXY")).
+		ProtoHandleFunc(u.protoHandle).AutoReconnect().Build()
+	return nil
+}
+
+func (u *userDataStream) keepaliveLoop(stop <-chan struct{}) {
+	tick := time.NewTicker(userDataKeepalive)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-tick.C:
+		}
+
+		u.lock.Lock()
+		params := url.Values{}
+		params.Set("listenKey", u.listenKey)
+		u.lock.Unlock()
+
+		_, err := goex.HttpPut(u.httpClient, u.restUrl, params, map[string]string{"X-MBX-APIKEY": u.apiKey})
+		if err != nil {
+			logger.Warnf("[user data] keepalive the listenKey error: %s", adaptError(err))
+			u.renew()
+		}
+	}
+}
+
+// renew opens a new listenKey and connection, then closes the old one. A closed
+// stream is left closed.
+func (u *userDataStream) renew() {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	old := u.conn
+	if old == nil {
+		return
+	}
+	if err := u.connect(); err != nil {
+		logger.Errorf("[user data] renew the listenKey error: %s", err)
+		return
+	}
+	old.CloseWs()
+}
+
+// close stops the keepalive, closes the connection and drops the listenKey.
+func (u *userDataStream) close(ctx context.Context) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.conn == nil {
+		return nil
+	}
+	close(u.stop)
+	err := u.conn.Close(ctx)
+	u.conn = nil
+
+	params := url.Values{}
+	params.Set("listenKey", u.listenKey)
+	u.listenKey = ""
+	if _, e := goex.HttpDeleteFormWithContext(ctx, u.httpClient, u.restUrl, params, map[string]string{"X-MBX-APIKEY": u.apiKey}); e != nil {
+		logger.Warnf("[user data] drop the listenKey error: %s", adaptError(e))
+	}
+	return err
+}
+
+func (u *userDataStream) protoHandle(data []byte) error {
+	var m = make(map[string]interface{}, 8)
+	if err := json.Unmarshal(data, &m); err != nil {
+		logger.Errorf("json unmarshal user data error [%s] , data = %s", err, string(data))
+		return err
+	}
+
+	if e, _ := m["e"].(string); e == "listenKeyExpired" {
+		logger.Warn("[user data] the listenKey expired, renew it")
+		go u.renew()
+		return nil
+	}
+
+	return u.handle(m)
+}
+
+func toString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func adaptUserOrder(m map[string]interface{}, pair goex.CurrencyPair) *goex.Order {
+	ord := &goex.Order{
+		Cid:        toString(m["c"]),
+		OrderID:    goex.ToInt(m["i"]),
+		OrderID2:   fmt.Sprint(goex.ToInt64(m["i"])),
+		Price:      goex.ToFloat64(m["p"]),
+		Amount:     goex.ToFloat64(m["q"]),
+		DealAmount: goex.ToFloat64(m["z"]),
+		Status:     adaptOrderStatus(toString(m["X"])),
+		Currency:   pair,
+		Side:       goex.AdaptTradeSide(toString(m["S"])),
+		Type:       strings.ToLower(toString(m["o"])),
+		OrderTime:  goex.ToInt(m["O"]),
+	}
+
+	if cid := toString(m["C"]); cid != "" { //the cancel request has its own client id
+		ord.Cid = cid
+	}
+	if ord.DealAmount > 0 {
+		ord.AvgPrice = goex.ToFloat64(m["Z"]) / ord.DealAmount
+	}
+	switch ord.Status {
+	case goex.ORDER_FINISH, goex.ORDER_CANCEL, goex.ORDER_REJECT:
+		ord.FinishedTime = goex.ToInt64(m["T"])
+	}
+
+	return ord
+}
+
+// NewSpotWsWithConfig is NewSpotWs with the api key of the private streams.
+func NewSpotWsWithConfig(config *goex.APIConfig) *SpotWs {
+	if config.Endpoint == "" {
+		config.Endpoint = GLOBAL_API_BASE_URL
+	}
+	if config.HttpClient == nil {
+		config.HttpClient = http.DefaultClient
+	}
+
+	s := NewSpotWs()
+	s.user = newUserDataStream(config, config.Endpoint+"/api/v3/userDataStream",
+		"wss://stream.binance.com:9443/ws/", s.userHandle)
+	return s
+}
+
+// Close closes the market streams and the private one, the ws can not be used after.
+func (s *SpotWs) Close(ctx context.Context) error {
+	err := s.c.Close(ctx)
+	if s.user != nil {
+		if e := s.user.close(ctx); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (s *SpotWs) OrderCallback(f func(order *goex.Order)) {
+	s.orderCallFn = f
+}
+
+func (s *SpotWs) AccountCallback(f func(account *goex.Account)) {
+	s.accountCallFn = f
+}
+
+func (s *SpotWs) Login() error {
+	if s.user == nil {
+		return goex.EX_ERR_NOT_FIND_APIKEY
+	}
+	return s.user.start()
+}
+
+func (s *SpotWs) SubscribeOrder(pair goex.CurrencyPair) error {
+	if err := s.Login(); err != nil {
+		return err
+	}
+
+	s.subsLock.Lock()
+	defer s.subsLock.Unlock()
+	if s.orderPairs == nil {
+		s.orderPairs = make(map[string]goex.CurrencyPair, 4)
+	}
+	s.orderPairs[pair.ToSymbol("")] = pair
+	return nil
+}
+
+// SubscribeAccount subscribes the balances of both currencies of the pair.
+func (s *SpotWs) SubscribeAccount(pair goex.CurrencyPair) error {
+	if err := s.Login(); err != nil {
+		return err
+	}
+
+	s.subsLock.Lock()
+	defer s.subsLock.Unlock()
+	if s.accountCurrencies == nil {
+		s.accountCurrencies = make(map[string]bool, 4)
+	}
+	s.accountCurrencies[pair.CurrencyA.Symbol] = true
+	s.accountCurrencies[pair.CurrencyB.Symbol] = true
+	return nil
+}
+
+func (s *SpotWs) subscribed(symbol string) (pair goex.CurrencyPair, order bool, account bool) {
+	s.subsLock.Lock()
+	defer s.subsLock.Unlock()
+	pair, order = s.orderPairs[symbol]
+	return pair, order, s.accountCurrencies[symbol]
+}
+
+func (s *SpotWs) userHandle(m map[string]interface{}) error {
+	switch toString(m["e"]) {
+	case "executionReport":
+		pair, ok, _ := s.subscribed(toString(m["s"]))
+		if ok && s.orderCallFn != nil {
+			s.orderCallFn(adaptUserOrder(m, pair))
+		}
+	case "outboundAccountPosition":
+		acc := goex.Account{Exchange: goex.BINANCE, SubAccounts: make(map[goex.Currency]goex.SubAccount)}
+		balances, _ := m["B"].([]interface{})
+		for _, v := range balances {
+			b, _ := v.(map[string]interface{})
+			symbol := toString(b["a"])
+			if _, _, ok := s.subscribed(symbol); !ok {
+				continue
+			}
+			currency := goex.NewCurrency(symbol, "")
+			acc.SubAccounts[currency] = goex.SubAccount{
+				Currency:     currency,
+				Amount:       goex.ToFloat64(b["f"]),
+				ForzenAmount: goex.ToFloat64(b["l"]),
+			}
+		}
+		if len(acc.SubAccounts) > 0 && s.accountCallFn != nil {
+			s.accountCallFn(&acc)
+		}
+	case "balanceUpdate":
+	default:
+		logger.Warn("unknown user data event:", m["e"])
+	}
+
+	return nil
+}
+
+// futuresSub is a subscribed contract of the private futures streams.
+type futuresSub struct {
+	pair         goex.CurrencyPair
+	contractType string
+}
+
+// NewFuturesWsWithConfig is NewFuturesWs with the api key of the private streams,
+// the usdt margined contracts are streamed by fapi and the others by dapi.
+func NewFuturesWsWithConfig(config *goex.APIConfig) *FuturesWs {
+	s := NewFuturesWs()
+	if config.HttpClient == nil {
+		config.HttpClient = s.base.base.httpClient
+	}
+
+	s.fUser = newUserDataStream(config, "https://fapi.binance.com/fapi/v1/listenKey",
+		"wss://fstream.binance.com/ws/", s.userHandle)
+	s.dUser = newUserDataStream(config, "https://dapi.binance.com/dapi/v1/listenKey",
+		"wss://dstream.binance.com/ws/", s.userHandle)
+	return s
+}
+
+// Close closes the market streams and the private ones, the ws can not be used after.
+func (s *FuturesWs) Close(ctx context.Context) error {
+	var err error
+	for _, p := range []*goex.WsPool{s.f, s.d} {
+		if e := p.Close(ctx); e != nil {
+			err = e
+		}
+	}
+	for _, u := range []*userDataStream{s.fUser, s.dUser} {
+		if u == nil {
+			continue
+		}
+		if e := u.close(ctx); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (s *FuturesWs) OrderCallback(f func(order *goex.FutureOrder)) {
+	s.orderCallFn = f
+}
+
+func (s *FuturesWs) PositionCallback(f func(position *goex.FuturePosition)) {
+	s.positionCallFn = f
+}
+
+func (s *FuturesWs) AccountCallback(f func(account *goex.FutureAccount)) {
+	s.accountCallFn = f
+}
+
+// Login opens the streams of both the usdt and the coin margined contracts.
+func (s *FuturesWs) Login() error {
+	for _, u := range []*userDataStream{s.fUser, s.dUser} {
+		if err := s.login(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FuturesWs) login(u *userDataStream) error {
+	if u == nil {
+		return goex.EX_ERR_NOT_FIND_APIKEY
+	}
+	return u.start()
+}
+
+// userSub logs in the stream of the contract and adds it to subs.
+func (s *FuturesWs) userSub(subs *map[string]futuresSub, pair goex.CurrencyPair, contractType string) error {
+	var (
+		symbol string
+		u      = s.dUser
+		err    error
+	)
+	if contractType == goex.SWAP_USDT_CONTRACT {
+		symbol, u = pair.AdaptUsdToUsdt().ToSymbol(""), s.fUser
+	} else if symbol, err = s.base.adaptToSymbol(pair.AdaptUsdtToUsd(), contractType); err != nil {
+		return err
+	}
+
+	if err = s.login(u); err != nil {
+		return err
+	}
+
+	s.subsLock.Lock()
+	defer s.subsLock.Unlock()
+	if *subs == nil {
+		*subs = make(map[string]futuresSub, 4)
+	}
+	(*subs)[symbol] = futuresSub{pair: pair, contractType: contractType}
+	return nil
+}
+
+func (s *FuturesWs) SubscribeOrder(pair goex.CurrencyPair, contractType string) error {
+	return s.userSub(&s.orderSubs, pair, contractType)
+}
+
+func (s *FuturesWs) SubscribePosition(pair goex.CurrencyPair, contractType string) error {
+	return s.userSub(&s.positionSubs, pair, contractType)
+}
+
+// SubscribeAccount subscribes the margin of the pair, usdt for the usdt margined
+// contracts and the base currency for the coin margined ones.
+func (s *FuturesWs) SubscribeAccount(pair goex.CurrencyPair) error {
+	u, currency := s.dUser, pair.CurrencyA
+	if pair.CurrencyB == goex.USDT {
+		u, currency = s.fUser, goex.USDT
+	}
+	if err := s.login(u); err != nil {
+		return err
+	}
+
+	s.subsLock.Lock()
+	defer s.subsLock.Unlock()
+	if s.accountCurrencies == nil {
+		s.accountCurrencies = make(map[string]bool, 4)
+	}
+	s.accountCurrencies[currency.Symbol] = true
+	return nil
+}
+
+func (s *FuturesWs) subscribed(subs *map[string]futuresSub, symbol string) (futuresSub, bool) {
+	s.subsLock.Lock()
+	defer s.subsLock.Unlock()
+	sub, ok := (*subs)[symbol]
+	return sub, ok
+}
+
+func (s *FuturesWs) userHandle(m map[string]interface{}) error {
+	switch toString(m["e"]) {
+	case "ORDER_TRADE_UPDATE":
+		o, _ := m["o"].(map[string]interface{})
+		sub, ok := s.subscribed(&s.orderSubs, toString(o["s"]))
+		if ok && s.orderCallFn != nil {
+			s.orderCallFn(s.adaptUserOrder(o, sub))
+		}
+	case "ACCOUNT_UPDATE":
+		a, _ := m["a"].(map[string]interface{})
+		s.accountUpdateHandle(a)
+	case "MARGIN_CALL", "ACCOUNT_CONFIG_UPDATE":
+	default:
+		logger.Warn("unknown user data event:", m["e"])
+	}
+
+	return nil
+}
+
+func (s *FuturesWs) adaptUserOrder(o map[string]interface{}, sub futuresSub) *goex.FutureOrder {
+	ord := &goex.FutureOrder{
+		ClientOid:    toString(o["c"]),
+		OrderID:      goex.ToInt64(o["i"]),
+		OrderID2:     fmt.Sprint(goex.ToInt64(o["i"])),
+		Price:        goex.ToFloat64(o["p"]),
+		Amount:       goex.ToFloat64(o["q"]),
+		AvgPrice:     goex.ToFloat64(o["ap"]),
+		DealAmount:   goex.ToFloat64(o["z"]),
+		Status:       s.base.adaptStatus(toString(o["X"])),
+		Currency:     sub.pair,
+		OType:        s.base.adaptOType(toString(o["S"]), toString(o["ps"])),
+		ContractName: sub.contractType,
+		Fee:          goex.ToFloat64(o["n"]),
+		TriggerPrice: goex.ToFloat64(o["sp"]),
+	}
+	switch toString(o["f"]) {
+	case "GTX":
+		ord.OrderType = goex.ORDER_FEATURE_POST_ONLY
+	case "FOK":
+		ord.OrderType = goex.ORDER_FEATURE_FOK
+	case "IOC":
+		ord.OrderType = goex.ORDER_FEATURE_IOC
+	}
+	switch ord.Status {
+	case goex.ORDER_FINISH, goex.ORDER_CANCEL, goex.ORDER_REJECT:
+		ord.FinishedTime = goex.ToInt64(o["T"])
+	default:
+		ord.OrderTime = goex.ToInt64(o["T"])
+	}
+	return ord
+}
+
+func (s *FuturesWs) accountUpdateHandle(a map[string]interface{}) {
+	acc := goex.FutureAccount{FutureSubAccounts: make(map[goex.Currency]goex.FutureSubAccount)}
+	balances, _ := a["B"].([]interface{})
+	for _, v := range balances {
+		b, _ := v.(map[string]interface{})
+		symbol := toString(b["a"])
+		s.subsLock.Lock()
+		ok := s.accountCurrencies[symbol]
+		s.subsLock.Unlock()
+		if !ok {
+			continue
+		}
+		currency := goex.NewCurrency(symbol, "")
+		acc.FutureSubAccounts[currency] = goex.FutureSubAccount{
+			Currency:      currency,
+			AccountRights: goex.ToFloat64(b["wb"]),
+		}
+	}
+	if len(acc.FutureSubAccounts) > 0 && s.accountCallFn != nil {
+		s.accountCallFn(&acc)
+	}
+
+	positions, _ := a["P"].([]interface{})
+	for _, v := range positions {
+		p, _ := v.(map[string]interface{})
+		sub, ok := s.subscribed(&s.positionSubs, toString(p["s"]))
+		if !ok || s.positionCallFn == nil {
+			continue
+		}
+
+		pos := &goex.FuturePosition{Symbol: sub.pair, ContractType: sub.contractType}
+		amount, price, profit := goex.ToFloat64(p["pa"]), goex.ToFloat64(p["ep"]), goex.ToFloat64(p["up"])
+		if toString(p["ps"]) == "SHORT" || amount < 0 {
+			pos.SellAmount, pos.SellAvailable = math.Abs(amount), math.Abs(amount)
+			pos.SellPriceAvg, pos.SellPriceCost, pos.SellProfit = price, price, profit
+		} else {
+			pos.BuyAmount, pos.BuyAvailable = amount, amount
+			pos.BuyPriceAvg, pos.BuyPriceCost, pos.BuyProfit = price, price, profit
+		}
+		s.positionCallFn(pos)
+	}
+}
@@ -0,0 +1,148 @@
+package binance
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpotWs_UserData(t *testing.T) {
+	var (
+		orders   []*goex.Order
+		accounts []*goex.Account
+	)
+	ws := &SpotWs{
+		orderPairs:        map[string]goex.CurrencyPair{"BTCUSDT": goex.BTC_USDT},
+		accountCurrencies: map[string]bool{"BTC": true, "USDT": true},
+	}
+	ws.OrderCallback(func(order *goex.Order) { orders = append(orders, order) })
+	ws.AccountCallback(func(account *goex.Account) { accounts = append(accounts, account) })
+	u := &userDataStream{handle: ws.userHandle}
+
+	require.NoError(t, u.protoHandle([]byte(`{"e":"executionReport","E":1609459200100,"s":"BTCUSDT","c":"abc","S":"BUY","o":"LIMIT","f":"GTC","q":"2.00000000","p":"29000.00000000","P":"0.00000000","X":"PARTIALLY_FILLED","i":4293153,"l":"0.5","z":"1.50000000","L":"29000.00000000","Z":"43200.00000000","T":1609459200099,"O":1609459200000,"C":"","I":8641984}`)))
+	require.NoError(t, u.protoHandle([]byte(`{"e":"executionReport","E":1609459200100,"s":"ETHUSDT","c":"abc","S":"BUY","o":"LIMIT","q":"1","p":"700","X":"NEW","i":1,"z":"0","Z":"0"}`)))
+	require.Len(t, orders, 1, "only the subscribed pairs")
+	ord := orders[0]
+	assert.Equal(t, "4293153", ord.OrderID2)
+	assert.Equal(t, "abc", ord.Cid)
+	assert.Equal(t, goex.BTC_USDT, ord.Currency)
+	assert.Equal(t, goex.BUY, ord.Side)
+	assert.Equal(t, goex.ORDER_PART_FINISH, ord.Status)
+	assert.Equal(t, 29000.0, ord.Price, "not the stop price of P")
+	assert.Equal(t, 2.0, ord.Amount)
+	assert.Equal(t, 1.5, ord.DealAmount)
+	assert.Equal(t, 28800.0, ord.AvgPrice)
+	assert.Equal(t, "limit", ord.Type)
+
+	require.NoError(t, u.protoHandle([]byte(`{"e":"executionReport","s":"BTCUSDT","c":"cancel1","S":"SELL","o":"LIMIT","X":"CANCELED","i":4293153,"z":"0","T":1609459201000,"C":"abc"}`)))
+	require.Len(t, orders, 2)
+	assert.Equal(t, "abc", orders[1].Cid, "the client id of the canceled order")
+	assert.Equal(t, int64(1609459201000), orders[1].FinishedTime)
+
+	require.NoError(t, u.protoHandle([]byte(`{"e":"outboundAccountPosition","E":1609459200100,"u":1609459200099,"B":[{"a":"BTC","f":"1.5","l":"0.5"},{"a":"BNB","f":"1","l":"0"}]}`)))
+	require.Len(t, accounts, 1)
+	assert.Equal(t, map[goex.Currency]goex.SubAccount{
+		goex.BTC: {Currency: goex.BTC, Amount: 1.5, ForzenAmount: 0.5},
+	}, accounts[0].SubAccounts)
+}
+
+func TestFuturesWs_UserData(t *testing.T) {
+	var (
+		orders    []*goex.FutureOrder
+		positions []*goex.FuturePosition
+		accounts  []*goex.FutureAccount
+	)
+	ws := &FuturesWs{
+		orderSubs:         map[string]futuresSub{"BTCUSDT": {goex.BTC_USDT, goex.SWAP_USDT_CONTRACT}},
+		positionSubs:      map[string]futuresSub{"BTCUSD_PERP": {goex.BTC_USD, goex.SWAP_CONTRACT}},
+		accountCurrencies: map[string]bool{"USDT": true},
+	}
+	ws.OrderCallback(func(order *goex.FutureOrder) { orders = append(orders, order) })
+	ws.PositionCallback(func(position *goex.FuturePosition) { positions = append(positions, position) })
+	ws.AccountCallback(func(account *goex.FutureAccount) { accounts = append(accounts, account) })
+	u := &userDataStream{handle: ws.userHandle}
+
+	require.NoError(t, u.protoHandle([]byte(`{"e":"ORDER_TRADE_UPDATE","E":1568879465651,"T":1568879465650,"o":{"s":"BTCUSDT","c":"cid","S":"SELL","o":"LIMIT","f":"IOC","q":"0.002","p":"30000","ap":"30001","sp":"0","x":"TRADE","X":"FILLED","i":8886774,"l":"0.002","z":"0.002","L":"30001","N":"USDT","n":"0.024","T":1568879465650,"t":1,"ps":"LONG"}}`)))
+	require.Len(t, orders, 1)
+	ord := orders[0]
+	assert.Equal(t, "8886774", ord.OrderID2)
+	assert.Equal(t, goex.ORDER_FINISH, ord.Status)
+	assert.Equal(t, goex.CLOSE_BUY, ord.OType)
+	assert.Equal(t, goex.ORDER_FEATURE_IOC, ord.OrderType)
+	assert.Equal(t, goex.SWAP_USDT_CONTRACT, ord.ContractName)
+	assert.Equal(t, 30001.0, ord.AvgPrice)
+	assert.Equal(t, 0.024, ord.Fee)
+	assert.Equal(t, int64(1568879465650), ord.FinishedTime)
+
+	require.NoError(t, u.protoHandle([]byte(`{"e":"ACCOUNT_UPDATE","E":1564745798939,"T":1564745798938,"a":{"m":"ORDER","B":[{"a":"USDT","wb":"122624.12","cw":"100.12"},{"a":"BTC","wb":"1","cw":"1"}],"P":[{"s":"BTCUSD_PERP","pa":"-20","ep":"9000","cr":"0","up":"-1.5","mt":"isolated","iw":"0","ps":"BOTH"},{"s":"ETHUSD_PERP","pa":"1","ep":"200","up":"0","ps":"BOTH"}]}}`)))
+	require.Len(t, accounts, 1)
+	assert.Equal(t, map[goex.Currency]goex.FutureSubAccount{
+		goex.USDT: {Currency: goex.USDT, AccountRights: 122624.12},
+	}, accounts[0].FutureSubAccounts)
+	require.Len(t, positions, 1)
+	assert.Equal(t, &goex.FuturePosition{Symbol: goex.BTC_USD, ContractType: goex.SWAP_CONTRACT,
+		SellAmount: 20, SellAvailable: 20, SellPriceAvg: 9000, SellPriceCost: 9000, SellProfit: -1.5}, positions[0])
+}
+
+func TestSpotWs_LoginWithoutKey(t *testing.T) {
+	ws := &SpotWs{}
+	assert.Equal(t, goex.EX_ERR_NOT_FIND_APIKEY, ws.SubscribeOrder(goex.BTC_USDT))
+	ws.user = newUserDataStream(&goex.APIConfig{}, "", "", ws.userHandle)
+	assert.Equal(t, goex.EX_ERR_NOT_FIND_APIKEY, ws.Login())
+}
+
+func TestUserDataStream_Close(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		requests []string
+		upgrader websocket.Upgrader
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			c, err := upgrader.Upgrade(w, r, nil)
+			require.NoError(t, err)
+			defer c.Close()
+			for {
+				if _, _, err := c.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		form, _ := url.ParseQuery(string(body))
+		lock.Lock()
+		requests = append(requests, r.Method+" "+form.Get("listenKey"))
+		lock.Unlock()
+		w.Write([]byte(`{"listenKey":"key1"}`))
+	}))
+	defer srv.Close()
+
+	u := newUserDataStream(&goex.APIConfig{ApiKey: "k", HttpClient: http.DefaultClient}, srv.URL+"/api/v3/userDataStream",
+		"ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/", func(map[string]interface{}) error { return nil })
+	require.NoError(t, u.start())
+	conn, stop := u.conn, u.stop
+
+	require.NoError(t, u.close(context.Background()))
+	assert.Equal(t, goex.WsClosed, conn.State())
+	select {
+	case <-stop:
+	default:
+		t.Fatal("the keepalive loop is not stopped")
+	}
+	assert.NoError(t, u.close(context.Background()), "closed twice")
+
+	u.renew()
+	lock.Lock()
+	assert.Equal(t, []string{"POST ", "DELETE key1"}, requests, "a closed stream is not renewed")
+	lock.Unlock()
+}
@@ -1,6 +1,7 @@
 package binance
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -20,14 +21,26 @@ func NewWallet(c *APIConfig) *Wallet {
 }
 
 func (w *Wallet) GetAccount() (*Account, error) {
+	return w.GetAccountWithContext(context.Background())
+}
+
+func (w *Wallet) GetAccountWithContext(ctx context.Context) (*Account, error) {
 	return nil, errors.New("not implement")
 }
 
 func (w *Wallet) Withdrawal(param WithdrawParameter) (withdrawId string, err error) {
+	return w.WithdrawalWithContext(context.Background(), param)
+}
+
+func (w *Wallet) WithdrawalWithContext(ctx context.Context, param WithdrawParameter) (withdrawId string, err error) {
 	return "", errors.New("not implement")
 }
 
 func (w *Wallet) Transfer(param TransferParameter) error {
+	return w.TransferWithContext(context.Background(), param)
+}
+
+func (w *Wallet) TransferWithContext(ctx context.Context, param TransferParameter) error {
 	transferUrl := w.conf.Endpoint + "/sapi/v1/futures/transfer"
 
 	postParam := url.Values{}
@@ -52,11 +65,11 @@ func (w *Wallet) Transfer(param TransferParameter) error {
 
 	w.ba.buildParamsSigned(&postParam)
 
-	resp, err := HttpPostForm2(w.ba.httpClient, transferUrl, postParam,
+	resp, err := HttpPostForm2WithContext(ctx, w.ba.httpClient, transferUrl, postParam,
 		map[string]string{"X-MBX-APIKEY": w.ba.accessKey})
 
 	if err != nil {
-		return err
+		return adaptError(err)
 	}
 
 	respmap := make(map[string]interface{})
@@ -69,21 +82,25 @@ func (w *Wallet) Transfer(param TransferParameter) error {
 		return nil
 	}
 
-	return errors.New(string(resp))
+	return adaptErrorBody(resp)
 }
 
 func (w *Wallet) GetWithDrawHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	return w.GetWithDrawHistoryWithContext(context.Background(), currency)
+}
+
+func (w *Wallet) GetWithDrawHistoryWithContext(ctx context.Context, currency *Currency) ([]DepositWithdrawHistory, error) {
 	//historyUrl := w.conf.Endpoint + "/wapi/v3/withdrawHistory.html"
 	historyUrl := w.conf.Endpoint + "/sapi/v1/accountSnapshot"
 	postParam := url.Values{}
 	postParam.Set("type", "SPOT")
 	w.ba.buildParamsSigned(&postParam)
 
-	resp, err := HttpGet5(w.ba.httpClient, historyUrl+"?"+postParam.Encode(),
+	resp, err := HttpGet5WithContext(ctx, w.ba.httpClient, historyUrl+"?"+postParam.Encode(),
 		map[string]string{"X-MBX-APIKEY": w.ba.accessKey})
 
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 	logger.Debugf("response body: %s", string(resp))
 	respmap := make(map[string]interface{})
@@ -96,16 +113,20 @@ func (w *Wallet) GetWithDrawHistory(currency *Currency) ([]DepositWithdrawHistor
 }
 
 func (w *Wallet) GetDepositHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	return w.GetDepositHistoryWithContext(context.Background(), currency)
+}
+
+func (w *Wallet) GetDepositHistoryWithContext(ctx context.Context, currency *Currency) ([]DepositWithdrawHistory, error) {
 	historyUrl := w.conf.Endpoint + "/wapi/v3/depositHistory.html"
 	postParam := url.Values{}
 	postParam.Set("asset", currency.Symbol)
 	w.ba.buildParamsSigned(&postParam)
 
-	resp, err := HttpGet5(w.ba.httpClient, historyUrl+"?"+postParam.Encode(),
+	resp, err := HttpGet5WithContext(ctx, w.ba.httpClient, historyUrl+"?"+postParam.Encode(),
 		map[string]string{"X-MBX-APIKEY": w.ba.accessKey})
 
 	if err != nil {
-		return nil, err
+		return nil, adaptError(err)
 	}
 	logger.Debugf("response body: %s", string(resp))
 	respmap := make(map[string]interface{})
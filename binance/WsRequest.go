@@ -0,0 +1,67 @@
+package binance
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/soulsplit/goex"
+)
+
+// the stream limits of a connection
+const (
+	spotWsMaxStreams    = 1024
+	futuresWsMaxStreams = 200
+)
+
+// wsReqId numbers the requests of all the connections, the result of a request
+// carries its id.
+var wsReqId int64
+
+func nextWsReqId() int {
+	return int(atomic.AddInt64(&wsReqId, 1))
+}
+
+// subscribeStream waits for the result of the SUBSCRIBE, a stream subscribed already
+// is skipped.
+func subscribeStream(conn *goex.WsPool, stream string) error {
+	id := nextWsReqId()
+	return conn.AddSubscription(goex.WsSubscription{
+		Key:   stream,
+		AckId: strconv.Itoa(id),
+		Event: req{Method: "SUBSCRIBE", Params: []string{stream}, Id: id},
+	})
+}
+
+func unsubscribeStream(conn *goex.WsPool, stream string) error {
+	return conn.RemoveSubscription(stream, req{Method: "UNSUBSCRIBE", Params: []string{stream}, Id: nextWsReqId()})
+}
+
+// wsResult acks the request of a {"result":null,"id":1} or {"code":2,"msg":"Invalid request","id":1}
+// message on the pool which sent it, it is false for the stream data.
+func wsResult(data []byte, pools ...*goex.WsPool) bool {
+	var r struct {
+		Id     *int   `json:"id"`
+		Code   *int   `json:"code"`
+		Msg    string `json:"msg"`
+		Stream string `json:"stream"`
+	}
+	if json.Unmarshal(data, &r) != nil || r.Id == nil || r.Stream != "" {
+		return false
+	}
+	var err error
+	if r.Code != nil || r.Msg != "" {
+		code := 0
+		if r.Code != nil {
+			code = *r.Code
+		}
+		err = goex.EX_ERR_INVALID_PARAMETER.OriginErr(fmt.Sprintf("%d %s", code, r.Msg))
+	}
+	for _, pool := range pools {
+		if pool != nil {
+			pool.AckSubscription(strconv.Itoa(*r.Id), err)
+		}
+	}
+	return true
+}
@@ -2,9 +2,9 @@ package bitfinex
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"strconv"
 	"strings"
 
@@ -93,7 +93,7 @@ func (bfx *Exchange) GetLendBook(currency Currency) (error, *LendBook) {
 	body, _ := ioutil.ReadAll(resp.Body)
 
 	if resp.StatusCode != 200 {
-		return errors.New(fmt.Sprintf("HttpCode: %d , errmsg: %s", resp.StatusCode, string(body))), nil
+		return adaptError(HttpStatusError(resp.StatusCode, string(body))), nil
 	}
 	//println(string(body))
 	var lendBook LendBook
@@ -125,7 +125,7 @@ func (bfx *Exchange) Transfer(amount float64, currency Currency, fromWallet, toW
 		return nil
 	}
 
-	return errors.New(resp[0]["message"].(string))
+	return errorMessages.Adapt(http.StatusOK, fmt.Sprint(resp[0]["message"]))
 }
 
 func (bfx *Exchange) newOffer(currency Currency, amount, rate string, period int, direction string) (error, *LendOrder) {
@@ -0,0 +1,44 @@
+package bitfinex
+
+import (
+	"encoding/json"
+
+	. "github.com/soulsplit/goex"
+)
+
+type symbolDetails struct {
+	Pair             string  `json:"pair"`
+	MinimumOrderSize Decimal `json:"minimum_order_size"`
+}
+
+// instrument converts the symbol details, the pairs have no tick size as the
+// prices are rounded to 5 significant digits.
+func (details symbolDetails) instrument() InstrumentInfo {
+	return InstrumentInfo{
+		Id:        details.Pair,
+		Pair:      CanonicalPair(guessSymbol(details.Pair)),
+		Kind:      SPOT,
+		MinAmount: details.MinimumOrderSize,
+		Status:    INSTRUMENT_TRADING,
+	}
+}
+
+// GetInstrumentsInfo lists the symbols and registers them in Symbols, bitfinex only
+// lists the symbols it trades. The names are 6 letters or split by a colon.
+func (exchange *Exchange) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	resp, err := HttpGet5(exchange.httpClient, apiURLV1+"/symbols_details", nil)
+	if err != nil {
+		return nil, adaptError(err)
+	}
+	var details []symbolDetails
+	if err = json.Unmarshal(resp, &details); err != nil {
+		return nil, err
+	}
+
+	instruments := make([]InstrumentInfo, 0, len(details))
+	for _, d := range details {
+		instruments = append(instruments, d.instrument())
+	}
+	Symbols.RegisterInstruments(BITFINEX, instruments)
+	return instruments, nil
+}
@@ -3,6 +3,7 @@ package bitfinex
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -43,7 +44,7 @@ func (exchange *Exchange) GetTicker(currencyPair CurrencyPair) (*Ticker, error)
 	}
 
 	if resp["error"] != nil {
-		return nil, fmt.Errorf("%s", resp["error"])
+		return nil, errorMessages.Adapt(http.StatusOK, fmt.Sprint(resp["error"]))
 	}
 
 	//fmt.Println(resp)
@@ -254,9 +255,13 @@ func (exchange *Exchange) CancelOrder(orderId string, currencyPair CurrencyPair)
 	return respmap["is_cancelled"].(bool), nil
 }
 
-func (exchange *Exchange) toOrder(respmap map[string]interface{}) *Order {
+func (exchange *Exchange) toOrder(respmap map[string]interface{}) (*Order, error) {
+	pair, err := symbolToCurrencyPair(respmap["symbol"].(string))
+	if err != nil {
+		return nil, err
+	}
 	order := new(Order)
-	order.Currency = symbolToCurrencyPair(respmap["symbol"].(string))
+	order.Currency = pair
 	order.OrderID = ToInt(respmap["id"])
 	order.OrderID2 = fmt.Sprint(ToInt(respmap["id"]))
 	order.Amount = ToFloat64(respmap["original_amount"])
@@ -281,7 +286,7 @@ func (exchange *Exchange) toOrder(respmap map[string]interface{}) *Order {
 	if respmap["is_cancelled"].(bool) {
 		order.Status = ORDER_CANCEL
 	}
-	return order
+	return order, nil
 }
 
 func (exchange *Exchange) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Order, error) {
@@ -291,7 +296,7 @@ func (exchange *Exchange) GetOneOrder(orderId string, currencyPair CurrencyPair)
 	if err != nil {
 		return nil, err
 	}
-	return exchange.toOrder(respmap), nil
+	return exchange.toOrder(respmap)
 }
 
 func (exchange *Exchange) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error) {
@@ -303,8 +308,11 @@ func (exchange *Exchange) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order,
 
 	var orders []Order
 	for _, v := range ordersmap {
-		ordermap := v.(map[string]interface{})
-		orders = append(orders, *exchange.toOrder(ordermap))
+		order, err := exchange.toOrder(v.(map[string]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
 	}
 	return orders, nil
 }
@@ -333,13 +341,49 @@ func (exchange *Exchange) doAuthenticatedRequest(method, path string, payload ma
 		"X-BFX-SIGNATURE": sign})
 
 	if err != nil {
-		return err
+		return adaptError(err)
 	}
 	//print(string(resp))
 	err = json.Unmarshal(resp, ret)
 	return err
 }
 
+// bitfinex has no error codes, the kind is told by the message
+var errorMessages = ErrorMessages{
+	"not enough":                   EX_ERR_INSUFFICIENT_BALANCE,
+	"no such order":                EX_ERR_NOT_FIND_ORDER,
+	"order could not be cancelled": EX_ERR_CANCEL_ORDER_FAIL,
+	"nonce is too small":           EX_ERR_TIMESTAMP,
+	"x-bfx-signature":              EX_ERR_SIGN,
+	"x-bfx-apikey":                 EX_ERR_AUTH,
+	"ratelimit":                    EX_ERR_API_LIMIT,
+	"unknown symbol":               EX_ERR_INVALID_CURRENCY_PAIR,
+	"invalid order: minimum size":  EX_ERR_MIN_AMOUNT,
+	"maintenance":                  EX_ERR_MAINTENANCE,
+}
+
+// adaptError maps the {"message":"..."} or {"error":"..."} body of a failed request.
+func adaptError(err error) error {
+	var apiErr ApiError
+	if !errors.As(err, &apiErr) || apiErr.HttpStatus == 0 {
+		return err
+	}
+
+	var resp struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	json.Unmarshal([]byte(apiErr.OriginErrMsg), &resp)
+	msg := resp.Message
+	if msg == "" {
+		msg = resp.Error
+	}
+	if msg == "" {
+		msg = apiErr.OriginErrMsg
+	}
+	return errorMessages.Adapt(apiErr.HttpStatus, msg)
+}
+
 func (exchange *Exchange) currencyPairToSymbol(currencyPair CurrencyPair) string {
 	return strings.ToUpper(currencyPair.ToSymbol(""))
 }
@@ -379,7 +423,19 @@ func (exchange *Exchange) adaptCurrencyPair(pair CurrencyPair) CurrencyPair {
 	return NewCurrencyPair(currencyA, currencyB)
 }
 
-func symbolToCurrencyPair(symbol string) CurrencyPair {
+// symbolToCurrencyPair resolves a symbol such as btcusd or TESTBTC:TESTUSD with Symbols.
+func symbolToCurrencyPair(symbol string) (CurrencyPair, error) {
+	return Symbols.Resolve(BITFINEX, symbol, guessSymbol)
+}
+
+// guessSymbol splits the symbol at the colon of the long names, else after 3 letters.
+func guessSymbol(symbol string) CurrencyPair {
+	if i := strings.Index(symbol, ":"); i > 0 {
+		return NewCurrencyPair(NewCurrency(strings.ToUpper(symbol[:i]), ""), NewCurrency(strings.ToUpper(symbol[i+1:]), ""))
+	}
+	if len(symbol) < 6 {
+		return UNKNOWN_PAIR
+	}
 	currencyA := strings.ToUpper(symbol[0:3])
 	currencyB := strings.ToUpper(symbol[3:])
 	return NewCurrencyPair(NewCurrency(currencyA, ""), NewCurrency(currencyB, ""))
@@ -416,8 +472,12 @@ func convertPairToBitfinexSymbol(prefix string, pair CurrencyPair) string {
 	return prefix + symbol
 }
 
-func convertKeyToPair(key string) CurrencyPair {
-	split := strings.Split(key, ":")
+// convertKeyToPair resolves the pair of a candles key such as trade:1m:tBTCUSD.
+func convertKeyToPair(key string) (CurrencyPair, error) {
+	split := strings.SplitN(key, ":", 3)
+	if len(split) < 3 || len(split[2]) < 2 {
+		return UNKNOWN_PAIR, EX_ERR_SYMBOL_ERR.OriginErr("no symbol in key " + key)
+	}
 	return symbolToCurrencyPair(split[2][1:])
 }
 
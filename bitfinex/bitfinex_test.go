@@ -1,10 +1,12 @@
 package bitfinex
 
 import (
+	"errors"
 	"net/http"
 	"testing"
 
 	"github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
 )
 
 var bfx = New(http.DefaultClient, "", "")
@@ -26,3 +28,27 @@ func TestBitfinex_GetKline(t *testing.T) {
 		t.Log(k)
 	}
 }
+
+func TestBitfinex_AdaptError(t *testing.T) {
+	err := adaptError(goex.HttpStatusError(http.StatusBadRequest, `{"message":"Invalid order: not enough exchange balance for 1.0 BTCUSD at 9000.0"}`))
+	assert.True(t, errors.Is(err, goex.EX_ERR_INSUFFICIENT_BALANCE))
+	assert.Equal(t, "Invalid order: not enough exchange balance for 1.0 BTCUSD at 9000.0", err.Error())
+	assert.True(t, errors.Is(adaptError(goex.HttpStatusError(http.StatusTooManyRequests, `{"error":"ERR_RATE_LIMIT"}`)), goex.EX_ERR_API_LIMIT))
+}
+
+func TestBitfinex_symbolDetails(t *testing.T) {
+	t.Cleanup(func() { goex.Symbols.Reset(goex.BITFINEX) })
+	var instruments []goex.InstrumentInfo
+	for _, d := range []symbolDetails{{Pair: "btcusd"}, {Pair: "testbtc:testusd"}, {Pair: "dogeusd"}} {
+		instruments = append(instruments, d.instrument())
+	}
+	assert.Equal(t, "BTC_USD", instruments[0].Pair.String())
+	assert.Equal(t, "TESTBTC_TESTUSD", instruments[1].Pair.String())
+	goex.Symbols.RegisterInstruments(goex.BITFINEX, instruments)
+
+	pair, err := symbolToCurrencyPair("testbtc:testusd")
+	assert.NoError(t, err)
+	assert.Equal(t, "TESTBTC_TESTUSD", pair.String())
+	_, err = symbolToCurrencyPair("ethusd")
+	assert.True(t, errors.Is(err, goex.EX_ERR_SYMBOL_ERR), "not listed")
+}
@@ -126,7 +126,10 @@ func (bws *BitfinexWs) handle(msg []byte) error {
 		switch event.Channel {
 		case ticker:
 			if raw, ok := resp[1].([]interface{}); ok {
-				pair := symbolToCurrencyPair(event.Pair)
+				pair, err := symbolToCurrencyPair(event.Pair)
+				if err != nil {
+					return err
+				}
 				t := bws.tickerFromRaw(pair, raw)
 				bws.tickerCallback(t)
 				return nil
@@ -137,7 +140,10 @@ func (bws *BitfinexWs) handle(msg []byte) error {
 			}
 
 			if raw, ok := resp[2].([]interface{}); ok {
-				pair := symbolToCurrencyPair(event.Pair)
+				pair, err := symbolToCurrencyPair(event.Pair)
+				if err != nil {
+					return err
+				}
 				trade := bws.tradeFromRaw(pair, raw)
 				bws.tradeCallback(trade)
 				return nil
@@ -148,7 +154,11 @@ func (bws *BitfinexWs) handle(msg []byte) error {
 					return nil
 				}
 
-				kline := klineFromRaw(convertKeyToPair(event.Key), raw)
+				pair, err := convertKeyToPair(event.Key)
+				if err != nil {
+					return err
+				}
+				kline := klineFromRaw(pair, raw)
 				bws.candleCallback(kline)
 				return nil
 			}
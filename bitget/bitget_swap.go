@@ -79,7 +79,7 @@ func (bs *BitgetSwap) GetFutureTicker(currency CurrencyPair, contractType string
 
 	status, isOk := tickerMap["status"]
 	if !isOk || status != "ok" {
-		return nil, errors.New(tickerMap["err_msg"].(string))
+		return nil, adaptError(http.StatusOK, tickerMap, fmt.Sprintf("%+v", tickerMap))
 	}
 
 	data := tickerMap["data"].(interface{})
@@ -141,6 +141,12 @@ func (bs *BitgetSwap) doAuthRequest(method, uri string, param map[string]interfa
 	headers["ACCESS-SIGN"] = sign
 	resp, err := NewHttpRequest(bs.httpClient, method, bs.baseUrl+uri, postBody, headers)
 
+	var apiErr ApiError
+	if errors.As(err, &apiErr) && apiErr.HttpStatus != 0 {
+		var respmap map[string]interface{}
+		json.Unmarshal([]byte(apiErr.OriginErrMsg), &respmap)
+		return resp, adaptError(apiErr.HttpStatus, respmap, apiErr.OriginErrMsg)
+	}
 	return resp, err
 }
 
@@ -277,7 +283,7 @@ func (bs *BitgetSwap) PlaceFutureOrder2(currencyPair CurrencyPair, contractType,
 
 	orderId := ToInt(respmap["order_id"])
 	if orderId <= 0 {
-		return fOrder, errors.New(string(resp))
+		return fOrder, adaptError(http.StatusOK, respmap, string(resp))
 	}
 	fOrder.OrderID2 = respmap["order_id"].(string)
 
@@ -316,7 +322,7 @@ func (bs *BitgetSwap) FutureCancelOrder(currencyPair CurrencyPair, contractType,
 
 	result := respmap["result"].(bool)
 	if !result {
-		return false, errors.New(respmap["err_msg"].(string))
+		return false, adaptError(http.StatusOK, respmap, string(resp))
 	}
 	return true, nil
 }
@@ -545,6 +551,51 @@ func (bs *BitgetSwap) adaptSymbol(pair CurrencyPair) string {
 	return symbol
 }
 
+var errorCodes = ErrorCodes{
+	"40001": EX_ERR_AUTH,
+	"40002": EX_ERR_NOT_FIND_APIKEY,
+	"40003": EX_ERR_SIGN,
+	"40004": EX_ERR_TIMESTAMP,
+	"40006": EX_ERR_AUTH,
+	"40008": EX_ERR_TIMESTAMP,
+	"40009": EX_ERR_SIGN,
+	"40011": EX_ERR_AUTH,
+	"40012": EX_ERR_AUTH,
+	"40014": EX_ERR_AUTH,
+	"40017": EX_ERR_INVALID_PARAMETER,
+	"40019": EX_ERR_INVALID_PARAMETER,
+	"40020": EX_ERR_INVALID_PARAMETER,
+	"40034": EX_ERR_INVALID_PARAMETER,
+	"40725": EX_ERR_SERVER,
+	"40754": EX_ERR_INSUFFICIENT_BALANCE,
+	"40757": EX_ERR_INSUFFICIENT_BALANCE,
+	"40762": EX_ERR_INSUFFICIENT_BALANCE,
+	"40768": EX_ERR_NOT_FIND_ORDER,
+	"40786": EX_ERR_DUPLICATE_CLIENT_OID,
+	"40808": EX_ERR_INVALID_PARAMETER,
+	"43001": EX_ERR_NOT_FIND_ORDER,
+	"45110": EX_ERR_MIN_AMOUNT,
+	"429":   EX_ERR_API_LIMIT,
+}
+
+// adaptError maps a {"code":"40768","msg":"..."} body or the err_code and err_msg of
+// a response, raw is the message if the body has none.
+func adaptError(httpStatus int, respmap map[string]interface{}, raw string) ApiError {
+	code, msg := respmap["code"], respmap["msg"]
+	if respmap["err_code"] != nil {
+		code, msg = respmap["err_code"], respmap["err_msg"]
+	}
+	nativeCode := ""
+	if code != nil {
+		nativeCode = fmt.Sprint(code)
+	}
+	errMsg, _ := msg.(string)
+	if errMsg == "" {
+		errMsg = raw
+	}
+	return errorCodes.Adapt(httpStatus, nativeCode, errMsg)
+}
+
 type MarginLeverage struct {
 	LongLeverage        float64 `json:"long_leverage,string"`
 	MarginMode          string  `json:"margin_mode"`
@@ -605,6 +656,7 @@ type Instrument struct {
 	Symbol              string        `json:"symbol"`
 	TickSize            int           `json:"tick_size"`
 	UnderlyingIndex     string        `json:"underlying_index"`
+	Status              string        `json:"status"`
 }
 
 func (bs *BitgetSwap) GetContractInfo(pair CurrencyPair) (*Instrument, error) {
@@ -624,7 +676,7 @@ func (bs *BitgetSwap) GetContractInfo(pair CurrencyPair) (*Instrument, error) {
 				PriceEndStep:        ToInt(contract["priceEndStep"]),
 				QuoteCurrency:       contract["quote_currency"].(string),
 				SizeIncrement:       ToInt(contract["size_increment"]),
-				Symbol:              contract["contract_val"].(string),
+				Symbol:              contract["symbol"].(string),
 				TickSize:            ToInt(contract["tick_size"]),
 				UnderlyingIndex:     contract["underlying_index"].(string),
 			}, nil
@@ -642,6 +694,7 @@ func (bs *BitgetSwap) GetInstruments() ([]Instrument, error) {
 	ins := make([]Instrument, 0)
 	for _, v := range resp {
 		contract := v.(map[string]interface{})
+		status, _ := contract["status"].(string)
 		ins = append(ins, Instrument{
 			Coin:                contract["coin"].(string),
 			ContractVal:         contract["contract_val"].(string),
@@ -650,14 +703,66 @@ func (bs *BitgetSwap) GetInstruments() ([]Instrument, error) {
 			PriceEndStep:        ToInt(contract["priceEndStep"]),
 			QuoteCurrency:       contract["quote_currency"].(string),
 			SizeIncrement:       ToInt(contract["size_increment"]),
-			Symbol:              contract["contract_val"].(string),
+			Symbol:              contract["symbol"].(string),
 			TickSize:            ToInt(contract["tick_size"]),
 			UnderlyingIndex:     contract["underlying_index"].(string),
+			Listing:             contract["listing"],
+			Status:              status,
 		})
 	}
 	return ins, nil
 }
 
+// adaptInstrumentStatus maps the status of a contract, normal, maintain or off. A
+// contract without status trades once its listing time (ms) is passed.
+func adaptInstrumentStatus(c Instrument) InstrumentStatus {
+	switch c.Status {
+	case "maintain":
+		return INSTRUMENT_SUSPENDED
+	case "off":
+		return INSTRUMENT_DELISTED
+	}
+	if listing := ToInt64(c.Listing); listing > time.Now().UnixNano()/int64(time.Millisecond) {
+		return INSTRUMENT_PENDING
+	}
+	return INSTRUMENT_TRADING
+}
+
+// GetInstrumentsInfo converts the contracts: tick_size and size_increment are numbers of
+// decimals, the price moves by priceEndStep in the last decimal.
+func (bs *BitgetSwap) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	contracts, err := bs.GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+
+	instruments := make([]InstrumentInfo, 0, len(contracts))
+	for _, c := range contracts {
+		step := c.PriceEndStep
+		if step <= 0 {
+			step = 1
+		}
+		lot := NewDecimal(1, int32(c.SizeIncrement))
+		ins := InstrumentInfo{
+			Id:                  c.Symbol,
+			Pair:                NewCurrencyPair(NewCurrency(c.UnderlyingIndex, ""), NewCurrency(c.QuoteCurrency, "")),
+			Kind:                SWAP,
+			ContractType:        SWAP_CONTRACT,
+			TickSize:            NewDecimal(int64(step), int32(c.TickSize)),
+			LotSize:             lot,
+			MinAmount:           lot,
+			ContractVal:         ToDecimal(c.ContractVal),
+			ContractValCurrency: NewCurrency(c.Coin, ""),
+			Status:              adaptInstrumentStatus(c),
+		}
+		if c.ForwardContractFlag {
+			ins.Kind, ins.ContractType = SWAP_USDT, SWAP_USDT_CONTRACT
+		}
+		instruments = append(instruments, ins)
+	}
+	return instruments, nil
+}
+
 // side
 //1:多仓
 //2:空仓
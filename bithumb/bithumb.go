@@ -3,7 +3,6 @@ package bithumb
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -26,6 +25,29 @@ var (
 	baseUrl = "https://api.bithumb.com"
 )
 
+var errorCodes = ErrorCodes{
+	"5100": EX_ERR_INVALID_PARAMETER,
+	"5200": EX_ERR_AUTH,
+	"5300": EX_ERR_AUTH,
+	"5302": EX_ERR_INVALID_PARAMETER,
+	"5400": EX_ERR_SERVER,
+	"5500": EX_ERR_INVALID_PARAMETER,
+	"5900": EX_ERR_SERVER,
+}
+
+// adaptError maps the status of a response, 5600 is a custom notice so its message is matched.
+func adaptError(respmap map[string]interface{}) ApiError {
+	status, _ := respmap["status"].(string)
+	msg, _ := respmap["message"].(string)
+	if status == "5600" {
+		return CommonErrorMessages.Adapt(http.StatusOK, msg).WithNativeCode(status)
+	}
+	if msg == "" {
+		msg = status
+	}
+	return errorCodes.Adapt(http.StatusOK, status, msg)
+}
+
 func New(client *http.Client, accesskey, secretkey string) *Exchange {
 	return &Exchange{client: client, accesskey: accesskey, secretkey: secretkey}
 }
@@ -40,7 +62,7 @@ func (exchange *Exchange) placeOrder(side, amount, price string, pair CurrencyPa
 	}
 	if retmap["status"].(string) != "0000" {
 		log.Println(retmap)
-		return nil, errors.New(retmap["status"].(string))
+		return nil, adaptError(retmap)
 	}
 
 	var tradeSide TradeSide
@@ -92,7 +114,7 @@ func (exchange *Exchange) CancelOrder2(side, orderId string, currency CurrencyPa
 	if retmap["status"].(string) == "0000" {
 		return true, nil
 	}
-	return false, errors.New(retmap["status"].(string))
+	return false, adaptError(retmap)
 }
 
 func (exchange *Exchange) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
@@ -114,7 +136,7 @@ func (exchange *Exchange) GetOneOrder2(side, orderId string, currency CurrencyPa
 			return nil, EX_ERR_NOT_FIND_ORDER
 		}
 		log.Println(retmap)
-		return nil, errors.New(retmap["status"].(string))
+		return nil, adaptError(retmap)
 	}
 
 	order := new(Order)
@@ -158,7 +180,7 @@ func (exchange *Exchange) GetUnfinishOrders(currency CurrencyPair) ([]Order, err
 		if "거래 진행중인 내역이 존재하지 않습니다." == message {
 			return []Order{}, nil
 		}
-		return nil, errors.New(fmt.Sprintf("[%s]%s", retmap["status"].(string), message))
+		return nil, adaptError(retmap)
 	}
 
 	var orders []Order
@@ -266,13 +288,8 @@ func (exchange *Exchange) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	if err != nil {
 		return nil, err
 	}
-	s, isok := respmap["status"].(string)
-	if s != "0000" || isok != true {
-		msg := "ticker error"
-		if isok {
-			msg = s
-		}
-		return nil, errors.New(msg)
+	if s, _ := respmap["status"].(string); s != "0000" {
+		return nil, adaptError(respmap)
 	}
 
 	datamap := respmap["data"].(map[string]interface{})
@@ -294,7 +311,7 @@ func (exchange *Exchange) GetDepth(size int, currency CurrencyPair) (*Depth, err
 	}
 
 	if resp["status"].(string) != "0000" {
-		return nil, errors.New(resp["status"].(string))
+		return nil, adaptError(resp)
 	}
 
 	datamap := resp["data"].(map[string]interface{})
@@ -1,13 +1,21 @@
 package bitmex
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
 	. "github.com/soulsplit/goex"
 )
 
-func AdaptCurrencyPairToSymbol(pair CurrencyPair, contract string) string {
+// AdaptCurrencyPairToSymbol returns the symbol of pair in Symbols, falling back to
+// XBTUSD style names until the instruments are registered.
+func AdaptCurrencyPairToSymbol(pair CurrencyPair, contract string) (string, error) {
+	return Symbols.ResolveSymbol(BITMEX, pair, contract, guessSymbol)
+}
+
+func guessSymbol(pair CurrencyPair, contract string) string {
 	if contract == "" || contract == SWAP_CONTRACT {
 		if pair.CurrencyA.Eq(BTC) {
 			pair = NewCurrencyPair(XBT, USD)
@@ -25,34 +33,79 @@ func AdaptCurrencyPairToSymbol(pair CurrencyPair, contract string) string {
 	return fmt.Sprintf("%s%s", coin, strings.ToUpper(contract))
 }
 
-func AdaptWsSymbol(symbol string) (pair CurrencyPair, contract string) {
+// AdaptWsSymbol resolves a symbol with Symbols once the instruments are registered,
+// the contract of a future is its month code such as Z21.
+func AdaptWsSymbol(symbol string) (pair CurrencyPair, contract string, err error) {
+	if Symbols.Loaded(BITMEX) {
+		return Symbols.Pair(BITMEX, symbol)
+	}
+
 	symbol = strings.ToUpper(symbol)
+	if len(symbol) < 6 {
+		return UNKNOWN_PAIR, "", EX_ERR_SYMBOL_ERR.OriginErr("bitmex unknown symbol " + symbol)
+	}
 
-	if symbol == "XBTCUSD" {
-		return BTC_USD, SWAP_CONTRACT
+	if symbol == "XBTUSD" {
+		return BTC_USD, SWAP_CONTRACT, nil
 	}
 
 	if symbol == "BCHUSD" {
-		return BCH_USD, SWAP_CONTRACT
+		return BCH_USD, SWAP_CONTRACT, nil
 	}
 
 	if symbol == "ETHUSD" {
-		return ETH_USD, SWAP_CONTRACT
+		return ETH_USD, SWAP_CONTRACT, nil
 	}
 
 	if symbol == "LTCUSD" {
-		return LTC_USD, SWAP_CONTRACT
+		return LTC_USD, SWAP_CONTRACT, nil
 	}
 
 	if symbol == "LINKUSDT" {
-		return NewCurrencyPair2("LINK_USDT"), SWAP_CONTRACT
+		return NewCurrencyPair2("LINK_USDT"), SWAP_CONTRACT, nil
 	}
 
 	pair = NewCurrencyPair(NewCurrency(symbol[0:3], ""), USDT)
 	contract = symbol[3:]
 	if pair.CurrencyA.Eq(XBT) {
-		return NewCurrencyPair(BTC, USDT), contract
+		return NewCurrencyPair(BTC, USDT), contract, nil
+	}
+
+	return pair, contract, nil
+}
+
+// bitmex has no error codes, the kind is told by the message
+var errorMessages = ErrorMessages{
+	"insufficient available balance": EX_ERR_INSUFFICIENT_BALANCE,
+	"duplicate clordid":              EX_ERR_DUPLICATE_CLIENT_OID,
+	"not found":                      EX_ERR_NOT_FIND_ORDER,
+	"invalid orderid":                EX_ERR_NOT_FIND_ORDER,
+	"invalid status":                 EX_ERR_ORDER_STATE,
+	"signature not valid":            EX_ERR_SIGN,
+	"invalid api key":                EX_ERR_AUTH,
+	"this request has expired":       EX_ERR_TIMESTAMP,
+	"overloaded":                     EX_ERR_SERVER,
+	"rate limit exceeded":            EX_ERR_API_LIMIT,
+	"invalid symbol":                 EX_ERR_INVALID_CURRENCY_PAIR,
+}
+
+// adaptError maps the {"error":{"message":"...","name":"HTTPError"}} body of a failed
+// request to an ApiError, the error name is kept as NativeCode.
+func adaptError(err error) error {
+	var apiErr ApiError
+	if !errors.As(err, &apiErr) || apiErr.HttpStatus == 0 {
+		return err
+	}
+
+	var resp struct {
+		Error struct {
+			Message string `json:"message"`
+			Name    string `json:"name"`
+		} `json:"error"`
+	}
+	if json.Unmarshal([]byte(apiErr.OriginErrMsg), &resp) != nil || resp.Error.Message == "" {
+		return err
 	}
 
-	return pair, contract
+	return errorMessages.Adapt(apiErr.HttpStatus, resp.Error.Message).WithNativeCode(resp.Error.Name)
 }
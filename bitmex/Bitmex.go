@@ -59,7 +59,7 @@ func (bm *bitmex) doAuthRequest(m, uri, param string, r interface{}) error {
 		"api-signature": sign})
 	Log.Debug("response:", string(resp))
 	if err != nil {
-		return err
+		return adaptError(err)
 	} else {
 		//println(string(resp))
 		return json.Unmarshal(resp, &r)
@@ -1,8 +1,10 @@
 package bitmex
 
 import (
+	"errors"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
@@ -88,3 +90,68 @@ func TestBitmex_GetFutureOrder(t *testing.T) {
 func TestBitmex_FutureCancelOrder(t *testing.T) {
 	t.Log(mex.FutureCancelOrder(goex.BTC_USD, goex.SWAP_CONTRACT, "goexfd6fd7694877448e8ae81a9cd7ecd89a"))
 }
+
+func TestBitmex_AdaptError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"Not Found","name":"HTTPError"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"Account has insufficient Available Balance, 100 XBt required","name":"ValidationError"}}`))
+	}))
+	defer srv.Close()
+	bm := New(&goex.APIConfig{Endpoint: srv.URL, HttpClient: http.DefaultClient})
+
+	_, err := bm.FutureCancelOrder(goex.BTC_USD, goex.SWAP_CONTRACT, "1")
+	assert.True(t, errors.Is(err, goex.EX_ERR_NOT_FIND_ORDER))
+
+	_, err = bm.PlaceFutureOrder(goex.BTC_USD, goex.SWAP_CONTRACT, "30000", "1", goex.OPEN_BUY, 0, 10)
+	assert.True(t, errors.Is(err, goex.EX_ERR_INSUFFICIENT_BALANCE))
+	var apiErr goex.ApiError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "ValidationError", apiErr.NativeCode)
+	assert.Equal(t, http.StatusBadRequest, apiErr.HttpStatus)
+}
+
+func TestAdaptWsSymbol(t *testing.T) {
+	pair, contract, err := AdaptWsSymbol("XBTUSD")
+	assert.NoError(t, err)
+	assert.Equal(t, goex.BTC_USD, pair)
+	assert.Equal(t, goex.SWAP_CONTRACT, contract)
+
+	_, _, err = AdaptWsSymbol("XBT")
+	assert.True(t, errors.Is(err, goex.EX_ERR_SYMBOL_ERR))
+
+	symbol, err := AdaptCurrencyPairToSymbol(goex.BTC_USDT, goex.SWAP_CONTRACT)
+	assert.NoError(t, err)
+	assert.Equal(t, "XBTUSD", symbol)
+}
+
+func TestBitmex_GetInstrumentsInfo(t *testing.T) {
+	t.Cleanup(func() { goex.Symbols.Reset(goex.BITMEX) })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"symbol":"XBTUSD","rootSymbol":"XBT","state":"Open","typ":"FFWCSX","underlying":"XBT","quoteCurrency":"USD","tickSize":0.5,"lotSize":100,"isInverse":true},
+			{"symbol":"ETHZ21","rootSymbol":"ETH","state":"Open","typ":"FFCCSX","expiry":"2021-12-31T12:00:00.000Z","underlying":"ETH","quoteCurrency":"XBT","tickSize":0.00001,"lotSize":1},
+			{"symbol":".BXBT","rootSymbol":"XBT","state":"Unlisted","typ":"MRCXXX","underlying":"XBT","quoteCurrency":"USD"}]`))
+	}))
+	defer srv.Close()
+
+	var _ goex.InstrumentsAPI = (*bitmex)(nil)
+	instruments, err := New(&goex.APIConfig{Endpoint: srv.URL, HttpClient: http.DefaultClient}).GetInstrumentsInfo()
+	assert.NoError(t, err)
+	assert.Len(t, instruments, 2, "the index is skipped")
+	assert.Equal(t, "BTC_USD", instruments[0].Pair.String())
+	assert.Equal(t, "0.5", instruments[0].TickSize.String())
+	assert.Equal(t, goex.USD, instruments[0].ContractValCurrency)
+	assert.Equal(t, "Z21", instruments[1].ContractType)
+	assert.Equal(t, 2021, instruments[1].Expiry.Year())
+
+	pair, contract, err := AdaptWsSymbol("ETHZ21")
+	assert.NoError(t, err)
+	assert.Equal(t, "ETH_BTC", pair.String())
+	assert.Equal(t, "Z21", contract)
+	_, _, err = AdaptWsSymbol("LTCUSD")
+	assert.True(t, errors.Is(err, goex.EX_ERR_SYMBOL_ERR), "not listed")
+}
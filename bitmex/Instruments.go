@@ -0,0 +1,84 @@
+package bitmex
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+type instrumentInfo struct {
+	Symbol        string  `json:"symbol"`
+	RootSymbol    string  `json:"rootSymbol"`
+	State         string  `json:"state"`
+	Typ           string  `json:"typ"`
+	Expiry        string  `json:"expiry"`
+	Underlying    string  `json:"underlying"`
+	QuoteCurrency string  `json:"quoteCurrency"`
+	TickSize      Decimal `json:"tickSize"`
+	LotSize       Decimal `json:"lotSize"`
+	IsInverse     bool    `json:"isInverse"`
+}
+
+func adaptInstrumentState(state string) InstrumentStatus {
+	switch state {
+	case "Open":
+		return INSTRUMENT_TRADING
+	case "Unlisted":
+		return INSTRUMENT_PENDING
+	case "Settled", "Closed", "Delisted":
+		return INSTRUMENT_DELISTED
+	}
+	return INSTRUMENT_SUSPENDED
+}
+
+// instrument converts the perpetual (typ FFWCSX) and futures (FFCCSX) contracts, the
+// contract type of a future is its month code such as Z21. Indices, spot and the
+// other products are skipped.
+func (info *instrumentInfo) instrument() (InstrumentInfo, bool) {
+	ins := InstrumentInfo{
+		Id:        info.Symbol,
+		Pair:      CanonicalPair(NewCurrencyPair(NewCurrency(info.Underlying, ""), NewCurrency(info.QuoteCurrency, ""))),
+		TickSize:  info.TickSize,
+		LotSize:   info.LotSize,
+		MinAmount: info.LotSize,
+		Status:    adaptInstrumentState(info.State),
+	}
+	switch {
+	case strings.HasPrefix(info.Typ, "FFWCS"):
+		ins.Kind, ins.ContractType = SWAP, SWAP_CONTRACT
+	case strings.HasPrefix(info.Typ, "FFCCS"):
+		ins.Kind, ins.ContractType = FUTURE, strings.TrimPrefix(info.Symbol, info.RootSymbol)
+		if expiry, err := time.Parse(time.RFC3339, info.Expiry); err == nil {
+			ins.Expiry = expiry
+		}
+	default:
+		return ins, false
+	}
+	if info.IsInverse {
+		ins.ContractVal, ins.ContractValCurrency = NewDecimal(1, 0), ins.Pair.CurrencyB
+	}
+	return ins, true
+}
+
+// GetInstrumentsInfo lists the active contracts and registers them in Symbols.
+func (bm *bitmex) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	resp, err := HttpGet5(bm.HttpClient, bm.Endpoint+"/api/v1/instrument/active", nil)
+	if err != nil {
+		return nil, adaptError(err)
+	}
+	var infos []instrumentInfo
+	if err = json.Unmarshal(resp, &infos); err != nil {
+		return nil, err
+	}
+
+	instruments := make([]InstrumentInfo, 0, len(infos))
+	for i := range infos {
+		if ins, ok := infos[i].instrument(); ok {
+			instruments = append(instruments, ins)
+		}
+	}
+	Symbols.RegisterInstruments(BITMEX, instruments)
+	return instruments, nil
+}
@@ -2,8 +2,10 @@ package bitmex
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
 	. "github.com/soulsplit/goex"
@@ -42,13 +44,38 @@ type depthData struct {
 	Timestamp string          `json:"timestamp"`
 }
 
+type tradeBinData struct {
+	Symbol       string  `json:"symbol"`
+	Timestamp    string  `json:"timestamp"`
+	Open         float64 `json:"open"`
+	High         float64 `json:"high"`
+	Low          float64 `json:"low"`
+	Close        float64 `json:"close"`
+	Volume       float64 `json:"volume"`
+	HomeNotional float64 `json:"homeNotional"`
+}
+
+// wsBinSizes are the bins of the tradeBin tables.
+var wsBinSizes = map[KlinePeriod]string{
+	KLINE_PERIOD_1MIN:  "1m",
+	KLINE_PERIOD_5MIN:  "5m",
+	KLINE_PERIOD_60MIN: "1h",
+	KLINE_PERIOD_1H:    "1h",
+	KLINE_PERIOD_1DAY:  "1d",
+}
+
+var errPrivateUnsupported = errors.New("the private streams are not supported by the bitmex ws")
+
 type SwapWs struct {
 	c *WsConn
 
 	depthCall  func(depth *Depth)
 	tickerCall func(ticker *FutureTicker)
+	klineCall  func(kline *FutureKline, period KlinePeriod, closed bool)
 
 	tickerCacheMap map[string]FutureTicker
+	klineLock      sync.Mutex
+	klinePeriods   map[string]KlinePeriod //by table:symbol
 }
 
 func NewSwapWs() *SwapWs {
@@ -69,34 +96,132 @@ func (s *SwapWs) TickerCallback(f func(ticker *FutureTicker)) {
 	s.tickerCall = f
 }
 
+func (s *SwapWs) KlineCallback(f func(kline *FutureKline, period KlinePeriod, closed bool)) {
+	s.klineCall = f
+}
+
 func (s *SwapWs) TradeCallback(f func(trade *Trade, contract string)) {
 	panic("implement me")
 }
 
 func (s *SwapWs) SubscribeDepth(pair CurrencyPair, contractType string) error {
 	//{"op": "subscribe", "args": ["orderBook10:XBTUSD"]}
-	op := SubscribeOp{
-		Op: "subscribe",
-		Args: []string{
-			fmt.Sprintf("orderBook10:%s", AdaptCurrencyPairToSymbol(pair, contractType)),
-		},
+	symbol, err := AdaptCurrencyPairToSymbol(pair, contractType)
+	if err != nil {
+		return err
 	}
-	return s.c.Subscribe(op)
+	return s.subscribe(fmt.Sprintf("orderBook10:%s", symbol))
 }
 
 func (s *SwapWs) SubscribeTicker(pair CurrencyPair, contractType string) error {
-	return s.c.Subscribe(SubscribeOp{
-		Op: "subscribe",
-		Args: []string{
-			"instrument:" + AdaptCurrencyPairToSymbol(pair, contractType),
-		},
-	})
+	symbol, err := AdaptCurrencyPairToSymbol(pair, contractType)
+	if err != nil {
+		return err
+	}
+	return s.subscribe("instrument:" + symbol)
 }
 
 func (s *SwapWs) SubscribeTrade(pair CurrencyPair, contractType string) error {
 	panic("implement me")
 }
 
+// SubscribeKline subscribes the tradeBin table, a bin is only pushed once closed.
+func (s *SwapWs) SubscribeKline(pair CurrencyPair, contractType string, period KlinePeriod) error {
+	binSize, ok := wsBinSizes[period]
+	if !ok {
+		return fmt.Errorf("unsupported kline period %d in bitmex", period)
+	}
+	symbol, err := AdaptCurrencyPairToSymbol(pair, contractType)
+	if err != nil {
+		return err
+	}
+	sub := "tradeBin" + binSize + ":" + symbol
+
+	s.klineLock.Lock()
+	if s.klinePeriods == nil {
+		s.klinePeriods = make(map[string]KlinePeriod, 4)
+	}
+	s.klinePeriods[sub] = period
+	s.klineLock.Unlock()
+
+	return s.subscribe(sub)
+}
+
+func (s *SwapWs) UnsubscribeDepth(pair CurrencyPair, contractType string) error {
+	symbol, err := AdaptCurrencyPairToSymbol(pair, contractType)
+	if err != nil {
+		return err
+	}
+	return s.unsubscribe("orderBook10:" + symbol)
+}
+
+func (s *SwapWs) UnsubscribeTicker(pair CurrencyPair, contractType string) error {
+	symbol, err := AdaptCurrencyPairToSymbol(pair, contractType)
+	if err != nil {
+		return err
+	}
+	return s.unsubscribe("instrument:" + symbol)
+}
+
+func (s *SwapWs) UnsubscribeTrade(pair CurrencyPair, contractType string) error {
+	symbol, err := AdaptCurrencyPairToSymbol(pair, contractType)
+	if err != nil {
+		return err
+	}
+	return s.unsubscribe("trade:" + symbol)
+}
+
+func (s *SwapWs) UnsubscribeKline(pair CurrencyPair, contractType string, period KlinePeriod) error {
+	binSize, ok := wsBinSizes[period]
+	if !ok {
+		return fmt.Errorf("unsupported kline period %d in bitmex", period)
+	}
+	symbol, err := AdaptCurrencyPairToSymbol(pair, contractType)
+	if err != nil {
+		return err
+	}
+	sub := "tradeBin" + binSize + ":" + symbol
+	if err = s.unsubscribe(sub); err != nil {
+		return err
+	}
+
+	s.klineLock.Lock()
+	delete(s.klinePeriods, sub)
+	s.klineLock.Unlock()
+	return nil
+}
+
+// subscribe registers the topic by its name, e.g. instrument:XBTUSD.
+func (s *SwapWs) subscribe(topic string) error {
+	return s.c.AddSubscription(WsSubscription{Key: topic, Event: SubscribeOp{Op: "subscribe", Args: []string{topic}}})
+}
+
+func (s *SwapWs) unsubscribe(topic string) error {
+	return s.c.RemoveSubscription(topic, SubscribeOp{Op: "unsubscribe", Args: []string{topic}})
+}
+
+func (s *SwapWs) OrderCallback(call func(order *FutureOrder)) {}
+
+func (s *SwapWs) PositionCallback(call func(position *FuturePosition)) {}
+
+func (s *SwapWs) AccountCallback(call func(account *FutureAccount)) {}
+
+func (s *SwapWs) Login() error {
+	return errPrivateUnsupported
+}
+
+func (s *SwapWs) SubscribeOrder(pair CurrencyPair, contractType string) error {
+	return errPrivateUnsupported
+}
+
+func (s *SwapWs) SubscribePosition(pair CurrencyPair, contractType string) error {
+	return errPrivateUnsupported
+}
+
+func (s *SwapWs) SubscribeAccount(pair CurrencyPair) error {
+	return errPrivateUnsupported
+}
+
 func (s *SwapWs) handle(data []byte) error {
 	if string(data) == "pong" {
 		return nil
@@ -132,7 +257,11 @@ func (s *SwapWs) handle(data []byte) error {
 		}
 
 		dep.UTime, _ = time.Parse(time.RFC3339, depthData[0].Timestamp)
-		dep.Pair, dep.ContractType = AdaptWsSymbol(depthData[0].Symbol)
+		dep.Pair, dep.ContractType, err = AdaptWsSymbol(depthData[0].Symbol)
+		if err != nil {
+			logger.Errorf("[depth] %s", err)
+			return err
+		}
 
 		for _, item := range depthData[0].Bids {
 			dep.BidList = append(dep.BidList, DepthRecord{
@@ -163,7 +292,11 @@ func (s *SwapWs) handle(data []byte) error {
 		if msg.Action == "partial" {
 			ticker := s.tickerCacheMap[tickerData[0].Symbol]
 			ticker.Ticker = new(Ticker)
-			ticker.Pair, ticker.ContractType = AdaptWsSymbol(tickerData[0].Symbol)
+			ticker.Pair, ticker.ContractType, err = AdaptWsSymbol(tickerData[0].Symbol)
+			if err != nil {
+				logger.Errorf("[ticker] %s", err)
+				return err
+			}
 			ticker.Vol = tickerData[0].HomeNotional24h
 			ticker.Last = tickerData[0].LastPrice
 			ticker.Sell = tickerData[0].AskPrice
@@ -210,9 +343,51 @@ func (s *SwapWs) handle(data []byte) error {
 			s.tickerCacheMap[tickerData[0].Symbol] = ticker
 			s.tickerCall(&ticker)
 		}
+	case "tradeBin1m", "tradeBin5m", "tradeBin1h", "tradeBin1d":
+		return s.klineHandle(msg.Table, msg.Data)
 	default:
 		logger.Warnf("unknown ws message: %s", string(data))
 	}
 
 	return nil
 }
+
+func (s *SwapWs) klineHandle(table string, data json.RawMessage) error {
+	var bins []tradeBinData
+	err := json.Unmarshal(data, &bins)
+	if err != nil {
+		logger.Errorf("trade bin data unmarshal error , data: %s", string(data))
+		return err
+	}
+
+	for _, bin := range bins {
+		s.klineLock.Lock()
+		period, ok := s.klinePeriods[table+":"+bin.Symbol]
+		s.klineLock.Unlock()
+		if !ok || s.klineCall == nil {
+			continue
+		}
+
+		pair, contract, err := AdaptWsSymbol(bin.Symbol)
+		if err != nil {
+			logger.Errorf("[kline] %s", err)
+			return err
+		}
+		//the timestamp of a bin is its close time, as in GetKlineRecords
+		t, _ := time.Parse(time.RFC3339, bin.Timestamp)
+		s.klineCall(&FutureKline{
+			Kline: &Kline{
+				Pair:      pair,
+				Timestamp: t.Unix(),
+				Open:      bin.Open,
+				High:      bin.High,
+				Low:       bin.Low,
+				Close:     bin.Close,
+				Vol:       bin.Volume},
+			Vol2:         bin.HomeNotional,
+			ContractType: contract,
+		}, period, true)
+	}
+
+	return nil
+}
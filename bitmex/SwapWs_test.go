@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewSwapWs(t *testing.T) {
@@ -22,3 +24,23 @@ func TestNewSwapWs(t *testing.T) {
 
 	time.Sleep(5 * time.Minute)
 }
+
+func TestSwapWs_Kline(t *testing.T) {
+	var klines []*goex.FutureKline
+	ws := &SwapWs{klinePeriods: map[string]goex.KlinePeriod{"tradeBin1m:XBTUSD": goex.KLINE_PERIOD_1MIN}}
+	ws.KlineCallback(func(kline *goex.FutureKline, period goex.KlinePeriod, closed bool) {
+		assert.Equal(t, goex.KlinePeriod(goex.KLINE_PERIOD_1MIN), period)
+		assert.True(t, closed, "a bin is pushed once closed")
+		klines = append(klines, kline)
+	})
+
+	require.NoError(t, ws.handle([]byte(`{"table":"tradeBin1m","action":"insert","data":[{"timestamp":"2021-01-01T00:01:00.000Z","symbol":"XBTUSD","open":28923.5,"high":28990,"low":28900,"close":28951,"trades":310,"volume":1523400,"vwap":28944.2,"homeNotional":52.63,"foreignNotional":1523400},{"timestamp":"2021-01-01T00:01:00.000Z","symbol":"ETHUSD","open":737.1,"high":738,"low":736.5,"close":737.4,"volume":10}]}`)))
+	require.Len(t, klines, 1, "only the subscribed symbols")
+	assert.Equal(t, &goex.FutureKline{
+		Kline:        &goex.Kline{Pair: goex.BTC_USD, Timestamp: 1609459260, Open: 28923.5, High: 28990, Low: 28900, Close: 28951, Vol: 1523400},
+		Vol2:         52.63,
+		ContractType: goex.SWAP_CONTRACT,
+	}, klines[0])
+
+	assert.Error(t, ws.SubscribeKline(goex.BTC_USD, goex.SWAP_CONTRACT, goex.KLINE_PERIOD_4H))
+}
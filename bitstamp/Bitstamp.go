@@ -18,6 +18,37 @@ var (
 	BASE_URL = "https://www.bitstamp.net/api/"
 )
 
+var errorCodes = ErrorCodes{
+	"API0001": EX_ERR_NOT_FIND_APIKEY,
+	"API0002": EX_ERR_AUTH,
+	"API0003": EX_ERR_AUTH,
+	"API0004": EX_ERR_TIMESTAMP,
+	"API0005": EX_ERR_SIGN,
+	"API0006": EX_ERR_AUTH,
+	"API0008": EX_ERR_AUTH,
+}
+
+var errorMessages = ErrorMessages{
+	"check your account balance": EX_ERR_INSUFFICIENT_BALANCE,
+	"you have only":              EX_ERR_INSUFFICIENT_BALANCE,
+	"order not found":            EX_ERR_NOT_FIND_ORDER,
+	"invalid order id":           EX_ERR_NOT_FIND_ORDER,
+	"minimum order size":         EX_ERR_MIN_NOTIONAL,
+	"invalid currency pair":      EX_ERR_INVALID_CURRENCY_PAIR,
+	"rate limit":                 EX_ERR_API_LIMIT,
+	"maintenance":                EX_ERR_MAINTENANCE,
+}
+
+// adaptError maps the {"status":"error","reason":...,"code":"API0005"} or
+// {"error":"Order not found"} body of a request by its code, else by its message.
+func adaptError(respmap map[string]interface{}, raw string) ApiError {
+	code, _ := respmap["code"].(string)
+	if _, ok := errorCodes[code]; ok {
+		return errorCodes.Adapt(http.StatusOK, code, raw)
+	}
+	return errorMessages.Adapt(http.StatusOK, raw).WithNativeCode(code)
+}
+
 type Exchange struct {
 	client *http.Client
 	clientId,
@@ -137,7 +168,7 @@ func (exchange *Exchange) placeOrder(side string, pair CurrencyPair, amount, pri
 
 	orderId, isok := respmap["id"].(string)
 	if !isok {
-		return nil, errors.New(string(resp))
+		return nil, adaptError(respmap, string(resp))
 	}
 
 	orderSide := BUY
@@ -147,7 +178,7 @@ func (exchange *Exchange) placeOrder(side string, pair CurrencyPair, amount, pri
 
 	orderprice, isok := respmap["price"].(string)
 	if !isok {
-		return nil, errors.New(string(resp))
+		return nil, adaptError(respmap, string(resp))
 	}
 
 	return &Order{
@@ -209,7 +240,7 @@ func (exchange *Exchange) CancelOrder(orderId string, currency CurrencyPair) (bo
 	}
 
 	if respmap["error"] != nil {
-		return false, errors.New(string(resp))
+		return false, adaptError(respmap, string(resp))
 	}
 
 	println(string(resp))
@@ -235,7 +266,7 @@ func (exchange *Exchange) GetOneOrder(orderId string, currency CurrencyPair) (*O
 
 	transactions, isok := respmap["transactions"].([]interface{})
 	if !isok {
-		return nil, errors.New(string(resp))
+		return nil, adaptError(respmap, string(resp))
 	}
 
 	status := respmap["status"].(string)
@@ -1,7 +1,6 @@
 package bittrex
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 	"sort"
@@ -9,6 +8,18 @@ import (
 	. "github.com/soulsplit/goex"
 )
 
+var errorMessages = ErrorMessages{
+	"APIKEY_INVALID":                EX_ERR_AUTH,
+	"APISIGN_NOT_FOUND":             EX_ERR_SIGN,
+	"INVALID_SIGNATURE":             EX_ERR_SIGN,
+	"INVALID_MARKET":                EX_ERR_SYMBOL_ERR,
+	"MARKET_OFFLINE":                EX_ERR_MAINTENANCE,
+	"INSUFFICIENT_FUNDS":            EX_ERR_INSUFFICIENT_BALANCE,
+	"MIN_TRADE_REQUIREMENT_NOT_MET": EX_ERR_MIN_AMOUNT,
+	"ORDER_NOT_OPEN":                EX_ERR_ORDER_STATE,
+	"INVALID_ORDER":                 EX_ERR_NOT_FIND_ORDER,
+}
+
 type Exchange struct {
 	client *http.Client
 	baseUrl,
@@ -84,7 +95,8 @@ func (exchange *Exchange) GetDepth(size int, currency CurrencyPair) (*Depth, err
 
 	result, err2 := resp["result"].(map[string]interface{})
 	if err2 != true {
-		return nil, errors.New(resp["message"].(string))
+		msg, _ := resp["message"].(string)
+		return nil, errorMessages.Adapt(http.StatusOK, msg)
 	}
 	bids, _ := result["buy"].([]interface{})
 	asks, _ := result["sell"].([]interface{})
@@ -110,7 +122,7 @@ func (exchange *Exchange) GetKlineRecords(currency CurrencyPair, period KlinePer
 	panic("not implement")
 }
 
-//非个人，整个交易所的交易记录
+// 非个人，整个交易所的交易记录
 func (exchange *Exchange) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
 	panic("not implement")
 }
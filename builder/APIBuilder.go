@@ -30,6 +30,7 @@ import (
 	"github.com/soulsplit/goex/kraken"
 	"github.com/soulsplit/goex/okex"
 	"github.com/soulsplit/goex/poloniex"
+	"github.com/soulsplit/goex/sim"
 	"github.com/soulsplit/goex/zb"
 )
 
@@ -43,6 +44,9 @@ type APIBuilder struct {
 	apiPassphrase    string
 	futuresEndPoint  string
 	endPoint         string
+	rateLimit        *RateLimitConfig
+	rateLimits       map[string][]RateLimitRule
+	rateLimitClients map[string]*http.Client
 }
 
 type HttpClientConfig struct {
@@ -136,8 +140,9 @@ func (builder *APIBuilder) HttpProxy(proxyUrl string) (_builder *APIBuilder) {
 		return builder
 	}
 	builder.HttpClientConfig.Proxy = proxy
-	transport := builder.client.Transport.(*http.Transport)
-	transport.Proxy = http.ProxyURL(proxy)
+	if transport, ok := builder.client.Transport.(*http.Transport); ok {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
 	return builder
 }
 
@@ -145,8 +150,8 @@ func (builder *APIBuilder) HttpTimeout(timeout time.Duration) (_builder *APIBuil
 	builder.HttpClientConfig.HttpTimeout = timeout
 	builder.httpTimeout = timeout
 	builder.client.Timeout = timeout
-	transport := builder.client.Transport.(*http.Transport)
-	if transport != nil {
+	transport, ok := builder.client.Transport.(*http.Transport)
+	if ok && transport != nil {
 		//transport.ResponseHeaderTimeout = timeout
 		//transport.TLSHandshakeTimeout = timeout
 		transport.IdleConnTimeout = timeout
@@ -157,6 +162,86 @@ func (builder *APIBuilder) HttpTimeout(timeout time.Duration) (_builder *APIBuil
 	return builder
 }
 
+// HttpTransport replaces the transport of the http client, e.g. with a cassette.Recorder
+// wrapping GetHttpClient().Transport. Call it after HttpProxy and HttpTimeout,
+// they only apply to an *http.Transport.
+func (builder *APIBuilder) HttpTransport(transport http.RoundTripper) (_builder *APIBuilder) {
+	builder.client.Transport = transport
+	return builder
+}
+
+// RateLimit keeps the requests of the apis built afterwards in the limits of their
+// exchange, see RateLimits. A request over the limits waits (RateLimitQueue) or fails
+// with EX_ERR_API_LIMIT (RateLimitFailFast, or RateLimitQueue when the wait is longer
+// than maxWait, 0 waits as long as needed). Call it after HttpTransport.
+func (builder *APIBuilder) RateLimit(policy RateLimitPolicy, maxWait time.Duration) (_builder *APIBuilder) {
+	builder.rateLimit = &RateLimitConfig{Policy: policy, MaxWait: maxWait}
+	builder.rateLimitClients = nil
+	return builder
+}
+
+// RateLimits replaces the default limits of an exchange. The defaults are
+// binance.SpotRateLimits (seeded with exchangeInfo later), binance.FuturesRateLimits,
+// okex.RateLimits, huobi.RateLimits and huobi.HbdmRateLimits.
+func (builder *APIBuilder) RateLimits(exName string, limits ...RateLimitRule) (_builder *APIBuilder) {
+	if builder.rateLimits == nil {
+		builder.rateLimits = make(map[string][]RateLimitRule)
+	}
+	builder.rateLimits[exName] = limits
+	builder.rateLimitClients = nil
+	return builder
+}
+
+// httpClient returns the client for the apis of exName, all apis of an exchange share
+// one RateLimiter. Exchanges without limits use the plain client.
+func (builder *APIBuilder) httpClient(exName string) *http.Client {
+	if builder.rateLimit == nil {
+		return builder.client
+	}
+	if client, ok := builder.rateLimitClients[exName]; ok {
+		return client
+	}
+
+	limits, ok := builder.rateLimits[exName]
+	if !ok {
+		limits = defaultRateLimits(exName)
+	}
+	if len(limits) == 0 {
+		return builder.client
+	}
+
+	conf := *builder.rateLimit
+	conf.Limits = limits
+	conf.Transport = builder.client.Transport
+	client := &http.Client{
+		Transport:     NewRateLimiter(&conf),
+		Timeout:       builder.client.Timeout,
+		Jar:           builder.client.Jar,
+		CheckRedirect: builder.client.CheckRedirect,
+	}
+	if builder.rateLimitClients == nil {
+		builder.rateLimitClients = make(map[string]*http.Client)
+	}
+	builder.rateLimitClients[exName] = client
+	return client
+}
+
+func defaultRateLimits(exName string) []RateLimitRule {
+	switch exName {
+	case BINANCE:
+		return binance.SpotRateLimits()
+	case BINANCE_FUTURES, BINANCE_SWAP:
+		return binance.FuturesRateLimits()
+	case OKEX:
+		return okex.RateLimits()
+	case HUOBI_PRO:
+		return huobi.RateLimits()
+	case HBDM:
+		return huobi.HbdmRateLimits()
+	}
+	return nil
+}
+
 func (builder *APIBuilder) APIKey(key string) (_builder *APIBuilder) {
 	builder.apiKey = key
 	return builder
@@ -203,13 +288,13 @@ func (builder *APIBuilder) Build(exName string) (api API) {
 	case HUOBI_PRO:
 		//_api = huobi.NewHuoBiProSpot(builder.client, builder.apiKey, builder.secretkey)
 		_api = huobi.NewHuobiWithConfig(&APIConfig{
-			HttpClient:   builder.client,
+			HttpClient:   builder.httpClient(HUOBI_PRO),
 			Endpoint:     builder.endPoint,
 			ApiKey:       builder.apiKey,
 			ApiSecretKey: builder.secretkey})
 	case OKEX_V3, OKEX:
 		_api = okex.NewOKEx(&APIConfig{
-			HttpClient:    builder.client,
+			HttpClient:    builder.httpClient(OKEX),
 			ApiKey:        builder.apiKey,
 			ApiSecretKey:  builder.secretkey,
 			ApiPassphrase: builder.apiPassphrase,
@@ -222,7 +307,7 @@ func (builder *APIBuilder) Build(exName string) (api API) {
 	case BINANCE:
 		//_api = binance.New(builder.client, builder.apiKey, builder.secretkey)
 		_api = binance.NewWithConfig(&APIConfig{
-			HttpClient:   builder.client,
+			HttpClient:   builder.httpClient(BINANCE),
 			Endpoint:     builder.endPoint,
 			ApiKey:       builder.apiKey,
 			ApiSecretKey: builder.secretkey})
@@ -242,6 +327,8 @@ func (builder *APIBuilder) Build(exName string) (api API) {
 		_api = hitbtc.New(builder.client, builder.apiKey, builder.secretkey)
 	case ATOP:
 		_api = atop.New(builder.client, builder.apiKey, builder.secretkey)
+	case SIM:
+		_api = sim.New(nil)
 	default:
 		println("exchange name error [" + exName + "].")
 
@@ -268,7 +355,7 @@ func (builder *APIBuilder) BuildFuture(exName string) (api FutureRestAPI) {
 	case OKEX_FUTURE, OKEX_V3:
 		//return okcoin.NewOKEx(builder.client, builder.apiKey, builder.secretkey)
 		return okex.NewOKEx(&APIConfig{
-			HttpClient: builder.client,
+			HttpClient: builder.httpClient(OKEX),
 			//	Endpoint:      "https://www.okex.com",
 			Endpoint:      builder.futuresEndPoint,
 			ApiKey:        builder.apiKey,
@@ -276,20 +363,20 @@ func (builder *APIBuilder) BuildFuture(exName string) (api FutureRestAPI) {
 			ApiPassphrase: builder.apiPassphrase}).OKExFuture
 	case HBDM:
 		return huobi.NewHbdm(&APIConfig{
-			HttpClient:   builder.client,
+			HttpClient:   builder.httpClient(HBDM),
 			Endpoint:     builder.futuresEndPoint,
 			ApiKey:       builder.apiKey,
 			ApiSecretKey: builder.secretkey})
 	case HBDM_SWAP:
 		return huobi.NewHbdmSwap(&APIConfig{
-			HttpClient:   builder.client,
+			HttpClient:   builder.httpClient(HBDM),
 			Endpoint:     builder.endPoint,
 			ApiKey:       builder.apiKey,
 			ApiSecretKey: builder.secretkey,
 		})
 	case OKEX_SWAP:
 		return okex.NewOKEx(&APIConfig{
-			HttpClient:    builder.client,
+			HttpClient:    builder.httpClient(OKEX),
 			Endpoint:      builder.futuresEndPoint,
 			ApiKey:        builder.apiKey,
 			ApiSecretKey:  builder.secretkey,
@@ -305,18 +392,20 @@ func (builder *APIBuilder) BuildFuture(exName string) (api FutureRestAPI) {
 
 	case BINANCE_SWAP:
 		return binance.NewBinanceSwap(&APIConfig{
-			HttpClient:   builder.client,
+			HttpClient:   builder.httpClient(BINANCE_SWAP),
 			Endpoint:     builder.futuresEndPoint,
 			ApiKey:       builder.apiKey,
 			ApiSecretKey: builder.secretkey,
 		})
 	case BINANCE, BINANCE_FUTURES:
 		return binance.NewBinanceFutures(&APIConfig{
-			HttpClient:   builder.client,
+			HttpClient:   builder.httpClient(BINANCE_FUTURES),
 			Endpoint:     builder.futuresEndPoint,
 			ApiKey:       builder.apiKey,
 			ApiSecretKey: builder.secretkey,
 		})
+	case SIM:
+		return sim.New(nil).Futures()
 	default:
 		println(fmt.Sprintf("%s not support future", exName))
 		return nil
@@ -327,15 +416,22 @@ func (builder *APIBuilder) BuildFuturesWs(exName string) (FuturesWsApi, error) {
 	switch exName {
 	case OKEX_V3, OKEX, OKEX_FUTURE:
 		return okex.NewOKExV3FuturesWs(okex.NewOKEx(&APIConfig{
-			HttpClient: builder.client,
-			Endpoint:   builder.futuresEndPoint,
+			HttpClient:    builder.httpClient(OKEX),
+			Endpoint:      builder.futuresEndPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
 		})), nil
 	case HBDM:
 		return huobi.NewHbdmWs(), nil
 	case HBDM_SWAP:
 		return huobi.NewHbdmSwapWs(), nil
 	case BINANCE, BINANCE_FUTURES, BINANCE_SWAP:
-		return binance.NewFuturesWs(), nil
+		return binance.NewFuturesWsWithConfig(&APIConfig{
+			HttpClient:   builder.httpClient(BINANCE_FUTURES), //the listenKey calls count in the futures weight
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
 	case BITMEX:
 		return bitmex.NewSwapWs(), nil
 	}
@@ -345,11 +441,23 @@ func (builder *APIBuilder) BuildFuturesWs(exName string) (FuturesWsApi, error) {
 func (builder *APIBuilder) BuildSpotWs(exName string) (SpotWsApi, error) {
 	switch exName {
 	case OKEX_V3, OKEX:
-		return okex.NewOKExSpotV3Ws(nil), nil
+		return okex.NewOKEx(&APIConfig{
+			HttpClient:    builder.httpClient(OKEX),
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+		}).OKExV3SpotWs, nil
 	case HUOBI_PRO, HUOBI:
-		return huobi.NewSpotWs(), nil
+		return huobi.NewSpotWsWithConfig(&APIConfig{
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
 	case BINANCE:
-		return binance.NewSpotWs(), nil
+		return binance.NewSpotWsWithConfig(&APIConfig{
+			HttpClient:   builder.httpClient(BINANCE),
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
 	}
 	return nil, errors.New("not support the exchange " + exName)
 }
@@ -358,21 +466,21 @@ func (builder *APIBuilder) BuildWallet(exName string) (WalletApi, error) {
 	switch exName {
 	case OKEX_V3, OKEX:
 		return okex.NewOKEx(&APIConfig{
-			HttpClient:    builder.client,
+			HttpClient:    builder.httpClient(OKEX),
 			ApiKey:        builder.apiKey,
 			ApiSecretKey:  builder.secretkey,
 			ApiPassphrase: builder.apiPassphrase,
 		}).OKExWallet, nil
 	case HUOBI_PRO:
 		return huobi.NewWallet(&APIConfig{
-			HttpClient:   builder.client,
+			HttpClient:   builder.httpClient(HUOBI_PRO),
 			Endpoint:     builder.endPoint,
 			ApiKey:       builder.apiKey,
 			ApiSecretKey: builder.secretkey,
 		}), nil
 	case BINANCE:
 		return binance.NewWallet(&APIConfig{
-			HttpClient:   builder.client,
+			HttpClient:   builder.httpClient(BINANCE),
 			Endpoint:     builder.endPoint,
 			ApiKey:       builder.apiKey,
 			ApiSecretKey: builder.secretkey,
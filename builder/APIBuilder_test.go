@@ -26,6 +26,8 @@ func TestAPIBuilder_Build(t *testing.T) {
 	assert.Equal(t, builder.APIKey("").APISecretkey("").Build(goex.KRAKEN).GetExchangeName(), goex.KRAKEN)
 	assert.Equal(t, builder.APIKey("").APISecretkey("").Build(goex.FCOIN_MARGIN).GetExchangeName(), goex.FCOIN_MARGIN)
 	assert.Equal(t, builder.APIKey("").APISecretkey("").BuildFuture(goex.HBDM).GetExchangeName(), goex.HBDM)
+	assert.Equal(t, builder.Build(goex.SIM).GetExchangeName(), goex.SIM)
+	assert.Equal(t, builder.BuildFuture(goex.SIM).GetExchangeName(), goex.SIM)
 }
 
 func TestAPIBuilder_BuildSpotWs(t *testing.T) {
@@ -47,3 +49,20 @@ func TestAPIBuilder_BuildFuturesWs(t *testing.T) {
 	wsApi.SubscribeDepth(goex.BTC_USD, goex.QUARTER_CONTRACT)
 	time.Sleep(time.Minute)
 }
+
+func TestAPIBuilder_RateLimit(t *testing.T) {
+	b := NewAPIBuilder().RateLimit(goex.RateLimitFailFast, 0)
+	assert.IsType(t, &goex.RateLimiter{}, b.httpClient(goex.BINANCE).Transport)
+	assert.Same(t, b.httpClient(goex.OKEX), b.httpClient(goex.OKEX), "the apis of an exchange share the limiter")
+	assert.NotSame(t, b.httpClient(goex.BINANCE), b.httpClient(goex.BINANCE_FUTURES))
+	assert.Same(t, b.client, b.httpClient(goex.KRAKEN), "no limits for kraken")
+
+	b.RateLimits(goex.KRAKEN, goex.RateLimitRule{Name: "calls", Interval: 3 * time.Second, Limit: 1})
+	assert.IsType(t, &goex.RateLimiter{}, b.httpClient(goex.KRAKEN).Transport)
+
+	b = NewAPIBuilder().RateLimit(goex.RateLimitFailFast, 0)
+	_, err := b.BuildFuturesWs(goex.BINANCE)
+	assert.NoError(t, err)
+	assert.Contains(t, b.rateLimitClients, goex.BINANCE_FUTURES, "the listenKey calls count in the futures weight")
+	assert.NotContains(t, b.rateLimitClients, goex.BINANCE)
+}
@@ -0,0 +1,340 @@
+// Package cassette records the http traffic of an adapter to a fixture file
+// and serves it back later, so adapters can be tested without network access
+// or real api keys.
+//
+//	rec, _ := cassette.New(&cassette.Config{Path: "testdata/ticker.json", Mode: cassette.ModeAuto})
+//	api := builder.NewAPIBuilder().HttpTransport(rec).Build(goex.BINANCE)
+//	...
+//	rec.Save()
+//
+// Only requests sent through the net/http client are seen, with HTTP_LIB=fasthttp the
+// requests fail as they would bypass the recorder.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type Mode int
+
+const (
+	ModeRecord Mode = iota //send requests and keep the interactions
+	ModeReplay             //serve the interactions from the file, nothing goes to the network
+	ModeAuto               //replay if the file exists, record otherwise
+)
+
+const Redacted = "REDACTED"
+
+var ErrInteractionNotFound = errors.New("cassette: no recorded interaction for request")
+
+// DefaultRedactKeys are the credential, signature and time dependent parameters of
+// the supported exchanges. They are matched case-insensitively against header names,
+// query and form parameters and top-level json body fields. Time dependent values are
+// redacted too, otherwise a signed request would never match its recording.
+var DefaultRedactKeys = []string{
+	//binance
+	"X-MBX-APIKEY", "signature", "timestamp", "recvWindow",
+	//okex
+	"OK-ACCESS-KEY", "OK-ACCESS-SIGN", "OK-ACCESS-TIMESTAMP", "OK-ACCESS-PASSPHRASE",
+	//huobi
+	"AccessKeyId",
+	//bitmex, kraken
+	"api-key", "api-signature", "api-expires", "API-Sign",
+	//bitfinex
+	"X-BFX-APIKEY", "X-BFX-SIGNATURE", "X-BFX-PAYLOAD",
+	//others
+	"Authorization", "apiKey", "api_key", "sign", "secret_key", "nonce",
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Config struct {
+	Path       string
+	Mode       Mode
+	Transport  http.RoundTripper //used in record mode, default http.DefaultTransport
+	RedactKeys []string          //default DefaultRedactKeys
+	// RedactValues are literal strings (api keys, passphrases ...) scrubbed from
+	// everything that is written, wherever they appear.
+	RedactValues []string
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+type Recorder struct {
+	sync.Mutex
+	conf         Config
+	mode         Mode
+	redactKeys   map[string]bool
+	interactions []*Interaction
+	used         []bool
+}
+
+func New(conf *Config) (*Recorder, error) {
+	if conf == nil || conf.Path == "" {
+		return nil, errors.New("cassette: path is required")
+	}
+
+	r := &Recorder{conf: *conf, mode: conf.Mode, redactKeys: make(map[string]bool)}
+	if r.conf.Transport == nil {
+		r.conf.Transport = http.DefaultTransport
+	}
+	keys := r.conf.RedactKeys
+	if keys == nil {
+		keys = DefaultRedactKeys
+	}
+	for _, k := range keys {
+		r.redactKeys[strings.ToLower(k)] = true
+	}
+
+	if r.mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(conf.Path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+
+	if r.mode == ModeReplay {
+		data, err := ioutil.ReadFile(conf.Path)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("cassette: %s: %w", conf.Path, err)
+		}
+		r.used = make([]bool, len(r.interactions))
+	}
+
+	return r, nil
+}
+
+// Mode returns ModeRecord or ModeReplay, ModeAuto is resolved by New.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+func (r *Recorder) Interactions() []*Interaction {
+	r.Lock()
+	defer r.Unlock()
+	return append([]*Interaction(nil), r.interactions...)
+}
+
+// Unwrap is the transport of the record mode.
+func (r *Recorder) Unwrap() http.RoundTripper {
+	return r.conf.Transport
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, body)
+	}
+	return r.record(req, body)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := r.conf.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    r.redactURL(req.URL.String()),
+			Header: r.redactHeader(req.Header),
+			Body:   r.redactBody(string(body)),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     r.redactHeader(resp.Header),
+			Body:       r.redactValues(string(respBody)),
+		},
+	}
+
+	//redaction may change the body length
+	it.Response.Header.Del("Content-Length")
+
+	r.Lock()
+	r.interactions = append(r.interactions, it)
+	r.Unlock()
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// replay serves the first unused interaction matching method, url and body. When
+// all matches are used up, the last match is served again, so polling loops work.
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	method, u, b := req.Method, r.redactURL(req.URL.String()), r.redactBody(string(body))
+
+	r.Lock()
+	defer r.Unlock()
+
+	match := -1
+	for i, it := range r.interactions {
+		if it.Request.Method != method || it.Request.URL != u || it.Request.Body != b {
+			continue
+		}
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, method, u)
+	}
+	r.used[match] = true
+
+	it := r.interactions[match]
+	header := it.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", it.Response.StatusCode, http.StatusText(it.Response.StatusCode)),
+		StatusCode:    it.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(it.Response.Body)),
+		ContentLength: int64(len(it.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Save writes the recorded interactions to Config.Path. It does nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+
+	r.Lock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(r.conf.Path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.conf.Path, data, 0644)
+}
+
+func (r *Recorder) redactValues(s string) string {
+	for _, v := range r.conf.RedactValues {
+		if v != "" {
+			s = strings.ReplaceAll(s, v, Redacted)
+		}
+	}
+	return s
+}
+
+func (r *Recorder) redactParams(values url.Values) {
+	for k := range values {
+		if r.redactKeys[strings.ToLower(k)] {
+			values[k] = []string{Redacted}
+		}
+	}
+}
+
+// redactURL redacts the query parameters and sorts them, the order of params
+// built from a map is random.
+func (r *Recorder) redactURL(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return r.redactValues(rawurl)
+	}
+	values := u.Query()
+	r.redactParams(values)
+	u.RawQuery = values.Encode()
+	return r.redactValues(u.String())
+}
+
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	h := make(http.Header, len(header))
+	for k, vs := range header {
+		if r.redactKeys[strings.ToLower(k)] {
+			h[k] = []string{Redacted}
+			continue
+		}
+		for _, v := range vs {
+			h.Add(k, r.redactValues(v))
+		}
+	}
+	return h
+}
+
+// redactBody handles json objects and url encoded forms, anything else only has
+// RedactValues applied.
+func (r *Recorder) redactBody(body string) string {
+	if body == "" {
+		return ""
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(body), "{") {
+		var obj map[string]interface{}
+		if json.Unmarshal([]byte(body), &obj) == nil {
+			for k := range obj {
+				if r.redactKeys[strings.ToLower(k)] {
+					obj[k] = Redacted
+				}
+			}
+			data, _ := json.Marshal(obj) //sorted keys
+			return r.redactValues(string(data))
+		}
+		return r.redactValues(body)
+	}
+
+	values, err := url.ParseQuery(body)
+	if err != nil {
+		return r.redactValues(body)
+	}
+	r.redactParams(values)
+	return r.redactValues(values.Encode()) //sorted keys
+}
@@ -0,0 +1,121 @@
+package cassette
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func doRequest(t *testing.T, client *http.Client, method, url, body string, header map[string]string) (string, error) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	return string(data), err
+}
+
+func TestRecorder_RecordReplay(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(r.URL.Path + "|account-of-my-api-key"))
+	}))
+
+	path := filepath.Join(t.TempDir(), "fixtures", "test.json")
+	rec, err := New(&Config{Path: path, Mode: ModeAuto, RedactValues: []string{"my-api-key"}})
+	require.NoError(t, err)
+	assert.Equal(t, ModeRecord, rec.Mode())
+
+	client := &http.Client{Transport: rec}
+	resp, err := doRequest(t, client, "GET", srv.URL+"/api/v3/account?timestamp=1&symbol=BTCUSDT&signature=abc", "",
+		map[string]string{"X-MBX-APIKEY": "my-api-key"})
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v3/account|account-of-my-api-key", resp)
+
+	_, err = doRequest(t, client, "POST", srv.URL+"/api/v5/order", `{"instId":"BTC-USDT","sign":"xyz"}`,
+		map[string]string{"OK-ACCESS-SIGN": "xyz", "OK-ACCESS-PASSPHRASE": "pass"})
+	require.NoError(t, err)
+	require.NoError(t, rec.Save())
+	srv.Close()
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	for _, secret := range []string{"my-api-key", "abc", "xyz", "pass", "timestamp=1"} {
+		assert.NotContains(t, string(data), secret)
+	}
+
+	//the server is gone, signatures and timestamps differ: still served from the file
+	rec, err = New(&Config{Path: path, Mode: ModeAuto, RedactValues: []string{"my-api-key"}})
+	require.NoError(t, err)
+	assert.Equal(t, ModeReplay, rec.Mode())
+	client = &http.Client{Transport: rec}
+
+	resp, err = doRequest(t, client, "GET", srv.URL+"/api/v3/account?signature=def&symbol=BTCUSDT&timestamp=2", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v3/account|account-of-REDACTED", resp)
+
+	resp, err = doRequest(t, client, "POST", srv.URL+"/api/v5/order", `{"sign":"other","instId":"BTC-USDT"}`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v5/order|account-of-REDACTED", resp)
+
+	_, err = doRequest(t, client, "GET", srv.URL+"/api/v3/account?symbol=ETHUSDT", "", nil)
+	assert.True(t, errors.Is(err, ErrInteractionNotFound))
+	assert.Equal(t, 2, calls)
+}
+
+func TestRecorder_RedactHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "test.json")
+	rec, err := New(&Config{Path: path, Mode: ModeRecord})
+	require.NoError(t, err)
+	client := &http.Client{Transport: rec}
+
+	_, err = doRequest(t, client, "POST", srv.URL+"/0/private/Balance", "nonce=1611",
+		map[string]string{"API-Key": "kraken-key", "API-Sign": "kraken-sign"})
+	require.NoError(t, err)
+	_, err = doRequest(t, client, "POST", srv.URL+"/v1/balances", "",
+		map[string]string{"X-BFX-APIKEY": "bfx-key", "X-BFX-PAYLOAD": "bfx-payload", "X-BFX-SIGNATURE": "bfx-sign"})
+	require.NoError(t, err)
+	require.NoError(t, rec.Save())
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	for _, secret := range []string{"kraken-key", "kraken-sign", "1611", "bfx-key", "bfx-payload", "bfx-sign"} {
+		assert.NotContains(t, string(data), secret)
+	}
+}
+
+func TestRecorder_ReplayOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order.json")
+	err := ioutil.WriteFile(path, []byte(`[
+{"request":{"method":"GET","url":"http://localhost/order"},"response":{"status_code":200,"body":"new"}},
+{"request":{"method":"GET","url":"http://localhost/order"},"response":{"status_code":200,"body":"filled"}}
+]`), 0644)
+	require.NoError(t, err)
+
+	rec, err := New(&Config{Path: path, Mode: ModeReplay})
+	require.NoError(t, err)
+	client := &http.Client{Transport: rec}
+
+	for _, expected := range []string{"new", "filled", "filled"} {
+		resp, err := doRequest(t, client, "GET", "http://localhost/order", "", nil)
+		require.NoError(t, err)
+		assert.Equal(t, expected, resp)
+	}
+}
@@ -2,7 +2,6 @@ package coinbene
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"sort"
@@ -284,7 +283,9 @@ func (swap *CoinbeneSwap) GetFutureOrder(orderId string, currencyPair CurrencyPa
 	json.Unmarshal(resp.Data, &data)
 
 	if data.OrderId == "" {
-		return nil, errors.New(fmt.Sprintf("not fund order[%s]", orderId))
+		e := EX_ERR_NOT_FIND_ORDER
+		e.OriginErrMsg = fmt.Sprintf("not fund order[%s]", orderId)
+		return nil, e
 	}
 
 	return &FutureOrder{
@@ -392,7 +393,7 @@ func (swap *CoinbeneSwap) doAuthRequest(method, uri string, param interface{}) (
 		return nil, err
 	}
 	if ret.Code != 200 {
-		return nil, errors.New(fmt.Sprintf("[%d]%s", ret.Code, ret.Message))
+		return nil, CommonErrorMessages.Adapt(http.StatusOK, ret.Message).WithNativeCode(fmt.Sprint(ret.Code))
 	}
 	return &ret, nil
 }
@@ -21,6 +21,12 @@ const (
 	API_BASE_URL = "https://www.coinbig.com"
 )
 
+// adaptError maps the msg of a response, coinbig documents no error codes.
+func adaptError(respmap map[string]interface{}) ApiError {
+	msg, _ := respmap["msg"].(string)
+	return CommonErrorMessages.Adapt(http.StatusOK, msg).WithNativeCode(fmt.Sprint(respmap["code"]))
+}
+
 type CoinBig struct {
 	httpClient *http.Client
 	accessKey,
@@ -64,7 +70,7 @@ func (cb *CoinBig) GetAccount() (*Account, error) {
 	}
 	if bodyDataMap["code"].(float64) != 0 {
 		// log.Println("respData", string(body))
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, adaptError(bodyDataMap)
 	}
 
 	balances, isok := bodyDataMap["data"].(map[string]interface{})
@@ -140,7 +146,7 @@ func (cb *CoinBig) placeOrder(amount, price string, pair CurrencyPair, orderType
 	}
 	if bodyDataMap["code"].(float64) != 0 {
 		// log.Println("respData", string(body))
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, adaptError(bodyDataMap)
 	}
 
 	data, isok := bodyDataMap["data"].(map[string]interface{})
@@ -208,7 +214,7 @@ func (cb *CoinBig) CancelOrder(orderId string, currencyPair CurrencyPair) (bool,
 	}
 	if bodyDataMap["code"].(float64) != 0 {
 		// log.Println("respData", string(body))
-		return false, errors.New(bodyDataMap["msg"].(string))
+		return false, adaptError(bodyDataMap)
 	}
 	return true, nil
 }
@@ -237,7 +243,7 @@ func (cb *CoinBig) CancelOrders(orderId []string) (bool, error) {
 	}
 
 	if bodyDataMap["status"].(string) != "1000" {
-		return false, errors.New(bodyDataMap["msg"].(string))
+		return false, adaptError(bodyDataMap)
 	}
 
 	return true, nil
@@ -271,7 +277,7 @@ func (cb *CoinBig) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Orde
 	}
 	if bodyDataMap["code"].(float64) != 0 {
 		// log.Println("respData", string(body))
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, adaptError(bodyDataMap)
 	}
 
 	data, _ := bodyDataMap["data"].(map[string]interface{})
@@ -319,7 +325,7 @@ func (cb *CoinBig) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error)
 	}
 	if bodyDataMap["code"].(float64) != 0 {
 		// log.Println("respData", string(body))
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, adaptError(bodyDataMap)
 	}
 
 	data, _ := bodyDataMap["data"].(map[string]interface{})
@@ -359,7 +365,7 @@ func (cb *CoinBig) GetDepth(size int, currencyPair CurrencyPair) (*Depth, error)
 	}
 	if bodyDataMap["code"].(float64) != 0 {
 		// log.Println("respData", string(body))
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, adaptError(bodyDataMap)
 	}
 
 	data := bodyDataMap["data"].(map[string]interface{})
@@ -402,7 +408,7 @@ func (cb *CoinBig) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 	}
 	if bodyDataMap["code"].(float64) != 0 {
 		// log.Println("respData", string(body))
-		return nil, errors.New(bodyDataMap["msg"].(string))
+		return nil, adaptError(bodyDataMap)
 	}
 
 	data := bodyDataMap["data"].(map[string]interface{})
@@ -430,7 +436,7 @@ func (cb *CoinBig) GetServerSync() error {
 	}
 	if bodyDataMap["code"].(float64) != 0 {
 		// log.Println("respData", string(body))
-		return errors.New(bodyDataMap["msg"].(string))
+		return adaptError(bodyDataMap)
 	}
 	data, _ := bodyDataMap["data"].(map[string]interface{})
 
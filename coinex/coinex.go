@@ -15,6 +15,19 @@ import (
 	. "github.com/soulsplit/goex"
 )
 
+var errorCodes = ErrorCodes{
+	"2":   EX_ERR_INVALID_PARAMETER,
+	"3":   EX_ERR_SERVER,
+	"23":  EX_ERR_AUTH,
+	"24":  EX_ERR_NOT_FIND_APIKEY,
+	"25":  EX_ERR_SIGN,
+	"107": EX_ERR_INSUFFICIENT_BALANCE,
+	"227": EX_ERR_TIMESTAMP,
+	"600": EX_ERR_NOT_FIND_ORDER,
+	"601": EX_ERR_ORDER_STATE,
+	"602": EX_ERR_MIN_AMOUNT,
+}
+
 type Exchange struct {
 	httpClient *http.Client
 	accessKey,
@@ -242,7 +255,7 @@ func (exchange *Exchange) GetKlineRecords(currency CurrencyPair, period KlinePer
 	panic("not implement")
 }
 
-//非个人，整个交易所的交易记录
+// 非个人，整个交易所的交易记录
 func (exchange *Exchange) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
 	panic("not implement")
 }
@@ -295,7 +308,8 @@ func (exchange *Exchange) doRequest(method, uri string, params *url.Values) (map
 	}
 
 	if ToInt(retmap["code"]) != 0 {
-		return nil, errors.New(retmap["message"].(string))
+		msg, _ := retmap["message"].(string)
+		return nil, errorCodes.Adapt(http.StatusOK, fmt.Sprint(ToInt(retmap["code"])), msg)
 	}
 
 	//	log.Println(retmap)
@@ -32,6 +32,42 @@ const (
 	CANCELWITHDRAW_API        = "cancelWithdraw"
 )
 
+var errorCodes = ErrorCodes{
+	"1001": EX_ERR_SERVER,
+	"1002": EX_ERR_SERVER,
+	"1003": EX_ERR_AUTH,
+	"1009": EX_ERR_MAINTENANCE,
+	"1012": EX_ERR_AUTH,
+	"2001": EX_ERR_INSUFFICIENT_BALANCE,
+	"2002": EX_ERR_INSUFFICIENT_BALANCE,
+	"2003": EX_ERR_INSUFFICIENT_BALANCE,
+	"2005": EX_ERR_INSUFFICIENT_BALANCE,
+	"2006": EX_ERR_INSUFFICIENT_BALANCE,
+	"2007": EX_ERR_INSUFFICIENT_BALANCE,
+	"2008": EX_ERR_INSUFFICIENT_BALANCE,
+	"2009": EX_ERR_INSUFFICIENT_BALANCE,
+	"3001": EX_ERR_NOT_FIND_ORDER,
+	"3002": EX_ERR_INVALID_PARAMETER,
+	"3003": EX_ERR_INVALID_PARAMETER,
+	"3004": EX_ERR_AUTH,
+	"3005": EX_ERR_INVALID_PARAMETER,
+	"3006": EX_ERR_AUTH,
+	"3007": EX_ERR_TIMESTAMP,
+	"3008": EX_ERR_NOT_FIND_ORDER,
+	"4001": EX_ERR_AUTH,
+	"4002": EX_ERR_API_LIMIT,
+}
+
+// adaptError maps the code of a {"code":3001,"message":"..."} response.
+func adaptError(respmap map[string]interface{}) ApiError {
+	code := fmt.Sprintf("%.0f", ToFloat64(respmap["code"]))
+	msg, _ := respmap["message"].(string)
+	if msg == "" {
+		msg = code
+	}
+	return errorCodes.Adapt(http.StatusOK, code, msg)
+}
+
 type Exx struct {
 	httpClient *http.Client
 	accessKey,
@@ -149,7 +185,7 @@ func (exx *Exx) GetAccount() (*Account, error) {
 	}
 
 	if respmap["code"] != nil && respmap["code"].(float64) != 1000 {
-		//return nil, errors.New(string(resp))
+		return nil, adaptError(respmap)
 	}
 
 	acc := new(Account)
@@ -205,7 +241,7 @@ func (exx *Exx) placeOrder(amount, price string, currency CurrencyPair, tradeTyp
 	code := respmap["code"].(float64)
 	if code != 1000 {
 		//log.Println(string(resp))
-		return nil, errors.New(fmt.Sprintf("%.0f", code))
+		return nil, adaptError(respmap)
 	}
 
 	orid := respmap["id"].(string)
@@ -265,7 +301,7 @@ func (exx *Exx) CancelOrder(orderId string, currency CurrencyPair) (bool, error)
 	}
 
 	//log.Println(respmap)
-	return false, errors.New(fmt.Sprintf("%.0f", code))
+	return false, adaptError(respmap)
 }
 
 func parseOrder(order *Order, ordermap map[string]interface{}) {
@@ -415,7 +451,7 @@ func (exx *Exx) Withdraw(amount string, currency Currency, fees, receiveAddr, sa
 		return respMap["id"].(string), nil
 	}
 
-	return "", errors.New(string(resp))
+	return "", adaptError(respMap)
 }
 
 func (exx *Exx) CancelWithdraw(id string, currency Currency, safePwd string) (bool, error) {
@@ -443,7 +479,7 @@ func (exx *Exx) CancelWithdraw(id string, currency Currency, safePwd string) (bo
 		return true, nil
 	}
 
-	return false, errors.New(string(resp))
+	return false, adaptError(respMap)
 }
 
 func (exx *Exx) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
@@ -1,6 +1,7 @@
 package gdax
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -13,6 +14,39 @@ import (
 
 //www.coinbase.com or www.gdax.com
 
+// coinbase has no error codes, the kind is told by the message
+var errorMessages = ErrorMessages{
+	"insufficient funds":        EX_ERR_INSUFFICIENT_BALANCE,
+	"order not found":           EX_ERR_NOT_FIND_ORDER,
+	"notfound":                  EX_ERR_INVALID_CURRENCY_PAIR,
+	"invalid product_id":        EX_ERR_INVALID_CURRENCY_PAIR,
+	"invalid signature":         EX_ERR_SIGN,
+	"invalid api key":           EX_ERR_AUTH,
+	"invalid passphrase":        EX_ERR_AUTH,
+	"request timestamp expired": EX_ERR_TIMESTAMP,
+	"rate limit exceeded":       EX_ERR_API_LIMIT,
+	"size is too small":         EX_ERR_MIN_AMOUNT,
+}
+
+// adaptError maps the {"message":"NotFound"} body of a failed request, the other
+// errors are a HTTP_ERR_CODE.
+func adaptError(err error) error {
+	var apiErr ApiError
+	if !errors.As(err, &apiErr) || apiErr.HttpStatus == 0 {
+		errCode := HTTP_ERR_CODE
+		errCode.OriginErrMsg = err.Error()
+		return errCode
+	}
+
+	var resp struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal([]byte(apiErr.OriginErrMsg), &resp) != nil || resp.Message == "" {
+		return apiErr
+	}
+	return errorMessages.Adapt(apiErr.HttpStatus, resp.Message)
+}
+
 type Exchange struct {
 	httpClient *http.Client
 	baseUrl,
@@ -55,9 +89,7 @@ func (exchange *Exchange) GetAccount() (*Account, error) {
 func (exchange *Exchange) GetTicker(currency CurrencyPair) (*Ticker, error) {
 	resp, err := HttpGet(exchange.httpClient, fmt.Sprintf("%s/products/%s/ticker", exchange.baseUrl, currency.ToSymbol("-")))
 	if err != nil {
-		errCode := HTTP_ERR_CODE
-		errCode.OriginErrMsg = err.Error()
-		return nil, errCode
+		return nil, adaptError(err)
 	}
 
 	return &Ticker{
@@ -71,9 +103,7 @@ func (exchange *Exchange) GetTicker(currency CurrencyPair) (*Ticker, error) {
 func (exchange *Exchange) Get24HStats(pair CurrencyPair) (*Ticker, error) {
 	resp, err := HttpGet(exchange.httpClient, fmt.Sprintf("%s/products/%s/stats", exchange.baseUrl, pair.ToSymbol("-")))
 	if err != nil {
-		errCode := HTTP_ERR_CODE
-		errCode.OriginErrMsg = err.Error()
-		return nil, errCode
+		return nil, adaptError(err)
 	}
 	return &Ticker{
 		High: ToFloat64(resp["high"]),
@@ -91,9 +121,7 @@ func (exchange *Exchange) GetDepth(size int, currency CurrencyPair) (*Depth, err
 
 	resp, err := HttpGet(exchange.httpClient, fmt.Sprintf("%s/products/%s/book?level=%d", exchange.baseUrl, currency.ToSymbol("-"), level))
 	if err != nil {
-		errCode := HTTP_ERR_CODE
-		errCode.OriginErrMsg = err.Error()
-		return nil, errCode
+		return nil, adaptError(err)
 	}
 
 	bids, _ := resp["bids"].([]interface{})
@@ -138,9 +166,7 @@ func (exchange *Exchange) GetKlineRecords(currency CurrencyPair, period KlinePer
 	urlpath += fmt.Sprintf("?granularity=%d", granularity)
 	resp, err := HttpGet3(exchange.httpClient, urlpath, map[string]string{})
 	if err != nil {
-		errCode := HTTP_ERR_CODE
-		errCode.OriginErrMsg = err.Error()
-		return nil, errCode
+		return nil, adaptError(err)
 	}
 
 	var klines []goex.Kline
@@ -36,6 +36,37 @@ var (
 	YCC_BTC = goex.CurrencyPair{CurrencyA: YCC, CurrencyB: BTC}
 )
 
+var errorCodes = goex.ErrorCodes{
+	"429":   goex.EX_ERR_API_LIMIT,
+	"500":   goex.EX_ERR_SERVER,
+	"503":   goex.EX_ERR_MAINTENANCE,
+	"504":   goex.EX_ERR_SERVER,
+	"1001":  goex.EX_ERR_AUTH,
+	"1002":  goex.EX_ERR_AUTH,
+	"1003":  goex.EX_ERR_AUTH,
+	"1004":  goex.EX_ERR_AUTH,
+	"2001":  goex.EX_ERR_SYMBOL_ERR,
+	"2002":  goex.EX_ERR_INVALID_CURRENCY_PAIR,
+	"2010":  goex.EX_ERR_MIN_AMOUNT,
+	"2011":  goex.EX_ERR_MIN_AMOUNT,
+	"2012":  goex.EX_ERR_INVALID_PARAMETER,
+	"2020":  goex.EX_ERR_INVALID_PARAMETER,
+	"10001": goex.EX_ERR_INVALID_PARAMETER,
+	"20001": goex.EX_ERR_INSUFFICIENT_BALANCE,
+	"20002": goex.EX_ERR_NOT_FIND_ORDER,
+	"20008": goex.EX_ERR_DUPLICATE_CLIENT_OID,
+}
+
+// adaptError maps the error object of a response, {"code":20001,"message":"...","description":"..."}.
+func adaptError(httpStatus int, errObj interface{}) goex.ApiError {
+	obj, _ := errObj.(map[string]interface{})
+	msg, _ := obj["message"].(string)
+	if desc, _ := obj["description"].(string); desc != "" {
+		msg += ", " + desc
+	}
+	return errorCodes.Adapt(httpStatus, fmt.Sprintf("%.0f", goex.ToFloat64(obj["code"])), msg)
+}
+
 type Exchange struct {
 	accessKey,
 	secretKey string
@@ -113,7 +144,7 @@ func (exchange *Exchange) GetTicker(currency goex.CurrencyPair) (*goex.Ticker, e
 	}
 
 	if result, isok := bodyDataMap["error"].(map[string]interface{}); isok == true {
-		return nil, errors.New(result["message"].(string) + ", " + result["description"].(string))
+		return nil, adaptError(http.StatusOK, result)
 	}
 
 	tickerMap := bodyDataMap
@@ -208,7 +239,7 @@ func (exchange *Exchange) placeOrder(ty goex.TradeSide, amount, price string, cu
 
 	if errObj, ok := resp["error"]; ok {
 		log.Println(errObj)
-		return nil, errors.New(errObj.(map[string]string)["message"])
+		return nil, adaptError(http.StatusOK, errObj)
 	}
 
 	return exchange.toOrder(resp), nil
@@ -248,7 +279,7 @@ func (exchange *Exchange) CancelOrder(orderId string, currency goex.CurrencyPair
 
 	if errObj, ok := resp["error"]; ok {
 		log.Println(errObj)
-		return false, errors.New(errObj.(map[string]string)["message"])
+		return false, adaptError(http.StatusOK, errObj)
 	}
 
 	return true, nil
@@ -262,7 +293,7 @@ func (exchange *Exchange) GetOneOrder(orderId string, currency goex.CurrencyPair
 	}
 
 	if errObj, ok := resp["error"]; ok {
-		return nil, errors.New(errObj.(map[string]string)["message"])
+		return nil, adaptError(http.StatusOK, errObj)
 	}
 
 	return exchange.toOrder(resp), nil
@@ -369,7 +400,7 @@ func (exchange *Exchange) GetDepth(size int, currency goex.CurrencyPair) (*goex.
 	}
 
 	if errObj, ok := resp["error"]; ok {
-		return nil, errors.New(errObj.(map[string]string)["message"])
+		return nil, adaptError(http.StatusOK, errObj)
 	}
 
 	askList := []goex.DepthRecord{}
@@ -523,7 +554,13 @@ func (exchange *Exchange) doRequest(reqMethod, uri string, ret interface{}) erro
 	}
 
 	if resp.StatusCode != 200 {
-		return errors.New(fmt.Sprintf("HttpStatusCode:%d ,Desc:%s", resp.StatusCode, string(bodyData)))
+		var errResp struct {
+			Error interface{} `json:"error"`
+		}
+		if json.Unmarshal(bodyData, &errResp) == nil && errResp.Error != nil {
+			return adaptError(resp.StatusCode, errResp.Error)
+		}
+		return goex.HttpStatusError(resp.StatusCode, string(bodyData))
 	}
 
 	err = json.Unmarshal(bodyData, ret)
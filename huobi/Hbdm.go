@@ -1,6 +1,7 @@
 package huobi
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -134,6 +135,10 @@ func (dm *Hbdm) GetExchangeName() string {
 }
 
 func (dm *Hbdm) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAccount, error) {
+	return dm.GetFutureUserinfoWithContext(context.Background(), currencyPair...)
+}
+
+func (dm *Hbdm) GetFutureUserinfoWithContext(ctx context.Context, currencyPair ...CurrencyPair) (*FutureAccount, error) {
 	path := "/api/v1/contract_account_info"
 	var data []struct {
 		Symbol            string  `json:"symbol"`
@@ -150,7 +155,7 @@ func (dm *Hbdm) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAccount,
 	}
 
 	params := &url.Values{}
-	err := dm.doRequest(path, params, &data)
+	err := dm.doRequestWithContext(ctx, path, params, &data)
 	if err != nil {
 		return nil, err
 	}
@@ -172,6 +177,10 @@ func (dm *Hbdm) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAccount,
 }
 
 func (dm *Hbdm) GetFuturePosition(currencyPair CurrencyPair, contractType string) ([]FuturePosition, error) {
+	return dm.GetFuturePositionWithContext(context.Background(), currencyPair, contractType)
+}
+
+func (dm *Hbdm) GetFuturePositionWithContext(ctx context.Context, currencyPair CurrencyPair, contractType string) ([]FuturePosition, error) {
 	var data []struct {
 		Symbol         string  `json:"symbol"`
 		ContractCode   string  `json:"contract_code"`
@@ -193,7 +202,7 @@ func (dm *Hbdm) GetFuturePosition(currencyPair CurrencyPair, contractType string
 	params := &url.Values{}
 	params.Add("symbol", currencyPair.CurrencyA.Symbol)
 
-	err := dm.doRequest(path, params, &data)
+	err := dm.doRequestWithContext(ctx, path, params, &data)
 	if err != nil {
 		return nil, err
 	}
@@ -278,6 +287,10 @@ func (dm *Hbdm) PlaceFutureOrder(currencyPair CurrencyPair, contractType, price,
 }
 
 func (dm *Hbdm) PlaceFutureOrder2(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	return dm.PlaceFutureOrder2WithContext(context.Background(), currencyPair, contractType, price, amount, openType, matchPrice, leverRate, opt...)
+}
+
+func (dm *Hbdm) PlaceFutureOrder2WithContext(ctx context.Context, currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
 	var data struct {
 		OrderId  int64 `json:"order_id"`
 		COrderId int64 `json:"client_order_id"`
@@ -315,7 +328,7 @@ func (dm *Hbdm) PlaceFutureOrder2(currencyPair CurrencyPair, contractType, price
 	params.Add("offset", offset)
 	params.Add("direction", direction)
 
-	err := dm.doRequest(path, params, &data)
+	err := dm.doRequestWithContext(ctx, path, params, &data)
 
 	fOrd := &FutureOrder{
 		ClientOid:    params.Get("client_order_id"),
@@ -336,14 +349,26 @@ func (dm *Hbdm) PlaceFutureOrder2(currencyPair CurrencyPair, contractType, price
 }
 
 func (dm *Hbdm) LimitFuturesOrder(currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
-	return dm.PlaceFutureOrder2(currencyPair, contractType, price, amount, openType, 0, dm.config.Lever)
+	return dm.LimitFuturesOrderWithContext(context.Background(), currencyPair, contractType, price, amount, openType, opt...)
+}
+
+func (dm *Hbdm) LimitFuturesOrderWithContext(ctx context.Context, currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	return dm.PlaceFutureOrder2WithContext(ctx, currencyPair, contractType, price, amount, openType, 0, dm.config.Lever)
 }
 
 func (dm *Hbdm) MarketFuturesOrder(currencyPair CurrencyPair, contractType, amount string, openType int) (*FutureOrder, error) {
-	return dm.PlaceFutureOrder2(currencyPair, contractType, "0", amount, openType, 1, dm.config.Lever)
+	return dm.MarketFuturesOrderWithContext(context.Background(), currencyPair, contractType, amount, openType)
+}
+
+func (dm *Hbdm) MarketFuturesOrderWithContext(ctx context.Context, currencyPair CurrencyPair, contractType, amount string, openType int) (*FutureOrder, error) {
+	return dm.PlaceFutureOrder2WithContext(ctx, currencyPair, contractType, "0", amount, openType, 1, dm.config.Lever)
 }
 
 func (dm *Hbdm) FutureCancelOrder(currencyPair CurrencyPair, contractType, orderId string) (bool, error) {
+	return dm.FutureCancelOrderWithContext(context.Background(), currencyPair, contractType, orderId)
+}
+
+func (dm *Hbdm) FutureCancelOrderWithContext(ctx context.Context, currencyPair CurrencyPair, contractType, orderId string) (bool, error) {
 	var data struct {
 		Successes string `json:"successes"`
 		Errors    []struct {
@@ -358,19 +383,23 @@ func (dm *Hbdm) FutureCancelOrder(currencyPair CurrencyPair, contractType, order
 	params.Add("order_id", orderId)
 	params.Add("symbol", currencyPair.CurrencyA.Symbol)
 
-	err := dm.doRequest(path, params, &data)
+	err := dm.doRequestWithContext(ctx, path, params, &data)
 	if err != nil {
 		return false, err
 	}
 
 	if len(data.Errors) > 0 {
-		return false, errors.New(fmt.Sprintf("%d:[%s]", data.Errors[0].ErrCode, data.Errors[0].ErrMsg))
+		return false, adaptHbdmError(data.Errors[0].ErrCode, data.Errors[0].ErrMsg)
 	} else {
 		return true, nil
 	}
 }
 
 func (dm *Hbdm) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	return dm.GetUnfinishFutureOrdersWithContext(context.Background(), currencyPair, contractType)
+}
+
+func (dm *Hbdm) GetUnfinishFutureOrdersWithContext(ctx context.Context, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
 	var data struct {
 		Orders      []OrderInfo `json:"orders"`
 		TotalPage   int         `json:"total_page"`
@@ -382,7 +411,7 @@ func (dm *Hbdm) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType
 	params := &url.Values{}
 	params.Add("symbol", currencyPair.CurrencyA.Symbol)
 
-	err := dm.doRequest(path, params, &data)
+	err := dm.doRequestWithContext(ctx, path, params, &data)
 	if err != nil {
 		return nil, err
 	}
@@ -411,7 +440,11 @@ func (dm *Hbdm) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType
 }
 
 func (dm *Hbdm) GetFutureOrder(orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error) {
-	ords, err := dm.GetFutureOrders([]string{orderId}, currencyPair, contractType)
+	return dm.GetFutureOrderWithContext(context.Background(), orderId, currencyPair, contractType)
+}
+
+func (dm *Hbdm) GetFutureOrderWithContext(ctx context.Context, orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error) {
+	ords, err := dm.GetFutureOrdersWithContext(ctx, []string{orderId}, currencyPair, contractType)
 	if err != nil {
 		return nil, err
 	}
@@ -423,6 +456,10 @@ func (dm *Hbdm) GetFutureOrder(orderId string, currencyPair CurrencyPair, contra
 }
 
 func (dm *Hbdm) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	return dm.GetFutureOrdersWithContext(context.Background(), orderIds, currencyPair, contractType)
+}
+
+func (dm *Hbdm) GetFutureOrdersWithContext(ctx context.Context, orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
 	var data []OrderInfo
 	path := "/api/v1/contract_order_info"
 	params := &url.Values{}
@@ -430,7 +467,7 @@ func (dm *Hbdm) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, co
 	params.Add("order_id", strings.Join(orderIds, ","))
 	params.Add("symbol", currencyPair.CurrencyA.Symbol)
 
-	err := dm.doRequest(path, params, &data)
+	err := dm.doRequestWithContext(ctx, path, params, &data)
 	if err != nil {
 		return nil, err
 	}
@@ -459,6 +496,10 @@ func (dm *Hbdm) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, co
 }
 
 func (dm *Hbdm) GetFutureOrderHistory(pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error) {
+	return dm.GetFutureOrderHistoryWithContext(context.Background(), pair, contractType, optional...)
+}
+
+func (dm *Hbdm) GetFutureOrderHistoryWithContext(ctx context.Context, pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error) {
 	panic("implement me")
 }
 
@@ -472,28 +513,36 @@ func (dm *Hbdm) GetContractValue(currencyPair CurrencyPair) (float64, error) {
 }
 
 func (dm *Hbdm) GetFutureEstimatedPrice(currencyPair CurrencyPair) (float64, error) {
-	ret, err := HttpGet(dm.config.HttpClient, dm.config.Endpoint+"/api/v1//contract_delivery_price?symbol="+currencyPair.CurrencyA.Symbol)
+	return dm.GetFutureEstimatedPriceWithContext(context.Background(), currencyPair)
+}
+
+func (dm *Hbdm) GetFutureEstimatedPriceWithContext(ctx context.Context, currencyPair CurrencyPair) (float64, error) {
+	ret, err := HttpGetWithContext(ctx, dm.config.HttpClient, dm.config.Endpoint+"/api/v1//contract_delivery_price?symbol="+currencyPair.CurrencyA.Symbol)
 	if err != nil {
 		return -1, err
 	}
 
 	if ret["status"].(string) != "ok" {
-		return -1, errors.New(fmt.Sprintf("%+v", ret))
+		return -1, adaptMapError(ret, fmt.Sprintf("%+v", ret))
 	}
 
 	return ToFloat64(ret["data"].(map[string]interface{})["delivery_price"]), nil
 }
 
 func (dm *Hbdm) GetFutureTicker(currencyPair CurrencyPair, contractType string) (*Ticker, error) {
+	return dm.GetFutureTickerWithContext(context.Background(), currencyPair, contractType)
+}
+
+func (dm *Hbdm) GetFutureTickerWithContext(ctx context.Context, currencyPair CurrencyPair, contractType string) (*Ticker, error) {
 	symbol := dm.adaptSymbol(currencyPair, contractType)
-	ret, err := HttpGet(dm.config.HttpClient, dm.config.Endpoint+"/market/detail/merged?symbol="+symbol)
+	ret, err := HttpGetWithContext(ctx, dm.config.HttpClient, dm.config.Endpoint+"/market/detail/merged?symbol="+symbol)
 	if err != nil {
 		return nil, err
 	}
 	//log.Println(ret)
 	s := ret["status"].(string)
 	if s == "error" {
-		return nil, errors.New(ret["err_msg"].(string))
+		return nil, adaptMapError(ret, fmt.Sprintf("%+v", ret))
 	}
 
 	tick, ok1 := ret["tick"].(map[string]interface{})
@@ -514,16 +563,20 @@ func (dm *Hbdm) GetFutureTicker(currencyPair CurrencyPair, contractType string)
 }
 
 func (dm *Hbdm) GetFutureDepth(currencyPair CurrencyPair, contractType string, size int) (*Depth, error) {
+	return dm.GetFutureDepthWithContext(context.Background(), currencyPair, contractType, size)
+}
+
+func (dm *Hbdm) GetFutureDepthWithContext(ctx context.Context, currencyPair CurrencyPair, contractType string, size int) (*Depth, error) {
 	symbol := dm.adaptSymbol(currencyPair, contractType)
 	url := dm.config.Endpoint + "/market/depth?type=step0&symbol=" + symbol
-	ret, err := HttpGet(dm.config.HttpClient, url)
+	ret, err := HttpGetWithContext(ctx, dm.config.HttpClient, url)
 	if err != nil {
 		return nil, err
 	}
 
 	s := ret["status"].(string)
 	if s == "error" {
-		return nil, errors.New(ret["err_msg"].(string))
+		return nil, adaptMapError(ret, fmt.Sprintf("%+v", ret))
 	}
 	//log.Println(ret)
 	dep := new(Depth)
@@ -557,13 +610,17 @@ func (dm *Hbdm) GetFutureDepth(currencyPair CurrencyPair, contractType string, s
 }
 
 func (dm *Hbdm) GetFutureIndex(currencyPair CurrencyPair) (float64, error) {
-	ret, err := HttpGet(dm.config.HttpClient, dm.config.Endpoint+"/api/v1/contract_index?symbol="+currencyPair.CurrencyA.Symbol)
+	return dm.GetFutureIndexWithContext(context.Background(), currencyPair)
+}
+
+func (dm *Hbdm) GetFutureIndexWithContext(ctx context.Context, currencyPair CurrencyPair) (float64, error) {
+	ret, err := HttpGetWithContext(ctx, dm.config.HttpClient, dm.config.Endpoint+"/api/v1/contract_index?symbol="+currencyPair.CurrencyA.Symbol)
 	if err != nil {
 		return -1, err
 	}
 
 	if ret["status"].(string) != "ok" {
-		return -1, errors.New(fmt.Sprintf("%+v", ret))
+		return -1, adaptMapError(ret, fmt.Sprintf("%+v", ret))
 	}
 
 	datamap := ret["data"].([]interface{})
@@ -572,6 +629,10 @@ func (dm *Hbdm) GetFutureIndex(currencyPair CurrencyPair) (float64, error) {
 }
 
 func (dm *Hbdm) GetKlineRecords(contract_type string, currency CurrencyPair, period KlinePeriod, size int, opt ...OptionalParameter) ([]FutureKline, error) {
+	return dm.GetKlineRecordsWithContext(context.Background(), contract_type, currency, period, size, opt...)
+}
+
+func (dm *Hbdm) GetKlineRecordsWithContext(ctx context.Context, contract_type string, currency CurrencyPair, period KlinePeriod, size int, opt ...OptionalParameter) ([]FutureKline, error) {
 	symbol := dm.adaptSymbol(currency, contract_type)
 	periodS := dm.adaptKLinePeriod(period)
 	url := fmt.Sprintf("%s/market/history/kline?symbol=%s&period=%s&size=%d", dm.config.Endpoint, symbol, periodS, size)
@@ -589,13 +650,13 @@ func (dm *Hbdm) GetKlineRecords(contract_type string, currency CurrencyPair, per
 		} `json:"data"`
 	}
 
-	err := HttpGet4(dm.config.HttpClient, url, nil, &ret)
+	err := HttpGet4WithContext(ctx, dm.config.HttpClient, url, nil, &ret)
 	if err != nil {
 		return nil, err
 	}
 
 	if ret.Status != "ok" {
-		return nil, errors.New(ret.ErrMsg)
+		return nil, adaptHbdmError(ret.ErrCode, ret.ErrMsg)
 	}
 
 	var klines []FutureKline
@@ -629,6 +690,10 @@ func (dm *Hbdm) GetFee() (float64, error) {
 }
 
 func (dm *Hbdm) GetTrades(contract_type string, currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	return dm.GetTradesWithContext(context.Background(), contract_type, currencyPair, since)
+}
+
+func (dm *Hbdm) GetTradesWithContext(ctx context.Context, contract_type string, currencyPair CurrencyPair, since int64) ([]Trade, error) {
 	panic("not supported.")
 }
 
@@ -736,13 +801,17 @@ func (dm *Hbdm) buildPostForm(reqMethod, path string, postForm *url.Values) erro
 }
 
 func (dm *Hbdm) doRequest(path string, params *url.Values, data interface{}) error {
+	return dm.doRequestWithContext(context.Background(), path, params, data)
+}
+
+func (dm *Hbdm) doRequestWithContext(ctx context.Context, path string, params *url.Values, data interface{}) error {
 	dm.buildPostForm("POST", path, params)
 	jsonD, _ := ValuesToJson(*params)
 	//log.Println(string(jsonD))
 
 	var ret BaseResponse
 
-	resp, err := HttpPostForm3(dm.config.HttpClient, dm.config.Endpoint+path+"?"+params.Encode(), string(jsonD),
+	resp, err := HttpPostForm3WithContext(ctx, dm.config.HttpClient, dm.config.Endpoint+path+"?"+params.Encode(), string(jsonD),
 		map[string]string{"Content-Type": "application/json", "Accept-Language": "zh-cn"})
 
 	if err != nil {
@@ -757,7 +826,7 @@ func (dm *Hbdm) doRequest(path string, params *url.Values, data interface{}) err
 	}
 
 	if ret.Status != "ok" {
-		return errors.New(fmt.Sprintf("%d:[%s]", ret.ErrCode, ret.ErrMsg))
+		return adaptHbdmError(ret.ErrCode, ret.ErrMsg)
 	}
 
 	return json.Unmarshal(ret.Data, data)
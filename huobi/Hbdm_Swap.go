@@ -78,7 +78,7 @@ func (swap *HbdmSwap) GetFutureTicker(currencyPair CurrencyPair, contractType st
 	}
 
 	if tickResponse.Status != "ok" {
-		return nil, errors.New(string(responseBody))
+		return nil, adaptResponseError(responseBody)
 	}
 
 	return &Ticker{
@@ -124,7 +124,7 @@ func (swap *HbdmSwap) GetFutureDepth(currencyPair CurrencyPair, contractType str
 	}
 
 	if tickResponse.Status != "ok" {
-		return nil, errors.New(string(responseBody))
+		return nil, adaptResponseError(responseBody)
 	}
 
 	dep.Pair = currencyPair
@@ -259,6 +259,7 @@ func (swap *HbdmSwap) FutureCancelOrder(currencyPair CurrencyPair, contractType,
 
 	var cancelResponse struct {
 		Errors []struct {
+			ErrCode   int    `json:"err_code"`
 			ErrMsg    string `json:"err_msg"`
 			Successes string `json:"successes,omitempty"`
 		} `json:"errors"`
@@ -270,7 +271,7 @@ func (swap *HbdmSwap) FutureCancelOrder(currencyPair CurrencyPair, contractType,
 	}
 
 	if len(cancelResponse.Errors) > 0 {
-		return false, errors.New(cancelResponse.Errors[0].ErrMsg)
+		return false, adaptHbdmError(cancelResponse.Errors[0].ErrCode, cancelResponse.Errors[0].ErrMsg)
 	}
 
 	return true, nil
@@ -21,6 +21,8 @@ type HbdmSwapWs struct {
 	tickerCallback func(*FutureTicker)
 	depthCallback  func(*Depth)
 	tradeCallback  func(*Trade, string)
+	klineCallback  func(*FutureKline, KlinePeriod, bool)
+	klines         wsKlines
 }
 
 func NewHbdmSwapWs() *HbdmSwapWs {
@@ -65,6 +67,10 @@ func (ws *HbdmSwapWs) DepthCallback(call func(depth *Depth)) {
 	ws.depthCallback = call
 }
 
+func (ws *HbdmSwapWs) KlineCallback(call func(kline *FutureKline, period KlinePeriod, closed bool)) {
+	ws.klineCallback = call
+}
+
 func (ws *HbdmSwapWs) SubscribeTicker(pair CurrencyPair, contract string) error {
 	if ws.tickerCallback == nil {
 		return errors.New("please set ticker callback func")
@@ -107,10 +113,81 @@ func (ws *HbdmSwapWs) SubscribeTrade(pair CurrencyPair, contract string) error {
 	return errors.New("not implement")
 }
 
+func (ws *HbdmSwapWs) SubscribeKline(pair CurrencyPair, contract string, period KlinePeriod) error {
+	if ws.klineCallback == nil {
+		return errors.New("please set kline callback func")
+	}
+
+	if contract != SWAP_CONTRACT && contract != SWAP_USDT_CONTRACT {
+		return errors.New("not implement")
+	}
+
+	ch, err := ws.klines.topic(pair.ToSymbol("-"), period)
+	if err != nil {
+		return err
+	}
+	return ws.subscribe(map[string]interface{}{
+		"id":  "swap.kline",
+		"sub": ch})
+}
+
+func (ws *HbdmSwapWs) UnsubscribeTicker(pair CurrencyPair, contract string) error {
+	return ws.unsubscribe("ticker_1", fmt.Sprintf("market.%s.detail", pair.ToSymbol("-")))
+}
+
+func (ws *HbdmSwapWs) UnsubscribeDepth(pair CurrencyPair, contract string) error {
+	return ws.unsubscribe("swap.depth", fmt.Sprintf("market.%s.depth.step6", pair.ToSymbol("-")))
+}
+
+func (ws *HbdmSwapWs) UnsubscribeTrade(pair CurrencyPair, contract string) error {
+	return ws.unsubscribe("swap_trade_3", fmt.Sprintf("market.%s.trade.detail", pair.ToSymbol("-")))
+}
+
+func (ws *HbdmSwapWs) UnsubscribeKline(pair CurrencyPair, contract string, period KlinePeriod) error {
+	ch, err := klineTopic(pair.ToSymbol("-"), period)
+	if err != nil {
+		return err
+	}
+	if err = ws.unsubscribe("swap.kline", ch); err != nil {
+		return err
+	}
+	ws.klines.remove(ch)
+	return nil
+}
+
+func (ws *HbdmSwapWs) OrderCallback(call func(order *FutureOrder)) {}
+
+func (ws *HbdmSwapWs) PositionCallback(call func(position *FuturePosition)) {}
+
+func (ws *HbdmSwapWs) AccountCallback(call func(account *FutureAccount)) {}
+
+func (ws *HbdmSwapWs) Login() error {
+	return errPrivateUnsupported
+}
+
+func (ws *HbdmSwapWs) SubscribeOrder(pair CurrencyPair, contract string) error {
+	return errPrivateUnsupported
+}
+
+func (ws *HbdmSwapWs) SubscribePosition(pair CurrencyPair, contract string) error {
+	return errPrivateUnsupported
+}
+
+func (ws *HbdmSwapWs) SubscribeAccount(pair CurrencyPair) error {
+	return errPrivateUnsupported
+}
+
 func (ws *HbdmSwapWs) subscribe(sub map[string]interface{}) error {
 	//	log.Println(sub)
 	ws.connectWs()
-	return ws.wsConn.Subscribe(sub)
+	return ws.wsConn.AddSubscription(WsSubscription{Key: fmt.Sprint(sub["sub"]), Event: sub})
+}
+
+func (ws *HbdmSwapWs) unsubscribe(id, ch string) error {
+	if ws.wsConn == nil {
+		return ErrNotSubscribed
+	}
+	return ws.wsConn.RemoveSubscription(ch, map[string]interface{}{"id": id, "unsub": ch})
 }
 
 func (ws *HbdmSwapWs) connectWs() {
@@ -200,6 +277,10 @@ func (ws *HbdmSwapWs) handle(msg []byte) error {
 		return nil
 	}
 
+	if strings.Contains(resp.Ch, ".kline.") {
+		return ws.klines.handle(resp.Ch, resp.Tick, pair, contract, ws.klineCallback)
+	}
+
 	logger.Errorf("[%s] unknown message, msg=%s", ws.wsConn.WsUrl, string(msg))
 
 	return nil
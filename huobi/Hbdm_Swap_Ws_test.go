@@ -1,11 +1,14 @@
 package huobi
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/soulsplit/goex"
 	"github.com/soulsplit/goex/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewHbdmSwapWs(t *testing.T) {
@@ -29,3 +32,27 @@ func TestNewHbdmSwapWs(t *testing.T) {
 
 	time.Sleep(time.Minute)
 }
+
+func TestHbdmSwapWs_Kline(t *testing.T) {
+	var (
+		klines []*goex.FutureKline
+		closed []bool
+	)
+	ws := NewHbdmSwapWs()
+	_, err := ws.klines.topic("BTC-USD", goex.KLINE_PERIOD_1MIN)
+	require.NoError(t, err)
+	ws.KlineCallback(func(kline *goex.FutureKline, period goex.KlinePeriod, c bool) {
+		klines = append(klines, kline)
+		closed = append(closed, c)
+	})
+
+	tick := func(id int64) []byte {
+		return []byte(fmt.Sprintf(`{"ch":"market.BTC-USD.kline.1min","ts":1609459260000,"tick":{"id":%d,"open":28923.5,"close":28951,"low":28900,"high":28990,"amount":5.26,"vol":1523,"count":310}}`, id))
+	}
+	require.NoError(t, ws.handle(tick(1609459200)))
+	require.NoError(t, ws.handle(tick(1609459260)))
+	assert.Equal(t, []bool{false, true, false}, closed)
+	assert.Equal(t, goex.SWAP_CONTRACT, klines[0].ContractType)
+	assert.Equal(t, 1523.0, klines[0].Vol, "the contracts")
+	assert.Equal(t, 5.26, klines[0].Vol2, "the base currency")
+}
@@ -15,8 +15,10 @@ import (
 
 type WsResponse struct {
 	Ch   string
+	Rep  string //reply of a req
 	Ts   int64
 	Tick json.RawMessage
+	Data json.RawMessage //data of a req reply
 }
 
 type TradeResponse struct {
@@ -53,11 +55,15 @@ type DetailResponse struct {
 }
 
 type DepthResponse struct {
-	Bids [][]float64
-	Asks [][]float64
-	Ts   int64 `json:"ts"`
+	Bids       [][]float64
+	Asks       [][]float64
+	Ts         int64 `json:"ts"`
+	SeqNum     int64 `json:"seqNum"`     //mbp only
+	PrevSeqNum int64 `json:"prevSeqNum"` //mbp incremental only
 }
 
+var errPrivateUnsupported = errors.New("the private streams are not supported by the hbdm ws")
+
 type HbdmWs struct {
 	*WsBuilder
 	sync.Once
@@ -66,6 +72,8 @@ type HbdmWs struct {
 	tickerCallback func(*FutureTicker)
 	depthCallback  func(*Depth)
 	tradeCallback  func(*Trade, string)
+	klineCallback  func(*FutureKline, KlinePeriod, bool)
+	klines         wsKlines
 }
 
 func NewHbdmWs() *HbdmWs {
@@ -100,6 +108,10 @@ func (hbdmWs *HbdmWs) DepthCallback(call func(depth *Depth)) {
 	hbdmWs.depthCallback = call
 }
 
+func (hbdmWs *HbdmWs) KlineCallback(call func(kline *FutureKline, period KlinePeriod, closed bool)) {
+	hbdmWs.klineCallback = call
+}
+
 func (hbdmWs *HbdmWs) SubscribeTicker(pair CurrencyPair, contract string) error {
 	if hbdmWs.tickerCallback == nil {
 		return errors.New("please set ticker callback func")
@@ -127,10 +139,77 @@ func (hbdmWs *HbdmWs) SubscribeTrade(pair CurrencyPair, contract string) error {
 		"sub": fmt.Sprintf("market.%s_%s.trade.detail", pair.CurrencyA.Symbol, hbdmWs.adaptContractSymbol(contract))})
 }
 
+func (hbdmWs *HbdmWs) SubscribeKline(pair CurrencyPair, contract string, period KlinePeriod) error {
+	if hbdmWs.klineCallback == nil {
+		return errors.New("please set kline callback func")
+	}
+
+	ch, err := hbdmWs.klines.topic(pair.CurrencyA.Symbol+"_"+hbdmWs.adaptContractSymbol(contract), period)
+	if err != nil {
+		return err
+	}
+	return hbdmWs.subscribe(map[string]interface{}{
+		"id":  "futures.kline",
+		"sub": ch})
+}
+
+func (hbdmWs *HbdmWs) UnsubscribeTicker(pair CurrencyPair, contract string) error {
+	return hbdmWs.unsubscribe("ticker_1", fmt.Sprintf("market.%s_%s.detail", pair.CurrencyA.Symbol, hbdmWs.adaptContractSymbol(contract)))
+}
+
+func (hbdmWs *HbdmWs) UnsubscribeDepth(pair CurrencyPair, contract string) error {
+	return hbdmWs.unsubscribe("futures.depth", fmt.Sprintf("market.%s_%s.depth.size_20.high_freq", pair.CurrencyA.Symbol, hbdmWs.adaptContractSymbol(contract)))
+}
+
+func (hbdmWs *HbdmWs) UnsubscribeTrade(pair CurrencyPair, contract string) error {
+	return hbdmWs.unsubscribe("trade_3", fmt.Sprintf("market.%s_%s.trade.detail", pair.CurrencyA.Symbol, hbdmWs.adaptContractSymbol(contract)))
+}
+
+func (hbdmWs *HbdmWs) UnsubscribeKline(pair CurrencyPair, contract string, period KlinePeriod) error {
+	ch, err := klineTopic(pair.CurrencyA.Symbol+"_"+hbdmWs.adaptContractSymbol(contract), period)
+	if err != nil {
+		return err
+	}
+	if err = hbdmWs.unsubscribe("futures.kline", ch); err != nil {
+		return err
+	}
+	hbdmWs.klines.remove(ch)
+	return nil
+}
+
+func (hbdmWs *HbdmWs) OrderCallback(call func(order *FutureOrder)) {}
+
+func (hbdmWs *HbdmWs) PositionCallback(call func(position *FuturePosition)) {}
+
+func (hbdmWs *HbdmWs) AccountCallback(call func(account *FutureAccount)) {}
+
+func (hbdmWs *HbdmWs) Login() error {
+	return errPrivateUnsupported
+}
+
+func (hbdmWs *HbdmWs) SubscribeOrder(pair CurrencyPair, contract string) error {
+	return errPrivateUnsupported
+}
+
+func (hbdmWs *HbdmWs) SubscribePosition(pair CurrencyPair, contract string) error {
+	return errPrivateUnsupported
+}
+
+func (hbdmWs *HbdmWs) SubscribeAccount(pair CurrencyPair) error {
+	return errPrivateUnsupported
+}
+
 func (hbdmWs *HbdmWs) subscribe(sub map[string]interface{}) error {
 	//	log.Println(sub)
 	hbdmWs.connectWs()
-	return hbdmWs.wsConn.Subscribe(sub)
+	return hbdmWs.wsConn.AddSubscription(WsSubscription{Key: fmt.Sprint(sub["sub"]), Event: sub})
+}
+
+func (hbdmWs *HbdmWs) unsubscribe(id, ch string) error {
+	if hbdmWs.wsConn == nil {
+		return ErrNotSubscribed
+	}
+	return hbdmWs.wsConn.RemoveSubscription(ch, map[string]interface{}{"id": id, "unsub": ch})
 }
 
 func (hbdmWs *HbdmWs) connectWs() {
@@ -208,6 +287,10 @@ func (hbdmWs *HbdmWs) handle(msg []byte) error {
 		return nil
 	}
 
+	if strings.Contains(resp.Ch, ".kline.") {
+		return hbdmWs.klines.handle(resp.Ch, resp.Tick, pair, contract, hbdmWs.klineCallback)
+	}
+
 	logger.Errorf("[%s] unknown message, msg=%s", hbdmWs.wsConn.WsUrl, string(msg))
 
 	return nil
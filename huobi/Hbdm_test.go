@@ -1,10 +1,15 @@
 package huobi
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var dm = NewHbdm(&goex.APIConfig{
@@ -59,9 +64,77 @@ func TestHbdm_GetFutureEstimatedPrice(t *testing.T) {
 }
 
 func TestHbdm_GetKlineRecords(t *testing.T) {
-	klines, _ := dm.GetKlineRecords(goex.QUARTER_CONTRACT, goex.EOS_USD, goex.KLINE_PERIOD_1MIN, 20, 0)
+	klines, _ := dm.GetKlineRecords(goex.QUARTER_CONTRACT, goex.EOS_USD, goex.KLINE_PERIOD_1MIN, 20)
 	for _, k := range klines {
 		tt := time.Unix(k.Timestamp, 0)
 		t.Log(k.Pair, tt, k.Open, k.Close, k.High, k.Low, k.Vol, k.Vol2)
 	}
 }
+
+func TestHbdm_AdaptError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/contract_cancel":
+			w.Write([]byte(`{"status":"ok","data":{"errors":[{"order_id":"1","err_code":1061,"err_msg":"This order doesnt exist."}],"successes":""},"ts":1}`))
+		default:
+			w.Write([]byte(`{"status":"error","err_code":1047,"err_msg":"Insufficient margin available.","ts":1}`))
+		}
+	}))
+	defer srv.Close()
+	hbdm := NewHbdm(&goex.APIConfig{Endpoint: srv.URL, HttpClient: http.DefaultClient})
+
+	_, err := hbdm.FutureCancelOrder(goex.BTC_USD, goex.QUARTER_CONTRACT, "1")
+	assert.True(t, errors.Is(err, goex.EX_ERR_NOT_FIND_ORDER))
+
+	_, err = hbdm.PlaceFutureOrder(goex.BTC_USD, goex.QUARTER_CONTRACT, "3800", "1", goex.OPEN_BUY, 0, 20)
+	assert.True(t, errors.Is(err, goex.EX_ERR_INSUFFICIENT_BALANCE))
+	assert.Equal(t, "1047:[Insufficient margin available.]", err.Error())
+}
+
+func TestHbdmSwap_AdaptError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"error","err-code":"invalid-symbol","err-msg":"invalid symbol","ts":1}`))
+	}))
+	defer srv.Close()
+	swap := NewHbdmSwap(&goex.APIConfig{Endpoint: srv.URL, HttpClient: http.DefaultClient})
+
+	_, err := swap.GetFutureTicker(goex.NewCurrencyPair2("FOO_USD"), goex.SWAP_CONTRACT)
+	assert.True(t, errors.Is(err, goex.EX_ERR_INVALID_CURRENCY_PAIR))
+	_, err = swap.GetFutureDepth(goex.NewCurrencyPair2("FOO_USD"), goex.SWAP_CONTRACT, 5)
+	assert.True(t, errors.Is(err, goex.EX_ERR_INVALID_CURRENCY_PAIR))
+}
+
+func TestHbdm_GetInstrumentsInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/contract_contract_info":
+			w.Write([]byte(`{"status":"ok","data":[{"symbol":"BTC","contract_code":"BTC210326","contract_type":"quarter","contract_size":100.000000000000000000,
+				"price_tick":0.010000000000000000,"delivery_date":"20210326","create_date":"20201211","contract_status":1},
+				{"symbol":"ETH","contract_code":"ETH210402","contract_type":"next_week","contract_size":10,"price_tick":0.001,
+				"delivery_date":"20210402","create_date":"20210319","contract_status":5}],"ts":1}`))
+		case getSwapContractInfoApiPath:
+			w.Write([]byte(`{"status":"ok","data":[{"symbol":"BTC","contract_code":"BTC-USD","contract_size":100,"price_tick":0.1,"create_date":"20200325","contract_status":1}],"ts":1}`))
+		}
+	}))
+	defer srv.Close()
+	conf := &goex.APIConfig{Endpoint: srv.URL, HttpClient: http.DefaultClient}
+
+	instruments, err := NewHbdm(conf).GetInstrumentsInfo()
+	require.NoError(t, err)
+	require.Len(t, instruments, 2)
+	assert.Equal(t, "BTC210326", instruments[0].Id)
+	assert.Equal(t, goex.BTC_USD.String(), instruments[0].Pair.String())
+	assert.Equal(t, goex.QUARTER_CONTRACT, instruments[0].ContractType)
+	assert.Equal(t, "0.010000000000000000", instruments[0].TickSize.String())
+	assert.True(t, instruments[0].ContractVal.Equal(goex.NewDecimal(100, 0)))
+	assert.Equal(t, time.Date(2021, 3, 26, 8, 0, 0, 0, time.UTC), instruments[0].Expiry)
+	assert.Equal(t, goex.NEXT_WEEK_CONTRACT, instruments[1].ContractType)
+	assert.Equal(t, goex.INSTRUMENT_SUSPENDED, instruments[1].Status)
+
+	instruments, err = NewHbdmSwap(conf).GetInstrumentsInfo()
+	require.NoError(t, err)
+	require.Len(t, instruments, 1)
+	assert.Equal(t, goex.SWAP, instruments[0].Kind)
+	assert.Equal(t, goex.SWAP_CONTRACT, instruments[0].ContractType)
+	assert.Equal(t, "0.1", instruments[0].TickSize.String())
+}
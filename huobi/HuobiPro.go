@@ -1,6 +1,7 @@
 package huobi
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -23,6 +24,8 @@ var _INERNAL_KLINE_PERIOD_CONVERTER = map[KlinePeriod]string{
 	KLINE_PERIOD_15MIN:  "15min",
 	KLINE_PERIOD_30MIN:  "30min",
 	KLINE_PERIOD_60MIN:  "60min",
+	KLINE_PERIOD_1H:     "60min",
+	KLINE_PERIOD_4H:     "4hour",
 	KLINE_PERIOD_1DAY:   "1day",
 	KLINE_PERIOD_1WEEK:  "1week",
 	KLINE_PERIOD_1MONTH: "1mon",
@@ -151,7 +154,7 @@ func (exchange *Exchange) GetAccountInfo(acc string) (AccountInfo, error) {
 	}
 
 	if respmap["status"].(string) != "ok" {
-		return AccountInfo{}, errors.New(respmap["err-code"].(string))
+		return AccountInfo{}, adaptSpotError(respmap)
 	}
 
 	var info AccountInfo
@@ -171,6 +174,10 @@ func (exchange *Exchange) GetAccountInfo(acc string) (AccountInfo, error) {
 }
 
 func (exchange *Exchange) GetAccount() (*Account, error) {
+	return exchange.GetAccountWithContext(context.Background())
+}
+
+func (exchange *Exchange) GetAccountWithContext(ctx context.Context) (*Account, error) {
 	path := fmt.Sprintf("/v1/account/accounts/%s/balance", exchange.accountId)
 	params := &url.Values{}
 	params.Set("accountId-id", exchange.accountId)
@@ -178,7 +185,7 @@ func (exchange *Exchange) GetAccount() (*Account, error) {
 
 	urlStr := exchange.baseUrl + path + "?" + params.Encode()
 	//println(urlStr)
-	respmap, err := HttpGet(exchange.httpClient, urlStr)
+	respmap, err := HttpGetWithContext(ctx, exchange.httpClient, urlStr)
 
 	if err != nil {
 		return nil, err
@@ -187,7 +194,7 @@ func (exchange *Exchange) GetAccount() (*Account, error) {
 	//log.Println(respmap)
 
 	if respmap["status"].(string) != "ok" {
-		return nil, errors.New(respmap["err-code"].(string))
+		return nil, adaptSpotError(respmap)
 	}
 
 	datamap := respmap["data"].(map[string]interface{})
@@ -227,7 +234,7 @@ func (exchange *Exchange) GetAccount() (*Account, error) {
 	return acc, nil
 }
 
-func (exchange *Exchange) placeOrder(amount, price string, pair CurrencyPair, orderType string) (string, error) {
+func (exchange *Exchange) placeOrder(ctx context.Context, amount, price string, pair CurrencyPair, orderType string) (string, error) {
 	symbol := exchange.Symbols[pair.ToLower().ToSymbol("")]
 
 	path := "/v1/order/orders/place"
@@ -245,7 +252,7 @@ func (exchange *Exchange) placeOrder(amount, price string, pair CurrencyPair, or
 
 	exchange.buildPostForm("POST", path, &params)
 
-	resp, err := HttpPostForm3(exchange.httpClient, exchange.baseUrl+path+"?"+params.Encode(), exchange.toJson(params),
+	resp, err := HttpPostForm3WithContext(ctx, exchange.httpClient, exchange.baseUrl+path+"?"+params.Encode(), exchange.toJson(params),
 		map[string]string{"Content-Type": "application/json", "Accept-Language": "zh-cn"})
 	if err != nil {
 		return "", err
@@ -258,13 +265,17 @@ func (exchange *Exchange) placeOrder(amount, price string, pair CurrencyPair, or
 	}
 
 	if respmap["status"].(string) != "ok" {
-		return "", errors.New(respmap["err-code"].(string))
+		return "", adaptSpotError(respmap)
 	}
 
 	return respmap["data"].(string), nil
 }
 
 func (exchange *Exchange) LimitBuy(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	return exchange.LimitBuyWithContext(context.Background(), amount, price, currency, opt...)
+}
+
+func (exchange *Exchange) LimitBuyWithContext(ctx context.Context, amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
 	orderTy := "buy-limit"
 	if len(opt) > 0 {
 		switch opt[0] {
@@ -278,7 +289,7 @@ func (exchange *Exchange) LimitBuy(amount, price string, currency CurrencyPair,
 			Log.Error("limit order optional parameter error ,opt= ", opt[0])
 		}
 	}
-	orderId, err := exchange.placeOrder(amount, price, currency, orderTy)
+	orderId, err := exchange.placeOrder(ctx, amount, price, currency, orderTy)
 	if err != nil {
 		return nil, err
 	}
@@ -292,6 +303,10 @@ func (exchange *Exchange) LimitBuy(amount, price string, currency CurrencyPair,
 }
 
 func (exchange *Exchange) LimitSell(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	return exchange.LimitSellWithContext(context.Background(), amount, price, currency, opt...)
+}
+
+func (exchange *Exchange) LimitSellWithContext(ctx context.Context, amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
 	orderTy := "sell-limit"
 	if len(opt) > 0 {
 		switch opt[0] {
@@ -305,7 +320,7 @@ func (exchange *Exchange) LimitSell(amount, price string, currency CurrencyPair,
 			Log.Error("limit order optional parameter error ,opt= ", opt[0])
 		}
 	}
-	orderId, err := exchange.placeOrder(amount, price, currency, orderTy)
+	orderId, err := exchange.placeOrder(ctx, amount, price, currency, orderTy)
 	if err != nil {
 		return nil, err
 	}
@@ -319,7 +334,11 @@ func (exchange *Exchange) LimitSell(amount, price string, currency CurrencyPair,
 }
 
 func (exchange *Exchange) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	orderId, err := exchange.placeOrder(amount, price, currency, "buy-market")
+	return exchange.MarketBuyWithContext(context.Background(), amount, price, currency)
+}
+
+func (exchange *Exchange) MarketBuyWithContext(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	orderId, err := exchange.placeOrder(ctx, amount, price, currency, "buy-market")
 	if err != nil {
 		return nil, err
 	}
@@ -333,7 +352,11 @@ func (exchange *Exchange) MarketBuy(amount, price string, currency CurrencyPair)
 }
 
 func (exchange *Exchange) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	orderId, err := exchange.placeOrder(amount, price, currency, "sell-market")
+	return exchange.MarketSellWithContext(context.Background(), amount, price, currency)
+}
+
+func (exchange *Exchange) MarketSellWithContext(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	orderId, err := exchange.placeOrder(ctx, amount, price, currency, "sell-market")
 	if err != nil {
 		return nil, err
 	}
@@ -358,49 +381,60 @@ func (exchange *Exchange) parseOrder(ordmap map[string]interface{}) Order {
 		OrderTime:  ToInt(ordmap["created-at"]),
 	}
 
-	state := ordmap["state"].(string)
+	ord.Status = adaptOrderState(ordmap["state"].(string))
+
+	if ord.DealAmount > 0.0 {
+		ord.AvgPrice = ToFloat64(ordmap["field-cash-amount"]) / ord.DealAmount
+	}
+
+	ord.Side = adaptOrderType(ordmap["type"].(string))
+	return ord
+}
+
+func adaptOrderState(state string) TradeStatus {
 	switch state {
 	case "submitted", "pre-submitted":
-		ord.Status = ORDER_UNFINISH
+		return ORDER_UNFINISH
 	case "filled":
-		ord.Status = ORDER_FINISH
+		return ORDER_FINISH
 	case "partial-filled":
-		ord.Status = ORDER_PART_FINISH
+		return ORDER_PART_FINISH
 	case "canceled", "partial-canceled":
-		ord.Status = ORDER_CANCEL
+		return ORDER_CANCEL
 	default:
-		ord.Status = ORDER_UNFINISH
-	}
-
-	if ord.DealAmount > 0.0 {
-		ord.AvgPrice = ToFloat64(ordmap["field-cash-amount"]) / ord.DealAmount
+		return ORDER_UNFINISH
 	}
+}
 
-	typeS := ordmap["type"].(string)
-	switch typeS {
+func adaptOrderType(typ string) TradeSide {
+	switch typ {
 	case "buy-limit":
-		ord.Side = BUY
+		return BUY
 	case "buy-market":
-		ord.Side = BUY_MARKET
+		return BUY_MARKET
 	case "sell-limit":
-		ord.Side = SELL
+		return SELL
 	case "sell-market":
-		ord.Side = SELL_MARKET
+		return SELL_MARKET
 	}
-	return ord
+	return 0
 }
 
 func (exchange *Exchange) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
+	return exchange.GetOneOrderWithContext(context.Background(), orderId, currency)
+}
+
+func (exchange *Exchange) GetOneOrderWithContext(ctx context.Context, orderId string, currency CurrencyPair) (*Order, error) {
 	path := "/v1/order/orders/" + orderId
 	params := url.Values{}
 	exchange.buildPostForm("GET", path, &params)
-	respmap, err := HttpGet(exchange.httpClient, exchange.baseUrl+path+"?"+params.Encode())
+	respmap, err := HttpGetWithContext(ctx, exchange.httpClient, exchange.baseUrl+path+"?"+params.Encode())
 	if err != nil {
 		return nil, err
 	}
 
 	if respmap["status"].(string) != "ok" {
-		return nil, errors.New(respmap["err-code"].(string))
+		return nil, adaptSpotError(respmap)
 	}
 
 	datamap := respmap["data"].(map[string]interface{})
@@ -411,16 +445,24 @@ func (exchange *Exchange) GetOneOrder(orderId string, currency CurrencyPair) (*O
 }
 
 func (exchange *Exchange) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	return exchange.getOrders(currency, OptionalParameter{}.
+	return exchange.GetUnfinishOrdersWithContext(context.Background(), currency)
+}
+
+func (exchange *Exchange) GetUnfinishOrdersWithContext(ctx context.Context, currency CurrencyPair) ([]Order, error) {
+	return exchange.getOrders(ctx, currency, OptionalParameter{}.
 		Optional("states", "pre-submitted,submitted,partial-filled").
 		Optional("size", "100"))
 }
 
 func (exchange *Exchange) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
+	return exchange.CancelOrderWithContext(context.Background(), orderId, currency)
+}
+
+func (exchange *Exchange) CancelOrderWithContext(ctx context.Context, orderId string, currency CurrencyPair) (bool, error) {
 	path := fmt.Sprintf("/v1/order/orders/%s/submitcancel", orderId)
 	params := url.Values{}
 	exchange.buildPostForm("POST", path, &params)
-	resp, err := HttpPostForm3(exchange.httpClient, exchange.baseUrl+path+"?"+params.Encode(), exchange.toJson(params),
+	resp, err := HttpPostForm3WithContext(ctx, exchange.httpClient, exchange.baseUrl+path+"?"+params.Encode(), exchange.toJson(params),
 		map[string]string{"Content-Type": "application/json", "Accept-Language": "zh-cn"})
 	if err != nil {
 		return false, err
@@ -433,20 +475,24 @@ func (exchange *Exchange) CancelOrder(orderId string, currency CurrencyPair) (bo
 	}
 
 	if respmap["status"].(string) != "ok" {
-		return false, errors.New(string(resp))
+		return false, adaptSpotError(respmap)
 	}
 
 	return true, nil
 }
 
 func (exchange *Exchange) GetOrderHistorys(currency CurrencyPair, optional ...OptionalParameter) ([]Order, error) {
+	return exchange.GetOrderHistorysWithContext(context.Background(), currency, optional...)
+}
+
+func (exchange *Exchange) GetOrderHistorysWithContext(ctx context.Context, currency CurrencyPair, optional ...OptionalParameter) ([]Order, error) {
 	var optionals []OptionalParameter
 	optionals = append(optionals, OptionalParameter{}.
 		Optional("states", "canceled,partial-canceled,filled").
 		Optional("size", "100").
 		Optional("direct", "next"))
 	optionals = append(optionals, optional...)
-	return exchange.getOrders(currency, optionals...)
+	return exchange.getOrders(ctx, currency, optionals...)
 }
 
 type queryOrdersParams struct {
@@ -460,20 +506,20 @@ type queryOrdersParams struct {
 	pair CurrencyPair
 }
 
-func (exchange *Exchange) getOrders(pair CurrencyPair, optional ...OptionalParameter) ([]Order, error) {
+func (exchange *Exchange) getOrders(ctx context.Context, pair CurrencyPair, optional ...OptionalParameter) ([]Order, error) {
 	path := "/v1/order/orders"
 	params := url.Values{}
 	params.Set("symbol", strings.ToLower(pair.AdaptUsdToUsdt().ToSymbol("")))
 	MergeOptionalParameter(&params, optional...)
 	Log.Info(params)
 	exchange.buildPostForm("GET", path, &params)
-	respmap, err := HttpGet(exchange.httpClient, fmt.Sprintf("%s%s?%s", exchange.baseUrl, path, params.Encode()))
+	respmap, err := HttpGetWithContext(ctx, exchange.httpClient, fmt.Sprintf("%s%s?%s", exchange.baseUrl, path, params.Encode()))
 	if err != nil {
 		return nil, err
 	}
 
 	if respmap["status"].(string) != "ok" {
-		return nil, errors.New(respmap["err-code"].(string))
+		return nil, adaptSpotError(respmap)
 	}
 
 	datamap := respmap["data"].([]interface{})
@@ -489,15 +535,19 @@ func (exchange *Exchange) getOrders(pair CurrencyPair, optional ...OptionalParam
 }
 
 func (exchange *Exchange) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
+	return exchange.GetTickerWithContext(context.Background(), currencyPair)
+}
+
+func (exchange *Exchange) GetTickerWithContext(ctx context.Context, currencyPair CurrencyPair) (*Ticker, error) {
 	pair := currencyPair.AdaptUsdToUsdt()
 	url := exchange.baseUrl + "/market/detail/merged?symbol=" + strings.ToLower(pair.ToSymbol(""))
-	respmap, err := HttpGet(exchange.httpClient, url)
+	respmap, err := HttpGetWithContext(ctx, exchange.httpClient, url)
 	if err != nil {
 		return nil, err
 	}
 
 	if respmap["status"].(string) == "error" {
-		return nil, errors.New(respmap["err-msg"].(string))
+		return nil, adaptSpotError(respmap)
 	}
 
 	tickmap, ok := respmap["tick"].(map[string]interface{})
@@ -527,6 +577,10 @@ func (exchange *Exchange) GetTicker(currencyPair CurrencyPair) (*Ticker, error)
 }
 
 func (exchange *Exchange) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
+	return exchange.GetDepthWithContext(context.Background(), size, currency)
+}
+
+func (exchange *Exchange) GetDepthWithContext(ctx context.Context, size int, currency CurrencyPair) (*Depth, error) {
 	url := exchange.baseUrl + "/market/depth?symbol=%s&type=step0&depth=%d"
 	n := 5
 	pair := currency.AdaptUsdToUsdt()
@@ -539,13 +593,13 @@ func (exchange *Exchange) GetDepth(size int, currency CurrencyPair) (*Depth, err
 	} else {
 		url = exchange.baseUrl + "/market/depth?symbol=%s&type=step0&d=%d"
 	}
-	respmap, err := HttpGet(exchange.httpClient, fmt.Sprintf(url, strings.ToLower(pair.ToSymbol("")), n))
+	respmap, err := HttpGetWithContext(ctx, exchange.httpClient, fmt.Sprintf(url, strings.ToLower(pair.ToSymbol("")), n))
 	if err != nil {
 		return nil, err
 	}
 
 	if "ok" != respmap["status"].(string) {
-		return nil, errors.New(respmap["err-msg"].(string))
+		return nil, adaptSpotError(respmap)
 	}
 
 	tick, _ := respmap["tick"].(map[string]interface{})
@@ -560,6 +614,10 @@ func (exchange *Exchange) GetDepth(size int, currency CurrencyPair) (*Depth, err
 
 //倒序
 func (exchange *Exchange) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
+	return exchange.GetKlineRecordsWithContext(context.Background(), currency, period, size, optional...)
+}
+
+func (exchange *Exchange) GetKlineRecordsWithContext(ctx context.Context, currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
 	url := exchange.baseUrl + "/market/history/kline?period=%s&size=%d&symbol=%s"
 	symbol := strings.ToLower(currency.AdaptUsdToUsdt().ToSymbol(""))
 	periodS, isOk := _INERNAL_KLINE_PERIOD_CONVERTER[period]
@@ -567,7 +625,7 @@ func (exchange *Exchange) GetKlineRecords(currency CurrencyPair, period KlinePer
 		periodS = "1min"
 	}
 
-	ret, err := HttpGet(exchange.httpClient, fmt.Sprintf(url, periodS, size, symbol))
+	ret, err := HttpGetWithContext(ctx, exchange.httpClient, fmt.Sprintf(url, periodS, size, symbol))
 	if err != nil {
 		return nil, err
 	}
@@ -596,12 +654,17 @@ func (exchange *Exchange) GetKlineRecords(currency CurrencyPair, period KlinePer
 //非个人，整个交易所的交易记录
 //https://github.com/huobiapi/API_Docs/wiki/REST_api_reference#get-markettrade-获取-trade-detail-数据
 func (exchange *Exchange) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	return exchange.GetTradesWithContext(context.Background(), currencyPair, since)
+}
+
+func (exchange *Exchange) GetTradesWithContext(ctx context.Context, currencyPair CurrencyPair, since int64) ([]Trade, error) {
 	var (
 		trades []Trade
 		ret    struct {
-			Status string
-			ErrMsg string `json:"err-msg"`
-			Data   []struct {
+			Status  string
+			ErrCode string `json:"err-code"`
+			ErrMsg  string `json:"err-msg"`
+			Data    []struct {
 				Ts   int64
 				Data []struct {
 					Id        big.Int
@@ -615,13 +678,13 @@ func (exchange *Exchange) GetTrades(currencyPair CurrencyPair, since int64) ([]T
 	)
 
 	url := exchange.baseUrl + "/market/history/trade?size=2000&symbol=" + currencyPair.AdaptUsdToUsdt().ToLower().ToSymbol("")
-	err := HttpGet4(exchange.httpClient, url, map[string]string{}, &ret)
+	err := HttpGet4WithContext(ctx, exchange.httpClient, url, map[string]string{}, &ret)
 	if err != nil {
 		return nil, err
 	}
 
 	if ret.Status != "ok" {
-		return nil, errors.New(ret.ErrMsg)
+		return nil, adaptSpotError(map[string]interface{}{"err-code": ret.ErrCode, "err-msg": ret.ErrMsg})
 	}
 
 	for _, d := range ret.Data {
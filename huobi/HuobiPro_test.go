@@ -1,8 +1,10 @@
 package huobi
 
 import (
+	"errors"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"github.com/soulsplit/goex"
 	"github.com/soulsplit/goex/internal/logger"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var httpProxyClient = &http.Client{
@@ -140,3 +143,18 @@ func TestHuobiPro_GetCurrenciesPrecision(t *testing.T) {
 	//return
 	t.Log(hbpro.GetCurrenciesPrecision())
 }
+
+func TestHuobiPro_AdaptError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"error","err-code":"order-orderstate-error","err-msg":"the order state is error","data":null}`))
+	}))
+	defer srv.Close()
+	hb := &Exchange{baseUrl: srv.URL, httpClient: http.DefaultClient}
+
+	_, err := hb.CancelOrder("1", goex.BTC_USDT)
+	assert.True(t, errors.Is(err, goex.EX_ERR_ORDER_STATE))
+	var apiErr goex.ApiError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "order-orderstate-error", apiErr.NativeCode)
+	assert.Equal(t, "the order state is error", apiErr.Error())
+}
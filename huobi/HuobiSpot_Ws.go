@@ -18,11 +18,22 @@ type SpotWs struct {
 	sync.Once
 	wsConn *WsConn
 
-	tickerCallback func(*Ticker)
-	depthCallback  func(*Depth)
-	tradeCallback  func(*Trade)
+	tickerCallback      func(*Ticker)
+	depthCallback       func(*Depth)
+	depthUpdateCallback func(*DepthUpdate)
+	tradeCallback       func(*Trade)
+	klineCallback       func(*Kline, KlinePeriod, bool)
+	orderCallback       func(*Order)
+	accountCallback     func(*Account)
+
+	klines wsKlines
+	v2     spotWsV2
 }
 
+// levels of the mbp incremental stream, wss://api.huobi.pro/ws serves 5 and 20 levels,
+// the deeper books are only served by wss://api.huobi.pro/feed
+const mbpLevels = 20
+
 func NewSpotWs() *SpotWs {
 	ws := &SpotWs{
 		WsBuilder: NewWsBuilder(),
@@ -39,6 +50,12 @@ func (ws *SpotWs) DepthCallback(call func(depth *Depth)) {
 	ws.depthCallback = call
 }
 
+// DepthUpdateCallback receives the mbp stream. Feed it to a goex.OrderBook with
+// RequestDepthSnapshot as its Resync func.
+func (ws *SpotWs) DepthUpdateCallback(call func(update *DepthUpdate)) {
+	ws.depthUpdateCallback = call
+}
+
 func (ws *SpotWs) TickerCallback(call func(ticker *Ticker)) {
 	ws.tickerCallback = call
 }
@@ -46,6 +63,10 @@ func (ws *SpotWs) TradeCallback(call func(trade *Trade)) {
 	ws.tradeCallback = call
 }
 
+func (ws *SpotWs) KlineCallback(call func(kline *Kline, period KlinePeriod, closed bool)) {
+	ws.klineCallback = call
+}
+
 func (ws *SpotWs) connectWs() {
 	ws.Do(func() {
 		ws.wsConn = ws.WsBuilder.Build()
@@ -54,7 +75,14 @@ func (ws *SpotWs) connectWs() {
 
 func (ws *SpotWs) subscribe(sub map[string]interface{}) error {
 	ws.connectWs()
-	return ws.wsConn.Subscribe(sub)
+	return ws.wsConn.AddSubscription(WsSubscription{Key: fmt.Sprint(sub["sub"]), Event: sub})
+}
+
+func (ws *SpotWs) unsubscribe(id, ch string) error {
+	if ws.wsConn == nil {
+		return ErrNotSubscribed
+	}
+	return ws.wsConn.RemoveSubscription(ch, map[string]interface{}{"id": id, "unsub": ch})
 }
 
 func (ws *SpotWs) SubscribeDepth(pair CurrencyPair) error {
@@ -66,6 +94,27 @@ func (ws *SpotWs) SubscribeDepth(pair CurrencyPair) error {
 		"sub": fmt.Sprintf("market.%s.mbp.refresh.20", pair.ToLower().ToSymbol(""))})
 }
 
+func (ws *SpotWs) SubscribeDepthUpdate(pair CurrencyPair) error {
+	if ws.depthUpdateCallback == nil {
+		return errors.New("please set depth update callback func")
+	}
+	return ws.subscribe(map[string]interface{}{
+		"id":  "spot.depth.update",
+		"sub": fmt.Sprintf("market.%s.mbp.%d", pair.ToLower().ToSymbol(""), mbpLevels)})
+}
+
+// RequestDepthSnapshot asks for a full mbp book, it is passed to the depth update
+// callback as a DepthUpdate with Snapshot set.
+func (ws *SpotWs) RequestDepthSnapshot(pair CurrencyPair) error {
+	if ws.depthUpdateCallback == nil {
+		return errors.New("please set depth update callback func")
+	}
+	ws.connectWs()
+	return ws.wsConn.SendJsonMessage(map[string]interface{}{
+		"id":  "spot.depth.snapshot",
+		"req": fmt.Sprintf("market.%s.mbp.%d", pair.ToLower().ToSymbol(""), mbpLevels)})
+}
+
 func (ws *SpotWs) SubscribeTicker(pair CurrencyPair) error {
 	if ws.tickerCallback == nil {
 		return errors.New("please set ticker call back func")
@@ -81,6 +130,47 @@ func (ws *SpotWs) SubscribeTrade(pair CurrencyPair) error {
 	return nil
 }
 
+func (ws *SpotWs) SubscribeKline(pair CurrencyPair, period KlinePeriod) error {
+	if ws.klineCallback == nil {
+		return errors.New("please set kline callback func")
+	}
+	ch, err := ws.klines.topic(pair.ToLower().ToSymbol(""), period)
+	if err != nil {
+		return err
+	}
+	return ws.subscribe(map[string]interface{}{
+		"id":  "spot.kline",
+		"sub": ch})
+}
+
+func (ws *SpotWs) UnsubscribeDepth(pair CurrencyPair) error {
+	return ws.unsubscribe("spot.depth", fmt.Sprintf("market.%s.mbp.refresh.20", pair.ToLower().ToSymbol("")))
+}
+
+func (ws *SpotWs) UnsubscribeDepthUpdate(pair CurrencyPair) error {
+	return ws.unsubscribe("spot.depth.update", fmt.Sprintf("market.%s.mbp.%d", pair.ToLower().ToSymbol(""), mbpLevels))
+}
+
+func (ws *SpotWs) UnsubscribeTicker(pair CurrencyPair) error {
+	return ws.unsubscribe("spot.ticker", fmt.Sprintf("market.%s.detail", pair.ToLower().ToSymbol("")))
+}
+
+func (ws *SpotWs) UnsubscribeTrade(pair CurrencyPair) error {
+	return nil
+}
+
+func (ws *SpotWs) UnsubscribeKline(pair CurrencyPair, period KlinePeriod) error {
+	ch, err := klineTopic(pair.ToLower().ToSymbol(""), period)
+	if err != nil {
+		return err
+	}
+	if err = ws.unsubscribe("spot.kline", ch); err != nil {
+		return err
+	}
+	ws.klines.remove(ch)
+	return nil
+}
+
 func (ws *SpotWs) handle(msg []byte) error {
 	if bytes.Contains(msg, []byte("ping")) {
 		pong := bytes.ReplaceAll(msg, []byte("ping"), []byte("pong"))
@@ -94,7 +184,14 @@ func (ws *SpotWs) handle(msg []byte) error {
 		return err
 	}
 
-	currencyPair := ParseCurrencyPairFromSpotWsCh(resp.Ch)
+	if strings.Contains(resp.Rep, ".mbp.") {
+		return ws.depthUpdateHandle(resp.Rep, resp.Ts, resp.Data, true)
+	}
+
+	if strings.Contains(resp.Ch, ".mbp.") && !strings.Contains(resp.Ch, "mbp.refresh") {
+		return ws.depthUpdateHandle(resp.Ch, resp.Ts, resp.Tick, false)
+	}
+
 	if strings.Contains(resp.Ch, "mbp.refresh") {
 		var (
 			depthResp DepthResponse
@@ -105,6 +202,12 @@ func (ws *SpotWs) handle(msg []byte) error {
 			return err
 		}
 
+		currencyPair, err := ParseCurrencyPairFromSpotWsCh(resp.Ch)
+		if err != nil {
+			logger.Errorf("[%s] %s", ws.wsConn.WsUrl, err)
+			return err
+		}
+
 		dep := ParseDepthFromResponse(depthResp)
 		dep.Pair = currencyPair
 		dep.UTime = time.Unix(0, resp.Ts*int64(time.Millisecond))
@@ -119,6 +222,11 @@ func (ws *SpotWs) handle(msg []byte) error {
 		if err != nil {
 			return err
 		}
+		currencyPair, err := ParseCurrencyPairFromSpotWsCh(resp.Ch)
+		if err != nil {
+			logger.Errorf("[%s] %s", ws.wsConn.WsUrl, err)
+			return err
+		}
 		ws.tickerCallback(&Ticker{
 			Pair: currencyPair,
 			Last: tickerResp.Close,
@@ -130,7 +238,47 @@ func (ws *SpotWs) handle(msg []byte) error {
 		return nil
 	}
 
+	if strings.Contains(resp.Ch, ".kline.") {
+		currencyPair, err := ParseCurrencyPairFromSpotWsCh(resp.Ch)
+		if err != nil {
+			logger.Errorf("[%s] %s", ws.wsConn.WsUrl, err)
+			return err
+		}
+		//the vol of a spot tick is the turnover
+		return ws.klines.handle(resp.Ch, resp.Tick, currencyPair, "", func(kline *FutureKline, period KlinePeriod, closed bool) {
+			k := *kline.Kline
+			k.Vol = kline.Vol2
+			ws.klineCallback(&k, period, closed)
+		})
+	}
+
 	logger.Errorf("[%s] unknown message ch , msg=%s", ws.wsConn.WsUrl, string(msg))
 
 	return nil
 }
+
+func (ws *SpotWs) depthUpdateHandle(ch string, ts int64, data json.RawMessage, snapshot bool) error {
+	var depthResp DepthResponse
+	err := json.Unmarshal(data, &depthResp)
+	if err != nil {
+		return err
+	}
+
+	pair, err := ParseCurrencyPairFromSpotWsCh(ch)
+	if err != nil {
+		logger.Errorf("[%s] %s", ws.wsConn.WsUrl, err)
+		return err
+	}
+
+	dep := ParseDepthFromResponse(depthResp)
+	ws.depthUpdateCallback(&DepthUpdate{
+		Pair:         pair,
+		LastUpdateId: depthResp.SeqNum,
+		PrevUpdateId: depthResp.PrevSeqNum,
+		Snapshot:     snapshot,
+		UTime:        time.Unix(0, ts*int64(time.Millisecond)),
+		BidList:      dep.BidList,
+		AskList:      dep.AskList,
+	})
+	return nil
+}
@@ -0,0 +1,312 @@
+package huobi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/internal/logger"
+)
+
+// the private topics are served by the v2 api on its own connection
+const spotWsV2Url = "wss://api.huobi.pro/ws/v2"
+
+type spotWsV2 struct {
+	accessKey string
+	secretKey string
+	wsUrl     string
+	once      sync.Once
+	conn      *WsConn
+
+	loginLock  sync.Mutex
+	loggedIn   bool
+	loginCh    chan error
+	lock       sync.Mutex
+	orderPairs map[string]CurrencyPair //by symbol
+	currencies map[string]bool         //of the account topic
+	orderFills map[int64][2]float64    //value and volume of the fills seen by the open orders
+}
+
+type wsV2Resp struct {
+	Action  string          `json:"action"`
+	Code    int             `json:"code"`
+	Ch      string          `json:"ch"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// NewSpotWsWithConfig is NewSpotWs with the api key of the private topics.
+func NewSpotWsWithConfig(config *APIConfig) *SpotWs {
+	ws := NewSpotWs()
+	ws.v2.accessKey = config.ApiKey
+	ws.v2.secretKey = config.ApiSecretKey
+	return ws
+}
+
+func (ws *SpotWs) OrderCallback(call func(order *Order)) {
+	ws.orderCallback = call
+}
+
+func (ws *SpotWs) AccountCallback(call func(account *Account)) {
+	ws.accountCallback = call
+}
+
+func (ws *SpotWs) authMessage() []byte {
+	params := url.Values{}
+	params.Set("accessKey", ws.v2.accessKey)
+	params.Set("signatureMethod", "HmacSHA256")
+	params.Set("signatureVersion", "2.1")
+	params.Set("timestamp", time.Now().UTC().Format("2006-01-02T15:04:05"))
+
+	u, _ := url.Parse(ws.v2.wsUrl)
+	payload := fmt.Sprintf("GET\n%s\n%s\n%s", u.Host, u.Path, params.Encode())
+	sign, _ := GetParamHmacSHA256Base64Sign(ws.v2.secretKey, payload)
+
+	msg, _ := json.Marshal(map[string]interface{}{
+		"action": "req",
+		"ch":     "auth",
+		"params": map[string]string{
+			"authType":         "api",
+			"accessKey":        ws.v2.accessKey,
+			"signatureMethod":  "HmacSHA256",
+			"signatureVersion": "2.1",
+			"timestamp":        params.Get("timestamp"),
+			"signature":        sign,
+		}})
+	return msg
+}
+
+// Login authenticates the v2 connection of the private topics, the auth is sent
+// again after a reconnect.
+func (ws *SpotWs) Login() error {
+	if ws.v2.accessKey == "" {
+		return EX_ERR_NOT_FIND_APIKEY
+	}
+
+	ws.v2.loginLock.Lock()
+	defer ws.v2.loginLock.Unlock()
+	if ws.v2.loggedIn {
+		return nil
+	}
+
+	built := false
+	ws.v2.once.Do(func() {
+		built = true
+		ws.v2.loginCh = make(chan error, 1)
+		if ws.v2.wsUrl == "" {
+			ws.v2.wsUrl = spotWsV2Url
+		}
+		//the auth is sent on connect and after every reconnect
+		ws.v2.conn = NewWsBuilder().
+			WsUrl(ws.v2.wsUrl).
+			AutoReconnect().
+			ConnectSuccessAfterSendMessage(ws.authMessage).
+			ProtoHandleFunc(ws.v2Handle).Build()
+	})
+	if !built { //the auth of the last call failed
+		for len(ws.v2.loginCh) > 0 {
+			<-ws.v2.loginCh
+		}
+		ws.v2.conn.SendMessage(ws.authMessage())
+	}
+
+	select {
+	case err := <-ws.v2.loginCh:
+		if err != nil {
+			return err
+		}
+	case <-time.After(10 * time.Second):
+		return EX_ERR_AUTH.OriginErr("login timeout")
+	}
+	ws.v2.loggedIn = true
+	return nil
+}
+
+func (ws *SpotWs) subscribeV2(ch string) error {
+	if err := ws.Login(); err != nil {
+		return err
+	}
+	return ws.v2.conn.Subscribe(map[string]interface{}{"action": "sub", "ch": ch})
+}
+
+func (ws *SpotWs) SubscribeOrder(pair CurrencyPair) error {
+	symbol := pair.ToLower().ToSymbol("")
+	ws.v2.lock.Lock()
+	if ws.v2.orderPairs == nil {
+		ws.v2.orderPairs = make(map[string]CurrencyPair, 4)
+	}
+	ws.v2.orderPairs[symbol] = pair
+	ws.v2.lock.Unlock()
+
+	return ws.subscribeV2("orders#" + symbol)
+}
+
+// SubscribeAccount subscribes the balances of both currencies of the pair.
+func (ws *SpotWs) SubscribeAccount(pair CurrencyPair) error {
+	ws.v2.lock.Lock()
+	if ws.v2.currencies == nil {
+		ws.v2.currencies = make(map[string]bool, 4)
+	}
+	ws.v2.currencies[pair.ToLower().CurrencyA.Symbol] = true
+	ws.v2.currencies[pair.ToLower().CurrencyB.Symbol] = true
+	ws.v2.lock.Unlock()
+
+	return ws.subscribeV2("accounts.update#1")
+}
+
+func (ws *SpotWs) v2Handle(msg []byte) error {
+	var resp wsV2Resp
+	if err := json.Unmarshal(msg, &resp); err != nil {
+		logger.Errorf("[ws v2] json unmarshal error %s, msg=%s", err, string(msg))
+		return err
+	}
+
+	switch resp.Action {
+	case "ping":
+		return ws.v2.conn.SendJsonMessage(map[string]interface{}{"action": "pong", "data": resp.Data})
+	case "req":
+		if resp.Ch == "auth" {
+			var err error
+			if resp.Code != 200 {
+				err = EX_ERR_AUTH.OriginErr(fmt.Sprintf("%d %s", resp.Code, resp.Message))
+				logger.Error("[ws v2] auth error:", err)
+			}
+			select {
+			case ws.v2.loginCh <- err:
+			default:
+			}
+		}
+		return nil
+	case "sub":
+		if resp.Code != 200 {
+			logger.Errorf("[ws v2] subscribe %s error: %d %s", resp.Ch, resp.Code, resp.Message)
+			return fmt.Errorf("subscribe %s error: %d %s", resp.Ch, resp.Code, resp.Message)
+		}
+		return nil
+	case "push":
+		if strings.HasPrefix(resp.Ch, "orders#") {
+			return ws.orderHandle(resp.Data)
+		}
+		if strings.HasPrefix(resp.Ch, "accounts.update#") {
+			return ws.accountHandle(resp.Data)
+		}
+	}
+
+	logger.Warnf("[ws v2] unknown message, msg=%s", string(msg))
+	return nil
+}
+
+func (ws *SpotWs) orderHandle(data json.RawMessage) error {
+	var o struct {
+		EventType       string  `json:"eventType"`
+		Symbol          string  `json:"symbol"`
+		OrderId         int64   `json:"orderId"`
+		ClientOrderId   string  `json:"clientOrderId"`
+		Type            string  `json:"type"`
+		OrderStatus     string  `json:"orderStatus"`
+		OrderPrice      float64 `json:"orderPrice,string"`
+		OrderSize       float64 `json:"orderSize,string"`
+		OrderValue      float64 `json:"orderValue,string"`
+		OrderCreateTime int64   `json:"orderCreateTime"`
+		TradePrice      float64 `json:"tradePrice,string"`
+		TradeVolume     float64 `json:"tradeVolume,string"`
+		ExecAmt         float64 `json:"execAmt,string"`
+		TradeTime       int64   `json:"tradeTime"`
+		LastActTime     int64   `json:"lastActTime"`
+	}
+	if err := json.Unmarshal(data, &o); err != nil {
+		return err
+	}
+
+	ws.v2.lock.Lock()
+	pair, ok := ws.v2.orderPairs[o.Symbol]
+	ws.v2.lock.Unlock()
+	if !ok {
+		return nil
+	}
+
+	ord := &Order{
+		Cid:        o.ClientOrderId,
+		OrderID:    int(o.OrderId),
+		OrderID2:   fmt.Sprint(o.OrderId),
+		Price:      o.OrderPrice,
+		Amount:     o.OrderSize,
+		DealAmount: o.ExecAmt,
+		Status:     adaptOrderState(o.OrderStatus),
+		Currency:   pair,
+		Side:       adaptOrderType(o.Type),
+		Type:       "limit",
+		OrderTime:  int(o.OrderCreateTime),
+	}
+	if strings.HasSuffix(o.Type, "-market") {
+		ord.Type = "market"
+	}
+	if ord.Amount == 0 { //the value of a market buy
+		ord.Amount = o.OrderValue
+	}
+
+	ws.v2.lock.Lock()
+	if ws.v2.orderFills == nil {
+		ws.v2.orderFills = make(map[int64][2]float64, 4)
+	}
+	fills := ws.v2.orderFills[o.OrderId]
+	if o.EventType == "trade" {
+		fills[0] += o.TradePrice * o.TradeVolume
+		fills[1] += o.TradeVolume
+		ws.v2.orderFills[o.OrderId] = fills
+	}
+	if fills[1] > 0 {
+		ord.AvgPrice = fills[0] / fills[1]
+	}
+	if ord.DealAmount == 0 {
+		ord.DealAmount = fills[1]
+	}
+	switch ord.Status {
+	case ORDER_FINISH, ORDER_CANCEL:
+		delete(ws.v2.orderFills, o.OrderId)
+		ord.FinishedTime = o.TradeTime
+		if ord.FinishedTime == 0 {
+			ord.FinishedTime = o.LastActTime
+		}
+	}
+	ws.v2.lock.Unlock()
+
+	if ws.orderCallback != nil {
+		ws.orderCallback(ord)
+	}
+	return nil
+}
+
+func (ws *SpotWs) accountHandle(data json.RawMessage) error {
+	var b struct {
+		Currency    string  `json:"currency"`
+		AccountType string  `json:"accountType"`
+		Balance     float64 `json:"balance,string"`
+		Available   float64 `json:"available,string"`
+	}
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+
+	ws.v2.lock.Lock()
+	ok := ws.v2.currencies[b.Currency]
+	ws.v2.lock.Unlock()
+	if !ok || (b.AccountType != "" && b.AccountType != "trade") || ws.accountCallback == nil {
+		return nil
+	}
+
+	currency := NewCurrency(b.Currency, "")
+	ws.accountCallback(&Account{
+		Exchange: HUOBI_PRO,
+		SubAccounts: map[Currency]SubAccount{currency: {
+			Currency:     currency,
+			Amount:       b.Available,
+			ForzenAmount: b.Balance - b.Available,
+		}},
+	})
+	return nil
+}
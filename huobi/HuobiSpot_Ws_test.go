@@ -1,11 +1,14 @@
 package huobi
 
 import (
+	"fmt"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewSpotWs(t *testing.T) {
@@ -27,3 +30,98 @@ func TestNewSpotWs(t *testing.T) {
 	//spotWs.SubscribeDepth(goex.BTC_USDT)
 	time.Sleep(time.Minute)
 }
+
+func TestSpotWs_DepthUpdate(t *testing.T) {
+	var updates []*goex.DepthUpdate
+	spotWs := NewSpotWs()
+	spotWs.DepthUpdateCallback(func(u *goex.DepthUpdate) { updates = append(updates, u) })
+
+	err := spotWs.handle([]byte(`{"ch":"market.btcusdt.mbp.20","ts":1609459200100,"tick":{"seqNum":101,"prevSeqNum":100,"bids":[[29316.42,0.5]],"asks":[[29316.43,0]]}}`))
+	require.NoError(t, err)
+	err = spotWs.handle([]byte(`{"id":"spot.depth.snapshot","rep":"market.btcusdt.mbp.20","status":"ok","data":{"seqNum":100,"bids":[[29316.42,1]],"asks":[[29316.43,2],[29317,1]]}}`))
+	require.NoError(t, err)
+
+	require.Len(t, updates, 2)
+	assert.Equal(t, goex.BTC_USDT.String(), updates[0].Pair.String())
+	assert.False(t, updates[0].Snapshot)
+	assert.Equal(t, int64(100), updates[0].PrevUpdateId)
+	assert.Equal(t, int64(101), updates[0].LastUpdateId)
+	assert.Equal(t, goex.DepthRecords{{Price: 29316.43, Amount: 0}}, updates[0].AskList)
+
+	assert.True(t, updates[1].Snapshot)
+	assert.Equal(t, int64(100), updates[1].LastUpdateId)
+	assert.Len(t, updates[1].AskList, 2)
+
+	ob := goex.NewOrderBook(&goex.OrderBookConfig{Pair: goex.BTC_USDT})
+	for i := len(updates) - 1; i >= 0; i-- {
+		require.NoError(t, ob.Update(updates[i]))
+	}
+	dep, err := ob.Depth(0)
+	require.NoError(t, err)
+	assert.Equal(t, goex.DepthRecords{{Price: 29317, Amount: 1}}, dep.AskList)
+	assert.Equal(t, goex.DepthRecords{{Price: 29316.42, Amount: 0.5}}, dep.BidList)
+}
+
+func TestSpotWs_Private(t *testing.T) {
+	var (
+		orders   []*goex.Order
+		accounts []*goex.Account
+	)
+	ws := NewSpotWsWithConfig(&goex.APIConfig{})
+	ws.v2.orderPairs = map[string]goex.CurrencyPair{"btcusdt": goex.BTC_USDT}
+	ws.v2.currencies = map[string]bool{"btc": true, "usdt": true}
+	ws.OrderCallback(func(order *goex.Order) { orders = append(orders, order) })
+	ws.AccountCallback(func(account *goex.Account) { accounts = append(accounts, account) })
+
+	require.NoError(t, ws.v2Handle([]byte(`{"action":"push","ch":"orders#btcusdt","data":{"eventType":"trade","symbol":"btcusdt","tradePrice":"30000","tradeVolume":"0.1","orderId":1001,"type":"buy-limit","clientOrderId":"abc","orderStatus":"partial-filled","orderPrice":"30010","orderSize":"0.3","tradeTime":1609459200000}}`)))
+	require.NoError(t, ws.v2Handle([]byte(`{"action":"push","ch":"orders#btcusdt","data":{"eventType":"trade","symbol":"btcusdt","tradePrice":"30020","tradeVolume":"0.1","orderId":1001,"type":"buy-limit","clientOrderId":"abc","orderStatus":"filled","orderPrice":"30010","orderSize":"0.2","tradeTime":1609459201000}}`)))
+	require.NoError(t, ws.v2Handle([]byte(`{"action":"push","ch":"orders#ethusdt","data":{"eventType":"creation","symbol":"ethusdt","orderId":1002,"type":"sell-limit","orderStatus":"submitted","orderPrice":"700","orderSize":"1"}}`)))
+	require.Len(t, orders, 2, "only the subscribed pairs")
+	assert.Equal(t, goex.ORDER_PART_FINISH, orders[0].Status)
+	assert.Equal(t, 0.1, orders[0].DealAmount)
+	ord := orders[1]
+	assert.Equal(t, "1001", ord.OrderID2)
+	assert.Equal(t, "abc", ord.Cid)
+	assert.Equal(t, goex.BUY, ord.Side)
+	assert.Equal(t, goex.ORDER_FINISH, ord.Status)
+	assert.InDelta(t, 0.2, ord.DealAmount, 1e-9)
+	assert.InDelta(t, 30010, ord.AvgPrice, 1e-9)
+	assert.Equal(t, int64(1609459201000), ord.FinishedTime)
+	assert.Empty(t, ws.v2.orderFills, "the fills of the finished orders")
+
+	require.NoError(t, ws.v2Handle([]byte(`{"action":"push","ch":"accounts.update#1","data":{"currency":"usdt","accountId":1,"balance":"100.5","available":"80.5","changeType":"order.place","accountType":"trade"}}`)))
+	require.NoError(t, ws.v2Handle([]byte(`{"action":"push","ch":"accounts.update#1","data":{"currency":"usdt","accountId":2,"balance":"3","available":"3","accountType":"margin"}}`)))
+	require.Len(t, accounts, 1)
+	usdt := goex.NewCurrency("usdt", "")
+	assert.Equal(t, goex.SubAccount{Currency: usdt, Amount: 80.5, ForzenAmount: 20}, accounts[0].SubAccounts[usdt])
+
+	assert.Equal(t, goex.EX_ERR_NOT_FIND_APIKEY, ws.SubscribeOrder(goex.BTC_USDT))
+}
+
+func TestSpotWs_Kline(t *testing.T) {
+	var (
+		klines []*goex.Kline
+		closed []bool
+	)
+	ws := NewSpotWs()
+	ch, err := ws.klines.topic("btcusdt", goex.KLINE_PERIOD_1H)
+	require.NoError(t, err)
+	assert.Equal(t, "market.btcusdt.kline.60min", ch)
+	ws.KlineCallback(func(kline *goex.Kline, period goex.KlinePeriod, c bool) {
+		assert.Equal(t, goex.KlinePeriod(goex.KLINE_PERIOD_1H), period)
+		klines = append(klines, kline)
+		closed = append(closed, c)
+	})
+
+	tick := func(id int64, close float64) []byte {
+		return []byte(fmt.Sprintf(`{"ch":"market.btcusdt.kline.60min","ts":1609459260000,"tick":{"id":%d,"open":28923.5,"close":%v,"low":28900,"high":28990,"amount":52.63,"vol":1523400.5,"count":310}}`, id, close))
+	}
+	require.NoError(t, ws.handle(tick(1609459200, 28951)))
+	require.NoError(t, ws.handle(tick(1609459200, 28960)))
+	require.NoError(t, ws.handle(tick(1609462800, 28970)))
+	require.NoError(t, ws.handle([]byte(`{"ch":"market.ethusdt.kline.60min","ts":1609459260000,"tick":{"id":1609459200}}`)))
+	assert.Equal(t, []bool{false, false, true, false}, closed)
+	assert.Equal(t, &goex.Kline{Pair: klines[0].Pair, Timestamp: 1609459200, Open: 28923.5, Close: 28951, Low: 28900, High: 28990, Vol: 52.63}, klines[0])
+	assert.Equal(t, goex.BTC_USDT.String(), klines[0].Pair.String())
+	assert.Equal(t, 28960.0, klines[2].Close, "the last update of the closed candle")
+}
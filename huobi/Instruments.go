@@ -0,0 +1,154 @@
+package huobi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+var hbdmContractTypes = map[string]string{
+	"this_week":    THIS_WEEK_CONTRACT,
+	"next_week":    NEXT_WEEK_CONTRACT,
+	"quarter":      QUARTER_CONTRACT,
+	"next_quarter": BI_QUARTER_CONTRACT,
+}
+
+func adaptSpotInstrumentStatus(state string) InstrumentStatus {
+	switch state {
+	case "online":
+		return INSTRUMENT_TRADING
+	case "pre-online":
+		return INSTRUMENT_PENDING
+	case "offline":
+		return INSTRUMENT_DELISTED
+	}
+	return INSTRUMENT_SUSPENDED
+}
+
+// adaptContractStatus maps contract_status: 0 delisted, 1 trading, 2 pending,
+// 3 suspended, 4 suspended listing, 5 settling, 6 delivering, 7 settled, 8 delivered.
+func adaptContractStatus(status int) InstrumentStatus {
+	switch status {
+	case 1:
+		return INSTRUMENT_TRADING
+	case 2:
+		return INSTRUMENT_PENDING
+	case 0, 8:
+		return INSTRUMENT_DELISTED
+	}
+	return INSTRUMENT_SUSPENDED
+}
+
+// GetInstrumentsInfo lists the spot symbols and registers them in Symbols.
+func (exchange *Exchange) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	respBody, err := HttpGet5(exchange.httpClient, exchange.baseUrl+"/v1/common/symbols", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Status  string `json:"status"`
+		ErrCode string `json:"err-code"`
+		ErrMsg  string `json:"err-msg"`
+		Data    []struct {
+			Symbol          string  `json:"symbol"`
+			BaseCurrency    string  `json:"base-currency"`
+			QuoteCurrency   string  `json:"quote-currency"`
+			PricePrecision  int32   `json:"price-precision"`
+			AmountPrecision int32   `json:"amount-precision"`
+			MinOrderAmt     Decimal `json:"min-order-amt"`
+			MinOrderValue   Decimal `json:"min-order-value"`
+			State           string  `json:"state"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(respBody, &response); err != nil {
+		return nil, err
+	}
+	if response.Status != "ok" {
+		return nil, spotErrorCodes.Adapt(http.StatusOK, response.ErrCode, response.ErrMsg)
+	}
+
+	instruments := make([]InstrumentInfo, 0, len(response.Data))
+	for _, sym := range response.Data {
+		instruments = append(instruments, InstrumentInfo{
+			Id:          sym.Symbol,
+			Pair:        NewCurrencyPair(NewCurrency(strings.ToUpper(sym.BaseCurrency), ""), NewCurrency(strings.ToUpper(sym.QuoteCurrency), "")),
+			Kind:        SPOT,
+			TickSize:    NewDecimal(1, sym.PricePrecision),
+			LotSize:     NewDecimal(1, sym.AmountPrecision),
+			MinAmount:   sym.MinOrderAmt,
+			MinNotional: sym.MinOrderValue,
+			Status:      adaptSpotInstrumentStatus(sym.State),
+		})
+	}
+	Symbols.RegisterInstruments(HUOBI_PRO, instruments)
+	return instruments, nil
+}
+
+type hbdmContractInfo struct {
+	Symbol         string  `json:"symbol"`
+	ContractCode   string  `json:"contract_code"`
+	ContractType   string  `json:"contract_type"` //futures only
+	ContractSize   Decimal `json:"contract_size"`
+	PriceTick      Decimal `json:"price_tick"`
+	DeliveryDate   string  `json:"delivery_date"` //futures only, 20210326
+	ContractStatus int     `json:"contract_status"`
+}
+
+func (c *hbdmContractInfo) instrument() InstrumentInfo {
+	ins := InstrumentInfo{
+		Id:                  c.ContractCode,
+		Pair:                NewCurrencyPair(NewCurrency(c.Symbol, ""), USD),
+		Kind:                SWAP,
+		ContractType:        SWAP_CONTRACT,
+		TickSize:            c.PriceTick,
+		LotSize:             NewDecimal(1, 0),
+		MinAmount:           NewDecimal(1, 0),
+		ContractVal:         c.ContractSize,
+		ContractValCurrency: USD,
+		Status:              adaptContractStatus(c.ContractStatus),
+	}
+	if c.ContractType != "" {
+		ins.Kind = FUTURE
+		ins.ContractType = hbdmContractTypes[c.ContractType]
+		if delivery, err := time.Parse("20060102", c.DeliveryDate); err == nil {
+			ins.Expiry = delivery.Add(8 * time.Hour) //16:00 in Beijing
+		}
+	}
+	return ins
+}
+
+func (dm *Hbdm) getContractInfos(path string) ([]InstrumentInfo, error) {
+	respBody, err := HttpGet5(dm.config.HttpClient, dm.config.Endpoint+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		BaseResponse
+		Data []hbdmContractInfo `json:"data"`
+	}
+	if err = json.Unmarshal(respBody, &response); err != nil {
+		return nil, err
+	}
+	if response.Status != "ok" {
+		return nil, adaptHbdmError(response.ErrCode, response.ErrMsg)
+	}
+
+	instruments := make([]InstrumentInfo, 0, len(response.Data))
+	for i := range response.Data {
+		instruments = append(instruments, response.Data[i].instrument())
+	}
+	return instruments, nil
+}
+
+func (dm *Hbdm) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	return dm.getContractInfos("/api/v1/contract_contract_info")
+}
+
+func (swap *HbdmSwap) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	return swap.base.getContractInfos(getSwapContractInfoApiPath)
+}
@@ -1,12 +1,13 @@
 package huobi
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"sort"
 	"strings"
 
 	"github.com/soulsplit/goex"
-	"github.com/soulsplit/goex/internal/logger"
 )
 
 func ParseDepthFromResponse(r DepthResponse) goex.Depth {
@@ -24,14 +25,18 @@ func ParseDepthFromResponse(r DepthResponse) goex.Depth {
 	return dep
 }
 
-func ParseCurrencyPairFromSpotWsCh(ch string) goex.CurrencyPair {
+// ParseCurrencyPairFromSpotWsCh resolves the symbol of a channel such as
+// market.btcusdt.detail with goex.Symbols.
+func ParseCurrencyPairFromSpotWsCh(ch string) (goex.CurrencyPair, error) {
 	meta := strings.Split(ch, ".")
 	if len(meta) < 2 {
-		logger.Errorf("parse error, ch=%s", ch)
-		return goex.UNKNOWN_PAIR
+		return goex.UNKNOWN_PAIR, goex.EX_ERR_SYMBOL_ERR.OriginErr("no symbol in ch " + ch)
 	}
+	return goex.Symbols.Resolve(goex.HUOBI_PRO, meta[1], guessSpotSymbol)
+}
 
-	currencyPairStr := meta[1]
+// guessSpotSymbol guesses the pair by the quote suffix until the symbols are loaded.
+func guessSpotSymbol(currencyPairStr string) goex.CurrencyPair {
 	if strings.HasSuffix(currencyPairStr, "usdt") {
 		currencyA := strings.TrimSuffix(currencyPairStr, "usdt")
 		return goex.NewCurrencyPair2(fmt.Sprintf("%s_usdt", currencyA))
@@ -64,3 +69,89 @@ func ParseCurrencyPairFromSpotWsCh(ch string) goex.CurrencyPair {
 
 	return goex.UNKNOWN_PAIR
 }
+
+var spotErrorCodes = goex.ErrorCodes{
+	"account-frozen-balance-insufficient-error": goex.EX_ERR_INSUFFICIENT_BALANCE,
+	"account-balance-insufficient-error":        goex.EX_ERR_INSUFFICIENT_BALANCE,
+	"insufficient-balance":                      goex.EX_ERR_INSUFFICIENT_BALANCE,
+	"order-accountbalance-error":                goex.EX_ERR_INSUFFICIENT_BALANCE,
+	"base-symbol-error":                         goex.EX_ERR_INVALID_CURRENCY_PAIR,
+	"base-symbol-trade-disabled":                goex.EX_ERR_INVALID_CURRENCY_PAIR,
+	"invalid-symbol":                            goex.EX_ERR_INVALID_CURRENCY_PAIR,
+	"base-record-invalid":                       goex.EX_ERR_NOT_FIND_ORDER,
+	"order-queryorder-invalid":                  goex.EX_ERR_NOT_FIND_ORDER,
+	"order-orderstate-error":                    goex.EX_ERR_ORDER_STATE,
+	"api-signature-not-valid":                   goex.EX_ERR_SIGN,
+	"api-signature-check-failed":                goex.EX_ERR_SIGN,
+	"login-required":                            goex.EX_ERR_AUTH,
+	"invalid-parameter":                         goex.EX_ERR_INVALID_PARAMETER,
+	"invalid-amount":                            goex.EX_ERR_INVALID_PARAMETER,
+	"order-limitorder-amount-min-error":         goex.EX_ERR_INVALID_PARAMETER,
+	"order-limitorder-amount-max-error":         goex.EX_ERR_INVALID_PARAMETER,
+	"order-orderprice-precision-error":          goex.EX_ERR_INVALID_PARAMETER,
+	"order-orderamount-precision-error":         goex.EX_ERR_INVALID_PARAMETER,
+	"order-value-min-error":                     goex.EX_ERR_INVALID_PARAMETER,
+	"base-system-error":                         goex.EX_ERR_SERVER,
+	"api-request-frequency-limit":               goex.EX_ERR_API_LIMIT,
+}
+
+var hbdmErrorCodes = goex.ErrorCodes{
+	"1000": goex.EX_ERR_SERVER,
+	"1001": goex.EX_ERR_MAINTENANCE,
+	"1002": goex.EX_ERR_SERVER,
+	"1004": goex.EX_ERR_SERVER,
+	"1014": goex.EX_ERR_INVALID_CURRENCY_PAIR,
+	"1030": goex.EX_ERR_INVALID_PARAMETER,
+	"1032": goex.EX_ERR_API_LIMIT,
+	"1040": goex.EX_ERR_INVALID_PARAMETER,
+	"1047": goex.EX_ERR_INSUFFICIENT_BALANCE,
+	"1048": goex.EX_ERR_INSUFFICIENT_BALANCE,
+	"1050": goex.EX_ERR_DUPLICATE_CLIENT_OID,
+	"1051": goex.EX_ERR_ORDER_STATE,
+	"1061": goex.EX_ERR_NOT_FIND_ORDER,
+	"1063": goex.EX_ERR_ORDER_STATE,
+}
+
+// adaptSpotError maps the err-code of a {"status":"error","err-code":"...","err-msg":"..."} response.
+func adaptSpotError(respmap map[string]interface{}) goex.ApiError {
+	errCode, _ := respmap["err-code"].(string)
+	errMsg, _ := respmap["err-msg"].(string)
+	if errMsg == "" {
+		errMsg = errCode
+	}
+	return spotErrorCodes.Adapt(http.StatusOK, errCode, errMsg)
+}
+
+// adaptHbdmError maps the err_code of a futures or swap response.
+func adaptHbdmError(errCode int, errMsg string) goex.ApiError {
+	return hbdmErrorCodes.Adapt(http.StatusOK, fmt.Sprint(errCode), fmt.Sprintf("%d:[%s]", errCode, errMsg))
+}
+
+// adaptResponseError maps the error of any response body: the err_code of the futures
+// and swap apis, the err-code of the spot and market apis or the code of the v2 apis.
+func adaptResponseError(body []byte) goex.ApiError {
+	var respmap map[string]interface{}
+	json.Unmarshal(body, &respmap)
+	return adaptMapError(respmap, string(body))
+}
+
+// adaptMapError is adaptResponseError of a decoded body, raw is the message if the body
+// has none.
+func adaptMapError(respmap map[string]interface{}, raw string) goex.ApiError {
+	if errCode, ok := respmap["err_code"].(float64); ok {
+		errMsg, _ := respmap["err_msg"].(string)
+		return adaptHbdmError(int(errCode), errMsg)
+	}
+	if _, ok := respmap["err-code"]; ok {
+		return adaptSpotError(respmap)
+	}
+	code := ""
+	if c, ok := respmap["code"].(float64); ok {
+		code = fmt.Sprint(c)
+	}
+	msg, _ := respmap["message"].(string)
+	if msg == "" {
+		msg = raw
+	}
+	return spotErrorCodes.Adapt(http.StatusOK, code, msg)
+}
@@ -0,0 +1,69 @@
+package huobi
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+// RateLimits are the spot limits, the private endpoints are counted per api key and
+// the public ones, market data and reference data, per ip.
+func RateLimits() []RateLimitRule {
+	return []RateLimitRule{
+		{Name: "private", Interval: time.Second, Limit: 10, Weight: spotWeight(spotPrivate)},
+		{Name: "reference", Interval: 10 * time.Second, Limit: 100, Weight: spotWeight(spotReference)},
+		{Name: "market", Path: "/market/", Interval: 10 * time.Second, Limit: 100},
+	}
+}
+
+const (
+	spotPrivate = iota
+	spotReference
+	spotMarket
+)
+
+// spotClass tells the class of a spot endpoint: /market is market data, /v1/common,
+// /v2/reference and /v2/market-status reference data, the rest of /v1 and /v2 private.
+func spotClass(path string) int {
+	switch {
+	case strings.HasPrefix(path, "/market/"):
+		return spotMarket
+	case strings.HasPrefix(path, "/v1/common/"), strings.HasPrefix(path, "/v2/reference/"),
+		path == "/v2/market-status":
+		return spotReference
+	}
+	return spotPrivate
+}
+
+func spotWeight(class int) func(req *http.Request) int {
+	return func(req *http.Request) int {
+		if spotClass(req.URL.Path) == class {
+			return 1
+		}
+		return 0
+	}
+}
+
+// HbdmRateLimits are the limits of the futures and coin margined swap api.
+func HbdmRateLimits() []RateLimitRule {
+	return []RateLimitRule{
+		{Name: "trade", Method: http.MethodPost, Interval: 3 * time.Second, Limit: 36, Weight: hbdmWeight(true)},
+		{Name: "read", Method: http.MethodPost, Interval: 3 * time.Second, Limit: 36, Weight: hbdmWeight(false)},
+		{Name: "market", Method: http.MethodGet, Interval: time.Second, Limit: 800},
+	}
+}
+
+// hbdmWeight counts the order and cancel requests as trade, the other private requests as read.
+func hbdmWeight(trade bool) func(req *http.Request) int {
+	return func(req *http.Request) int {
+		path := req.URL.Path
+		isTrade := strings.HasSuffix(path, "_order") || strings.HasSuffix(path, "_cancel") ||
+			strings.HasSuffix(path, "_batchorder") || strings.HasSuffix(path, "_cancelall")
+		if isTrade == trade {
+			return 1
+		}
+		return 0
+	}
+}
@@ -0,0 +1,22 @@
+package huobi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimits_spotClass(t *testing.T) {
+	assert.Equal(t, spotPrivate, spotClass("/v1/order/orders/place"))
+	assert.Equal(t, spotPrivate, spotClass("/v2/account/transfer"))
+	assert.Equal(t, spotReference, spotClass("/v1/common/symbols"))
+	assert.Equal(t, spotReference, spotClass("/v2/reference/currencies"))
+	assert.Equal(t, spotReference, spotClass("/v2/market-status"))
+	assert.Equal(t, spotMarket, spotClass("/market/depth"))
+
+	names := make(map[string]bool)
+	for _, l := range RateLimits() {
+		assert.False(t, names[l.Name], "%s is used twice", l.Name)
+		names[l.Name] = true
+	}
+}
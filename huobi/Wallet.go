@@ -1,6 +1,7 @@
 package huobi
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -21,14 +22,26 @@ func NewWallet(c *APIConfig) *Wallet {
 
 //获取钱包资产
 func (w *Wallet) GetAccount() (*Account, error) {
+	return w.GetAccountWithContext(context.Background())
+}
+
+func (w *Wallet) GetAccountWithContext(ctx context.Context) (*Account, error) {
 	return nil, errors.New("not implement")
 }
 
 func (w *Wallet) Withdrawal(param WithdrawParameter) (withdrawId string, err error) {
+	return w.WithdrawalWithContext(context.Background(), param)
+}
+
+func (w *Wallet) WithdrawalWithContext(ctx context.Context, param WithdrawParameter) (withdrawId string, err error) {
 	return "", errors.New("not implement")
 }
 
 func (w *Wallet) Transfer(param TransferParameter) error {
+	return w.TransferWithContext(context.Background(), param)
+}
+
+func (w *Wallet) TransferWithContext(ctx context.Context, param TransferParameter) error {
 	if param.From == SUB_ACCOUNT || param.To == SUB_ACCOUNT ||
 		param.From == SPOT_MARGIN || param.To == SPOT_MARGIN {
 		return errors.New("not implements")
@@ -86,7 +99,7 @@ func (w *Wallet) Transfer(param TransferParameter) error {
 	w.pro.buildPostForm("POST", path, &httpParam)
 
 	postJsonParam, _ := ValuesToJson(httpParam)
-	responseBody, err := HttpPostForm3(w.pro.httpClient,
+	responseBody, err := HttpPostForm3WithContext(ctx, w.pro.httpClient,
 		fmt.Sprintf("%s%s?%s", w.pro.baseUrl, path, httpParam.Encode()),
 		string(postJsonParam),
 		map[string]string{"Content-Type": "application/json", "Accept-Language": "zh-cn"})
@@ -113,13 +126,21 @@ func (w *Wallet) Transfer(param TransferParameter) error {
 		return nil
 	}
 
-	return errors.New(string(responseBody))
+	return adaptResponseError(responseBody)
 }
 
 func (w *Wallet) GetWithDrawHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	return w.GetWithDrawHistoryWithContext(context.Background(), currency)
+}
+
+func (w *Wallet) GetWithDrawHistoryWithContext(ctx context.Context, currency *Currency) ([]DepositWithdrawHistory, error) {
 	return nil, errors.New("not implement")
 }
 
 func (w *Wallet) GetDepositHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	return w.GetDepositHistoryWithContext(context.Background(), currency)
+}
+
+func (w *Wallet) GetDepositHistoryWithContext(ctx context.Context, currency *Currency) ([]DepositWithdrawHistory, error) {
 	return nil, errors.New("not implement")
 }
@@ -0,0 +1,86 @@
+package huobi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	. "github.com/soulsplit/goex"
+)
+
+// wsKlines keeps the periods of the subscribed kline topics. The topics only push
+// the forming candle, it is closed by the first update of the next one.
+type wsKlines struct {
+	closer  KlineCloser
+	lock    sync.Mutex
+	periods map[string]KlinePeriod //by topic
+}
+
+func klineTopic(symbol string, period KlinePeriod) (string, error) {
+	p, ok := _INERNAL_KLINE_PERIOD_CONVERTER[period]
+	if !ok {
+		return "", fmt.Errorf("unsupported kline period %d in huobi", period)
+	}
+	return fmt.Sprintf("market.%s.kline.%s", symbol, p), nil
+}
+
+// topic registers the kline topic of the symbol.
+func (k *wsKlines) topic(symbol string, period KlinePeriod) (string, error) {
+	ch, err := klineTopic(symbol, period)
+	if err != nil {
+		return "", err
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.periods == nil {
+		k.periods = make(map[string]KlinePeriod, 4)
+	}
+	k.periods[ch] = period
+	return ch, nil
+}
+
+// remove forgets the topic of an unsubscribe.
+func (k *wsKlines) remove(ch string) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	delete(k.periods, ch)
+}
+
+// handle parses the tick of the kline topic ch, Vol is the vol of the tick and Vol2 the amount.
+func (k *wsKlines) handle(ch string, tick json.RawMessage, pair CurrencyPair, contractType string,
+	call func(kline *FutureKline, period KlinePeriod, closed bool)) error {
+	k.lock.Lock()
+	period, ok := k.periods[ch]
+	k.lock.Unlock()
+	if !ok {
+		return nil
+	}
+
+	var r DetailResponse
+	if err := json.Unmarshal(tick, &r); err != nil {
+		return err
+	}
+
+	kline := &FutureKline{
+		Kline: &Kline{
+			Pair:      pair,
+			Timestamp: r.Id,
+			Open:      r.Open,
+			Close:     r.Close,
+			High:      r.High,
+			Low:       r.Low,
+			Vol:       r.Vol},
+		Vol2:         r.Amount,
+		ContractType: contractType,
+	}
+	closed, stale := k.closer.Next(ch, kline)
+	if stale {
+		return nil
+	}
+	if closed != nil {
+		call(closed, period, true)
+	}
+	call(kline, period, false)
+	return nil
+}
@@ -0,0 +1,240 @@
+package kline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+// RangeQuery is how an adapter takes a time range in the OptionalParameter of
+// GetKlineRecords.
+type RangeQuery struct {
+	MaxSize int //klines per request
+	// Params returns the parameters selecting the klines opened in [since, until].
+	Params func(since, until time.Time) OptionalParameter
+}
+
+func millisRange(sinceKey, untilKey string) func(since, until time.Time) OptionalParameter {
+	return func(since, until time.Time) OptionalParameter {
+		return OptionalParameter{}.
+			Optional(sinceKey, since.UnixNano()/int64(time.Millisecond)).
+			Optional(untilKey, until.UnixNano()/int64(time.Millisecond))
+	}
+}
+
+func isoRange(sinceKey, untilKey string) func(since, until time.Time) OptionalParameter {
+	return func(since, until time.Time) OptionalParameter {
+		return OptionalParameter{}.
+			Optional(sinceKey, since.UTC().Format("2006-01-02T15:04:05.000Z")).
+			Optional(untilKey, until.UTC().Format("2006-01-02T15:04:05.000Z"))
+	}
+}
+
+var (
+	rangeQueriesLock sync.RWMutex
+	rangeQueries     = map[string]RangeQuery{
+		BINANCE:      {MaxSize: 1000, Params: millisRange("startTime", "endTime")},
+		BINANCE_SWAP: {MaxSize: 1000, Params: millisRange("startTime", "endTime")},
+		OKEX:         {MaxSize: 200, Params: isoRange("start", "end")},
+		OKEX_FUTURE:  {MaxSize: 200, Params: isoRange("start", "end")},
+		OKEX_SWAP:    {MaxSize: 200, Params: isoRange("start", "end")},
+	}
+)
+
+// RegisterRangeQuery sets the RangeQuery of an exchange, by GetExchangeName.
+func RegisterRangeQuery(exName string, query RangeQuery) {
+	rangeQueriesLock.Lock()
+	defer rangeQueriesLock.Unlock()
+	rangeQueries[exName] = query
+}
+
+func GetRangeQuery(exName string) (RangeQuery, error) {
+	rangeQueriesLock.RLock()
+	defer rangeQueriesLock.RUnlock()
+	query, ok := rangeQueries[exName]
+	if !ok {
+		return query, fmt.Errorf("%s: kline range queries are not supported", exName)
+	}
+	return query, nil
+}
+
+type fetchFunc func(ctx context.Context, size int, opt OptionalParameter) ([]Kline, error)
+
+// Downloader pulls the klines of a period in pages of PageSize, each page asking
+// the time range of PageSize candles, so it walks through years of history with
+// the RangeQuery of the adapter. The rate limits are kept by the RateLimiter of
+// the http client and the pages are retried with Retry.
+type Downloader struct {
+	Period   KlinePeriod
+	PageSize int
+	Backward bool          //from until back to since
+	Retry    *RetryPolicy  //nil to fail on the first error
+	Interval time.Duration //pause between two requests
+	// MaxEmptyPages stops the backward walk after this many empty pages in a row, taken
+	// as the time before the listing. A shorter gap, such as an exchange outage, is
+	// walked through. 0 walks back to since, default 3.
+	MaxEmptyPages int
+
+	query       RangeQuery
+	fetch       fetchFunc
+	lastRequest time.Time
+	now         func() time.Time
+}
+
+func newDownloader(exName string, period KlinePeriod, fetch fetchFunc) (*Downloader, error) {
+	if _, ok := periodSeconds[period]; !ok {
+		return nil, fmt.Errorf("%w: %d has no fixed length", ErrPeriod, period)
+	}
+	query, err := GetRangeQuery(exName)
+	if err != nil {
+		return nil, err
+	}
+	return &Downloader{
+		Period:        period,
+		PageSize:      query.MaxSize,
+		Retry:         DefaultRetryPolicy(),
+		MaxEmptyPages: 3,
+		query:         query,
+		fetch:         fetch,
+		now:           time.Now,
+	}, nil
+}
+
+// NewDownloader downloads the klines of a spot pair.
+func NewDownloader(api API, pair CurrencyPair, period KlinePeriod) (*Downloader, error) {
+	return newDownloader(api.GetExchangeName(), period, func(ctx context.Context, size int, opt OptionalParameter) ([]Kline, error) {
+		if ctxApi, ok := api.(APIWithContext); ok {
+			return ctxApi.GetKlineRecordsWithContext(ctx, pair, period, size, opt)
+		}
+		return api.GetKlineRecords(pair, period, size, opt)
+	})
+}
+
+// NewFutureDownloader downloads the klines of a contract, Vol2 is not kept.
+func NewFutureDownloader(api FutureRestAPI, contractType string, pair CurrencyPair, period KlinePeriod) (*Downloader, error) {
+	return newDownloader(api.GetExchangeName(), period, func(ctx context.Context, size int, opt OptionalParameter) ([]Kline, error) {
+		var (
+			futureKlines []FutureKline
+			err          error
+		)
+		if ctxApi, ok := api.(FutureRestAPIWithContext); ok {
+			futureKlines, err = ctxApi.GetKlineRecordsWithContext(ctx, contractType, pair, period, size, opt)
+		} else {
+			futureKlines, err = api.GetKlineRecords(contractType, pair, period, size, opt)
+		}
+		if err != nil {
+			return nil, err
+		}
+		klines := make([]Kline, 0, len(futureKlines))
+		for _, k := range futureKlines {
+			if k.Kline != nil {
+				klines = append(klines, *k.Kline)
+			}
+		}
+		return klines, nil
+	})
+}
+
+// Download calls fn with the closed klines opened in [since, until), page by page
+// in the order of the walk, each page sorted by time. A kline is never passed twice.
+func (d *Downloader) Download(ctx context.Context, since, until time.Time, fn func([]Kline) error) error {
+	if d.PageSize <= 0 {
+		return errors.New("page size must be positive")
+	}
+	step := int64(d.PageSize) * periodSeconds[d.Period]
+	first := Start(since.Unix(), d.Period)
+	if first < since.Unix() {
+		first = Next(first, d.Period)
+	}
+	limit := Start(d.now().Unix(), d.Period) //the forming kline is not downloaded
+	if u := until.Unix(); u < limit {
+		limit = u
+	}
+
+	if d.Backward {
+		empty := 0
+		for to := limit; to > first; {
+			from := to - step
+			if from < first {
+				from = first
+			}
+			page, err := d.page(ctx, from, to)
+			if err != nil {
+				return err
+			}
+			if len(page) == 0 {
+				empty++
+				if d.MaxEmptyPages > 0 && empty >= d.MaxEmptyPages { //before the listing
+					return nil
+				}
+			} else {
+				empty = 0
+				if err = fn(page); err != nil {
+					return err
+				}
+			}
+			to = from
+		}
+		return nil
+	}
+
+	for from := first; from < limit; {
+		to := from + step
+		if to > limit {
+			to = limit
+		}
+		page, err := d.page(ctx, from, to)
+		if err != nil {
+			return err
+		}
+		if len(page) > 0 {
+			if err = fn(page); err != nil {
+				return err
+			}
+		}
+		from = to
+	}
+	return nil
+}
+
+// page fetches the klines opened in [from, to).
+func (d *Downloader) page(ctx context.Context, from, to int64) ([]Kline, error) {
+	if wait := d.lastRequest.Add(d.Interval).Sub(d.now()); d.Interval > 0 && wait > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	d.lastRequest = d.now()
+
+	opt := d.query.Params(time.Unix(from, 0), time.Unix(to-1, 0))
+	var klines []Kline
+	call := func() error {
+		var err error
+		klines, err = d.fetch(ctx, d.PageSize, opt)
+		return err
+	}
+
+	var err error
+	if d.Retry != nil {
+		err = d.Retry.Do(ctx, "GetKlineRecords", true, call)
+	} else {
+		err = call()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var page []Kline
+	for _, k := range Normalize(klines) {
+		if k.Timestamp >= from && k.Timestamp < to { //the pages overlap on some exchanges
+			page = append(page, k)
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,146 @@
+package kline
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRangeAPI has a 1min kline every minute from listed, it answers startTime and
+// endTime in milliseconds with one kline before the range, as the pages of some
+// exchanges overlap.
+type fakeRangeAPI struct {
+	API
+	listed   int64
+	gap      [2]int64 //no klines in [gap[0], gap[1])
+	requests int
+	failAt   int
+}
+
+func (f *fakeRangeAPI) GetExchangeName() string { return "fake.range" }
+
+func (f *fakeRangeAPI) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
+	f.requests++
+	if f.failAt > 0 && f.requests == f.failAt {
+		return nil, errors.New("connection reset")
+	}
+	opt := optional[0]
+	since, until := opt.GetInt64("startTime")/1000, opt.GetInt64("endTime")/1000
+
+	var klines []Kline
+	for ts := since - 60; ts <= until && len(klines) <= size; ts += 60 {
+		if ts >= f.listed && (ts < f.gap[0] || ts >= f.gap[1]) {
+			klines = append([]Kline{{Pair: currency, Timestamp: ts * 1000, Open: float64(ts), Close: float64(ts), Vol: 1}}, klines...)
+		}
+	}
+	return klines, nil
+}
+
+func newTestDownloader(t *testing.T, api *fakeRangeAPI) *Downloader {
+	RegisterRangeQuery(api.GetExchangeName(), RangeQuery{MaxSize: 10, Params: millisRange("startTime", "endTime")})
+	d, err := NewDownloader(api, BTC_USDT, KLINE_PERIOD_1MIN)
+	require.NoError(t, err)
+	d.Retry = nil
+	d.now = func() time.Time { return time.Unix(t0+3600+30, 0) }
+	return d
+}
+
+func TestDownloader_Download(t *testing.T) {
+	api := &fakeRangeAPI{listed: t0 + 600}
+	d := newTestDownloader(t, api)
+
+	var klines []Kline
+	err := d.Download(context.Background(), time.Unix(t0, 0), time.Now(), func(page []Kline) error {
+		klines = append(klines, page...)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, klines, 50, "listed at minute 10, the kline of minute 60 is forming")
+	assert.Equal(t, 6, api.requests)
+	for i, k := range klines {
+		assert.Equal(t, t0+600+int64(i)*60, k.Timestamp)
+	}
+
+	api.requests = 0
+	d.Backward = true
+	klines = nil
+	err = d.Download(context.Background(), time.Unix(t0, 0), time.Unix(t0+1800, 0), func(page []Kline) error {
+		klines = append(page, klines...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, klines, 20)
+	assert.Equal(t, t0+600, klines[0].Timestamp)
+	assert.Equal(t, 3, api.requests, "the last page is empty")
+
+	api = &fakeRangeAPI{listed: t0, gap: [2]int64{t0 + 1200, t0 + 2400}}
+	d = newTestDownloader(t, api)
+	d.Backward = true
+	klines = nil
+	err = d.Download(context.Background(), time.Unix(t0, 0), time.Now(), func(page []Kline) error {
+		klines = append(page, klines...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, klines, 40, "walks through the outage")
+	assert.Equal(t, t0, klines[0].Timestamp)
+	assert.Equal(t, 6, api.requests)
+
+	api = &fakeRangeAPI{listed: t0 + 3000}
+	d = newTestDownloader(t, api)
+	d.Backward = true
+	klines = nil
+	err = d.Download(context.Background(), time.Unix(t0, 0), time.Now(), func(page []Kline) error {
+		klines = append(page, klines...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, klines, 10)
+	assert.Equal(t, 4, api.requests, "stops after 3 empty pages")
+}
+
+func TestDownloader_DownloadFile(t *testing.T) {
+	for _, format := range []Format{CSV, Binary} {
+		path := filepath.Join(t.TempDir(), "klines")
+		api := &fakeRangeAPI{listed: t0, failAt: 3}
+		d := newTestDownloader(t, api)
+
+		err := d.DownloadFile(context.Background(), path, format, time.Unix(t0, 0), time.Unix(t0+3600, 0))
+		require.Error(t, err)
+		klines, err := ReadFile(path, format)
+		require.NoError(t, err)
+		assert.Len(t, klines, 20)
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		require.NoError(t, err)
+		f.Write([]byte("160")) //an interrupted write
+		f.Close()
+
+		require.NoError(t, d.DownloadFile(context.Background(), path, format, time.Unix(t0, 0), time.Unix(t0+3600, 0)))
+		klines, err = ReadFile(path, format)
+		require.NoError(t, err)
+		require.Len(t, klines, 60)
+		for i, k := range klines {
+			assert.Equal(t, t0+int64(i)*60, k.Timestamp)
+			assert.Equal(t, float64(k.Timestamp), k.Close)
+		}
+
+		api.requests = 0
+		require.NoError(t, d.DownloadFile(context.Background(), path, format, time.Unix(t0, 0), time.Unix(t0+3600, 0)))
+		assert.Equal(t, 0, api.requests, "nothing left")
+	}
+}
+
+func TestNewDownloader_Unsupported(t *testing.T) {
+	_, err := GetRangeQuery(KRAKEN)
+	assert.Error(t, err)
+	_, err = NewDownloader(&fakeRangeAPI{}, BTC_USDT, KLINE_PERIOD_1MONTH)
+	assert.True(t, errors.Is(err, ErrPeriod))
+}
@@ -0,0 +1,255 @@
+package kline
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+type Format int
+
+const (
+	CSV    Format = iota //timestamp,open,high,low,close,vol with a header line
+	Binary               //a magic header then records of int64 timestamp and 5 float64, little endian
+)
+
+const binaryRecordSize = 48
+
+var (
+	binaryMagic = []byte("GOEXKL1\n")
+	csvHeader   = []string{"timestamp", "open", "high", "low", "close", "vol"}
+)
+
+// file is a kline file opened for append, the klines it has cover [first, last].
+type file struct {
+	f           *os.File
+	format      Format
+	first, last int64
+	count       int
+}
+
+// openFile opens or creates a kline file. A record cut by an interrupted write is
+// dropped, the file is truncated after the last whole one.
+func openFile(path string, format Format) (*file, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	kf := &file{f: f, format: format}
+	if err = kf.load(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return kf, nil
+}
+
+func (kf *file) load() error {
+	data, err := ioutil.ReadAll(kf.f)
+	if err != nil {
+		return err
+	}
+
+	var (
+		klines []Kline
+		size   int
+	)
+	switch kf.format {
+	case CSV:
+		klines, size, err = decodeCSV(data)
+	case Binary:
+		klines, size, err = decodeBinary(data)
+	default:
+		err = errors.New("unknown kline file format")
+	}
+	if err != nil {
+		return err
+	}
+
+	if size < len(data) {
+		if err = kf.f.Truncate(int64(size)); err != nil {
+			return err
+		}
+	}
+	if _, err = kf.f.Seek(int64(size), io.SeekStart); err != nil {
+		return err
+	}
+	if size == 0 {
+		if err = kf.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	for i, k := range klines {
+		if i == 0 || k.Timestamp < kf.first {
+			kf.first = k.Timestamp
+		}
+		if i == 0 || k.Timestamp > kf.last {
+			kf.last = k.Timestamp
+		}
+	}
+	kf.count = len(klines)
+	return nil
+}
+
+func (kf *file) writeHeader() error {
+	if kf.format == Binary {
+		_, err := kf.f.Write(binaryMagic)
+		return err
+	}
+	w := csv.NewWriter(kf.f)
+	w.Write(csvHeader)
+	w.Flush()
+	return w.Error()
+}
+
+// write appends the klines outside of [first, last] and syncs the file, so an
+// interruption loses at most the page being written.
+func (kf *file) write(klines []Kline) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for _, k := range klines {
+		if kf.count > 0 && k.Timestamp >= kf.first && k.Timestamp <= kf.last {
+			continue
+		}
+		if kf.format == Binary {
+			var rec [binaryRecordSize]byte
+			binary.LittleEndian.PutUint64(rec[0:], uint64(k.Timestamp))
+			for i, v := range []float64{k.Open, k.High, k.Low, k.Close, k.Vol} {
+				binary.LittleEndian.PutUint64(rec[8+8*i:], math.Float64bits(v))
+			}
+			buf.Write(rec[:])
+		} else {
+			w.Write([]string{strconv.FormatInt(k.Timestamp, 10), formatFloat(k.Open), formatFloat(k.High),
+				formatFloat(k.Low), formatFloat(k.Close), formatFloat(k.Vol)})
+		}
+		if kf.count == 0 || k.Timestamp < kf.first {
+			kf.first = k.Timestamp
+		}
+		if kf.count == 0 || k.Timestamp > kf.last {
+			kf.last = k.Timestamp
+		}
+		kf.count++
+	}
+	w.Flush()
+	if buf.Len() == 0 {
+		return nil
+	}
+	if _, err := kf.f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return kf.f.Sync()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// decodeCSV returns the klines and the length of the data up to the last whole line.
+func decodeCSV(data []byte) ([]Kline, int, error) {
+	size := bytes.LastIndexByte(data, '\n') + 1
+	r := csv.NewReader(bytes.NewReader(data[:size]))
+	r.FieldsPerRecord = len(csvHeader)
+
+	var klines []Kline
+	for line := 0; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if line == 0 && rec[0] == csvHeader[0] {
+			continue
+		}
+		k := Kline{}
+		if k.Timestamp, err = strconv.ParseInt(rec[0], 10, 64); err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", line+1, err)
+		}
+		for i, v := range []*float64{&k.Open, &k.High, &k.Low, &k.Close, &k.Vol} {
+			if *v, err = strconv.ParseFloat(rec[i+1], 64); err != nil {
+				return nil, 0, fmt.Errorf("line %d: %w", line+1, err)
+			}
+		}
+		klines = append(klines, k)
+	}
+	return klines, size, nil
+}
+
+// decodeBinary returns the klines and the length of the data up to the last whole record.
+func decodeBinary(data []byte) ([]Kline, int, error) {
+	if len(data) < len(binaryMagic) {
+		return nil, 0, nil
+	}
+	if !bytes.Equal(data[:len(binaryMagic)], binaryMagic) {
+		return nil, 0, errors.New("not a kline file")
+	}
+
+	records := data[len(binaryMagic):]
+	n := len(records) / binaryRecordSize
+	klines := make([]Kline, n)
+	for i := range klines {
+		rec := records[i*binaryRecordSize:]
+		k := &klines[i]
+		k.Timestamp = int64(binary.LittleEndian.Uint64(rec))
+		for j, v := range []*float64{&k.Open, &k.High, &k.Low, &k.Close, &k.Vol} {
+			*v = math.Float64frombits(binary.LittleEndian.Uint64(rec[8+8*j:]))
+		}
+	}
+	return klines, len(binaryMagic) + n*binaryRecordSize, nil
+}
+
+// DownloadFile downloads the klines opened in [since, until) to a file, appending to
+// what it has. A forward download resumes after the last kline of the file and a
+// backward one before the first, so a download that was interrupted is run again
+// with the same arguments. The file is in the order of the walk, ReadFile sorts it.
+func (d *Downloader) DownloadFile(ctx context.Context, path string, format Format, since, until time.Time) error {
+	kf, err := openFile(path, format)
+	if err != nil {
+		return err
+	}
+	defer kf.f.Close()
+
+	if kf.count > 0 {
+		if d.Backward {
+			if first := time.Unix(kf.first, 0); first.Before(until) {
+				until = first
+			}
+		} else if next := time.Unix(Next(kf.last, d.Period), 0); next.After(since) {
+			since = next
+		}
+	}
+	return d.Download(ctx, since, until, kf.write)
+}
+
+// ReadFile reads a kline file, the klines are sorted by time.
+func ReadFile(path string, format Format) ([]Kline, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var klines []Kline
+	switch format {
+	case CSV:
+		klines, _, err = decodeCSV(data)
+	case Binary:
+		klines, _, err = decodeBinary(data)
+	default:
+		err = errors.New("unknown kline file format")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Normalize(klines), nil
+}
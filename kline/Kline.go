@@ -0,0 +1,315 @@
+// Package kline resamples, gap fills and normalizes the klines of the adapters.
+// The timestamps are unix seconds, the open time of the candle, and the buckets
+// are aligned in UTC: to the epoch for the periods up to 3 days, to monday for
+// the weeks and to the calendar for months and years.
+package kline
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+var periodSeconds = map[KlinePeriod]int64{
+	KLINE_PERIOD_1MIN:  60,
+	KLINE_PERIOD_3MIN:  180,
+	KLINE_PERIOD_5MIN:  300,
+	KLINE_PERIOD_15MIN: 900,
+	KLINE_PERIOD_30MIN: 1800,
+	KLINE_PERIOD_60MIN: 3600,
+	KLINE_PERIOD_1H:    3600,
+	KLINE_PERIOD_2H:    7200,
+	KLINE_PERIOD_3H:    10800,
+	KLINE_PERIOD_4H:    14400,
+	KLINE_PERIOD_6H:    21600,
+	KLINE_PERIOD_8H:    28800,
+	KLINE_PERIOD_12H:   43200,
+	KLINE_PERIOD_1DAY:  86400,
+	KLINE_PERIOD_3DAY:  259200,
+	KLINE_PERIOD_1WEEK: 604800,
+}
+
+const mondayOffset = 4 * 86400 //1970-01-01 is a thursday
+
+var ErrPeriod = errors.New("unsupported kline period")
+
+// PeriodSeconds returns the length of a fixed period, false for months and years.
+func PeriodSeconds(period KlinePeriod) (int64, bool) {
+	secs, ok := periodSeconds[period]
+	return secs, ok
+}
+
+func validPeriod(period KlinePeriod) bool {
+	_, ok := periodSeconds[period]
+	return ok || period == KLINE_PERIOD_1MONTH || period == KLINE_PERIOD_1YEAR
+}
+
+// Start returns the open time of the candle of period containing ts, in seconds.
+func Start(ts int64, period KlinePeriod) int64 {
+	switch period {
+	case KLINE_PERIOD_1WEEK:
+		return floorDiv(ts-mondayOffset, 604800)*604800 + mondayOffset
+	case KLINE_PERIOD_1MONTH:
+		t := time.Unix(ts, 0).UTC()
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).Unix()
+	case KLINE_PERIOD_1YEAR:
+		t := time.Unix(ts, 0).UTC()
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	}
+	secs := periodSeconds[period]
+	return floorDiv(ts, secs) * secs
+}
+
+// Next returns the open time of the candle after the one opened at start.
+func Next(start int64, period KlinePeriod) int64 {
+	switch period {
+	case KLINE_PERIOD_1MONTH:
+		return time.Unix(start, 0).UTC().AddDate(0, 1, 0).Unix()
+	case KLINE_PERIOD_1YEAR:
+		return time.Unix(start, 0).UTC().AddDate(1, 0, 0).Unix()
+	}
+	return start + periodSeconds[period]
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b < 0 {
+		q--
+	}
+	return q
+}
+
+// CanResample tells if the candles of from tile the ones of to: 5min to 15min or
+// 1day to 1month can, 3min to 5min or 1week to 1month can't.
+func CanResample(from, to KlinePeriod) bool {
+	if !validPeriod(from) || !validPeriod(to) {
+		return false
+	}
+	fromSecs, fixedFrom := periodSeconds[from]
+	toSecs, fixedTo := periodSeconds[to]
+	switch {
+	case fixedFrom && fixedTo:
+		if toSecs < fromSecs || toSecs%fromSecs != 0 {
+			return false
+		}
+		return to != KLINE_PERIOD_1WEEK || 86400%fromSecs == 0
+	case fixedFrom:
+		return 86400%fromSecs == 0
+	case fixedTo:
+		return false
+	}
+	return from == to || from == KLINE_PERIOD_1MONTH
+}
+
+// TimestampToSeconds converts a timestamp in seconds, milliseconds, microseconds or
+// nanoseconds to seconds, the unit is told by the magnitude.
+func TimestampToSeconds(ts int64) int64 {
+	abs := ts
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs >= 1e17:
+		return ts / 1e9
+	case abs >= 1e14:
+		return ts / 1e6
+	case abs >= 1e11:
+		return ts / 1e3
+	}
+	return ts
+}
+
+// Normalize returns the klines sorted by time with the timestamps in seconds, for
+// a candle listed twice the last one is kept.
+func Normalize(klines []Kline) []Kline {
+	out := make([]Kline, len(klines))
+	for i, k := range klines {
+		k.Timestamp = TimestampToSeconds(k.Timestamp)
+		out[i] = k
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp < out[j].Timestamp })
+
+	n := 0
+	for i := range out {
+		if n > 0 && out[n-1].Timestamp == out[i].Timestamp {
+			out[n-1] = out[i]
+			continue
+		}
+		out[n] = out[i]
+		n++
+	}
+	return out[:n]
+}
+
+type candle struct {
+	Kline
+	vol2 float64
+}
+
+func (c *candle) merge(k *candle) {
+	if k.High > c.High {
+		c.High = k.High
+	}
+	if k.Low < c.Low {
+		c.Low = k.Low
+	}
+	c.Close = k.Close
+	c.Vol += k.Vol
+	c.vol2 += k.vol2
+}
+
+func resample(candles []candle, from, to KlinePeriod) ([]candle, error) {
+	if !CanResample(from, to) {
+		return nil, fmt.Errorf("%w: can't resample %d to %d", ErrPeriod, from, to)
+	}
+
+	var out []candle
+	for _, c := range candles {
+		start := Start(c.Timestamp, to)
+		if n := len(out); n > 0 && out[n-1].Timestamp == start {
+			out[n-1].merge(&c)
+			continue
+		}
+		c.Timestamp = start
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func fillGaps(candles []candle, period KlinePeriod) ([]candle, error) {
+	if !validPeriod(period) {
+		return nil, ErrPeriod
+	}
+	if len(candles) == 0 {
+		return candles, nil
+	}
+
+	out := make([]candle, 0, len(candles))
+	for i, c := range candles {
+		if i > 0 {
+			prev := out[len(out)-1]
+			for ts := Next(prev.Timestamp, period); ts < c.Timestamp; ts = Next(ts, period) {
+				out = append(out, candle{Kline: Kline{Pair: prev.Pair, Timestamp: ts,
+					Open: prev.Close, Close: prev.Close, High: prev.Close, Low: prev.Close}})
+			}
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func fromKlines(klines []Kline) []candle {
+	candles := make([]candle, 0, len(klines))
+	for _, k := range Normalize(klines) {
+		candles = append(candles, candle{Kline: k})
+	}
+	return candles
+}
+
+func toKlines(candles []candle) []Kline {
+	klines := make([]Kline, len(candles))
+	for i := range candles {
+		klines[i] = candles[i].Kline
+	}
+	return klines
+}
+
+func fromFutureKlines(klines []FutureKline) []candle {
+	plain := make([]Kline, 0, len(klines))
+	vol2 := make(map[int64]float64, len(klines))
+	for _, k := range klines {
+		if k.Kline == nil {
+			continue
+		}
+		kline := *k.Kline
+		kline.Timestamp = TimestampToSeconds(kline.Timestamp)
+		plain = append(plain, kline)
+		vol2[kline.Timestamp] = k.Vol2
+	}
+
+	candles := fromKlines(plain)
+	for i := range candles {
+		candles[i].vol2 = vol2[candles[i].Timestamp]
+	}
+	return candles
+}
+
+func toFutureKlines(candles []candle) []FutureKline {
+	klines := make([]FutureKline, len(candles))
+	for i := range candles {
+		k := candles[i].Kline
+		klines[i] = FutureKline{Kline: &k, Vol2: candles[i].vol2}
+	}
+	return klines
+}
+
+// Resample aggregates the klines of period from into candles of the coarser period
+// to: the open of the first, the close of the last, the highest high, the lowest
+// low and the sum of the volumes. The input is normalized first, the first and
+// last candles are partial if the klines don't cover them.
+func Resample(klines []Kline, from, to KlinePeriod) ([]Kline, error) {
+	candles, err := resample(fromKlines(klines), from, to)
+	if err != nil {
+		return nil, err
+	}
+	return toKlines(candles), nil
+}
+
+// ResampleFuture is Resample for the futures klines, Vol2 is summed too.
+func ResampleFuture(klines []FutureKline, from, to KlinePeriod) ([]FutureKline, error) {
+	candles, err := resample(fromFutureKlines(klines), from, to)
+	if err != nil {
+		return nil, err
+	}
+	return toFutureKlines(candles), nil
+}
+
+// FillGaps inserts a flat candle at the close of the previous one, with no volume,
+// for every missing candle of period. The input is normalized first.
+func FillGaps(klines []Kline, period KlinePeriod) ([]Kline, error) {
+	candles, err := fillGaps(fromKlines(klines), period)
+	if err != nil {
+		return nil, err
+	}
+	return toKlines(candles), nil
+}
+
+// FillFutureGaps is FillGaps for the futures klines.
+func FillFutureGaps(klines []FutureKline, period KlinePeriod) ([]FutureKline, error) {
+	candles, err := fillGaps(fromFutureKlines(klines), period)
+	if err != nil {
+		return nil, err
+	}
+	return toFutureKlines(candles), nil
+}
+
+// GetKlineRecords returns size klines of period built from the klines of the finer
+// native period of the exchange, for the periods an adapter doesn't support.
+// Only the fixed periods can be fetched this way.
+func GetKlineRecords(api API, pair CurrencyPair, period, native KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
+	if period == native {
+		return api.GetKlineRecords(pair, period, size, optional...)
+	}
+	secs, ok := periodSeconds[period]
+	nativeSecs, nativeOk := periodSeconds[native]
+	if !ok || !nativeOk || !CanResample(native, period) {
+		return nil, fmt.Errorf("%w: can't build %d from %d", ErrPeriod, period, native)
+	}
+
+	ratio := int(secs / nativeSecs)
+	klines, err := api.GetKlineRecords(pair, native, (size+1)*ratio, optional...)
+	if err != nil {
+		return nil, err
+	}
+	klines, err = Resample(klines, native, period)
+	if err != nil {
+		return nil, err
+	}
+	if len(klines) > size {
+		klines = klines[len(klines)-size:]
+	}
+	return klines, nil
+}
@@ -0,0 +1,139 @@
+package kline
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var t0 = time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC).Unix() //a monday
+
+func minuteKlines(n int, unit int64) []Kline {
+	klines := make([]Kline, n)
+	for i := range klines {
+		price := float64(100 + i)
+		klines[i] = Kline{Pair: BTC_USDT, Timestamp: (t0 + int64(i)*60) * unit,
+			Open: price, Close: price + 0.5, High: price + 1, Low: price - 1, Vol: 1}
+	}
+	return klines
+}
+
+func TestNormalize(t *testing.T) {
+	klines := minuteKlines(3, 1000)
+	klines[0], klines[2] = klines[2], klines[0]
+	klines = append(klines, Kline{Timestamp: t0 + 60, Close: 1})
+
+	out := Normalize(klines)
+	require.Len(t, out, 3)
+	assert.Equal(t, t0, out[0].Timestamp)
+	assert.Equal(t, t0+60, out[1].Timestamp)
+	assert.Equal(t, float64(1), out[1].Close, "the last one is kept")
+	assert.Equal(t, t0+120, out[2].Timestamp)
+
+	assert.Equal(t, t0, TimestampToSeconds(t0))
+	assert.Equal(t, t0, TimestampToSeconds(t0*1e6))
+	assert.Equal(t, t0, TimestampToSeconds(t0*1e9))
+}
+
+func TestResample(t *testing.T) {
+	klines := minuteKlines(7, 1000)
+	out, err := Resample(klines, KLINE_PERIOD_1MIN, KLINE_PERIOD_3MIN)
+	require.NoError(t, err)
+	require.Len(t, out, 3)
+
+	assert.Equal(t, Kline{Pair: BTC_USDT, Timestamp: t0, Open: 100, Close: 102.5, High: 103, Low: 99, Vol: 3}, out[0])
+	assert.Equal(t, t0+180, out[1].Timestamp)
+	assert.Equal(t, float64(103), out[1].Open)
+	assert.Equal(t, float64(106), out[2].Open, "partial last candle")
+	assert.Equal(t, float64(1), out[2].Vol)
+
+	_, err = Resample(klines, KLINE_PERIOD_3MIN, KLINE_PERIOD_5MIN)
+	assert.True(t, errors.Is(err, ErrPeriod))
+	_, err = Resample(klines, KLINE_PERIOD_1WEEK, KLINE_PERIOD_1MONTH)
+	assert.True(t, errors.Is(err, ErrPeriod))
+}
+
+func TestResample_Calendar(t *testing.T) {
+	var days []Kline
+	for d := 0; d < 40; d++ {
+		days = append(days, Kline{Timestamp: t0 + int64(d)*86400, Open: 1, Close: 1, High: float64(d), Low: 1, Vol: 1})
+	}
+
+	weeks, err := Resample(days, KLINE_PERIOD_1DAY, KLINE_PERIOD_1WEEK)
+	require.NoError(t, err)
+	assert.Len(t, weeks, 6)
+	assert.Equal(t, t0, weeks[0].Timestamp, "weeks open on monday")
+	assert.Equal(t, time.Monday, time.Unix(weeks[1].Timestamp, 0).UTC().Weekday())
+
+	months, err := Resample(days, KLINE_PERIOD_1DAY, KLINE_PERIOD_1MONTH)
+	require.NoError(t, err)
+	require.Len(t, months, 2)
+	assert.Equal(t, float64(31), months[0].Vol)
+	assert.Equal(t, time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC).Unix(), months[1].Timestamp)
+	assert.Equal(t, float64(39), months[1].High)
+
+	assert.True(t, CanResample(KLINE_PERIOD_8H, KLINE_PERIOD_1DAY))
+	assert.True(t, CanResample(KLINE_PERIOD_1MONTH, KLINE_PERIOD_1YEAR))
+	assert.False(t, CanResample(KLINE_PERIOD_3DAY, KLINE_PERIOD_1MONTH))
+	assert.False(t, CanResample(KLINE_PERIOD_1DAY, KLINE_PERIOD_1H))
+}
+
+func TestFillGaps(t *testing.T) {
+	klines := minuteKlines(5, 1)
+	klines = append(klines[:1], klines[3:]...)
+
+	out, err := FillGaps(klines, KLINE_PERIOD_1MIN)
+	require.NoError(t, err)
+	require.Len(t, out, 5)
+	assert.Equal(t, Kline{Pair: BTC_USDT, Timestamp: t0 + 60, Open: 100.5, Close: 100.5, High: 100.5, Low: 100.5}, out[1])
+	assert.Equal(t, t0+120, out[2].Timestamp)
+	assert.Equal(t, float64(103), out[3].Open)
+}
+
+func TestResampleFuture(t *testing.T) {
+	var klines []FutureKline
+	for i, k := range minuteKlines(4, 1) {
+		k := k
+		klines = append(klines, FutureKline{Kline: &k, Vol2: float64(i)})
+	}
+	klines = append(klines[:1], klines[2:]...)
+
+	out, err := ResampleFuture(klines, KLINE_PERIOD_1MIN, KLINE_PERIOD_3MIN)
+	require.NoError(t, err)
+	require.Len(t, out, 2)
+	assert.Equal(t, float64(2), out[0].Vol)
+	assert.Equal(t, float64(2), out[0].Vol2)
+
+	filled, err := FillFutureGaps(klines, KLINE_PERIOD_1MIN)
+	require.NoError(t, err)
+	require.Len(t, filled, 4)
+	assert.Equal(t, float64(0), filled[1].Vol2)
+}
+
+type fakeKlineAPI struct {
+	API
+	period KlinePeriod
+	size   int
+}
+
+func (f *fakeKlineAPI) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
+	f.period, f.size = period, size
+	return minuteKlines(size, 1000), nil
+}
+
+func TestGetKlineRecords(t *testing.T) {
+	api := &fakeKlineAPI{}
+	klines, err := GetKlineRecords(api, BTC_USDT, KLINE_PERIOD_3MIN, KLINE_PERIOD_1MIN, 2)
+	require.NoError(t, err)
+	assert.Equal(t, KlinePeriod(KLINE_PERIOD_1MIN), api.period)
+	assert.Equal(t, 9, api.size)
+	require.Len(t, klines, 2)
+	assert.Equal(t, t0+180, klines[0].Timestamp)
+
+	_, err = GetKlineRecords(api, BTC_USDT, KLINE_PERIOD_1MONTH, KLINE_PERIOD_1DAY, 2)
+	assert.True(t, errors.Is(err, ErrPeriod))
+}
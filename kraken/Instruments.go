@@ -0,0 +1,54 @@
+package kraken
+
+import (
+	"net/url"
+	"strings"
+
+	. "github.com/soulsplit/goex"
+)
+
+type assetPair struct {
+	Wsname       string  `json:"wsname"`
+	PairDecimals int32   `json:"pair_decimals"`
+	LotDecimals  int32   `json:"lot_decimals"`
+	Ordermin     Decimal `json:"ordermin"`
+	Status       string  `json:"status"`
+}
+
+// adaptAssetPairStatus maps the status of a pair, the older listings have none.
+func adaptAssetPairStatus(status string) InstrumentStatus {
+	switch status {
+	case "", "online":
+		return INSTRUMENT_TRADING
+	case "delisted":
+		return INSTRUMENT_DELISTED
+	}
+	return INSTRUMENT_SUSPENDED //cancel_only, post_only, limit_only, reduce_only
+}
+
+// GetInstrumentsInfo lists the asset pairs and registers them in Symbols, the id is
+// the name of the pair such as XXBTZUSD. The dark pool pairs (.d) are skipped.
+func (exchange *Exchange) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	var pairs map[string]assetPair
+	if err := exchange.doAuthenticatedRequest("GET", PUBLIC+"AssetPairs", url.Values{}, &pairs); err != nil {
+		return nil, err
+	}
+
+	instruments := make([]InstrumentInfo, 0, len(pairs))
+	for name, p := range pairs {
+		if strings.HasSuffix(name, ".d") || !strings.Contains(p.Wsname, "/") {
+			continue
+		}
+		instruments = append(instruments, InstrumentInfo{
+			Id:        name,
+			Pair:      CanonicalPair(NewCurrencyPair3(p.Wsname, "/")),
+			Kind:      SPOT,
+			TickSize:  NewDecimal(1, p.PairDecimals),
+			LotSize:   NewDecimal(1, p.LotDecimals),
+			MinAmount: p.Ordermin,
+			Status:    adaptAssetPairStatus(p.Status),
+		})
+	}
+	Symbols.RegisterInstruments(KRAKEN, instruments)
+	return instruments, nil
+}
@@ -6,7 +6,6 @@ import (
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -27,6 +26,25 @@ type NewOrderResponse struct {
 	TxIds       []string    `json:"txid"`
 }
 
+var errorCodes = ErrorCodes{
+	"EOrder:Insufficient funds":      EX_ERR_INSUFFICIENT_BALANCE,
+	"EOrder:Unknown order":           EX_ERR_NOT_FIND_ORDER,
+	"EOrder:Rate limit exceeded":     EX_ERR_API_LIMIT,
+	"EOrder:Invalid price":           EX_ERR_INVALID_PARAMETER,
+	"EAPI:Rate limit exceeded":       EX_ERR_API_LIMIT,
+	"EAPI:Invalid key":               EX_ERR_AUTH,
+	"EAPI:Invalid signature":         EX_ERR_SIGN,
+	"EAPI:Invalid nonce":             EX_ERR_TIMESTAMP,
+	"EGeneral:Invalid arguments":     EX_ERR_INVALID_PARAMETER,
+	"EGeneral:Permission denied":     EX_ERR_AUTH,
+	"EQuery:Unknown asset pair":      EX_ERR_INVALID_CURRENCY_PAIR,
+	"EService:Unavailable":           EX_ERR_MAINTENANCE,
+	"EService:Busy":                  EX_ERR_SERVER,
+	"EGeneral:Temporary lockout":     EX_ERR_API_LIMIT,
+	"EOrder:Cannot open position":    EX_ERR_PLACE_ORDER_FAIL,
+	"EOrder:Margin allowance exceed": EX_ERR_INSUFFICIENT_BALANCE,
+}
+
 type Exchange struct {
 	httpClient *http.Client
 	accessKey,
@@ -48,15 +66,20 @@ func New(client *http.Client, accesskey, secretkey string) *Exchange {
 func (exchange *Exchange) placeOrder(orderType, side, amount, price string, pair CurrencyPair) (*Order, error) {
 	apiuri := PRIVATE + "AddOrder"
 
+	symbol, err := exchange.symbol(pair)
+	if err != nil {
+		return nil, err
+	}
+
 	params := url.Values{}
-	params.Set("pair", exchange.convertPair(pair).ToSymbol(""))
+	params.Set("pair", symbol)
 	params.Set("type", side)
 	params.Set("ordertype", orderType)
 	params.Set("price", price)
 	params.Set("volume", amount)
 
 	var resp NewOrderResponse
-	err := exchange.doAuthenticatedRequest("POST", apiuri, params, &resp)
+	err = exchange.doAuthenticatedRequest("POST", apiuri, params, &resp)
 	//log.Println
 	if err != nil {
 		return nil, err
@@ -176,7 +199,7 @@ func (exchange *Exchange) GetOneOrder(orderId string, currency CurrencyPair) (*O
 	}
 
 	if len(orders) == 0 {
-		return nil, errors.New("not fund the order " + orderId)
+		return nil, EX_ERR_NOT_FIND_ORDER.OriginErr("not fund the order " + orderId)
 	}
 
 	ord := &orders[0]
@@ -280,8 +303,13 @@ func (exchange *Exchange) GetAssets(currency CurrencyPair) (*Assets, error) {
 }
 
 func (exchange *Exchange) GetTicker(currency CurrencyPair) (*Ticker, error) {
+	symbol, err := exchange.symbol(currency)
+	if err != nil {
+		return nil, err
+	}
+
 	var resultmap map[string]interface{}
-	err := exchange.doAuthenticatedRequest("GET", "public/Ticker?pair="+exchange.convertPair(currency).ToSymbol(""), url.Values{}, &resultmap)
+	err = exchange.doAuthenticatedRequest("GET", "public/Ticker?pair="+symbol, url.Values{}, &resultmap)
 	if err != nil {
 		return nil, err
 	}
@@ -302,9 +330,14 @@ func (exchange *Exchange) GetTicker(currency CurrencyPair) (*Ticker, error) {
 }
 
 func (exchange *Exchange) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	apiuri := fmt.Sprintf(PUBLIC+"Depth?pair=%s&count=%d", exchange.convertPair(currency).ToSymbol(""), size)
+	symbol, err := exchange.symbol(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	apiuri := fmt.Sprintf(PUBLIC+"Depth?pair=%s&count=%d", symbol, size)
 	var resultmap map[string]interface{}
-	err := exchange.doAuthenticatedRequest("GET", apiuri, url.Values{}, &resultmap)
+	err = exchange.doAuthenticatedRequest("GET", apiuri, url.Values{}, &resultmap)
 	if err != nil {
 		return nil, err
 	}
@@ -394,7 +427,7 @@ func (exchange *Exchange) doAuthenticatedRequest(method, apiuri string, params u
 	//println(string(resp))
 
 	if len(base.Error) > 0 {
-		return errors.New(base.Error[0])
+		return errorCodes.Adapt(http.StatusOK, base.Error[0], base.Error[0])
 	}
 
 	return nil
@@ -408,6 +441,14 @@ func (exchange *Exchange) convertCurrency(currencySymbol string) Currency {
 	return NewCurrency(currencySymbol, "")
 }
 
+// symbol returns the name of the pair in Symbols, XBTUSD for BTC_USD until the
+// asset pairs are registered.
+func (exchange *Exchange) symbol(pair CurrencyPair) (string, error) {
+	return Symbols.ResolveSymbol(KRAKEN, pair, "", func(pair CurrencyPair, _ string) string {
+		return exchange.convertPair(pair).ToSymbol("")
+	})
+}
+
 func (exchange *Exchange) convertPair(pair CurrencyPair) CurrencyPair {
 	if "BTC" == pair.CurrencyA.Symbol {
 		return NewCurrencyPair(XBT, pair.CurrencyB)
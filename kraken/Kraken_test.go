@@ -2,10 +2,13 @@ package kraken
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/cassette"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var k = New(http.DefaultClient, "", "")
@@ -62,3 +65,58 @@ func TestKraken_GetOneOrder(t *testing.T) {
 	assert.Nil(t, err)
 	t.Log(ord)
 }
+
+func TestKraken_GetInstrumentsInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":[],"result":{
+			"XXBTZUSD":{"altname":"XBTUSD","wsname":"XBT/USD","pair_decimals":1,"lot_decimals":8,"ordermin":"0.0001","status":"online"},
+			"XXBTZUSD.d":{"altname":"XBTUSD.d","pair_decimals":1,"lot_decimals":8},
+			"XETHXXBT":{"altname":"ETHXBT","wsname":"ETH/XBT","pair_decimals":5,"lot_decimals":8,"ordermin":"0.01","status":"cancel_only"}}}`))
+	}))
+	defer srv.Close()
+	domain := API_DOMAIN
+	API_DOMAIN = srv.URL + API_V0
+	t.Cleanup(func() {
+		API_DOMAIN = domain
+		goex.Symbols.Reset(goex.KRAKEN)
+	})
+
+	instruments, err := New(http.DefaultClient, "", "").GetInstrumentsInfo()
+	assert.NoError(t, err)
+	assert.Len(t, instruments, 2, "the dark pool pair is skipped")
+
+	symbol, err := New(http.DefaultClient, "", "").symbol(goex.BTC_USD)
+	assert.NoError(t, err)
+	assert.Equal(t, "XXBTZUSD", symbol)
+	pair, _, err := goex.Symbols.Pair(goex.KRAKEN, "XETHXXBT")
+	assert.NoError(t, err)
+	assert.Equal(t, "ETH_BTC", pair.String())
+}
+
+func TestKraken_Replay(t *testing.T) {
+	rec, err := cassette.New(&cassette.Config{Path: "testdata/spot.json", Mode: cassette.ModeReplay})
+	require.NoError(t, err)
+	kr := New(&http.Client{Transport: rec}, "key", "c2VjcmV0")
+
+	ticker, err := kr.GetTicker(goex.BTC_USD)
+	require.NoError(t, err)
+	assert.Equal(t, 29318.4, ticker.Last)
+	assert.Equal(t, 29320.0, ticker.Buy)
+	assert.Equal(t, 29320.1, ticker.Sell)
+	assert.Equal(t, 28710.0, ticker.Low)
+	assert.Equal(t, 29690.0, ticker.High)
+
+	dep, err := kr.GetDepth(2, goex.BTC_USD)
+	require.NoError(t, err)
+	require.Len(t, dep.BidList, 2)
+	require.Len(t, dep.AskList, 2)
+	assert.Equal(t, 29320.0, dep.BidList[0].Price)
+	assert.Equal(t, 29321.5, dep.AskList[0].Price) //descending
+	assert.Equal(t, 1.25, dep.AskList[1].Amount)
+
+	acc, err := kr.GetAccount()
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, acc.SubAccounts[goex.BTC].Amount)
+	assert.Equal(t, 1200.0, acc.SubAccounts[goex.USD].Amount)
+	assert.Equal(t, 2.0, acc.SubAccounts[goex.ETH].Amount)
+}
@@ -47,6 +47,32 @@ type Exchange struct {
 	service       *kucoin.ApiService
 }
 
+var errorCodes = ErrorCodes{
+	"200004": EX_ERR_INSUFFICIENT_BALANCE,
+	"230003": EX_ERR_INSUFFICIENT_BALANCE,
+	"400001": EX_ERR_AUTH,
+	"400002": EX_ERR_TIMESTAMP,
+	"400003": EX_ERR_NOT_FIND_APIKEY,
+	"400004": EX_ERR_AUTH,
+	"400005": EX_ERR_SIGN,
+	"400006": EX_ERR_AUTH,
+	"400007": EX_ERR_AUTH,
+	"400100": EX_ERR_INVALID_PARAMETER,
+	"411100": EX_ERR_AUTH,
+	"429000": EX_ERR_API_LIMIT,
+	"500000": EX_ERR_SERVER,
+	"900001": EX_ERR_INVALID_CURRENCY_PAIR,
+}
+
+// readData is resp.ReadData with the code of a failed request mapped to its kind.
+func readData(resp *kucoin.ApiResponse, v interface{}) error {
+	err := resp.ReadData(v)
+	if err == nil || resp.Code == "" {
+		return err
+	}
+	return errorCodes.Adapt(0, resp.Code, resp.Message)
+}
+
 var inernalKlinePeriodConverter = map[KlinePeriod]string{
 	KLINE_PERIOD_1MIN:  "1min",
 	KLINE_PERIOD_3MIN:  "3min",
@@ -77,7 +103,7 @@ func (exchange *Exchange) GetTicker(currency CurrencyPair) (*Ticker, error) {
 
 	var model kucoin.TickerLevel1Model
 
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin GetTicker error:", err)
 		return nil, err
@@ -110,7 +136,7 @@ func (exchange *Exchange) LimitBuy(amount, price string, currency CurrencyPair,
 
 	var model kucoin.OrderModel
 
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin LimitBuy error:", err)
 		return nil, err
@@ -140,7 +166,7 @@ func (exchange *Exchange) LimitSell(amount, price string, currency CurrencyPair,
 
 	var model kucoin.OrderModel
 
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin LimitSell error:", err)
 		return nil, err
@@ -171,7 +197,7 @@ func (exchange *Exchange) MarketBuy(amount, price string, currency CurrencyPair)
 
 	var model kucoin.OrderModel
 
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin MarketBuy error:", err)
 		return nil, err
@@ -201,7 +227,7 @@ func (exchange *Exchange) MarketSell(amount, price string, currency CurrencyPair
 
 	var model kucoin.OrderModel
 
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin MarketSell error:", err)
 		return nil, err
@@ -228,7 +254,7 @@ func (exchange *Exchange) CancelOrder(orderId string, currency CurrencyPair) (bo
 	}
 
 	var model kucoin.CancelOrderResultModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin CancelOrder error:", err)
 		return false, err
@@ -245,7 +271,7 @@ func (exchange *Exchange) GetOneOrder(orderId string, currency CurrencyPair) (*O
 
 	var model kucoin.OrderModel
 
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin GetOneOrder error:", err)
 		return nil, err
@@ -306,7 +332,7 @@ func (exchange *Exchange) GetUnfinishOrders(currency CurrencyPair) ([]Order, err
 
 	var model kucoin.OrderModel
 
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin GetUnfinishOrders error:", err)
 		return nil, err
@@ -336,7 +362,7 @@ func (exchange *Exchange) GetOrderHistorys(currency CurrencyPair, optional ...Op
 
 	var model kucoin.OrderModel
 
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin GetOrderHistorys error:", err)
 		return nil, err
@@ -390,7 +416,7 @@ func (exchange *Exchange) GetDepth(size int, currency CurrencyPair) (*Depth, err
 
 	var model kucoin.PartOrderBookModel
 
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin GetDepth error:", err)
 		return nil, err
@@ -429,7 +455,7 @@ func (exchange *Exchange) GetKlineRecords(currency CurrencyPair, period KlinePer
 
 	var model kucoin.KLinesModel
 
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin GetKlineRecords error:", err)
 		return nil, err
@@ -461,7 +487,7 @@ func (exchange *Exchange) GetTrades(currency CurrencyPair, since int64) ([]Trade
 
 	var model kucoin.TradeHistoriesModel
 
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin GetTrades error:", err)
 		return nil, err
@@ -498,7 +524,7 @@ func (exchange *Exchange) Accounts(currency, typo string) (kucoin.AccountsModel,
 	}
 
 	var model kucoin.AccountsModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin Accounts error:", err)
 		return nil, err
@@ -516,7 +542,7 @@ func (exchange *Exchange) Account(accountId string) (*kucoin.AccountModel, error
 	}
 
 	var model *kucoin.AccountModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin Accounts error:", err)
 		return nil, err
@@ -534,7 +560,7 @@ func (exchange *Exchange) SubAccountUsers() (kucoin.SubAccountUsersModel, error)
 	}
 
 	var model kucoin.SubAccountUsersModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin SubAccountUsers error:", err)
 		return nil, err
@@ -552,7 +578,7 @@ func (exchange *Exchange) SubAccounts() (kucoin.SubAccountsModel, error) {
 	}
 
 	var model kucoin.SubAccountsModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin SubAccounts error:", err)
 		return nil, err
@@ -570,7 +596,7 @@ func (exchange *Exchange) SubAccount(subUserId string) (*kucoin.SubAccountModel,
 	}
 
 	var model *kucoin.SubAccountModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin SubAccount error:", err)
 		return nil, err
@@ -589,7 +615,7 @@ func (exchange *Exchange) CreateAccount(typo, currency string) (*kucoin.AccountM
 	}
 
 	var model *kucoin.AccountModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin CreateAccount error:", err)
 		return nil, err
@@ -608,7 +634,7 @@ func (exchange *Exchange) InnerTransfer(currency, from, to, amount string) (stri
 	}
 
 	var model *kucoin.InnerTransferResultModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin InnerTransfer error:", err)
 		return "", err
@@ -635,7 +661,7 @@ func (exchange *Exchange) SubTransfer(currency, amount, direction, subUserId, ac
 	}
 
 	var model *kucoin.InnerTransferResultModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin SubTransfer error:", err)
 		return "", err
@@ -655,7 +681,7 @@ func (exchange *Exchange) CreateDepositAddress(currency, chain string) (*kucoin.
 	}
 
 	var model *kucoin.DepositAddressModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin CreateDepositAddress error:", err)
 		return nil, err
@@ -674,7 +700,7 @@ func (exchange *Exchange) DepositAddresses(currency, chain string) (*kucoin.Depo
 	}
 
 	var model *kucoin.DepositAddressModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin DepositAddresses error:", err)
 		return nil, err
@@ -698,7 +724,7 @@ func (exchange *Exchange) Deposits(currency, startAt, endAt, status string) (*ku
 	}
 
 	var model *kucoin.DepositsModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin Deposits error:", err)
 		return nil, err
@@ -724,7 +750,7 @@ func (exchange *Exchange) Withdrawals(currency, startAt, endAt, status string) (
 	}
 
 	var model *kucoin.WithdrawalsModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin Withdrawals error:", err)
 		return nil, err
@@ -747,7 +773,7 @@ func (exchange *Exchange) ApplyWithdrawal(currency, address, amount, memo, isInn
 	}
 
 	var model *kucoin.ApplyWithdrawalResultModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin ApplyWithdrawal error:", err)
 		return "", err
@@ -765,7 +791,7 @@ func (exchange *Exchange) WithdrawalQuotas(currency, chain string) (*kucoin.With
 	}
 
 	var model *kucoin.WithdrawalQuotasModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin WithdrawalQuotas error:", err)
 		return nil, err
@@ -783,7 +809,7 @@ func (exchange *Exchange) CancelWithdrawal(withdrawalId string) (*kucoin.CancelW
 	}
 
 	var model *kucoin.CancelWithdrawalResultModel
-	err = resp.ReadData(&model)
+	err = readData(resp, &model)
 	if err != nil {
 		log.Error("KuCoin CancelWithdrawal error:", err)
 		return nil, err
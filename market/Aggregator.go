@@ -0,0 +1,473 @@
+// Package market consolidates the books of one pair across exchanges.
+package market
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/internal/logger"
+)
+
+const (
+	SourceRest = "rest"
+	SourceWs   = "ws"
+)
+
+// usdQuotes are the quotes taken at par with each other unless Config.QuoteRates says otherwise.
+var usdQuotes = []Currency{USD, USDT, USDC, PAX, {Symbol: "BUSD"}, {Symbol: "TUSD"}, {Symbol: "DAI"}}
+
+func isUsdQuote(c Currency) bool {
+	for _, q := range usdQuotes {
+		if q.Eq(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// Venue is one exchange of the consolidated view.
+type Venue struct {
+	Name string       //default API.GetExchangeName()
+	API  API          //polled with GetDepth when there is no Ws
+	Ws   SpotWsApi    //optional, depth or else ticker pushes
+	Pair CurrencyPair //the pair on the venue, default Config.Pair, e.g. BTC_USDT for a BTC_USD view
+	// SharedWs keeps the callbacks of a Ws that feeds others too: Start only subscribes
+	// and the caller forwards the pushes with UpdateDepth and UpdateTicker. Else Start
+	// sets the depth and ticker callbacks of Ws, which must be dedicated to the venue.
+	SharedWs bool
+}
+
+type Config struct {
+	Pair         CurrencyPair
+	Venues       []Venue
+	DepthSize    int           //levels polled or kept per venue, default 20
+	PollInterval time.Duration //of the venues without a ws, default 1s
+	StaleAfter   time.Duration //a venue not updated for this long is left out, default 10s
+	// QuoteRates is the value of one unit of a venue quote currency in the quote of
+	// Pair. The usd stablecoins default to 1 for a usd quote, see SetQuoteRate.
+	QuoteRates      map[Currency]float64
+	BBOCallback     func(bbo BBO) //called when the consolidated best bid or ask changes
+	ErrorHandleFunc func(venue string, err error)
+}
+
+// VenueLevel is a price level of a venue, the price in the quote of the view.
+type VenueLevel struct {
+	Venue  string
+	Price  float64
+	Amount float64 //0 when the venue only pushes tickers
+}
+
+// BBO is the consolidated best bid and offer, a side is empty when no venue has it.
+type BBO struct {
+	Pair CurrencyPair
+	Bid  VenueLevel
+	Ask  VenueLevel
+}
+
+type VenueStatus struct {
+	Venue   string
+	Source  string //SourceWs or SourceRest
+	Updated time.Time
+	Age     time.Duration
+	Stale   bool
+	Err     error //of the last update
+}
+
+type venueState struct {
+	Venue
+	source     string
+	bids, asks DepthRecords //normalized, best first
+	updated    time.Time
+	err        error
+}
+
+// Aggregator is the consolidated market of Config.Pair: best bid and offer, depth
+// and the staleness of every venue. The venues with a ws are updated by their
+// callbacks, the others are polled.
+type Aggregator struct {
+	sync.Mutex
+	conf   Config
+	venues []*venueState
+	rates  map[Currency]float64
+	bbo    BBO
+	bboSeq int64 //counts the changes of bbo
+	now    func() time.Time
+
+	callbackLock sync.Mutex //delivers the changes of bbo one at a time, in order
+	delivered    int64
+}
+
+func NewAggregator(conf *Config) (*Aggregator, error) {
+	a := &Aggregator{conf: *conf, rates: make(map[Currency]float64), now: time.Now}
+	if a.conf.DepthSize <= 0 {
+		a.conf.DepthSize = 20
+	}
+	if a.conf.PollInterval <= 0 {
+		a.conf.PollInterval = time.Second
+	}
+	if a.conf.StaleAfter <= 0 {
+		a.conf.StaleAfter = 10 * time.Second
+	}
+	if len(a.conf.Venues) == 0 {
+		return nil, errors.New("no venues")
+	}
+
+	quote := a.conf.Pair.CurrencyB
+	a.rates[quote] = 1
+	if isUsdQuote(quote) {
+		for _, c := range usdQuotes {
+			a.rates[c] = 1
+		}
+	}
+	for c, rate := range a.conf.QuoteRates {
+		a.rates[c] = rate
+	}
+
+	names := make(map[string]bool)
+	for _, v := range a.conf.Venues {
+		if v.API == nil && v.Ws == nil {
+			return nil, errors.New("venue without API or Ws")
+		}
+		if v.Name == "" && v.API != nil {
+			v.Name = v.API.GetExchangeName()
+		}
+		if v.Name == "" || names[v.Name] {
+			return nil, fmt.Errorf("venue name %q is empty or used twice", v.Name)
+		}
+		names[v.Name] = true
+		if v.Pair == (CurrencyPair{}) {
+			v.Pair = a.conf.Pair
+		}
+		if !v.Pair.CurrencyA.Eq(a.conf.Pair.CurrencyA) {
+			return nil, fmt.Errorf("%s: %s is not a %s pair", v.Name, v.Pair, a.conf.Pair.CurrencyA)
+		}
+		if _, ok := a.rates[v.Pair.CurrencyB]; !ok {
+			return nil, fmt.Errorf("%s: no rate from %s to %s", v.Name, v.Pair.CurrencyB, quote)
+		}
+		a.venues = append(a.venues, &venueState{Venue: v, source: SourceRest})
+	}
+	return a, nil
+}
+
+// SetQuoteRate updates the value of one unit of currency in the quote of the view,
+// e.g. from a USDT_USD ticker. The books already received are not converted again.
+func (a *Aggregator) SetQuoteRate(currency Currency, rate float64) {
+	a.Lock()
+	defer a.Unlock()
+	a.rates[currency] = rate
+}
+
+// Start subscribes the depth, or else the ticker, of the venues with a ws and polls
+// the others until ctx is done. A venue whose subscriptions fail is polled.
+func (a *Aggregator) Start(ctx context.Context) error {
+	var polled []*venueState
+	for _, v := range a.venues {
+		if v.Ws != nil {
+			err := a.subscribe(v)
+			if err == nil {
+				continue
+			}
+			a.handleError(v, err)
+			if v.API == nil {
+				return fmt.Errorf("%s: %w", v.Name, err)
+			}
+		}
+		polled = append(polled, v)
+	}
+
+	if len(polled) > 0 {
+		go func() {
+			tick := time.NewTicker(a.conf.PollInterval)
+			defer tick.Stop()
+			for {
+				a.refresh(ctx, polled)
+				select {
+				case <-ctx.Done():
+					return
+				case <-tick.C:
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+func (a *Aggregator) subscribe(v *venueState) error {
+	if !v.SharedWs {
+		v.Ws.DepthCallback(func(depth *Depth) {
+			a.pushDepth(v, depth)
+		})
+		v.Ws.TickerCallback(func(ticker *Ticker) {
+			a.pushTicker(v, ticker)
+		})
+	}
+
+	err := v.Ws.SubscribeDepth(v.Pair)
+	if err != nil {
+		err = v.Ws.SubscribeTicker(v.Pair)
+	}
+	if err == nil {
+		a.Lock()
+		v.source = SourceWs
+		a.Unlock()
+	}
+	return err
+}
+
+// isPair tells if a push is for the pair of v, the adapters which do not set the
+// pair of their pushes are taken at their word.
+func isPair(v *venueState, pair CurrencyPair) bool {
+	return pair == (CurrencyPair{}) || pair.Eq(v.Pair)
+}
+
+func (a *Aggregator) pushDepth(v *venueState, depth *Depth) {
+	if isPair(v, depth.Pair) {
+		a.update(v, SourceWs, depth.BidList, depth.AskList, nil)
+	}
+}
+
+func (a *Aggregator) pushTicker(v *venueState, ticker *Ticker) {
+	if isPair(v, ticker.Pair) {
+		a.update(v, SourceWs, DepthRecords{{Price: ticker.Buy}}, DepthRecords{{Price: ticker.Sell}}, nil)
+	}
+}
+
+func (a *Aggregator) venue(name string) *venueState {
+	for _, v := range a.venues {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// UpdateDepth forwards a depth push of a venue with a SharedWs, the pushes of the
+// other pairs and unknown venues are ignored.
+func (a *Aggregator) UpdateDepth(venue string, depth *Depth) {
+	if v := a.venue(venue); v != nil {
+		a.pushDepth(v, depth)
+	}
+}
+
+// UpdateTicker forwards a ticker push of a venue with a SharedWs.
+func (a *Aggregator) UpdateTicker(venue string, ticker *Ticker) {
+	if v := a.venue(venue); v != nil {
+		a.pushTicker(v, ticker)
+	}
+}
+
+// Refresh polls the venues that are not updated by a ws, once.
+func (a *Aggregator) Refresh(ctx context.Context) {
+	var polled []*venueState
+	a.Lock()
+	for _, v := range a.venues {
+		if v.source == SourceRest && v.API != nil {
+			polled = append(polled, v)
+		}
+	}
+	a.Unlock()
+	a.refresh(ctx, polled)
+}
+
+func (a *Aggregator) refresh(ctx context.Context, venues []*venueState) {
+	var wg sync.WaitGroup
+	for _, v := range venues {
+		wg.Add(1)
+		go func(v *venueState) {
+			defer wg.Done()
+			var (
+				dep *Depth
+				err error
+			)
+			if ctxApi, ok := v.API.(APIWithContext); ok {
+				dep, err = ctxApi.GetDepthWithContext(ctx, a.conf.DepthSize, v.Pair)
+			} else {
+				dep, err = v.API.GetDepth(a.conf.DepthSize, v.Pair)
+			}
+			if err != nil {
+				a.update(v, SourceRest, nil, nil, err)
+				return
+			}
+			a.update(v, SourceRest, dep.BidList, dep.AskList, nil)
+		}(v)
+	}
+	wg.Wait()
+}
+
+// update replaces the book of a venue, an error keeps the last book and its time.
+func (a *Aggregator) update(v *venueState, source string, bids, asks DepthRecords, err error) {
+	a.Lock()
+	v.err = err
+	if err == nil {
+		v.source = source
+		v.bids, v.asks = a.normalize(v, bids, asks)
+		v.updated = a.now()
+	}
+	bbo, changed := a.topOfBook()
+	seq := a.bboSeq
+	a.Unlock()
+
+	if err != nil {
+		a.handleError(v, err)
+	}
+	if changed && a.conf.BBOCallback != nil {
+		a.deliver(bbo, seq)
+	}
+}
+
+// deliver calls BBOCallback unless a later change is delivered already, the polls
+// and the pushes of the venues update concurrently.
+func (a *Aggregator) deliver(bbo BBO, seq int64) {
+	a.callbackLock.Lock()
+	defer a.callbackLock.Unlock()
+	if seq <= a.delivered {
+		return
+	}
+	a.delivered = seq
+	a.conf.BBOCallback(bbo)
+}
+
+// normalize converts the prices to the quote of the view and sorts the levels best first.
+func (a *Aggregator) normalize(v *venueState, bids, asks DepthRecords) (DepthRecords, DepthRecords) {
+	rate := a.rates[v.Pair.CurrencyB]
+	convert := func(records DepthRecords) DepthRecords {
+		out := make(DepthRecords, 0, len(records))
+		for _, r := range records {
+			if r.Price > 0 {
+				out = append(out, DepthRecord{Price: r.Price * rate, Amount: r.Amount})
+			}
+		}
+		return out
+	}
+	nbids, nasks := convert(bids), convert(asks)
+	sort.Sort(sort.Reverse(nbids))
+	sort.Sort(nasks)
+	if len(nbids) > a.conf.DepthSize {
+		nbids = nbids[:a.conf.DepthSize]
+	}
+	if len(nasks) > a.conf.DepthSize {
+		nasks = nasks[:a.conf.DepthSize]
+	}
+	return nbids, nasks
+}
+
+func (a *Aggregator) handleError(v *venueState, err error) {
+	if a.conf.ErrorHandleFunc != nil {
+		a.conf.ErrorHandleFunc(v.Name, err)
+		return
+	}
+	logger.Warnf("[market] %s %s: %s", v.Name, v.Pair, err.Error())
+}
+
+func (a *Aggregator) fresh(v *venueState, now time.Time) bool {
+	return !v.updated.IsZero() && now.Sub(v.updated) <= a.conf.StaleAfter
+}
+
+func (a *Aggregator) topOfBook() (BBO, bool) {
+	bbo := BBO{Pair: a.conf.Pair}
+	now := a.now()
+	for _, v := range a.venues {
+		if !a.fresh(v, now) {
+			continue
+		}
+		if len(v.bids) > 0 && v.bids[0].Price > bbo.Bid.Price {
+			bbo.Bid = VenueLevel{Venue: v.Name, Price: v.bids[0].Price, Amount: v.bids[0].Amount}
+		}
+		if len(v.asks) > 0 && (bbo.Ask.Price == 0 || v.asks[0].Price < bbo.Ask.Price) {
+			bbo.Ask = VenueLevel{Venue: v.Name, Price: v.asks[0].Price, Amount: v.asks[0].Amount}
+		}
+	}
+	changed := bbo != a.bbo
+	if changed {
+		a.bbo = bbo
+		a.bboSeq++
+	}
+	return bbo, changed
+}
+
+// BBO returns the consolidated best bid and offer of the venues that are not stale.
+func (a *Aggregator) BBO() BBO {
+	a.Lock()
+	defer a.Unlock()
+	bbo, _ := a.topOfBook()
+	return bbo
+}
+
+// Levels returns the price levels of all the venues that are not stale, best
+// first, the levels of several venues at a price are kept apart. size <= 0 is all.
+func (a *Aggregator) Levels(size int) (bids, asks []VenueLevel) {
+	a.Lock()
+	defer a.Unlock()
+
+	now := a.now()
+	for _, v := range a.venues {
+		if !a.fresh(v, now) {
+			continue
+		}
+		for _, r := range v.bids {
+			bids = append(bids, VenueLevel{Venue: v.Name, Price: r.Price, Amount: r.Amount})
+		}
+		for _, r := range v.asks {
+			asks = append(asks, VenueLevel{Venue: v.Name, Price: r.Price, Amount: r.Amount})
+		}
+	}
+	sort.SliceStable(bids, func(i, j int) bool { return bids[i].Price > bids[j].Price })
+	sort.SliceStable(asks, func(i, j int) bool { return asks[i].Price < asks[j].Price })
+	if size > 0 && len(bids) > size {
+		bids = bids[:size]
+	}
+	if size > 0 && len(asks) > size {
+		asks = asks[:size]
+	}
+	return bids, asks
+}
+
+// Depth returns the merged depth of the venues that are not stale, the amounts of
+// a price are summed. AskList is in descending order like everywhere else in goex.
+func (a *Aggregator) Depth(size int) *Depth {
+	bids, asks := a.Levels(0)
+	dep := &Depth{Pair: a.conf.Pair, UTime: a.now()}
+	merge := func(levels []VenueLevel) DepthRecords {
+		var records DepthRecords
+		for _, l := range levels {
+			if l.Amount <= 0 {
+				continue
+			}
+			if n := len(records); n > 0 && records[n-1].Price == l.Price {
+				records[n-1].Amount += l.Amount
+				continue
+			}
+			if size > 0 && len(records) == size {
+				break
+			}
+			records = append(records, DepthRecord{Price: l.Price, Amount: l.Amount})
+		}
+		return records
+	}
+	dep.BidList = merge(bids)
+	dep.AskList = merge(asks)
+	sort.Sort(sort.Reverse(dep.AskList))
+	return dep
+}
+
+// Status reports the source and the staleness of every venue.
+func (a *Aggregator) Status() []VenueStatus {
+	a.Lock()
+	defer a.Unlock()
+
+	now := a.now()
+	status := make([]VenueStatus, 0, len(a.venues))
+	for _, v := range a.venues {
+		s := VenueStatus{Venue: v.Name, Source: v.source, Updated: v.updated, Stale: !a.fresh(v, now), Err: v.err}
+		if !v.updated.IsZero() {
+			s.Age = now.Sub(v.updated)
+		}
+		status = append(status, s)
+	}
+	return status
+}
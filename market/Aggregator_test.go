@@ -0,0 +1,195 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/sim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVenue returns a sim exchange with one ask and one bid for each of the prices.
+func newVenue(pair CurrencyPair, bid, ask float64) *sim.Exchange {
+	ex := sim.New(nil)
+	ex.Deposit(pair.CurrencyA, 100)
+	ex.Deposit(pair.CurrencyB, 1e6)
+	ex.LimitBuy("1", FloatToString(bid, 8), pair)
+	ex.LimitSell("2", FloatToString(ask, 8), pair)
+	return ex
+}
+
+type fakeSpotWs struct {
+	depthCallback  func(*Depth)
+	tickerCallback func(*Ticker)
+	depthErr       error
+	subscribed     []string
+}
+
+func (ws *fakeSpotWs) DepthCallback(f func(*Depth))   { ws.depthCallback = f }
+func (ws *fakeSpotWs) TickerCallback(f func(*Ticker)) { ws.tickerCallback = f }
+func (ws *fakeSpotWs) TradeCallback(f func(*Trade))   {}
+func (ws *fakeSpotWs) SubscribeDepth(pair CurrencyPair) error {
+	if ws.depthErr != nil {
+		return ws.depthErr
+	}
+	ws.subscribed = append(ws.subscribed, "depth")
+	return nil
+}
+func (ws *fakeSpotWs) SubscribeTicker(pair CurrencyPair) error {
+	ws.subscribed = append(ws.subscribed, "ticker")
+	return nil
+}
+func (ws *fakeSpotWs) SubscribeTrade(pair CurrencyPair) error                       { return nil }
+func (ws *fakeSpotWs) KlineCallback(f func(*Kline, KlinePeriod, bool))              {}
+func (ws *fakeSpotWs) SubscribeKline(pair CurrencyPair, period KlinePeriod) error   { return nil }
+func (ws *fakeSpotWs) UnsubscribeDepth(pair CurrencyPair) error                     { return nil }
+func (ws *fakeSpotWs) UnsubscribeTicker(pair CurrencyPair) error                    { return nil }
+func (ws *fakeSpotWs) UnsubscribeTrade(pair CurrencyPair) error                     { return nil }
+func (ws *fakeSpotWs) UnsubscribeKline(pair CurrencyPair, period KlinePeriod) error { return nil }
+func (ws *fakeSpotWs) OrderCallback(f func(*Order))                                 {}
+func (ws *fakeSpotWs) AccountCallback(f func(*Account))                             {}
+func (ws *fakeSpotWs) Login() error                                                 { return nil }
+func (ws *fakeSpotWs) SubscribeOrder(pair CurrencyPair) error                       { return nil }
+func (ws *fakeSpotWs) SubscribeAccount(pair CurrencyPair) error                     { return nil }
+
+func TestAggregator(t *testing.T) {
+	usdc := NewCurrencyPair(BTC, USDC)
+	depthWs := &fakeSpotWs{}
+	tickerWs := &fakeSpotWs{depthErr: errors.New("not supported")}
+
+	var (
+		lock sync.Mutex
+		bbos []BBO
+	)
+	lastBBO := func() BBO {
+		lock.Lock()
+		defer lock.Unlock()
+		return bbos[len(bbos)-1]
+	}
+	agg, err := NewAggregator(&Config{
+		Pair: BTC_USD,
+		Venues: []Venue{
+			{Name: "a", API: newVenue(BTC_USD, 99, 102)},
+			{Name: "b", API: newVenue(BTC_USDT, 100, 101), Pair: BTC_USDT},
+			{Name: "c", Ws: depthWs, Pair: usdc},
+			{Name: "d", Ws: tickerWs},
+		},
+		QuoteRates: map[Currency]float64{USDT: 0.99},
+		BBOCallback: func(bbo BBO) {
+			lock.Lock()
+			bbos = append(bbos, bbo)
+			lock.Unlock()
+		},
+	})
+	require.NoError(t, err)
+	now := time.Now()
+	agg.now = func() time.Time { return now }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() //a single poll
+	require.NoError(t, agg.Start(ctx))
+	assert.Equal(t, []string{"depth"}, depthWs.subscribed)
+	assert.Equal(t, []string{"ticker"}, tickerWs.subscribed)
+	require.Eventually(t, func() bool {
+		status := agg.Status()
+		return !status[0].Updated.IsZero() && !status[1].Updated.IsZero()
+	}, time.Second, time.Millisecond, "the poll of Start")
+	agg.Refresh(context.Background())
+
+	bbo := agg.BBO()
+	assert.Equal(t, VenueLevel{Venue: "a", Price: 99, Amount: 1}, bbo.Bid)
+	assert.Equal(t, "b", bbo.Ask.Venue)
+	assert.InDelta(t, 99.99, bbo.Ask.Price, 1e-9, "usdt at 0.99 usd")
+
+	depthWs.depthCallback(&Depth{Pair: usdc,
+		BidList: DepthRecords{{Price: 99.5, Amount: 3}, {Price: 99, Amount: 1}},
+		AskList: DepthRecords{{Price: 100.5, Amount: 1}, {Price: 99.9, Amount: 2}}})
+	depthWs.depthCallback(&Depth{Pair: BTC_USDT, BidList: DepthRecords{{Price: 200, Amount: 1}}})
+	tickerWs.tickerCallback(&Ticker{Pair: BTC_USD, Buy: 99.7, Sell: 103})
+
+	bbo = agg.BBO()
+	assert.Equal(t, VenueLevel{Venue: "d", Price: 99.7}, bbo.Bid)
+	assert.Equal(t, VenueLevel{Venue: "c", Price: 99.9, Amount: 2}, bbo.Ask)
+	assert.Equal(t, bbo, lastBBO())
+
+	bids, asks := agg.Levels(3)
+	assert.Equal(t, []VenueLevel{{"d", 99.7, 0}, {"c", 99.5, 3}, {"a", 99, 1}}, bids)
+	assert.Equal(t, "c", asks[0].Venue)
+
+	dep := agg.Depth(2)
+	assert.Equal(t, DepthRecords{{Price: 99.5, Amount: 3}, {Price: 99, Amount: 3}}, dep.BidList, "the ticker has no amount, 100 usdt is 99 usd")
+	require.Len(t, dep.AskList, 2)
+	assert.InDelta(t, 99.99, dep.AskList[0].Price, 1e-9, "asks in descending order")
+
+	//the polled venues go stale, only the ws ones are left
+	now = now.Add(5 * time.Second)
+	tickerWs.tickerCallback(&Ticker{Pair: BTC_USD, Buy: 99.7, Sell: 103})
+	depthWs.depthCallback(&Depth{Pair: usdc, BidList: DepthRecords{{Price: 98, Amount: 1}}})
+	now = now.Add(6 * time.Second)
+
+	bbo = agg.BBO()
+	assert.Equal(t, "d", bbo.Bid.Venue)
+	assert.Equal(t, VenueLevel{Venue: "d", Price: 103}, bbo.Ask)
+
+	status := agg.Status()
+	require.Len(t, status, 4)
+	assert.Equal(t, VenueStatus{Venue: "a", Source: SourceRest, Updated: now.Add(-11 * time.Second), Age: 11 * time.Second, Stale: true}, status[0])
+	assert.Equal(t, SourceWs, status[2].Source)
+	assert.False(t, status[3].Stale)
+}
+
+func TestAggregator_SharedWs(t *testing.T) {
+	ws := &fakeSpotWs{}
+	agg, err := NewAggregator(&Config{
+		Pair:   BTC_USD,
+		Venues: []Venue{{Name: "a", Ws: ws, SharedWs: true}},
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, agg.Start(ctx))
+	assert.Equal(t, []string{"depth"}, ws.subscribed)
+	assert.Nil(t, ws.depthCallback, "the callbacks of a shared ws are kept")
+
+	agg.UpdateDepth("a", &Depth{Pair: ETH_USD, BidList: DepthRecords{{Price: 9, Amount: 1}}})
+	agg.UpdateDepth("b", &Depth{Pair: BTC_USD, BidList: DepthRecords{{Price: 9, Amount: 1}}})
+	assert.Equal(t, VenueLevel{}, agg.BBO().Bid)
+
+	agg.UpdateDepth("a", &Depth{BidList: DepthRecords{{Price: 99, Amount: 1}}})
+	assert.Equal(t, VenueLevel{Venue: "a", Price: 99, Amount: 1}, agg.BBO().Bid, "a push without pair is for the venue")
+	agg.UpdateTicker("a", &Ticker{Pair: BTC_USD, Buy: 98, Sell: 101})
+	assert.Equal(t, VenueLevel{Venue: "a", Price: 101}, agg.BBO().Ask)
+}
+
+func TestAggregator_DeliverInOrder(t *testing.T) {
+	var bbos []BBO
+	agg, err := NewAggregator(&Config{
+		Pair:        BTC_USD,
+		Venues:      []Venue{{Name: "a", API: sim.New(nil)}},
+		BBOCallback: func(bbo BBO) { bbos = append(bbos, bbo) },
+	})
+	require.NoError(t, err)
+	newer, older := BBO{Bid: VenueLevel{Price: 2}}, BBO{Bid: VenueLevel{Price: 1}}
+	agg.deliver(newer, 2)
+	agg.deliver(older, 1)
+	assert.Equal(t, []BBO{newer}, bbos, "an older change delivered late is dropped")
+}
+
+func TestNewAggregator_Errors(t *testing.T) {
+	_, err := NewAggregator(&Config{Pair: BTC_USD})
+	assert.Error(t, err)
+
+	_, err = NewAggregator(&Config{Pair: BTC_USD, Venues: []Venue{{API: sim.New(nil), Pair: NewCurrencyPair(BTC, EUR)}}})
+	assert.Error(t, err, "no eur rate")
+
+	_, err = NewAggregator(&Config{Pair: BTC_USD, Venues: []Venue{{API: sim.New(nil)}, {API: sim.New(nil)}}})
+	assert.Error(t, err, "same name")
+
+	_, err = NewAggregator(&Config{Pair: BTC_USDT, Venues: []Venue{{API: sim.New(nil), Pair: ETH_USDT}}})
+	assert.Error(t, err)
+}
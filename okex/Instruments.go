@@ -0,0 +1,108 @@
+package okex
+
+import (
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+// okex v3 does not publish a status, the listed instruments are trading.
+
+func (ok *Exchange) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	return ok.OKExSpot.GetInstrumentsInfo()
+}
+
+func (ok *OKExSpot) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	var response []struct {
+		InstrumentId  string  `json:"instrument_id"`
+		BaseCurrency  string  `json:"base_currency"`
+		QuoteCurrency string  `json:"quote_currency"`
+		MinSize       Decimal `json:"min_size"`
+		SizeIncrement Decimal `json:"size_increment"`
+		TickSize      Decimal `json:"tick_size"`
+	}
+	err := ok.DoRequest("GET", "/api/spot/v3/instruments", "", &response)
+	if err != nil {
+		return nil, err
+	}
+
+	instruments := make([]InstrumentInfo, 0, len(response))
+	for _, v := range response {
+		instruments = append(instruments, InstrumentInfo{
+			Id:        v.InstrumentId,
+			Pair:      NewCurrencyPair(NewCurrency(v.BaseCurrency, ""), NewCurrency(v.QuoteCurrency, "")),
+			Kind:      SPOT,
+			TickSize:  v.TickSize,
+			LotSize:   v.SizeIncrement,
+			MinAmount: v.MinSize,
+			Status:    INSTRUMENT_TRADING,
+		})
+	}
+	return instruments, nil
+}
+
+func (ok *OKExFuture) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	var response []struct {
+		InstrumentId        string  `json:"instrument_id"`
+		Underlying          string  `json:"underlying"`
+		TickSize            Decimal `json:"tick_size"`
+		TradeIncrement      Decimal `json:"trade_increment"`
+		ContractVal         Decimal `json:"contract_val"`
+		ContractValCurrency string  `json:"contract_val_currency"`
+		Delivery            string  `json:"delivery"`
+		Alias               string  `json:"alias"`
+	}
+	err := ok.DoRequest("GET", "/api/futures/v3/instruments", "", &response)
+	if err != nil {
+		return nil, err
+	}
+
+	instruments := make([]InstrumentInfo, 0, len(response))
+	for _, v := range response {
+		ins := InstrumentInfo{
+			Id:                  v.InstrumentId,
+			Pair:                NewCurrencyPair3(v.Underlying, "-"),
+			Kind:                FUTURE,
+			ContractType:        v.Alias,
+			TickSize:            v.TickSize,
+			LotSize:             v.TradeIncrement,
+			MinAmount:           v.TradeIncrement,
+			ContractVal:         v.ContractVal,
+			ContractValCurrency: NewCurrency(v.ContractValCurrency, ""),
+			Status:              INSTRUMENT_TRADING,
+		}
+		if delivery, err := time.Parse("2006-01-02", v.Delivery); err == nil {
+			ins.Expiry = delivery.Add(8 * time.Hour) //16:00 in Hong Kong
+		}
+		instruments = append(instruments, ins)
+	}
+	return instruments, nil
+}
+
+func (ok *OKExSwap) GetInstrumentsInfo() ([]InstrumentInfo, error) {
+	swaps, err := ok.GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+
+	instruments := make([]InstrumentInfo, 0, len(swaps))
+	for _, v := range swaps {
+		ins := InstrumentInfo{
+			Id:                  v.InstrumentID,
+			Pair:                NewCurrencyPair3(v.Underlying, "-"),
+			Kind:                SWAP,
+			ContractType:        SWAP_CONTRACT,
+			TickSize:            DecimalFromFloat(v.TickSize),
+			LotSize:             NewDecimal(int64(v.SizeIncrement), 0),
+			MinAmount:           NewDecimal(int64(v.SizeIncrement), 0),
+			ContractVal:         DecimalFromFloat(v.ContractVal),
+			ContractValCurrency: NewCurrency(v.ContractValCurrency, ""),
+			Status:              INSTRUMENT_TRADING,
+		}
+		if !v.IsInverse {
+			ins.Kind, ins.ContractType = SWAP_USDT, SWAP_USDT_CONTRACT
+		}
+		instruments = append(instruments, ins)
+	}
+	return instruments, nil
+}
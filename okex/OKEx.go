@@ -2,6 +2,7 @@ package okex
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -53,10 +54,14 @@ func (ok *Exchange) UUID() string {
 }
 
 func (ok *Exchange) DoRequest(httpMethod, uri, reqBody string, response interface{}) error {
+	return ok.DoRequestWithContext(context.Background(), httpMethod, uri, reqBody, response)
+}
+
+func (ok *Exchange) DoRequestWithContext(ctx context.Context, httpMethod, uri, reqBody string, response interface{}) error {
 	url := ok.config.Endpoint + uri
 	sign, timestamp := ok.doParamSign(httpMethod, uri, reqBody)
 	//logger.Log.Debug("timestamp=", timestamp, ", sign=", sign)
-	resp, err := NewHttpRequest(ok.config.HttpClient, httpMethod, url, reqBody, map[string]string{
+	resp, err := NewHttpRequestWithContext(ctx, ok.config.HttpClient, httpMethod, url, reqBody, map[string]string{
 		CONTENT_TYPE: APPLICATION_JSON_UTF8,
 		ACCEPT:       APPLICATION_JSON,
 		//COOKIE:               LOCALE + "en_US",
@@ -66,13 +71,85 @@ func (ok *Exchange) DoRequest(httpMethod, uri, reqBody string, response interfac
 		OK_ACCESS_TIMESTAMP:  fmt.Sprint(timestamp)})
 	if err != nil {
 		//log.Println(err)
-		return err
+		return adaptError(err)
 	} else {
 		logger.Log.Debug(string(resp))
 		return json.Unmarshal(resp, &response)
 	}
 }
 
+var errorCodes = ErrorCodes{
+	"30001": EX_ERR_AUTH,
+	"30002": EX_ERR_AUTH,
+	"30004": EX_ERR_AUTH,
+	"30005": EX_ERR_TIMESTAMP,
+	"30006": EX_ERR_AUTH,
+	"30008": EX_ERR_TIMESTAMP,
+	"30012": EX_ERR_AUTH,
+	"30013": EX_ERR_SIGN,
+	"30014": EX_ERR_API_LIMIT,
+	"30015": EX_ERR_AUTH,
+	"30023": EX_ERR_INVALID_PARAMETER,
+	"30024": EX_ERR_INVALID_PARAMETER,
+	"30026": EX_ERR_API_LIMIT,
+	"30027": EX_ERR_AUTH,
+	"30030": EX_ERR_SERVER,
+	"30032": EX_ERR_INVALID_CURRENCY_PAIR,
+	"32014": EX_ERR_INSUFFICIENT_BALANCE,
+	"32015": EX_ERR_INSUFFICIENT_BALANCE,
+	"32016": EX_ERR_INSUFFICIENT_BALANCE,
+	"32019": EX_ERR_INVALID_PARAMETER,
+	"32020": EX_ERR_INVALID_PARAMETER,
+	"33013": EX_ERR_PLACE_ORDER_FAIL,
+	"33014": EX_ERR_NOT_FIND_ORDER,
+	"33017": EX_ERR_INSUFFICIENT_BALANCE,
+	"33026": EX_ERR_ORDER_STATE,
+	"33027": EX_ERR_ORDER_STATE,
+	"35001": EX_ERR_INVALID_CURRENCY_PAIR,
+	"35008": EX_ERR_INSUFFICIENT_BALANCE,
+	"35010": EX_ERR_INSUFFICIENT_BALANCE,
+	"35014": EX_ERR_INVALID_PARAMETER,
+	"35029": EX_ERR_NOT_FIND_ORDER,
+}
+
+// adaptError maps the {"error_code":"33014","error_message":"..."} body of a failed
+// request to an ApiError, other errors are returned as they are.
+func adaptError(err error) error {
+	var apiErr ApiError
+	if !errors.As(err, &apiErr) || apiErr.HttpStatus == 0 {
+		return err
+	}
+
+	var resp struct {
+		ErrorCode    interface{} `json:"error_code"`
+		ErrorMessage string      `json:"error_message"`
+		Code         interface{} `json:"code"`
+		Message      string      `json:"message"`
+	}
+	if json.Unmarshal([]byte(apiErr.OriginErrMsg), &resp) != nil {
+		return err
+	}
+	if resp.ErrorCode == nil {
+		resp.ErrorCode, resp.ErrorMessage = resp.Code, resp.Message
+	}
+	if resp.ErrorCode == nil {
+		return err
+	}
+	return adaptErrorCode(apiErr.HttpStatus, fmt.Sprint(resp.ErrorCode), resp.ErrorMessage, apiErr)
+}
+
+// adaptErrorCode maps the error_code of a response, fallback is the kind of an unknown code.
+func adaptErrorCode(httpStatus int, code, msg string, fallback ApiError) ApiError {
+	e := errorCodes.Adapt(httpStatus, code, msg)
+	if _, ok := errorCodes[code]; !ok && errors.Is(e, API_ERR) {
+		e.ErrCode = fallback.ErrCode
+	}
+	if strings.Contains(strings.ToLower(msg), "client_oid") && strings.Contains(strings.ToLower(msg), "duplicate") {
+		e.ErrCode = EX_ERR_DUPLICATE_CLIENT_OID.ErrCode
+	}
+	return e
+}
+
 func (ok *Exchange) adaptOrderState(state int) TradeStatus {
 	switch state {
 	case -2:
@@ -133,55 +210,107 @@ func (ok *Exchange) IsoTime() string {
 }
 
 func (ok *Exchange) LimitBuy(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
-	return ok.OKExSpot.LimitBuy(amount, price, currency, opt...)
+	return ok.LimitBuyWithContext(context.Background(), amount, price, currency, opt...)
+}
+
+func (ok *Exchange) LimitBuyWithContext(ctx context.Context, amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	return ok.OKExSpot.LimitBuyWithContext(ctx, amount, price, currency, opt...)
 }
 
 func (ok *Exchange) LimitSell(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
-	return ok.OKExSpot.LimitSell(amount, price, currency, opt...)
+	return ok.LimitSellWithContext(context.Background(), amount, price, currency, opt...)
+}
+
+func (ok *Exchange) LimitSellWithContext(ctx context.Context, amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	return ok.OKExSpot.LimitSellWithContext(ctx, amount, price, currency, opt...)
 }
 
 func (ok *Exchange) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	return ok.OKExSpot.MarketBuy(amount, price, currency)
+	return ok.MarketBuyWithContext(context.Background(), amount, price, currency)
+}
+
+func (ok *Exchange) MarketBuyWithContext(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	return ok.OKExSpot.MarketBuyWithContext(ctx, amount, price, currency)
 }
 
 func (ok *Exchange) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	return ok.OKExSpot.MarketSell(amount, price, currency)
+	return ok.MarketSellWithContext(context.Background(), amount, price, currency)
+}
+
+func (ok *Exchange) MarketSellWithContext(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	return ok.OKExSpot.MarketSellWithContext(ctx, amount, price, currency)
 }
 
 func (ok *Exchange) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	return ok.OKExSpot.OKExSpot.CancelOrder(orderId, currency)
+	return ok.CancelOrderWithContext(context.Background(), orderId, currency)
+}
+
+func (ok *Exchange) CancelOrderWithContext(ctx context.Context, orderId string, currency CurrencyPair) (bool, error) {
+	return ok.OKExSpot.CancelOrderWithContext(ctx, orderId, currency)
 }
 
 func (ok *Exchange) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	return ok.OKExSpot.GetOneOrder(orderId, currency)
+	return ok.GetOneOrderWithContext(context.Background(), orderId, currency)
+}
+
+func (ok *Exchange) GetOneOrderWithContext(ctx context.Context, orderId string, currency CurrencyPair) (*Order, error) {
+	return ok.OKExSpot.GetOneOrderWithContext(ctx, orderId, currency)
 }
 
 func (ok *Exchange) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	return ok.OKExSpot.GetUnfinishOrders(currency)
+	return ok.GetUnfinishOrdersWithContext(context.Background(), currency)
+}
+
+func (ok *Exchange) GetUnfinishOrdersWithContext(ctx context.Context, currency CurrencyPair) ([]Order, error) {
+	return ok.OKExSpot.GetUnfinishOrdersWithContext(ctx, currency)
 }
 
 func (ok *Exchange) GetOrderHistorys(currency CurrencyPair, opt ...OptionalParameter) ([]Order, error) {
-	return ok.OKExSpot.GetOrderHistorys(currency, opt...)
+	return ok.GetOrderHistorysWithContext(context.Background(), currency, opt...)
+}
+
+func (ok *Exchange) GetOrderHistorysWithContext(ctx context.Context, currency CurrencyPair, opt ...OptionalParameter) ([]Order, error) {
+	return ok.OKExSpot.GetOrderHistorysWithContext(ctx, currency, opt...)
 }
 
 func (ok *Exchange) GetAccount() (*Account, error) {
-	return ok.OKExSpot.GetAccount()
+	return ok.GetAccountWithContext(context.Background())
+}
+
+func (ok *Exchange) GetAccountWithContext(ctx context.Context) (*Account, error) {
+	return ok.OKExSpot.GetAccountWithContext(ctx)
 }
 
 func (ok *Exchange) GetTicker(currency CurrencyPair) (*Ticker, error) {
-	return ok.OKExSpot.GetTicker(currency)
+	return ok.GetTickerWithContext(context.Background(), currency)
+}
+
+func (ok *Exchange) GetTickerWithContext(ctx context.Context, currency CurrencyPair) (*Ticker, error) {
+	return ok.OKExSpot.GetTickerWithContext(ctx, currency)
 }
 
 func (ok *Exchange) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-	return ok.OKExSpot.GetDepth(size, currency)
+	return ok.GetDepthWithContext(context.Background(), size, currency)
+}
+
+func (ok *Exchange) GetDepthWithContext(ctx context.Context, size int, currency CurrencyPair) (*Depth, error) {
+	return ok.OKExSpot.GetDepthWithContext(ctx, size, currency)
 }
 
 func (ok *Exchange) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
-	return ok.OKExSpot.GetKlineRecords(currency, period, size, optional...)
+	return ok.GetKlineRecordsWithContext(context.Background(), currency, period, size, optional...)
+}
+
+func (ok *Exchange) GetKlineRecordsWithContext(ctx context.Context, currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
+	return ok.OKExSpot.GetKlineRecordsWithContext(ctx, currency, period, size, optional...)
 }
 
 func (ok *Exchange) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	return ok.OKExSpot.GetTrades(currencyPair, since)
+	return ok.GetTradesWithContext(context.Background(), currencyPair, since)
+}
+
+func (ok *Exchange) GetTradesWithContext(ctx context.Context, currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	return ok.OKExSpot.GetTradesWithContext(ctx, currencyPair, since)
 }
 
 func (ok *Exchange) GetAssets(currency CurrencyPair) (*Assets, error) {
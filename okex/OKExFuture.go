@@ -1,8 +1,10 @@
 package okex
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"sort"
 	"sync"
@@ -56,13 +58,17 @@ func (ok *OKExFuture) GetRate() (float64, error) {
 }
 
 func (ok *OKExFuture) GetFutureEstimatedPrice(currencyPair CurrencyPair) (float64, error) {
+	return ok.GetFutureEstimatedPriceWithContext(context.Background(), currencyPair)
+}
+
+func (ok *OKExFuture) GetFutureEstimatedPriceWithContext(ctx context.Context, currencyPair CurrencyPair) (float64, error) {
 	urlPath := fmt.Sprintf("/api/futures/v3/instruments/%s/estimated_price", ok.GetFutureContractId(currencyPair, QUARTER_CONTRACT))
 	var response struct {
 		InstrumentId    string  `json:"instrument_id"`
 		SettlementPrice float64 `json:"settlement_price,string"`
 		Timestamp       string  `json:"timestamp"`
 	}
-	err := ok.DoRequest("GET", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return 0, err
 	}
@@ -158,11 +164,15 @@ type tickerResponse struct {
 }
 
 func (ok *OKExFuture) GetFutureTicker(currencyPair CurrencyPair, contractType string) (*Ticker, error) {
+	return ok.GetFutureTickerWithContext(context.Background(), currencyPair, contractType)
+}
+
+func (ok *OKExFuture) GetFutureTickerWithContext(ctx context.Context, currencyPair CurrencyPair, contractType string) (*Ticker, error) {
 	var (
 		urlPath  = fmt.Sprintf("/api/futures/v3/instruments/%s/ticker", ok.GetFutureContractId(currencyPair, contractType))
 		response tickerResponse
 	)
-	err := ok.DoRequest("GET", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -214,15 +224,20 @@ type depthResponse struct {
 	Asks         [][4]interface{} `json:"asks"`
 	InstrumentId string           `json:"instrument_id"`
 	Timestamp    string           `json:"timestamp"`
+	Checksum     int32            `json:"checksum"` //incremental depth channels only
 }
 
 func (ok *OKExFuture) GetFutureDepth(currencyPair CurrencyPair, contractType string, size int) (*Depth, error) {
+	return ok.GetFutureDepthWithContext(context.Background(), currencyPair, contractType, size)
+}
+
+func (ok *OKExFuture) GetFutureDepthWithContext(ctx context.Context, currencyPair CurrencyPair, contractType string, size int) (*Depth, error) {
 	var (
 		response depthResponse
 		dep      Depth
 	)
 	urlPath := fmt.Sprintf("/api/futures/v3/instruments/%s/book?size=%d", ok.GetFutureContractId(currencyPair, contractType), size)
-	err := ok.DoRequest("GET", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -244,6 +259,10 @@ func (ok *OKExFuture) GetFutureDepth(currencyPair CurrencyPair, contractType str
 }
 
 func (ok *OKExFuture) GetFutureIndex(currencyPair CurrencyPair) (float64, error) {
+	return ok.GetFutureIndexWithContext(context.Background(), currencyPair)
+}
+
+func (ok *OKExFuture) GetFutureIndexWithContext(ctx context.Context, currencyPair CurrencyPair) (float64, error) {
 	//统一交易对，当周，次周，季度指数一样的
 	urlPath := fmt.Sprintf("/api/futures/v3/instruments/%s/index", ok.GetFutureContractId(currencyPair, QUARTER_CONTRACT))
 	var response struct {
@@ -251,7 +270,7 @@ func (ok *OKExFuture) GetFutureIndex(currencyPair CurrencyPair) (float64, error)
 		Index        float64 `json:"index,string"`
 		Timestamp    string  `json:"timestamp"`
 	}
-	err := ok.DoRequest("GET", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return 0, nil
 	}
@@ -269,10 +288,14 @@ type CrossedAccountInfo struct {
 }
 
 func (ok *OKExFuture) GetAccounts(currencyPair CurrencyPair) (*FutureAccount, error) {
+	return ok.GetAccountsWithContext(context.Background(), currencyPair)
+}
+
+func (ok *OKExFuture) GetAccountsWithContext(ctx context.Context, currencyPair CurrencyPair) (*FutureAccount, error) {
 	urlPath := "/api/futures/v3/accounts/" + currencyPair.ToLower().ToSymbol("-")
 	var response CrossedAccountInfo
 
-	err := ok.DoRequest("GET", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -299,8 +322,12 @@ func (ok *OKExFuture) GetAccounts(currencyPair CurrencyPair) (*FutureAccount, er
 //基本上已经报废，OK限制10s一次，但是基本上都会返回error：{"code":30014,"message":"Too Many Requests"}
 //加入currency  pair救活了这个接口
 func (ok *OKExFuture) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAccount, error) {
+	return ok.GetFutureUserinfoWithContext(context.Background(), currencyPair...)
+}
+
+func (ok *OKExFuture) GetFutureUserinfoWithContext(ctx context.Context, currencyPair ...CurrencyPair) (*FutureAccount, error) {
 	if len(currencyPair) == 1 {
-		return ok.GetAccounts(currencyPair[0])
+		return ok.GetAccountsWithContext(ctx, currencyPair[0])
 	}
 
 	urlPath := "/api/futures/v3/accounts"
@@ -308,7 +335,7 @@ func (ok *OKExFuture) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAc
 		Info map[string]map[string]interface{}
 	}
 
-	err := ok.DoRequest("GET", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -336,12 +363,7 @@ func (ok *OKExFuture) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAc
 func (ok *OKExFuture) normalizePrice(price float64, pair CurrencyPair) string {
 	for _, info := range ok.allContractInfo.contractInfos {
 		if info.UnderlyingIndex == pair.CurrencyA.Symbol && info.QuoteCurrency == pair.CurrencyB.Symbol {
-			var bit = 0
-			for info.TickSize < 1 {
-				bit++
-				info.TickSize *= 10
-			}
-			return FloatToString(price, bit)
+			return DecimalFromFloat(price).RoundStep(DecimalFromFloat(info.TickSize)).Normalize().String()
 		}
 	}
 	return FloatToString(price, 2)
@@ -349,6 +371,10 @@ func (ok *OKExFuture) normalizePrice(price float64, pair CurrencyPair) string {
 
 //matchPrice:是否以对手价下单(0:不是 1:是)，默认为0;当取值为1时,price字段无效，当以对手价下单，order_type只能选择0:普通委托
 func (ok *OKExFuture) PlaceFutureOrder2(matchPrice int, ord *FutureOrder) (*FutureOrder, error) {
+	return ok.PlaceFutureOrder2WithContext(context.Background(), matchPrice, ord)
+}
+
+func (ok *OKExFuture) PlaceFutureOrder2WithContext(ctx context.Context, matchPrice int, ord *FutureOrder) (*FutureOrder, error) {
 	urlPath := "/api/futures/v3/order"
 	var param struct {
 		ClientOid    string `json:"client_oid"`
@@ -387,12 +413,16 @@ func (ok *OKExFuture) PlaceFutureOrder2(matchPrice int, ord *FutureOrder) (*Futu
 	}
 
 	reqBody, _, _ := ok.BuildRequestBody(param)
-	err := ok.DoRequest("POST", urlPath, reqBody, &response)
+	err := ok.DoRequestWithContext(ctx, "POST", urlPath, reqBody, &response)
 
 	if err != nil {
 		return ord, err
 	}
 
+	if response.ErrorCode != "" && response.ErrorCode != "0" {
+		return ord, adaptErrorCode(http.StatusOK, response.ErrorCode, response.ErrorMessage, EX_ERR_PLACE_ORDER_FAIL)
+	}
+
 	ord.ClientOid = response.ClientOid
 	ord.OrderID2 = response.OrderId
 	ord.OrderTime = time.Now().UnixNano() / int64(time.Millisecond)
@@ -412,6 +442,10 @@ func (ok *OKExFuture) PlaceFutureOrder(currencyPair CurrencyPair, contractType,
 }
 
 func (ok *OKExFuture) LimitFuturesOrder(currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	return ok.LimitFuturesOrderWithContext(context.Background(), currencyPair, contractType, price, amount, openType, opt...)
+}
+
+func (ok *OKExFuture) LimitFuturesOrderWithContext(ctx context.Context, currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
 	ord := &FutureOrder{
 		Currency:     currencyPair,
 		Price:        ToFloat64(price),
@@ -431,11 +465,15 @@ func (ok *OKExFuture) LimitFuturesOrder(currencyPair CurrencyPair, contractType,
 		}
 	}
 
-	return ok.PlaceFutureOrder2(0, ord)
+	return ok.PlaceFutureOrder2WithContext(ctx, 0, ord)
 }
 
 func (ok *OKExFuture) MarketFuturesOrder(currencyPair CurrencyPair, contractType, amount string, openType int) (*FutureOrder, error) {
-	return ok.PlaceFutureOrder2(1, &FutureOrder{
+	return ok.MarketFuturesOrderWithContext(context.Background(), currencyPair, contractType, amount, openType)
+}
+
+func (ok *OKExFuture) MarketFuturesOrderWithContext(ctx context.Context, currencyPair CurrencyPair, contractType, amount string, openType int) (*FutureOrder, error) {
+	return ok.PlaceFutureOrder2WithContext(ctx, 1, &FutureOrder{
 		Currency:     currencyPair,
 		Amount:       ToFloat64(amount),
 		OType:        openType,
@@ -444,6 +482,10 @@ func (ok *OKExFuture) MarketFuturesOrder(currencyPair CurrencyPair, contractType
 }
 
 func (ok *OKExFuture) FutureCancelOrder(currencyPair CurrencyPair, contractType, orderId string) (bool, error) {
+	return ok.FutureCancelOrderWithContext(context.Background(), currencyPair, contractType, orderId)
+}
+
+func (ok *OKExFuture) FutureCancelOrderWithContext(ctx context.Context, currencyPair CurrencyPair, contractType, orderId string) (bool, error) {
 	urlPath := fmt.Sprintf("/api/futures/v3/cancel_order/%s/%s", ok.GetFutureContractId(currencyPair, contractType), orderId)
 	var response struct {
 		Result       bool   `json:"result"`
@@ -451,7 +493,7 @@ func (ok *OKExFuture) FutureCancelOrder(currencyPair CurrencyPair, contractType,
 		ClientOid    string `json:"client_oid"`
 		InstrumentId string `json:"instrument_id"`
 	}
-	err := ok.DoRequest("POST", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "POST", urlPath, "", &response)
 	if err != nil {
 		return false, err
 	}
@@ -459,6 +501,10 @@ func (ok *OKExFuture) FutureCancelOrder(currencyPair CurrencyPair, contractType,
 }
 
 func (ok *OKExFuture) GetFuturePosition(currencyPair CurrencyPair, contractType string) ([]FuturePosition, error) {
+	return ok.GetFuturePositionWithContext(context.Background(), currencyPair, contractType)
+}
+
+func (ok *OKExFuture) GetFuturePositionWithContext(ctx context.Context, currencyPair CurrencyPair, contractType string) ([]FuturePosition, error) {
 	urlPath := fmt.Sprintf("/api/futures/v3/%s/position", ok.GetFutureContractId(currencyPair, contractType))
 	var response struct {
 		Result     bool   `json:"result"`
@@ -488,7 +534,7 @@ func (ok *OKExFuture) GetFuturePosition(currencyPair CurrencyPair, contractType
 			UpdatedAt            time.Time `json:"updated_at"`
 		}
 	}
-	err := ok.DoRequest("GET", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -530,10 +576,18 @@ func (ok *OKExFuture) GetFuturePosition(currencyPair CurrencyPair, contractType
 }
 
 func (ok *OKExFuture) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	return ok.GetFutureOrdersWithContext(context.Background(), orderIds, currencyPair, contractType)
+}
+
+func (ok *OKExFuture) GetFutureOrdersWithContext(ctx context.Context, orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
 	panic("")
 }
 
 func (ok *OKExFuture) GetFutureOrderHistory(pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error) {
+	return ok.GetFutureOrderHistoryWithContext(context.Background(), pair, contractType, optional...)
+}
+
+func (ok *OKExFuture) GetFutureOrderHistoryWithContext(ctx context.Context, pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error) {
 	urlPath := fmt.Sprintf("/api/futures/v3/orders/%s?", ok.GetFutureContractId(pair, contractType))
 
 	param := url.Values{}
@@ -547,7 +601,7 @@ func (ok *OKExFuture) GetFutureOrderHistory(pair CurrencyPair, contractType stri
 		OrderInfo []futureOrderResponse `json:"order_info"`
 	}
 
-	err := ok.DoRequest("GET", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -602,9 +656,13 @@ func (ok *OKExFuture) adaptOrder(response futureOrderResponse) FutureOrder {
 }
 
 func (ok *OKExFuture) GetFutureOrder(orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error) {
+	return ok.GetFutureOrderWithContext(context.Background(), orderId, currencyPair, contractType)
+}
+
+func (ok *OKExFuture) GetFutureOrderWithContext(ctx context.Context, orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error) {
 	urlPath := fmt.Sprintf("/api/futures/v3/orders/%s/%s", ok.GetFutureContractId(currencyPair, contractType), orderId)
 	var response futureOrderResponse
-	err := ok.DoRequest("GET", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -616,12 +674,16 @@ func (ok *OKExFuture) GetFutureOrder(orderId string, currencyPair CurrencyPair,
 }
 
 func (ok *OKExFuture) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	return ok.GetUnfinishFutureOrdersWithContext(context.Background(), currencyPair, contractType)
+}
+
+func (ok *OKExFuture) GetUnfinishFutureOrdersWithContext(ctx context.Context, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
 	urlPath := fmt.Sprintf("/api/futures/v3/orders/%s?state=6&limit=100", ok.GetFutureContractId(currencyPair, contractType))
 	var response struct {
 		Result    bool                  `json:"result"`
 		OrderInfo []futureOrderResponse `json:"order_info"`
 	}
-	err := ok.DoRequest("GET", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -659,6 +721,10 @@ func (ok *OKExFuture) GetDeliveryTime() (int, int, int, int) {
 }
 
 func (ok *OKExFuture) GetKlineRecords(contractType string, currency CurrencyPair, period KlinePeriod, size int, opt ...OptionalParameter) ([]FutureKline, error) {
+	return ok.GetKlineRecordsWithContext(context.Background(), contractType, currency, period, size, opt...)
+}
+
+func (ok *OKExFuture) GetKlineRecordsWithContext(ctx context.Context, contractType string, currency CurrencyPair, period KlinePeriod, size int, opt ...OptionalParameter) ([]FutureKline, error) {
 	urlPath := "/api/futures/v3/instruments/%s/candles?granularity=%d"
 	contractId := ok.GetFutureContractId(currency, contractType)
 	granularity := adaptKLinePeriod(KlinePeriod(period))
@@ -666,8 +732,15 @@ func (ok *OKExFuture) GetKlineRecords(contractType string, currency CurrencyPair
 		return nil, errors.New("kline period parameter is error")
 	}
 
+	reqPath := fmt.Sprintf(urlPath, contractId, granularity)
+	optParam := url.Values{}
+	MergeOptionalParameter(&optParam, opt...)
+	if len(optParam) > 0 {
+		reqPath += "&" + optParam.Encode()
+	}
+
 	var response [][]interface{}
-	err := ok.DoRequest("GET", fmt.Sprintf(urlPath, contractId, granularity), "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", reqPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -730,6 +803,10 @@ func (ok *OKExFuture) GetKlineRecordsByRange(contractType string, currency Curre
 }
 
 func (ok *OKExFuture) GetTrades(contractType string, currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	return ok.GetTradesWithContext(context.Background(), contractType, currencyPair, since)
+}
+
+func (ok *OKExFuture) GetTradesWithContext(ctx context.Context, contractType string, currencyPair CurrencyPair, since int64) ([]Trade, error) {
 	panic("")
 }
 
@@ -766,7 +843,7 @@ func (ok *OKExFuture) MarketCloseAllPosition(currency CurrencyPair, contract str
 	}
 
 	if !response.Result {
-		return false, errors.New(response.Message)
+		return false, adaptErrorCode(http.StatusOK, fmt.Sprint(response.Code), response.Message, API_ERR)
 	}
 
 	return true, nil
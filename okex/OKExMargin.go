@@ -1,8 +1,8 @@
 package okex
 
 import (
-	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 
 	. "github.com/soulsplit/goex"
@@ -79,7 +79,7 @@ func (ok *ExchangeMargin) Borrow(parameter BorrowParameter) (borrowId string, er
 	}
 
 	if response.ErrorMessage != "" {
-		return "", errors.New(response.ErrorMessage)
+		return "", adaptErrorCode(http.StatusOK, response.ErrorCode, response.ErrorMessage, API_ERR)
 	}
 
 	return response.BorrowId, nil
@@ -112,7 +112,7 @@ func (ok *ExchangeMargin) Repayment(parameter RepaymentParameter) (repaymentId s
 	}
 
 	if !response.Result {
-		return "", errors.New(response.Message)
+		return "", adaptErrorCode(http.StatusOK, response.Code, response.Message, API_ERR)
 	}
 
 	return response.RepaymentId, nil
@@ -151,7 +151,7 @@ func (ok *ExchangeMargin) PlaceOrder(ord *Order) (*Order, error) {
 	}
 
 	if !response.Result {
-		return nil, errors.New(response.ErrorMessage)
+		return nil, adaptErrorCode(http.StatusOK, response.ErrorCode, response.ErrorMessage, EX_ERR_PLACE_ORDER_FAIL)
 	}
 
 	ord.Cid = response.ClientOid
@@ -194,7 +194,7 @@ func (ok *ExchangeMargin) CancelOrder(orderId string, currency CurrencyPair) (bo
 	}
 
 	if !response.Result {
-		return false, errors.New(response.ErrorMessage)
+		return false, adaptErrorCode(http.StatusOK, response.ErrorCode, response.ErrorMessage, EX_ERR_CANCEL_ORDER_FAIL)
 	}
 
 	return true, nil
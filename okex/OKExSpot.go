@@ -1,13 +1,14 @@
 package okex
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/go-openapi/errors"
 	. "github.com/soulsplit/goex"
 	"github.com/soulsplit/goex/internal/logger"
 )
@@ -28,6 +29,10 @@ type OKExSpot struct {
 //    ...]
 
 func (ok *OKExSpot) GetAccount() (*Account, error) {
+	return ok.GetAccountWithContext(context.Background())
+}
+
+func (ok *OKExSpot) GetAccountWithContext(ctx context.Context) (*Account, error) {
 	urlPath := "/api/spot/v3/accounts"
 	var response []struct {
 		Frozen    float64 `json:"frozen,string"`
@@ -38,7 +43,7 @@ func (ok *OKExSpot) GetAccount() (*Account, error) {
 		Holds     float64 `json:"holds,string"`
 	}
 
-	err := ok.Exchange.DoRequest("GET", urlPath, "", &response)
+	err := ok.Exchange.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -111,6 +116,10 @@ func (ok *OKExSpot) BatchPlaceOrders(orders []Order) ([]PlaceOrderResponse, erro
 }
 
 func (ok *OKExSpot) PlaceOrder(ty string, ord *Order) (*Order, error) {
+	return ok.PlaceOrderWithContext(context.Background(), ty, ord)
+}
+
+func (ok *OKExSpot) PlaceOrderWithContext(ctx context.Context, ty string, ord *Order) (*Order, error) {
 	urlPath := "/api/spot/v3/orders"
 	param := PlaceOrderParam{
 		ClientOid:    GenerateOrderClientId(32),
@@ -149,13 +158,13 @@ func (ok *OKExSpot) PlaceOrder(ty string, ord *Order) (*Order, error) {
 	}
 
 	jsonStr, _, _ := ok.Exchange.BuildRequestBody(param)
-	err := ok.Exchange.DoRequest("POST", urlPath, jsonStr, &response)
+	err := ok.Exchange.DoRequestWithContext(ctx, "POST", urlPath, jsonStr, &response)
 	if err != nil {
 		return nil, err
 	}
 
 	if !response.Result {
-		return nil, errors.New(int32(ToInt(response.ErrorCode)), response.ErrorMessage)
+		return nil, adaptErrorCode(http.StatusOK, response.ErrorCode, response.ErrorMessage, EX_ERR_PLACE_ORDER_FAIL)
 	}
 
 	ord.Cid = response.ClientOid
@@ -165,11 +174,15 @@ func (ok *OKExSpot) PlaceOrder(ty string, ord *Order) (*Order, error) {
 }
 
 func (ok *OKExSpot) LimitBuy(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	return ok.LimitBuyWithContext(context.Background(), amount, price, currency, opt...)
+}
+
+func (ok *OKExSpot) LimitBuyWithContext(ctx context.Context, amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
 	ty := "limit"
 	if len(opt) > 0 {
 		ty = opt[0].String()
 	}
-	return ok.PlaceOrder(ty, &Order{
+	return ok.PlaceOrderWithContext(ctx, ty, &Order{
 		Price:    ToFloat64(price),
 		Amount:   ToFloat64(amount),
 		Currency: currency,
@@ -178,11 +191,15 @@ func (ok *OKExSpot) LimitBuy(amount, price string, currency CurrencyPair, opt ..
 }
 
 func (ok *OKExSpot) LimitSell(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	return ok.LimitSellWithContext(context.Background(), amount, price, currency, opt...)
+}
+
+func (ok *OKExSpot) LimitSellWithContext(ctx context.Context, amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
 	ty := "limit"
 	if len(opt) > 0 {
 		ty = opt[0].String()
 	}
-	return ok.PlaceOrder(ty, &Order{
+	return ok.PlaceOrderWithContext(ctx, ty, &Order{
 		Price:    ToFloat64(price),
 		Amount:   ToFloat64(amount),
 		Currency: currency,
@@ -191,7 +208,11 @@ func (ok *OKExSpot) LimitSell(amount, price string, currency CurrencyPair, opt .
 }
 
 func (ok *OKExSpot) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	return ok.PlaceOrder("market", &Order{
+	return ok.MarketBuyWithContext(context.Background(), amount, price, currency)
+}
+
+func (ok *OKExSpot) MarketBuyWithContext(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	return ok.PlaceOrderWithContext(ctx, "market", &Order{
 		Price:    ToFloat64(price),
 		Amount:   ToFloat64(amount),
 		Currency: currency,
@@ -200,7 +221,11 @@ func (ok *OKExSpot) MarketBuy(amount, price string, currency CurrencyPair) (*Ord
 }
 
 func (ok *OKExSpot) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	return ok.PlaceOrder("market", &Order{
+	return ok.MarketSellWithContext(context.Background(), amount, price, currency)
+}
+
+func (ok *OKExSpot) MarketSellWithContext(ctx context.Context, amount, price string, currency CurrencyPair) (*Order, error) {
+	return ok.PlaceOrderWithContext(ctx, "market", &Order{
 		Price:    ToFloat64(price),
 		Amount:   ToFloat64(amount),
 		Currency: currency,
@@ -210,6 +235,10 @@ func (ok *OKExSpot) MarketSell(amount, price string, currency CurrencyPair) (*Or
 
 //orderId can set client oid or orderId
 func (ok *OKExSpot) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
+	return ok.CancelOrderWithContext(context.Background(), orderId, currency)
+}
+
+func (ok *OKExSpot) CancelOrderWithContext(ctx context.Context, orderId string, currency CurrencyPair) (bool, error) {
 	urlPath := "/api/spot/v3/cancel_orders/" + orderId
 	param := struct {
 		InstrumentId string `json:"instrument_id"`
@@ -222,14 +251,14 @@ func (ok *OKExSpot) CancelOrder(orderId string, currency CurrencyPair) (bool, er
 		ErrorCode    string `json:"error_code"`
 		ErrorMessage string `json:"error_message"`
 	}
-	err := ok.Exchange.DoRequest("POST", urlPath, reqBody, &response)
+	err := ok.Exchange.DoRequestWithContext(ctx, "POST", urlPath, reqBody, &response)
 	if err != nil {
 		return false, err
 	}
 	if response.Result {
 		return true, nil
 	}
-	return false, errors.New(400, fmt.Sprintf("cancel fail, %s", response.ErrorMessage))
+	return false, adaptErrorCode(http.StatusOK, response.ErrorCode, fmt.Sprintf("cancel fail, %s", response.ErrorMessage), EX_ERR_CANCEL_ORDER_FAIL)
 }
 
 type OrderResponse struct {
@@ -291,13 +320,17 @@ func (ok *OKExSpot) adaptOrder(response OrderResponse) *Order {
 
 //orderId can set client oid or orderId
 func (ok *OKExSpot) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
+	return ok.GetOneOrderWithContext(context.Background(), orderId, currency)
+}
+
+func (ok *OKExSpot) GetOneOrderWithContext(ctx context.Context, orderId string, currency CurrencyPair) (*Order, error) {
 	urlPath := "/api/spot/v3/orders/" + orderId + "?instrument_id=" + currency.AdaptUsdToUsdt().ToSymbol("-")
 	//param := struct {
 	//	InstrumentId string `json:"instrument_id"`
 	//}{currency.AdaptUsdToUsdt().ToLower().ToSymbol("-")}
 	//reqBody, _, _ := ok.BuildRequestBody(param)
 	var response OrderResponse
-	err := ok.Exchange.DoRequest("GET", urlPath, "", &response)
+	err := ok.Exchange.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -309,9 +342,13 @@ func (ok *OKExSpot) GetOneOrder(orderId string, currency CurrencyPair) (*Order,
 }
 
 func (ok *OKExSpot) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
+	return ok.GetUnfinishOrdersWithContext(context.Background(), currency)
+}
+
+func (ok *OKExSpot) GetUnfinishOrdersWithContext(ctx context.Context, currency CurrencyPair) ([]Order, error) {
 	urlPath := fmt.Sprintf("/api/spot/v3/orders_pending?instrument_id=%s", currency.AdaptUsdToUsdt().ToSymbol("-"))
 	var response []OrderResponse
-	err := ok.Exchange.DoRequest("GET", urlPath, "", &response)
+	err := ok.Exchange.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -327,6 +364,10 @@ func (ok *OKExSpot) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
 }
 
 func (ok *OKExSpot) GetOrderHistorys(currency CurrencyPair, optional ...OptionalParameter) ([]Order, error) {
+	return ok.GetOrderHistorysWithContext(context.Background(), currency, optional...)
+}
+
+func (ok *OKExSpot) GetOrderHistorysWithContext(ctx context.Context, currency CurrencyPair, optional ...OptionalParameter) ([]Order, error) {
 	urlPath := "/api/spot/v3/orders"
 
 	param := url.Values{}
@@ -337,7 +378,7 @@ func (ok *OKExSpot) GetOrderHistorys(currency CurrencyPair, optional ...Optional
 	urlPath += "?" + param.Encode()
 
 	var response []OrderResponse
-	err := ok.Exchange.DoRequest("GET", urlPath, "", &response)
+	err := ok.Exchange.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -368,9 +409,13 @@ type spotTickerResponse struct {
 }
 
 func (ok *OKExSpot) GetTicker(currency CurrencyPair) (*Ticker, error) {
+	return ok.GetTickerWithContext(context.Background(), currency)
+}
+
+func (ok *OKExSpot) GetTickerWithContext(ctx context.Context, currency CurrencyPair) (*Ticker, error) {
 	urlPath := fmt.Sprintf("/api/spot/v3/instruments/%s/ticker", currency.AdaptUsdToUsdt().ToSymbol("-"))
 	var response spotTickerResponse
-	err := ok.Exchange.DoRequest("GET", urlPath, "", &response)
+	err := ok.Exchange.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -388,6 +433,10 @@ func (ok *OKExSpot) GetTicker(currency CurrencyPair) (*Ticker, error) {
 }
 
 func (ok *OKExSpot) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
+	return ok.GetDepthWithContext(context.Background(), size, currency)
+}
+
+func (ok *OKExSpot) GetDepthWithContext(ctx context.Context, size int, currency CurrencyPair) (*Depth, error) {
 	urlPath := fmt.Sprintf("/api/spot/v3/instruments/%s/book?size=%d", currency.AdaptUsdToUsdt().ToSymbol("-"), size)
 
 	var response struct {
@@ -396,7 +445,7 @@ func (ok *OKExSpot) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 		Timestamp string          `json:"timestamp"`
 	}
 
-	err := ok.Exchange.DoRequest("GET", urlPath, "", &response)
+	err := ok.Exchange.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -425,11 +474,11 @@ func (ok *OKExSpot) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 }
 
 func (ok *OKExSpot) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
-	urlPath := "/api/spot/v3/instruments/%s/candles?granularity=%d"
+	return ok.GetKlineRecordsWithContext(context.Background(), currency, period, size, optional...)
+}
 
-	optParam := url.Values{}
-	MergeOptionalParameter(&optParam, optional...)
-	urlPath += "&" + optParam.Encode()
+func (ok *OKExSpot) GetKlineRecordsWithContext(ctx context.Context, currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
+	urlPath := "/api/spot/v3/instruments/%s/candles?granularity=%d"
 
 	granularity := 60
 	switch period {
@@ -459,8 +508,13 @@ func (ok *OKExSpot) GetKlineRecords(currency CurrencyPair, period KlinePeriod, s
 		granularity = 1800
 	}
 
+	//the encoded start and end have % escapes, they are appended after Sprintf
+	optParam := url.Values{}
+	MergeOptionalParameter(&optParam, optional...)
+	reqPath := fmt.Sprintf(urlPath, currency.AdaptUsdToUsdt().ToSymbol("-"), granularity) + "&" + optParam.Encode()
+
 	var response [][]interface{}
-	err := ok.DoRequest("GET", fmt.Sprintf(urlPath, currency.AdaptUsdToUsdt().ToSymbol("-"), granularity), "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", reqPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -483,6 +537,10 @@ func (ok *OKExSpot) GetKlineRecords(currency CurrencyPair, period KlinePeriod, s
 
 //非个人，整个交易所的交易记录
 func (ok *OKExSpot) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	return ok.GetTradesWithContext(context.Background(), currencyPair, since)
+}
+
+func (ok *OKExSpot) GetTradesWithContext(ctx context.Context, currencyPair CurrencyPair, since int64) ([]Trade, error) {
 	urlPath := fmt.Sprintf("/api/spot/v3/instruments/%s/trades?limit=%d", currencyPair.AdaptUsdToUsdt().ToSymbol("-"), since)
 
 	var response []struct {
@@ -492,7 +550,7 @@ func (ok *OKExSpot) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade,
 		Size      float64 `json:"size,string"`
 		Side      string  `json:"side"`
 	}
-	err := ok.DoRequest("GET", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -19,7 +19,11 @@ type OKExV3SpotWs struct {
 	tickerCallback func(*Ticker)
 	depthCallback  func(*Depth)
 	tradeCallback  func(*Trade)
-	klineCallback  func(*Kline, KlinePeriod)
+	klineCallback  func(*Kline, KlinePeriod, bool)
+	klines         KlineCloser
+
+	orderCallback   func(*Order)
+	accountCallback func(*Account)
 }
 
 func NewOKExSpotV3Ws(base *Exchange) *OKExV3SpotWs {
@@ -30,6 +34,11 @@ func NewOKExSpotV3Ws(base *Exchange) *OKExV3SpotWs {
 	return okV3Ws
 }
 
+// ErrorHandleFunc receives connection errors and depth checksum errors (ErrDepthChecksum).
+func (okV3Ws *OKExV3SpotWs) ErrorHandleFunc(f func(err error)) {
+	okV3Ws.v3Ws.ErrorHandleFunc(f)
+}
+
 func (okV3Ws *OKExV3SpotWs) TickerCallback(tickerCallback func(*Ticker)) {
 	okV3Ws.tickerCallback = tickerCallback
 }
@@ -42,14 +51,14 @@ func (okV3Ws *OKExV3SpotWs) TradeCallback(tradeCallback func(*Trade)) {
 	okV3Ws.tradeCallback = tradeCallback
 }
 
-func (okV3Ws *OKExV3SpotWs) KLineCallback(klineCallback func(kline *Kline, period KlinePeriod)) {
+func (okV3Ws *OKExV3SpotWs) KlineCallback(klineCallback func(kline *Kline, period KlinePeriod, closed bool)) {
 	okV3Ws.klineCallback = klineCallback
 }
 
 func (okV3Ws *OKExV3SpotWs) SetCallbacks(tickerCallback func(*Ticker),
 	depthCallback func(*Depth),
 	tradeCallback func(*Trade),
-	klineCallback func(*Kline, KlinePeriod)) {
+	klineCallback func(*Kline, KlinePeriod, bool)) {
 	okV3Ws.tickerCallback = tickerCallback
 	okV3Ws.depthCallback = depthCallback
 	okV3Ws.tradeCallback = tradeCallback
@@ -61,49 +70,80 @@ func (okV3Ws *OKExV3SpotWs) SubscribeDepth(currencyPair CurrencyPair) error {
 		return errors.New("please set depth callback func")
 	}
 
-	return okV3Ws.v3Ws.Subscribe(map[string]interface{}{
-		"op":   "subscribe",
-		"args": []string{fmt.Sprintf("spot/depth5:%s", currencyPair.ToSymbol("-"))}})
+	return okV3Ws.v3Ws.Subscribe(fmt.Sprintf("spot/depth5:%s", currencyPair.ToSymbol("-")))
+}
+
+// SubscribeIncrementalDepth keeps the full book (depth_l2_tbt) locally and verifies
+// the checksum of every message. DepthCallback gets the whole book after each update.
+func (okV3Ws *OKExV3SpotWs) SubscribeIncrementalDepth(currencyPair CurrencyPair) error {
+	if okV3Ws.depthCallback == nil {
+		return errors.New("please set depth callback func")
+	}
+
+	return okV3Ws.v3Ws.Subscribe(fmt.Sprintf("spot/depth_l2_tbt:%s", currencyPair.ToSymbol("-")))
 }
 
 func (okV3Ws *OKExV3SpotWs) SubscribeTicker(currencyPair CurrencyPair) error {
 	if okV3Ws.tickerCallback == nil {
 		return errors.New("please set ticker callback func")
 	}
-	return okV3Ws.v3Ws.Subscribe(map[string]interface{}{
-		"op":   "subscribe",
-		"args": []string{fmt.Sprintf("spot/ticker:%s", currencyPair.ToSymbol("-"))}})
+	return okV3Ws.v3Ws.Subscribe(fmt.Sprintf("spot/ticker:%s", currencyPair.ToSymbol("-")))
 }
 
 func (okV3Ws *OKExV3SpotWs) SubscribeTrade(currencyPair CurrencyPair) error {
 	if okV3Ws.tradeCallback == nil {
 		return errors.New("please set trade callback func")
 	}
-	return okV3Ws.v3Ws.Subscribe(map[string]interface{}{
-		"op":   "subscribe",
-		"args": []string{fmt.Sprintf("spot/trade:%s", currencyPair.ToSymbol("-"))}})
+	return okV3Ws.v3Ws.Subscribe(fmt.Sprintf("spot/trade:%s", currencyPair.ToSymbol("-")))
 }
 
-func (okV3Ws *OKExV3SpotWs) SubscribeKline(currencyPair CurrencyPair, period int) error {
+// SubscribeKline streams the forming candle, it is closed by the first update of the next one.
+func (okV3Ws *OKExV3SpotWs) SubscribeKline(currencyPair CurrencyPair, period KlinePeriod) error {
 	if okV3Ws.klineCallback == nil {
 		return errors.New("place set kline callback func")
 	}
 
-	seconds := adaptKLinePeriod(KlinePeriod(period))
+	seconds := adaptKLinePeriod(period)
 	if seconds == -1 {
 		return fmt.Errorf("unsupported kline period %d in okex", period)
 	}
 
-	return okV3Ws.v3Ws.Subscribe(map[string]interface{}{
-		"op":   "subscribe",
-		"args": []string{fmt.Sprintf("spot/candle%ds:%s", seconds, currencyPair.ToSymbol("-"))}})
+	return okV3Ws.v3Ws.Subscribe(fmt.Sprintf("spot/candle%ds:%s", seconds, currencyPair.ToSymbol("-")))
+}
+
+func (okV3Ws *OKExV3SpotWs) UnsubscribeDepth(currencyPair CurrencyPair) error {
+	return okV3Ws.v3Ws.Unsubscribe(fmt.Sprintf("spot/depth5:%s", currencyPair.ToSymbol("-")))
+}
+
+func (okV3Ws *OKExV3SpotWs) UnsubscribeIncrementalDepth(currencyPair CurrencyPair) error {
+	return okV3Ws.v3Ws.Unsubscribe(fmt.Sprintf("spot/depth_l2_tbt:%s", currencyPair.ToSymbol("-")))
+}
+
+func (okV3Ws *OKExV3SpotWs) UnsubscribeTicker(currencyPair CurrencyPair) error {
+	return okV3Ws.v3Ws.Unsubscribe(fmt.Sprintf("spot/ticker:%s", currencyPair.ToSymbol("-")))
+}
+
+func (okV3Ws *OKExV3SpotWs) UnsubscribeTrade(currencyPair CurrencyPair) error {
+	return okV3Ws.v3Ws.Unsubscribe(fmt.Sprintf("spot/trade:%s", currencyPair.ToSymbol("-")))
+}
+
+func (okV3Ws *OKExV3SpotWs) UnsubscribeKline(currencyPair CurrencyPair, period KlinePeriod) error {
+	seconds := adaptKLinePeriod(period)
+	if seconds == -1 {
+		return fmt.Errorf("unsupported kline period %d in okex", period)
+	}
+	return okV3Ws.v3Ws.Unsubscribe(fmt.Sprintf("spot/candle%ds:%s", seconds, currencyPair.ToSymbol("-")))
 }
 
 func (okV3Ws *OKExV3SpotWs) getCurrencyPair(instrumentId string) CurrencyPair {
 	return NewCurrencyPair3(instrumentId, "-")
 }
 
-func (okV3Ws *OKExV3SpotWs) handle(ch string, data json.RawMessage) error {
+func (okV3Ws *OKExV3SpotWs) handle(ch, action string, data json.RawMessage) error {
+	if ok, err := okV3Ws.privateHandle(ch, data); ok {
+		return err
+	}
+
 	var (
 		err           error
 		tickers       []spotTickerResponse
@@ -170,6 +210,26 @@ func (okV3Ws *OKExV3SpotWs) handle(ch string, data json.RawMessage) error {
 		//call back func
 		okV3Ws.depthCallback(&dep)
 		return nil
+	case "spot/depth", "spot/depth_l2_tbt":
+		err := json.Unmarshal(data, &depthResp)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+		for i := range depthResp {
+			book, err := okV3Ws.v3Ws.mergeDepth(ch, action, &depthResp[i])
+			if err != nil {
+				return err
+			}
+			if book == nil {
+				continue
+			}
+			dep := Depth{Pair: okV3Ws.getCurrencyPair(depthResp[i].InstrumentId)}
+			dep.UTime, _ = time.Parse(time.RFC3339, depthResp[i].Timestamp)
+			book.depth(&dep)
+			okV3Ws.depthCallback(&dep)
+		}
+		return nil
 	case "spot/trade":
 		err := json.Unmarshal(data, &tradeResponse)
 		if err != nil {
@@ -207,10 +267,11 @@ func (okV3Ws *OKExV3SpotWs) handle(ch string, data json.RawMessage) error {
 			}
 			periodMs := strings.TrimPrefix(ch, "spot/candle")
 			periodMs = strings.TrimSuffix(periodMs, "s")
+			period := adaptSecondsToKlinePeriod(ToInt(periodMs))
 			for _, k := range candleResponse {
 				pair := okV3Ws.getCurrencyPair(k.InstrumentId)
 				tm, _ := time.Parse(time.RFC3339, k.Candle[0])
-				okV3Ws.klineCallback(&Kline{
+				kline := &Kline{
 					Pair:      pair,
 					Timestamp: tm.Unix(),
 					Open:      ToFloat64(k.Candle[1]),
@@ -218,7 +279,15 @@ func (okV3Ws *OKExV3SpotWs) handle(ch string, data json.RawMessage) error {
 					High:      ToFloat64(k.Candle[2]),
 					Low:       ToFloat64(k.Candle[3]),
 					Vol:       ToFloat64(k.Candle[5]),
-				}, adaptSecondsToKlinePeriod(ToInt(periodMs)))
+				}
+				closed, stale := okV3Ws.klines.Next(ch+":"+k.InstrumentId, &FutureKline{Kline: kline})
+				if stale {
+					continue
+				}
+				if closed != nil {
+					okV3Ws.klineCallback(closed.Kline, period, true)
+				}
+				okV3Ws.klineCallback(kline, period, false)
 			}
 			return nil
 		}
@@ -1,12 +1,17 @@
 package okex
 
 import (
+	"context"
+	"errors"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/soulsplit/goex"
 	"github.com/soulsplit/goex/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func init() {
@@ -25,8 +30,8 @@ func TestNewOKExSpotV3Ws(t *testing.T) {
 	okexSpotV3Ws.TradeCallback(func(trade *goex.Trade) {
 		t.Log(trade)
 	})
-	okexSpotV3Ws.KLineCallback(func(kline *goex.Kline, period goex.KlinePeriod) {
-		t.Log(period, kline)
+	okexSpotV3Ws.KlineCallback(func(kline *goex.Kline, period goex.KlinePeriod, closed bool) {
+		t.Log(period, closed, kline)
 	})
 	//okexSpotV3Ws.SubscribeDepth(goex.EOS_USDT, 5)
 	//okexSpotV3Ws.SubscribeTrade(goex.EOS_USDT)
@@ -34,3 +39,83 @@ func TestNewOKExSpotV3Ws(t *testing.T) {
 	okexSpotV3Ws.SubscribeKline(goex.EOS_USDT, goex.KLINE_PERIOD_1H)
 	time.Sleep(time.Minute)
 }
+
+func TestOKExV3SpotWs_Kline(t *testing.T) {
+	type update struct {
+		kline  goex.Kline
+		period goex.KlinePeriod
+		closed bool
+	}
+	var updates []update
+	ws := NewOKExSpotV3Ws(nil)
+	ws.KlineCallback(func(kline *goex.Kline, period goex.KlinePeriod, closed bool) {
+		updates = append(updates, update{*kline, period, closed})
+	})
+
+	candle := func(ts, close string) []byte {
+		return []byte(`{"table":"spot/candle60s","data":[{"candle":["` + ts + `","8533.02","8553.74","8527.17","` + close + `","45.25"],"instrument_id":"BTC-USDT"}]}`)
+	}
+	require.NoError(t, ws.v3Ws.handle(candle("2021-01-01T00:00:00.000Z", "8548.26")))
+	require.NoError(t, ws.v3Ws.handle(candle("2021-01-01T00:00:00.000Z", "8550")))
+	require.NoError(t, ws.v3Ws.handle(candle("2021-01-01T00:01:00.000Z", "8551")))
+	require.Len(t, updates, 4)
+
+	assert.False(t, updates[0].closed)
+	assert.Equal(t, goex.KlinePeriod(goex.KLINE_PERIOD_1MIN), updates[0].period)
+	assert.Equal(t, int64(1609459200), updates[0].kline.Timestamp)
+	assert.Equal(t, 8533.02, updates[0].kline.Open)
+	assert.Equal(t, 8548.26, updates[0].kline.Close)
+	assert.Equal(t, 45.25, updates[0].kline.Vol)
+	assert.Equal(t, goex.BTC_USDT.String(), updates[0].kline.Pair.String())
+
+	assert.True(t, updates[2].closed, "closed by the next candle")
+	assert.Equal(t, 8550.0, updates[2].kline.Close)
+	assert.False(t, updates[3].closed)
+	assert.Equal(t, int64(1609459260), updates[3].kline.Timestamp)
+}
+
+func TestOKExV3SpotWs_Subscribe(t *testing.T) {
+	rp := &goex.WsReplayer{}
+	srv := rp.Server()
+	defer srv.Close()
+
+	var sent lockedBuffer
+	ws := NewOKExSpotV3Ws(nil)
+	ws.v3Ws.WsBuilder.WsUrl("ws" + strings.TrimPrefix(srv.URL, "http")).Recorder(goex.NewWsRecorderWithWriter(&sent))
+	ws.TickerCallback(func(ticker *goex.Ticker) {})
+	ws.TradeCallback(func(trade *goex.Trade) {})
+	defer ws.v3Ws.WsPool.Close(context.Background())
+
+	subscribe := func(sub func() error, channel, resp string) error {
+		done := make(chan error, 1)
+		go func() { done <- sub() }()
+		require.Eventually(t, func() bool {
+			return len(ws.v3Ws.WsPool.PendingAcks()) > 0 && strings.Contains(sent.String(), channel)
+		}, time.Second, 10*time.Millisecond)
+		require.NoError(t, ws.v3Ws.handle([]byte(resp)))
+		return <-done
+	}
+
+	require.NoError(t, subscribe(func() error { return ws.SubscribeTicker(goex.BTC_USDT) },
+		"spot/ticker:BTC-USDT", `{"event":"subscribe","channel":"spot/ticker:BTC-USDT"}`))
+	assert.NoError(t, ws.SubscribeTicker(goex.BTC_USDT), "subscribed already")
+
+	err := subscribe(func() error { return ws.SubscribeTrade(goex.BTC_USDT) },
+		"spot/trade:BTC-USDT", `{"event":"error","message":"Channel spot/trade:BTC-USDT doesn't exist","errorCode":30040}`)
+	assert.True(t, errors.Is(err, goex.EX_ERR_INVALID_PARAMETER), err)
+	assert.Equal(t, []string{"spot/ticker:BTC-USDT"}, ws.v3Ws.WsPool.Subscriptions())
+	assert.Nil(t, ws.v3Ws.WsConn, "no private connection for the public channels")
+
+	require.NoError(t, ws.UnsubscribeTicker(goex.BTC_USDT))
+	assert.Equal(t, goex.ErrNotSubscribed, ws.UnsubscribeTicker(goex.BTC_USDT))
+	assert.Empty(t, ws.v3Ws.WsPool.Subscriptions())
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(sent.String(), "unsubscribe")
+	}, time.Second, 10*time.Millisecond)
+	frames, err := goex.ReadWsFrames(strings.NewReader(sent.String()))
+	require.NoError(t, err)
+	require.Len(t, frames, 3)
+	assert.Equal(t, `{"args":["spot/ticker:BTC-USDT"],"op":"subscribe"}`, frames[0].Text)
+	assert.Equal(t, `{"args":["spot/ticker:BTC-USDT"],"op":"unsubscribe"}`, frames[2].Text)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -326,7 +327,7 @@ func (ok *OKExSwap) PlaceFutureOrder2(currencyPair CurrencyPair, contractType, p
 
 	if resp.ErrorMessage != "" {
 		logger.Errorf("[param] %s", param)
-		return fOrder, errors.New(fmt.Sprintf("%s:%s", resp.ErrorCode, resp.ErrorMessage))
+		return fOrder, adaptErrorCode(http.StatusOK, resp.ErrorCode, resp.ErrorMessage, EX_ERR_PLACE_ORDER_FAIL)
 	}
 
 	fOrder.OrderID2 = resp.OrderID
@@ -415,7 +416,7 @@ func (ok *OKExSwap) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractT
 	}
 
 	if resp.Message != "" {
-		return nil, errors.New(fmt.Sprintf("{\"ErrCode\":%d,\"ErrMessage\":\"%s\"", resp.Code, resp.Message))
+		return nil, adaptErrorCode(http.StatusOK, fmt.Sprint(resp.Code), resp.Message, API_ERR)
 	}
 
 	var orders []FutureOrder
@@ -464,7 +465,7 @@ func (ok *OKExSwap) GetFutureOrder(orderId string, currencyPair CurrencyPair, co
 	}
 
 	if resp.Message != "" {
-		return nil, errors.New(fmt.Sprintf("{\"ErrCode\":%d,\"ErrMessage\":\"%s\"}", resp.Code, resp.Message))
+		return nil, adaptErrorCode(http.StatusOK, fmt.Sprint(resp.Code), resp.Message, API_ERR)
 	}
 
 	oTime, err := time.Parse(time.RFC3339, resp.Timestamp)
@@ -569,7 +570,9 @@ func (ok *OKExSwap) GetKlineRecords(contractType string, currency CurrencyPair,
 	if granularity == -1 {
 		return nil, errors.New("kline period parameter is error")
 	}
-	return ok.GetKlineRecords2(contractType, currency, "", "", strconv.Itoa(granularity))
+	optParam := url.Values{}
+	MergeOptionalParameter(&optParam, opt...)
+	return ok.GetKlineRecords2(contractType, currency, optParam.Get("start"), optParam.Get("end"), strconv.Itoa(granularity))
 }
 
 /**
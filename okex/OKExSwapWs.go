@@ -19,7 +19,9 @@ type OKExV3SwapWs struct {
 	tickerCallback func(*FutureTicker)
 	depthCallback  func(*Depth)
 	tradeCallback  func(*Trade, string)
-	klineCallback  func(*FutureKline, int)
+	klineCallback  func(*FutureKline, KlinePeriod, bool)
+	klines         KlineCloser
+	*futuresPrivateWs
 }
 
 func NewOKExV3SwapWs(base *Exchange) *OKExV3SwapWs {
@@ -27,9 +29,15 @@ func NewOKExV3SwapWs(base *Exchange) *OKExV3SwapWs {
 		base: base,
 	}
 	okV3Ws.v3Ws = NewOKExV3Ws(base, okV3Ws.handle)
+	okV3Ws.futuresPrivateWs = &futuresPrivateWs{base: base, v3Ws: okV3Ws.v3Ws, swapAccount: true}
 	return okV3Ws
 }
 
+// ErrorHandleFunc receives connection errors and depth checksum errors (ErrDepthChecksum).
+func (okV3Ws *OKExV3SwapWs) ErrorHandleFunc(f func(err error)) {
+	okV3Ws.v3Ws.ErrorHandleFunc(f)
+}
+
 func (okV3Ws *OKExV3SwapWs) TickerCallback(tickerCallback func(*FutureTicker)) {
 	okV3Ws.tickerCallback = tickerCallback
 }
@@ -42,14 +50,14 @@ func (okV3Ws *OKExV3SwapWs) TradeCallback(tradeCallback func(*Trade, string)) {
 	okV3Ws.tradeCallback = tradeCallback
 }
 
-func (okV3Ws *OKExV3SwapWs) KlineCallback(klineCallback func(*FutureKline, int)) {
+func (okV3Ws *OKExV3SwapWs) KlineCallback(klineCallback func(kline *FutureKline, period KlinePeriod, closed bool)) {
 	okV3Ws.klineCallback = klineCallback
 }
 
 func (okV3Ws *OKExV3SwapWs) SetCallbacks(tickerCallback func(*FutureTicker),
 	depthCallback func(*Depth),
 	tradeCallback func(*Trade, string),
-	klineCallback func(*FutureKline, int)) {
+	klineCallback func(*FutureKline, KlinePeriod, bool)) {
 	okV3Ws.tickerCallback = tickerCallback
 	okV3Ws.depthCallback = depthCallback
 	okV3Ws.tradeCallback = tradeCallback
@@ -91,9 +99,22 @@ func (okV3Ws *OKExV3SwapWs) SubscribeDepth(currencyPair CurrencyPair, contractTy
 		return errors.New("subscribe error, get channel name fail")
 	}
 
-	return okV3Ws.v3Ws.Subscribe(map[string]interface{}{
-		"op":   "subscribe",
-		"args": []string{fmt.Sprintf(chName, "depth5")}})
+	return okV3Ws.v3Ws.Subscribe(fmt.Sprintf(chName, "depth5"))
+}
+
+// SubscribeIncrementalDepth keeps the full book (depth_l2_tbt) locally and verifies
+// the checksum of every message. DepthCallback gets the whole book after each update.
+func (okV3Ws *OKExV3SwapWs) SubscribeIncrementalDepth(currencyPair CurrencyPair, contractType string) error {
+	if okV3Ws.depthCallback == nil {
+		return errors.New("please set depth callback func")
+	}
+
+	chName := okV3Ws.getChannelName(currencyPair, contractType)
+	if chName == "" {
+		return errors.New("subscribe error, get channel name fail")
+	}
+
+	return okV3Ws.v3Ws.Subscribe(fmt.Sprintf(chName, "depth_l2_tbt"))
 }
 
 func (okV3Ws *OKExV3SwapWs) SubscribeTicker(currencyPair CurrencyPair, contractType string) error {
@@ -106,9 +127,7 @@ func (okV3Ws *OKExV3SwapWs) SubscribeTicker(currencyPair CurrencyPair, contractT
 		return errors.New("subscribe error, get channel name fail")
 	}
 
-	return okV3Ws.v3Ws.Subscribe(map[string]interface{}{
-		"op":   "subscribe",
-		"args": []string{fmt.Sprintf(chName, "ticker")}})
+	return okV3Ws.v3Ws.Subscribe(fmt.Sprintf(chName, "ticker"))
 }
 
 func (okV3Ws *OKExV3SwapWs) SubscribeTrade(currencyPair CurrencyPair, contractType string) error {
@@ -121,17 +140,15 @@ func (okV3Ws *OKExV3SwapWs) SubscribeTrade(currencyPair CurrencyPair, contractTy
 		return errors.New("subscribe error, get channel name fail")
 	}
 
-	return okV3Ws.v3Ws.Subscribe(map[string]interface{}{
-		"op":   "subscribe",
-		"args": []string{fmt.Sprintf(chName, "trade")}})
+	return okV3Ws.v3Ws.Subscribe(fmt.Sprintf(chName, "trade"))
 }
 
-func (okV3Ws *OKExV3SwapWs) SubscribeKline(currencyPair CurrencyPair, contractType string, period int) error {
+func (okV3Ws *OKExV3SwapWs) SubscribeKline(currencyPair CurrencyPair, contractType string, period KlinePeriod) error {
 	if okV3Ws.klineCallback == nil {
 		return errors.New("place set kline callback func")
 	}
 
-	seconds := adaptKLinePeriod(KlinePeriod(period))
+	seconds := adaptKLinePeriod(period)
 	if seconds == -1 {
 		return fmt.Errorf("unsupported kline period %d in okex", period)
 	}
@@ -141,9 +158,39 @@ func (okV3Ws *OKExV3SwapWs) SubscribeKline(currencyPair CurrencyPair, contractTy
 		return errors.New("subscribe error, get channel name fail")
 	}
 
-	return okV3Ws.v3Ws.Subscribe(map[string]interface{}{
-		"op":   "subscribe",
-		"args": []string{fmt.Sprintf(chName, fmt.Sprintf("candle%ds", seconds))}})
+	return okV3Ws.v3Ws.Subscribe(fmt.Sprintf(chName, fmt.Sprintf("candle%ds", seconds)))
+}
+
+func (okV3Ws *OKExV3SwapWs) unsubscribe(currencyPair CurrencyPair, contractType, table string) error {
+	chName := okV3Ws.getChannelName(currencyPair, contractType)
+	if chName == "" {
+		return errors.New("unsubscribe error, get channel name fail")
+	}
+	return okV3Ws.v3Ws.Unsubscribe(fmt.Sprintf(chName, table))
+}
+
+func (okV3Ws *OKExV3SwapWs) UnsubscribeDepth(currencyPair CurrencyPair, contractType string) error {
+	return okV3Ws.unsubscribe(currencyPair, contractType, "depth5")
+}
+
+func (okV3Ws *OKExV3SwapWs) UnsubscribeIncrementalDepth(currencyPair CurrencyPair, contractType string) error {
+	return okV3Ws.unsubscribe(currencyPair, contractType, "depth_l2_tbt")
+}
+
+func (okV3Ws *OKExV3SwapWs) UnsubscribeTicker(currencyPair CurrencyPair, contractType string) error {
+	return okV3Ws.unsubscribe(currencyPair, contractType, "ticker")
+}
+
+func (okV3Ws *OKExV3SwapWs) UnsubscribeTrade(currencyPair CurrencyPair, contractType string) error {
+	return okV3Ws.unsubscribe(currencyPair, contractType, "trade")
+}
+
+func (okV3Ws *OKExV3SwapWs) UnsubscribeKline(currencyPair CurrencyPair, contractType string, period KlinePeriod) error {
+	seconds := adaptKLinePeriod(period)
+	if seconds == -1 {
+		return fmt.Errorf("unsupported kline period %d in okex", period)
+	}
+	return okV3Ws.unsubscribe(currencyPair, contractType, fmt.Sprintf("candle%ds", seconds))
 }
 
 func (okV3Ws *OKExV3SwapWs) getContractAliasAndCurrencyPairFromInstrumentId(instrumentId string) (alias string, pair CurrencyPair) {
@@ -162,7 +209,11 @@ func (okV3Ws *OKExV3SwapWs) getContractAliasAndCurrencyPairFromInstrumentId(inst
 	}
 }
 
-func (okV3Ws *OKExV3SwapWs) handle(channel string, data json.RawMessage) error {
+func (okV3Ws *OKExV3SwapWs) handle(channel, action string, data json.RawMessage) error {
+	if ok, err := okV3Ws.privateHandle(channel, data); ok {
+		return err
+	}
+
 	var (
 		err           error
 		ch            string
@@ -226,11 +277,12 @@ func (okV3Ws *OKExV3SwapWs) handle(channel string, data json.RawMessage) error {
 			return err
 		}
 
+		seconds := strings.TrimSuffix(channel[strings.Index(channel, "/candle")+len("/candle"):], "s")
+		period := adaptSecondsToKlinePeriod(ToInt(seconds))
 		for _, t := range klineResponse {
-			_, pair := okV3Ws.getContractAliasAndCurrencyPairFromInstrumentId(t.InstrumentId)
+			alias, pair := okV3Ws.getContractAliasAndCurrencyPairFromInstrumentId(t.InstrumentId)
 			ts, _ := time.Parse(time.RFC3339, t.Candle[0])
-			//granularity := adaptKLinePeriod(KlinePeriod(period))
-			okV3Ws.klineCallback(&FutureKline{
+			kline := &FutureKline{
 				Kline: &Kline{
 					Pair:      pair,
 					High:      ToFloat64(t.Candle[2]),
@@ -240,8 +292,17 @@ func (okV3Ws *OKExV3SwapWs) handle(channel string, data json.RawMessage) error {
 					Close:     ToFloat64(t.Candle[4]),
 					Vol:       ToFloat64(t.Candle[5]),
 				},
-				Vol2: ToFloat64(t.Candle[6]),
-			}, 1)
+				Vol2:         ToFloat64(t.Candle[6]),
+				ContractType: alias,
+			}
+			closed, stale := okV3Ws.klines.Next(channel+":"+t.InstrumentId, kline)
+			if stale {
+				continue
+			}
+			if closed != nil {
+				okV3Ws.klineCallback(closed, period, true)
+			}
+			okV3Ws.klineCallback(kline, period, false)
 		}
 		return nil
 	case "depth5":
@@ -272,6 +333,27 @@ func (okV3Ws *OKExV3SwapWs) handle(channel string, data json.RawMessage) error {
 		//call back func
 		okV3Ws.depthCallback(&dep)
 		return nil
+	case "depth", "depth_l2_tbt":
+		err := json.Unmarshal(data, &depthResp)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+		for i := range depthResp {
+			book, err := okV3Ws.v3Ws.mergeDepth(channel, action, &depthResp[i])
+			if err != nil {
+				return err
+			}
+			if book == nil {
+				continue
+			}
+			alias, pair := okV3Ws.getContractAliasAndCurrencyPairFromInstrumentId(depthResp[i].InstrumentId)
+			dep := Depth{Pair: pair, ContractType: alias, ContractId: depthResp[i].InstrumentId}
+			dep.UTime, _ = time.Parse(time.RFC3339, depthResp[i].Timestamp)
+			book.depth(&dep)
+			okV3Ws.depthCallback(&dep)
+		}
+		return nil
 	case "trade":
 		err := json.Unmarshal(data, &tradeResponse)
 		if err != nil {
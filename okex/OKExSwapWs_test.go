@@ -8,6 +8,8 @@ import (
 
 	"github.com/soulsplit/goex"
 	"github.com/soulsplit/goex/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func init() {
@@ -31,3 +33,29 @@ func TestNewOKExV3SwapWs(t *testing.T) {
 	ok.OKExV3SwapWs.SubscribeTicker(goex.BTC_USDT, goex.SWAP_CONTRACT)
 	time.Sleep(1 * time.Minute)
 }
+
+func TestOKExV3SwapWs_Kline(t *testing.T) {
+	var (
+		klines []*goex.FutureKline
+		closed []bool
+	)
+	ws := NewOKEx(&goex.APIConfig{}).OKExV3SwapWs
+	ws.KlineCallback(func(kline *goex.FutureKline, period goex.KlinePeriod, c bool) {
+		assert.Equal(t, goex.KlinePeriod(goex.KLINE_PERIOD_1H), period)
+		klines = append(klines, kline)
+		closed = append(closed, c)
+	})
+
+	candle := func(ts string) []byte {
+		return []byte(`{"table":"swap/candle3600s","data":[{"candle":["` + ts + `","5.826","5.846","5.79","5.8","162","2781.6"],"instrument_id":"EOS-USD-SWAP"}]}`)
+	}
+	require.NoError(t, ws.v3Ws.handle(candle("2021-01-01T01:00:00.000Z")))
+	require.NoError(t, ws.v3Ws.handle(candle("2021-01-01T00:00:00.000Z")))
+	require.NoError(t, ws.v3Ws.handle(candle("2021-01-01T02:00:00.000Z")))
+	assert.Equal(t, []bool{false, true, false}, closed, "the late update of an older candle is dropped")
+	assert.Equal(t, int64(1609462800), klines[1].Timestamp)
+	assert.Equal(t, 162.0, klines[0].Vol)
+	assert.Equal(t, 2781.6, klines[0].Vol2)
+	assert.Equal(t, "EOS-USD-SWAP", klines[0].ContractType)
+	assert.Equal(t, "EOS_USD", klines[0].Pair.String())
+}
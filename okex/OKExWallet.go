@@ -1,8 +1,9 @@
 package okex
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"net/http"
 
 	. "github.com/soulsplit/goex"
 )
@@ -18,13 +19,17 @@ type OKExWallet struct {
 }
 
 func (ok *OKExWallet) GetAccount() (*Account, error) {
+	return ok.GetAccountWithContext(context.Background())
+}
+
+func (ok *OKExWallet) GetAccountWithContext(ctx context.Context) (*Account, error) {
 	var response []struct {
 		Balance   float64 `json:"balance,string"`
 		Available float64 `json:"available,string"`
 		Currency  string  `json:"currency"`
 		Hold      float64 `json:"hold,string"`
 	}
-	err := ok.DoRequest("GET", "/api/account/v3/wallet", "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", "/api/account/v3/wallet", "", &response)
 	if err != nil {
 		return nil, err
 	}
@@ -54,6 +59,10 @@ from或to指定为0时，sub_account为必填项。
 from或to指定为5时，instrument_id为必填项。
 */
 func (ok *OKExWallet) Transfer(param TransferParameter) error {
+	return ok.TransferWithContext(context.Background(), param)
+}
+
+func (ok *OKExWallet) TransferWithContext(ctx context.Context, param TransferParameter) error {
 	var response struct {
 		Result       bool   `json:"result"`
 		ErrorCode    string `json:"code"`
@@ -61,13 +70,13 @@ func (ok *OKExWallet) Transfer(param TransferParameter) error {
 	}
 	reqBody, _, _ := ok.BuildRequestBody(param)
 	println(reqBody)
-	err := ok.DoRequest("POST", "/api/account/v3/transfer", reqBody, &response)
+	err := ok.DoRequestWithContext(ctx, "POST", "/api/account/v3/transfer", reqBody, &response)
 	if err != nil {
 		return err
 	}
 
 	if !response.Result {
-		return errors.New(response.ErrorMessage)
+		return adaptErrorCode(http.StatusOK, response.ErrorCode, response.ErrorMessage, API_ERR)
 	}
 	return nil
 }
@@ -76,6 +85,10 @@ func (ok *OKExWallet) Transfer(param TransferParameter) error {
  认证过的数字货币地址、邮箱或手机号。某些数字货币地址格式为:地址+标签，例："ARDOR-7JF3-8F2E-QUWZ-CAN7F：123456"
 */
 func (ok *OKExWallet) Withdrawal(param WithdrawParameter) (withdrawId string, err error) {
+	return ok.WithdrawalWithContext(context.Background(), param)
+}
+
+func (ok *OKExWallet) WithdrawalWithContext(ctx context.Context, param WithdrawParameter) (withdrawId string, err error) {
 	var response struct {
 		Result       bool   `json:"result"`
 		WithdrawId   string `json:"withdraw_id"`
@@ -83,12 +96,12 @@ func (ok *OKExWallet) Withdrawal(param WithdrawParameter) (withdrawId string, er
 		ErrorMessage string `json:"message"`
 	}
 	reqBody, _, _ := ok.BuildRequestBody(param)
-	err = ok.DoRequest("POST", "/api/account/v3/withdrawal", reqBody, &response) //
+	err = ok.DoRequestWithContext(ctx, "POST", "/api/account/v3/withdrawal", reqBody, &response) //
 	if err != nil {
 		return
 	}
 	if !response.Result {
-		err = errors.New(response.ErrorMessage)
+		err = adaptErrorCode(http.StatusOK, response.ErrorCode, response.ErrorMessage, API_ERR)
 		return
 	}
 	withdrawId = response.WithdrawId
@@ -135,21 +148,29 @@ func (ok *OKExWallet) GetWithDrawalFee(currency *Currency) ([]WithdrawFee, error
 }
 
 func (ok *OKExWallet) GetWithDrawHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	return ok.GetWithDrawHistoryWithContext(context.Background(), currency)
+}
+
+func (ok *OKExWallet) GetWithDrawHistoryWithContext(ctx context.Context, currency *Currency) ([]DepositWithdrawHistory, error) {
 	urlPath := "/api/account/v3/withdrawal/history"
 	if currency != nil && *currency != UNKNOWN {
 		urlPath += "/" + currency.Symbol
 	}
 	var response []DepositWithdrawHistory
-	err := ok.DoRequest("GET", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	return response, err
 }
 
 func (ok *OKExWallet) GetDepositHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	return ok.GetDepositHistoryWithContext(context.Background(), currency)
+}
+
+func (ok *OKExWallet) GetDepositHistoryWithContext(ctx context.Context, currency *Currency) ([]DepositWithdrawHistory, error) {
 	urlPath := "/api/account/v3/deposit/history"
 	if currency != nil && *currency != UNKNOWN {
 		urlPath += "/" + currency.Symbol
 	}
 	var response []DepositWithdrawHistory
-	err := ok.DoRequest("GET", urlPath, "", &response)
+	err := ok.DoRequestWithContext(ctx, "GET", urlPath, "", &response)
 	return response, err
 }
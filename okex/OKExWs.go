@@ -2,10 +2,13 @@ package okex
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/soulsplit/goex/internal/logger"
@@ -17,24 +20,44 @@ type wsResp struct {
 	Event     string `json:"event"`
 	Channel   string `json:"channel"`
 	Table     string `json:"table"`
+	Action    string `json:"action"` //partial or update, incremental depth only
 	Data      json.RawMessage
 	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
 	ErrorCode interface{} `json:"errorCode"`
 }
 
+// wsMaxChannels are the public channels on a connection, more are spread over
+// other connections so a reconnect does not resubscribe them all at once.
+const wsMaxChannels = 100
+
+// OKExV3Ws carries the public channels on the connections of WsPool, WsConn is the
+// logged in connection of the private ones.
 type OKExV3Ws struct {
 	base *Exchange
 	*WsBuilder
-	once       *sync.Once
-	WsConn     *WsConn
-	respHandle func(channel string, data json.RawMessage) error
+	once        *sync.Once
+	WsConn      *WsConn
+	WsPool      *WsPool
+	respHandle  func(channel, action string, data json.RawMessage) error
+	errorHandle func(err error)
+	books       map[string]*depthBook //incremental depth books by channel
+	booksLock   sync.Mutex
+
+	loginLock    sync.Mutex
+	loggedIn     bool
+	loginPending int32
+	relogin      int32 //1 once a login is sent, it is sent again after a reconnect
+	loginCh      chan error
 }
 
-func NewOKExV3Ws(base *Exchange, handle func(channel string, data json.RawMessage) error) *OKExV3Ws {
+func NewOKExV3Ws(base *Exchange, handle func(channel, action string, data json.RawMessage) error) *OKExV3Ws {
 	okV3Ws := &OKExV3Ws{
 		once:       new(sync.Once),
 		base:       base,
 		respHandle: handle,
+		books:      make(map[string]*depthBook),
+		loginCh:    make(chan error, 1),
 	}
 	okV3Ws.WsBuilder = NewWsBuilder().
 		WsUrl("wss://real.okex.com:8443/ws/v3").
@@ -42,9 +65,17 @@ func NewOKExV3Ws(base *Exchange, handle func(channel string, data json.RawMessag
 		AutoReconnect().
 		Heartbeat(func() []byte { return []byte("ping") }, 28*time.Second).
 		DecompressFunc(FlateDecompress).ProtoHandleFunc(okV3Ws.handle)
+	okV3Ws.WsPool = NewWsPool(okV3Ws.WsBuilder, wsMaxChannels)
 	return okV3Ws
 }
 
+// ErrorHandleFunc receives connection errors and depth checksum errors (ErrDepthChecksum).
+// It has to be set before the first subscribe.
+func (okV3Ws *OKExV3Ws) ErrorHandleFunc(f func(err error)) {
+	okV3Ws.errorHandle = f
+	okV3Ws.WsBuilder.ErrorHandleFunc(f)
+}
+
 func (okV3Ws *OKExV3Ws) clearChan(c chan wsResp) {
 	for {
 		if len(c) > 0 {
@@ -62,9 +93,11 @@ func (okV3Ws *OKExV3Ws) getTablePrefix(currencyPair CurrencyPair, contractType s
 	return "futures"
 }
 
+// ConnectWs builds WsConn, the connection which is logged in, the public channels are
+// on the connections of WsPool.
 func (okV3Ws *OKExV3Ws) ConnectWs() {
 	okV3Ws.once.Do(func() {
-		okV3Ws.WsConn = okV3Ws.WsBuilder.Build()
+		okV3Ws.WsConn = okV3Ws.WsBuilder.Clone().ConnectSuccessAfterSendMessage(okV3Ws.reloginMessage).Build()
 	})
 }
 
@@ -98,6 +131,15 @@ func (okV3Ws *OKExV3Ws) handle(msg []byte) error {
 		return err
 	}
 
+	if wsResp.Event == "login" || (wsResp.Event == "error" && atomic.LoadInt32(&okV3Ws.loginPending) == 1) {
+		okV3Ws.loginResult(wsResp)
+		return nil
+	}
+
+	if wsResp.Event == "error" && okV3Ws.subscribeError(wsResp) {
+		return nil
+	}
+
 	if wsResp.ErrorCode != nil {
 		logger.Error(string(msg))
 		return fmt.Errorf("%s", string(msg))
@@ -107,6 +149,13 @@ func (okV3Ws *OKExV3Ws) handle(msg []byte) error {
 		switch wsResp.Event {
 		case "subscribe":
 			logger.Info("subscribed:", wsResp.Channel)
+			okV3Ws.WsPool.AckSubscription(wsResp.Channel, nil)
+			if okV3Ws.WsConn != nil {
+				okV3Ws.WsConn.AckSubscription(wsResp.Channel, nil)
+			}
+			return nil
+		case "unsubscribe":
+			logger.Info("unsubscribed:", wsResp.Channel)
 			return nil
 		case "error":
 			logger.Errorf(string(msg))
@@ -117,9 +166,12 @@ func (okV3Ws *OKExV3Ws) handle(msg []byte) error {
 	}
 
 	if wsResp.Table != "" {
-		err = okV3Ws.respHandle(wsResp.Table, wsResp.Data)
+		err = okV3Ws.respHandle(wsResp.Table, wsResp.Action, wsResp.Data)
 		if err != nil {
 			logger.Error("handle ws data error:", err)
+			if errors.Is(err, ErrDepthChecksum) && okV3Ws.errorHandle != nil {
+				okV3Ws.errorHandle(err)
+			}
 		}
 		return err
 	}
@@ -127,7 +179,144 @@ func (okV3Ws *OKExV3Ws) handle(msg []byte) error {
 	return fmt.Errorf("unknown websocket message: %v", wsResp)
 }
 
-func (okV3Ws *OKExV3Ws) Subscribe(sub map[string]interface{}) error {
+func subscription(ch string) WsSubscription {
+	return WsSubscription{
+		Key:   ch,
+		AckId: ch,
+		Event: map[string]interface{}{"op": "subscribe", "args": []string{ch}}}
+}
+
+// Subscribe waits for the subscribe event of each public channel, a channel subscribed
+// already is skipped.
+func (okV3Ws *OKExV3Ws) Subscribe(channels ...string) error {
+	for _, ch := range channels {
+		if err := okV3Ws.WsPool.AddSubscription(subscription(ch)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unsubscribe drops public or private channels.
+func (okV3Ws *OKExV3Ws) Unsubscribe(channels ...string) error {
+	for _, ch := range channels {
+		unsubEvent := map[string]interface{}{"op": "unsubscribe", "args": []string{ch}}
+		err := okV3Ws.WsPool.RemoveSubscription(ch, unsubEvent)
+		if err == ErrNotSubscribed && okV3Ws.WsConn != nil {
+			err = okV3Ws.WsConn.RemoveSubscription(ch, unsubEvent)
+		}
+		if err != nil {
+			return err
+		}
+		okV3Ws.booksLock.Lock()
+		delete(okV3Ws.books, ch)
+		okV3Ws.booksLock.Unlock()
+	}
+	return nil
+}
+
+// subscribeError fails a pending subscription, the error event names no channel so
+// it is the one in the message or else the oldest one.
+func (okV3Ws *OKExV3Ws) subscribeError(resp wsResp) bool {
+	pending := okV3Ws.WsPool.PendingAcks()
+	public := len(pending)
+	if okV3Ws.WsConn != nil {
+		pending = append(pending, okV3Ws.WsConn.PendingAcks()...)
+	}
+	if len(pending) == 0 {
+		return false
+	}
+	i := 0
+	for j, p := range pending {
+		if strings.Contains(resp.Message, p) {
+			i = j
+			break
+		}
+	}
+	err := adaptErrorCode(http.StatusOK, fmt.Sprint(resp.ErrorCode), resp.Message, EX_ERR_INVALID_PARAMETER)
+	if i < public {
+		okV3Ws.WsPool.AckSubscription(pending[i], err)
+	} else {
+		okV3Ws.WsConn.AckSubscription(pending[i], err)
+	}
+	return true
+}
+
+func (okV3Ws *OKExV3Ws) loginMessage() []byte {
+	config := okV3Ws.base.config
+	timestamp := fmt.Sprintf("%.3f", float64(time.Now().UnixNano())/float64(time.Second))
+	sign, _ := GetParamHmacSHA256Base64Sign(config.ApiSecretKey, timestamp+"GET/users/self/verify")
+	msg, _ := json.Marshal(map[string]interface{}{
+		"op":   "login",
+		"args": []string{config.ApiKey, config.ApiPassphrase, timestamp, sign}})
+	return msg
+}
+
+func (okV3Ws *OKExV3Ws) reloginMessage() []byte {
+	if atomic.LoadInt32(&okV3Ws.relogin) == 0 {
+		return nil
+	}
+	return okV3Ws.loginMessage()
+}
+
+func (okV3Ws *OKExV3Ws) loginResult(resp wsResp) {
+	var err error
+	if !resp.Success {
+		err = adaptErrorCode(http.StatusOK, fmt.Sprint(resp.ErrorCode), resp.Message, EX_ERR_AUTH)
+		logger.Error("[ws] login error:", err)
+	}
+	select {
+	case okV3Ws.loginCh <- err:
+	default:
+	}
+}
+
+// Login authenticates the connection for the private channels, the login is sent
+// again after a reconnect.
+func (okV3Ws *OKExV3Ws) Login() error {
+	if okV3Ws.base == nil || okV3Ws.base.config.ApiKey == "" {
+		return EX_ERR_NOT_FIND_APIKEY
+	}
+
+	okV3Ws.loginLock.Lock()
+	defer okV3Ws.loginLock.Unlock()
+	if okV3Ws.loggedIn {
+		return nil
+	}
+
 	okV3Ws.ConnectWs()
-	return okV3Ws.WsConn.Subscribe(sub)
+	for len(okV3Ws.loginCh) > 0 {
+		<-okV3Ws.loginCh
+	}
+	atomic.StoreInt32(&okV3Ws.loginPending, 1)
+	defer atomic.StoreInt32(&okV3Ws.loginPending, 0)
+
+	atomic.StoreInt32(&okV3Ws.relogin, 1)
+	okV3Ws.WsConn.SendMessage(okV3Ws.loginMessage())
+
+	var err error
+	select {
+	case err = <-okV3Ws.loginCh:
+	case <-time.After(10 * time.Second):
+		err = EX_ERR_AUTH.OriginErr("login timeout")
+	}
+	if err != nil {
+		atomic.StoreInt32(&okV3Ws.relogin, 0)
+		return err
+	}
+	okV3Ws.loggedIn = true
+	return nil
+}
+
+// subscribePrivate subscribes on the logged in WsConn, it logs in first if needed.
+func (okV3Ws *OKExV3Ws) subscribePrivate(channels ...string) error {
+	if err := okV3Ws.Login(); err != nil {
+		return err
+	}
+	for _, ch := range channels {
+		if err := okV3Ws.WsConn.AddSubscription(subscription(ch)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,137 @@
+package okex
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+
+	. "github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/internal/logger"
+)
+
+var ErrDepthChecksum = errors.New("okex depth checksum mismatch")
+
+// the checksum covers the best 25 levels of each side
+const checksumLevels = 25
+
+// depthLevel keeps price and size as sent by okex, the checksum is computed over these strings.
+type depthLevel struct {
+	price string
+	size  string
+	p     float64
+}
+
+// depthBook is the local book of an incremental depth channel (depth, depth_l2_tbt).
+type depthBook struct {
+	bids []depthLevel //descending
+	asks []depthLevel //ascending
+}
+
+func (b *depthBook) set(side TradeSide, price, size string) {
+	levels := &b.asks
+	if side == BUY {
+		levels = &b.bids
+	}
+
+	p := ToFloat64(price)
+	i := sort.Search(len(*levels), func(i int) bool {
+		if side == BUY {
+			return (*levels)[i].p <= p
+		}
+		return (*levels)[i].p >= p
+	})
+	found := i < len(*levels) && (*levels)[i].p == p
+	remove := ToFloat64(size) == 0
+
+	switch {
+	case remove && found:
+		*levels = append((*levels)[:i], (*levels)[i+1:]...)
+	case remove:
+	case found:
+		(*levels)[i].price, (*levels)[i].size = price, size
+	default:
+		*levels = append(*levels, depthLevel{})
+		copy((*levels)[i+1:], (*levels)[i:])
+		(*levels)[i] = depthLevel{price: price, size: size, p: p}
+	}
+}
+
+func (b *depthBook) update(resp *depthResponse) {
+	for _, itm := range resp.Bids {
+		b.set(BUY, fmt.Sprint(itm[0]), fmt.Sprint(itm[1]))
+	}
+	for _, itm := range resp.Asks {
+		b.set(SELL, fmt.Sprint(itm[0]), fmt.Sprint(itm[1]))
+	}
+}
+
+// checksum is the signed crc32 of "bid1.price:bid1.size:ask1.price:ask1.size:bid2.price...",
+// a side with fewer levels is just skipped.
+func (b *depthBook) checksum() int32 {
+	var parts []string
+	for i := 0; i < checksumLevels; i++ {
+		if i < len(b.bids) {
+			parts = append(parts, b.bids[i].price, b.bids[i].size)
+		}
+		if i < len(b.asks) {
+			parts = append(parts, b.asks[i].price, b.asks[i].size)
+		}
+	}
+	return int32(crc32.ChecksumIEEE([]byte(strings.Join(parts, ":"))))
+}
+
+// depth returns the book in goex order, AskList descending.
+func (b *depthBook) depth(dep *Depth) {
+	dep.BidList = make(DepthRecords, 0, len(b.bids))
+	for _, l := range b.bids {
+		dep.BidList = append(dep.BidList, DepthRecord{Price: l.p, Amount: ToFloat64(l.size)})
+	}
+	dep.AskList = make(DepthRecords, len(b.asks))
+	for i, l := range b.asks {
+		dep.AskList[len(b.asks)-1-i] = DepthRecord{Price: l.p, Amount: ToFloat64(l.size)}
+	}
+}
+
+// mergeDepth applies a partial or update message of an incremental depth table and
+// verifies the checksum. It returns nil while waiting for the partial message. On a
+// checksum mismatch the book is dropped and the channel resubscribed, which makes
+// okex send a new partial.
+func (okV3Ws *OKExV3Ws) mergeDepth(table, action string, resp *depthResponse) (*depthBook, error) {
+	key := table + ":" + resp.InstrumentId
+
+	okV3Ws.booksLock.Lock()
+	defer okV3Ws.booksLock.Unlock()
+
+	book := okV3Ws.books[key]
+	switch action {
+	case "partial":
+		book = new(depthBook)
+		okV3Ws.books[key] = book
+	case "update":
+		if book == nil {
+			return nil, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown depth action %s", action)
+	}
+
+	book.update(resp)
+	if sum := book.checksum(); sum != resp.Checksum {
+		delete(okV3Ws.books, key)
+		logger.Warnf("[ws] %s checksum %d, expected %d, resubscribe", key, sum, resp.Checksum)
+		okV3Ws.resubscribe(key)
+		return nil, fmt.Errorf("%w: %s local %d, remote %d", ErrDepthChecksum, key, sum, resp.Checksum)
+	}
+	return book, nil
+}
+
+func (okV3Ws *OKExV3Ws) resubscribe(channel string) {
+	conn := okV3Ws.WsPool.Conn(channel)
+	if conn == nil {
+		return
+	}
+	conn.SendJsonMessage(map[string]interface{}{"op": "unsubscribe", "args": []string{channel}})
+	conn.SendJsonMessage(map[string]interface{}{"op": "subscribe", "args": []string{channel}})
+}
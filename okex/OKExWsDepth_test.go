@@ -0,0 +1,114 @@
+package okex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func crc(s string) int32 {
+	return int32(crc32.ChecksumIEEE([]byte(s)))
+}
+
+// lockedBuffer is written by the ws write goroutine and read by the test
+type lockedBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.String()
+}
+
+func depthMsg(action, bids, asks string, checksum int32) []byte {
+	return []byte(fmt.Sprintf(`{"table":"spot/depth_l2_tbt","action":"%s","data":[{"instrument_id":"BTC-USDT","asks":%s,"bids":%s,"timestamp":"2021-01-01T00:00:00.000Z","checksum":%d}]}`,
+		action, asks, bids, checksum))
+}
+
+func TestDepthBook_Checksum(t *testing.T) {
+	b := new(depthBook)
+	b.update(&depthResponse{
+		Bids: [][4]interface{}{{"3366.1", "7", "0", "3"}, {"3366", "6", "3", "4"}},
+		Asks: [][4]interface{}{{"3366.8", "9", "10", "3"}, {"3368", "8", "3", "4"}, {"3372", "8", "3", "4"}},
+	})
+	assert.Equal(t, crc("3366.1:7:3366.8:9:3366:6:3368:8:3372:8"), b.checksum())
+}
+
+func TestOKExV3SpotWs_IncrementalDepth(t *testing.T) {
+	rp := &goex.WsReplayer{}
+	srv := rp.Server()
+	defer srv.Close()
+
+	var (
+		sent   lockedBuffer
+		depths []*goex.Depth
+		errs   []error
+	)
+	ws := NewOKExSpotV3Ws(nil)
+	ws.v3Ws.WsBuilder.WsUrl("ws" + strings.TrimPrefix(srv.URL, "http")).Recorder(goex.NewWsRecorderWithWriter(&sent))
+	ws.DepthCallback(func(depth *goex.Depth) { depths = append(depths, depth) })
+	ws.ErrorHandleFunc(func(err error) { errs = append(errs, err) })
+	defer ws.v3Ws.WsPool.Close(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- ws.SubscribeIncrementalDepth(goex.BTC_USDT) }()
+	require.Eventually(t, func() bool {
+		return len(ws.v3Ws.WsPool.PendingAcks()) > 0
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, ws.v3Ws.handle([]byte(`{"event":"subscribe","channel":"spot/depth_l2_tbt:BTC-USDT"}`)))
+	require.NoError(t, <-done)
+
+	//update before the partial is ignored
+	require.NoError(t, ws.v3Ws.handle(depthMsg("update", `[]`, `[["8800.1","1","0","1"]]`, 0)))
+	assert.Len(t, depths, 0)
+
+	require.NoError(t, ws.v3Ws.handle(depthMsg("partial",
+		`[["8800","2","0","1"],["8799.5","1.5","0","2"]]`, `[["8800.5","3","0","1"],["8801","1","0","1"]]`,
+		crc("8800:2:8800.5:3:8799.5:1.5:8801:1"))))
+	require.NoError(t, ws.v3Ws.handle(depthMsg("update",
+		`[["8800","0","0","0"]]`, `[["8800.2","0.5","0","1"]]`,
+		crc("8799.5:1.5:8800.2:0.5:8800.5:3:8801:1"))))
+
+	require.Len(t, depths, 2)
+	assert.Equal(t, goex.BTC_USDT.String(), depths[1].Pair.String())
+	assert.Equal(t, goex.DepthRecords{{Price: 8799.5, Amount: 1.5}}, depths[1].BidList)
+	assert.Equal(t, goex.DepthRecords{{Price: 8801, Amount: 1}, {Price: 8800.5, Amount: 3}, {Price: 8800.2, Amount: 0.5}}, depths[1].AskList)
+
+	//a lost frame: the checksum does not match anymore
+	err := ws.v3Ws.handle(depthMsg("update", `[]`, `[["8801","2","0","1"]]`, 12345))
+	assert.True(t, errors.Is(err, ErrDepthChecksum))
+	require.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], ErrDepthChecksum))
+	assert.Len(t, depths, 2)
+
+	//waiting for the new partial
+	require.NoError(t, ws.v3Ws.handle(depthMsg("update", `[]`, `[["8801","3","0","1"]]`, 0)))
+	assert.Len(t, depths, 2)
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(sent.String(), "unsubscribe")
+	}, time.Second, 10*time.Millisecond)
+	frames, err := goex.ReadWsFrames(strings.NewReader(sent.String()))
+	require.NoError(t, err)
+	require.Len(t, frames, 3)
+	assert.Equal(t, `{"args":["spot/depth_l2_tbt:BTC-USDT"],"op":"unsubscribe"}`, frames[1].Text)
+	assert.Equal(t, `{"args":["spot/depth_l2_tbt:BTC-USDT"],"op":"subscribe"}`, frames[2].Text)
+}
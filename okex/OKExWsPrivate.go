@@ -0,0 +1,348 @@
+package okex
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+func (okV3Ws *OKExV3SpotWs) OrderCallback(orderCallback func(*Order)) {
+	okV3Ws.orderCallback = orderCallback
+}
+
+func (okV3Ws *OKExV3SpotWs) AccountCallback(accountCallback func(*Account)) {
+	okV3Ws.accountCallback = accountCallback
+}
+
+func (okV3Ws *OKExV3SpotWs) Login() error {
+	return okV3Ws.v3Ws.Login()
+}
+
+func (okV3Ws *OKExV3SpotWs) SubscribeOrder(currencyPair CurrencyPair) error {
+	return okV3Ws.v3Ws.subscribePrivate(fmt.Sprintf("spot/order:%s", currencyPair.ToSymbol("-")))
+}
+
+// SubscribeAccount subscribes the balances of both currencies of the pair.
+func (okV3Ws *OKExV3SpotWs) SubscribeAccount(currencyPair CurrencyPair) error {
+	return okV3Ws.v3Ws.subscribePrivate(
+		fmt.Sprintf("spot/account:%s", currencyPair.CurrencyA.Symbol),
+		fmt.Sprintf("spot/account:%s", currencyPair.CurrencyB.Symbol))
+}
+
+func (okV3Ws *OKExV3SpotWs) privateHandle(ch string, data json.RawMessage) (bool, error) {
+	switch ch {
+	case "spot/order":
+		var orders []struct {
+			OrderResponse
+			FilledNotional float64 `json:"filled_notional,string"`
+		}
+		if err := json.Unmarshal(data, &orders); err != nil {
+			return true, err
+		}
+		for _, o := range orders {
+			ord := okV3Ws.base.OKExSpot.adaptOrder(o.OrderResponse)
+			ord.Currency = okV3Ws.getCurrencyPair(o.InstrumentId)
+			if ord.AvgPrice == 0 && ord.DealAmount > 0 && ord.Side != BUY_MARKET {
+				ord.AvgPrice = o.FilledNotional / ord.DealAmount
+			}
+			if okV3Ws.orderCallback != nil {
+				okV3Ws.orderCallback(ord)
+			}
+		}
+		return true, nil
+	case "spot/account":
+		var balances []struct {
+			Currency  string  `json:"currency"`
+			Available float64 `json:"available,string"`
+			Hold      float64 `json:"hold,string"`
+		}
+		if err := json.Unmarshal(data, &balances); err != nil {
+			return true, err
+		}
+		acc := Account{Exchange: OKEX, SubAccounts: make(map[Currency]SubAccount, len(balances))}
+		for _, b := range balances {
+			currency := NewCurrency(b.Currency, "")
+			acc.SubAccounts[currency] = SubAccount{
+				Currency:     currency,
+				Amount:       b.Available,
+				ForzenAmount: b.Hold,
+			}
+		}
+		if okV3Ws.accountCallback != nil {
+			okV3Ws.accountCallback(&acc)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// futuresSub is a subscribed contract of the private channels.
+type futuresSub struct {
+	pair         CurrencyPair
+	contractType string
+}
+
+// futuresPrivateWs is the private channels of the futures and the swap ws, both
+// stream the futures and swap orders and positions by the contract type. The account
+// channel is futures/account of the futures ws and swap/account of the swap ws.
+type futuresPrivateWs struct {
+	base             *Exchange
+	v3Ws             *OKExV3Ws
+	swapAccount      bool
+	orderCallback    func(*FutureOrder)
+	positionCallback func(*FuturePosition)
+	accountCallback  func(*FutureAccount)
+
+	subsLock sync.Mutex
+	subs     map[string]futuresSub //by instrument id
+}
+
+func (p *futuresPrivateWs) OrderCallback(orderCallback func(*FutureOrder)) {
+	p.orderCallback = orderCallback
+}
+
+func (p *futuresPrivateWs) PositionCallback(positionCallback func(*FuturePosition)) {
+	p.positionCallback = positionCallback
+}
+
+func (p *futuresPrivateWs) AccountCallback(accountCallback func(*FutureAccount)) {
+	p.accountCallback = accountCallback
+}
+
+func (p *futuresPrivateWs) Login() error {
+	return p.v3Ws.Login()
+}
+
+func (p *futuresPrivateWs) subscribe(table string, currencyPair CurrencyPair, contractType string) error {
+	prefix, instrumentId := "swap", fmt.Sprintf("%s-SWAP", currencyPair.ToSymbol("-"))
+	if contractType != SWAP_CONTRACT {
+		prefix, instrumentId = "futures", p.base.OKExFuture.GetFutureContractId(currencyPair, contractType)
+		if instrumentId == "" {
+			return EX_ERR_SYMBOL_ERR.OriginErr(fmt.Sprintf("no %s contract of %s", contractType, currencyPair))
+		}
+	}
+
+	p.subsLock.Lock()
+	if p.subs == nil {
+		p.subs = make(map[string]futuresSub, 4)
+	}
+	p.subs[instrumentId] = futuresSub{pair: currencyPair, contractType: contractType}
+	p.subsLock.Unlock()
+
+	return p.v3Ws.subscribePrivate(fmt.Sprintf("%s/%s:%s", prefix, table, instrumentId))
+}
+
+func (p *futuresPrivateWs) SubscribeOrder(currencyPair CurrencyPair, contractType string) error {
+	return p.subscribe("order", currencyPair, contractType)
+}
+
+func (p *futuresPrivateWs) SubscribePosition(currencyPair CurrencyPair, contractType string) error {
+	return p.subscribe("position", currencyPair, contractType)
+}
+
+// SubscribeAccount subscribes futures/account of the margin currency, BTC-USDT for the
+// usdt margined contracts, or swap/account of the swap contract with the swap ws.
+func (p *futuresPrivateWs) SubscribeAccount(currencyPair CurrencyPair) error {
+	if p.swapAccount {
+		return p.v3Ws.subscribePrivate(fmt.Sprintf("swap/account:%s-SWAP", currencyPair.ToSymbol("-")))
+	}
+	if currencyPair.CurrencyB == USDT {
+		return p.v3Ws.subscribePrivate(fmt.Sprintf("futures/account:%s", currencyPair.ToSymbol("-")))
+	}
+	return p.v3Ws.subscribePrivate(fmt.Sprintf("futures/account:%s", currencyPair.CurrencyA.Symbol))
+}
+
+func (p *futuresPrivateWs) sub(instrumentId string) futuresSub {
+	p.subsLock.Lock()
+	sub, ok := p.subs[instrumentId]
+	p.subsLock.Unlock()
+	if !ok && strings.HasSuffix(instrumentId, "-SWAP") {
+		sub = futuresSub{pair: NewCurrencyPair3(strings.TrimSuffix(instrumentId, "-SWAP"), "-"), contractType: SWAP_CONTRACT}
+	}
+	return sub
+}
+
+func (p *futuresPrivateWs) privateHandle(ch string, data json.RawMessage) (bool, error) {
+	var err error
+	switch ch {
+	case "futures/order":
+		var orders []futureOrderResponse
+		if err = json.Unmarshal(data, &orders); err == nil {
+			for _, o := range orders {
+				ord := p.base.OKExFuture.adaptOrder(o)
+				ord.Currency = p.sub(o.InstrumentId).pair
+				p.order(&ord)
+			}
+		}
+	case "swap/order":
+		var orders []BaseOrderInfo
+		if err = json.Unmarshal(data, &orders); err == nil {
+			for _, o := range orders {
+				ord := p.base.OKExSwap.parseOrder(o)
+				ord.ContractName = o.InstrumentId
+				ord.Currency = p.sub(o.InstrumentId).pair
+				p.order(&ord)
+			}
+		}
+	case "futures/position":
+		err = p.futuresPositionHandle(data)
+	case "swap/position":
+		err = p.swapPositionHandle(data)
+	case "futures/account":
+		err = p.futuresAccountHandle(data)
+	case "swap/account":
+		var infos []SwapAccountInfo
+		if err = json.Unmarshal(data, &infos); err == nil {
+			acc := FutureAccount{FutureSubAccounts: make(map[Currency]FutureSubAccount, len(infos))}
+			for _, info := range infos {
+				currency := NewCurrency(strings.Split(info.InstrumentId, "-")[0], "")
+				acc.FutureSubAccounts[currency] = FutureSubAccount{Currency: currency,
+					AccountRights: info.Equity, KeepDeposit: info.Margin, ProfitReal: info.RealizedPnl,
+					ProfitUnreal: info.UnrealizedPnl, RiskRate: info.MarginRatio}
+			}
+			p.account(&acc)
+		}
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+func (p *futuresPrivateWs) order(ord *FutureOrder) {
+	if p.orderCallback != nil {
+		p.orderCallback(ord)
+	}
+}
+
+func (p *futuresPrivateWs) position(pos *FuturePosition) {
+	if p.positionCallback != nil {
+		p.positionCallback(pos)
+	}
+}
+
+func (p *futuresPrivateWs) account(acc *FutureAccount) {
+	if p.accountCallback != nil {
+		p.accountCallback(acc)
+	}
+}
+
+func (p *futuresPrivateWs) futuresPositionHandle(data json.RawMessage) error {
+	var holdings []struct {
+		InstrumentId     string  `json:"instrument_id"`
+		LongQty          float64 `json:"long_qty,string"`
+		LongAvailQty     float64 `json:"long_avail_qty,string"`
+		LongAvgCost      float64 `json:"long_avg_cost,string"`
+		LongPnl          float64 `json:"long_pnl,string"`
+		LongPnlRatio     float64 `json:"long_pnl_ratio,string"`
+		LongUnrealised   float64 `json:"long_unrealised_pnl,string"`
+		ShortQty         float64 `json:"short_qty,string"`
+		ShortAvailQty    float64 `json:"short_avail_qty,string"`
+		ShortAvgCost     float64 `json:"short_avg_cost,string"`
+		ShortPnl         float64 `json:"short_pnl,string"`
+		ShortPnlRatio    float64 `json:"short_pnl_ratio,string"`
+		ShortUnrealised  float64 `json:"short_unrealised_pnl,string"`
+		LiquidationPrice float64 `json:"liquidation_price,string"`
+		Leverage         float64 `json:"leverage,string"`
+		CreatedAt        string  `json:"created_at"`
+	}
+	if err := json.Unmarshal(data, &holdings); err != nil {
+		return err
+	}
+
+	for _, h := range holdings {
+		sub := p.sub(h.InstrumentId)
+		created, _ := time.Parse(time.RFC3339, h.CreatedAt)
+		p.position(&FuturePosition{
+			Symbol:         sub.pair,
+			ContractType:   sub.contractType,
+			ContractId:     ToInt64(h.InstrumentId[strings.LastIndex(h.InstrumentId, "-")+1:]),
+			BuyAmount:      h.LongQty,
+			BuyAvailable:   h.LongAvailQty,
+			BuyPriceAvg:    h.LongAvgCost,
+			BuyPriceCost:   h.LongAvgCost,
+			BuyProfitReal:  h.LongPnl,
+			BuyProfit:      h.LongUnrealised,
+			SellAmount:     h.ShortQty,
+			SellAvailable:  h.ShortAvailQty,
+			SellPriceAvg:   h.ShortAvgCost,
+			SellPriceCost:  h.ShortAvgCost,
+			SellProfitReal: h.ShortPnl,
+			SellProfit:     h.ShortUnrealised,
+			ForceLiquPrice: h.LiquidationPrice,
+			LeverRate:      h.Leverage,
+			CreateDate:     created.Unix(),
+			ShortPnlRatio:  h.ShortPnlRatio,
+			LongPnlRatio:   h.LongPnlRatio,
+		})
+	}
+	return nil
+}
+
+func (p *futuresPrivateWs) swapPositionHandle(data json.RawMessage) error {
+	var positions []struct {
+		InstrumentId string `json:"instrument_id"`
+		Holding      []struct {
+			Side             string  `json:"side"`
+			Position         float64 `json:"position,string"`
+			AvailPosition    float64 `json:"avail_position,string"`
+			AvgCost          float64 `json:"avg_cost,string"`
+			RealizedPnl      float64 `json:"realized_pnl,string"`
+			UnrealizedPnl    float64 `json:"unrealized_pnl,string"`
+			LiquidationPrice float64 `json:"liquidation_price,string"`
+			Leverage         float64 `json:"leverage,string"`
+		} `json:"holding"`
+	}
+	if err := json.Unmarshal(data, &positions); err != nil {
+		return err
+	}
+
+	for _, position := range positions {
+		sub := p.sub(position.InstrumentId)
+		pos := &FuturePosition{Symbol: sub.pair, ContractType: sub.contractType}
+		for _, h := range position.Holding {
+			pos.LeverRate, pos.ForceLiquPrice = h.Leverage, h.LiquidationPrice
+			if h.Side == "short" {
+				pos.SellAmount, pos.SellAvailable = h.Position, h.AvailPosition
+				pos.SellPriceAvg, pos.SellPriceCost = h.AvgCost, h.AvgCost
+				pos.SellProfitReal, pos.SellProfit = h.RealizedPnl, h.UnrealizedPnl
+			} else {
+				pos.BuyAmount, pos.BuyAvailable = h.Position, h.AvailPosition
+				pos.BuyPriceAvg, pos.BuyPriceCost = h.AvgCost, h.AvgCost
+				pos.BuyProfitReal, pos.BuyProfit = h.RealizedPnl, h.UnrealizedPnl
+			}
+		}
+		p.position(pos)
+	}
+	return nil
+}
+
+func (p *futuresPrivateWs) futuresAccountHandle(data json.RawMessage) error {
+	var accounts []map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return err
+	}
+
+	acc := FutureAccount{FutureSubAccounts: make(map[Currency]FutureSubAccount, 1)}
+	for _, m := range accounts {
+		for c, info := range m { //BTC of the coin margined contracts or BTC-USDT of the usdt margined ones
+			currency := NewCurrency(c, "")
+			if i := strings.Index(c, "-"); i > 0 {
+				currency = NewCurrency(c[i+1:], "")
+			}
+			acc.FutureSubAccounts[currency] = FutureSubAccount{
+				Currency:      currency,
+				AccountRights: ToFloat64(info["equity"]),
+				ProfitReal:    ToFloat64(info["realized_pnl"]),
+				ProfitUnreal:  ToFloat64(info["unrealized_pnl"]),
+				KeepDeposit:   ToFloat64(info["margin_frozen"]),
+				RiskRate:      ToFloat64(info["margin_ratio"]),
+			}
+		}
+	}
+	p.account(&acc)
+	return nil
+}
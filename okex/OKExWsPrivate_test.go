@@ -0,0 +1,130 @@
+package okex
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOKExV3Ws_Login(t *testing.T) {
+	rp := &goex.WsReplayer{}
+	srv := rp.Server()
+	defer srv.Close()
+
+	var sent lockedBuffer
+	ws := NewOKEx(&goex.APIConfig{ApiKey: "key", ApiSecretKey: "secret", ApiPassphrase: "pass"}).OKExV3SpotWs
+	ws.v3Ws.WsBuilder.WsUrl("ws" + strings.TrimPrefix(srv.URL, "http")).Recorder(goex.NewWsRecorderWithWriter(&sent))
+	ws.v3Ws.ConnectWs()
+	defer ws.v3Ws.WsConn.CloseWs()
+
+	login := func(resp string) error {
+		n := strings.Count(sent.String(), "login")
+		done := make(chan error, 1)
+		go func() { done <- ws.Login() }()
+		require.Eventually(t, func() bool {
+			return strings.Count(sent.String(), "login") > n
+		}, time.Second, 10*time.Millisecond)
+		require.NoError(t, ws.v3Ws.handle([]byte(resp)))
+		return <-done
+	}
+
+	assert.Nil(t, ws.v3Ws.reloginMessage(), "no login on reconnect before Login")
+	err := login(`{"event":"error","message":"Invalid sign","errorCode":30013}`)
+	assert.True(t, errors.Is(err, goex.EX_ERR_SIGN), err)
+	assert.Nil(t, ws.v3Ws.reloginMessage(), "nor after a failed one")
+
+	require.NoError(t, login(`{"event":"login","success":true}`))
+	assert.Contains(t, string(ws.v3Ws.reloginMessage()), `"op":"login"`)
+	assert.NoError(t, ws.Login(), "logged in already")
+
+	done := make(chan error, 1)
+	go func() { done <- ws.SubscribeOrder(goex.BTC_USDT) }()
+	require.Eventually(t, func() bool {
+		return len(ws.v3Ws.WsConn.PendingAcks()) > 0
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, ws.v3Ws.handle([]byte(`{"event":"subscribe","channel":"spot/order:BTC-USDT"}`)))
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"spot/order:BTC-USDT"}, ws.v3Ws.WsConn.Subscriptions(), "on the logged in connection")
+	assert.Empty(t, ws.v3Ws.WsPool.Conns())
+
+	frames, err := goex.ReadWsFrames(strings.NewReader(sent.String()))
+	require.NoError(t, err)
+	require.Len(t, frames, 3)
+	assert.Equal(t, `{"args":"REDACTED","op":"login"}`, frames[0].Text, "the credentials are not recorded")
+	assert.Contains(t, string(ws.v3Ws.loginMessage()), `"args":["key","pass",`)
+
+	assert.Equal(t, goex.EX_ERR_NOT_FIND_APIKEY, NewOKExSpotV3Ws(nil).Login())
+}
+
+func TestOKExV3SpotWs_Private(t *testing.T) {
+	var (
+		orders   []*goex.Order
+		accounts []*goex.Account
+	)
+	ws := NewOKEx(&goex.APIConfig{}).OKExV3SpotWs
+	ws.OrderCallback(func(order *goex.Order) { orders = append(orders, order) })
+	ws.AccountCallback(func(account *goex.Account) { accounts = append(accounts, account) })
+
+	require.NoError(t, ws.v3Ws.handle([]byte(`{"table":"spot/order","data":[{"client_oid":"abc","filled_notional":"17.5","filled_size":"0.002","instrument_id":"BTC-USDT","last_fill_px":"8750","order_id":"3576398568830976","order_type":"0","price":"8800","side":"buy","size":"0.01","state":"1","timestamp":"2021-01-01T00:00:00.000Z","type":"limit"}]}`)))
+	require.Len(t, orders, 1)
+	ord := orders[0]
+	assert.Equal(t, "3576398568830976", ord.OrderID2)
+	assert.Equal(t, "abc", ord.Cid)
+	assert.Equal(t, goex.BTC_USDT.String(), ord.Currency.String())
+	assert.Equal(t, goex.BUY, ord.Side)
+	assert.Equal(t, goex.ORDER_PART_FINISH, ord.Status)
+	assert.Equal(t, 0.002, ord.DealAmount)
+	assert.InDelta(t, 8750, ord.AvgPrice, 1e-9)
+
+	require.NoError(t, ws.v3Ws.handle([]byte(`{"table":"spot/account","data":[{"balance":"2.2","available":"1.6","currency":"USDT","id":"","hold":"0.6"}]}`)))
+	require.Len(t, accounts, 1)
+	assert.Equal(t, goex.SubAccount{Currency: goex.USDT, Amount: 1.6, ForzenAmount: 0.6}, accounts[0].SubAccounts[goex.USDT])
+}
+
+func TestOKExV3FuturesWs_Private(t *testing.T) {
+	var (
+		orders    []*goex.FutureOrder
+		positions []*goex.FuturePosition
+		accounts  []*goex.FutureAccount
+	)
+	ws := NewOKEx(&goex.APIConfig{}).OKExV3FuturesWs
+	ws.subs = map[string]futuresSub{"BTC-USD-210625": {goex.BTC_USD, goex.QUARTER_CONTRACT}}
+	ws.OrderCallback(func(order *goex.FutureOrder) { orders = append(orders, order) })
+	ws.PositionCallback(func(position *goex.FuturePosition) { positions = append(positions, position) })
+	ws.AccountCallback(func(account *goex.FutureAccount) { accounts = append(accounts, account) })
+
+	require.NoError(t, ws.v3Ws.handle([]byte(`{"table":"futures/order","data":[{"leverage":"20","filled_qty":"1","fee":"-0.0001","price_avg":"35000","client_oid":"","type":"1","instrument_id":"BTC-USD-210625","size":"2","price":"35000","state":"1","contract_val":"100","order_id":"6592583484541952","order_type":"0","timestamp":"2021-01-01T00:00:00.000Z"}]}`)))
+	require.NoError(t, ws.v3Ws.handle([]byte(`{"table":"swap/order","data":[{"client_oid":"","filled_qty":"0","fee":"0","price_avg":"0","type":"2","instrument_id":"ETH-USDT-SWAP","size":"1","price":"700","state":"0","status":"0","order_id":"123","order_type":"0","timestamp":"2021-01-01T00:00:00.000Z"}]}`)))
+	require.Len(t, orders, 2)
+	assert.Equal(t, goex.BTC_USD, orders[0].Currency)
+	assert.Equal(t, goex.OPEN_BUY, orders[0].OType)
+	assert.Equal(t, goex.ORDER_PART_FINISH, orders[0].Status)
+	assert.Equal(t, 1.0, orders[0].DealAmount)
+	assert.Equal(t, goex.ETH_USDT.String(), orders[1].Currency.String(), "swap pairs without a subscription")
+	assert.Equal(t, goex.OPEN_SELL, orders[1].OType)
+	assert.Equal(t, "ETH-USDT-SWAP", orders[1].ContractName)
+
+	require.NoError(t, ws.v3Ws.handle([]byte(`{"table":"futures/position","data":[{"long_qty":"2","long_avail_qty":"1","long_avg_cost":"35000","long_pnl":"0.001","long_unrealised_pnl":"0.002","short_qty":"0","short_avail_qty":"0","short_avg_cost":"0","liquidation_price":"20000","instrument_id":"BTC-USD-210625","leverage":"10","created_at":"2021-01-01T00:00:00.000Z","margin_mode":"crossed"}]}`)))
+	require.NoError(t, ws.v3Ws.handle([]byte(`{"table":"swap/position","data":[{"holding":[{"avail_position":"3","avg_cost":"700","leverage":"5","liquidation_price":"900","position":"3","realized_pnl":"0","side":"short","unrealized_pnl":"-1"}],"instrument_id":"ETH-USDT-SWAP","margin_mode":"crossed"}]}`)))
+	require.Len(t, positions, 2)
+	assert.Equal(t, goex.QUARTER_CONTRACT, positions[0].ContractType)
+	assert.Equal(t, int64(210625), positions[0].ContractId)
+	assert.Equal(t, 2.0, positions[0].BuyAmount)
+	assert.Equal(t, 1.0, positions[0].BuyAvailable)
+	assert.Equal(t, 0.002, positions[0].BuyProfit)
+	assert.Equal(t, &goex.FuturePosition{Symbol: goex.NewCurrencyPair3("ETH-USDT", "-"), ContractType: goex.SWAP_CONTRACT, LeverRate: 5, ForceLiquPrice: 900,
+		SellAmount: 3, SellAvailable: 3, SellPriceAvg: 700, SellPriceCost: 700, SellProfit: -1}, positions[1])
+
+	require.NoError(t, ws.v3Ws.handle([]byte(`{"table":"futures/account","data":[{"BTC-USDT":{"equity":"100","margin_frozen":"10","margin_ratio":"2","realized_pnl":"1","unrealized_pnl":"-1","margin_mode":"crossed"}}]}`)))
+	require.NoError(t, ws.v3Ws.handle([]byte(`{"table":"swap/account","data":[{"equity":"5","instrument_id":"BTC-USD-SWAP","margin":"1","margin_ratio":"3","realized_pnl":"0","unrealized_pnl":"0.1"}]}`)))
+	require.Len(t, accounts, 2)
+	assert.Equal(t, goex.FutureSubAccount{Currency: goex.USDT, AccountRights: 100, KeepDeposit: 10, RiskRate: 2, ProfitReal: 1, ProfitUnreal: -1},
+		accounts[0].FutureSubAccounts[goex.USDT])
+	assert.Equal(t, goex.FutureSubAccount{Currency: goex.BTC, AccountRights: 5, KeepDeposit: 1, RiskRate: 3, ProfitUnreal: 0.1},
+		accounts[1].FutureSubAccounts[goex.BTC])
+}
@@ -1,13 +1,16 @@
 package okex
 
 import (
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/soulsplit/goex"
 	"github.com/soulsplit/goex/internal/logger"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func init() {
@@ -138,7 +141,7 @@ func TestOKExFuture_GetRate(t *testing.T) {
 
 func TestOKExFuture_GetKlineRecords(t *testing.T) {
 	since := time.Now().Add(-24 * time.Hour).Unix()
-	kline, err := okex.OKExFuture.GetKlineRecords(goex.QUARTER_CONTRACT, goex.BTC_USD, goex.KLINE_PERIOD_4H, 0, int(since))
+	kline, err := okex.OKExFuture.GetKlineRecords(goex.QUARTER_CONTRACT, goex.BTC_USD, goex.KLINE_PERIOD_4H, 0, goex.OptionalParameter{}.Optional("since", since))
 	assert.Nil(t, err)
 	for _, k := range kline {
 		t.Logf("%+v", k.Kline)
@@ -241,3 +244,78 @@ func TestOKExSpot_GetOrderHistorys(t *testing.T) {
 	}
 	t.Log(len(orders))
 }
+
+func TestOKEx_AdaptError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/spot/v3/orders":
+			w.Write([]byte(`{"client_oid":"","order_id":"-1","result":false,"error_code":"33017","error_message":"Greater than the maximum available balance"}`))
+		case "/api/spot/v3/cancel_orders/1":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":33014,"message":"Order does not exist"}`))
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error_code":"30014","error_message":"Request too frequent"}`))
+		}
+	}))
+	defer srv.Close()
+	ok := NewOKEx(&goex.APIConfig{Endpoint: srv.URL, HttpClient: http.DefaultClient})
+
+	_, err := ok.OKExSpot.LimitBuy("1", "1", goex.BTC_USDT)
+	assert.True(t, errors.Is(err, goex.EX_ERR_INSUFFICIENT_BALANCE))
+	var apiErr goex.ApiError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "33017", apiErr.NativeCode)
+	assert.Equal(t, http.StatusOK, apiErr.HttpStatus)
+
+	_, err = ok.OKExSpot.CancelOrder("1", goex.BTC_USDT)
+	assert.True(t, errors.Is(err, goex.EX_ERR_NOT_FIND_ORDER))
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "33014", apiErr.NativeCode)
+	assert.Equal(t, http.StatusBadRequest, apiErr.HttpStatus)
+
+	_, err = ok.OKExSpot.GetAccount()
+	assert.True(t, errors.Is(err, goex.EX_ERR_API_LIMIT))
+}
+
+func TestOKEx_GetInstrumentsInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/spot/v3/instruments":
+			w.Write([]byte(`[{"base_currency":"BTC","instrument_id":"BTC-USDT","min_size":"0.00001","quote_currency":"USDT","size_increment":"0.00000001","tick_size":"0.1"}]`))
+		case "/api/futures/v3/instruments":
+			w.Write([]byte(`[{"instrument_id":"BTC-USD-210326","underlying_index":"BTC","quote_currency":"USD","tick_size":"0.01","contract_val":"100",
+				"listing":"2020-12-11","delivery":"2021-03-26","trade_increment":"1","alias":"quarter","underlying":"BTC-USD","base_currency":"BTC",
+				"settlement_currency":"BTC","is_inverse":"true","contract_val_currency":"USD"}]`))
+		case "/api/swap/v3/instruments":
+			w.Write([]byte(`[{"instrument_id":"ETH-USDT-SWAP","underlying_index":"ETH","quote_currency":"USDT","coin":"USDT","contract_val":"0.1",
+				"listing":"2019-11-11T11:11:11.000Z","delivery":"2021-01-01T08:00:00.000Z","size_increment":"1","tick_size":"0.01","base_currency":"ETH",
+				"underlying":"ETH-USDT","settlement_currency":"USDT","is_inverse":"false","contract_val_currency":"ETH"}]`))
+		}
+	}))
+	defer srv.Close()
+	ok := NewOKEx(&goex.APIConfig{Endpoint: srv.URL, HttpClient: http.DefaultClient})
+
+	instruments, err := ok.GetInstrumentsInfo()
+	require.NoError(t, err)
+	require.Len(t, instruments, 1)
+	assert.Equal(t, goex.BTC_USDT.String(), instruments[0].Pair.String())
+	assert.Equal(t, "0.00000001", instruments[0].LotSize.String())
+	assert.Equal(t, "0.00001", instruments[0].MinAmount.String())
+
+	instruments, err = ok.OKExFuture.GetInstrumentsInfo()
+	require.NoError(t, err)
+	require.Len(t, instruments, 1)
+	assert.Equal(t, goex.BTC_USD.String(), instruments[0].Pair.String())
+	assert.Equal(t, goex.QUARTER_CONTRACT, instruments[0].ContractType)
+	assert.Equal(t, "100", instruments[0].ContractVal.String())
+	assert.Equal(t, time.Date(2021, 3, 26, 8, 0, 0, 0, time.UTC), instruments[0].Expiry)
+
+	instruments, err = ok.OKExSwap.GetInstrumentsInfo()
+	require.NoError(t, err)
+	require.Len(t, instruments, 1)
+	assert.Equal(t, goex.SWAP_USDT, instruments[0].Kind)
+	assert.Equal(t, goex.SWAP_USDT_CONTRACT, instruments[0].ContractType)
+	assert.Equal(t, "0.1", instruments[0].ContractVal.String())
+	assert.Equal(t, "ETH", instruments[0].ContractValCurrency.Symbol)
+}
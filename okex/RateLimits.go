@@ -0,0 +1,82 @@
+package okex
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+// RateLimits are the published v3 limits, okex counts every endpoint on its own so the
+// paths are exact, "*" stands for an instrument or order id.
+func RateLimits() []RateLimitRule {
+	perEndpoint := func(method, path string, limit int) RateLimitRule {
+		return RateLimitRule{Name: method + " " + path, Method: method, Path: path, Interval: 2 * time.Second, Limit: limit}
+	}
+	return []RateLimitRule{
+		//spot and margin
+		perEndpoint(http.MethodPost, "/api/spot/v3/orders", 100),
+		perEndpoint(http.MethodPost, "/api/spot/v3/batch_orders", 50),
+		perEndpoint(http.MethodPost, "/api/spot/v3/cancel_orders/*", 100),
+		perEndpoint(http.MethodPost, "/api/spot/v3/cancel_batch_orders", 50),
+		perEndpoint(http.MethodGet, "/api/spot/v3/orders", 20),
+		perEndpoint(http.MethodGet, "/api/spot/v3/orders_pending", 20),
+		perEndpoint(http.MethodGet, "/api/spot/v3/orders/*", 20),
+		perEndpoint(http.MethodGet, "/api/spot/v3/accounts", 20),
+		perEndpoint(http.MethodGet, "/api/spot/v3/accounts/*", 20),
+		perEndpoint(http.MethodGet, "/api/spot/v3/instruments", 20),
+		perEndpoint(http.MethodGet, "/api/spot/v3/instruments/ticker", 20),
+		perEndpoint(http.MethodGet, "/api/spot/v3/instruments/*/ticker", 20),
+		perEndpoint(http.MethodGet, "/api/spot/v3/instruments/*/book", 20),
+		perEndpoint(http.MethodGet, "/api/spot/v3/instruments/*/trades", 20),
+		perEndpoint(http.MethodGet, "/api/spot/v3/instruments/*/candles", 20),
+		perEndpoint(http.MethodPost, "/api/margin/v3/orders", 100),
+		perEndpoint(http.MethodPost, "/api/margin/v3/cancel_orders/*", 100),
+		perEndpoint(http.MethodPost, "/api/margin/v3/accounts/borrow", 100),
+		perEndpoint(http.MethodPost, "/api/margin/v3/accounts/repayment", 100),
+		perEndpoint(http.MethodGet, "/api/margin/v3/orders", 20),
+		perEndpoint(http.MethodGet, "/api/margin/v3/orders_pending", 20),
+		perEndpoint(http.MethodGet, "/api/margin/v3/orders/*", 20),
+		perEndpoint(http.MethodGet, "/api/margin/v3/accounts", 20),
+		perEndpoint(http.MethodGet, "/api/margin/v3/accounts/*", 20),
+		//futures
+		perEndpoint(http.MethodPost, "/api/futures/v3/order", 40),
+		perEndpoint(http.MethodPost, "/api/futures/v3/orders", 20),
+		perEndpoint(http.MethodPost, "/api/futures/v3/cancel_order/*/*", 40),
+		perEndpoint(http.MethodPost, "/api/futures/v3/close_position", 20),
+		perEndpoint(http.MethodGet, "/api/futures/v3/orders/*", 20),
+		perEndpoint(http.MethodGet, "/api/futures/v3/orders/*/*", 20),
+		perEndpoint(http.MethodGet, "/api/futures/v3/*/position", 20),
+		perEndpoint(http.MethodGet, "/api/futures/v3/accounts", 20),
+		perEndpoint(http.MethodGet, "/api/futures/v3/accounts/*", 20),
+		perEndpoint(http.MethodGet, "/api/futures/v3/instruments", 20),
+		perEndpoint(http.MethodGet, "/api/futures/v3/instruments/ticker", 20),
+		perEndpoint(http.MethodGet, "/api/futures/v3/instruments/*/ticker", 20),
+		perEndpoint(http.MethodGet, "/api/futures/v3/instruments/*/book", 20),
+		perEndpoint(http.MethodGet, "/api/futures/v3/instruments/*/candles", 20),
+		perEndpoint(http.MethodGet, "/api/futures/v3/instruments/*/index", 20),
+		perEndpoint(http.MethodGet, "/api/futures/v3/instruments/*/estimated_price", 20),
+		//swap
+		perEndpoint(http.MethodPost, "/api/swap/v3/order", 40),
+		perEndpoint(http.MethodPost, "/api/swap/v3/orders", 20),
+		perEndpoint(http.MethodPost, "/api/swap/v3/cancel_order/*/*", 40),
+		perEndpoint(http.MethodPost, "/api/swap/v3/order_algo", 40),
+		perEndpoint(http.MethodPost, "/api/swap/v3/cancel_algos", 20),
+		perEndpoint(http.MethodGet, "/api/swap/v3/orders/*", 20),
+		perEndpoint(http.MethodGet, "/api/swap/v3/orders/*/*", 20),
+		perEndpoint(http.MethodGet, "/api/swap/v3/order_algo/*", 20),
+		perEndpoint(http.MethodGet, "/api/swap/v3/*/position", 20),
+		perEndpoint(http.MethodGet, "/api/swap/v3/accounts", 20),
+		perEndpoint(http.MethodGet, "/api/swap/v3/*/accounts", 20),
+		perEndpoint(http.MethodGet, "/api/swap/v3/instruments", 20),
+		perEndpoint(http.MethodGet, "/api/swap/v3/instruments/ticker", 20),
+		perEndpoint(http.MethodGet, "/api/swap/v3/instruments/*/ticker", 20),
+		perEndpoint(http.MethodGet, "/api/swap/v3/instruments/*/depth", 20),
+		perEndpoint(http.MethodGet, "/api/swap/v3/instruments/*/candles", 20),
+		perEndpoint(http.MethodGet, "/api/swap/v3/instruments/*/historical_funding_rate", 20),
+		//wallet
+		perEndpoint(http.MethodGet, "/api/account/v3/wallet", 20),
+		perEndpoint(http.MethodPost, "/api/account/v3/transfer", 1),
+		perEndpoint(http.MethodPost, "/api/account/v3/withdrawal", 20),
+	}
+}
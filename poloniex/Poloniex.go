@@ -77,13 +77,13 @@ func (exchange *Exchange) GetDepth(size int, currency CurrencyPair) (*Depth, err
 
 	if respmap["asks"] == nil {
 		log.Println(respmap)
-		return nil, errors.New(fmt.Sprintf("%+v", respmap))
+		return nil, adaptError(respmap, fmt.Sprintf("%+v", respmap))
 	}
 
 	_, isOK := respmap["asks"].([]interface{})
 	if !isOK {
 		log.Println(respmap)
-		return nil, errors.New(fmt.Sprintf("%+v", respmap))
+		return nil, adaptError(respmap, fmt.Sprintf("%+v", respmap))
 	}
 
 	var depth Depth
@@ -141,10 +141,13 @@ func (exchange *Exchange) placeLimitOrder(command, amount, price string, currenc
 
 	respmap := make(map[string]interface{})
 	err = json.Unmarshal(resp, &respmap)
-	if err != nil || respmap["error"] != nil {
+	if err != nil {
 		log.Println(err, string(resp))
 		return nil, err
 	}
+	if respmap["error"] != nil {
+		return nil, adaptError(respmap, string(resp))
+	}
 
 	orderNumber := respmap["orderNumber"].(string)
 	order := new(Order)
@@ -201,7 +204,7 @@ func (exchange *Exchange) CancelOrder(orderId string, currency CurrencyPair) (bo
 	err = json.Unmarshal(resp, &respmap)
 	if err != nil || respmap["error"] != nil {
 		//log.Println(err, string(resp))
-		return false, errors.New(string(resp))
+		return false, adaptError(respmap, string(resp))
 	}
 
 	success := int(respmap["success"].(float64))
@@ -247,7 +250,7 @@ func (exchange *Exchange) GetOneOrder(orderId string, currency CurrencyPair) (*O
 			}
 		}
 		//log.Println(string(resp))
-		return nil, errors.New(string(resp))
+		return nil, adaptError(nil, string(resp))
 	}
 
 	respmap := make([]interface{}, 0)
@@ -362,10 +365,13 @@ func (exchange *Exchange) GetAccount() (*Account, error) {
 	respmap := make(map[string]interface{})
 	err = json.Unmarshal(resp, &respmap)
 
-	if err != nil || respmap["error"] != nil {
+	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
+	if respmap["error"] != nil {
+		return nil, adaptError(respmap, string(resp))
+	}
 
 	acc := new(Account)
 	acc.Exchange = EXCHANGE_NAME
@@ -426,7 +432,34 @@ func (exchange *Exchange) Withdraw(amount string, currency Currency, fees, recei
 		return string(resp), nil
 	}
 
-	return "", errors.New(string(resp))
+	return "", adaptError(respMap, string(resp))
+}
+
+// poloniex has no error codes, the kind is told by the message
+var errorMessages = ErrorMessages{
+	"not enough":                   EX_ERR_INSUFFICIENT_BALANCE,
+	"invalid order number":         EX_ERR_NOT_FIND_ORDER,
+	"order not found":              EX_ERR_NOT_FIND_ORDER,
+	"invalid api key":              EX_ERR_AUTH,
+	"invalid currency pair":        EX_ERR_INVALID_CURRENCY_PAIR,
+	"nonce must be greater":        EX_ERR_TIMESTAMP,
+	"total must be at least":       EX_ERR_MIN_NOTIONAL,
+	"amount must be at least":      EX_ERR_MIN_AMOUNT,
+	"please do not make more than": EX_ERR_API_LIMIT,
+	"invalid command":              EX_ERR_INVALID_PARAMETER,
+	"invalid rate":                 EX_ERR_INVALID_PARAMETER,
+	"invalid amount":               EX_ERR_INVALID_PARAMETER,
+	"maintenance":                  EX_ERR_MAINTENANCE,
+}
+
+// adaptError maps the {"error":"Not enough BTC."} body of a request, raw is the
+// message if there is no error field.
+func adaptError(respmap map[string]interface{}, raw string) ApiError {
+	msg, _ := respmap["error"].(string)
+	if msg == "" {
+		msg = raw
+	}
+	return errorMessages.Adapt(http.StatusOK, msg)
 }
 
 type PoloniexDepositsWithdrawals struct {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"net/http"
 	"net/url"
 
 	. "github.com/soulsplit/goex"
@@ -54,7 +55,7 @@ func (poloniex *Exchange) CloseMarginPosition(currency CurrencyPair) (bool, erro
 		return false, err
 	}
 	if result.Success == 0 {
-		return false, errors.New(result.Error)
+		return false, errorMessages.Adapt(http.StatusOK, result.Error)
 	}
 	return true, nil
 }
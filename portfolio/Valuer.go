@@ -0,0 +1,472 @@
+// Package portfolio values the balances of spot, margin and futures accounts on
+// several exchanges in one quote currency.
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+const (
+	KindSpot    = "spot"
+	KindMargin  = "margin"
+	KindFutures = "futures"
+)
+
+// MarginAPI reads the margin account of a pair, e.g. okex.ExchangeMargin.
+type MarginAPI interface {
+	GetMarginAccount(pair CurrencyPair) (*MarginAccount, error)
+}
+
+// Source is an exchange whose accounts Value reads. API also prices the assets
+// with its tickers.
+type Source struct {
+	Name        string //default API.GetExchangeName()
+	API         API    //optional, the spot account
+	Futures     FutureRestAPI
+	FuturePairs []CurrencyPair //passed to GetFutureUserinfo
+	Margin      MarginAPI
+	MarginPairs []CurrencyPair //one margin account each
+}
+
+type Config struct {
+	Quote   Currency //default USDT
+	Sources []Source
+	// Tickers price the assets before the APIs of the sources, in order.
+	Tickers []API
+	// Bridges are the currencies an asset without a pair to Quote is converted
+	// through, in order, default BTC, ETH, USDT and USD.
+	Bridges      []Currency
+	MaxHops      int                  //pairs from an asset to Quote, default 3
+	Prices       map[Currency]float64 //fixed prices in Quote, e.g. 1 for a stablecoin
+	TickerMaxAge time.Duration        //tickers are read again after, default 10s
+}
+
+// Balance is an amount of a currency held on an account.
+type Balance struct {
+	Exchange string
+	Kind     string //KindSpot, KindMargin or KindFutures
+	Currency Currency
+	Amount   float64 //gross, the frozen amount included
+	Debt     float64 //loans and their interest
+}
+
+type Holding struct {
+	Balance
+	Price    float64 //of a unit in Quote
+	Asset    float64 //Amount * Price
+	NetAsset float64 //(Amount - Debt) * Price
+}
+
+type Value struct {
+	Asset    float64
+	NetAsset float64
+}
+
+type AssetValue struct {
+	Value
+	Amount float64
+	Debt   float64
+	Price  float64
+}
+
+type Valuation struct {
+	Quote      Currency
+	Time       time.Time
+	Holdings   []Holding //by exchange, kind and currency
+	ByAsset    map[Currency]AssetValue
+	ByExchange map[string]Value
+	Total      Value
+	// Accounts are the spot accounts read by Value, their Asset and NetAsset set
+	// when all their currencies could be priced.
+	Accounts map[string]*Account
+	Unpriced map[Currency]error //currencies left out of the values
+	Errors   map[string]error   //of the accounts that could not be read, by "exchange kind"
+}
+
+type quote struct {
+	price float64
+	time  time.Time
+}
+
+type Valuer struct {
+	conf    Config
+	sources []Source
+	tickers []API
+
+	mu     sync.Mutex
+	quotes map[string]quote //by pair, across the tickers
+	now    func() time.Time
+}
+
+func NewValuer(conf *Config) (*Valuer, error) {
+	v := &Valuer{
+		conf:   *conf,
+		quotes: make(map[string]quote),
+		now:    time.Now,
+	}
+	if v.conf.Quote.Symbol == "" {
+		v.conf.Quote = USDT
+	}
+	if v.conf.Bridges == nil {
+		v.conf.Bridges = []Currency{BTC, ETH, USDT, USD}
+	}
+	if v.conf.MaxHops <= 0 {
+		v.conf.MaxHops = 3
+	}
+	if v.conf.TickerMaxAge <= 0 {
+		v.conf.TickerMaxAge = 10 * time.Second
+	}
+
+	names := make(map[string]bool)
+	for _, s := range v.conf.Sources {
+		if s.Name == "" && s.API != nil {
+			s.Name = s.API.GetExchangeName()
+		}
+		if s.Name == "" && s.Futures != nil {
+			s.Name = s.Futures.GetExchangeName()
+		}
+		if s.Name == "" || names[s.Name] {
+			return nil, fmt.Errorf("source name %q is empty or used twice", s.Name)
+		}
+		if s.Margin != nil && len(s.MarginPairs) == 0 {
+			return nil, fmt.Errorf("%s: no margin pairs", s.Name)
+		}
+		names[s.Name] = true
+		v.sources = append(v.sources, s)
+	}
+
+	v.tickers = append(v.tickers, v.conf.Tickers...)
+	for _, s := range v.sources {
+		if s.API != nil {
+			v.tickers = append(v.tickers, s.API)
+		}
+	}
+	if len(v.tickers) == 0 && len(v.conf.Prices) == 0 {
+		return nil, errors.New("nothing to price with")
+	}
+	return v, nil
+}
+
+// SpotBalances returns the balances of a spot account, its loans as debt.
+func SpotBalances(exchange string, acc *Account) []Balance {
+	var balances []Balance
+	for c, sub := range acc.SubAccounts {
+		if sub.Currency.Symbol != "" {
+			c = sub.Currency
+		}
+		balances = append(balances, Balance{Exchange: exchange, Kind: KindSpot, Currency: c,
+			Amount: sub.Amount + sub.ForzenAmount, Debt: sub.LoanAmount})
+	}
+	return balances
+}
+
+// MarginBalances returns the balances of a margin account, its loans and lending
+// fees as debt.
+func MarginBalances(exchange string, acc *MarginAccount) []Balance {
+	var balances []Balance
+	for c, sub := range acc.Sub {
+		balances = append(balances, Balance{Exchange: exchange, Kind: KindMargin, Currency: c,
+			Amount: sub.Balance, Debt: sub.Loan + sub.LendingFee})
+	}
+	return balances
+}
+
+// FutureBalances returns the rights of a futures account, the unrealized profit
+// included.
+func FutureBalances(exchange string, acc *FutureAccount) []Balance {
+	var balances []Balance
+	for c, sub := range acc.FutureSubAccounts {
+		if sub.Currency.Symbol != "" {
+			c = sub.Currency
+		}
+		balances = append(balances, Balance{Exchange: exchange, Kind: KindFutures, Currency: c,
+			Amount: sub.AccountRights})
+	}
+	return balances
+}
+
+// Value reads the accounts of all the sources and values them. An account that
+// cannot be read is left out, its error is in Valuation.Errors, it fails only when
+// none can be read.
+func (v *Valuer) Value(ctx context.Context) (*Valuation, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		balances []Balance
+		accounts = make(map[string]*Account)
+		errs     = make(map[string]error)
+	)
+	read := func(name, kind string, f func() ([]Balance, error)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b, err := f()
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[name+" "+kind] = err
+				return
+			}
+			balances = append(balances, b...)
+		}()
+	}
+
+	for _, s := range v.sources {
+		s := s
+		if s.API != nil {
+			read(s.Name, KindSpot, func() ([]Balance, error) {
+				var (
+					acc *Account
+					err error
+				)
+				if ctxApi, ok := s.API.(APIWithContext); ok {
+					acc, err = ctxApi.GetAccountWithContext(ctx)
+				} else {
+					acc, err = s.API.GetAccount()
+				}
+				if err != nil {
+					return nil, err
+				}
+				mu.Lock()
+				accounts[s.Name] = acc
+				mu.Unlock()
+				return SpotBalances(s.Name, acc), nil
+			})
+		}
+		if s.Futures != nil {
+			read(s.Name, KindFutures, func() ([]Balance, error) {
+				var (
+					acc *FutureAccount
+					err error
+				)
+				if ctxApi, ok := s.Futures.(FutureRestAPIWithContext); ok {
+					acc, err = ctxApi.GetFutureUserinfoWithContext(ctx, s.FuturePairs...)
+				} else {
+					acc, err = s.Futures.GetFutureUserinfo(s.FuturePairs...)
+				}
+				if err != nil {
+					return nil, err
+				}
+				return FutureBalances(s.Name, acc), nil
+			})
+		}
+		if s.Margin != nil {
+			read(s.Name, KindMargin, func() ([]Balance, error) {
+				var balances []Balance
+				for _, pair := range s.MarginPairs {
+					acc, err := s.Margin.GetMarginAccount(pair)
+					if err != nil {
+						return nil, fmt.Errorf("%s: %w", pair, err)
+					}
+					balances = append(balances, MarginBalances(s.Name, acc)...)
+				}
+				return balances, nil
+			})
+		}
+	}
+	wg.Wait()
+	if len(balances) == 0 && len(errs) > 0 {
+		for kind, err := range errs {
+			return nil, fmt.Errorf("%s: %w", kind, err)
+		}
+	}
+
+	val := v.ValueBalances(ctx, balances)
+	val.Accounts, val.Errors = accounts, errs
+	for name, acc := range accounts {
+		if acc.Exchange == "" {
+			acc.Exchange = name
+		}
+		v.fill(acc, val.Holdings, name, val.Unpriced)
+	}
+	return val, nil
+}
+
+// ValueBalances prices the balances and sums them by asset, by exchange and in
+// total. A currency that cannot be priced is in Valuation.Unpriced.
+func (v *Valuer) ValueBalances(ctx context.Context, balances []Balance) *Valuation {
+	val := &Valuation{
+		Quote:      v.conf.Quote,
+		Time:       v.now(),
+		ByAsset:    make(map[Currency]AssetValue),
+		ByExchange: make(map[string]Value),
+		Unpriced:   make(map[Currency]error),
+	}
+	prices := make(map[Currency]float64)
+	for _, b := range balances {
+		if b.Amount == 0 && b.Debt == 0 {
+			continue
+		}
+		price, ok := prices[b.Currency]
+		if !ok {
+			if _, failed := val.Unpriced[b.Currency]; failed {
+				continue
+			}
+			var err error
+			if price, err = v.Price(ctx, b.Currency); err != nil {
+				val.Unpriced[b.Currency] = err
+				continue
+			}
+			prices[b.Currency] = price
+		}
+
+		h := Holding{Balance: b, Price: price, Asset: b.Amount * price, NetAsset: (b.Amount - b.Debt) * price}
+		val.Holdings = append(val.Holdings, h)
+
+		a := val.ByAsset[b.Currency]
+		a.Amount += b.Amount
+		a.Debt += b.Debt
+		a.Price = price
+		a.Asset += h.Asset
+		a.NetAsset += h.NetAsset
+		val.ByAsset[b.Currency] = a
+
+		e := val.ByExchange[b.Exchange]
+		e.Asset += h.Asset
+		e.NetAsset += h.NetAsset
+		val.ByExchange[b.Exchange] = e
+
+		val.Total.Asset += h.Asset
+		val.Total.NetAsset += h.NetAsset
+	}
+	sort.SliceStable(val.Holdings, func(i, j int) bool {
+		hi, hj := val.Holdings[i], val.Holdings[j]
+		if hi.Exchange != hj.Exchange {
+			return hi.Exchange < hj.Exchange
+		}
+		if hi.Kind != hj.Kind {
+			return hi.Kind < hj.Kind
+		}
+		return hi.Currency.Symbol < hj.Currency.Symbol
+	})
+	return val
+}
+
+// FillAccount sets the Asset and NetAsset of a spot account in Quote. It is left
+// as it is when one of its currencies cannot be priced.
+func (v *Valuer) FillAccount(ctx context.Context, acc *Account) error {
+	val := v.ValueBalances(ctx, SpotBalances(acc.Exchange, acc))
+	for c, err := range val.Unpriced {
+		return fmt.Errorf("%s: %w", c, err)
+	}
+	acc.Asset, acc.NetAsset = val.Total.Asset, val.Total.NetAsset
+	return nil
+}
+
+func (v *Valuer) fill(acc *Account, holdings []Holding, exchange string, unpriced map[Currency]error) {
+	for c, sub := range acc.SubAccounts {
+		if sub.Currency.Symbol != "" {
+			c = sub.Currency
+		}
+		if _, ok := unpriced[c]; ok {
+			return
+		}
+	}
+	acc.Asset, acc.NetAsset = 0, 0
+	for _, h := range holdings {
+		if h.Exchange == exchange && h.Kind == KindSpot {
+			acc.Asset += h.Asset
+			acc.NetAsset += h.NetAsset
+		}
+	}
+}
+
+// Price returns a unit of c in Quote, from the fixed prices or the last price of
+// the tickers, going through the bridges when no pair links c to Quote.
+func (v *Valuer) Price(ctx context.Context, c Currency) (float64, error) {
+	price, ok := v.convert(ctx, c, v.conf.Quote, v.conf.MaxHops, map[string]bool{c.Symbol: true})
+	if !ok {
+		return 0, fmt.Errorf("no route from %s to %s", c, v.conf.Quote)
+	}
+	return price, nil
+}
+
+func (v *Valuer) convert(ctx context.Context, from, to Currency, hops int, visited map[string]bool) (float64, bool) {
+	if from.Eq(to) {
+		return 1, true
+	}
+	if to.Eq(v.conf.Quote) {
+		if p, ok := v.conf.Prices[from]; ok && p > 0 {
+			return p, true
+		}
+	}
+	if p, ok := v.rate(ctx, from, to); ok {
+		return p, true
+	}
+	if hops <= 1 {
+		return 0, false
+	}
+	for _, b := range v.conf.Bridges {
+		if visited[b.Symbol] || b.Eq(to) {
+			continue
+		}
+		first, ok := v.rate(ctx, from, b)
+		if !ok {
+			continue
+		}
+		visited[b.Symbol] = true
+		rest, ok := v.convert(ctx, b, to, hops-1, visited)
+		delete(visited, b.Symbol)
+		if ok {
+			return first * rest, true
+		}
+	}
+	return 0, false
+}
+
+// rate converts from into to with the ticker of their pair either way round.
+func (v *Valuer) rate(ctx context.Context, from, to Currency) (float64, bool) {
+	if p := v.last(ctx, NewCurrencyPair(from, to)); p > 0 {
+		return p, true
+	}
+	if p := v.last(ctx, NewCurrencyPair(to, from)); p > 0 {
+		return 1 / p, true
+	}
+	return 0, false
+}
+
+// last returns the last price of the pair on the first ticker that has it, 0 for
+// none. The answers, the failures too, are kept for TickerMaxAge.
+func (v *Valuer) last(ctx context.Context, pair CurrencyPair) float64 {
+	key := pair.String()
+	v.mu.Lock()
+	q, ok := v.quotes[key]
+	v.mu.Unlock()
+	if ok && v.now().Sub(q.time) < v.conf.TickerMaxAge {
+		return q.price
+	}
+
+	q = quote{time: v.now()}
+	for _, api := range v.tickers {
+		var (
+			ticker *Ticker
+			err    error
+		)
+		if ctxApi, ok := api.(APIWithContext); ok {
+			ticker, err = ctxApi.GetTickerWithContext(ctx, pair)
+		} else {
+			ticker, err = api.GetTicker(pair)
+		}
+		if err != nil || ticker == nil {
+			continue
+		}
+		q.price = ticker.Last
+		if q.price <= 0 && ticker.Buy > 0 && ticker.Sell > 0 {
+			q.price = (ticker.Buy + ticker.Sell) / 2
+		}
+		if q.price > 0 {
+			break
+		}
+	}
+	v.mu.Lock()
+	v.quotes[key] = q
+	v.mu.Unlock()
+	return q.price
+}
@@ -0,0 +1,123 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/sim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMargin struct {
+	accounts map[string]*MarginAccount
+}
+
+func (m *fakeMargin) GetMarginAccount(pair CurrencyPair) (*MarginAccount, error) {
+	acc, ok := m.accounts[pair.String()]
+	if !ok {
+		return nil, errors.New("no margin account")
+	}
+	return acc, nil
+}
+
+func TestValuer_Value(t *testing.T) {
+	x, y := sim.New(nil), sim.New(nil)
+	x.ReplayTrade(BTC_USDT, Trade{Price: 100, Amount: 1})
+	x.ReplayTrade(ETH_BTC, Trade{Price: 0.05, Amount: 1})
+	y.ReplayTrade(LTC_BTC, Trade{Price: 0.004, Amount: 1})
+
+	x.Deposit(USDT, 50)
+	x.Deposit(BTC, 1)
+	_, err := x.LimitBuy("0.5", "60", BTC_USDT) //30 usdt frozen
+	require.NoError(t, err)
+	x.Futures().Deposit(BTC, 0.5)
+	y.Deposit(LTC, 10)
+	y.Deposit(ETH, 2)
+	y.Deposit(NewCurrency("XYZ", ""), 5)
+
+	v, err := NewValuer(&Config{Sources: []Source{
+		{Name: "x", API: x, Futures: x.Futures()},
+		{Name: "y", API: y, Margin: &fakeMargin{map[string]*MarginAccount{
+			"ETH_USDT": {Sub: map[Currency]MarginSubAccount{
+				ETH:  {Balance: 1},
+				USDT: {Balance: 10, Loan: 4, LendingFee: 1}}},
+		}}, MarginPairs: []CurrencyPair{ETH_USDT}},
+	}})
+	require.NoError(t, err)
+
+	val, err := v.Value(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, USDT, val.Quote)
+	assert.Empty(t, val.Errors)
+	require.Contains(t, val.Unpriced, NewCurrency("XYZ", ""))
+	assert.Len(t, val.Unpriced, 1)
+
+	btc := val.ByAsset[BTC]
+	assert.InDelta(t, 1.5, btc.Amount, 1e-9, "spot and futures")
+	assert.InDelta(t, 150, btc.Asset, 1e-9)
+	assert.InDelta(t, 0.4, val.ByAsset[LTC].Price, 1e-9, "ltc through btc")
+	assert.InDelta(t, 5, val.ByAsset[ETH].Price, 1e-9, "eth through btc")
+	assert.Equal(t, AssetValue{Value: Value{Asset: 60, NetAsset: 55}, Amount: 60, Debt: 5, Price: 1}, val.ByAsset[USDT])
+
+	assert.InDelta(t, 200, val.ByExchange["x"].Asset, 1e-9)
+	assert.InDelta(t, 4+10+5+10, val.ByExchange["y"].Asset, 1e-9)
+	assert.InDelta(t, 24, val.ByExchange["y"].NetAsset, 1e-9)
+	assert.InDelta(t, 229, val.Total.Asset, 1e-9)
+	assert.InDelta(t, 224, val.Total.NetAsset, 1e-9)
+
+	require.NotEmpty(t, val.Holdings)
+	assert.Equal(t, Balance{Exchange: "x", Kind: KindFutures, Currency: BTC, Amount: 0.5}, val.Holdings[0].Balance)
+
+	assert.InDelta(t, 150, val.Accounts["x"].Asset, 1e-9)
+	assert.Equal(t, 0.0, val.Accounts["y"].Asset, "xyz cannot be priced")
+}
+
+func TestValuer_FillAccount(t *testing.T) {
+	x := sim.New(nil)
+	x.ReplayTrade(BTC_USDT, Trade{Price: 100, Amount: 1})
+
+	v, err := NewValuer(&Config{Quote: USD, Tickers: []API{x}, Prices: map[Currency]float64{USDT: 0.99}})
+	require.NoError(t, err)
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	acc := &Account{SubAccounts: map[Currency]SubAccount{
+		BTC:  {Currency: BTC, Amount: 1, ForzenAmount: 1, LoanAmount: 0.5},
+		USDT: {Currency: USDT, Amount: 100},
+	}}
+	require.NoError(t, v.FillAccount(context.Background(), acc))
+	assert.InDelta(t, 2*99+99, acc.Asset, 1e-9, "btc through usdt")
+	assert.InDelta(t, 1.5*99+99, acc.NetAsset, 1e-9)
+
+	//the price is kept until TickerMaxAge
+	x.ReplayTrade(BTC_USDT, Trade{Price: 200, Amount: 1})
+	price, err := v.Price(context.Background(), BTC)
+	require.NoError(t, err)
+	assert.InDelta(t, 99, price, 1e-9)
+	now = now.Add(11 * time.Second)
+	price, err = v.Price(context.Background(), BTC)
+	require.NoError(t, err)
+	assert.InDelta(t, 198, price, 1e-9)
+
+	acc.SubAccounts[ETH] = SubAccount{Currency: ETH, Amount: 1}
+	assert.Error(t, v.FillAccount(context.Background(), acc))
+	assert.InDelta(t, 2*99+99, acc.Asset, 1e-9, "left as it was")
+}
+
+func TestValuer_Errors(t *testing.T) {
+	_, err := NewValuer(&Config{})
+	assert.Error(t, err)
+
+	_, err = NewValuer(&Config{Sources: []Source{{API: sim.New(nil)}, {API: sim.New(nil)}}})
+	assert.Error(t, err, "same name")
+
+	v, err := NewValuer(&Config{Sources: []Source{{Name: "m", Margin: &fakeMargin{}, MarginPairs: []CurrencyPair{BTC_USDT}}},
+		Prices: map[Currency]float64{BTC: 100}})
+	require.NoError(t, err)
+	_, err = v.Value(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,339 @@
+// Package router splits an order across exchanges by the depth of their books.
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	. "github.com/soulsplit/goex"
+)
+
+const epsilon = 1e-12
+
+// Venue is an exchange the orders can be routed to.
+type Venue struct {
+	Name     string //default API.GetExchangeName()
+	API      API
+	TakerFee float64     //rate, e.g. 0.001
+	Rules    *OrderRules //optional, rounds the child orders to the instrument
+}
+
+type Config struct {
+	Pair      CurrencyPair
+	Venues    []Venue
+	DepthSize int  //levels read from every venue, default 20
+	DryRun    bool //Route only plans the allocation
+}
+
+// ParentOrder is the order to split. Price is the worst price a child can trade
+// at, 0 for none.
+type ParentOrder struct {
+	Side   TradeSide //BUY or SELL
+	Amount float64
+	Price  float64
+}
+
+// Allocation is the child order planned on a venue, an IOC limit order at the
+// worst level it takes.
+type Allocation struct {
+	Venue    string
+	Price    float64
+	Amount   float64
+	Cost     float64 //price * amount of the levels taken
+	Fee      float64 //Cost * TakerFee
+	NetPrice float64 //(Cost ± Fee) / Amount, what a unit costs or brings after the fee
+}
+
+type ChildFill struct {
+	Allocation
+	Order *Order //nil when it could not be placed
+	Err   error
+}
+
+// Result is the plan and, unless in dry run, the aggregate fill of the children.
+type Result struct {
+	Planned     []Allocation
+	VenueErrors map[string]error //of the venues left out of the plan
+	Children    []ChildFill
+	Filled      float64
+	Cost        float64 //price * amount of the fills
+	Fee         float64 //the fees the venues report, in the currency each one charges
+	AvgPrice    float64
+}
+
+type Router struct {
+	conf   Config
+	venues []Venue
+}
+
+func NewRouter(conf *Config) (*Router, error) {
+	r := &Router{conf: *conf}
+	if r.conf.DepthSize <= 0 {
+		r.conf.DepthSize = 20
+	}
+	if len(r.conf.Venues) == 0 {
+		return nil, errors.New("no venues")
+	}
+	names := make(map[string]bool)
+	for _, v := range r.conf.Venues {
+		if v.Name == "" {
+			v.Name = v.API.GetExchangeName()
+		}
+		if names[v.Name] {
+			return nil, fmt.Errorf("venue %s used twice", v.Name)
+		}
+		names[v.Name] = true
+		r.venues = append(r.venues, v)
+	}
+	return r, nil
+}
+
+// venueBook is what Route reads from a venue: its depth and the balance the order can use.
+type venueBook struct {
+	venue     *Venue
+	levels    DepthRecords //opposite side of the order, best first
+	available float64      //quote for a buy, base for a sell
+	allocated float64
+	err       error
+}
+
+// level is a price level of a venue ranked by its price after the taker fee.
+type level struct {
+	book     *venueBook
+	price    float64
+	amount   float64
+	netPrice float64
+}
+
+// Plan reads the depth and the balance of every venue and allocates the parent
+// order to the best levels after the taker fees. A venue failing to answer is left
+// out, the error is in the returned map.
+func (r *Router) Plan(ctx context.Context, order ParentOrder) ([]Allocation, map[string]error, error) {
+	if order.Side != BUY && order.Side != SELL {
+		return nil, nil, errors.New("side must be BUY or SELL")
+	}
+	if order.Amount <= 0 {
+		return nil, nil, errors.New("amount must be positive")
+	}
+
+	books := r.books(ctx, order.Side)
+	errs := make(map[string]error)
+	var levels []level
+	for _, b := range books {
+		if b.err != nil {
+			errs[b.venue.Name] = b.err
+			continue
+		}
+		for _, rec := range b.levels {
+			if order.Price > 0 && (order.Side == BUY && rec.Price > order.Price || order.Side == SELL && rec.Price < order.Price) {
+				break
+			}
+			net := rec.Price * (1 + b.venue.TakerFee)
+			if order.Side == SELL {
+				net = rec.Price * (1 - b.venue.TakerFee)
+			}
+			levels = append(levels, level{book: b, price: rec.Price, amount: rec.Amount, netPrice: net})
+		}
+	}
+	sort.SliceStable(levels, func(i, j int) bool {
+		if order.Side == BUY {
+			return levels[i].netPrice < levels[j].netPrice
+		}
+		return levels[i].netPrice > levels[j].netPrice
+	})
+
+	allocs := make(map[*venueBook]*Allocation)
+	var planned []*Allocation
+	remain := order.Amount
+	for _, l := range levels {
+		if remain <= epsilon {
+			break
+		}
+		amount := l.amount
+		if amount > remain {
+			amount = remain
+		}
+		//a buy child holds its limit price times its amount until it is filled
+		max := l.book.available - l.book.allocated
+		if order.Side == BUY {
+			max = l.book.available/l.price - l.book.allocated
+		}
+		if amount > max {
+			amount = max
+		}
+		if amount <= epsilon {
+			continue
+		}
+		l.book.allocated += amount
+
+		a, ok := allocs[l.book]
+		if !ok {
+			a = &Allocation{Venue: l.book.venue.Name}
+			allocs[l.book] = a
+			planned = append(planned, a)
+		}
+		a.Price = l.price //the levels of a venue come best first
+		a.Amount += amount
+		a.Cost += amount * l.price
+		remain -= amount
+	}
+
+	out := make([]Allocation, 0, len(planned))
+	for _, a := range planned {
+		a.Fee = a.Cost * r.venue(a.Venue).TakerFee
+		if order.Side == BUY {
+			a.NetPrice = (a.Cost + a.Fee) / a.Amount
+		} else {
+			a.NetPrice = (a.Cost - a.Fee) / a.Amount
+		}
+		out = append(out, *a)
+	}
+	return out, errs, nil
+}
+
+// Route plans the parent order then sends the children as IOC limit orders, all at
+// once. A child that fails is reported in its ChildFill, the others still go.
+func (r *Router) Route(ctx context.Context, order ParentOrder) (*Result, error) {
+	planned, errs, err := r.Plan(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Planned: planned, VenueErrors: errs}
+	if r.conf.DryRun || len(planned) == 0 {
+		return res, nil
+	}
+
+	res.Children = make([]ChildFill, len(planned))
+	var wg sync.WaitGroup
+	for i := range planned {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child := &res.Children[i]
+			child.Allocation = planned[i]
+			child.Order, child.Err = r.place(ctx, r.venue(planned[i].Venue), order.Side, planned[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for _, c := range res.Children {
+		if c.Order == nil {
+			continue
+		}
+		res.Filled += c.Order.DealAmount
+		res.Cost += c.Order.DealAmount * c.Order.AvgPrice
+		res.Fee += c.Order.Fee
+	}
+	if res.Filled > 0 {
+		res.AvgPrice = res.Cost / res.Filled
+	}
+	return res, nil
+}
+
+func (r *Router) venue(name string) *Venue {
+	for i := range r.venues {
+		if r.venues[i].Name == name {
+			return &r.venues[i]
+		}
+	}
+	return nil
+}
+
+func (r *Router) books(ctx context.Context, side TradeSide) []*venueBook {
+	books := make([]*venueBook, len(r.venues))
+	var wg sync.WaitGroup
+	for i := range r.venues {
+		books[i] = &venueBook{venue: &r.venues[i]}
+		wg.Add(1)
+		go func(b *venueBook) {
+			defer wg.Done()
+			b.err = r.readBook(ctx, b, side)
+		}(books[i])
+	}
+	wg.Wait()
+	return books
+}
+
+func (r *Router) readBook(ctx context.Context, b *venueBook, side TradeSide) error {
+	var (
+		dep *Depth
+		acc *Account
+		err error
+	)
+	api := b.venue.API
+	ctxApi, withContext := api.(APIWithContext)
+	if withContext {
+		dep, err = ctxApi.GetDepthWithContext(ctx, r.conf.DepthSize, r.conf.Pair)
+	} else {
+		dep, err = api.GetDepth(r.conf.DepthSize, r.conf.Pair)
+	}
+	if err != nil {
+		return err
+	}
+	if withContext {
+		acc, err = ctxApi.GetAccountWithContext(ctx)
+	} else {
+		acc, err = api.GetAccount()
+	}
+	if err != nil {
+		return err
+	}
+
+	if side == BUY {
+		b.levels = append(DepthRecords(nil), dep.AskList...)
+		sort.Sort(b.levels)
+		b.available = acc.SubAccounts[r.conf.Pair.CurrencyB].Amount
+	} else {
+		b.levels = append(DepthRecords(nil), dep.BidList...)
+		sort.Sort(sort.Reverse(b.levels))
+		b.available = acc.SubAccounts[r.conf.Pair.CurrencyA].Amount
+	}
+	return nil
+}
+
+func (r *Router) place(ctx context.Context, v *Venue, side TradeSide, a Allocation) (*Order, error) {
+	price, amount := FloatToString(a.Price, 8), DecimalFromFloat(a.Amount).Truncate(8).Normalize().String()
+	if v.Rules != nil {
+		var err error
+		if price, amount, err = v.Rules.Normalize(r.conf.Pair, "", side, price, amount); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		ord *Order
+		err error
+	)
+	ctxApi, withContext := v.API.(APIWithContext)
+	switch {
+	case side == BUY && withContext:
+		ord, err = ctxApi.LimitBuyWithContext(ctx, amount, price, r.conf.Pair, Ioc)
+	case side == BUY:
+		ord, err = v.API.LimitBuy(amount, price, r.conf.Pair, Ioc)
+	case withContext:
+		ord, err = ctxApi.LimitSellWithContext(ctx, amount, price, r.conf.Pair, Ioc)
+	default:
+		ord, err = v.API.LimitSell(amount, price, r.conf.Pair, Ioc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	//most exchanges only answer the id of a new order
+	if ord.Status == ORDER_UNFINISH && ord.DealAmount == 0 && ord.OrderID2 != "" {
+		var filled *Order
+		if withContext {
+			filled, err = ctxApi.GetOneOrderWithContext(ctx, ord.OrderID2, r.conf.Pair)
+		} else {
+			filled, err = v.API.GetOneOrder(ord.OrderID2, r.conf.Pair)
+		}
+		if err != nil {
+			return ord, err
+		}
+		ord = filled
+	}
+	return ord, nil
+}
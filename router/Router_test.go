@@ -0,0 +1,95 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/sim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVenue returns the trading account of a sim exchange whose market maker sells
+// 1 BTC at each of the asks.
+func newVenue(usdt float64, asks ...float64) *sim.Exchange {
+	ex := sim.New(&sim.Config{TakerFee: 0.001})
+	ex.Deposit(USDT, usdt)
+	mm := ex.Account("mm")
+	mm.Deposit(BTC, 100)
+	for _, p := range asks {
+		mm.LimitSell("1", FloatToString(p, 8), BTC_USDT)
+	}
+	return ex
+}
+
+type failingAPI struct {
+	*sim.Exchange
+}
+
+func (f failingAPI) GetAccount() (*Account, error) {
+	return nil, errors.New("timeout")
+}
+
+func TestRouter_Route(t *testing.T) {
+	a := newVenue(1e6, 100, 101, 103)
+	b := newVenue(150, 100.5, 100.6)
+	c := newVenue(1e6, 99)
+	r, err := NewRouter(&Config{Pair: BTC_USDT, Venues: []Venue{
+		{Name: "a", API: a, TakerFee: 0.001},
+		{Name: "b", API: b},
+		{Name: "c", API: failingAPI{c}},
+	}})
+	require.NoError(t, err)
+
+	order := ParentOrder{Side: BUY, Amount: 3, Price: 102}
+	planned, errs, err := r.Plan(context.Background(), order)
+	require.NoError(t, err)
+	assert.Error(t, errs["c"])
+	require.Len(t, planned, 2)
+
+	//100 is 100.1 after the fee of a, it beats 100.5 at b, where 150 USDT are
+	//enough for 1 + 0.49 at 100.6 as the child order holds 100.6 for all of it
+	atB := 150 / 100.6
+	assert.Equal(t, "a", planned[0].Venue)
+	assert.Equal(t, 101.0, planned[0].Price, "103 is over the limit")
+	assert.InDelta(t, 3-atB, planned[0].Amount, 1e-9)
+	assert.InDelta(t, (100+101*(2-atB))*1.001/planned[0].Amount, planned[0].NetPrice, 1e-9)
+	assert.Equal(t, "b", planned[1].Venue)
+	assert.Equal(t, 100.6, planned[1].Price)
+	assert.InDelta(t, atB, planned[1].Amount, 1e-9)
+	assert.InDelta(t, (100.5+100.6*(atB-1))/atB, planned[1].NetPrice, 1e-9)
+
+	res, err := r.Route(context.Background(), order)
+	require.NoError(t, err)
+	require.Len(t, res.Children, 2)
+	for _, c := range res.Children {
+		require.NoError(t, c.Err)
+		assert.Equal(t, ORDER_FINISH, c.Order.Status)
+	}
+	assert.InDelta(t, 3, res.Filled, 1e-6)
+	assert.InDelta(t, (100+101*(2-atB)+100.5+100.6*(atB-1))/3, res.AvgPrice, 1e-6)
+
+	acc, _ := b.GetAccount()
+	assert.InDelta(t, 150-100.5-100.6*(atB-1), acc.SubAccounts[USDT].Amount, 1e-6)
+}
+
+func TestRouter_DryRun(t *testing.T) {
+	a := newVenue(1e6, 100)
+	r, err := NewRouter(&Config{Pair: BTC_USDT, DryRun: true, Venues: []Venue{{API: a}}})
+	require.NoError(t, err)
+
+	res, err := r.Route(context.Background(), ParentOrder{Side: BUY, Amount: 2})
+	require.NoError(t, err)
+	require.Len(t, res.Planned, 1)
+	assert.Equal(t, SIM, res.Planned[0].Venue)
+	assert.Equal(t, 1.0, res.Planned[0].Amount, "only 1 on the book")
+	assert.Empty(t, res.Children)
+
+	orders, _ := a.GetOrderHistorys(BTC_USDT)
+	assert.Empty(t, orders)
+
+	_, err = r.Route(context.Background(), ParentOrder{Side: SELL})
+	assert.Error(t, err)
+}
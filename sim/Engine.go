@@ -0,0 +1,412 @@
+package sim
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+const epsilon = 1e-12
+
+type orderKind int
+
+const (
+	spotOrder orderKind = iota
+	futuresOrder
+)
+
+// bookOrder is an order as seen by the matching engine. Spot and futures orders
+// share the engine and are told apart by kind when fills are settled.
+type bookOrder struct {
+	id       int64
+	account  string
+	book     string
+	kind     orderKind
+	side     TradeSide // BUY or SELL
+	price    float64   // 0 for market orders
+	amount   float64
+	filled   float64
+	cost     float64 // sum(price*amount) of fills
+	fee      float64
+	reserved float64 // balance (spot) or margin/position (futures) held for the unfilled part
+	seq      int64
+	openType int // futures only
+	feature  int // ORDER_FEATURE_*
+	status   TradeStatus
+	ts       int64
+	pair     CurrencyPair
+	contract string
+}
+
+func (o *bookOrder) remain() float64 {
+	return o.amount - o.filled
+}
+
+func (o *bookOrder) avgPrice() float64 {
+	if o.filled <= epsilon {
+		return 0
+	}
+	return o.cost / o.filled
+}
+
+type priceLevel struct {
+	price  float64
+	orders []*bookOrder
+}
+
+func (l *priceLevel) amount() float64 {
+	var a float64
+	for _, o := range l.orders {
+		a += o.remain()
+	}
+	return a
+}
+
+// orderBook keeps resting orders with price-time priority. bids are sorted
+// descending, asks ascending, so index 0 is always the best level.
+type orderBook struct {
+	bids []*priceLevel
+	asks []*priceLevel
+}
+
+func (b *orderBook) levels(side TradeSide) *[]*priceLevel {
+	if side == BUY {
+		return &b.bids
+	}
+	return &b.asks
+}
+
+func (b *orderBook) opposite(side TradeSide) *[]*priceLevel {
+	if side == BUY {
+		return &b.asks
+	}
+	return &b.bids
+}
+
+func (b *orderBook) add(o *bookOrder) {
+	levels := b.levels(o.side)
+	i := sort.Search(len(*levels), func(i int) bool {
+		if o.side == BUY {
+			return (*levels)[i].price <= o.price
+		}
+		return (*levels)[i].price >= o.price
+	})
+	if i < len(*levels) && (*levels)[i].price == o.price {
+		(*levels)[i].orders = append((*levels)[i].orders, o)
+		return
+	}
+	*levels = append(*levels, nil)
+	copy((*levels)[i+1:], (*levels)[i:])
+	(*levels)[i] = &priceLevel{price: o.price, orders: []*bookOrder{o}}
+}
+
+func (b *orderBook) remove(o *bookOrder) bool {
+	levels := b.levels(o.side)
+	for i, l := range *levels {
+		if l.price != o.price {
+			continue
+		}
+		for j, ro := range l.orders {
+			if ro.id == o.id {
+				l.orders = append(l.orders[:j], l.orders[j+1:]...)
+				if len(l.orders) == 0 {
+					*levels = append((*levels)[:i], (*levels)[i+1:]...)
+				}
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// crosses reports whether a taker at the given limit price can trade against level.
+// A zero limit price means a market order.
+func crosses(side TradeSide, limit, level float64) bool {
+	if limit == 0 {
+		return true
+	}
+	if side == BUY {
+		return level <= limit
+	}
+	return level >= limit
+}
+
+// available returns how much of the opposite side can be taken at the limit price.
+func (b *orderBook) available(side TradeSide, limit float64) float64 {
+	var a float64
+	for _, l := range *b.opposite(side) {
+		if !crosses(side, limit, l.price) {
+			break
+		}
+		a += l.amount()
+	}
+	return a
+}
+
+// cost returns the quote amount needed to take amount from the opposite side,
+// and how much of it can actually be filled.
+func (b *orderBook) cost(side TradeSide, limit, amount float64) (cost, filled float64) {
+	for _, l := range *b.opposite(side) {
+		if !crosses(side, limit, l.price) || amount-filled <= epsilon {
+			break
+		}
+		a := l.amount()
+		if a > amount-filled {
+			a = amount - filled
+		}
+		cost += a * l.price
+		filled += a
+	}
+	return
+}
+
+type fill struct {
+	maker  *bookOrder
+	taker  *bookOrder
+	price  float64
+	amount float64
+}
+
+// match takes liquidity for taker from the opposite side, best price first and
+// oldest order first within a level. Fully filled makers are removed from the book.
+func (b *orderBook) match(taker *bookOrder) []fill {
+	var fills []fill
+	levels := b.opposite(taker.side)
+	for len(*levels) > 0 && taker.remain() > epsilon {
+		l := (*levels)[0]
+		if !crosses(taker.side, taker.price, l.price) {
+			break
+		}
+		for len(l.orders) > 0 && taker.remain() > epsilon {
+			maker := l.orders[0]
+			amount := maker.remain()
+			if amount > taker.remain() {
+				amount = taker.remain()
+			}
+			maker.filled += amount
+			maker.cost += amount * l.price
+			taker.filled += amount
+			taker.cost += amount * l.price
+			fills = append(fills, fill{maker: maker, taker: taker, price: l.price, amount: amount})
+			if maker.remain() <= epsilon {
+				maker.status = ORDER_FINISH
+				l.orders = l.orders[1:]
+			} else {
+				maker.status = ORDER_PART_FINISH
+			}
+		}
+		if len(l.orders) == 0 {
+			*levels = (*levels)[1:]
+		}
+	}
+	return fills
+}
+
+func (b *orderBook) depth(size int) (bids, asks DepthRecords) {
+	for i, l := range b.bids {
+		if size > 0 && i >= size {
+			break
+		}
+		bids = append(bids, DepthRecord{Price: l.price, Amount: l.amount()})
+	}
+	for i, l := range b.asks {
+		if size > 0 && i >= size {
+			break
+		}
+		asks = append(asks, DepthRecord{Price: l.price, Amount: l.amount()})
+	}
+	//goex convention: AskList in descending order
+	sort.Sort(sort.Reverse(asks))
+	return
+}
+
+// engine is shared by every Exchange/Futures view created from the same New call.
+type engine struct {
+	sync.Mutex
+	conf    Config
+	books   map[string]*orderBook
+	orders  map[int64]*bookOrder
+	trades  map[string][]Trade // public trades by book
+	markets map[string]*market // replayed market data by book, see Replay.go
+	nextId  int64
+	nextSeq int64
+
+	balances       map[string]map[Currency]*SubAccount
+	futuresWallets map[string]map[Currency]*futuresWallet
+	positions      map[string]map[string]*FuturePosition
+	accountTrades  map[string][]Trade
+}
+
+func newEngine(conf Config) *engine {
+	return &engine{
+		conf:           conf,
+		books:          make(map[string]*orderBook),
+		orders:         make(map[int64]*bookOrder),
+		trades:         make(map[string][]Trade),
+		markets:        make(map[string]*market),
+		balances:       make(map[string]map[Currency]*SubAccount),
+		futuresWallets: make(map[string]map[Currency]*futuresWallet),
+		positions:      make(map[string]map[string]*FuturePosition),
+		accountTrades:  make(map[string][]Trade),
+	}
+}
+
+func (e *engine) now() time.Time {
+	if e.conf.Clock != nil {
+		return e.conf.Clock()
+	}
+	return time.Now()
+}
+
+func (e *engine) book(key string) *orderBook {
+	b, ok := e.books[key]
+	if !ok {
+		b = new(orderBook)
+		e.books[key] = b
+	}
+	return b
+}
+
+func (e *engine) newOrder(account, book string, side TradeSide, price, amount float64) *bookOrder {
+	e.nextId++
+	e.nextSeq++
+	o := &bookOrder{
+		id:      e.nextId,
+		account: account,
+		book:    book,
+		side:    side,
+		price:   price,
+		amount:  amount,
+		seq:     e.nextSeq,
+		status:  ORDER_UNFINISH,
+		ts:      e.now().UnixNano() / int64(time.Millisecond),
+	}
+	e.orders[o.id] = o
+	return o
+}
+
+// recordTrade appends a public trade print for the book. The taker side is reported.
+func (e *engine) recordTrade(f fill) {
+	t := Trade{
+		Tid:    int64(len(e.trades[f.taker.book]) + 1),
+		Type:   f.taker.side,
+		Amount: f.amount,
+		Price:  f.price,
+		Date:   e.now().UnixNano() / int64(time.Millisecond),
+		Pair:   f.taker.pair,
+	}
+	e.trades[f.taker.book] = append(e.trades[f.taker.book], t)
+	if m, ok := e.markets[f.taker.book]; ok {
+		m.last = f.price
+	}
+}
+
+func (e *engine) lastPrice(book string) float64 {
+	if m, ok := e.markets[book]; ok && m.last > 0 {
+		return m.last
+	}
+	trades := e.trades[book]
+	if len(trades) == 0 {
+		return 0
+	}
+	return trades[len(trades)-1].Price
+}
+
+// markPrice is the last trade price, falling back to the book mid price.
+func (e *engine) markPrice(book string) float64 {
+	if p := e.lastPrice(book); p > 0 {
+		return p
+	}
+	b := e.book(book)
+	if len(b.bids) > 0 && len(b.asks) > 0 {
+		return (b.bids[0].price + b.asks[0].price) / 2
+	}
+	if len(b.bids) > 0 {
+		return b.bids[0].price
+	}
+	if len(b.asks) > 0 {
+		return b.asks[0].price
+	}
+	return 0
+}
+
+func (e *engine) feeRate(maker bool) float64 {
+	if maker {
+		return e.conf.MakerFee
+	}
+	return e.conf.TakerFee
+}
+
+// submit runs a new order through the book: it is matched first, then against the
+// replayed market if there is one, and either rests in the book or has its
+// remainder cancelled depending on its type.
+// The caller must hold the engine lock and must have reserved funds already.
+func (e *engine) submit(o *bookOrder) {
+	b := e.book(o.book)
+	for _, f := range b.match(o) {
+		e.recordTrade(f)
+		e.settle(f.maker, f, true)
+		e.settle(f.taker, f, false)
+	}
+	e.takeReplayed(o)
+
+	if o.remain() <= epsilon {
+		o.status = ORDER_FINISH
+		e.release(o)
+		return
+	}
+
+	if o.price == 0 || o.feature == ORDER_FEATURE_IOC || o.feature == ORDER_FEATURE_FOK {
+		o.status = ORDER_CANCEL
+		e.release(o)
+		return
+	}
+
+	if o.filled > epsilon {
+		o.status = ORDER_PART_FINISH
+	}
+	b.add(o)
+	e.joinQueue(o)
+}
+
+func (e *engine) cancel(o *bookOrder) bool {
+	if o.status != ORDER_UNFINISH && o.status != ORDER_PART_FINISH {
+		return false
+	}
+	e.book(o.book).remove(o)
+	o.status = ORDER_CANCEL
+	e.release(o)
+	return true
+}
+
+func (e *engine) settle(o *bookOrder, f fill, maker bool) {
+	switch o.kind {
+	case spotOrder:
+		e.settleSpot(o, f, maker)
+	case futuresOrder:
+		e.settleFutures(o, f, maker)
+	}
+	if o.status == ORDER_FINISH && o != f.taker {
+		e.release(o)
+	}
+}
+
+// release gives back whatever is still reserved for an order that will not trade anymore.
+func (e *engine) release(o *bookOrder) {
+	if m, ok := e.markets[o.book]; ok {
+		delete(m.queue, o.id)
+	}
+	if o.reserved <= epsilon {
+		o.reserved = 0
+		return
+	}
+	switch o.kind {
+	case spotOrder:
+		e.releaseSpot(o)
+	case futuresOrder:
+		e.releaseFutures(o)
+	}
+	o.reserved = 0
+}
@@ -0,0 +1,505 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+const futuresBookSep = "@"
+
+// Futures simulates linear (quote margined) futures. Margin is held in the
+// quote currency of the pair and each contract is worth Config.ContractValue
+// units of the base currency. Every contractType has its own order book.
+type Futures struct {
+	e       *engine
+	account string
+}
+
+type futuresWallet struct {
+	balance  float64 //deposits + realized pnl - fees
+	frozen   float64 //margin held by open orders
+	used     float64 //margin held by positions
+	realized float64
+}
+
+func (w *futuresWallet) available() float64 {
+	return w.balance - w.frozen - w.used
+}
+
+func futuresBook(pair CurrencyPair, contractType string) string {
+	return pair.String() + futuresBookSep + contractType
+}
+
+// Account returns the futures API of another account on the same engine.
+func (f *Futures) Account(account string) *Futures {
+	return &Futures{e: f.e, account: account}
+}
+
+func (f *Futures) Deposit(currency Currency, amount float64) {
+	f.e.Lock()
+	defer f.e.Unlock()
+	f.e.wallet(f.account, currency).balance += amount
+}
+
+func (f *Futures) GetExchangeName() string {
+	return SIM
+}
+
+// there is no separate index, the spot mark price is used and the futures one as a fallback
+func (f *Futures) GetFutureEstimatedPrice(currencyPair CurrencyPair) (float64, error) {
+	return f.GetFutureIndex(currencyPair)
+}
+
+func (f *Futures) GetFutureIndex(currencyPair CurrencyPair) (float64, error) {
+	f.e.Lock()
+	defer f.e.Unlock()
+
+	if p := f.e.markPrice(currencyPair.String()); p > 0 {
+		return p, nil
+	}
+	for k := range f.e.books {
+		if strings.HasPrefix(k, currencyPair.String()+futuresBookSep) {
+			if p := f.e.markPrice(k); p > 0 {
+				return p, nil
+			}
+		}
+	}
+	return 0, errors.New("no price for " + currencyPair.String())
+}
+
+func (f *Futures) GetFutureTicker(currencyPair CurrencyPair, contractType string) (*Ticker, error) {
+	f.e.Lock()
+	defer f.e.Unlock()
+
+	ticker := f.e.ticker(futuresBook(currencyPair, contractType))
+	ticker.Pair = currencyPair
+	return ticker, nil
+}
+
+func (f *Futures) GetFutureDepth(currencyPair CurrencyPair, contractType string, size int) (*Depth, error) {
+	f.e.Lock()
+	defer f.e.Unlock()
+
+	dep := &Depth{Pair: currencyPair, ContractType: contractType, UTime: f.e.now()}
+	dep.BidList, dep.AskList = f.e.depth(futuresBook(currencyPair, contractType), size)
+	return dep, nil
+}
+
+func (f *Futures) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAccount, error) {
+	f.e.Lock()
+	defer f.e.Unlock()
+
+	acc := &FutureAccount{FutureSubAccounts: make(map[Currency]FutureSubAccount)}
+	for c, w := range f.e.futuresWallets[f.account] {
+		if len(currencyPair) > 0 && !c.Eq(currencyPair[0].CurrencyB) {
+			continue
+		}
+		unreal := f.e.unrealizedProfit(f.account, c)
+		sub := FutureSubAccount{
+			Currency:      c,
+			AccountRights: w.balance + unreal,
+			KeepDeposit:   w.used + w.frozen,
+			ProfitReal:    w.realized,
+			ProfitUnreal:  unreal,
+		}
+		if sub.AccountRights > 0 {
+			sub.RiskRate = sub.KeepDeposit / sub.AccountRights
+		}
+		acc.FutureSubAccounts[c] = sub
+	}
+	return acc, nil
+}
+
+func (f *Futures) PlaceFutureOrder(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64) (string, error) {
+	var (
+		ord *FutureOrder
+		err error
+	)
+	if matchPrice == 1 {
+		ord, err = f.MarketFuturesOrder(currencyPair, contractType, amount, openType)
+	} else {
+		ord, err = f.LimitFuturesOrder(currencyPair, contractType, price, amount, openType)
+	}
+	if err != nil {
+		return "", err
+	}
+	return ord.OrderID2, nil
+}
+
+func (f *Futures) LimitFuturesOrder(currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	p := ToFloat64(price)
+	if p <= 0 {
+		return nil, EX_ERR_PLACE_ORDER_FAIL.OriginErr("price must be positive")
+	}
+	return f.placeOrder(currencyPair, contractType, p, ToFloat64(amount), openType, opt...)
+}
+
+func (f *Futures) MarketFuturesOrder(currencyPair CurrencyPair, contractType, amount string, openType int) (*FutureOrder, error) {
+	return f.placeOrder(currencyPair, contractType, 0, ToFloat64(amount), openType)
+}
+
+func (f *Futures) placeOrder(pair CurrencyPair, contractType string, price, amount float64, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	if amount <= 0 {
+		return nil, EX_ERR_PLACE_ORDER_FAIL.OriginErr("amount must be positive")
+	}
+
+	var side TradeSide
+	switch openType {
+	case OPEN_BUY, CLOSE_SELL:
+		side = BUY
+	case OPEN_SELL, CLOSE_BUY:
+		side = SELL
+	default:
+		return nil, EX_ERR_PLACE_ORDER_FAIL.OriginErr(fmt.Sprintf("unknown open type %d", openType))
+	}
+
+	e := f.e
+	e.Lock()
+	defer e.Unlock()
+
+	key := futuresBook(pair, contractType)
+	feature := limitOrderFeature(opt...)
+
+	if feature == ORDER_FEATURE_POST_ONLY && e.available(key, side, price) > epsilon {
+		return nil, EX_ERR_PLACE_ORDER_FAIL.OriginErr("post only order would take liquidity")
+	}
+
+	var reserve float64
+	switch openType {
+	case OPEN_BUY, OPEN_SELL:
+		refPrice := price
+		if refPrice == 0 {
+			cost, filled := e.cost(key, side, 0, amount)
+			if filled > epsilon {
+				refPrice = cost / filled
+			}
+		}
+		reserve = refPrice * amount * e.conf.ContractValue / e.conf.Lever
+		w := e.wallet(f.account, pair.CurrencyB)
+		if w.available()+epsilon < reserve {
+			return nil, EX_ERR_INSUFFICIENT_BALANCE.OriginErr(fmt.Sprintf("need margin %f %s, available %f", reserve, pair.CurrencyB, w.available()))
+		}
+		w.frozen += reserve
+	case CLOSE_BUY:
+		pos := e.position(f.account, pair, contractType)
+		if pos.BuyAvailable+epsilon < amount {
+			return nil, EX_ERR_INSUFFICIENT_BALANCE.OriginErr(fmt.Sprintf("long position available %f", pos.BuyAvailable))
+		}
+		pos.BuyAvailable -= amount
+		reserve = amount
+	case CLOSE_SELL:
+		pos := e.position(f.account, pair, contractType)
+		if pos.SellAvailable+epsilon < amount {
+			return nil, EX_ERR_INSUFFICIENT_BALANCE.OriginErr(fmt.Sprintf("short position available %f", pos.SellAvailable))
+		}
+		pos.SellAvailable -= amount
+		reserve = amount
+	}
+
+	o := e.newOrder(f.account, key, side, price, amount)
+	o.kind = futuresOrder
+	o.pair = pair
+	o.contract = contractType
+	o.openType = openType
+	o.feature = feature
+	o.reserved = reserve
+
+	if feature == ORDER_FEATURE_FOK && e.available(key, side, price)+epsilon < amount {
+		o.status = ORDER_CANCEL
+		e.release(o)
+		return f.e.toFutureOrder(o), nil
+	}
+
+	e.submit(o)
+	return f.e.toFutureOrder(o), nil
+}
+
+func (f *Futures) FutureCancelOrder(currencyPair CurrencyPair, contractType, orderId string) (bool, error) {
+	f.e.Lock()
+	defer f.e.Unlock()
+
+	o, err := f.e.findOrder(f.account, orderId, futuresBook(currencyPair, contractType))
+	if err != nil {
+		return false, err
+	}
+	if !f.e.cancel(o) {
+		return false, EX_ERR_CANCEL_ORDER_FAIL.OriginErr("order " + orderId + " is " + o.status.String())
+	}
+	return true, nil
+}
+
+func (f *Futures) GetFuturePosition(currencyPair CurrencyPair, contractType string) ([]FuturePosition, error) {
+	f.e.Lock()
+	defer f.e.Unlock()
+
+	pos, ok := f.e.positions[f.account][futuresBook(currencyPair, contractType)]
+	if !ok {
+		return nil, nil
+	}
+	return []FuturePosition{f.e.markPosition(pos)}, nil
+}
+
+func (f *Futures) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	f.e.Lock()
+	defer f.e.Unlock()
+
+	var orders []FutureOrder
+	for _, id := range orderIds {
+		o, err := f.e.findOrder(f.account, id, futuresBook(currencyPair, contractType))
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *f.e.toFutureOrder(o))
+	}
+	return orders, nil
+}
+
+func (f *Futures) GetFutureOrder(orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error) {
+	f.e.Lock()
+	defer f.e.Unlock()
+
+	o, err := f.e.findOrder(f.account, orderId, futuresBook(currencyPair, contractType))
+	if err != nil {
+		return nil, err
+	}
+	return f.e.toFutureOrder(o), nil
+}
+
+func (f *Futures) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	f.e.Lock()
+	defer f.e.Unlock()
+
+	var orders []FutureOrder
+	for _, o := range f.e.accountOrders(f.account, futuresBook(currencyPair, contractType)) {
+		if o.status == ORDER_UNFINISH || o.status == ORDER_PART_FINISH {
+			orders = append(orders, *f.e.toFutureOrder(o))
+		}
+	}
+	return orders, nil
+}
+
+// newest first
+func (f *Futures) GetFutureOrderHistory(pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error) {
+	f.e.Lock()
+	defer f.e.Unlock()
+
+	var orders []FutureOrder
+	for _, o := range f.e.accountOrders(f.account, futuresBook(pair, contractType)) {
+		if o.status != ORDER_UNFINISH && o.status != ORDER_PART_FINISH {
+			orders = append(orders, *f.e.toFutureOrder(o))
+		}
+	}
+	for i, j := 0, len(orders)-1; i < j; i, j = i+1, j-1 {
+		orders[i], orders[j] = orders[j], orders[i]
+	}
+	return orders, nil
+}
+
+func (f *Futures) GetFee() (float64, error) {
+	return f.e.conf.TakerFee, nil
+}
+
+func (f *Futures) GetContractValue(currencyPair CurrencyPair) (float64, error) {
+	return f.e.conf.ContractValue, nil
+}
+
+// friday 16:00:00
+func (f *Futures) GetDeliveryTime() (int, int, int, int) {
+	return 4, 16, 0, 0
+}
+
+func (f *Futures) GetKlineRecords(contractType string, currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]FutureKline, error) {
+	f.e.Lock()
+	defer f.e.Unlock()
+
+	klines, err := f.e.klines(futuresBook(currency, contractType), currency, period, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var futureKlines []FutureKline
+	for i := range klines {
+		k := klines[i]
+		futureKlines = append(futureKlines, FutureKline{Kline: &k, Vol2: k.Vol * f.e.conf.ContractValue})
+	}
+	return futureKlines, nil
+}
+
+func (f *Futures) GetTrades(contractType string, currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	f.e.Lock()
+	defer f.e.Unlock()
+
+	var trades []Trade
+	for _, t := range f.e.trades[futuresBook(currencyPair, contractType)] {
+		if t.Tid > since {
+			trades = append(trades, t)
+		}
+	}
+	return trades, nil
+}
+
+func (e *engine) wallet(account string, currency Currency) *futuresWallet {
+	wallets, ok := e.futuresWallets[account]
+	if !ok {
+		wallets = make(map[Currency]*futuresWallet)
+		e.futuresWallets[account] = wallets
+	}
+	w, ok := wallets[currency]
+	if !ok {
+		w = new(futuresWallet)
+		wallets[currency] = w
+	}
+	return w
+}
+
+func (e *engine) position(account string, pair CurrencyPair, contractType string) *FuturePosition {
+	positions, ok := e.positions[account]
+	if !ok {
+		positions = make(map[string]*FuturePosition)
+		e.positions[account] = positions
+	}
+	key := futuresBook(pair, contractType)
+	pos, ok := positions[key]
+	if !ok {
+		pos = &FuturePosition{
+			Symbol:       pair,
+			ContractType: contractType,
+			LeverRate:    e.conf.Lever,
+			CreateDate:   e.now().UnixNano() / int64(time.Millisecond),
+		}
+		positions[key] = pos
+	}
+	return pos
+}
+
+// markPosition returns a copy of pos with the unrealized profit at the current mark price.
+func (e *engine) markPosition(pos *FuturePosition) FuturePosition {
+	p := *pos
+	mark := e.markPrice(futuresBook(pos.Symbol, pos.ContractType))
+	cv := e.conf.ContractValue
+	if p.BuyAmount > epsilon {
+		p.BuyProfit = (mark - p.BuyPriceAvg) * p.BuyAmount * cv
+		p.LongPnlRatio = p.BuyProfit / (p.BuyPriceAvg * p.BuyAmount * cv / e.conf.Lever)
+	}
+	if p.SellAmount > epsilon {
+		p.SellProfit = (p.SellPriceAvg - mark) * p.SellAmount * cv
+		p.ShortPnlRatio = p.SellProfit / (p.SellPriceAvg * p.SellAmount * cv / e.conf.Lever)
+	}
+	return p
+}
+
+func (e *engine) unrealizedProfit(account string, currency Currency) float64 {
+	var unreal float64
+	for _, pos := range e.positions[account] {
+		if !pos.Symbol.CurrencyB.Eq(currency) {
+			continue
+		}
+		p := e.markPosition(pos)
+		unreal += p.BuyProfit + p.SellProfit
+	}
+	return unreal
+}
+
+func (e *engine) settleFutures(o *bookOrder, f fill, maker bool) {
+	cv := e.conf.ContractValue
+	w := e.wallet(o.account, o.pair.CurrencyB)
+	pos := e.position(o.account, o.pair, o.contract)
+
+	fee := f.price * f.amount * cv * e.feeRate(maker)
+	w.balance -= fee
+	o.fee += fee
+
+	switch o.openType {
+	case OPEN_BUY, OPEN_SELL:
+		margin := f.price * f.amount * cv / e.conf.Lever
+		release := margin
+		if release > o.reserved {
+			release = o.reserved
+		}
+		w.frozen -= release
+		o.reserved -= release
+		w.used += margin
+		if o.openType == OPEN_BUY {
+			pos.BuyPriceAvg = (pos.BuyPriceAvg*pos.BuyAmount + f.price*f.amount) / (pos.BuyAmount + f.amount)
+			pos.BuyPriceCost = pos.BuyPriceAvg
+			pos.BuyAmount += f.amount
+			pos.BuyAvailable += f.amount
+		} else {
+			pos.SellPriceAvg = (pos.SellPriceAvg*pos.SellAmount + f.price*f.amount) / (pos.SellAmount + f.amount)
+			pos.SellPriceCost = pos.SellPriceAvg
+			pos.SellAmount += f.amount
+			pos.SellAvailable += f.amount
+		}
+	case CLOSE_BUY:
+		pnl := (f.price - pos.BuyPriceAvg) * f.amount * cv
+		w.balance += pnl
+		w.realized += pnl
+		w.used -= pos.BuyPriceAvg * f.amount * cv / e.conf.Lever
+		pos.BuyProfitReal += pnl
+		pos.BuyAmount -= f.amount
+		o.reserved -= f.amount
+		if pos.BuyAmount <= epsilon {
+			pos.BuyAmount, pos.BuyPriceAvg, pos.BuyPriceCost = 0, 0, 0
+		}
+	case CLOSE_SELL:
+		pnl := (pos.SellPriceAvg - f.price) * f.amount * cv
+		w.balance += pnl
+		w.realized += pnl
+		w.used -= pos.SellPriceAvg * f.amount * cv / e.conf.Lever
+		pos.SellProfitReal += pnl
+		pos.SellAmount -= f.amount
+		o.reserved -= f.amount
+		if pos.SellAmount <= epsilon {
+			pos.SellAmount, pos.SellPriceAvg, pos.SellPriceCost = 0, 0, 0
+		}
+	}
+
+	e.accountTrades[o.account] = append(e.accountTrades[o.account], Trade{
+		Tid:      int64(len(e.trades[o.book])),
+		Type:     o.side,
+		Amount:   f.amount,
+		Price:    f.price,
+		Date:     e.now().UnixNano() / int64(time.Millisecond),
+		Pair:     o.pair,
+		OrderID2: strconv.FormatInt(o.id, 10),
+		Cost:     f.price * f.amount * cv,
+		Fee:      fee,
+		Misc:     o.contract,
+	})
+}
+
+func (e *engine) releaseFutures(o *bookOrder) {
+	switch o.openType {
+	case OPEN_BUY, OPEN_SELL:
+		e.wallet(o.account, o.pair.CurrencyB).frozen -= o.reserved
+	case CLOSE_BUY:
+		e.position(o.account, o.pair, o.contract).BuyAvailable += o.reserved
+	case CLOSE_SELL:
+		e.position(o.account, o.pair, o.contract).SellAvailable += o.reserved
+	}
+}
+
+func (e *engine) toFutureOrder(o *bookOrder) *FutureOrder {
+	return &FutureOrder{
+		ClientOid:    strconv.FormatInt(o.id, 10),
+		OrderID2:     strconv.FormatInt(o.id, 10),
+		OrderID:      o.id,
+		Price:        o.price,
+		Amount:       o.amount,
+		AvgPrice:     o.avgPrice(),
+		DealAmount:   o.filled,
+		OrderTime:    o.ts,
+		Status:       o.status,
+		Currency:     o.pair,
+		OrderType:    o.feature,
+		OType:        o.openType,
+		LeverRate:    e.conf.Lever,
+		Fee:          o.fee,
+		ContractName: o.contract,
+	}
+}
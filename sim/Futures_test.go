@@ -0,0 +1,71 @@
+package sim
+
+import (
+	"testing"
+
+	. "github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFutures_OpenClose(t *testing.T) {
+	fut := New(&Config{Lever: 10}).Futures()
+	fut.Deposit(USDT, 1000)
+	mm := fut.Account("mm")
+	mm.Deposit(USDT, 100000)
+
+	_, err := mm.LimitFuturesOrder(BTC_USDT, QUARTER_CONTRACT, "100", "2", OPEN_SELL)
+	require.NoError(t, err)
+
+	ord, err := fut.MarketFuturesOrder(BTC_USDT, QUARTER_CONTRACT, "2", OPEN_BUY)
+	require.NoError(t, err)
+	assert.Equal(t, ORDER_FINISH, ord.Status)
+
+	pos, _ := fut.GetFuturePosition(BTC_USDT, QUARTER_CONTRACT)
+	require.Len(t, pos, 1)
+	assert.Equal(t, 2.0, pos[0].BuyAmount)
+	assert.Equal(t, 100.0, pos[0].BuyPriceAvg)
+
+	acc, _ := fut.GetFutureUserinfo(BTC_USDT)
+	assert.InDelta(t, 20, acc.FutureSubAccounts[USDT].KeepDeposit, 1e-9)
+
+	//bids move up, close the long for a profit
+	_, err = mm.LimitFuturesOrder(BTC_USDT, QUARTER_CONTRACT, "110", "2", CLOSE_SELL)
+	require.NoError(t, err)
+
+	pos, _ = fut.GetFuturePosition(BTC_USDT, QUARTER_CONTRACT)
+	assert.InDelta(t, 0, pos[0].BuyProfit, 1e-9)
+
+	ord, err = fut.LimitFuturesOrder(BTC_USDT, QUARTER_CONTRACT, "110", "2", CLOSE_BUY)
+	require.NoError(t, err)
+	assert.Equal(t, ORDER_FINISH, ord.Status)
+
+	pos, _ = fut.GetFuturePosition(BTC_USDT, QUARTER_CONTRACT)
+	assert.Equal(t, 0.0, pos[0].BuyAmount)
+	assert.InDelta(t, 20, pos[0].BuyProfitReal, 1e-9)
+
+	acc, _ = fut.GetFutureUserinfo(BTC_USDT)
+	assert.InDelta(t, 1020, acc.FutureSubAccounts[USDT].AccountRights, 1e-9)
+	assert.InDelta(t, 0, acc.FutureSubAccounts[USDT].KeepDeposit, 1e-9)
+}
+
+func TestFutures_Reserve(t *testing.T) {
+	fut := New(nil).Futures()
+	fut.Deposit(USDT, 100)
+
+	_, err := fut.LimitFuturesOrder(BTC_USDT, SWAP_CONTRACT, "100", "20", OPEN_BUY)
+	assert.Error(t, err)
+	_, err = fut.LimitFuturesOrder(BTC_USDT, SWAP_CONTRACT, "100", "1", CLOSE_BUY)
+	assert.Error(t, err)
+
+	ord, err := fut.LimitFuturesOrder(BTC_USDT, SWAP_CONTRACT, "100", "5", OPEN_BUY)
+	require.NoError(t, err)
+	acc, _ := fut.GetFutureUserinfo()
+	assert.InDelta(t, 50, acc.FutureSubAccounts[USDT].KeepDeposit, 1e-9)
+
+	ok, err := fut.FutureCancelOrder(BTC_USDT, SWAP_CONTRACT, ord.OrderID2)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	acc, _ = fut.GetFutureUserinfo()
+	assert.InDelta(t, 0, acc.FutureSubAccounts[USDT].KeepDeposit, 1e-9)
+}
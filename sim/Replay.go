@@ -0,0 +1,433 @@
+package sim
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	. "github.com/soulsplit/goex"
+	"github.com/soulsplit/goex/kline"
+)
+
+// FillModel is how resting orders are filled by replayed market data. Replayed
+// liquidity has no account behind it: orders take it at the replayed prices, with
+// Config.Slippage, and the books of the engine accounts are matched first.
+type FillModel int
+
+const (
+	FillTouch  FillModel = iota //a resting order fills entirely once the market trades at its price
+	FillVolume                  //at most Config.VolumeRatio of the volume traded at or through its price
+	FillQueue                   //the amount resting ahead of the order in the replayed depth has to trade first
+)
+
+// market is the replayed state of a book.
+type market struct {
+	last   float64
+	traded bool         //a trade or kline was replayed, else last is the depth mid price
+	bids   DepthRecords //best first
+	asks   DepthRecords //best first
+	period KlinePeriod
+	klines []Kline           //replayed klines in seconds, oldest first
+	queue  map[int64]float64 //amount ahead of resting orders, FillQueue only
+}
+
+// ReplayKline applies a closed historical kline of the pair, the klines of a pair
+// are replayed in one period.
+func (ex *Exchange) ReplayKline(pair CurrencyPair, period KlinePeriod, k Kline) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+	ex.e.replayKline(pair.String(), period, k)
+}
+
+// ReplayTrade applies a historical trade of the pair, Date is in milliseconds.
+func (ex *Exchange) ReplayTrade(pair CurrencyPair, t Trade) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+	t.Pair = pair
+	ex.e.replayTrade(pair.String(), t)
+}
+
+// ReplayDepth applies a historical depth snapshot of the pair.
+func (ex *Exchange) ReplayDepth(pair CurrencyPair, dep *Depth) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+	ex.e.replayDepth(pair.String(), dep)
+}
+
+// AccountTrades returns the spot and futures fills of the account, oldest first.
+func (ex *Exchange) AccountTrades() []Trade {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+	return append([]Trade(nil), ex.e.accountTrades[ex.account]...)
+}
+
+func (f *Futures) ReplayKline(pair CurrencyPair, contractType string, period KlinePeriod, k Kline) {
+	f.e.Lock()
+	defer f.e.Unlock()
+	f.e.replayKline(futuresBook(pair, contractType), period, k)
+}
+
+func (f *Futures) ReplayTrade(pair CurrencyPair, contractType string, t Trade) {
+	f.e.Lock()
+	defer f.e.Unlock()
+	t.Pair = pair
+	f.e.replayTrade(futuresBook(pair, contractType), t)
+}
+
+func (f *Futures) ReplayDepth(pair CurrencyPair, contractType string, dep *Depth) {
+	f.e.Lock()
+	defer f.e.Unlock()
+	f.e.replayDepth(futuresBook(pair, contractType), dep)
+}
+
+func (e *engine) replayed(book string) *market {
+	m, ok := e.markets[book]
+	if !ok {
+		m = &market{queue: make(map[int64]float64)}
+		e.markets[book] = m
+	}
+	return m
+}
+
+func (e *engine) replayKline(book string, period KlinePeriod, k Kline) {
+	m := e.replayed(book)
+	k.Timestamp = kline.TimestampToSeconds(k.Timestamp)
+	if m.period != period {
+		m.period, m.klines = period, nil
+	}
+	if n := len(m.klines); n > 0 && m.klines[n-1].Timestamp == k.Timestamp {
+		m.klines[n-1] = k
+	} else if n == 0 || m.klines[n-1].Timestamp < k.Timestamp {
+		m.klines = append(m.klines, k)
+	}
+
+	e.printed(book, k.Low, k.High, k.Vol)
+	m.last, m.traded = k.Close, true
+}
+
+func (e *engine) replayTrade(book string, t Trade) {
+	m := e.replayed(book)
+	if t.Date == 0 {
+		t.Date = e.now().UnixNano() / int64(time.Millisecond)
+	}
+	t.Tid = int64(len(e.trades[book]) + 1)
+	e.trades[book] = append(e.trades[book], t)
+
+	e.printed(book, t.Price, t.Price, t.Amount)
+	m.last, m.traded = t.Price, true
+}
+
+func (e *engine) replayDepth(book string, dep *Depth) {
+	m := e.replayed(book)
+	m.bids = append(DepthRecords(nil), dep.BidList...)
+	m.asks = append(DepthRecords(nil), dep.AskList...)
+	sort.Sort(sort.Reverse(m.bids))
+	sort.Sort(m.asks)
+	if !m.traded && len(m.bids) > 0 && len(m.asks) > 0 {
+		m.last = (m.bids[0].Price + m.asks[0].Price) / 2
+	}
+
+	//the resting orders the new depth crossed were traded through
+	b := e.book(book)
+	for _, side := range []TradeSide{BUY, SELL} {
+		for _, l := range append([]*priceLevel(nil), *b.levels(side)...) {
+			fills := m.take(side, l.price, l.amount(), 0, true)
+			if len(fills) == 0 {
+				break
+			}
+			var available float64
+			for _, f := range fills {
+				available += f.amount
+			}
+			for _, o := range append([]*bookOrder(nil), l.orders...) {
+				amount := o.remain()
+				if e.conf.FillModel != FillTouch {
+					amount = math.Min(amount, available)
+					available -= amount
+				}
+				if amount > epsilon {
+					e.fillResting(b, o, amount)
+				}
+			}
+		}
+	}
+
+	for id, q := range m.queue {
+		o := e.orders[id]
+		own := m.bids
+		if o.side == SELL {
+			own = m.asks
+		}
+		at, found := 0.0, false
+		for _, r := range own {
+			if r.Price == o.price {
+				at, found = r.Amount, true
+				break
+			}
+		}
+		switch {
+		case found && at < q:
+			m.queue[id] = at
+		case !found && (len(own) == 0 || better(o.side, o.price, own[len(own)-1].Price)):
+			m.queue[id] = 0 //the level is gone or the order is the best one
+		}
+	}
+}
+
+// printed fills the resting orders that were reached by market trades between low
+// and high with volume in total. The volume of a kline that only touches the price
+// of an order counts as traded at it.
+func (e *engine) printed(book string, low, high, volume float64) {
+	b := e.book(book)
+	m := e.replayed(book)
+	ratio := e.conf.VolumeRatio
+	for _, side := range []TradeSide{BUY, SELL} {
+		budget := volume * ratio
+		for _, l := range append([]*priceLevel(nil), *b.levels(side)...) {
+			touched, through := low <= l.price, low < l.price
+			if side == SELL {
+				touched, through = high >= l.price, high > l.price
+			}
+			if !touched {
+				break //the next levels are further away
+			}
+			for _, o := range append([]*bookOrder(nil), l.orders...) {
+				amount := o.remain()
+				switch e.conf.FillModel {
+				case FillVolume:
+					amount = math.Min(amount, budget)
+					budget -= amount
+				case FillQueue:
+					if through { //the whole queue traded
+						break
+					}
+					if q := m.queue[o.id]; budget <= q {
+						m.queue[o.id] = q - budget
+						budget = 0
+					} else {
+						budget -= q
+						m.queue[o.id] = 0
+					}
+					amount = math.Min(amount, budget)
+					budget -= amount
+				}
+				if amount > epsilon {
+					e.fillResting(b, o, amount)
+				}
+			}
+		}
+	}
+}
+
+// fillResting fills a resting order at its price as a maker against replayed liquidity.
+func (e *engine) fillResting(b *orderBook, o *bookOrder, amount float64) {
+	e.fillReplayed(o, o.price, amount, true)
+	if o.status == ORDER_FINISH {
+		b.remove(o)
+	}
+}
+
+// fillReplayed fills o against the replayed market, there is no counterparty account.
+func (e *engine) fillReplayed(o *bookOrder, price, amount float64, maker bool) {
+	o.filled += amount
+	o.cost += amount * price
+	if o.remain() <= epsilon {
+		o.status = ORDER_FINISH
+	} else {
+		o.status = ORDER_PART_FINISH
+	}
+	f := fill{price: price, amount: amount}
+	if maker {
+		f.maker = o
+	} else {
+		f.taker = o
+	}
+	e.settle(o, f, maker)
+}
+
+// takeReplayed fills what is left of a new order against the replayed market.
+func (e *engine) takeReplayed(o *bookOrder) {
+	m, ok := e.markets[o.book]
+	if !ok || o.remain() <= epsilon {
+		return
+	}
+	for _, f := range m.take(o.side, o.price, o.remain(), e.conf.Slippage, true) {
+		e.fillReplayed(o, f.price, f.amount, false)
+	}
+}
+
+// joinQueue puts a new resting order behind the amount of its price level in the replayed depth.
+func (e *engine) joinQueue(o *bookOrder) {
+	m, ok := e.markets[o.book]
+	if !ok || e.conf.FillModel != FillQueue {
+		return
+	}
+	own := m.bids
+	if o.side == SELL {
+		own = m.asks
+	}
+	m.queue[o.id] = 0
+	for _, r := range own {
+		if r.Price == o.price {
+			m.queue[o.id] = r.Amount
+		}
+	}
+}
+
+// take returns the fills of a taker against the replayed depth, or against the
+// last price when there is no depth, with the slippage applied within the limit.
+// consume removes the taken amounts from the depth.
+func (m *market) take(side TradeSide, limit, amount, slippage float64, consume bool) []fill {
+	var fills []fill
+	levels := m.asks
+	if side == SELL {
+		levels = m.bids
+	}
+	if len(levels) == 0 {
+		if m.last > 0 && amount > epsilon && crosses(side, limit, m.last) {
+			fills = append(fills, fill{price: slip(side, m.last, limit, slippage), amount: amount})
+		}
+		return fills
+	}
+	for i := range levels {
+		if amount <= epsilon || !crosses(side, limit, levels[i].Price) {
+			break
+		}
+		a := math.Min(levels[i].Amount, amount)
+		if a <= epsilon {
+			continue
+		}
+		fills = append(fills, fill{price: slip(side, levels[i].Price, limit, slippage), amount: a})
+		amount -= a
+		if consume {
+			levels[i].Amount -= a
+		}
+	}
+	return fills
+}
+
+// better reports whether price is a better price than than for an order of side.
+func better(side TradeSide, price, than float64) bool {
+	if side == BUY {
+		return price > than
+	}
+	return price < than
+}
+
+func slip(side TradeSide, price, limit, slippage float64) float64 {
+	if side == BUY {
+		price *= 1 + slippage
+		if limit > 0 && price > limit {
+			price = limit
+		}
+		return price
+	}
+	price *= 1 - slippage
+	if limit > 0 && price < limit {
+		price = limit
+	}
+	return price
+}
+
+// available is how much a taker at the limit price can fill on the book and the replayed market.
+func (e *engine) available(book string, side TradeSide, limit float64) float64 {
+	a := e.book(book).available(side, limit)
+	if m, ok := e.markets[book]; ok {
+		levels := m.asks
+		if side == SELL {
+			levels = m.bids
+		}
+		if len(levels) == 0 && m.last > 0 && crosses(side, limit, m.last) {
+			return math.Inf(1)
+		}
+		for _, f := range m.take(side, limit, math.Inf(1), 0, false) {
+			a += f.amount
+		}
+	}
+	return a
+}
+
+// cost is orderBook.cost continued on the replayed market.
+func (e *engine) cost(book string, side TradeSide, limit, amount float64) (cost, filled float64) {
+	cost, filled = e.book(book).cost(side, limit, amount)
+	if m, ok := e.markets[book]; ok {
+		for _, f := range m.take(side, limit, amount-filled, e.conf.Slippage, false) {
+			cost += f.price * f.amount
+			filled += f.amount
+		}
+	}
+	return
+}
+
+// depth merges the book of the engine with the replayed depth, the asks are in
+// descending order as in goex.
+func (e *engine) depth(book string, size int) (bids, asks DepthRecords) {
+	bids, asks = e.book(book).depth(0)
+	m, ok := e.markets[book]
+	if !ok {
+		return truncateDepth(bids, asks, size)
+	}
+	bids = mergeDepth(bids, m.bids)
+	asks = mergeDepth(asks, m.asks)
+	sort.Sort(sort.Reverse(bids))
+	sort.Sort(sort.Reverse(asks))
+	return truncateDepth(bids, asks, size)
+}
+
+func mergeDepth(records, replayed DepthRecords) DepthRecords {
+	amounts := make(map[float64]float64, len(records)+len(replayed))
+	for _, r := range append(append(DepthRecords(nil), records...), replayed...) {
+		amounts[r.Price] += r.Amount
+	}
+	merged := make(DepthRecords, 0, len(amounts))
+	for p, a := range amounts {
+		if a > epsilon {
+			merged = append(merged, DepthRecord{Price: p, Amount: a})
+		}
+	}
+	return merged
+}
+
+// truncateDepth keeps the best size levels of descending bids and asks.
+func truncateDepth(bids, asks DepthRecords, size int) (DepthRecords, DepthRecords) {
+	if size > 0 && len(bids) > size {
+		bids = bids[:size]
+	}
+	if size > 0 && len(asks) > size {
+		asks = asks[len(asks)-size:]
+	}
+	return bids, asks
+}
+
+// replayedKlines serves the klines of a replayed book, resampled to period.
+func (e *engine) replayedKlines(m *market, pair CurrencyPair, period KlinePeriod, size int) ([]Kline, error) {
+	klines := m.klines
+	if period != m.period {
+		var err error
+		if klines, err = kline.Resample(klines, m.period, period); err != nil {
+			return nil, err
+		}
+	}
+	if size > 0 && len(klines) > size {
+		klines = klines[len(klines)-size:]
+	}
+	out := make([]Kline, len(klines))
+	for i, k := range klines {
+		k.Pair = pair
+		out[i] = k
+	}
+	return out, nil
+}
+
+func (model FillModel) String() string {
+	switch model {
+	case FillTouch:
+		return "touch"
+	case FillVolume:
+		return "volume"
+	case FillQueue:
+		return "queue"
+	}
+	return strconv.Itoa(int(model))
+}
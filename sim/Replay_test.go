@@ -0,0 +1,139 @@
+package sim
+
+import (
+	"testing"
+
+	. "github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReplayExchange(conf Config) *Exchange {
+	ex := New(&conf)
+	ex.Deposit(USDT, 10000)
+	ex.Deposit(BTC, 10)
+	return ex
+}
+
+func TestExchange_ReplayTaker(t *testing.T) {
+	ex := newReplayExchange(Config{TakerFee: 0.001, Slippage: 0.01})
+	ex.ReplayKline(BTC_USDT, KLINE_PERIOD_1MIN, Kline{Timestamp: 60000, Open: 99, High: 101, Low: 98, Close: 100, Vol: 5})
+
+	ord, err := ex.MarketBuy("1", "", BTC_USDT)
+	require.NoError(t, err)
+	assert.Equal(t, ORDER_FINISH, ord.Status)
+	assert.InDelta(t, 101, ord.AvgPrice, 1e-9, "last price with slippage")
+
+	//slippage is capped by the limit price, a buy under the last price rests
+	ord, err = ex.LimitBuy("1", "100.5", BTC_USDT)
+	require.NoError(t, err)
+	assert.InDelta(t, 100.5, ord.AvgPrice, 1e-9)
+	ord, err = ex.LimitBuy("1", "99", BTC_USDT)
+	require.NoError(t, err)
+	assert.Equal(t, ORDER_UNFINISH, ord.Status)
+
+	acc, _ := ex.GetAccount()
+	assert.InDelta(t, 10000-101-100.5-99, acc.SubAccounts[USDT].Amount, 1e-9)
+	assert.InDelta(t, 12-0.002, acc.SubAccounts[BTC].Amount, 1e-9)
+
+	//the depth is taken level by level and consumed until the next snapshot
+	ex.ReplayDepth(BTC_USDT, &Depth{
+		AskList: DepthRecords{{Price: 102, Amount: 1}, {Price: 101, Amount: 0.5}},
+		BidList: DepthRecords{{Price: 100, Amount: 1}}})
+	ord, err = ex.MarketSell("1.5", "", BTC_USDT)
+	require.NoError(t, err)
+	assert.Equal(t, ORDER_FINISH, ord.Status, "the resting buy at 99 fills first as the book is matched before the market")
+
+	_, err = ex.LimitSell("1", "100", BTC_USDT, PostOnly)
+	assert.Error(t, err)
+	ord, err = ex.LimitBuy("1", "101.5", BTC_USDT, Fok)
+	require.NoError(t, err)
+	assert.Equal(t, ORDER_CANCEL, ord.Status)
+	ord, err = ex.LimitBuy("1", "101.5", BTC_USDT, Ioc)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5, ord.DealAmount, 1e-9)
+
+	dep, _ := ex.GetDepth(5, BTC_USDT)
+	require.Len(t, dep.AskList, 1)
+	assert.Equal(t, 102.0, dep.AskList[0].Price)
+}
+
+func TestExchange_ReplayFillModels(t *testing.T) {
+	restingBuy := func(conf Config) (*Exchange, string) {
+		ex := newReplayExchange(conf)
+		ex.ReplayDepth(BTC_USDT, &Depth{
+			AskList: DepthRecords{{Price: 101, Amount: 1}},
+			BidList: DepthRecords{{Price: 100, Amount: 3}}})
+		ord, err := ex.LimitBuy("2", "100", BTC_USDT)
+		require.NoError(t, err)
+		require.Equal(t, ORDER_UNFINISH, ord.Status)
+		return ex, ord.OrderID2
+	}
+	dealt := func(ex *Exchange, id string) float64 {
+		ord, err := ex.GetOneOrder(id, BTC_USDT)
+		require.NoError(t, err)
+		return ord.DealAmount
+	}
+
+	ex, id := restingBuy(Config{FillModel: FillTouch})
+	ex.ReplayTrade(BTC_USDT, Trade{Price: 100.5, Amount: 1})
+	assert.Equal(t, 0.0, dealt(ex, id))
+	ex.ReplayTrade(BTC_USDT, Trade{Price: 100, Amount: 0.1})
+	assert.Equal(t, 2.0, dealt(ex, id))
+
+	ex, id = restingBuy(Config{FillModel: FillVolume, VolumeRatio: 0.5})
+	ex.ReplayTrade(BTC_USDT, Trade{Price: 100, Amount: 2})
+	assert.Equal(t, 1.0, dealt(ex, id))
+	ex.ReplayKline(BTC_USDT, KLINE_PERIOD_1MIN, Kline{Timestamp: 60, Open: 101, High: 101, Low: 99, Close: 100, Vol: 10})
+	assert.Equal(t, 2.0, dealt(ex, id))
+
+	ex, id = restingBuy(Config{FillModel: FillQueue})
+	ex.ReplayTrade(BTC_USDT, Trade{Price: 100, Amount: 2})
+	assert.Equal(t, 0.0, dealt(ex, id), "3 ahead in the queue")
+	ex.ReplayDepth(BTC_USDT, &Depth{
+		AskList: DepthRecords{{Price: 101, Amount: 1}},
+		BidList: DepthRecords{{Price: 100, Amount: 0.5}}})
+	ex.ReplayTrade(BTC_USDT, Trade{Price: 100, Amount: 1})
+	assert.Equal(t, 0.5, dealt(ex, id), "cancels shrank the queue to 0.5")
+	ex.ReplayTrade(BTC_USDT, Trade{Price: 99.5, Amount: 0.1})
+	assert.Equal(t, 2.0, dealt(ex, id), "traded through")
+
+	//a depth crossing the order fills it for what it shows
+	ex, id = restingBuy(Config{FillModel: FillVolume})
+	ex.ReplayDepth(BTC_USDT, &Depth{
+		AskList: DepthRecords{{Price: 100, Amount: 0.5}, {Price: 99.5, Amount: 1}},
+		BidList: DepthRecords{{Price: 99, Amount: 3}}})
+	assert.Equal(t, 1.5, dealt(ex, id))
+
+	trades := ex.AccountTrades()
+	require.Len(t, trades, 1)
+	assert.Equal(t, 100.0, trades[0].Price, "resting orders fill at their price")
+}
+
+func TestExchange_ReplayKlines(t *testing.T) {
+	ex := newReplayExchange(Config{})
+	for i := int64(0); i < 4; i++ {
+		ex.ReplayKline(BTC_USDT, KLINE_PERIOD_1MIN, Kline{Timestamp: i * 60, Open: float64(i), High: float64(i) + 1, Low: float64(i), Close: float64(i), Vol: 1})
+	}
+
+	klines, err := ex.GetKlineRecords(BTC_USDT, KLINE_PERIOD_1MIN, 2)
+	require.NoError(t, err)
+	require.Len(t, klines, 2)
+	assert.Equal(t, int64(120), klines[0].Timestamp)
+	assert.Equal(t, BTC_USDT, klines[0].Pair)
+
+	klines, err = ex.GetKlineRecords(BTC_USDT, KLINE_PERIOD_3MIN, 0)
+	require.NoError(t, err)
+	require.Len(t, klines, 2)
+	assert.Equal(t, 3.0, klines[0].Vol)
+
+	fut := ex.Futures()
+	fut.Deposit(USDT, 1000)
+	fut.ReplayKline(BTC_USDT, QUARTER_CONTRACT, KLINE_PERIOD_1MIN, Kline{Timestamp: 0, Open: 100, High: 100, Low: 100, Close: 100, Vol: 1})
+	ord, err := fut.MarketFuturesOrder(BTC_USDT, QUARTER_CONTRACT, "1", OPEN_BUY)
+	require.NoError(t, err)
+	assert.Equal(t, ORDER_FINISH, ord.Status)
+	fut.ReplayKline(BTC_USDT, QUARTER_CONTRACT, KLINE_PERIOD_1MIN, Kline{Timestamp: 60, Open: 100, High: 110, Low: 100, Close: 110, Vol: 1})
+	acc, _ := fut.GetFutureUserinfo(BTC_USDT)
+	assert.InDelta(t, 10, acc.FutureSubAccounts[USDT].ProfitUnreal, 1e-9)
+}
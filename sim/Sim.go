@@ -0,0 +1,517 @@
+// Package sim is an in-process simulated exchange. It runs a price-time-priority
+// matching engine behind goex.API and goex.FutureRestAPI so strategies can be
+// exercised offline. Liquidity comes from other accounts on the same engine,
+// see Exchange.Account.
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/soulsplit/goex"
+)
+
+const DefaultAccount = "default"
+
+type Config struct {
+	MakerFee      float64 //maker fee rate, e.g. 0.001
+	TakerFee      float64 //taker fee rate
+	Lever         float64 //futures leverage, default 10
+	ContractValue float64 //futures contract size in base currency, default 1
+	Clock         func() time.Time
+
+	//replayed market data, see Replay.go
+	Slippage    float64   //price deviation of the orders taking replayed liquidity, e.g. 0.0005
+	FillModel   FillModel //how resting orders are filled by replayed data, default FillTouch
+	VolumeRatio float64   //share of the replayed volume FillVolume and FillQueue can fill, default 1
+}
+
+type Exchange struct {
+	e       *engine
+	account string
+}
+
+func New(conf *Config) *Exchange {
+	c := Config{}
+	if conf != nil {
+		c = *conf
+	}
+	if c.Lever <= 0 {
+		c.Lever = 10
+	}
+	if c.ContractValue <= 0 {
+		c.ContractValue = 1
+	}
+	if c.VolumeRatio <= 0 {
+		c.VolumeRatio = 1
+	}
+	return &Exchange{e: newEngine(c), account: DefaultAccount}
+}
+
+// Account returns a view of the same engine trading as another account, which
+// is how counterparties and liquidity are put into the book.
+func (ex *Exchange) Account(account string) *Exchange {
+	return &Exchange{e: ex.e, account: account}
+}
+
+// Futures returns the futures API of the same account.
+func (ex *Exchange) Futures() *Futures {
+	return &Futures{e: ex.e, account: ex.account}
+}
+
+func (ex *Exchange) Deposit(currency Currency, amount float64) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+	ex.e.balance(ex.account, currency).Amount += amount
+}
+
+func (ex *Exchange) GetExchangeName() string {
+	return SIM
+}
+
+func (ex *Exchange) LimitBuy(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	p := ToFloat64(price)
+	if p <= 0 {
+		return nil, EX_ERR_PLACE_ORDER_FAIL.OriginErr("price must be positive")
+	}
+	return ex.placeOrder(BUY, p, ToFloat64(amount), currency, opt...)
+}
+
+func (ex *Exchange) LimitSell(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	p := ToFloat64(price)
+	if p <= 0 {
+		return nil, EX_ERR_PLACE_ORDER_FAIL.OriginErr("price must be positive")
+	}
+	return ex.placeOrder(SELL, p, ToFloat64(amount), currency, opt...)
+}
+
+// amount is the base currency quantity, price is ignored
+func (ex *Exchange) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
+	return ex.placeOrder(BUY, 0, ToFloat64(amount), currency)
+}
+
+// amount is the base currency quantity, price is ignored
+func (ex *Exchange) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
+	return ex.placeOrder(SELL, 0, ToFloat64(amount), currency)
+}
+
+func (ex *Exchange) placeOrder(side TradeSide, price, amount float64, pair CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	if amount <= 0 {
+		return nil, EX_ERR_PLACE_ORDER_FAIL.OriginErr("amount must be positive")
+	}
+
+	e := ex.e
+	e.Lock()
+	defer e.Unlock()
+
+	key := pair.String()
+	feature := limitOrderFeature(opt...)
+
+	if feature == ORDER_FEATURE_POST_ONLY && e.available(key, side, price) > epsilon {
+		return nil, EX_ERR_PLACE_ORDER_FAIL.OriginErr("post only order would take liquidity")
+	}
+
+	base, quote := e.balance(ex.account, pair.CurrencyA), e.balance(ex.account, pair.CurrencyB)
+	var reserve float64
+	if side == BUY {
+		if price == 0 {
+			reserve, _ = e.cost(key, BUY, 0, amount)
+		} else {
+			reserve = price * amount
+		}
+		if quote.Amount+epsilon < reserve {
+			return nil, EX_ERR_INSUFFICIENT_BALANCE.OriginErr(fmt.Sprintf("need %f %s, available %f", reserve, pair.CurrencyB, quote.Amount))
+		}
+		quote.Amount -= reserve
+		quote.ForzenAmount += reserve
+	} else {
+		reserve = amount
+		if base.Amount+epsilon < reserve {
+			return nil, EX_ERR_INSUFFICIENT_BALANCE.OriginErr(fmt.Sprintf("need %f %s, available %f", reserve, pair.CurrencyA, base.Amount))
+		}
+		base.Amount -= reserve
+		base.ForzenAmount += reserve
+	}
+
+	o := e.newOrder(ex.account, key, side, price, amount)
+	o.kind = spotOrder
+	o.pair = pair
+	o.feature = feature
+	o.reserved = reserve
+
+	if feature == ORDER_FEATURE_FOK && e.available(key, side, price)+epsilon < amount {
+		o.status = ORDER_CANCEL
+		e.release(o)
+		return o.toOrder(), nil
+	}
+
+	e.submit(o)
+	return o.toOrder(), nil
+}
+
+func (ex *Exchange) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
+	e := ex.e
+	e.Lock()
+	defer e.Unlock()
+
+	o, err := ex.e.findOrder(ex.account, orderId, currency.String())
+	if err != nil {
+		return false, err
+	}
+	if !e.cancel(o) {
+		return false, EX_ERR_CANCEL_ORDER_FAIL.OriginErr("order " + orderId + " is " + o.status.String())
+	}
+	return true, nil
+}
+
+func (ex *Exchange) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+
+	o, err := ex.e.findOrder(ex.account, orderId, currency.String())
+	if err != nil {
+		return nil, err
+	}
+	return o.toOrder(), nil
+}
+
+func (ex *Exchange) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+
+	var orders []Order
+	for _, o := range ex.e.accountOrders(ex.account, currency.String()) {
+		if o.status == ORDER_UNFINISH || o.status == ORDER_PART_FINISH {
+			orders = append(orders, *o.toOrder())
+		}
+	}
+	return orders, nil
+}
+
+// newest first
+func (ex *Exchange) GetOrderHistorys(currency CurrencyPair, opt ...OptionalParameter) ([]Order, error) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+
+	var orders []Order
+	for _, o := range ex.e.accountOrders(ex.account, currency.String()) {
+		if o.status != ORDER_UNFINISH && o.status != ORDER_PART_FINISH {
+			orders = append(orders, *o.toOrder())
+		}
+	}
+	for i, j := 0, len(orders)-1; i < j; i, j = i+1, j-1 {
+		orders[i], orders[j] = orders[j], orders[i]
+	}
+	return orders, nil
+}
+
+func (ex *Exchange) GetTradeHistory(currency CurrencyPair, opt ...OptionalParameter) ([]Trade, error) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+
+	var trades []Trade
+	for _, t := range ex.e.accountTrades[ex.account] {
+		if t.Pair.Eq(currency) {
+			trades = append(trades, t)
+		}
+	}
+	return trades, nil
+}
+
+func (ex *Exchange) GetAccount() (*Account, error) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+
+	acc := &Account{
+		Exchange:    SIM,
+		SubAccounts: make(map[Currency]SubAccount)}
+	for c, sub := range ex.e.balances[ex.account] {
+		acc.SubAccounts[c] = *sub
+	}
+	return acc, nil
+}
+
+func (ex *Exchange) GetTicker(currency CurrencyPair) (*Ticker, error) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+
+	ticker := ex.e.ticker(currency.String())
+	ticker.Pair = currency
+	return ticker, nil
+}
+
+func (ex *Exchange) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+
+	dep := &Depth{Pair: currency, UTime: ex.e.now()}
+	dep.BidList, dep.AskList = ex.e.depth(currency.String(), size)
+	return dep, nil
+}
+
+// klines are built from the trades that happened on the engine, or from the
+// replayed klines, oldest first
+func (ex *Exchange) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+
+	return ex.e.klines(currency.String(), currency, period, size)
+}
+
+// since is the trade id, only trades after it are returned
+func (ex *Exchange) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+
+	var trades []Trade
+	for _, t := range ex.e.trades[currencyPair.String()] {
+		if t.Tid > since {
+			trades = append(trades, t)
+		}
+	}
+	return trades, nil
+}
+
+func (ex *Exchange) GetAssets(currencyPair CurrencyPair) (*Assets, error) {
+	ex.e.Lock()
+	defer ex.e.Unlock()
+
+	assets := new(Assets)
+	for k := range ex.e.books {
+		if strings.Contains(k, futuresBookSep) {
+			continue
+		}
+		assets.Assets = append(assets.Assets, NewCurrencyPair2(k))
+	}
+	return assets, nil
+}
+
+func limitOrderFeature(opt ...LimitOrderOptionalParameter) int {
+	if len(opt) == 0 {
+		return ORDER_FEATURE_ORDINARY
+	}
+	switch opt[0] {
+	case PostOnly:
+		return ORDER_FEATURE_POST_ONLY
+	case Ioc:
+		return ORDER_FEATURE_IOC
+	case Fok:
+		return ORDER_FEATURE_FOK
+	}
+	return ORDER_FEATURE_ORDINARY
+}
+
+func (e *engine) balance(account string, currency Currency) *SubAccount {
+	accounts, ok := e.balances[account]
+	if !ok {
+		accounts = make(map[Currency]*SubAccount)
+		e.balances[account] = accounts
+	}
+	sub, ok := accounts[currency]
+	if !ok {
+		sub = &SubAccount{Currency: currency}
+		accounts[currency] = sub
+	}
+	return sub
+}
+
+func (e *engine) findOrder(account, orderId, book string) (*bookOrder, error) {
+	id, err := strconv.ParseInt(orderId, 10, 64)
+	if err != nil {
+		return nil, EX_ERR_NOT_FIND_ORDER.OriginErr("invalid order id " + orderId)
+	}
+	o, ok := e.orders[id]
+	if !ok || o.account != account || o.book != book {
+		return nil, EX_ERR_NOT_FIND_ORDER.OriginErr("not found order " + orderId)
+	}
+	return o, nil
+}
+
+// orders of the account on the book, oldest first
+func (e *engine) accountOrders(account, book string) []*bookOrder {
+	var orders []*bookOrder
+	for _, o := range e.orders {
+		if o.account == account && o.book == book {
+			orders = append(orders, o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].id < orders[j].id
+	})
+	return orders
+}
+
+func (e *engine) settleSpot(o *bookOrder, f fill, maker bool) {
+	base, quote := e.balance(o.account, o.pair.CurrencyA), e.balance(o.account, o.pair.CurrencyB)
+	rate := e.feeRate(maker)
+	value := f.price * f.amount
+
+	var fee float64
+	var feeCurrency Currency
+	if o.side == BUY {
+		quote.ForzenAmount -= value
+		o.reserved -= value
+		fee = f.amount * rate
+		feeCurrency = o.pair.CurrencyA
+		base.Amount += f.amount - fee
+	} else {
+		base.ForzenAmount -= f.amount
+		o.reserved -= f.amount
+		fee = value * rate
+		feeCurrency = o.pair.CurrencyB
+		quote.Amount += value - fee
+	}
+	o.fee += fee
+
+	e.accountTrades[o.account] = append(e.accountTrades[o.account], Trade{
+		Tid:      int64(len(e.trades[o.book])),
+		Type:     o.side,
+		Amount:   f.amount,
+		Price:    f.price,
+		Date:     e.now().UnixNano() / int64(time.Millisecond),
+		Pair:     o.pair,
+		OrderID2: strconv.FormatInt(o.id, 10),
+		Cost:     value,
+		Fee:      fee,
+		Misc:     feeCurrency.Symbol,
+	})
+}
+
+func (e *engine) releaseSpot(o *bookOrder) {
+	c := o.pair.CurrencyA
+	if o.side == BUY {
+		c = o.pair.CurrencyB
+	}
+	sub := e.balance(o.account, c)
+	sub.ForzenAmount -= o.reserved
+	sub.Amount += o.reserved
+}
+
+func (e *engine) ticker(book string) *Ticker {
+	ticker := &Ticker{
+		Last: e.lastPrice(book),
+		Date: uint64(e.now().UnixNano() / int64(time.Millisecond))}
+
+	bids, asks := e.depth(book, 1)
+	if len(bids) > 0 {
+		ticker.Buy = bids[0].Price
+	}
+	if len(asks) > 0 {
+		ticker.Sell = asks[0].Price
+	}
+
+	since := e.now().Add(-24*time.Hour).UnixNano() / int64(time.Millisecond)
+	if m, ok := e.markets[book]; ok && len(m.klines) > 0 {
+		for _, k := range m.klines {
+			if k.Timestamp*1000 < since {
+				continue
+			}
+			if ticker.High == 0 || k.High > ticker.High {
+				ticker.High = k.High
+			}
+			if ticker.Low == 0 || k.Low < ticker.Low {
+				ticker.Low = k.Low
+			}
+			ticker.Vol += k.Vol
+		}
+		return ticker
+	}
+	for _, t := range e.trades[book] {
+		if t.Date < since {
+			continue
+		}
+		if ticker.High == 0 || t.Price > ticker.High {
+			ticker.High = t.Price
+		}
+		if ticker.Low == 0 || t.Price < ticker.Low {
+			ticker.Low = t.Price
+		}
+		ticker.Vol += t.Amount
+	}
+	return ticker
+}
+
+var klinePeriodSeconds = map[KlinePeriod]int64{
+	KLINE_PERIOD_1MIN:   60,
+	KLINE_PERIOD_3MIN:   180,
+	KLINE_PERIOD_5MIN:   300,
+	KLINE_PERIOD_15MIN:  900,
+	KLINE_PERIOD_30MIN:  1800,
+	KLINE_PERIOD_60MIN:  3600,
+	KLINE_PERIOD_1H:     3600,
+	KLINE_PERIOD_2H:     7200,
+	KLINE_PERIOD_3H:     10800,
+	KLINE_PERIOD_4H:     14400,
+	KLINE_PERIOD_6H:     21600,
+	KLINE_PERIOD_8H:     28800,
+	KLINE_PERIOD_12H:    43200,
+	KLINE_PERIOD_1DAY:   86400,
+	KLINE_PERIOD_3DAY:   259200,
+	KLINE_PERIOD_1WEEK:  604800,
+	KLINE_PERIOD_1MONTH: 2592000,
+}
+
+func (e *engine) klines(book string, pair CurrencyPair, period KlinePeriod, size int) ([]Kline, error) {
+	if m, ok := e.markets[book]; ok && len(m.klines) > 0 {
+		return e.replayedKlines(m, pair, period, size)
+	}
+	secs, ok := klinePeriodSeconds[period]
+	if !ok {
+		return nil, errors.New("unsupported kline period")
+	}
+
+	var klines []Kline
+	for _, t := range e.trades[book] {
+		ts := t.Date / 1000 / secs * secs
+		n := len(klines)
+		if n == 0 || klines[n-1].Timestamp != ts {
+			klines = append(klines, Kline{Pair: pair, Timestamp: ts, Open: t.Price, High: t.Price, Low: t.Price})
+			n++
+		}
+		k := &klines[n-1]
+		if t.Price > k.High {
+			k.High = t.Price
+		}
+		if t.Price < k.Low {
+			k.Low = t.Price
+		}
+		k.Close = t.Price
+		k.Vol += t.Amount
+	}
+
+	if size > 0 && len(klines) > size {
+		klines = klines[len(klines)-size:]
+	}
+	return klines, nil
+}
+
+func (o *bookOrder) toOrder() *Order {
+	ord := &Order{
+		Price:      o.price,
+		Amount:     o.amount,
+		AvgPrice:   o.avgPrice(),
+		DealAmount: o.filled,
+		Fee:        o.fee,
+		OrderID2:   strconv.FormatInt(o.id, 10),
+		OrderID:    int(o.id),
+		Status:     o.status,
+		Currency:   o.pair,
+		Side:       o.side,
+		Type:       "limit",
+		OrderType:  o.feature,
+		OrderTime:  int(o.ts),
+	}
+	if o.price == 0 {
+		ord.Type = "market"
+		if o.side == BUY {
+			ord.Side = BUY_MARKET
+		} else {
+			ord.Side = SELL_MARKET
+		}
+	}
+	return ord
+}
@@ -0,0 +1,137 @@
+package sim
+
+import (
+	"testing"
+
+	. "github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestExchange() (*Exchange, *Exchange) {
+	ex := New(&Config{MakerFee: 0.001, TakerFee: 0.002})
+	ex.Deposit(USDT, 100000)
+	ex.Deposit(BTC, 10)
+	mm := ex.Account("mm")
+	mm.Deposit(USDT, 100000)
+	mm.Deposit(BTC, 10)
+	return ex, mm
+}
+
+func TestExchange_LimitOrderMatching(t *testing.T) {
+	ex, mm := newTestExchange()
+
+	first, err := mm.LimitSell("1", "100", BTC_USDT)
+	require.NoError(t, err)
+	second, err := mm.LimitSell("1", "100", BTC_USDT)
+	require.NoError(t, err)
+	_, err = mm.LimitSell("1", "101", BTC_USDT)
+	require.NoError(t, err)
+
+	dep, _ := ex.GetDepth(5, BTC_USDT)
+	assert.Len(t, dep.AskList, 2)
+	assert.Equal(t, 101.0, dep.AskList[0].Price)
+	assert.Equal(t, 2.0, dep.AskList[1].Amount)
+
+	ord, err := ex.LimitBuy("1.5", "100.5", BTC_USDT)
+	require.NoError(t, err)
+	assert.Equal(t, ORDER_FINISH, ord.Status)
+	assert.Equal(t, 1.5, ord.DealAmount)
+	assert.Equal(t, 100.0, ord.AvgPrice)
+
+	//price-time priority: the older order at 100 is filled first
+	o1, _ := mm.GetOneOrder(first.OrderID2, BTC_USDT)
+	o2, _ := mm.GetOneOrder(second.OrderID2, BTC_USDT)
+	assert.Equal(t, ORDER_FINISH, o1.Status)
+	assert.Equal(t, ORDER_PART_FINISH, o2.Status)
+	assert.Equal(t, 0.5, o2.DealAmount)
+
+	acc, _ := ex.GetAccount()
+	assert.InDelta(t, 100000-150, acc.SubAccounts[USDT].Amount, 1e-9)
+	assert.InDelta(t, 10+1.5*(1-0.002), acc.SubAccounts[BTC].Amount, 1e-9)
+
+	acc, _ = mm.GetAccount()
+	assert.InDelta(t, 100000+150*(1-0.001), acc.SubAccounts[USDT].Amount, 1e-9)
+	assert.InDelta(t, 7, acc.SubAccounts[BTC].Amount, 1e-9)
+	assert.InDelta(t, 1.5, acc.SubAccounts[BTC].ForzenAmount, 1e-9)
+
+	ticker, _ := ex.GetTicker(BTC_USDT)
+	assert.Equal(t, 100.0, ticker.Last)
+}
+
+func TestExchange_CancelOrder(t *testing.T) {
+	ex, _ := newTestExchange()
+
+	ord, err := ex.LimitBuy("1", "90", BTC_USDT)
+	require.NoError(t, err)
+	acc, _ := ex.GetAccount()
+	assert.InDelta(t, 90, acc.SubAccounts[USDT].ForzenAmount, 1e-9)
+
+	orders, _ := ex.GetUnfinishOrders(BTC_USDT)
+	assert.Len(t, orders, 1)
+
+	ok, err := ex.CancelOrder(ord.OrderID2, BTC_USDT)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+
+	acc, _ = ex.GetAccount()
+	assert.InDelta(t, 0, acc.SubAccounts[USDT].ForzenAmount, 1e-9)
+	assert.InDelta(t, 100000, acc.SubAccounts[USDT].Amount, 1e-9)
+
+	_, err = ex.CancelOrder(ord.OrderID2, BTC_USDT)
+	assert.Error(t, err)
+	_, err = ex.CancelOrder("12345", BTC_USDT)
+	assert.Error(t, err)
+}
+
+func TestExchange_OrderFeatures(t *testing.T) {
+	ex, mm := newTestExchange()
+	_, err := mm.LimitSell("1", "100", BTC_USDT)
+	require.NoError(t, err)
+
+	_, err = ex.LimitBuy("1", "100", BTC_USDT, PostOnly)
+	assert.Error(t, err)
+
+	ord, err := ex.LimitBuy("2", "100", BTC_USDT, Fok)
+	require.NoError(t, err)
+	assert.Equal(t, ORDER_CANCEL, ord.Status)
+	assert.Equal(t, 0.0, ord.DealAmount)
+
+	ord, err = ex.LimitBuy("2", "100", BTC_USDT, Ioc)
+	require.NoError(t, err)
+	assert.Equal(t, ORDER_CANCEL, ord.Status)
+	assert.Equal(t, 1.0, ord.DealAmount)
+
+	orders, _ := ex.GetUnfinishOrders(BTC_USDT)
+	assert.Len(t, orders, 0)
+	acc, _ := ex.GetAccount()
+	assert.InDelta(t, 0, acc.SubAccounts[USDT].ForzenAmount, 1e-9)
+}
+
+func TestExchange_MarketOrder(t *testing.T) {
+	ex, mm := newTestExchange()
+	mm.LimitBuy("1", "99", BTC_USDT)
+	mm.LimitBuy("1", "98", BTC_USDT)
+
+	ord, err := ex.MarketSell("1.5", "", BTC_USDT)
+	require.NoError(t, err)
+	assert.Equal(t, ORDER_FINISH, ord.Status)
+	assert.InDelta(t, (99+0.5*98)/1.5, ord.AvgPrice, 1e-9)
+
+	//nothing on the ask side, the market order is cancelled
+	ord, err = ex.MarketBuy("1", "", BTC_USDT)
+	require.NoError(t, err)
+	assert.Equal(t, ORDER_CANCEL, ord.Status)
+
+	trades, _ := ex.GetTrades(BTC_USDT, 0)
+	assert.Len(t, trades, 2)
+	assert.Equal(t, SELL, trades[0].Type)
+}
+
+func TestExchange_InsufficientBalance(t *testing.T) {
+	ex, _ := newTestExchange()
+	_, err := ex.LimitBuy("10000", "100", BTC_USDT)
+	assert.Error(t, err)
+	_, err = ex.LimitSell("11", "100", BTC_USDT)
+	assert.Error(t, err)
+}
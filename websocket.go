@@ -1,6 +1,7 @@
 package goex
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -27,6 +29,11 @@ type WsConfig struct {
 	ConnectSuccessAfterSendMessage func() []byte //for reconnect
 	IsDump                         bool
 	DisableEnableCompression       bool
+	Recorder                       *WsRecorder   //records every frame, see WsReplayer
+	SubscribeAckTimeout            time.Duration //the wait for the ack of a WsSubscription with an AckId
+	StateHandleFunc                func(change WsStateChange)
+	Backoff                        WsBackoff //the waits between the reconnect attempts, LinearBackoff of reconnectInterval if nil
+	ctx                            context.Context
 	readDeadLineTime               time.Duration
 	reconnectInterval              time.Duration
 }
@@ -38,25 +45,50 @@ var dialer = &websocket.Dialer{
 }
 
 type WsConn struct {
-	c *websocket.Conn
+	c     *websocket.Conn
+	cLock sync.Mutex //c is replaced by a reconnect
 	WsConfig
 	writeBufferChan        chan []byte
 	pingMessageBufferChan  chan []byte
 	pongMessageBufferChan  chan []byte
 	closeMessageBufferChan chan []byte
-	subs                   [][]byte
+	subsLock               sync.Mutex
+	subs                   []*wsSub              //the registry, sent again after a reconnect
+	acks                   map[string]chan error //the pending acks by ack id
 	close                  chan bool
+	closeOnce              sync.Once
+	readDone               chan struct{}
+	state                  int32
 	reConnectLock          *sync.Mutex
 }
 
+// WsSubscription is an entry of the subscription registry of a WsConn.
+type WsSubscription struct {
+	Key   string //the dedupe key, the json of Event if empty
+	AckId string //empty if the protocol does not confirm the subscription, see AckSubscription
+	Event interface{}
+}
+
+type wsSub struct {
+	key   string
+	ackId string
+	data  []byte
+}
+
+var (
+	ErrNotSubscribed = errors.New("not subscribed")
+	ErrWsClosed      = errors.New("websocket closed")
+)
+
 type WsBuilder struct {
 	wsConfig *WsConfig
 }
 
 func NewWsBuilder() *WsBuilder {
 	return &WsBuilder{&WsConfig{
-		ReqHeaders:        make(map[string][]string, 1),
-		reconnectInterval: time.Second * 10,
+		ReqHeaders:          make(map[string][]string, 1),
+		SubscribeAckTimeout: time.Second * 10,
+		reconnectInterval:   time.Second * 10,
 	}}
 }
 
@@ -96,6 +128,34 @@ func (b *WsBuilder) ReconnectInterval(t time.Duration) *WsBuilder {
 	return b
 }
 
+func (b *WsBuilder) SubscribeAckTimeout(t time.Duration) *WsBuilder {
+	b.wsConfig.SubscribeAckTimeout = t
+	return b
+}
+
+func (b *WsBuilder) Backoff(backoff WsBackoff) *WsBuilder {
+	b.wsConfig.Backoff = backoff
+	return b
+}
+
+// StateHandleFunc is called on every state change, it runs on the ws goroutines and
+// must not block.
+func (b *WsBuilder) StateHandleFunc(f func(change WsStateChange)) *WsBuilder {
+	b.wsConfig.StateHandleFunc = f
+	return b
+}
+
+// Context closes the connection when ctx is done.
+func (b *WsBuilder) Context(ctx context.Context) *WsBuilder {
+	b.wsConfig.ctx = ctx
+	return b
+}
+
+func (b *WsBuilder) Recorder(r *WsRecorder) *WsBuilder {
+	b.wsConfig.Recorder = r
+	return b
+}
+
 func (b *WsBuilder) ProtoHandleFunc(f func([]byte) error) *WsBuilder {
 	b.wsConfig.ProtoHandleFunc = f
 	return b
@@ -121,6 +181,17 @@ func (b *WsBuilder) ConnectSuccessAfterSendMessage(msg func() []byte) *WsBuilder
 	return b
 }
 
+// Clone is a builder of a copy of the config, e.g. to build a connection with other
+// settings than the ones of a WsPool.
+func (b *WsBuilder) Clone() *WsBuilder {
+	conf := *b.wsConfig
+	conf.ReqHeaders = make(map[string][]string, len(b.wsConfig.ReqHeaders))
+	for k, v := range b.wsConfig.ReqHeaders {
+		conf.ReqHeaders[k] = append([]string(nil), v...)
+	}
+	return &WsBuilder{&conf}
+}
+
 func (b *WsBuilder) Build() *WsConn {
 	wsConn := &WsConn{WsConfig: *b.wsConfig}
 	return wsConn.NewWs()
@@ -138,25 +209,32 @@ func (ws *WsConn) NewWs() *WsConn {
 	}
 
 	ws.close = make(chan bool, 1)
+	ws.readDone = make(chan struct{})
 	ws.pingMessageBufferChan = make(chan []byte, 10)
 	ws.pongMessageBufferChan = make(chan []byte, 10)
 	ws.closeMessageBufferChan = make(chan []byte, 10)
 	ws.writeBufferChan = make(chan []byte, 10)
 	ws.reConnectLock = new(sync.Mutex)
+	ws.setState(WsConnected, nil, 0)
 
 	go ws.writeRequest()
 	go ws.receiveMessage()
-
-	if ws.ConnectSuccessAfterSendMessage != nil {
-		msg := ws.ConnectSuccessAfterSendMessage()
-		ws.SendMessage(msg)
-		Log.Infof("[ws] [%s] execute the connect success after send message=%s", ws.WsUrl, string(msg))
+	if ws.ctx != nil {
+		go func() {
+			select {
+			case <-ws.ctx.Done():
+				ws.CloseWs()
+			case <-ws.close:
+			}
+		}()
 	}
 
+	ws.sendConnectMessage()
 	return ws
 }
 
 func (ws *WsConn) connect() error {
+	dialer := *dialer //the connections are dialed concurrently, e.g. by a WsPool
 	if ws.ProxyUrl != "" {
 		proxy, err := url.Parse(ws.ProxyUrl)
 		if err == nil {
@@ -182,52 +260,171 @@ func (ws *WsConn) connect() error {
 	}
 
 	wsConn.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
+	ws.setHandlers(wsConn)
 
 	if ws.IsDump {
 		dumpData, _ := httputil.DumpResponse(resp, true)
 		Log.Debugf("[ws][%s] %s", ws.WsUrl, string(dumpData))
 	}
 	Log.Infof("[ws][%s] connected", ws.WsUrl)
+	ws.cLock.Lock()
 	ws.c = wsConn
+	ws.cLock.Unlock()
 	return nil
 }
 
-func (ws *WsConn) reconnect() {
+func (ws *WsConn) conn() *websocket.Conn {
+	ws.cLock.Lock()
+	defer ws.cLock.Unlock()
+	return ws.c
+}
+
+func (ws *WsConn) setHandlers(c *websocket.Conn) {
+	c.SetCloseHandler(func(code int, text string) error {
+		Log.Warnf("[ws][%s] websocket exiting [code=%d , text=%s]", ws.WsUrl, code, text)
+		return nil
+	})
+
+	c.SetPongHandler(func(pong string) error {
+		Log.Debugf("[%s] received [pong] %s", ws.WsUrl, pong)
+		c.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
+		return nil
+	})
+
+	c.SetPingHandler(func(ping string) error {
+		Log.Debugf("[%s] received [ping] %s", ws.WsUrl, ping)
+		if ws.Recorder != nil {
+			ws.Recorder.Record(WsFrameIn, websocket.PingMessage, []byte(ping))
+		}
+		ws.SendPongMessage([]byte(ping))
+		c.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
+		return nil
+	})
+}
+
+// State is the current state of the connection.
+func (ws *WsConn) State() WsState {
+	return WsState(atomic.LoadInt32(&ws.state))
+}
+
+func (ws *WsConn) setState(to WsState, err error, down time.Duration) {
+	from := ws.State()
+	for {
+		if from == to || from == WsClosed {
+			return
+		}
+		if atomic.CompareAndSwapInt32(&ws.state, int32(from), int32(to)) {
+			break
+		}
+		from = ws.State()
+	}
+
+	Log.Infof("[ws][%s] %s -> %s", ws.WsUrl, from, to)
+	if ws.StateHandleFunc != nil {
+		ws.StateHandleFunc(WsStateChange{From: from, To: to, Err: err, Down: down})
+	}
+}
+
+func (ws *WsConn) isClosed() bool {
+	select {
+	case <-ws.close:
+		return true
+	default:
+		return false
+	}
+}
+
+// shutdown stops the goroutines, it is true for the first call only.
+func (ws *WsConn) shutdown(err error) bool {
+	first := false
+	ws.closeOnce.Do(func() {
+		first = true
+		close(ws.close)
+	})
+	if first {
+		ws.setState(WsClosed, err, 0)
+	}
+	return first
+}
+
+func (ws *WsConn) reconnect(cause error) {
 	ws.reConnectLock.Lock()
 	defer ws.reConnectLock.Unlock()
 
-	ws.c.Close() //主动关闭一次
+	down := time.Now()
+	ws.setState(WsReconnecting, cause, 0)
+	ws.conn().Close() //主动关闭一次
+
+	backoff := ws.Backoff
+	if backoff == nil {
+		backoff = LinearBackoff(ws.reconnectInterval, 100)
+	}
 	var err error
-	for retry := 1; retry <= 100; retry++ {
-		err = ws.connect()
-		if err != nil {
-			Log.Errorf("[ws] [%s] websocket reconnect fail , %s", ws.WsUrl, err.Error())
-		} else {
+	for retry := 1; ; retry++ {
+		if err = ws.connect(); err == nil {
 			break
 		}
-		time.Sleep(ws.WsConfig.reconnectInterval * time.Duration(retry))
+		Log.Errorf("[ws] [%s] websocket reconnect fail , %s", ws.WsUrl, err.Error())
+		wait, ok := backoff(retry)
+		if !ok {
+			break
+		}
+		select {
+		case <-ws.close:
+			return
+		case <-time.After(wait):
+		}
 	}
 
 	if err != nil {
-		Log.Errorf("[ws] [%s] retry connect 100 count fail , begin exiting. ", ws.WsUrl)
-		ws.CloseWs()
+		Log.Errorf("[ws] [%s] retry connect %s , begin exiting. ", ws.WsUrl, err)
+		err = fmt.Errorf("retry reconnect fail: %w", err)
+		ws.shutdown(err)
 		if ws.ErrorHandleFunc != nil {
-			ws.ErrorHandleFunc(errors.New("retry reconnect fail"))
-		}
-	} else {
-		//re subscribe
-		if ws.ConnectSuccessAfterSendMessage != nil {
-			msg := ws.ConnectSuccessAfterSendMessage()
-			ws.SendMessage(msg)
-			Log.Infof("[ws] [%s] execute the connect success after send message=%s", ws.WsUrl, string(msg))
-			time.Sleep(time.Second) //wait response
+			ws.ErrorHandleFunc(err)
 		}
+		return
+	}
+	if ws.isClosed() { //closed while connecting
+		ws.conn().Close()
+		return
+	}
+	ws.setState(WsConnected, nil, time.Since(down))
 
-		for _, sub := range ws.subs {
-			Log.Info("[ws] re subscribe: ", string(sub))
-			ws.SendMessage(sub)
-		}
+	//re subscribe
+	if ws.sendConnectMessage() {
+		time.Sleep(time.Second) //wait response
+	}
+
+	ws.subsLock.Lock()
+	subs := append([]*wsSub(nil), ws.subs...)
+	ws.subsLock.Unlock()
+	for _, sub := range subs {
+		Log.Info("[ws] re subscribe: ", string(sub.data))
+		ws.SendMessage(sub.data)
+	}
+}
+
+// sendConnectMessage sends the message of ConnectSuccessAfterSendMessage, nil is
+// nothing to send. It is a login at times so it is not logged.
+func (ws *WsConn) sendConnectMessage() bool {
+	if ws.ConnectSuccessAfterSendMessage == nil {
+		return false
+	}
+	msg := ws.ConnectSuccessAfterSendMessage()
+	if msg == nil {
+		return false
+	}
+	ws.SendMessage(msg)
+	Log.Infof("[ws] [%s] sent the connect success after send message", ws.WsUrl)
+	return true
+}
+
+func (ws *WsConn) write(msgType int, data []byte) error {
+	if ws.Recorder != nil {
+		ws.Recorder.Record(WsFrameOut, msgType, data)
 	}
+	return ws.conn().WriteMessage(msgType, data)
 }
 
 func (ws *WsConn) writeRequest() {
@@ -248,16 +445,16 @@ func (ws *WsConn) writeRequest() {
 			Log.Infof("[ws][%s] close websocket , exiting write message goroutine.", ws.WsUrl)
 			return
 		case d := <-ws.writeBufferChan:
-			err = ws.c.WriteMessage(websocket.TextMessage, d)
+			err = ws.write(websocket.TextMessage, d)
 		case d := <-ws.pingMessageBufferChan:
-			err = ws.c.WriteMessage(websocket.PingMessage, d)
+			err = ws.write(websocket.PingMessage, d)
 		case d := <-ws.pongMessageBufferChan:
-			err = ws.c.WriteMessage(websocket.PongMessage, d)
+			err = ws.write(websocket.PongMessage, d)
 		case d := <-ws.closeMessageBufferChan:
-			err = ws.c.WriteMessage(websocket.CloseMessage, d)
+			err = ws.write(websocket.CloseMessage, d)
 		case <-heartTimer.C:
 			if ws.HeartbeatIntervalTime > 0 {
-				err = ws.c.WriteMessage(websocket.TextMessage, ws.HeartbeatData())
+				err = ws.write(websocket.TextMessage, ws.HeartbeatData())
 				heartTimer.Reset(ws.HeartbeatIntervalTime)
 			}
 		}
@@ -269,32 +466,196 @@ func (ws *WsConn) writeRequest() {
 	}
 }
 
+// Subscribe adds the event to the registry, an event subscribed already is not sent again.
 func (ws *WsConn) Subscribe(subEvent interface{}) error {
+	return ws.AddSubscription(WsSubscription{Event: subEvent})
+}
+
+// Unsubscribe removes the entry of subEvent from the registry and sends unsubEvent.
+func (ws *WsConn) Unsubscribe(subEvent, unsubEvent interface{}) error {
 	data, err := json.Marshal(subEvent)
+	if err != nil {
+		return err
+	}
+	return ws.RemoveSubscription(string(data), unsubEvent)
+}
+
+// AddSubscription sends the event unless its key is subscribed already. With an AckId
+// it waits for AckSubscription, a subscription that fails or times out is removed from
+// the registry.
+func (ws *WsConn) AddSubscription(sub WsSubscription) error {
+	data, err := json.Marshal(sub.Event)
 	if err != nil {
 		Log.Errorf("[ws][%s] json encode error , %s", ws.WsUrl, err)
 		return err
 	}
+	if sub.Key == "" {
+		sub.Key = string(data)
+	}
+
+	ws.subsLock.Lock()
+	if ws.subIndex(sub.Key) >= 0 {
+		ws.subsLock.Unlock()
+		Log.Debugf("[ws][%s] subscribed already: %s", ws.WsUrl, sub.Key)
+		return nil
+	}
+	var ack chan error
+	if sub.AckId != "" {
+		if ws.acks == nil {
+			ws.acks = make(map[string]chan error, 4)
+		}
+		ack = make(chan error, 1)
+		ws.acks[sub.AckId] = ack
+	}
+	ws.subs = append(ws.subs, &wsSub{key: sub.Key, ackId: sub.AckId, data: data})
+	ws.subsLock.Unlock()
+
 	Log.Debug(string(data))
-	ws.writeBufferChan <- data
-	ws.subs = append(ws.subs, data)
-	return nil
+	if err = ws.send(ws.writeBufferChan, data); err != nil {
+		ws.subsLock.Lock()
+		delete(ws.acks, sub.AckId)
+		if i := ws.subIndex(sub.Key); i >= 0 {
+			ws.subs = append(ws.subs[:i], ws.subs[i+1:]...)
+		}
+		ws.subsLock.Unlock()
+		return err
+	}
+	if ack == nil {
+		return nil
+	}
+
+	timeout := ws.SubscribeAckTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	select {
+	case err = <-ack:
+		return err //AckSubscription removed it already
+	case <-ws.close:
+		err = ErrWsClosed
+	case <-time.After(timeout):
+		err = fmt.Errorf("subscribe %s ack timeout", sub.Key)
+	}
+
+	ws.subsLock.Lock()
+	delete(ws.acks, sub.AckId)
+	if i := ws.subIndex(sub.Key); i >= 0 {
+		ws.subs = append(ws.subs[:i], ws.subs[i+1:]...)
+	}
+	ws.subsLock.Unlock()
+	return err
+}
+
+// RemoveSubscription removes the entry of the key and sends unsubEvent if it is not nil.
+func (ws *WsConn) RemoveSubscription(key string, unsubEvent interface{}) error {
+	ws.subsLock.Lock()
+	i := ws.subIndex(key)
+	if i < 0 {
+		ws.subsLock.Unlock()
+		return ErrNotSubscribed
+	}
+	ws.subs = append(ws.subs[:i], ws.subs[i+1:]...)
+	ws.subsLock.Unlock()
+
+	if unsubEvent == nil {
+		return nil
+	}
+	return ws.SendJsonMessage(unsubEvent)
+}
+
+// AckSubscription delivers the result of a subscription to its AddSubscription. An error
+// removes the subscription, it goes to ErrorHandleFunc if nobody waits for the ack, as
+// after a reconnect.
+func (ws *WsConn) AckSubscription(ackId string, err error) {
+	ws.subsLock.Lock()
+	ack, waiting := ws.acks[ackId]
+	delete(ws.acks, ackId)
+	if err != nil {
+		for i := 0; i < len(ws.subs); i++ {
+			if ws.subs[i].ackId == ackId {
+				ws.subs = append(ws.subs[:i], ws.subs[i+1:]...)
+				i--
+			}
+		}
+	}
+	ws.subsLock.Unlock()
+
+	if waiting {
+		ack <- err
+		return
+	}
+	if err != nil {
+		Log.Errorf("[ws][%s] subscribe %s error: %s", ws.WsUrl, ackId, err)
+		if ws.ErrorHandleFunc != nil {
+			ws.ErrorHandleFunc(err)
+		}
+	}
+}
+
+// PendingAcks are the ack ids waited for, in the order of the subscriptions.
+func (ws *WsConn) PendingAcks() []string {
+	ws.subsLock.Lock()
+	defer ws.subsLock.Unlock()
+	var ids []string
+	for _, sub := range ws.subs {
+		if _, ok := ws.acks[sub.ackId]; ok {
+			ids = append(ids, sub.ackId)
+		}
+	}
+	return ids
+}
+
+// Subscriptions are the keys of the registry in the order of the subscriptions.
+func (ws *WsConn) Subscriptions() []string {
+	ws.subsLock.Lock()
+	defer ws.subsLock.Unlock()
+	keys := make([]string, 0, len(ws.subs))
+	for _, sub := range ws.subs {
+		keys = append(keys, sub.key)
+	}
+	return keys
+}
+
+func (ws *WsConn) subIndex(key string) int {
+	for i, sub := range ws.subs {
+		if sub.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// send does not block once the connection is closed.
+func (ws *WsConn) send(c chan []byte, msg []byte) error {
+	select {
+	case <-ws.close:
+		return ErrWsClosed
+	default:
+	}
+	select {
+	case c <- msg:
+		return nil
+	case <-ws.close:
+		return ErrWsClosed
+	}
 }
 
 func (ws *WsConn) SendMessage(msg []byte) {
-	ws.writeBufferChan <- msg
+	if err := ws.send(ws.writeBufferChan, msg); err != nil {
+		Log.Warnf("[ws][%s] drop message %s, %s", ws.WsUrl, string(msg), err)
+	}
 }
 
 func (ws *WsConn) SendPingMessage(msg []byte) {
-	ws.pingMessageBufferChan <- msg
+	ws.send(ws.pingMessageBufferChan, msg)
 }
 
 func (ws *WsConn) SendPongMessage(msg []byte) {
-	ws.pongMessageBufferChan <- msg
+	ws.send(ws.pongMessageBufferChan, msg)
 }
 
 func (ws *WsConn) SendCloseMessage(msg []byte) {
-	ws.closeMessageBufferChan <- msg
+	ws.send(ws.closeMessageBufferChan, msg)
 }
 
 func (ws *WsConn) SendJsonMessage(m interface{}) error {
@@ -302,30 +663,11 @@ func (ws *WsConn) SendJsonMessage(m interface{}) error {
 	if err != nil {
 		return err
 	}
-	ws.writeBufferChan <- data
-	return nil
+	return ws.send(ws.writeBufferChan, data)
 }
 
 func (ws *WsConn) receiveMessage() {
-	//exit
-	ws.c.SetCloseHandler(func(code int, text string) error {
-		Log.Warnf("[ws][%s] websocket exiting [code=%d , text=%s]", ws.WsUrl, code, text)
-		//ws.CloseWs()
-		return nil
-	})
-
-	ws.c.SetPongHandler(func(pong string) error {
-		Log.Debugf("[%s] received [pong] %s", ws.WsUrl, pong)
-		ws.c.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
-		return nil
-	})
-
-	ws.c.SetPingHandler(func(ping string) error {
-		Log.Debugf("[%s] received [ping] %s", ws.WsUrl, ping)
-		ws.SendPongMessage([]byte(ping))
-		ws.c.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
-		return nil
-	})
+	defer close(ws.readDone)
 
 	for {
 		select {
@@ -333,15 +675,22 @@ func (ws *WsConn) receiveMessage() {
 			Log.Infof("[ws][%s] close websocket , exiting receive message goroutine.", ws.WsUrl)
 			return
 		default:
-			t, msg, err := ws.c.ReadMessage()
+			c := ws.conn()
+			t, msg, err := c.ReadMessage()
 			if err != nil {
+				if ws.isClosed() {
+					Log.Infof("[ws][%s] close websocket , exiting receive message goroutine.", ws.WsUrl)
+					return
+				}
 				Log.Errorf("[ws][%s] %s", ws.WsUrl, err.Error())
 				if ws.IsAutoReconnect {
 					Log.Infof("[ws][%s] Unexpected Closed , Begin Retry Connect.", ws.WsUrl)
-					ws.reconnect()
+					ws.reconnect(err)
 					continue
 				}
 
+				ws.shutdown(err)
+				c.Close()
 				if ws.ErrorHandleFunc != nil {
 					ws.ErrorHandleFunc(err)
 				}
@@ -349,42 +698,73 @@ func (ws *WsConn) receiveMessage() {
 				return
 			}
 			//			Log.Debug(string(msg))
-			ws.c.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
-			switch t {
-			case websocket.TextMessage:
-				ws.ProtoHandleFunc(msg)
-			case websocket.BinaryMessage:
-				if ws.DecompressFunc == nil {
-					ws.ProtoHandleFunc(msg)
-				} else {
-					msg2, err := ws.DecompressFunc(msg)
-					if err != nil {
-						Log.Errorf("[ws][%s] decompress error %s", ws.WsUrl, err.Error())
-					} else {
-						ws.ProtoHandleFunc(msg2)
-					}
-				}
-				//	case websocket.CloseMessage:
-				//	ws.CloseWs()
-			default:
-				Log.Errorf("[ws][%s] error websocket message type , content is :\n %s \n", ws.WsUrl, string(msg))
+			c.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
+			if ws.Recorder != nil {
+				ws.Recorder.Record(WsFrameIn, t, msg)
 			}
+			handleWsFrame(&ws.WsConfig, t, msg)
 		}
 	}
 }
 
-func (ws *WsConn) CloseWs() {
-	//ws.close <- true
-	close(ws.close)
-	close(ws.writeBufferChan)
-	close(ws.closeMessageBufferChan)
-	close(ws.pingMessageBufferChan)
-	close(ws.pongMessageBufferChan)
-
-	err := ws.c.Close()
-	if err != nil {
-		Log.Error("[ws][", ws.WsUrl, "] close websocket error ,", err)
+// handleWsFrame passes a text or binary frame to ProtoHandleFunc, decompressing binary
+// frames if there is a DecompressFunc.
+func handleWsFrame(conf *WsConfig, t int, msg []byte) error {
+	switch t {
+	case websocket.TextMessage:
+		return conf.ProtoHandleFunc(msg)
+	case websocket.BinaryMessage:
+		if conf.DecompressFunc == nil {
+			return conf.ProtoHandleFunc(msg)
+		}
+		msg2, err := conf.DecompressFunc(msg)
+		if err != nil {
+			Log.Errorf("[ws][%s] decompress error %s", conf.WsUrl, err.Error())
+			return err
+		}
+		return conf.ProtoHandleFunc(msg2)
+		//	case websocket.CloseMessage:
+		//	ws.CloseWs()
+	default:
+		Log.Errorf("[ws][%s] error websocket message type , content is :\n %s \n", conf.WsUrl, string(msg))
+	}
+	return nil
+}
+
+// Close sends a close frame and waits until the server closes the connection or ctx is
+// done. It returns ctx.Err() if the server did not answer, calling it again does nothing.
+// Do not call it from ProtoHandleFunc, the close answer is read by the same goroutine.
+func (ws *WsConn) Close(ctx context.Context) error {
+	if !ws.shutdown(nil) {
+		return nil
 	}
+
+	c := ws.conn()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Second)
+	}
+	var err error
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	if c.WriteControl(websocket.CloseMessage, msg, deadline) == nil {
+		select {
+		case <-ws.readDone:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	if e := c.Close(); e != nil {
+		Log.Debug("[ws][", ws.WsUrl, "] close websocket ,", e)
+	}
+	return err
+}
+
+// CloseWs is Close with a second for the close handshake.
+func (ws *WsConn) CloseWs() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ws.Close(ctx)
 }
 
 func (ws *WsConn) clearChannel(c chan struct{}) {
@@ -2,10 +2,16 @@ package goex
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	. "github.com/soulsplit/goex/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_time(t *testing.T) {
@@ -50,3 +56,94 @@ func TestNewWsConn(t *testing.T) {
 	ws.c.Close()
 	time.Sleep(time.Second * 120)
 }
+
+func TestWsConn_Subscriptions(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer c.Close()
+		for {
+			_, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req struct{ Op, Ch string }
+			json.Unmarshal(msg, &req)
+			switch {
+			case req.Op != "sub" || req.Ch == "silent":
+			case req.Ch == "bad":
+				c.WriteMessage(websocket.TextMessage, []byte(`{"ack":"bad","error":"unknown channel"}`))
+			default:
+				c.WriteMessage(websocket.TextMessage, []byte(`{"ack":"`+req.Ch+`"}`))
+			}
+		}
+	}))
+	defer srv.Close()
+
+	var ws *WsConn
+	ws = NewWsBuilder().WsUrl(wsUrl(srv)).SubscribeAckTimeout(200 * time.Millisecond).ProtoHandleFunc(func(data []byte) error {
+		var ack struct{ Ack, Error string }
+		json.Unmarshal(data, &ack)
+		var err error
+		if ack.Error != "" {
+			err = errors.New(ack.Error)
+		}
+		ws.AckSubscription(ack.Ack, err)
+		return nil
+	}).Build()
+	defer ws.CloseWs()
+
+	sub := func(ch string) error {
+		return ws.AddSubscription(WsSubscription{Key: ch, AckId: ch, Event: map[string]string{"op": "sub", "ch": ch}})
+	}
+	require.NoError(t, sub("depth"))
+	require.NoError(t, sub("depth"), "deduped")
+	require.NoError(t, ws.Subscribe(map[string]string{"op": "ping"}))
+	require.NoError(t, ws.Subscribe(map[string]string{"op": "ping"}))
+	assert.EqualError(t, sub("bad"), "unknown channel")
+	assert.Error(t, sub("silent"), "ack timeout")
+	assert.Empty(t, ws.PendingAcks())
+	assert.Equal(t, []string{"depth", `{"op":"ping"}`}, ws.Subscriptions())
+
+	assert.NoError(t, ws.RemoveSubscription("depth", map[string]string{"op": "unsub", "ch": "depth"}))
+	assert.Equal(t, ErrNotSubscribed, ws.RemoveSubscription("depth", nil))
+	assert.NoError(t, ws.Unsubscribe(map[string]string{"op": "ping"}, nil))
+	assert.Empty(t, ws.Subscriptions())
+	require.NoError(t, sub("depth"), "subscribed again")
+
+	//an error without a waiter, e.g. after a reconnect
+	var errs []error
+	ws.ErrorHandleFunc = func(err error) { errs = append(errs, err) }
+	ws.AckSubscription("depth", errors.New("expired"))
+	assert.Len(t, errs, 1)
+	assert.Empty(t, ws.Subscriptions())
+}
+
+func TestWsConn_ConcurrentDial(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer c.Close()
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	conns := make(chan *WsConn, 4)
+	for i := 0; i < cap(conns); i++ {
+		go func() {
+			conns <- NewWsBuilder().WsUrl(wsUrl(srv)).DisableEnableCompression().
+				ProtoHandleFunc(func([]byte) error { return nil }).Build()
+		}()
+	}
+	for i := 0; i < cap(conns); i++ {
+		c := <-conns
+		assert.Equal(t, WsConnected, c.State())
+		c.CloseWs()
+	}
+}
@@ -1,10 +1,12 @@
 package zb
 
 import (
+	"errors"
 	"net/http"
 	"testing"
 
 	"github.com/soulsplit/goex"
+	"github.com/stretchr/testify/assert"
 )
 
 var (
@@ -58,3 +60,10 @@ func TestZb_GetOneOrder(t *testing.T) {
 	t.Log(err)
 	t.Log(ord)
 }
+
+func TestZb_AdaptError(t *testing.T) {
+	err := adaptError(map[string]interface{}{"code": 3001.0, "message": "order not found"})
+	assert.True(t, errors.Is(err, goex.EX_ERR_NOT_FIND_ORDER))
+	assert.Equal(t, "3001", err.NativeCode)
+	assert.Equal(t, "2009", adaptError(map[string]interface{}{"code": 2009.0}).Error())
+}